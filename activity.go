@@ -0,0 +1,167 @@
+package planetscale
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+type (
+	// Activity is an entry of the feed of a group, or of the friends sharing a
+	// direct expense, such as "Alice added Dinner". It keeps a snapshot of
+	// what its summary is rendered from, so the feed reads the same after the
+	// expense or settlement changed again.
+	Activity struct {
+		ActivityID int64  `json:"activity_id"`
+		GroupID    *int64 `json:"group_id"`
+		// set for expense and item activities
+		ExpenseID  *int64    `json:"expense_id"`
+		EntityType string    `json:"entity_type"`
+		EntityID   string    `json:"entity_id"`
+		Action     string    `json:"action"`
+		ActorID    string    `json:"actor_id"`
+		Subject    string    `json:"subject"` // description of an expense, name of an item or role of a member
+		UserID     string    `json:"user_id"` // member of a member activity, payee of a settlement
+		Amount     Money     `json:"amount"`
+		Currency   string    `json:"currency"`
+		CreatedAt  time.Time `json:"created_at"`
+
+		// set when read
+		ActorName string `json:"actor_name"`
+		UserName  string `json:"user_name"`
+		Summary   string `json:"summary"`
+	}
+
+	ActivityRepo interface {
+		Create(tx *sql.Tx, activity *Activity) error
+		// Find lists activities newest first.
+		Find(tx *sql.Tx, filter ActivityFilter) ([]*Activity, error)
+		Count(tx *sql.Tx, filter ActivityFilter) (int, error)
+		// GetReadMarker returns the id of the last activity the user read in
+		// the feed of a group, or in their own feed for groupID 0. It is 0
+		// when they never read it.
+		GetReadMarker(tx *sql.Tx, userID string, groupID int64) (int64, error)
+		// MarkRead moves the read marker of a feed up to activityID. It never
+		// moves back.
+		MarkRead(tx *sql.Tx, userID string, groupID int64, activityID int64) error
+	}
+
+	ActivityFilter struct {
+		GroupID      int64
+		UserID       string // the activities of the user's groups and direct expenses
+		After        int64  // only activities newer than this one
+		ExcludeActor string // leave out what this user did
+
+		Cursor string // from ActivityCursor, continues after that activity
+		Limit  int    // 0 for no limit
+	}
+
+	ActivityController interface {
+		HandleGetGroupActivity(w http.ResponseWriter, r *http.Request)
+		HandlePostGroupActivityRead(w http.ResponseWriter, r *http.Request)
+		HandleGetUserActivity(w http.ResponseWriter, r *http.Request)
+		HandlePostUserActivityRead(w http.ResponseWriter, r *http.Request)
+	}
+)
+
+// ActivitySort is the order of activity feeds, newest first.
+const ActivitySort = "-" + SortTimestamp
+
+// ActivityCursor returns the cursor of the page ending with activity.
+func ActivityCursor(activity *Activity) string {
+	return newCursor(ActivitySort, activity.ActivityID, activity.CreatedAt, 0).Encode()
+}
+
+// NewActivity turns an audited change of an expense, settlement, group member
+// or item into an activity.
+func NewActivity(audit *AuditEvent) *Activity {
+	activity := &Activity{
+		GroupID:    audit.GroupID,
+		EntityType: audit.EntityType,
+		EntityID:   audit.EntityID,
+		Action:     audit.Action,
+		ActorID:    audit.ActorID,
+	}
+	data := audit.After
+	if data == nil {
+		data = audit.Before
+	}
+	switch data := data.(type) {
+	case *Expense:
+		activity.ExpenseID = &data.ExpenseID
+		activity.Subject = data.Description
+		activity.Amount = data.Amount
+		activity.Currency = data.Currency
+	case *Settlement:
+		activity.UserID = data.PaidTo
+		activity.Amount = data.Amount
+		activity.Currency = data.Currency
+	case *GroupMember:
+		activity.UserID = data.UserID
+		activity.Subject = data.Role
+	case *Item:
+		activity.ExpenseID = &data.ExpenseID
+		activity.Subject = data.Name
+		activity.Amount = data.Price
+	}
+	return activity
+}
+
+// Render sets the summary of the activity from its snapshot and the names of
+// the users it mentions.
+func (a *Activity) Render() {
+	actor := displayName(a.ActorName, a.ActorID)
+	user := displayName(a.UserName, a.UserID)
+	verb := activityVerbs[a.Action]
+
+	switch a.EntityType {
+	case AuditEntityExpense:
+		subject := "an expense"
+		if a.Subject != "" {
+			subject = a.Subject
+		}
+		a.Summary = fmt.Sprintf("%s %s %s", actor, verb, subject)
+		if a.Action == AuditActionCreate {
+			a.Summary += fmt.Sprintf(" (%s %s)", a.Amount, a.Currency)
+		}
+	case AuditEntitySettlement:
+		if a.Action == AuditActionCreate {
+			a.Summary = fmt.Sprintf("%s paid %s %s %s", actor, user, a.Amount, a.Currency)
+		} else {
+			a.Summary = fmt.Sprintf("%s %s a payment to %s", actor, verb, user)
+		}
+	case AuditEntityGroupMember:
+		switch {
+		case a.Action == AuditActionCreate && a.ActorID == a.UserID:
+			a.Summary = fmt.Sprintf("%s joined the group", actor)
+		case a.Action == AuditActionDelete && a.ActorID == a.UserID:
+			a.Summary = fmt.Sprintf("%s left the group", actor)
+		case a.Action == AuditActionUpdate:
+			a.Summary = fmt.Sprintf("%s made %s %s", actor, user, a.Subject)
+		case a.Action == AuditActionDelete:
+			a.Summary = fmt.Sprintf("%s removed %s", actor, user)
+		default:
+			a.Summary = fmt.Sprintf("%s %s %s", actor, verb, user)
+		}
+	case AuditEntityItem:
+		a.Summary = fmt.Sprintf("%s %s the item %s", actor, verb, a.Subject)
+	default:
+		a.Summary = fmt.Sprintf("%s %s a %s", actor, verb, a.EntityType)
+	}
+}
+
+var activityVerbs = map[string]string{
+	AuditActionCreate:  "added",
+	AuditActionUpdate:  "updated",
+	AuditActionDelete:  "deleted",
+	AuditActionRestore: "restored",
+}
+
+// displayName is the name of a user, or their id when they have none.
+func displayName(name string, userID string) string {
+	if name != "" {
+		return name
+	}
+	return userID
+}
@@ -0,0 +1,95 @@
+package planetscale
+
+import "testing"
+
+func TestActivity_Render(t *testing.T) {
+	groupID := int64(1)
+	newActivity := func(audit *AuditEvent) *Activity {
+		audit.GroupID = &groupID
+		activity := NewActivity(audit)
+		activity.ActorName = "Alice"
+		if activity.UserID == "bob" {
+			activity.UserName = "Bob"
+		}
+		return activity
+	}
+
+	tests := []struct {
+		name     string
+		activity *Activity
+		expected string
+	}{
+		{"expense added", newActivity(&AuditEvent{
+			EntityType: AuditEntityExpense,
+			Action:     AuditActionCreate,
+			ActorID:    "alice",
+			After:      &Expense{ExpenseID: 1, Description: "Dinner", Amount: 40_00, Currency: "USD"},
+		}), "Alice added Dinner (40.00 USD)"},
+		{"expense without description deleted", newActivity(&AuditEvent{
+			EntityType: AuditEntityExpense,
+			Action:     AuditActionDelete,
+			ActorID:    "alice",
+			Before:     &Expense{ExpenseID: 1},
+		}), "Alice deleted an expense"},
+		{"settlement", newActivity(&AuditEvent{
+			EntityType: AuditEntitySettlement,
+			Action:     AuditActionCreate,
+			ActorID:    "alice",
+			After:      &Settlement{PaidBy: "alice", PaidTo: "bob", Amount: 20_00, Currency: "USD"},
+		}), "Alice paid Bob 20.00 USD"},
+		{"settlement restored", newActivity(&AuditEvent{
+			EntityType: AuditEntitySettlement,
+			Action:     AuditActionRestore,
+			ActorID:    "alice",
+			After:      &Settlement{PaidBy: "alice", PaidTo: "bob", Amount: 20_00, Currency: "USD"},
+		}), "Alice restored a payment to Bob"},
+		{"member joined", newActivity(&AuditEvent{
+			EntityType: AuditEntityGroupMember,
+			Action:     AuditActionCreate,
+			ActorID:    "alice",
+			After:      &GroupMember{UserID: "alice", Role: RoleMember},
+		}), "Alice joined the group"},
+		{"member added", newActivity(&AuditEvent{
+			EntityType: AuditEntityGroupMember,
+			Action:     AuditActionCreate,
+			ActorID:    "alice",
+			After:      &GroupMember{UserID: "bob", Role: RoleMember},
+		}), "Alice added Bob"},
+		{"member promoted", newActivity(&AuditEvent{
+			EntityType: AuditEntityGroupMember,
+			Action:     AuditActionUpdate,
+			ActorID:    "alice",
+			Before:     &GroupMember{UserID: "bob", Role: RoleMember},
+			After:      &GroupMember{UserID: "bob", Role: RoleAdmin},
+		}), "Alice made Bob admin"},
+		{"member left", newActivity(&AuditEvent{
+			EntityType: AuditEntityGroupMember,
+			Action:     AuditActionDelete,
+			ActorID:    "alice",
+			Before:     &GroupMember{UserID: "alice"},
+		}), "Alice left the group"},
+		{"member removed without a name", newActivity(&AuditEvent{
+			EntityType: AuditEntityGroupMember,
+			Action:     AuditActionDelete,
+			ActorID:    "alice",
+			Before:     &GroupMember{UserID: "carol"},
+		}), "Alice removed carol"},
+		{"item updated", newActivity(&AuditEvent{
+			EntityType: AuditEntityItem,
+			Action:     AuditActionUpdate,
+			ActorID:    "alice",
+			After:      &Item{ExpenseID: 1, Name: "Wine", Price: 12_00},
+		}), "Alice updated the item Wine"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.activity.Render()
+			if test.activity.Summary != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, test.activity.Summary)
+			} else if *test.activity.GroupID != groupID {
+				t.Errorf("expected group %d, got %d", groupID, *test.activity.GroupID)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package planetscale
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"net/http"
+	"time"
+)
+
+// MaxAttachmentSize is the largest file that can be attached to an expense.
+const MaxAttachmentSize = 10 << 20
+
+// AttachmentContentTypes are the kinds of files that can be attached to an
+// expense, as sniffed from their content.
+var AttachmentContentTypes = map[string]bool{
+	"image/jpeg":      true,
+	"image/png":       true,
+	"image/gif":       true,
+	"image/webp":      true,
+	"application/pdf": true,
+}
+
+type (
+	// Attachment is a file, such as the photo of a bill, kept with an
+	// expense. The file itself lives in the BlobStore under Key.
+	Attachment struct {
+		AttachmentID int64     `json:"attachment_id"`
+		ExpenseID    int64     `json:"expense_id"`
+		FileName     string    `json:"file_name"`
+		ContentType  string    `json:"content_type"`
+		Size         int64     `json:"size"`
+		Key          string    `json:"-"`
+		CreatedBy    string    `json:"created_by"`
+		CreatedAt    time.Time `json:"created_at"`
+	}
+
+	AttachmentRepo interface {
+		Get(tx *sql.Tx, attachmentID int64) (*Attachment, error)
+		Create(tx *sql.Tx, attachment *Attachment) error
+		Delete(tx *sql.Tx, attachmentID int64) error
+		Find(tx *sql.Tx, filter AttachmentFilter) ([]*Attachment, error)
+	}
+
+	AttachmentFilter struct {
+		ExpenseID int64 `json:"expense_id"`
+	}
+
+	// BlobStore keeps the content of attachments outside of the database.
+	BlobStore interface {
+		Put(ctx context.Context, key string, r io.Reader) error
+		// Get fails with ENOTFOUND when nothing is stored under key.
+		Get(ctx context.Context, key string) (io.ReadCloser, error)
+		// Delete does nothing when nothing is stored under key.
+		Delete(ctx context.Context, key string) error
+	}
+
+	AttachmentController interface {
+		HandlePostAttachment(w http.ResponseWriter, r *http.Request)
+		HandleGetExpenseAttachments(w http.ResponseWriter, r *http.Request)
+		HandleGetAttachment(w http.ResponseWriter, r *http.Request)
+		HandleDeleteAttachment(w http.ResponseWriter, r *http.Request)
+	}
+)
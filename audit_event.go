@@ -0,0 +1,66 @@
+package planetscale
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// Audited entity types.
+const (
+	AuditEntityExpense             = "expense"
+	AuditEntitySettlement          = "settlement"
+	AuditEntityGroup               = "group"
+	AuditEntityGroupMember         = "group_member"
+	AuditEntityRecurringExpense    = "recurring_expense"
+	AuditEntityItem                = "item"
+	AuditEntityItemSplit           = "item_split"
+	AuditEntityAttachment          = "attachment"
+	AuditEntityGroupInvite         = "group_invite"
+	AuditEntityWebhookSubscription = "webhook_subscription"
+)
+
+// Audited actions.
+const (
+	AuditActionCreate  = "create"
+	AuditActionUpdate  = "update"
+	AuditActionDelete  = "delete"
+	AuditActionRestore = "restore"
+)
+
+type (
+	// AuditEvent is an append-only record of a single mutation. Before is
+	// nil for creates and After is nil for deletes.
+	AuditEvent struct {
+		AuditEventID int64       `json:"audit_event_id"`
+		GroupID      *int64      `json:"group_id"`
+		EntityType   string      `json:"entity_type"`
+		EntityID     string      `json:"entity_id"`
+		Action       string      `json:"action"`
+		ActorID      string      `json:"actor_id"`
+		Before       interface{} `json:"before"`
+		After        interface{} `json:"after"`
+		CreatedAt    time.Time   `json:"created_at"`
+	}
+
+	// AuditEventRepo has no update or delete on purpose.
+	AuditEventRepo interface {
+		Create(tx *sql.Tx, event *AuditEvent) error
+		Find(tx *sql.Tx, filter AuditEventFilter) ([]*AuditEvent, error)
+	}
+
+	AuditEventFilter struct {
+		GroupID    int64
+		EntityType string
+		EntityID   string
+	}
+)
+
+// ActorFromContext returns the id of the user making the request, or fallback
+// for work that does not run on behalf of a request, such as the scheduler.
+func ActorFromContext(ctx context.Context, fallback string) string {
+	if user, found := UserFromContext(ctx); found {
+		return user.UserID
+	}
+	return fallback
+}
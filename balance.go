@@ -4,15 +4,84 @@ import (
 	"context"
 )
 
+// DashboardRecentSize is how many of the latest expenses and settlements a
+// Dashboard holds.
+const DashboardRecentSize = 10
+
 type (
 	Balance struct {
-		ExpenseGroupID int64              `json:"group_id"`
-		UserID         string             `json:"user_id"`
-		Amount         float64            `json:"amount"`
-		BalanceItems   map[string]float64 `json:"balance_items"`
+		ExpenseGroupID int64            `json:"group_id"`
+		UserID         string           `json:"user_id"`
+		Amount         Money            `json:"amount"`
+		Currency       string           `json:"currency"`
+		BalanceItems   map[string]Money `json:"balance_items"`
+	}
+
+	// SettlePlan is the minimal set of payments that brings every balance in
+	// a group back to zero.
+	SettlePlan struct {
+		GroupID   int64       `json:"group_id"`
+		Transfers []*Transfer `json:"transfers"`
+		// Settlements is only set once the plan has been applied.
+		Settlements []*Settlement `json:"settlements,omitempty"`
+	}
+
+	// UserBalance sums up what a user is owed and owes across all of their
+	// groups and the expenses they share with friends outside of any group,
+	// converted into a single currency at today's rates.
+	UserBalance struct {
+		UserID   string `json:"user_id"`
+		Currency string `json:"currency"`
+		// Amount is Owed minus Owing.
+		Amount Money `json:"amount"`
+		Owed   Money `json:"owed"`
+		Owing  Money `json:"owing"`
+		// Groups holds the user's balance in each of their groups, in the
+		// group's base currency.
+		Groups []*Balance `json:"groups"`
+		// Friends holds what each friend owes the user through direct
+		// expenses, negative where the user owes them.
+		Friends map[string]Money `json:"friends"`
+	}
+
+	// Dashboard is what a user's home screen shows: their groups with their
+	// net balance in each, the totals of UserBalance and the latest
+	// expenses and settlements across their groups and friends.
+	Dashboard struct {
+		UserID   string `json:"user_id"`
+		Currency string `json:"currency"`
+		// Amount is Owed minus Owing.
+		Amount            Money             `json:"amount"`
+		Owed              Money             `json:"owed"`
+		Owing             Money             `json:"owing"`
+		Groups            []*DashboardGroup `json:"groups"`
+		Friends           map[string]Money  `json:"friends"`
+		RecentExpenses    []*Expense        `json:"recent_expenses"`
+		RecentSettlements []*Settlement     `json:"recent_settlements"`
+	}
+
+	DashboardGroup struct {
+		*ExpenseGroup
+		// Balance is the user's net balance in the group's base currency.
+		Balance Money `json:"balance"`
+	}
+
+	Transfer struct {
+		PaidBy string `json:"paid_by"`
+		PaidTo string `json:"paid_to"`
+		Amount Money  `json:"amount"`
 	}
 
 	BalanceService interface {
 		GetGroupBalances(ctx context.Context, groupID int64) ([]*Balance, error)
+		// GetSettlePlan computes the settle plan for a group. When apply is
+		// true every transfer is recorded as a Settlement in the same
+		// transaction the balances were read in.
+		GetSettlePlan(ctx context.Context, groupID int64, apply bool) (*SettlePlan, error)
+		// GetUserBalance totals the balances of a user in currency.
+		GetUserBalance(ctx context.Context, userID string, currency string) (*UserBalance, error)
+		// GetDashboard builds the dashboard of a user, with totals in
+		// currency.
+		GetDashboard(ctx context.Context, userID string, currency string) (*Dashboard, error)
 	}
 )
@@ -12,8 +12,11 @@ import (
 	"github.com/clerkinc/clerk-sdk-go/clerk"
 	planetscale "github.com/harshav17/planet_scale"
 	"github.com/harshav17/planet_scale/db"
+	"github.com/harshav17/planet_scale/fs"
 	"github.com/harshav17/planet_scale/http"
+	"github.com/harshav17/planet_scale/receipt"
 	"github.com/harshav17/planet_scale/service"
+	"github.com/harshav17/planet_scale/smtp"
 	utilities "github.com/harshav17/planet_scale/utilites"
 	"github.com/joho/godotenv"
 	"github.com/patrickmn/go-cache"
@@ -58,6 +61,9 @@ func main() {
 type Main struct {
 	HTTPServer *http.Server
 	DB         *db.DB
+	Scheduler  *service.RecurringExpenseScheduler
+	Webhooks   *service.WebhookWorker
+	Digests    *service.DigestWorker
 }
 
 func NewMain() *Main {
@@ -106,38 +112,148 @@ func (m *Main) Run(ctx context.Context) (err error) {
 	repos.Item = db.NewItemRepo(m.DB)
 	repos.ItemSplit = db.NewItemSplitRepo(m.DB)
 	repos.User = db.NewUserRepo(m.DB)
+	repos.ClerkMessage = db.NewClerkMessageRepo(m.DB)
+	repos.FXRate = db.NewFXRateRepo(m.DB)
+	repos.RecurringExpense = db.NewRecurringExpenseRepo(m.DB)
+	repos.AuditEvent = db.NewAuditEventRepo(m.DB)
+	repos.Search = db.NewSearchRepo(m.DB)
+	repos.Attachment = db.NewAttachmentRepo(m.DB)
+	repos.GroupInvite = db.NewGroupInviteRepo(m.DB)
+	repos.WebhookSubscription = db.NewWebhookSubscriptionRepo(m.DB)
+	repos.WebhookEvent = db.NewWebhookEventRepo(m.DB)
+	repos.WebhookDelivery = db.NewWebhookDeliveryRepo(m.DB)
+	repos.Activity = db.NewActivityRepo(m.DB)
+	repos.NotificationPreferences = db.NewNotificationPreferencesRepo(m.DB)
+
+	// attachments
+	attachmentsDir, ok := os.LookupEnv("ATTACHMENTS_DIR")
+	if !ok {
+		attachmentsDir = "attachments"
+	}
+	blobs := fs.NewBlobStore(attachmentsDir)
+	if err := blobs.Open(); err != nil {
+		return fmt.Errorf("cannot open attachments dir: %w", err)
+	}
 
 	// services
 	services := planetscale.ServiceProvider{}
-	services.Balance = service.NewBalanceService(&repos, tm)
-	services.Expense = service.NewExpenseService(&repos, tm)
+	services.Events = service.NewEventHub()
+	services.Balance = service.NewBalanceService(&repos, tm, services.Events)
+	services.Expense = service.NewExpenseService(&repos, tm, services.Events)
+	services.FX = service.NewFXService(&repos, tm)
+	services.RecurringExpense = service.NewRecurringExpenseService(&repos, services.Expense, tm)
+	services.Ledger = service.NewLedgerService(&repos, tm, services.Events)
+	services.Receipt = receipt.NewParser()
+	services.Blob = blobs
+	services.GroupMember = service.NewGroupMemberService(&repos, tm, services.Events)
+	services.Webhook = service.NewWebhookService(&repos, tm)
+	services.Notifier = service.NewNotifier(&repos, &services, tm)
+
+	// emails are only sent with an SMTP server to send them through
+	if addr, ok := os.LookupEnv("SMTP_ADDR"); ok {
+		services.Mailer = smtp.NewMailer(addr, os.Getenv("SMTP_FROM"), os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD"))
+	} else {
+		slog.Warn("SMTP_ADDR not set, emails are not sent")
+	}
+
+	// invites are signed so they can be shared as links
+	if secret := os.Getenv("INVITE_SECRET"); secret != "" {
+		services.InviteToken = service.NewInviteTokenService([]byte(secret))
+	} else {
+		slog.Warn("INVITE_SECRET not set, invite links are disabled")
+	}
+
+	// exchange rates
+	if path, ok := os.LookupEnv("FX_RATES_CSV"); ok {
+		if err := loadFXRates(ctx, services.FX, path); err != nil {
+			return fmt.Errorf("cannot load fx rates: %w", err)
+		}
+	}
 
 	// controllers
 	controllers := planetscale.ControllerProvider{}
 	controllers.Product = http.NewProductController(&repos, tm)
 	controllers.ExpenseGroup = http.NewExpenseGroupController(&repos, &services, tm)
-	controllers.GroupMember = http.NewGroupMemberController(&repos, tm)
+	controllers.GroupMember = http.NewGroupMemberController(&repos, &services, tm)
 	controllers.Expense = http.NewExpenseController(&repos, &services, tm)
-	controllers.Settlement = http.NewSettlementController(&repos, tm)
+	controllers.Settlement = http.NewSettlementController(&repos, &services, tm)
 	controllers.SplitType = http.NewSplitTypeController(&repos, tm)
-	controllers.User = http.NewUserController(&repos, tm, userWh)
+	controllers.User = http.NewUserController(&repos, &services, tm, userWh)
 	controllers.Item = http.NewItemController(&repos, &services, tm)
+	controllers.RecurringExpense = http.NewRecurringExpenseController(&repos, &services, tm)
+	controllers.Search = http.NewSearchController(&repos, tm)
+	controllers.Attachment = http.NewAttachmentController(&repos, &services, tm)
+	controllers.GroupInvite = http.NewGroupInviteController(&repos, &services, tm)
+	controllers.Webhook = http.NewWebhookController(&repos, &services, tm)
+	controllers.Activity = http.NewActivityController(&repos, tm)
+	controllers.Event = http.NewEventController(&repos, &services, tm)
 
 	// middleware
 	c := cache.New(10*time.Minute, 10*time.Minute)
 	middleware := http.NewMiddleware(&repos, tm, c, &clerkClient)
 
 	// start the HTTP server.
-	m.HTTPServer = http.NewServer(&controllers, middleware)
+	m.HTTPServer = http.NewServer(&controllers, middleware, services.Events)
 	if err := m.HTTPServer.Open(); err != nil {
 		return err
 	}
 
+	// start materializing recurring expenses in the background.
+	m.Scheduler = service.NewRecurringExpenseScheduler(services.RecurringExpense, time.Minute)
+	if err := m.Scheduler.Open(); err != nil {
+		return err
+	}
+
+	// send the webhook outbox in the background.
+	m.Webhooks = service.NewWebhookWorker(services.Webhook, 5*time.Second)
+	if err := m.Webhooks.Open(); err != nil {
+		return err
+	}
+
+	// send the balance digests that are due in the background.
+	if services.Mailer != nil {
+		m.Digests = service.NewDigestWorker(services.Notifier, time.Hour)
+		if err := m.Digests.Open(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// loadFXRates imports the exchange rates in the CSV file at path.
+func loadFXRates(ctx context.Context, fx planetscale.FXService, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	n, err := fx.ImportCSV(ctx, f)
+	if err != nil {
+		return err
+	}
+	slog.Info("loaded fx rates", slog.String("path", path), slog.Int("count", n))
 	return nil
 }
 
 // Close gracefully stops the program.
 func (m *Main) Close() error {
+	if m.Digests != nil {
+		if err := m.Digests.Close(); err != nil {
+			return err
+		}
+	}
+	if m.Webhooks != nil {
+		if err := m.Webhooks.Close(); err != nil {
+			return err
+		}
+	}
+	if m.Scheduler != nil {
+		if err := m.Scheduler.Close(); err != nil {
+			return err
+		}
+	}
 	if m.HTTPServer != nil {
 		if err := m.HTTPServer.Close(); err != nil {
 			return err
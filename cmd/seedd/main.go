@@ -77,6 +77,7 @@ func main() {
 		fakeGroupMember := &planetscale.GroupMember{
 			GroupID: fakeExpenseGroup.ExpenseGroupID,
 			UserID:  fakeUser.UserID,
+			Role:    planetscale.RoleOwner,
 		}
 		if err := gmRepo.Create(tx, fakeGroupMember); err != nil {
 			return fmt.Errorf("cannot create group member: %w", err)
@@ -95,7 +96,7 @@ func main() {
 		fakeExpense := &planetscale.Expense{
 			GroupID:     &fakeExpenseGroup.ExpenseGroupID,
 			PaidBy:      fakeUser.UserID,
-			Amount:      gofakeit.Price(0, 1000),
+			Amount:      planetscale.MoneyFromFloat(gofakeit.Price(0, 1000)),
 			Description: gofakeit.ProductDescription(),
 			Timestamp:   gofakeit.Date(),
 			SplitTypeID: 1, // TODO load from db into cache
@@ -110,7 +111,7 @@ func main() {
 		fakeExpense2 := &planetscale.Expense{
 			GroupID:     &fakeExpenseGroup.ExpenseGroupID,
 			PaidBy:      fakeUser2.UserID,
-			Amount:      gofakeit.Price(0, 1000),
+			Amount:      planetscale.MoneyFromFloat(gofakeit.Price(0, 1000)),
 			Description: gofakeit.ProductDescription(),
 			Timestamp:   gofakeit.Date(),
 			SplitTypeID: 1,
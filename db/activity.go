@@ -0,0 +1,159 @@
+package db
+
+import (
+	"database/sql"
+	"log/slog"
+	"time"
+
+	planetscale "github.com/harshav17/planet_scale"
+)
+
+type activityRepo struct {
+	db *DB
+}
+
+func NewActivityRepo(db *DB) *activityRepo {
+	return &activityRepo{
+		db: db,
+	}
+}
+
+func (r *activityRepo) Create(tx *sql.Tx, activity *planetscale.Activity) error {
+	activity.CreatedAt = time.Now().UTC().Truncate(time.Second)
+
+	query := `
+		INSERT INTO activities (group_id, expense_id, entity_type, entity_id, action, actor_id, subject, user_id, amount, currency, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	result, err := tx.Exec(query, activity.GroupID, activity.ExpenseID, activity.EntityType, activity.EntityID, activity.Action, activity.ActorID, activity.Subject, activity.UserID, activity.Amount, activity.Currency, (*NullTime)(&activity.CreatedAt))
+	if err != nil {
+		return err
+	}
+	activityID, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	activity.ActivityID = activityID
+	slog.Info("created activity", slog.Int64("id", activity.ActivityID), slog.String("entity", activity.EntityType), slog.String("action", activity.Action))
+
+	return nil
+}
+
+func (r *activityRepo) Find(tx *sql.Tx, filter planetscale.ActivityFilter) ([]*planetscale.Activity, error) {
+	where := activityWhere(filter)
+	page, err := findPage(where, "a.activity_id", map[string]string{
+		planetscale.SortTimestamp: "a.created_at",
+	}, planetscale.ActivitySort, filter.Cursor, filter.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT
+			a.activity_id,
+			a.group_id,
+			a.expense_id,
+			a.entity_type,
+			a.entity_id,
+			a.action,
+			a.actor_id,
+			a.subject,
+			a.user_id,
+			a.amount,
+			a.currency,
+			a.created_at,
+			actor.name,
+			u.name
+		FROM activities a
+		LEFT JOIN users actor ON a.actor_id = actor.user_id
+		LEFT JOIN users u ON a.user_id = u.user_id
+		` + where.ToClause() + `
+		` + page
+
+	rows, err := tx.Query(query, where.values...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var activities []*planetscale.Activity
+	for rows.Next() {
+		var activity planetscale.Activity
+		var actorName, userName sql.NullString
+		err := rows.Scan(&activity.ActivityID, &activity.GroupID, &activity.ExpenseID, &activity.EntityType, &activity.EntityID, &activity.Action, &activity.ActorID, &activity.Subject, &activity.UserID, &activity.Amount, &activity.Currency, (*NullTime)(&activity.CreatedAt), &actorName, &userName)
+		if err != nil {
+			return nil, err
+		}
+		activity.ActorName = actorName.String
+		activity.UserName = userName.String
+		activity.Render()
+		activities = append(activities, &activity)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return activities, nil
+}
+
+func (r *activityRepo) Count(tx *sql.Tx, filter planetscale.ActivityFilter) (int, error) {
+	where := activityWhere(filter)
+
+	var count int
+	err := tx.QueryRow(`SELECT COUNT(*) FROM activities a `+where.ToClause(), where.values...).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// activityWhere filters activities. The feed of a user holds the activities of
+// the groups they are an active member of and of the direct expenses they
+// paid, created or take part in.
+func activityWhere(filter planetscale.ActivityFilter) *findWhereClause {
+	where := &findWhereClause{}
+	if filter.GroupID != 0 {
+		where.Add("a.group_id", filter.GroupID)
+	}
+	if filter.UserID != "" {
+		where.AddExpr(`(
+			a.group_id IN (SELECT group_id FROM group_members WHERE user_id = ? AND left_at IS NULL)
+			OR (a.group_id IS NULL AND a.expense_id IN (
+				SELECT expense_id FROM expenses WHERE paid_by = ? OR created_by = ?
+				UNION SELECT expense_id FROM expense_participants WHERE user_id = ?
+			))
+		)`, filter.UserID, filter.UserID, filter.UserID, filter.UserID)
+	}
+	if filter.After != 0 {
+		where.AddOp("a.activity_id", ">", filter.After)
+	}
+	if filter.ExcludeActor != "" {
+		where.AddOp("a.actor_id", "<>", filter.ExcludeActor)
+	}
+	return where
+}
+
+func (r *activityRepo) GetReadMarker(tx *sql.Tx, userID string, groupID int64) (int64, error) {
+	var activityID int64
+	err := tx.QueryRow(`SELECT activity_id FROM activity_reads WHERE user_id = ? AND group_id = ?`, userID, groupID).Scan(&activityID)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+	return activityID, nil
+}
+
+func (r *activityRepo) MarkRead(tx *sql.Tx, userID string, groupID int64, activityID int64) error {
+	query := `
+		INSERT INTO activity_reads (user_id, group_id, activity_id) VALUES (?, ?, ?)
+		ON DUPLICATE KEY UPDATE activity_id = GREATEST(activity_id, VALUES(activity_id))`
+
+	_, err := tx.Exec(query, userID, groupID, activityID)
+	if err != nil {
+		return err
+	}
+	slog.Info("marked activity read", slog.String("user_id", userID), slog.Int64("group_id", groupID), slog.Int64("id", activityID))
+
+	return nil
+}
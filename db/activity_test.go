@@ -0,0 +1,159 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	planetscale "github.com/harshav17/planet_scale"
+)
+
+func TestActivityRepo_All(t *testing.T) {
+	t.Parallel()
+
+	db := MustOpenDB(t)
+	defer MustCloseDB(t, db)
+	ctx := context.Background()
+
+	t.Run("Find Tests", func(t *testing.T) {
+		t.Run("group and user feeds", func(t *testing.T) {
+			tx, err := db.db.BeginTx(ctx, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tx.Rollback()
+
+			alice := MustCreateUser(t, tx, db.DB, &planetscale.User{UserID: "alice", Name: "Alice"})
+			bob := MustCreateUser(t, tx, db.DB, &planetscale.User{UserID: "bob", Name: "Bob"})
+			g := MustCreateExpenseGroup(t, tx, db.DB, &planetscale.ExpenseGroup{
+				GroupName: "test group",
+				CreateBy:  alice.UserID,
+			})
+			MustCreateGroupMember(t, tx, db.DB, &planetscale.GroupMember{GroupID: g.ExpenseGroupID, UserID: alice.UserID})
+			other := MustCreateExpenseGroup(t, tx, db.DB, &planetscale.ExpenseGroup{
+				GroupName: "other group",
+				CreateBy:  bob.UserID,
+			})
+			direct := MustCreateExpense(t, tx, db.DB, &planetscale.Expense{
+				PaidBy:      bob.UserID,
+				SplitTypeID: 1,
+				Amount:      30_00,
+				Timestamp:   time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
+				CreatedBy:   bob.UserID,
+				UpdatedBy:   bob.UserID,
+			})
+			MustCreateExpenseParticipant(t, tx, db.DB, &planetscale.ExpenseParticipant{ExpenseID: direct.ExpenseID, UserID: alice.UserID})
+
+			repo := NewActivityRepo(db.DB)
+			for _, activity := range []*planetscale.Activity{
+				planetscale.NewActivity(&planetscale.AuditEvent{
+					GroupID:    &g.ExpenseGroupID,
+					EntityType: planetscale.AuditEntityExpense,
+					EntityID:   "1",
+					Action:     planetscale.AuditActionCreate,
+					ActorID:    alice.UserID,
+					After:      &planetscale.Expense{ExpenseID: 1, Description: "Dinner", Amount: 40_00, Currency: "USD"},
+				}),
+				planetscale.NewActivity(&planetscale.AuditEvent{
+					GroupID:    &g.ExpenseGroupID,
+					EntityType: planetscale.AuditEntitySettlement,
+					EntityID:   "1",
+					Action:     planetscale.AuditActionCreate,
+					ActorID:    bob.UserID,
+					After:      &planetscale.Settlement{PaidBy: bob.UserID, PaidTo: alice.UserID, Amount: 20_00, Currency: "USD"},
+				}),
+				planetscale.NewActivity(&planetscale.AuditEvent{
+					GroupID:    &other.ExpenseGroupID,
+					EntityType: planetscale.AuditEntityGroupMember,
+					EntityID:   bob.UserID,
+					Action:     planetscale.AuditActionCreate,
+					ActorID:    bob.UserID,
+					After:      &planetscale.GroupMember{UserID: bob.UserID},
+				}),
+				planetscale.NewActivity(&planetscale.AuditEvent{
+					EntityType: planetscale.AuditEntityExpense,
+					EntityID:   "2",
+					Action:     planetscale.AuditActionCreate,
+					ActorID:    bob.UserID,
+					After:      direct,
+				}),
+			} {
+				if err := repo.Create(tx, activity); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got, err := repo.Find(tx, planetscale.ActivityFilter{GroupID: g.ExpenseGroupID})
+			if err != nil {
+				t.Fatal(err)
+			} else if len(got) != 2 {
+				t.Fatalf("expected 2 activities, got %d", len(got))
+			} else if got[0].Summary != "Bob paid Alice 20.00 USD" {
+				t.Fatalf("expected the settlement first, got %q", got[0].Summary)
+			} else if got[1].Summary != "Alice added Dinner (40.00 USD)" {
+				t.Fatalf("unexpected summary %q", got[1].Summary)
+			}
+
+			// the first page ends with the settlement, the next one holds
+			// the expense
+			page, err := repo.Find(tx, planetscale.ActivityFilter{GroupID: g.ExpenseGroupID, Limit: 1})
+			if err != nil {
+				t.Fatal(err)
+			}
+			page, err = repo.Find(tx, planetscale.ActivityFilter{GroupID: g.ExpenseGroupID, Limit: 1, Cursor: planetscale.ActivityCursor(page[0])})
+			if err != nil {
+				t.Fatal(err)
+			} else if len(page) != 1 || page[0].ActivityID != got[1].ActivityID {
+				t.Fatalf("expected the expense on the next page, got %+v", page)
+			}
+
+			// the group of alice and the direct expense she takes part in
+			feed, err := repo.Find(tx, planetscale.ActivityFilter{UserID: alice.UserID})
+			if err != nil {
+				t.Fatal(err)
+			} else if len(feed) != 3 {
+				t.Fatalf("expected 3 activities, got %d", len(feed))
+			}
+
+			unread, err := repo.Count(tx, planetscale.ActivityFilter{UserID: alice.UserID, ExcludeActor: alice.UserID})
+			if err != nil {
+				t.Fatal(err)
+			} else if unread != 2 {
+				t.Fatalf("expected 2 unread activities, got %d", unread)
+			}
+		})
+	})
+
+	t.Run("Read Marker Tests", func(t *testing.T) {
+		t.Run("never moves back", func(t *testing.T) {
+			tx, err := db.db.BeginTx(ctx, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tx.Rollback()
+
+			repo := NewActivityRepo(db.DB)
+			if got, err := repo.GetReadMarker(tx, "alice", 1); err != nil {
+				t.Fatal(err)
+			} else if got != 0 {
+				t.Fatalf("expected no marker, got %d", got)
+			}
+
+			for _, activityID := range []int64{5, 3} {
+				if err := repo.MarkRead(tx, "alice", 1, activityID); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got, err := repo.GetReadMarker(tx, "alice", 1); err != nil {
+				t.Fatal(err)
+			} else if got != 5 {
+				t.Fatalf("expected marker 5, got %d", got)
+			}
+			if got, err := repo.GetReadMarker(tx, "alice", 0); err != nil {
+				t.Fatal(err)
+			} else if got != 0 {
+				t.Fatalf("expected the user's own feed to be unread, got %d", got)
+			}
+		})
+	})
+}
@@ -0,0 +1,130 @@
+package db
+
+import (
+	"database/sql"
+	"time"
+
+	planetscale "github.com/harshav17/planet_scale"
+)
+
+type attachmentRepo struct {
+	db *DB
+}
+
+func NewAttachmentRepo(db *DB) *attachmentRepo {
+	return &attachmentRepo{
+		db: db,
+	}
+}
+
+func (r *attachmentRepo) Get(tx *sql.Tx, attachmentID int64) (*planetscale.Attachment, error) {
+	query := `
+		SELECT
+			attachment_id,
+			expense_id,
+			file_name,
+			content_type,
+			size,
+			storage_key,
+			created_by,
+			created_at
+		FROM
+			attachments
+		WHERE
+			attachment_id = ?
+	`
+
+	var attachment planetscale.Attachment
+	row := tx.QueryRow(query, attachmentID)
+	err := row.Scan(&attachment.AttachmentID, &attachment.ExpenseID, &attachment.FileName, &attachment.ContentType, &attachment.Size, &attachment.Key, &attachment.CreatedBy, (*NullTime)(&attachment.CreatedAt))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, planetscale.Errorf(planetscale.ENOTFOUND, "no attachment found with ID %d", attachmentID)
+		}
+		return nil, err
+	}
+	return &attachment, nil
+}
+
+func (r *attachmentRepo) Create(tx *sql.Tx, attachment *planetscale.Attachment) error {
+	attachment.CreatedAt = time.Now().UTC().Truncate(time.Second)
+
+	query := `
+		INSERT INTO
+			attachments
+			(expense_id, file_name, content_type, size, storage_key, created_by, created_at)
+		VALUES
+			(?, ?, ?, ?, ?, ?, ?)
+	`
+
+	result, err := tx.Exec(query, attachment.ExpenseID, attachment.FileName, attachment.ContentType, attachment.Size, attachment.Key, attachment.CreatedBy, attachment.CreatedAt)
+	if err != nil {
+		return err
+	}
+	attachmentID, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	attachment.AttachmentID = attachmentID
+	return nil
+}
+
+func (r *attachmentRepo) Delete(tx *sql.Tx, attachmentID int64) error {
+	query := `
+		DELETE FROM
+			attachments
+		WHERE
+			attachment_id = ?
+	`
+
+	result, err := tx.Exec(query, attachmentID)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return planetscale.Errorf(planetscale.ENOTFOUND, "no attachment found with ID %d", attachmentID)
+	}
+	return nil
+}
+
+func (r *attachmentRepo) Find(tx *sql.Tx, filter planetscale.AttachmentFilter) ([]*planetscale.Attachment, error) {
+	where := &findWhereClause{}
+	if filter.ExpenseID != 0 {
+		where.Add("expense_id", filter.ExpenseID)
+	}
+
+	query := `
+		SELECT
+			attachment_id,
+			expense_id,
+			file_name,
+			content_type,
+			size,
+			storage_key,
+			created_by,
+			created_at
+		FROM
+			attachments
+		` + where.ToClause() + `
+		ORDER BY attachment_id`
+
+	rows, err := tx.Query(query, where.values...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var attachments []*planetscale.Attachment
+	for rows.Next() {
+		var attachment planetscale.Attachment
+		if err := rows.Scan(&attachment.AttachmentID, &attachment.ExpenseID, &attachment.FileName, &attachment.ContentType, &attachment.Size, &attachment.Key, &attachment.CreatedBy, (*NullTime)(&attachment.CreatedAt)); err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, &attachment)
+	}
+	return attachments, rows.Err()
+}
@@ -0,0 +1,170 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	planetscale "github.com/harshav17/planet_scale"
+)
+
+func MustCreateAttachment(tb testing.TB, tx *sql.Tx, db *DB, a *planetscale.Attachment) *planetscale.Attachment {
+	tb.Helper()
+
+	if err := NewAttachmentRepo(db).Create(tx, a); err != nil {
+		tb.Fatal(err)
+	}
+
+	return a
+}
+
+func TestAttachmentRepo_All(t *testing.T) {
+	t.Parallel()
+
+	db := MustOpenDB(t)
+	defer MustCloseDB(t, db)
+	ctx := context.Background()
+
+	mustCreateExpense := func(t *testing.T, tx *sql.Tx) (*planetscale.User, *planetscale.Expense) {
+		t.Helper()
+
+		u := MustCreateUser(t, tx, db.DB, &planetscale.User{
+			UserID: "test-user-id",
+			Name:   "test user",
+			Email:  "",
+		})
+		g := MustCreateExpenseGroup(t, tx, db.DB, &planetscale.ExpenseGroup{
+			GroupName: "test group",
+			CreateBy:  u.UserID,
+		})
+		e := MustCreateExpense(t, tx, db.DB, &planetscale.Expense{
+			GroupID:     &g.ExpenseGroupID,
+			PaidBy:      u.UserID,
+			SplitTypeID: 1,
+			Amount:      100_00,
+			Description: "test expense",
+			Timestamp:   time.Now(),
+			CreatedBy:   u.UserID,
+			UpdatedBy:   u.UserID,
+		})
+		return u, e
+	}
+
+	t.Run("Get Tests", func(t *testing.T) {
+		t.Run("invalid attachment id", func(t *testing.T) {
+			tx, err := db.db.BeginTx(ctx, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tx.Rollback()
+
+			if _, err := NewAttachmentRepo(db.DB).Get(tx, 0); planetscale.ErrorCode(err) != planetscale.ENOTFOUND {
+				t.Fatalf("expected ENOTFOUND, got %v", err)
+			}
+		})
+
+		t.Run("successful get", func(t *testing.T) {
+			tx, err := db.db.BeginTx(ctx, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tx.Rollback()
+
+			u, e := mustCreateExpense(t, tx)
+			a := MustCreateAttachment(t, tx, db.DB, &planetscale.Attachment{
+				ExpenseID:   e.ExpenseID,
+				FileName:    "bill.jpg",
+				ContentType: "image/jpeg",
+				Size:        1024,
+				Key:         "test-key",
+				CreatedBy:   u.UserID,
+			})
+
+			a2, err := NewAttachmentRepo(db.DB).Get(tx, a.AttachmentID)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if a2.ExpenseID != e.ExpenseID {
+				t.Fatalf("expected expense id %d, got %d", e.ExpenseID, a2.ExpenseID)
+			} else if a2.FileName != a.FileName {
+				t.Fatalf("expected file name %s, got %s", a.FileName, a2.FileName)
+			} else if a2.Key != a.Key {
+				t.Fatalf("expected key %s, got %s", a.Key, a2.Key)
+			} else if a2.Size != a.Size {
+				t.Fatalf("expected size %d, got %d", a.Size, a2.Size)
+			} else if !a2.CreatedAt.Equal(a.CreatedAt) {
+				t.Fatalf("expected created at %v, got %v", a.CreatedAt, a2.CreatedAt)
+			}
+		})
+	})
+
+	t.Run("Find Tests", func(t *testing.T) {
+		tx, err := db.db.BeginTx(ctx, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer tx.Rollback()
+
+		u, e := mustCreateExpense(t, tx)
+		for _, key := range []string{"test-key-1", "test-key-2"} {
+			MustCreateAttachment(t, tx, db.DB, &planetscale.Attachment{
+				ExpenseID:   e.ExpenseID,
+				FileName:    "bill.pdf",
+				ContentType: "application/pdf",
+				Size:        2048,
+				Key:         key,
+				CreatedBy:   u.UserID,
+			})
+		}
+
+		attachments, err := NewAttachmentRepo(db.DB).Find(tx, planetscale.AttachmentFilter{ExpenseID: e.ExpenseID})
+		if err != nil {
+			t.Fatal(err)
+		} else if len(attachments) != 2 {
+			t.Fatalf("expected 2 attachments, got %d", len(attachments))
+		} else if attachments[0].Key != "test-key-1" {
+			t.Fatalf("expected attachments in upload order, got %s first", attachments[0].Key)
+		}
+	})
+
+	t.Run("Delete Tests", func(t *testing.T) {
+		t.Run("invalid attachment id", func(t *testing.T) {
+			tx, err := db.db.BeginTx(ctx, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tx.Rollback()
+
+			if err := NewAttachmentRepo(db.DB).Delete(tx, 0); err == nil {
+				t.Fatal("expected error, got nil")
+			}
+		})
+
+		t.Run("successful delete", func(t *testing.T) {
+			tx, err := db.db.BeginTx(ctx, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tx.Rollback()
+
+			u, e := mustCreateExpense(t, tx)
+			a := MustCreateAttachment(t, tx, db.DB, &planetscale.Attachment{
+				ExpenseID:   e.ExpenseID,
+				FileName:    "bill.png",
+				ContentType: "image/png",
+				Size:        512,
+				Key:         "test-key",
+				CreatedBy:   u.UserID,
+			})
+
+			if err := NewAttachmentRepo(db.DB).Delete(tx, a.AttachmentID); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := NewAttachmentRepo(db.DB).Get(tx, a.AttachmentID); planetscale.ErrorCode(err) != planetscale.ENOTFOUND {
+				t.Fatalf("expected ENOTFOUND, got %v", err)
+			}
+		})
+	})
+}
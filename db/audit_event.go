@@ -0,0 +1,114 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log/slog"
+
+	planetscale "github.com/harshav17/planet_scale"
+)
+
+type auditEventRepo struct {
+	db *DB
+}
+
+func NewAuditEventRepo(db *DB) *auditEventRepo {
+	return &auditEventRepo{
+		db: db,
+	}
+}
+
+func (r *auditEventRepo) Create(tx *sql.Tx, event *planetscale.AuditEvent) error {
+	before, err := marshalAuditData(event.Before)
+	if err != nil {
+		return err
+	}
+	after, err := marshalAuditData(event.After)
+	if err != nil {
+		return err
+	}
+
+	query := `INSERT INTO audit_events (group_id, entity_type, entity_id, action, actor_id, before_data, after_data) VALUES (?, ?, ?, ?, ?, ?, ?)`
+
+	result, err := tx.Exec(query, event.GroupID, event.EntityType, event.EntityID, event.Action, event.ActorID, before, after)
+	if err != nil {
+		return err
+	}
+	auditEventID, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	event.AuditEventID = auditEventID
+	slog.Info("created audit event", slog.Int64("id", event.AuditEventID), slog.String("entity", event.EntityType), slog.String("action", event.Action))
+
+	return nil
+}
+
+func (r *auditEventRepo) Find(tx *sql.Tx, filter planetscale.AuditEventFilter) ([]*planetscale.AuditEvent, error) {
+	where := &findWhereClause{}
+	if filter.GroupID != 0 {
+		where.Add("group_id", filter.GroupID)
+	}
+	if filter.EntityType != "" {
+		where.Add("entity_type", filter.EntityType)
+	}
+	if filter.EntityID != "" {
+		where.Add("entity_id", filter.EntityID)
+	}
+
+	query := `
+		SELECT
+			audit_event_id,
+			group_id,
+			entity_type,
+			entity_id,
+			action,
+			actor_id,
+			before_data,
+			after_data,
+			created_at
+		FROM audit_events
+		` + where.ToClause() + `
+		ORDER BY audit_event_id`
+
+	rows, err := tx.Query(query, where.values...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []*planetscale.AuditEvent
+	for rows.Next() {
+		var event planetscale.AuditEvent
+		var before, after []byte
+		err := rows.Scan(&event.AuditEventID, &event.GroupID, &event.EntityType, &event.EntityID, &event.Action, &event.ActorID, &before, &after, (*NullTime)(&event.CreatedAt))
+		if err != nil {
+			return nil, err
+		}
+		if before != nil {
+			event.Before = json.RawMessage(before)
+		}
+		if after != nil {
+			event.After = json.RawMessage(after)
+		}
+		events = append(events, &event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// marshalAuditData encodes a before or after snapshot, keeping nil as NULL.
+func marshalAuditData(data interface{}) (interface{}, error) {
+	if data == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	// send JSON as text, MySQL refuses binary strings in JSON columns
+	return string(b), nil
+}
@@ -0,0 +1,65 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	planetscale "github.com/harshav17/planet_scale"
+)
+
+func TestAuditEventRepo_All(t *testing.T) {
+	t.Parallel()
+
+	db := MustOpenDB(t)
+	defer MustCloseDB(t, db)
+	ctx := context.Background()
+
+	t.Run("Create and Find Tests", func(t *testing.T) {
+		t.Run("events are returned in order with snapshots", func(t *testing.T) {
+			tx, err := db.db.BeginTx(ctx, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tx.Rollback()
+
+			repo := NewAuditEventRepo(db.DB)
+			groupID := int64(1)
+			expense := &planetscale.Expense{ExpenseID: 1, GroupID: &groupID, Amount: 100_00}
+			for _, event := range []*planetscale.AuditEvent{
+				{GroupID: &groupID, EntityType: planetscale.AuditEntityExpense, EntityID: "1", Action: planetscale.AuditActionCreate, ActorID: "test-user-id", After: expense},
+				{GroupID: &groupID, EntityType: planetscale.AuditEntityExpense, EntityID: "1", Action: planetscale.AuditActionDelete, ActorID: "test-user-id", Before: expense},
+			} {
+				if err := repo.Create(tx, event); err != nil {
+					t.Fatal(err)
+				}
+				if event.AuditEventID == 0 {
+					t.Fatal("expected audit event id to be set")
+				}
+			}
+
+			events, err := repo.Find(tx, planetscale.AuditEventFilter{
+				EntityType: planetscale.AuditEntityExpense,
+				EntityID:   "1",
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(events) != 2 {
+				t.Fatalf("expected 2 audit events, got %d", len(events))
+			} else if events[0].Action != planetscale.AuditActionCreate || events[1].Action != planetscale.AuditActionDelete {
+				t.Fatalf("expected create then delete, got %s then %s", events[0].Action, events[1].Action)
+			} else if events[0].Before != nil || events[1].After != nil {
+				t.Fatal("expected missing snapshots to stay nil")
+			}
+
+			var got planetscale.Expense
+			if err := json.Unmarshal(events[0].After.(json.RawMessage), &got); err != nil {
+				t.Fatal(err)
+			}
+			if got.Amount != 100_00 {
+				t.Fatalf("expected amount 100, got %s", got.Amount)
+			}
+		})
+	})
+}
@@ -0,0 +1,35 @@
+package db
+
+import (
+	"database/sql"
+	"log/slog"
+	"time"
+
+	planetscale "github.com/harshav17/planet_scale"
+)
+
+type clerkMessageRepo struct {
+	db *DB
+}
+
+func NewClerkMessageRepo(db *DB) *clerkMessageRepo {
+	return &clerkMessageRepo{
+		db: db,
+	}
+}
+
+func (r *clerkMessageRepo) Create(tx *sql.Tx, message *planetscale.ClerkMessage) error {
+	message.ProcessedAt = time.Now().UTC().Truncate(time.Second)
+
+	query := `INSERT INTO clerk_messages (message_id, event_type, processed_at) VALUES (?, ?, ?)`
+
+	_, err := tx.Exec(query, message.MessageID, message.Type, message.ProcessedAt)
+	if isDuplicateEntry(err) {
+		return planetscale.Errorf(planetscale.ECONFLICT, "clerk message %s was already processed", message.MessageID)
+	} else if err != nil {
+		return err
+	}
+	slog.Info("created clerk message", slog.String("id", message.MessageID), slog.String("type", message.Type))
+
+	return nil
+}
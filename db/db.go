@@ -5,16 +5,18 @@ import (
 	"database/sql"
 	"database/sql/driver"
 	"embed"
+	"errors"
 	"fmt"
 	"log"
 	"strings"
 	"time"
 
-	_ "github.com/go-sql-driver/mysql"
+	gomysql "github.com/go-sql-driver/mysql"
 	"github.com/golang-migrate/migrate/v4"
 	"github.com/golang-migrate/migrate/v4/database/mysql"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 	"github.com/golang-migrate/migrate/v4/source/iofs"
+	planetscale "github.com/harshav17/planet_scale"
 )
 
 //go:embed migrations/*.sql
@@ -109,7 +111,12 @@ func (n *NullTime) Scan(value interface{}) error {
 		*(*time.Time)(n) = time.Time{}
 		return nil
 	} else if v, ok := value.([]byte); ok {
-		t, err := time.Parse("2006-01-02 15:04:05", string(v))
+		layout := "2006-01-02 15:04:05"
+		if len(v) == len(time.DateOnly) {
+			// DATE columns carry no time of day
+			layout = time.DateOnly
+		}
+		t, err := time.Parse(layout, string(v))
 		if err != nil {
 			return err
 		}
@@ -128,6 +135,12 @@ func (n *NullTime) Value() (driver.Value, error) {
 	return (*time.Time)(n).UTC().Format("2006-01-02 15:04:05"), nil
 }
 
+// isDuplicateEntry reports whether err is a MySQL unique key violation.
+func isDuplicateEntry(err error) bool {
+	var mysqlErr *gomysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == 1062
+}
+
 // TODO consider moving to a DB util class
 type findWhereClause struct {
 	columns []string
@@ -135,10 +148,69 @@ type findWhereClause struct {
 }
 
 func (w *findWhereClause) Add(column string, value interface{}) {
-	w.columns = append(w.columns, column)
+	w.AddOp(column, "=", value)
+}
+
+// AddOp compares column to value with op, e.g. ">=" for the start of a range.
+func (w *findWhereClause) AddOp(column string, op string, value interface{}) {
+	w.columns = append(w.columns, column+" "+op+" ?")
 	w.values = append(w.values, value)
 }
 
+// AddIn matches column against any of values.
+func (w *findWhereClause) AddIn(column string, values ...interface{}) {
+	if len(values) == 0 {
+		w.columns = append(w.columns, "FALSE")
+		return
+	}
+	w.columns = append(w.columns, column+" IN (?"+strings.Repeat(", ?", len(values)-1)+")")
+	w.values = append(w.values, values...)
+}
+
+// AddExpr matches rows for which expr holds, with its placeholders bound to
+// values.
+func (w *findWhereClause) AddExpr(expr string, values ...interface{}) {
+	w.columns = append(w.columns, expr)
+	w.values = append(w.values, values...)
+}
+
+// AddMatch matches rows whose FULLTEXT indexed column is relevant to query.
+func (w *findWhereClause) AddMatch(column string, query string) {
+	w.columns = append(w.columns, matchExpr(column))
+	w.values = append(w.values, query)
+}
+
+// matchExpr is the natural language relevance of column to a query placeholder.
+func matchExpr(column string) string {
+	return "MATCH (" + column + ") AGAINST (? IN NATURAL LANGUAGE MODE)"
+}
+
+// AddAfter matches rows that come after values when ordered by columns, which
+// is how a page continues from its cursor.
+func (w *findWhereClause) AddAfter(columns []string, desc bool, values ...interface{}) {
+	op := ">"
+	if desc {
+		op = "<"
+	}
+	placeholders := "?" + strings.Repeat(", ?", len(values)-1)
+	w.columns = append(w.columns, "("+strings.Join(columns, ", ")+") "+op+" ("+placeholders+")")
+	w.values = append(w.values, values...)
+}
+
+// AddIsNull matches rows without a value in column.
+func (w *findWhereClause) AddIsNull(column string) {
+	w.columns = append(w.columns, column+" IS NULL")
+}
+
+// AddDeleted matches soft-deleted rows when deleted is set, live rows otherwise.
+func (w *findWhereClause) AddDeleted(column string, deleted bool) {
+	if deleted {
+		w.columns = append(w.columns, column+" IS NOT NULL")
+	} else {
+		w.columns = append(w.columns, column+" IS NULL")
+	}
+}
+
 func (w *findWhereClause) ToClause() string {
 	s := strings.Builder{}
 	if len(w.columns) > 0 {
@@ -147,8 +219,72 @@ func (w *findWhereClause) ToClause() string {
 			if i > 0 {
 				s.WriteString(" AND ")
 			}
-			s.WriteString(column + " = ?")
+			s.WriteString(column)
 		}
 	}
 	return s.String()
 }
+
+// int64Values and stringValues convert values for use with AddIn.
+func int64Values(values []int64) []interface{} {
+	converted := make([]interface{}, len(values))
+	for i, v := range values {
+		converted[i] = v
+	}
+	return converted
+}
+
+func stringValues(values []string) []interface{} {
+	converted := make([]interface{}, len(values))
+	for i, v := range values {
+		converted[i] = v
+	}
+	return converted
+}
+
+// findPage applies the sort and cursor of a list filter to where and returns
+// the ORDER BY and LIMIT clause. sortColumns maps each sort field to its column
+// and idColumn breaks ties so that every row has a single position.
+func findPage(where *findWhereClause, idColumn string, sortColumns map[string]string, sort string, cursor string, limit int) (string, error) {
+	field, desc, err := planetscale.ParseSort(sort)
+	if err != nil {
+		return "", err
+	}
+	columns := []string{idColumn}
+	if field != "" {
+		columns = []string{sortColumns[field], idColumn}
+	}
+
+	if cursor != "" {
+		c, err := planetscale.DecodeCursor(cursor)
+		if err != nil {
+			return "", err
+		}
+		values := []interface{}{c.ID}
+		switch field {
+		case planetscale.SortTimestamp:
+			t, err := time.Parse(time.RFC3339, c.Value)
+			if err != nil {
+				return "", planetscale.Errorf(planetscale.EINVALID, "invalid cursor")
+			}
+			values = []interface{}{(*NullTime)(&t), c.ID}
+		case planetscale.SortAmount:
+			amount, err := planetscale.ParseMoney(c.Value)
+			if err != nil {
+				return "", planetscale.Errorf(planetscale.EINVALID, "invalid cursor")
+			}
+			values = []interface{}{amount, c.ID}
+		}
+		where.AddAfter(columns, desc, values...)
+	}
+
+	direction := " ASC"
+	if desc {
+		direction = " DESC"
+	}
+	clause := "ORDER BY " + strings.Join(columns, direction+", ") + direction
+	if limit > 0 {
+		clause += fmt.Sprintf(" LIMIT %d", limit)
+	}
+	return clause, nil
+}
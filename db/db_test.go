@@ -8,6 +8,7 @@ import (
 	"time"
 
 	_ "github.com/golang-migrate/migrate/v4/source/file"
+	planetscale "github.com/harshav17/planet_scale"
 
 	"github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/modules/mysql"
@@ -123,6 +124,18 @@ func TestNullTime_Scan(t *testing.T) {
 		}
 	})
 
+	t.Run("scan date", func(t *testing.T) {
+		var nt NullTime
+		err := nt.Scan([]byte("2022-01-01"))
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+		expected := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+		if !time.Time(nt).Equal(expected) {
+			t.Errorf("Expected %v, got %v", expected, nt)
+		}
+	})
+
 	t.Run("scan unsupported type", func(t *testing.T) {
 		var nt NullTime
 		err := nt.Scan(123)
@@ -179,3 +192,52 @@ func TestNullTime_Value(t *testing.T) {
 		}
 	})
 }
+
+func TestFindPage(t *testing.T) {
+	columns := map[string]string{
+		planetscale.SortTimestamp: "timestamp",
+		planetscale.SortAmount:    "amount",
+	}
+
+	t.Run("insertion order", func(t *testing.T) {
+		where := &findWhereClause{}
+		page, err := findPage(where, "id", columns, "", "", 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if page != "ORDER BY id ASC" {
+			t.Fatalf("unexpected page clause %q", page)
+		} else if where.ToClause() != "" {
+			t.Fatalf("unexpected where clause %q", where.ToClause())
+		}
+	})
+
+	t.Run("continue after cursor", func(t *testing.T) {
+		where := &findWhereClause{}
+		where.Add("group_id", 1)
+		where.AddIn("paid_by", "a", "b")
+		cursor := planetscale.Cursor{Value: "12.34", ID: 7}.Encode()
+
+		page, err := findPage(where, "id", columns, "-amount", cursor, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if page != "ORDER BY amount DESC, id DESC LIMIT 10" {
+			t.Fatalf("unexpected page clause %q", page)
+		}
+		if got := where.ToClause(); got != "WHERE group_id = ? AND paid_by IN (?, ?) AND (amount, id) < (?, ?)" {
+			t.Fatalf("unexpected where clause %q", got)
+		}
+		if len(where.values) != 5 || where.values[3] != planetscale.Money(12_34) || where.values[4] != int64(7) {
+			t.Fatalf("unexpected values %v", where.values)
+		}
+	})
+
+	t.Run("invalid cursor", func(t *testing.T) {
+		cursor := planetscale.Cursor{Value: "yesterday", ID: 7}.Encode()
+		_, err := findPage(&findWhereClause{}, "id", columns, "timestamp", cursor, 10)
+		if planetscale.ErrorCode(err) != planetscale.EINVALID {
+			t.Fatalf("expected invalid error, got %v", err)
+		}
+	})
+}
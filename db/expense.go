@@ -21,22 +21,32 @@ func (r *expenseRepo) Get(tx *sql.Tx, expenseID int64) (*planetscale.Expense, er
 		SELECT 
 			expense_id, 
 			group_id, 
+			split_type_id, 
 			paid_by, 
 			amount, 
+			currency, 
 			description, 
 			timestamp, 
 			created_at, 
 			updated_at, 
 			created_by, 
-			updated_by 
+			updated_by, 
+			recurring_expense_id, 
+			recurrence_index, 
+			deleted_at, 
+			tax, 
+			tip, 
+			service_charge, 
+			discount 
 		FROM 
 			expenses 
 		WHERE 
-			expense_id = ?`
+			expense_id = ? 
+			AND deleted_at IS NULL`
 
 	var expense planetscale.Expense
 	row := tx.QueryRow(query, expenseID)
-	err := row.Scan(&expense.ExpenseID, &expense.GroupID, &expense.PaidBy, &expense.Amount, &expense.Description, (*NullTime)(&expense.Timestamp), (*NullTime)(&expense.CreatedAt), (*NullTime)(&expense.UpdatedAt), &expense.CreatedBy, &expense.UpdatedBy)
+	err := row.Scan(&expense.ExpenseID, &expense.GroupID, &expense.SplitTypeID, &expense.PaidBy, &expense.Amount, &expense.Currency, &expense.Description, (*NullTime)(&expense.Timestamp), (*NullTime)(&expense.CreatedAt), (*NullTime)(&expense.UpdatedAt), &expense.CreatedBy, &expense.UpdatedBy, &expense.RecurringExpenseID, &expense.RecurrenceIndex, (*NullTime)(&expense.DeletedAt), &expense.Tax, &expense.Tip, &expense.ServiceCharge, &expense.Discount)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			// Handle no rows error specifically if needed
@@ -50,12 +60,20 @@ func (r *expenseRepo) Get(tx *sql.Tx, expenseID int64) (*planetscale.Expense, er
 }
 
 func (r *expenseRepo) Create(tx *sql.Tx, expense *planetscale.Expense) error {
-	query := `INSERT INTO expenses (group_id, paid_by, amount, description, timestamp, created_by, updated_by, split_type_id) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
-
-	result, err := tx.Exec(query, expense.GroupID, expense.PaidBy, expense.Amount, expense.Description, (*NullTime)(&expense.Timestamp), expense.CreatedBy, expense.CreatedBy, expense.SplitTypeID)
+	currency, err := resolveCurrency(tx, expense.Currency, expense.GroupID)
 	if err != nil {
 		return err
 	}
+	expense.Currency = currency
+
+	query := `INSERT INTO expenses (group_id, paid_by, amount, currency, description, timestamp, created_by, updated_by, split_type_id, recurring_expense_id, recurrence_index, tax, tip, service_charge, discount) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	result, err := tx.Exec(query, expense.GroupID, expense.PaidBy, expense.Amount, expense.Currency, expense.Description, (*NullTime)(&expense.Timestamp), expense.CreatedBy, expense.CreatedBy, expense.SplitTypeID, expense.RecurringExpenseID, expense.RecurrenceIndex, expense.Tax, expense.Tip, expense.ServiceCharge, expense.Discount)
+	if isDuplicateEntry(err) && expense.RecurringExpenseID != nil {
+		return planetscale.Errorf(planetscale.ECONFLICT, "occurrence %d of recurring expense %d already exists", expense.RecurrenceIndex, *expense.RecurringExpenseID)
+	} else if err != nil {
+		return err
+	}
 	expenseID, err := result.LastInsertId()
 	if err != nil {
 		return err
@@ -67,23 +85,31 @@ func (r *expenseRepo) Create(tx *sql.Tx, expense *planetscale.Expense) error {
 }
 
 func (r *expenseRepo) Upsert(tx *sql.Tx, expense *planetscale.Expense) error {
+	currency, err := resolveCurrency(tx, expense.Currency, expense.GroupID)
+	if err != nil {
+		return err
+	}
+	expense.Currency = currency
+
 	query := `
 		INSERT INTO 
 			expenses (
 				group_id, 
 				paid_by, 
 				amount, 
+				currency, 
 				description, 
 				timestamp, 
 				created_by, 
 				updated_by, 
 				split_type_id
 			) 
-			VALUES (?, ?, ?, ?, ?, ?, ?, ?) 
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?) 
 			ON DUPLICATE KEY UPDATE 
 				group_id = ?, 
 				paid_by = ?, 
 				amount = ?, 
+				currency = ?, 
 				description = ?, 
 				timestamp = ?, 
 				updated_by = ?, 
@@ -94,6 +120,7 @@ func (r *expenseRepo) Upsert(tx *sql.Tx, expense *planetscale.Expense) error {
 		expense.GroupID,
 		expense.PaidBy,
 		expense.Amount,
+		expense.Currency,
 		expense.Description,
 		(*NullTime)(&expense.Timestamp),
 		expense.CreatedBy,
@@ -102,6 +129,7 @@ func (r *expenseRepo) Upsert(tx *sql.Tx, expense *planetscale.Expense) error {
 		expense.GroupID,
 		expense.PaidBy,
 		expense.Amount,
+		expense.Currency,
 		expense.Description,
 		(*NullTime)(&expense.Timestamp),
 		expense.CreatedBy,
@@ -135,6 +163,13 @@ func (r *expenseRepo) Update(tx *sql.Tx, expenseID int64, update *planetscale.Ex
 	if update.Amount != nil {
 		expense.Amount = *update.Amount
 	}
+	if update.Currency != nil {
+		currency, err := planetscale.NormalizeCurrency(*update.Currency)
+		if err != nil {
+			return nil, err
+		}
+		expense.Currency = currency
+	}
 	if update.Description != nil {
 		expense.Description = *update.Description
 	}
@@ -144,10 +179,22 @@ func (r *expenseRepo) Update(tx *sql.Tx, expenseID int64, update *planetscale.Ex
 	if update.UpdatedBy != nil {
 		expense.UpdatedBy = *update.UpdatedBy
 	}
+	if update.Tax != nil {
+		expense.Tax = *update.Tax
+	}
+	if update.Tip != nil {
+		expense.Tip = *update.Tip
+	}
+	if update.ServiceCharge != nil {
+		expense.ServiceCharge = *update.ServiceCharge
+	}
+	if update.Discount != nil {
+		expense.Discount = *update.Discount
+	}
 
-	query := `UPDATE expenses SET group_id = ?, paid_by = ?, amount = ?, description = ?, timestamp = ?, updated_by = ? WHERE expense_id = ?`
+	query := `UPDATE expenses SET group_id = ?, paid_by = ?, amount = ?, currency = ?, description = ?, timestamp = ?, updated_by = ?, tax = ?, tip = ?, service_charge = ?, discount = ? WHERE expense_id = ?`
 
-	result, err := tx.Exec(query, expense.GroupID, expense.PaidBy, expense.Amount, expense.Description, expense.Timestamp, expense.UpdatedBy, expenseID)
+	result, err := tx.Exec(query, expense.GroupID, expense.PaidBy, expense.Amount, expense.Currency, expense.Description, expense.Timestamp, expense.UpdatedBy, expense.Tax, expense.Tip, expense.ServiceCharge, expense.Discount, expenseID)
 	if err != nil {
 		return nil, err
 	}
@@ -163,8 +210,9 @@ func (r *expenseRepo) Update(tx *sql.Tx, expenseID int64, update *planetscale.Ex
 	return r.Get(tx, expenseID)
 }
 
+// Delete soft deletes the expense, it stays restorable from the group's trash.
 func (r *expenseRepo) Delete(tx *sql.Tx, expenseID int64) error {
-	query := `DELETE FROM expenses WHERE expense_id = ?`
+	query := `UPDATE expenses SET deleted_at = CURRENT_TIMESTAMP WHERE expense_id = ? AND deleted_at IS NULL`
 
 	result, err := tx.Exec(query, expenseID)
 	if err != nil {
@@ -182,10 +230,61 @@ func (r *expenseRepo) Delete(tx *sql.Tx, expenseID int64) error {
 	return nil
 }
 
+func (r *expenseRepo) Restore(tx *sql.Tx, expenseID int64) error {
+	query := `UPDATE expenses SET deleted_at = NULL WHERE expense_id = ? AND deleted_at IS NOT NULL`
+
+	result, err := tx.Exec(query, expenseID)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return planetscale.Errorf(planetscale.ENOTFOUND, "no deleted expense found with ID %d", expenseID)
+	}
+	slog.Info("restored expense", slog.Int64("id", expenseID))
+
+	return nil
+}
+
 func (r *expenseRepo) Find(tx *sql.Tx, filter planetscale.ExpenseFilter) ([]*planetscale.Expense, error) {
 	where := &findWhereClause{}
 	if filter.GroupID != 0 {
-		where.Add("group_id", filter.GroupID)
+		where.Add("e.group_id", filter.GroupID)
+	}
+	if filter.GroupIDs != nil {
+		where.AddIn("e.group_id", int64Values(filter.GroupIDs)...)
+	}
+	if filter.Direct {
+		where.AddIsNull("e.group_id")
+	}
+	if filter.UserID != "" {
+		where.AddExpr("(e.paid_by = ? OR e.expense_id IN (SELECT expense_id FROM expense_participants WHERE user_id = ?))", filter.UserID, filter.UserID)
+	}
+	where.AddDeleted("e.deleted_at", filter.Deleted)
+	if filter.PaidBy != nil {
+		where.AddIn("e.paid_by", stringValues(filter.PaidBy)...)
+	}
+	if filter.From != nil {
+		where.AddOp("e.timestamp", ">=", (*NullTime)(filter.From))
+	}
+	if filter.To != nil {
+		where.AddOp("e.timestamp", "<", (*NullTime)(filter.To))
+	}
+	if filter.MinAmount != nil {
+		where.AddOp("e.amount", ">=", *filter.MinAmount)
+	}
+	if filter.MaxAmount != nil {
+		where.AddOp("e.amount", "<=", *filter.MaxAmount)
+	}
+	page, err := findPage(where, "e.expense_id", map[string]string{
+		planetscale.SortTimestamp: "e.timestamp",
+		planetscale.SortAmount:    "e.amount",
+	}, filter.Sort, filter.Cursor, filter.Limit)
+	if err != nil {
+		return nil, err
 	}
 
 	query := `
@@ -194,6 +293,7 @@ func (r *expenseRepo) Find(tx *sql.Tx, filter planetscale.ExpenseFilter) ([]*pla
 			e.group_id,
 			e.paid_by,
 			e.amount,
+			e.currency,
 			e.description,
 			e.timestamp,
 			e.created_at,
@@ -201,9 +301,17 @@ func (r *expenseRepo) Find(tx *sql.Tx, filter planetscale.ExpenseFilter) ([]*pla
 			e.created_by,
 			e.updated_by,
 			e.split_type_id,
+			e.recurring_expense_id,
+			e.recurrence_index,
+			e.deleted_at,
+			e.tax,
+			e.tip,
+			e.service_charge,
+			e.discount,
 			u.name
 		FROM expenses e JOIN users u ON e.paid_by = u.user_id
-		` + where.ToClause()
+		` + where.ToClause() + `
+		` + page
 	rows, err := tx.Query(query, where.values...)
 	if err != nil {
 		return nil, err
@@ -213,7 +321,7 @@ func (r *expenseRepo) Find(tx *sql.Tx, filter planetscale.ExpenseFilter) ([]*pla
 	for rows.Next() {
 		var expense planetscale.Expense
 		var user planetscale.User
-		err := rows.Scan(&expense.ExpenseID, &expense.GroupID, &expense.PaidBy, &expense.Amount, &expense.Description, (*NullTime)(&expense.Timestamp), (*NullTime)(&expense.CreatedAt), (*NullTime)(&expense.UpdatedAt), &expense.CreatedBy, &expense.UpdatedBy, &expense.SplitTypeID, &user.Name)
+		err := rows.Scan(&expense.ExpenseID, &expense.GroupID, &expense.PaidBy, &expense.Amount, &expense.Currency, &expense.Description, (*NullTime)(&expense.Timestamp), (*NullTime)(&expense.CreatedAt), (*NullTime)(&expense.UpdatedAt), &expense.CreatedBy, &expense.UpdatedBy, &expense.SplitTypeID, &expense.RecurringExpenseID, &expense.RecurrenceIndex, (*NullTime)(&expense.DeletedAt), &expense.Tax, &expense.Tip, &expense.ServiceCharge, &expense.Discount, &user.Name)
 		if err != nil {
 			return nil, err
 		}
@@ -19,11 +19,11 @@ func NewExpenseGroupRepo(db *DB) *expenseGroupRepo {
 }
 
 func (r *expenseGroupRepo) Get(tx *sql.Tx, groupID int64) (*planetscale.ExpenseGroup, error) {
-	query := `SELECT group_id, group_name, created_at, created_by FROM expense_groups WHERE group_id = ?`
+	query := `SELECT group_id, group_name, base_currency, created_at, created_by FROM expense_groups WHERE group_id = ? AND deleted_at IS NULL`
 
 	var group planetscale.ExpenseGroup
 	row := tx.QueryRow(query, groupID)
-	err := row.Scan(&group.ExpenseGroupID, &group.GroupName, (*NullTime)(&group.CreatedAt), &group.CreateBy)
+	err := row.Scan(&group.ExpenseGroupID, &group.GroupName, &group.BaseCurrency, (*NullTime)(&group.CreatedAt), &group.CreateBy)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			// Handle no rows error specifically if needed
@@ -37,9 +37,18 @@ func (r *expenseGroupRepo) Get(tx *sql.Tx, groupID int64) (*planetscale.ExpenseG
 }
 
 func (r *expenseGroupRepo) Create(tx *sql.Tx, group *planetscale.ExpenseGroup) error {
-	query := `INSERT INTO expense_groups (group_name, created_by, updated_by) VALUES (?, ?, ?)`
+	if group.BaseCurrency == "" {
+		group.BaseCurrency = planetscale.DefaultCurrency
+	}
+	currency, err := planetscale.NormalizeCurrency(group.BaseCurrency)
+	if err != nil {
+		return err
+	}
+	group.BaseCurrency = currency
 
-	result, err := tx.Exec(query, group.GroupName, group.CreateBy, group.CreateBy)
+	query := `INSERT INTO expense_groups (group_name, base_currency, created_by, updated_by) VALUES (?, ?, ?, ?)`
+
+	result, err := tx.Exec(query, group.GroupName, group.BaseCurrency, group.CreateBy, group.CreateBy)
 	if err != nil {
 		return err
 	}
@@ -54,9 +63,25 @@ func (r *expenseGroupRepo) Create(tx *sql.Tx, group *planetscale.ExpenseGroup) e
 }
 
 func (r *expenseGroupRepo) Update(tx *sql.Tx, groupID int64, update *planetscale.ExpenseGroupUpdate) (*planetscale.ExpenseGroup, error) {
-	query := `UPDATE expense_groups SET group_name = ? WHERE group_id = ?`
+	group, err := r.Get(tx, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	if update.GroupName != "" {
+		group.GroupName = update.GroupName
+	}
+	if update.BaseCurrency != nil {
+		currency, err := planetscale.NormalizeCurrency(*update.BaseCurrency)
+		if err != nil {
+			return nil, err
+		}
+		group.BaseCurrency = currency
+	}
+
+	query := `UPDATE expense_groups SET group_name = ?, base_currency = ? WHERE group_id = ? AND deleted_at IS NULL`
 
-	result, err := tx.Exec(query, update.GroupName, groupID)
+	result, err := tx.Exec(query, group.GroupName, group.BaseCurrency, groupID)
 	if err != nil {
 		return nil, err
 	}
@@ -72,8 +97,10 @@ func (r *expenseGroupRepo) Update(tx *sql.Tx, groupID int64, update *planetscale
 	return r.Get(tx, groupID)
 }
 
+// Delete soft deletes the group. Its members, expenses and settlements are
+// left in place so that Restore brings the group back as it was.
 func (r *expenseGroupRepo) Delete(tx *sql.Tx, groupID int64) error {
-	query := `DELETE FROM expense_groups WHERE group_id = ?`
+	query := `UPDATE expense_groups SET deleted_at = CURRENT_TIMESTAMP WHERE group_id = ? AND deleted_at IS NULL`
 
 	result, err := tx.Exec(query, groupID)
 	if err != nil {
@@ -91,12 +118,31 @@ func (r *expenseGroupRepo) Delete(tx *sql.Tx, groupID int64) error {
 	return nil
 }
 
+func (r *expenseGroupRepo) Restore(tx *sql.Tx, groupID int64) error {
+	query := `UPDATE expense_groups SET deleted_at = NULL WHERE group_id = ? AND deleted_at IS NOT NULL`
+
+	result, err := tx.Exec(query, groupID)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return planetscale.Errorf(planetscale.ENOTFOUND, "no deleted expense group found with ID %d", groupID)
+	}
+	slog.Info("restored expense group", slog.Int64("id", groupID))
+
+	return nil
+}
+
 func (r *expenseGroupRepo) ListAllForUser(tx *sql.Tx, userID string) ([]*planetscale.ExpenseGroup, error) {
 	// join with group_members to get all groups for a user
-	query := `SELECT eg.group_id, eg.group_name, eg.created_at, eg.created_by, eg.updated_at, eg.updated_by
+	query := `SELECT eg.group_id, eg.group_name, eg.base_currency, eg.created_at, eg.created_by, eg.updated_at, eg.updated_by
 		FROM expense_groups eg
 		JOIN group_members gm ON gm.group_id = eg.group_id
-		WHERE gm.user_id = ?`
+		WHERE gm.user_id = ? AND gm.left_at IS NULL AND eg.deleted_at IS NULL`
 
 	rows, err := tx.Query(query, userID)
 	if err != nil {
@@ -106,7 +152,7 @@ func (r *expenseGroupRepo) ListAllForUser(tx *sql.Tx, userID string) ([]*planets
 	var groups []*planetscale.ExpenseGroup
 	for rows.Next() {
 		var group planetscale.ExpenseGroup
-		err := rows.Scan(&group.ExpenseGroupID, &group.GroupName, (*NullTime)(&group.CreatedAt), &group.CreateBy, (*NullTime)(&group.UpdatedAt), &group.UpdatedBy)
+		err := rows.Scan(&group.ExpenseGroupID, &group.GroupName, &group.BaseCurrency, (*NullTime)(&group.CreatedAt), &group.CreateBy, (*NullTime)(&group.UpdatedAt), &group.UpdatedBy)
 		if err != nil {
 			return nil, err
 		}
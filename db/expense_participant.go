@@ -25,6 +25,7 @@ func (r *expenseParticipantRepo) Get(tx *sql.Tx, expenseID int64, userID string)
 			user_id,
 			amount_owed,
 			share_percentage,
+			shares,
 			note
 		FROM expense_participants
 		WHERE expense_id = ? AND user_id = ?
@@ -32,7 +33,7 @@ func (r *expenseParticipantRepo) Get(tx *sql.Tx, expenseID int64, userID string)
 
 	var participant planetscale.ExpenseParticipant
 	row := tx.QueryRow(query, expenseID, userID)
-	err := row.Scan(&participant.ExpenseID, &participant.UserID, &participant.AmountOwed, &participant.SharePercentage, &participant.Note)
+	err := row.Scan(&participant.ExpenseID, &participant.UserID, &participant.AmountOwed, &participant.SharePercentage, &participant.Shares, &participant.Note)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			// Handle no rows error specifically if needed
@@ -52,11 +53,12 @@ func (r *expenseParticipantRepo) Create(tx *sql.Tx, participant *planetscale.Exp
 			user_id,
 			amount_owed,
 			share_percentage,
+			shares,
 			note
-		) VALUES (?, ?, ?, ?, ?)
+		) VALUES (?, ?, ?, ?, ?, ?)
 	`
 
-	result, err := tx.Exec(query, participant.ExpenseID, participant.UserID, participant.AmountOwed, participant.SharePercentage, participant.Note)
+	result, err := tx.Exec(query, participant.ExpenseID, participant.UserID, participant.AmountOwed, participant.SharePercentage, participant.Shares, participant.Note)
 	if err != nil {
 		return err
 	}
@@ -76,11 +78,12 @@ func (r *expenseParticipantRepo) Upsert(tx *sql.Tx, participant *planetscale.Exp
 			user_id,
 			amount_owed,
 			share_percentage,
+			shares,
 			note
-		) VALUES (?, ?, ?, ?, ?) ON DUPLICATE KEY UPDATE amount_owed = ?, share_percentage = ?, note = ?
+		) VALUES (?, ?, ?, ?, ?, ?) ON DUPLICATE KEY UPDATE amount_owed = ?, share_percentage = ?, shares = ?, note = ?
 	`
 
-	result, err := tx.Exec(query, participant.ExpenseID, participant.UserID, participant.AmountOwed, participant.SharePercentage, participant.Note, participant.AmountOwed, participant.SharePercentage, participant.Note)
+	result, err := tx.Exec(query, participant.ExpenseID, participant.UserID, participant.AmountOwed, participant.SharePercentage, participant.Shares, participant.Note, participant.AmountOwed, participant.SharePercentage, participant.Shares, participant.Note)
 	if err != nil {
 		return err
 	}
@@ -127,17 +130,20 @@ func (r *expenseParticipantRepo) Update(tx *sql.Tx, expenseID int64, userID stri
 	if update.SharePercentage != nil {
 		participant.SharePercentage = *update.SharePercentage
 	}
+	if update.Shares != nil {
+		participant.Shares = *update.Shares
+	}
 	if update.Note != nil {
 		participant.Note = *update.Note
 	}
 
 	query := `
 		UPDATE expense_participants
-		SET amount_owed = ?, share_percentage = ?, note = ?
+		SET amount_owed = ?, share_percentage = ?, shares = ?, note = ?
 		WHERE expense_id = ? AND user_id = ?
 	`
 
-	result, err := tx.Exec(query, participant.AmountOwed, participant.SharePercentage, participant.Note, expenseID, userID)
+	result, err := tx.Exec(query, participant.AmountOwed, participant.SharePercentage, participant.Shares, participant.Note, expenseID, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -158,6 +164,9 @@ func (r *expenseParticipantRepo) Find(tx *sql.Tx, filter planetscale.ExpensePart
 	if filter.ExpenseID != 0 {
 		where.Add("expense_id", filter.ExpenseID)
 	}
+	if filter.ExpenseIDs != nil {
+		where.AddIn("expense_id", int64Values(filter.ExpenseIDs)...)
+	}
 
 	query := `
 		SELECT
@@ -165,6 +174,7 @@ func (r *expenseParticipantRepo) Find(tx *sql.Tx, filter planetscale.ExpensePart
 			user_id,
 			amount_owed,
 			share_percentage,
+			shares,
 			note
 		FROM expense_participants
 	` + where.ToClause()
@@ -177,7 +187,7 @@ func (r *expenseParticipantRepo) Find(tx *sql.Tx, filter planetscale.ExpensePart
 	var participants []*planetscale.ExpenseParticipant
 	for rows.Next() {
 		var participant planetscale.ExpenseParticipant
-		err := rows.Scan(&participant.ExpenseID, &participant.UserID, &participant.AmountOwed, &participant.SharePercentage, &participant.Note)
+		err := rows.Scan(&participant.ExpenseID, &participant.UserID, &participant.AmountOwed, &participant.SharePercentage, &participant.Shares, &participant.Note)
 		if err != nil {
 			return nil, err
 		}
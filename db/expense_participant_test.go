@@ -37,7 +37,7 @@ func TestExpenseParticipantRepo_All(t *testing.T) {
 			ep := &planetscale.ExpenseParticipant{
 				ExpenseID:       1,
 				UserID:          "non-existent-user-id",
-				AmountOwed:      100,
+				AmountOwed:      100_00,
 				SharePercentage: 100,
 				Note:            "test expense",
 			}
@@ -71,7 +71,7 @@ func TestExpenseParticipantRepo_All(t *testing.T) {
 				GroupID:     &g.ExpenseGroupID,
 				PaidBy:      u.UserID,
 				SplitTypeID: 1,
-				Amount:      100,
+				Amount:      100_00,
 				Description: "test expense",
 				Timestamp:   time.Now(),
 				CreatedBy:   u.UserID,
@@ -81,8 +81,9 @@ func TestExpenseParticipantRepo_All(t *testing.T) {
 			ep := &planetscale.ExpenseParticipant{
 				ExpenseID:       e.ExpenseID,
 				UserID:          u.UserID,
-				AmountOwed:      100,
+				AmountOwed:      100_00,
 				SharePercentage: 100,
+				Shares:          2,
 				Note:            "test expense",
 			}
 
@@ -100,9 +101,11 @@ func TestExpenseParticipantRepo_All(t *testing.T) {
 			} else if got.UserID != ep.UserID {
 				t.Fatalf("expected user id %s, got %s", ep.UserID, got.UserID)
 			} else if got.AmountOwed != ep.AmountOwed {
-				t.Fatalf("expected amount owed %f, got %f", ep.AmountOwed, got.AmountOwed)
+				t.Fatalf("expected amount owed %s, got %s", ep.AmountOwed, got.AmountOwed)
 			} else if got.SharePercentage != ep.SharePercentage {
 				t.Fatalf("expected share percentage %f, got %f", ep.SharePercentage, got.SharePercentage)
+			} else if got.Shares != ep.Shares {
+				t.Fatalf("expected shares %d, got %d", ep.Shares, got.Shares)
 			} else if got.Note != ep.Note {
 				t.Fatalf("expected note %s, got %s", ep.Note, got.Note)
 			}
@@ -118,7 +121,7 @@ func TestExpenseParticipantRepo_All(t *testing.T) {
 				t.Fatal(err)
 			}
 			if got.AmountOwed != ep.AmountOwed {
-				t.Fatalf("expected amount owed %f, got %f", ep.AmountOwed, got.AmountOwed)
+				t.Fatalf("expected amount owed %s, got %s", ep.AmountOwed, got.AmountOwed)
 			}
 		})
 	})
@@ -144,7 +147,7 @@ func TestExpenseParticipantRepo_All(t *testing.T) {
 				GroupID:     &g.ExpenseGroupID,
 				PaidBy:      u.UserID,
 				SplitTypeID: 1,
-				Amount:      100,
+				Amount:      100_00,
 				Description: "test expense",
 				Timestamp:   time.Now(),
 				CreatedBy:   u.UserID,
@@ -153,7 +156,7 @@ func TestExpenseParticipantRepo_All(t *testing.T) {
 			ep := MustCreateExpenseParticipant(t, tx, db.DB, &planetscale.ExpenseParticipant{
 				ExpenseID:       e.ExpenseID,
 				UserID:          u.UserID,
-				AmountOwed:      100,
+				AmountOwed:      100_00,
 				SharePercentage: 100,
 				Note:            "test expense",
 			})
@@ -212,7 +215,7 @@ func TestExpenseParticipantRepo_All(t *testing.T) {
 				GroupID:     &g.ExpenseGroupID,
 				PaidBy:      u.UserID,
 				SplitTypeID: 1,
-				Amount:      100,
+				Amount:      100_00,
 				Description: "test expense",
 				Timestamp:   time.Now(),
 				CreatedBy:   u.UserID,
@@ -221,12 +224,12 @@ func TestExpenseParticipantRepo_All(t *testing.T) {
 			ep := MustCreateExpenseParticipant(t, tx, db.DB, &planetscale.ExpenseParticipant{
 				ExpenseID:       e.ExpenseID,
 				UserID:          u.UserID,
-				AmountOwed:      100,
+				AmountOwed:      100_00,
 				SharePercentage: 100,
 				Note:            "test expense",
 			})
 
-			amountOwed := 200.0
+			amountOwed := planetscale.Money(200_00)
 			sharePercentage := 50.0
 			splitMethod := "PERCENTAGE"
 			note := "updated note"
@@ -243,7 +246,7 @@ func TestExpenseParticipantRepo_All(t *testing.T) {
 			} else if got.UserID != ep.UserID {
 				t.Fatalf("expected user id %s, got %s", ep.UserID, got.UserID)
 			} else if got.AmountOwed != amountOwed {
-				t.Fatalf("expected amount owed %f, got %f", amountOwed, got.AmountOwed)
+				t.Fatalf("expected amount owed %s, got %s", amountOwed, got.AmountOwed)
 			} else if got.SharePercentage != sharePercentage {
 				t.Fatalf("expected share percentage %f, got %f", sharePercentage, got.SharePercentage)
 			}
@@ -271,7 +274,7 @@ func TestExpenseParticipantRepo_All(t *testing.T) {
 				GroupID:     &g.ExpenseGroupID,
 				PaidBy:      u.UserID,
 				SplitTypeID: 1,
-				Amount:      100,
+				Amount:      100_00,
 				Description: "test expense",
 				Timestamp:   time.Now(),
 				CreatedBy:   u.UserID,
@@ -280,7 +283,7 @@ func TestExpenseParticipantRepo_All(t *testing.T) {
 			ep := MustCreateExpenseParticipant(t, tx, db.DB, &planetscale.ExpenseParticipant{
 				ExpenseID:       e.ExpenseID,
 				UserID:          u.UserID,
-				AmountOwed:      100,
+				AmountOwed:      100_00,
 				SharePercentage: 100,
 				Note:            "test expense",
 			})
@@ -316,7 +319,7 @@ func TestExpenseParticipantRepo_All(t *testing.T) {
 				GroupID:     &g.ExpenseGroupID,
 				PaidBy:      u.UserID,
 				SplitTypeID: 1,
-				Amount:      100,
+				Amount:      100_00,
 				Description: "test expense",
 				Timestamp:   time.Now(),
 				CreatedBy:   u.UserID,
@@ -325,7 +328,7 @@ func TestExpenseParticipantRepo_All(t *testing.T) {
 			ep := MustCreateExpenseParticipant(t, tx, db.DB, &planetscale.ExpenseParticipant{
 				ExpenseID:       e.ExpenseID,
 				UserID:          u.UserID,
-				AmountOwed:      100,
+				AmountOwed:      100_00,
 				SharePercentage: 100,
 				Note:            "test expense",
 			})
@@ -35,7 +35,7 @@ func TestExpenseRepo_All(t *testing.T) {
 			e := &planetscale.Expense{
 				GroupID:     &groupID,
 				PaidBy:      "non-existent-user-id",
-				Amount:      100,
+				Amount:      100_00,
 				Description: "test expense",
 				Timestamp:   time.Now(),
 				CreatedBy:   "test-user-id",
@@ -70,7 +70,7 @@ func TestExpenseRepo_All(t *testing.T) {
 				GroupID:     &eg.ExpenseGroupID,
 				PaidBy:      u.UserID,
 				SplitTypeID: 1,
-				Amount:      100,
+				Amount:      100_00,
 				Description: "test expense",
 				Timestamp:   time.Now(),
 				CreatedBy:   u.UserID,
@@ -104,7 +104,7 @@ func TestExpenseRepo_All(t *testing.T) {
 				t.Fatal(err)
 			}
 			if got.Amount != e.Amount {
-				t.Fatalf("expected amount to be %f, got %f", e.Amount, got.Amount)
+				t.Fatalf("expected amount to be %s, got %s", e.Amount, got.Amount)
 			}
 		})
 	})
@@ -132,7 +132,7 @@ func TestExpenseRepo_All(t *testing.T) {
 				GroupID:     &eg.ExpenseGroupID,
 				PaidBy:      u.UserID,
 				SplitTypeID: 1,
-				Amount:      100,
+				Amount:      100_00,
 				Description: "test expense",
 				Timestamp:   time.Now(),
 				CreatedBy:   u.UserID,
@@ -172,22 +172,25 @@ func TestExpenseRepo_All(t *testing.T) {
 				GroupID:     &eg.ExpenseGroupID,
 				PaidBy:      u.UserID,
 				SplitTypeID: 1,
-				Amount:      100,
+				Amount:      100_00,
 				Description: "test expense",
 				Timestamp:   time.Now(),
 				CreatedBy:   u.UserID,
 				UpdatedBy:   u.UserID,
 			})
 
-			newAmount := 200.0
+			newAmount, tip := planetscale.Money(200_00), planetscale.Money(15_00)
 			update := &planetscale.ExpenseUpdate{
 				Amount: &newAmount,
+				Tip:    &tip,
 			}
 
 			if got, err := NewExpenseRepo(db.DB).Update(tx, e.ExpenseID, update); err != nil {
 				t.Fatal(err)
 			} else if got.Amount != *update.Amount {
-				t.Fatalf("expected amount to be %f, got %f", *update.Amount, got.Amount)
+				t.Fatalf("expected amount to be %s, got %s", *update.Amount, got.Amount)
+			} else if got.Tip != tip {
+				t.Fatalf("expected tip to be %s, got %s", tip, got.Tip)
 			}
 		})
 	})
@@ -215,7 +218,7 @@ func TestExpenseRepo_All(t *testing.T) {
 				GroupID:     &eg.ExpenseGroupID,
 				PaidBy:      u.UserID,
 				SplitTypeID: 1,
-				Amount:      100,
+				Amount:      100_00,
 				Description: "test expense",
 				Timestamp:   time.Now(),
 				CreatedBy:   u.UserID,
@@ -231,6 +234,65 @@ func TestExpenseRepo_All(t *testing.T) {
 				t.Fatal("expected error, got nil")
 			}
 		})
+
+		t.Run("restore from trash", func(t *testing.T) {
+			tx, err := db.db.BeginTx(ctx, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tx.Rollback()
+
+			u := MustCreateUser(t, tx, db.DB, &planetscale.User{
+				UserID: "test-user-id",
+				Name:   "test user",
+				Email:  "",
+			})
+
+			eg := MustCreateExpenseGroup(t, tx, db.DB, &planetscale.ExpenseGroup{
+				GroupName: "test group",
+				CreateBy:  u.UserID,
+			})
+
+			e := MustCreateExpense(t, tx, db.DB, &planetscale.Expense{
+				GroupID:     &eg.ExpenseGroupID,
+				PaidBy:      u.UserID,
+				SplitTypeID: 1,
+				Amount:      100_00,
+				Description: "test expense",
+				Timestamp:   time.Now(),
+				CreatedBy:   u.UserID,
+				UpdatedBy:   u.UserID,
+			})
+
+			repo := NewExpenseRepo(db.DB)
+			if err := repo.Delete(tx, e.ExpenseID); err != nil {
+				t.Fatal(err)
+			}
+
+			live, err := repo.Find(tx, planetscale.ExpenseFilter{GroupID: eg.ExpenseGroupID})
+			if err != nil {
+				t.Fatal(err)
+			}
+			trash, err := repo.Find(tx, planetscale.ExpenseFilter{GroupID: eg.ExpenseGroupID, Deleted: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(live) != 0 {
+				t.Fatalf("expected no live expenses, got %d", len(live))
+			} else if len(trash) != 1 || trash[0].DeletedAt.IsZero() {
+				t.Fatalf("expected 1 deleted expense, got %d", len(trash))
+			}
+
+			if err := repo.Restore(tx, e.ExpenseID); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := repo.Get(tx, e.ExpenseID); err != nil {
+				t.Fatal(err)
+			}
+			if err := repo.Restore(tx, e.ExpenseID); planetscale.ErrorCode(err) != planetscale.ENOTFOUND {
+				t.Fatalf("expected not found error, got %v", err)
+			}
+		})
 	})
 
 	t.Run("Find Tests", func(t *testing.T) {
@@ -256,7 +318,7 @@ func TestExpenseRepo_All(t *testing.T) {
 				GroupID:     &eg.ExpenseGroupID,
 				PaidBy:      u.UserID,
 				SplitTypeID: 1,
-				Amount:      100,
+				Amount:      100_00,
 				Description: "test expense",
 				Timestamp:   time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
 				CreatedBy:   u.UserID,
@@ -278,7 +340,7 @@ func TestExpenseRepo_All(t *testing.T) {
 			} else if got[0].PaidBy != e.PaidBy {
 				t.Fatalf("expected paid by %s, got %s", e.PaidBy, got[0].PaidBy)
 			} else if got[0].Amount != e.Amount {
-				t.Fatalf("expected amount %f, got %f", e.Amount, got[0].Amount)
+				t.Fatalf("expected amount %s, got %s", e.Amount, got[0].Amount)
 			} else if got[0].Description != e.Description {
 				t.Fatalf("expected description %s, got %s", e.Description, got[0].Description)
 			} else if got[0].CreatedBy != e.CreatedBy {
@@ -293,5 +355,108 @@ func TestExpenseRepo_All(t *testing.T) {
 				t.Fatalf("expected timestamp %s, got %s", e.Timestamp, got[0].Timestamp)
 			}
 		})
+
+		t.Run("direct expenses of a user", func(t *testing.T) {
+			tx, err := db.db.BeginTx(ctx, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tx.Rollback()
+
+			u1 := MustCreateUser(t, tx, db.DB, &planetscale.User{UserID: "test-user-id", Name: "test user"})
+			u2 := MustCreateUser(t, tx, db.DB, &planetscale.User{UserID: "test-user-id-2", Name: "test user 2"})
+			u3 := MustCreateUser(t, tx, db.DB, &planetscale.User{UserID: "test-user-id-3", Name: "test user 3"})
+			eg := MustCreateExpenseGroup(t, tx, db.DB, &planetscale.ExpenseGroup{
+				GroupName: "test group",
+				CreateBy:  u1.UserID,
+			})
+
+			newExpense := func(groupID *int64, paidBy string) *planetscale.Expense {
+				return MustCreateExpense(t, tx, db.DB, &planetscale.Expense{
+					GroupID:     groupID,
+					PaidBy:      paidBy,
+					SplitTypeID: 1,
+					Amount:      100_00,
+					Timestamp:   time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
+					CreatedBy:   paidBy,
+					UpdatedBy:   paidBy,
+				})
+			}
+			newExpense(&eg.ExpenseGroupID, u1.UserID)
+			paid := newExpense(nil, u1.UserID)
+			shared := newExpense(nil, u3.UserID)
+			MustCreateExpenseParticipant(t, tx, db.DB, &planetscale.ExpenseParticipant{ExpenseID: shared.ExpenseID, UserID: u1.UserID})
+			other := newExpense(nil, u2.UserID)
+			MustCreateExpenseParticipant(t, tx, db.DB, &planetscale.ExpenseParticipant{ExpenseID: other.ExpenseID, UserID: u3.UserID})
+
+			got, err := NewExpenseRepo(db.DB).Find(tx, planetscale.ExpenseFilter{
+				Direct: true,
+				UserID: u1.UserID,
+			})
+			if err != nil {
+				t.Fatal(err)
+			} else if len(got) != 2 {
+				t.Fatalf("expected 2 expenses, got %d", len(got))
+			}
+			for _, e := range got {
+				if e.GroupID != nil {
+					t.Fatalf("expected no group, got %d", *e.GroupID)
+				} else if e.ExpenseID != paid.ExpenseID && e.ExpenseID != shared.ExpenseID {
+					t.Fatalf("unexpected expense %d", e.ExpenseID)
+				}
+			}
+		})
+
+		t.Run("expenses of many groups", func(t *testing.T) {
+			tx, err := db.db.BeginTx(ctx, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tx.Rollback()
+
+			u := MustCreateUser(t, tx, db.DB, &planetscale.User{UserID: "test-user-id", Name: "test user"})
+			var groupIDs []int64
+			for _, name := range []string{"group 1", "group 2", "group 3"} {
+				eg := MustCreateExpenseGroup(t, tx, db.DB, &planetscale.ExpenseGroup{
+					GroupName: name,
+					CreateBy:  u.UserID,
+				})
+				groupIDs = append(groupIDs, eg.ExpenseGroupID)
+				e := MustCreateExpense(t, tx, db.DB, &planetscale.Expense{
+					GroupID:     &eg.ExpenseGroupID,
+					PaidBy:      u.UserID,
+					SplitTypeID: 1,
+					Amount:      100_00,
+					Timestamp:   time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
+					CreatedBy:   u.UserID,
+					UpdatedBy:   u.UserID,
+				})
+				MustCreateExpenseParticipant(t, tx, db.DB, &planetscale.ExpenseParticipant{ExpenseID: e.ExpenseID, UserID: u.UserID})
+			}
+
+			got, err := NewExpenseRepo(db.DB).Find(tx, planetscale.ExpenseFilter{
+				GroupIDs: groupIDs[:2],
+			})
+			if err != nil {
+				t.Fatal(err)
+			} else if len(got) != 2 {
+				t.Fatalf("expected 2 expenses, got %d", len(got))
+			}
+
+			participants, err := NewExpenseParticipantRepo(db.DB).Find(tx, planetscale.ExpenseParticipantFilter{
+				ExpenseIDs: []int64{got[0].ExpenseID, got[1].ExpenseID},
+			})
+			if err != nil {
+				t.Fatal(err)
+			} else if len(participants) != 2 {
+				t.Fatalf("expected 2 participants, got %d", len(participants))
+			}
+
+			if got, err := NewExpenseRepo(db.DB).Find(tx, planetscale.ExpenseFilter{GroupIDs: []int64{}}); err != nil {
+				t.Fatal(err)
+			} else if len(got) != 0 {
+				t.Fatalf("expected no expenses for no groups, got %d", len(got))
+			}
+		})
 	})
 }
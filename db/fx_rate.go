@@ -0,0 +1,125 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+
+	planetscale "github.com/harshav17/planet_scale"
+)
+
+type fxRateRepo struct {
+	db *DB
+}
+
+func NewFXRateRepo(db *DB) *fxRateRepo {
+	return &fxRateRepo{
+		db: db,
+	}
+}
+
+func (r *fxRateRepo) GetAsOf(tx *sql.Tx, baseCurrency, quoteCurrency string, asOf time.Time) (*planetscale.FXRate, error) {
+	query := `
+		SELECT
+			base_currency,
+			quote_currency,
+			rate,
+			as_of
+		FROM fx_rates
+		WHERE base_currency = ? AND quote_currency = ? AND as_of <= ?
+		ORDER BY as_of DESC
+		LIMIT 1`
+
+	var rate planetscale.FXRate
+	row := tx.QueryRow(query, baseCurrency, quoteCurrency, asOf.UTC().Format(time.DateOnly))
+	err := row.Scan(&rate.BaseCurrency, &rate.QuoteCurrency, &rate.Rate, (*NullTime)(&rate.AsOf))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, planetscale.Errorf(planetscale.ENOTFOUND, "no %s/%s rate found as of %s", baseCurrency, quoteCurrency, asOf.Format(time.DateOnly))
+		}
+		return nil, err
+	}
+
+	return &rate, nil
+}
+
+func (r *fxRateRepo) Upsert(tx *sql.Tx, rate *planetscale.FXRate) error {
+	query := `
+		INSERT INTO
+			fx_rates (
+				base_currency,
+				quote_currency,
+				rate,
+				as_of
+			)
+			VALUES (?, ?, ?, ?)
+			ON DUPLICATE KEY UPDATE
+				rate = ?`
+
+	_, err := tx.Exec(query, rate.BaseCurrency, rate.QuoteCurrency, rate.Rate, rate.AsOf.UTC().Format(time.DateOnly), rate.Rate)
+	if err != nil {
+		return err
+	}
+	slog.Info("upserted fx rate", slog.String("base", rate.BaseCurrency), slog.String("quote", rate.QuoteCurrency), slog.Time("as_of", rate.AsOf))
+
+	return nil
+}
+
+func (r *fxRateRepo) Find(tx *sql.Tx, filter planetscale.FXRateFilter) ([]*planetscale.FXRate, error) {
+	where := &findWhereClause{}
+	if filter.BaseCurrency != "" {
+		where.Add("base_currency", filter.BaseCurrency)
+	}
+	if filter.QuoteCurrency != "" {
+		where.Add("quote_currency", filter.QuoteCurrency)
+	}
+
+	query := `
+		SELECT
+			base_currency,
+			quote_currency,
+			rate,
+			as_of
+		FROM fx_rates
+		` + where.ToClause() + `
+		ORDER BY as_of`
+
+	rows, err := tx.Query(query, where.values...)
+	if err != nil {
+		return nil, err
+	}
+
+	var rates []*planetscale.FXRate
+	for rows.Next() {
+		var rate planetscale.FXRate
+		err := rows.Scan(&rate.BaseCurrency, &rate.QuoteCurrency, &rate.Rate, (*NullTime)(&rate.AsOf))
+		if err != nil {
+			return nil, err
+		}
+		rates = append(rates, &rate)
+	}
+
+	return rates, nil
+}
+
+// resolveCurrency validates the currency of a new expense or settlement,
+// falling back to the base currency of its group when none was given.
+func resolveCurrency(tx *sql.Tx, currency string, groupID *int64) (string, error) {
+	if currency != "" {
+		return planetscale.NormalizeCurrency(currency)
+	}
+	if groupID == nil {
+		return planetscale.DefaultCurrency, nil
+	}
+
+	query := `SELECT base_currency FROM expense_groups WHERE group_id = ?`
+	err := tx.QueryRow(query, *groupID).Scan(&currency)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", fmt.Errorf("no expense group found with ID %d", *groupID)
+		}
+		return "", err
+	}
+	return currency, nil
+}
@@ -0,0 +1,133 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	planetscale "github.com/harshav17/planet_scale"
+)
+
+func TestFXRateRepo_All(t *testing.T) {
+	t.Parallel()
+
+	db := MustOpenDB(t)
+	defer MustCloseDB(t, db)
+	ctx := context.Background()
+
+	t.Run("GetAsOf Tests", func(t *testing.T) {
+		t.Run("latest rate on or before date", func(t *testing.T) {
+			tx, err := db.db.BeginTx(ctx, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tx.Rollback()
+
+			repo := NewFXRateRepo(db.DB)
+			for _, rate := range []*planetscale.FXRate{
+				{BaseCurrency: "EUR", QuoteCurrency: "USD", Rate: 1.1, AsOf: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+				{BaseCurrency: "EUR", QuoteCurrency: "USD", Rate: 1.2, AsOf: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+			} {
+				if err := repo.Upsert(tx, rate); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got, err := repo.GetAsOf(tx, "EUR", "USD", time.Date(2024, 1, 31, 18, 0, 0, 0, time.UTC))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Rate != 1.1 {
+				t.Fatalf("expected rate to be 1.1, got %v", got.Rate)
+			}
+			if !got.AsOf.Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)) {
+				t.Fatalf("expected as of to be 2024-01-01, got %v", got.AsOf)
+			}
+		})
+
+		t.Run("no rate before date", func(t *testing.T) {
+			tx, err := db.db.BeginTx(ctx, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tx.Rollback()
+
+			repo := NewFXRateRepo(db.DB)
+			if err := repo.Upsert(tx, &planetscale.FXRate{BaseCurrency: "EUR", QuoteCurrency: "USD", Rate: 1.1, AsOf: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = repo.GetAsOf(tx, "EUR", "USD", time.Date(2023, 12, 31, 0, 0, 0, 0, time.UTC))
+			if planetscale.ErrorCode(err) != planetscale.ENOTFOUND {
+				t.Fatalf("expected not found error, got %v", err)
+			}
+		})
+	})
+
+	t.Run("Upsert Tests", func(t *testing.T) {
+		t.Run("same day replaces rate", func(t *testing.T) {
+			tx, err := db.db.BeginTx(ctx, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tx.Rollback()
+
+			repo := NewFXRateRepo(db.DB)
+			asOf := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+			for _, rate := range []float64{1.1, 1.15} {
+				if err := repo.Upsert(tx, &planetscale.FXRate{BaseCurrency: "GBP", QuoteCurrency: "USD", Rate: rate, AsOf: asOf}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			rates, err := repo.Find(tx, planetscale.FXRateFilter{BaseCurrency: "GBP"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(rates) != 1 || rates[0].Rate != 1.15 {
+				t.Fatalf("expected a single rate of 1.15, got %+v", rates)
+			}
+		})
+	})
+
+	t.Run("Currency defaults", func(t *testing.T) {
+		t.Run("settlement uses group base currency", func(t *testing.T) {
+			tx, err := db.db.BeginTx(ctx, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tx.Rollback()
+
+			u := MustCreateUser(t, tx, db.DB, &planetscale.User{
+				UserID: "test-user-id",
+				Name:   "test user",
+			})
+			u2 := MustCreateUser(t, tx, db.DB, &planetscale.User{
+				UserID: "test-user-id-2",
+				Name:   "test user 2",
+			})
+			g := MustCreateExpenseGroup(t, tx, db.DB, &planetscale.ExpenseGroup{
+				GroupName:    "test group",
+				BaseCurrency: "eur",
+				CreateBy:     u.UserID,
+			})
+			if g.BaseCurrency != "EUR" {
+				t.Fatalf("expected base currency to be EUR, got %s", g.BaseCurrency)
+			}
+
+			s := MustCreateSettlement(t, tx, db.DB, &planetscale.Settlement{
+				GroupID: g.ExpenseGroupID,
+				PaidBy:  u.UserID,
+				PaidTo:  u2.UserID,
+				Amount:  10_00,
+			})
+			got, err := NewSettlementRepo(db.DB).Get(tx, s.SettlementID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Currency != "EUR" {
+				t.Fatalf("expected currency to be EUR, got %s", got.Currency)
+			}
+		})
+	})
+}
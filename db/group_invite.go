@@ -0,0 +1,155 @@
+package db
+
+import (
+	"database/sql"
+	"time"
+
+	planetscale "github.com/harshav17/planet_scale"
+)
+
+type groupInviteRepo struct {
+	db *DB
+}
+
+func NewGroupInviteRepo(db *DB) *groupInviteRepo {
+	return &groupInviteRepo{
+		db: db,
+	}
+}
+
+func (r *groupInviteRepo) Get(tx *sql.Tx, inviteID int64) (*planetscale.GroupInvite, error) {
+	query := `
+		SELECT
+			invite_id,
+			group_id,
+			email,
+			role,
+			created_by,
+			created_at,
+			expires_at,
+			accepted_by,
+			accepted_at
+		FROM
+			group_invites
+		WHERE
+			invite_id = ?
+	`
+
+	var invite planetscale.GroupInvite
+	row := tx.QueryRow(query, inviteID)
+	err := row.Scan(&invite.InviteID, &invite.GroupID, &invite.Email, &invite.Role, &invite.CreatedBy, (*NullTime)(&invite.CreatedAt), (*NullTime)(&invite.ExpiresAt), &invite.AcceptedBy, (*NullTime)(&invite.AcceptedAt))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, planetscale.Errorf(planetscale.ENOTFOUND, "no invite found with ID %d", inviteID)
+		}
+		return nil, err
+	}
+	return &invite, nil
+}
+
+func (r *groupInviteRepo) Create(tx *sql.Tx, invite *planetscale.GroupInvite) error {
+	if invite.Role == "" {
+		invite.Role = planetscale.RoleMember
+	}
+	invite.CreatedAt = time.Now().UTC().Truncate(time.Second)
+	if invite.ExpiresAt.IsZero() {
+		invite.ExpiresAt = invite.CreatedAt.Add(planetscale.InviteTTL)
+	}
+
+	query := `
+		INSERT INTO
+			group_invites
+			(group_id, email, role, created_by, created_at, expires_at)
+		VALUES
+			(?, ?, ?, ?, ?, ?)
+	`
+
+	result, err := tx.Exec(query, invite.GroupID, invite.Email, invite.Role, invite.CreatedBy, invite.CreatedAt, (*NullTime)(&invite.ExpiresAt))
+	if err != nil {
+		return err
+	}
+	inviteID, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	invite.InviteID = inviteID
+	return nil
+}
+
+// Accept records that userID accepted the invite. It fails with ECONFLICT
+// when the invite was already accepted.
+func (r *groupInviteRepo) Accept(tx *sql.Tx, inviteID int64, userID string) error {
+	query := `
+		UPDATE
+			group_invites
+		SET
+			accepted_by = ?,
+			accepted_at = ?
+		WHERE
+			invite_id = ? AND accepted_by IS NULL
+	`
+
+	result, err := tx.Exec(query, userID, time.Now().UTC().Truncate(time.Second), inviteID)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		// tell a missing invite apart from one that was already used
+		if _, err := r.Get(tx, inviteID); err != nil {
+			return err
+		}
+		return planetscale.Errorf(planetscale.ECONFLICT, "invite %d was already accepted", inviteID)
+	}
+	return nil
+}
+
+func (r *groupInviteRepo) Find(tx *sql.Tx, filter planetscale.GroupInviteFilter) ([]*planetscale.GroupInvite, error) {
+	where := &findWhereClause{}
+	if filter.GroupID != 0 {
+		where.Add("group_id", filter.GroupID)
+	}
+	if filter.Email != "" {
+		where.Add("email", filter.Email)
+	}
+	if filter.Pending {
+		now := time.Now().UTC().Truncate(time.Second)
+		where.AddIsNull("accepted_by")
+		where.AddOp("expires_at", ">", (*NullTime)(&now))
+	}
+
+	query := `
+		SELECT
+			invite_id,
+			group_id,
+			email,
+			role,
+			created_by,
+			created_at,
+			expires_at,
+			accepted_by,
+			accepted_at
+		FROM
+			group_invites
+		` + where.ToClause() + `
+		ORDER BY invite_id`
+
+	rows, err := tx.Query(query, where.values...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var invites []*planetscale.GroupInvite
+	for rows.Next() {
+		var invite planetscale.GroupInvite
+		if err := rows.Scan(&invite.InviteID, &invite.GroupID, &invite.Email, &invite.Role, &invite.CreatedBy, (*NullTime)(&invite.CreatedAt), (*NullTime)(&invite.ExpiresAt), &invite.AcceptedBy, (*NullTime)(&invite.AcceptedAt)); err != nil {
+			return nil, err
+		}
+		invites = append(invites, &invite)
+	}
+	return invites, rows.Err()
+}
@@ -0,0 +1,179 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	planetscale "github.com/harshav17/planet_scale"
+)
+
+func MustCreateGroupInvite(tb testing.TB, tx *sql.Tx, db *DB, i *planetscale.GroupInvite) *planetscale.GroupInvite {
+	tb.Helper()
+
+	if err := NewGroupInviteRepo(db).Create(tx, i); err != nil {
+		tb.Fatal(err)
+	}
+
+	return i
+}
+
+func TestGroupInviteRepo_All(t *testing.T) {
+	t.Parallel()
+
+	db := MustOpenDB(t)
+	defer MustCloseDB(t, db)
+	ctx := context.Background()
+
+	mustCreateGroup := func(t *testing.T, tx *sql.Tx) (*planetscale.User, *planetscale.ExpenseGroup) {
+		t.Helper()
+
+		u := MustCreateUser(t, tx, db.DB, &planetscale.User{
+			UserID: "test-user-id",
+			Name:   "test user",
+			Email:  "",
+		})
+		g := MustCreateExpenseGroup(t, tx, db.DB, &planetscale.ExpenseGroup{
+			GroupName: "test group",
+			CreateBy:  u.UserID,
+		})
+		return u, g
+	}
+
+	t.Run("Get Tests", func(t *testing.T) {
+		t.Run("invalid invite id", func(t *testing.T) {
+			tx, err := db.db.BeginTx(ctx, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tx.Rollback()
+
+			if _, err := NewGroupInviteRepo(db.DB).Get(tx, 0); planetscale.ErrorCode(err) != planetscale.ENOTFOUND {
+				t.Fatalf("expected ENOTFOUND, got %v", err)
+			}
+		})
+
+		t.Run("successful get", func(t *testing.T) {
+			tx, err := db.db.BeginTx(ctx, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tx.Rollback()
+
+			u, g := mustCreateGroup(t, tx)
+			email := "friend@example.com"
+			i := MustCreateGroupInvite(t, tx, db.DB, &planetscale.GroupInvite{
+				GroupID:   g.ExpenseGroupID,
+				Email:     &email,
+				CreatedBy: u.UserID,
+			})
+
+			i2, err := NewGroupInviteRepo(db.DB).Get(tx, i.InviteID)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if i2.GroupID != g.ExpenseGroupID {
+				t.Fatalf("expected group id %d, got %d", g.ExpenseGroupID, i2.GroupID)
+			} else if i2.Email == nil || *i2.Email != email {
+				t.Fatalf("expected email %s, got %v", email, i2.Email)
+			} else if i2.Role != planetscale.RoleMember {
+				t.Fatalf("expected role %s, got %s", planetscale.RoleMember, i2.Role)
+			} else if !i2.ExpiresAt.Equal(i.CreatedAt.Add(planetscale.InviteTTL)) {
+				t.Fatalf("expected invite to expire after %v, got %v", planetscale.InviteTTL, i2.ExpiresAt)
+			} else if i2.AcceptedBy != nil || !i2.AcceptedAt.IsZero() {
+				t.Fatalf("expected invite not to be accepted, got %v", i2.AcceptedBy)
+			}
+		})
+	})
+
+	t.Run("Accept Tests", func(t *testing.T) {
+		t.Run("invalid invite id", func(t *testing.T) {
+			tx, err := db.db.BeginTx(ctx, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tx.Rollback()
+
+			if err := NewGroupInviteRepo(db.DB).Accept(tx, 0, "test-user-id"); planetscale.ErrorCode(err) != planetscale.ENOTFOUND {
+				t.Fatalf("expected ENOTFOUND, got %v", err)
+			}
+		})
+
+		t.Run("accepted once", func(t *testing.T) {
+			tx, err := db.db.BeginTx(ctx, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tx.Rollback()
+
+			u, g := mustCreateGroup(t, tx)
+			i := MustCreateGroupInvite(t, tx, db.DB, &planetscale.GroupInvite{
+				GroupID:   g.ExpenseGroupID,
+				CreatedBy: u.UserID,
+			})
+
+			repo := NewGroupInviteRepo(db.DB)
+			if err := repo.Accept(tx, i.InviteID, u.UserID); err != nil {
+				t.Fatal(err)
+			}
+			if err := repo.Accept(tx, i.InviteID, u.UserID); planetscale.ErrorCode(err) != planetscale.ECONFLICT {
+				t.Fatalf("expected ECONFLICT, got %v", err)
+			}
+
+			i2, err := repo.Get(tx, i.InviteID)
+			if err != nil {
+				t.Fatal(err)
+			} else if i2.AcceptedBy == nil || *i2.AcceptedBy != u.UserID || i2.AcceptedAt.IsZero() {
+				t.Fatalf("expected invite to be accepted by %s, got %v", u.UserID, i2.AcceptedBy)
+			}
+		})
+	})
+
+	t.Run("Find Tests", func(t *testing.T) {
+		tx, err := db.db.BeginTx(ctx, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer tx.Rollback()
+
+		u, g := mustCreateGroup(t, tx)
+		email := "friend@example.com"
+		pending := MustCreateGroupInvite(t, tx, db.DB, &planetscale.GroupInvite{
+			GroupID:   g.ExpenseGroupID,
+			Email:     &email,
+			CreatedBy: u.UserID,
+		})
+		MustCreateGroupInvite(t, tx, db.DB, &planetscale.GroupInvite{
+			GroupID:   g.ExpenseGroupID,
+			Email:     &email,
+			CreatedBy: u.UserID,
+			ExpiresAt: time.Now().Add(-time.Hour),
+		})
+		accepted := MustCreateGroupInvite(t, tx, db.DB, &planetscale.GroupInvite{
+			GroupID:   g.ExpenseGroupID,
+			Email:     &email,
+			CreatedBy: u.UserID,
+		})
+		if err := NewGroupInviteRepo(db.DB).Accept(tx, accepted.InviteID, u.UserID); err != nil {
+			t.Fatal(err)
+		}
+
+		invites, err := NewGroupInviteRepo(db.DB).Find(tx, planetscale.GroupInviteFilter{Email: email})
+		if err != nil {
+			t.Fatal(err)
+		} else if len(invites) != 3 {
+			t.Fatalf("expected 3 invites, got %d", len(invites))
+		}
+
+		invites, err = NewGroupInviteRepo(db.DB).Find(tx, planetscale.GroupInviteFilter{Email: email, Pending: true})
+		if err != nil {
+			t.Fatal(err)
+		} else if len(invites) != 1 {
+			t.Fatalf("expected 1 pending invite, got %d", len(invites))
+		} else if invites[0].InviteID != pending.InviteID {
+			t.Fatalf("expected invite %d, got %d", pending.InviteID, invites[0].InviteID)
+		}
+	})
+}
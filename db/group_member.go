@@ -2,7 +2,6 @@ package db
 
 import (
 	"database/sql"
-	"fmt"
 	"log/slog"
 
 	planetscale "github.com/harshav17/planet_scale"
@@ -19,11 +18,25 @@ func NewGroupMemberRepo(db *DB) *groupMemberRepo {
 }
 
 func (r *groupMemberRepo) Get(tx *sql.Tx, groupID int64, userID string) (*planetscale.GroupMember, error) {
-	query := `SELECT group_id, user_id, joined_at FROM group_members WHERE group_id = ? AND user_id = ?`
+	return r.get(tx, groupID, userID, false)
+}
+
+func (r *groupMemberRepo) GetIncludingDeletedGroup(tx *sql.Tx, groupID int64, userID string) (*planetscale.GroupMember, error) {
+	return r.get(tx, groupID, userID, true)
+}
+
+func (r *groupMemberRepo) get(tx *sql.Tx, groupID int64, userID string, deletedGroup bool) (*planetscale.GroupMember, error) {
+	query := `
+		SELECT gm.group_id, gm.user_id, gm.role, gm.joined_at
+		FROM group_members gm JOIN expense_groups g ON gm.group_id = g.group_id
+		WHERE gm.group_id = ? AND gm.user_id = ? AND gm.left_at IS NULL`
+	if !deletedGroup {
+		query += ` AND g.deleted_at IS NULL`
+	}
 
 	var group planetscale.GroupMember
 	row := tx.QueryRow(query, groupID, userID)
-	err := row.Scan(&group.GroupID, &group.UserID, (*NullTime)(&group.JoinedAt))
+	err := row.Scan(&group.GroupID, &group.UserID, &group.Role, (*NullTime)(&group.JoinedAt))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			// Handle no rows error specifically if needed
@@ -37,9 +50,29 @@ func (r *groupMemberRepo) Get(tx *sql.Tx, groupID int64, userID string) (*planet
 }
 
 func (r *groupMemberRepo) Create(tx *sql.Tx, group *planetscale.GroupMember) error {
-	query := `INSERT INTO group_members (group_id, user_id) VALUES (?, ?)`
+	if group.Role == "" {
+		group.Role = planetscale.RoleMember
+	}
 
-	result, err := tx.Exec(query, group.GroupID, group.UserID)
+	// former members rejoin on their old row so that it stays unique
+	query := `UPDATE group_members SET role = ?, joined_at = CURRENT_TIMESTAMP, left_at = NULL WHERE group_id = ? AND user_id = ? AND left_at IS NOT NULL`
+
+	result, err := tx.Exec(query, group.Role, group.GroupID, group.UserID)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected > 0 {
+		slog.Info("rejoined group member", slog.Int64("id", group.GroupID))
+		return nil
+	}
+
+	query = `INSERT INTO group_members (group_id, user_id, role) VALUES (?, ?, ?)`
+
+	result, err = tx.Exec(query, group.GroupID, group.UserID, group.Role)
 	if err != nil {
 		return err
 	}
@@ -52,8 +85,21 @@ func (r *groupMemberRepo) Create(tx *sql.Tx, group *planetscale.GroupMember) err
 	return nil
 }
 
+func (r *groupMemberRepo) Update(tx *sql.Tx, groupID int64, userID string, update *planetscale.GroupMemberUpdate) (*planetscale.GroupMember, error) {
+	query := `UPDATE group_members SET role = COALESCE(?, role) WHERE group_id = ? AND user_id = ? AND left_at IS NULL`
+
+	_, err := tx.Exec(query, update.Role, groupID, userID)
+	if err != nil {
+		return nil, err
+	}
+	slog.Info("updated group member", slog.Int64("id", groupID))
+
+	// rows affected is 0 when nothing changed, so check the member exists
+	return r.Get(tx, groupID, userID)
+}
+
 func (r *groupMemberRepo) Delete(tx *sql.Tx, groupID int64, userID string) error {
-	query := `DELETE FROM group_members WHERE group_id = ? AND user_id = ?`
+	query := `UPDATE group_members SET left_at = CURRENT_TIMESTAMP WHERE group_id = ? AND user_id = ? AND left_at IS NULL`
 
 	result, err := tx.Exec(query, groupID, userID)
 	if err != nil {
@@ -64,9 +110,9 @@ func (r *groupMemberRepo) Delete(tx *sql.Tx, groupID int64, userID string) error
 		return err
 	}
 	if rowsAffected == 0 {
-		return fmt.Errorf("no group member found with ID %d", groupID)
+		return planetscale.Errorf(planetscale.ENOTFOUND, "no group member found with ID %d", groupID)
 	}
-	slog.Info("deleted group member", slog.Int64("id", groupID))
+	slog.Info("deactivated group member", slog.Int64("id", groupID))
 
 	return nil
 }
@@ -74,11 +120,12 @@ func (r *groupMemberRepo) Delete(tx *sql.Tx, groupID int64, userID string) error
 func (r *groupMemberRepo) Find(tx *sql.Tx, filter planetscale.GroupMemberFilter) ([]*planetscale.GroupMember, error) {
 	where := &findWhereClause{}
 	if filter.GroupID != 0 {
-		where.Add("group_id", filter.GroupID)
+		where.Add("gm.group_id", filter.GroupID)
 	}
+	where.AddDeleted("gm.left_at", filter.Inactive)
 
 	query := `
-		SELECT gm.group_id, gm.user_id, gm.joined_at, u.email, u.name
+		SELECT gm.group_id, gm.user_id, gm.role, gm.joined_at, gm.left_at, u.email, u.name
 		FROM group_members gm JOIN users u ON gm.user_id = u.user_id
 		` + where.ToClause()
 
@@ -91,7 +138,7 @@ func (r *groupMemberRepo) Find(tx *sql.Tx, filter planetscale.GroupMemberFilter)
 	for rows.Next() {
 		var groupMember planetscale.GroupMember
 		var user planetscale.User
-		err := rows.Scan(&groupMember.GroupID, &groupMember.UserID, (*NullTime)(&groupMember.JoinedAt), &user.Email, &user.Name)
+		err := rows.Scan(&groupMember.GroupID, &groupMember.UserID, &groupMember.Role, (*NullTime)(&groupMember.JoinedAt), (*NullTime)(&groupMember.LeftAt), &user.Email, &user.Name)
 		if err != nil {
 			return nil, err
 		}
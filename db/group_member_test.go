@@ -106,6 +106,41 @@ func TestGroupMemberRepo_All(t *testing.T) {
 				t.Fatal(err)
 			} else if got.GroupID != gm.GroupID {
 				t.Fatalf("expected group id to be %d, got %d", gm.GroupID, got.GroupID)
+			} else if got.Role != planetscale.RoleMember {
+				t.Fatalf("expected role to default to %s, got %s", planetscale.RoleMember, got.Role)
+			}
+		})
+
+		t.Run("deleted group", func(t *testing.T) {
+			tx, err := db.db.BeginTx(ctx, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tx.Rollback()
+
+			u := MustCreateUser(t, tx, db.DB, &planetscale.User{
+				UserID: "test-user-id",
+				Name:   "test user",
+				Email:  "",
+			})
+			g := MustCreateExpenseGroup(t, tx, db.DB, &planetscale.ExpenseGroup{
+				GroupName: "test group",
+				CreateBy:  u.UserID,
+			})
+			gm := MustCreateGroupMember(t, tx, db.DB, &planetscale.GroupMember{
+				GroupID: g.ExpenseGroupID,
+				UserID:  u.UserID,
+			})
+			if err := NewExpenseGroupRepo(db.DB).Delete(tx, g.ExpenseGroupID); err != nil {
+				t.Fatal(err)
+			}
+
+			repo := NewGroupMemberRepo(db.DB)
+			if _, err := repo.Get(tx, gm.GroupID, gm.UserID); planetscale.ErrorCode(err) != planetscale.ENOTFOUND {
+				t.Fatalf("expected %s, got %v", planetscale.ENOTFOUND, err)
+			}
+			if _, err := repo.GetIncludingDeletedGroup(tx, gm.GroupID, gm.UserID); err != nil {
+				t.Fatal(err)
 			}
 		})
 
@@ -123,6 +158,52 @@ func TestGroupMemberRepo_All(t *testing.T) {
 		})
 	})
 
+	t.Run("Update Tests", func(t *testing.T) {
+		t.Run("successful update", func(t *testing.T) {
+			tx, err := db.db.BeginTx(ctx, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tx.Rollback()
+
+			u := MustCreateUser(t, tx, db.DB, &planetscale.User{
+				UserID: "test-user-id",
+				Name:   "test user",
+				Email:  "",
+			})
+
+			g := MustCreateExpenseGroup(t, tx, db.DB, &planetscale.ExpenseGroup{
+				GroupName: "test group",
+				CreateBy:  u.UserID,
+			})
+
+			gm := MustCreateGroupMember(t, tx, db.DB, &planetscale.GroupMember{
+				GroupID: g.ExpenseGroupID,
+				UserID:  u.UserID,
+			})
+
+			role := planetscale.RoleAdmin
+			if got, err := NewGroupMemberRepo(db.DB).Update(tx, gm.GroupID, gm.UserID, &planetscale.GroupMemberUpdate{Role: &role}); err != nil {
+				t.Fatal(err)
+			} else if got.Role != role {
+				t.Fatalf("expected role to be %s, got %s", role, got.Role)
+			}
+		})
+
+		t.Run("invalid user id", func(t *testing.T) {
+			tx, err := db.db.BeginTx(ctx, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tx.Rollback()
+
+			role := planetscale.RoleAdmin
+			if _, err := NewGroupMemberRepo(db.DB).Update(tx, 1, "non-existent-user-id", &planetscale.GroupMemberUpdate{Role: &role}); planetscale.ErrorCode(err) != planetscale.ENOTFOUND {
+				t.Fatalf("expected ENOTFOUND, got %v", err)
+			}
+		})
+	})
+
 	t.Run("Delete Tests", func(t *testing.T) {
 		t.Run("successful delete", func(t *testing.T) {
 			tx, err := db.db.BeginTx(ctx, nil)
@@ -154,6 +235,56 @@ func TestGroupMemberRepo_All(t *testing.T) {
 			if _, err := NewGroupMemberRepo(db.DB).Get(tx, gm.GroupID, gm.UserID); err == nil {
 				t.Fatal("expected error, got nil")
 			}
+			// the row is kept as an inactive member
+			if got, err := NewGroupMemberRepo(db.DB).Find(tx, planetscale.GroupMemberFilter{
+				GroupID:  gm.GroupID,
+				Inactive: true,
+			}); err != nil {
+				t.Fatal(err)
+			} else if len(got) != 1 {
+				t.Fatalf("expected 1 inactive member, got %d", len(got))
+			} else if got[0].Active() {
+				t.Fatal("expected member to be inactive")
+			}
+		})
+
+		t.Run("rejoin after delete", func(t *testing.T) {
+			tx, err := db.db.BeginTx(ctx, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tx.Rollback()
+
+			u := MustCreateUser(t, tx, db.DB, &planetscale.User{
+				UserID: "test-user-id",
+				Name:   "test user",
+				Email:  "",
+			})
+
+			g := MustCreateExpenseGroup(t, tx, db.DB, &planetscale.ExpenseGroup{
+				GroupName: "test group",
+				CreateBy:  u.UserID,
+			})
+
+			gm := MustCreateGroupMember(t, tx, db.DB, &planetscale.GroupMember{
+				GroupID: g.ExpenseGroupID,
+				UserID:  u.UserID,
+			})
+
+			if err := NewGroupMemberRepo(db.DB).Delete(tx, gm.GroupID, gm.UserID); err != nil {
+				t.Fatal(err)
+			}
+			MustCreateGroupMember(t, tx, db.DB, &planetscale.GroupMember{
+				GroupID: g.ExpenseGroupID,
+				UserID:  u.UserID,
+				Role:    planetscale.RoleViewer,
+			})
+
+			if got, err := NewGroupMemberRepo(db.DB).Get(tx, gm.GroupID, gm.UserID); err != nil {
+				t.Fatal(err)
+			} else if got.Role != planetscale.RoleViewer {
+				t.Fatalf("expected role to be %s, got %s", planetscale.RoleViewer, got.Role)
+			}
 		})
 
 		t.Run("invalid user id", func(t *testing.T) {
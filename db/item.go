@@ -70,9 +70,9 @@ func (r *itemRepo) Update(tx *sql.Tx, itemID int64, update *planetscale.ItemUpda
 		UPDATE
 			items
 		SET
-			name = ?,
-			price = ?,
-			quantity = ?
+			name = COALESCE(?, name),
+			price = COALESCE(?, price),
+			quantity = COALESCE(?, quantity)
 		WHERE
 			item_id = ?
 	`
@@ -114,6 +114,9 @@ func (r *itemRepo) Find(tx *sql.Tx, filter planetscale.ItemFilter) ([]*planetsca
 	if filter.ExpenseID != 0 {
 		where.Add("expense_id", filter.ExpenseID)
 	}
+	if filter.ExpenseIDs != nil {
+		where.AddIn("expense_id", int64Values(filter.ExpenseIDs)...)
+	}
 
 	query := `
 		SELECT
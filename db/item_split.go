@@ -23,6 +23,7 @@ func (r *itemSplitRepo) Get(tx *sql.Tx, itemSplitID int64) (*planetscale.ItemSpl
 			item_split_id,
 			item_id,
 			user_id,
+			guest_name,
 			amount
 		FROM
 			item_splits
@@ -32,7 +33,7 @@ func (r *itemSplitRepo) Get(tx *sql.Tx, itemSplitID int64) (*planetscale.ItemSpl
 
 	var itemSplit planetscale.ItemSplit
 	row := tx.QueryRow(query, itemSplitID)
-	err := row.Scan(&itemSplit.ItemSplitID, &itemSplit.ItemID, &itemSplit.UserID, &itemSplit.Amount)
+	err := row.Scan(&itemSplit.ItemSplitID, &itemSplit.ItemID, &itemSplit.UserID, &itemSplit.GuestName, &itemSplit.Amount)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			// Handle no rows error specifically if needed
@@ -47,12 +48,12 @@ func (r *itemSplitRepo) Create(tx *sql.Tx, itemSplit *planetscale.ItemSplit) err
 	query := `
 		INSERT INTO
 			item_splits
-			(item_id, user_id, amount)
+			(item_id, user_id, guest_name, amount)
 		VALUES
-			(?, ?, ?)
+			(?, ?, ?, ?)
 	`
 
-	result, err := tx.Exec(query, itemSplit.ItemID, itemSplit.UserID, itemSplit.Amount)
+	result, err := tx.Exec(query, itemSplit.ItemID, itemSplit.UserID, itemSplit.GuestName, itemSplit.Amount)
 	if err != nil {
 		return err
 	}
@@ -69,7 +70,7 @@ func (r *itemSplitRepo) Update(tx *sql.Tx, itemSplitID int64, itemSplit *planets
 		UPDATE
 			item_splits
 		SET
-			amount = ?
+			amount = COALESCE(?, amount)
 		WHERE
 			item_split_id = ?
 	`
@@ -88,6 +89,36 @@ func (r *itemSplitRepo) Update(tx *sql.Tx, itemSplitID int64, itemSplit *planets
 	return r.Get(tx, itemSplitID)
 }
 
+// Claim hands a guest split over to userID. It fails with ECONFLICT when the
+// split is already held by a user.
+func (r *itemSplitRepo) Claim(tx *sql.Tx, itemSplitID int64, userID string) (*planetscale.ItemSplit, error) {
+	query := `
+		UPDATE
+			item_splits
+		SET
+			user_id = ?
+		WHERE
+			item_split_id = ?
+			AND user_id IS NULL
+	`
+
+	result, err := tx.Exec(query, userID, itemSplitID)
+	if err != nil {
+		return nil, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if rowsAffected == 0 {
+		if _, err := r.Get(tx, itemSplitID); err != nil {
+			return nil, planetscale.Errorf(planetscale.ENOTFOUND, "no item split found with ID %d", itemSplitID)
+		}
+		return nil, planetscale.Errorf(planetscale.ECONFLICT, "item split %d is already claimed", itemSplitID)
+	}
+	return r.Get(tx, itemSplitID)
+}
+
 func (r *itemSplitRepo) Delete(tx *sql.Tx, itemSplitID int64) error {
 	query := `
 		DELETE FROM
@@ -115,12 +146,16 @@ func (r *itemSplitRepo) Find(tx *sql.Tx, filter planetscale.ItemSplitFilter) ([]
 	if filter.ItemID != 0 {
 		where.Add("item_id", filter.ItemID)
 	}
+	if filter.ItemIDs != nil {
+		where.AddIn("item_id", int64Values(filter.ItemIDs)...)
+	}
 
 	query := `
 		SELECT
 			item_split_id,
 			item_id,
 			user_id,
+			guest_name,
 			amount
 		FROM
 			item_splits
@@ -134,7 +169,7 @@ func (r *itemSplitRepo) Find(tx *sql.Tx, filter planetscale.ItemSplitFilter) ([]
 	var itemSplits []*planetscale.ItemSplit
 	for rows.Next() {
 		var itemSplit planetscale.ItemSplit
-		if err := rows.Scan(&itemSplit.ItemSplitID, &itemSplit.ItemID, &itemSplit.UserID, &itemSplit.Amount); err != nil {
+		if err := rows.Scan(&itemSplit.ItemSplitID, &itemSplit.ItemID, &itemSplit.UserID, &itemSplit.GuestName, &itemSplit.Amount); err != nil {
 			return nil, err
 		}
 		itemSplits = append(itemSplits, &itemSplit)
@@ -59,7 +59,7 @@ func TestItemSplitRepo_All(t *testing.T) {
 				GroupID:     &g.ExpenseGroupID,
 				PaidBy:      u.UserID,
 				SplitTypeID: 1,
-				Amount:      100,
+				Amount:      100_00,
 				Description: "test expense",
 				Timestamp:   time.Now(),
 				CreatedBy:   u.UserID,
@@ -67,14 +67,14 @@ func TestItemSplitRepo_All(t *testing.T) {
 			})
 			i := MustCreateItem(t, tx, db.DB, &planetscale.Item{
 				Name:      "test item",
-				Price:     100,
+				Price:     100_00,
 				Quantity:  1,
 				ExpenseID: e.ExpenseID,
 			})
 			is := MustCreateItemSplit(t, tx, db.DB, &planetscale.ItemSplit{
 				ItemID: i.ItemID,
-				UserID: u.UserID,
-				Amount: 100,
+				UserID: &u.UserID,
+				Amount: 100_00,
 			})
 
 			is2, err := NewItemSplitRepo(db.DB).Get(tx, is.ItemSplitID)
@@ -86,10 +86,10 @@ func TestItemSplitRepo_All(t *testing.T) {
 				t.Fatalf("expected item split id %d, got %d", is.ItemSplitID, is2.ItemSplitID)
 			} else if is2.ItemID != is.ItemID {
 				t.Fatalf("expected item id %d, got %d", is.ItemID, is2.ItemID)
-			} else if is2.UserID != is.UserID {
-				t.Fatalf("expected user id %s, got %s", is.UserID, is2.UserID)
+			} else if *is2.UserID != *is.UserID {
+				t.Fatalf("expected user id %s, got %s", *is.UserID, *is2.UserID)
 			} else if is2.Amount != is.Amount {
-				t.Fatalf("expected amount %f, got %f", is.Amount, is2.Amount)
+				t.Fatalf("expected amount %s, got %s", is.Amount, is2.Amount)
 			}
 		})
 	})
@@ -127,7 +127,7 @@ func TestItemSplitRepo_All(t *testing.T) {
 				GroupID:     &g.ExpenseGroupID,
 				PaidBy:      u.UserID,
 				SplitTypeID: 1,
-				Amount:      100,
+				Amount:      100_00,
 				Description: "test expense",
 				Timestamp:   time.Now(),
 				CreatedBy:   u.UserID,
@@ -135,17 +135,17 @@ func TestItemSplitRepo_All(t *testing.T) {
 			})
 			i := MustCreateItem(t, tx, db.DB, &planetscale.Item{
 				Name:      "test item",
-				Price:     100,
+				Price:     100_00,
 				Quantity:  1,
 				ExpenseID: e.ExpenseID,
 			})
 			is := MustCreateItemSplit(t, tx, db.DB, &planetscale.ItemSplit{
 				ItemID: i.ItemID,
-				UserID: u.UserID,
-				Amount: 100,
+				UserID: &u.UserID,
+				Amount: 100_00,
 			})
 
-			amount := 200.0
+			amount := planetscale.Money(200_00)
 			is2, err := NewItemSplitRepo(db.DB).Update(tx, is.ItemSplitID, &planetscale.ItemSplitUpdate{
 				Amount: &amount,
 			})
@@ -157,10 +157,10 @@ func TestItemSplitRepo_All(t *testing.T) {
 				t.Fatalf("expected item split id %d, got %d", is.ItemSplitID, is2.ItemSplitID)
 			} else if is2.ItemID != is.ItemID {
 				t.Fatalf("expected item id %d, got %d", is.ItemID, is2.ItemID)
-			} else if is2.UserID != is.UserID {
-				t.Fatalf("expected user id %s, got %s", is.UserID, is2.UserID)
+			} else if *is2.UserID != *is.UserID {
+				t.Fatalf("expected user id %s, got %s", *is.UserID, *is2.UserID)
 			} else if is2.Amount != amount {
-				t.Fatalf("expected amount %f, got %f", amount, is2.Amount)
+				t.Fatalf("expected amount %s, got %s", amount, is2.Amount)
 			}
 		})
 	})
@@ -198,7 +198,7 @@ func TestItemSplitRepo_All(t *testing.T) {
 				GroupID:     &g.ExpenseGroupID,
 				PaidBy:      u.UserID,
 				SplitTypeID: 1,
-				Amount:      100,
+				Amount:      100_00,
 				Description: "test expense",
 				Timestamp:   time.Now(),
 				CreatedBy:   u.UserID,
@@ -206,14 +206,14 @@ func TestItemSplitRepo_All(t *testing.T) {
 			})
 			i := MustCreateItem(t, tx, db.DB, &planetscale.Item{
 				Name:      "test item",
-				Price:     100,
+				Price:     100_00,
 				Quantity:  1,
 				ExpenseID: e.ExpenseID,
 			})
 			is := MustCreateItemSplit(t, tx, db.DB, &planetscale.ItemSplit{
 				ItemID: i.ItemID,
-				UserID: u.UserID,
-				Amount: 100,
+				UserID: &u.UserID,
+				Amount: 100_00,
 			})
 
 			if err := NewItemSplitRepo(db.DB).Delete(tx, is.ItemSplitID); err != nil {
@@ -260,7 +260,7 @@ func TestItemSplitRepo_All(t *testing.T) {
 				GroupID:     &g.ExpenseGroupID,
 				PaidBy:      u.UserID,
 				SplitTypeID: 1,
-				Amount:      100,
+				Amount:      100_00,
 				Description: "test expense",
 				Timestamp:   time.Now(),
 				CreatedBy:   u.UserID,
@@ -268,14 +268,14 @@ func TestItemSplitRepo_All(t *testing.T) {
 			})
 			i := MustCreateItem(t, tx, db.DB, &planetscale.Item{
 				Name:      "test item",
-				Price:     100,
+				Price:     100_00,
 				Quantity:  1,
 				ExpenseID: e.ExpenseID,
 			})
 			is := MustCreateItemSplit(t, tx, db.DB, &planetscale.ItemSplit{
 				ItemID: i.ItemID,
-				UserID: u.UserID,
-				Amount: 100,
+				UserID: &u.UserID,
+				Amount: 100_00,
 			})
 
 			filter := planetscale.ItemSplitFilter{
@@ -292,10 +292,78 @@ func TestItemSplitRepo_All(t *testing.T) {
 				t.Fatalf("expected item split id %d, got %d", is.ItemSplitID, itemSplits[0].ItemSplitID)
 			} else if itemSplits[0].ItemID != is.ItemID {
 				t.Fatalf("expected item id %d, got %d", is.ItemID, itemSplits[0].ItemID)
-			} else if itemSplits[0].UserID != is.UserID {
-				t.Fatalf("expected user id %s, got %s", is.UserID, itemSplits[0].UserID)
+			} else if *itemSplits[0].UserID != *is.UserID {
+				t.Fatalf("expected user id %s, got %s", *is.UserID, *itemSplits[0].UserID)
 			} else if itemSplits[0].Amount != is.Amount {
-				t.Fatalf("expected amount %f, got %f", is.Amount, itemSplits[0].Amount)
+				t.Fatalf("expected amount %s, got %s", is.Amount, itemSplits[0].Amount)
+			}
+		})
+	})
+	t.Run("Claim Tests", func(t *testing.T) {
+		t.Run("guest split is claimed once", func(t *testing.T) {
+			tx, err := db.db.BeginTx(ctx, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tx.Rollback()
+
+			u := MustCreateUser(t, tx, db.DB, &planetscale.User{
+				UserID: "test-user-id",
+				Name:   "test user",
+				Email:  "",
+			})
+			g := MustCreateExpenseGroup(t, tx, db.DB, &planetscale.ExpenseGroup{
+				GroupName: "test group",
+				CreateBy:  u.UserID,
+			})
+			e := MustCreateExpense(t, tx, db.DB, &planetscale.Expense{
+				GroupID:     &g.ExpenseGroupID,
+				PaidBy:      u.UserID,
+				SplitTypeID: 3,
+				Amount:      100_00,
+				Description: "test expense",
+				Timestamp:   time.Now(),
+				CreatedBy:   u.UserID,
+				UpdatedBy:   u.UserID,
+			})
+			i := MustCreateItem(t, tx, db.DB, &planetscale.Item{
+				Name:      "test item",
+				Price:     100_00,
+				Quantity:  1,
+				ExpenseID: e.ExpenseID,
+			})
+			guest := "AB"
+			is := MustCreateItemSplit(t, tx, db.DB, &planetscale.ItemSplit{
+				ItemID:    i.ItemID,
+				GuestName: &guest,
+				Amount:    100_00,
+			})
+
+			claimed, err := NewItemSplitRepo(db.DB).Claim(tx, is.ItemSplitID, u.UserID)
+			if err != nil {
+				t.Fatal(err)
+			} else if claimed.UserID == nil || *claimed.UserID != u.UserID {
+				t.Fatalf("expected split to be claimed by %s, got %v", u.UserID, claimed.UserID)
+			} else if claimed.GuestName == nil || *claimed.GuestName != guest {
+				t.Fatalf("expected guest name to be kept, got %v", claimed.GuestName)
+			}
+
+			_, err = NewItemSplitRepo(db.DB).Claim(tx, is.ItemSplitID, u.UserID)
+			if planetscale.ErrorCode(err) != planetscale.ECONFLICT {
+				t.Fatalf("expected conflict error, got %v", err)
+			}
+		})
+
+		t.Run("invalid item split id", func(t *testing.T) {
+			tx, err := db.db.BeginTx(ctx, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tx.Rollback()
+
+			_, err = NewItemSplitRepo(db.DB).Claim(tx, 0, "test-user-id")
+			if planetscale.ErrorCode(err) != planetscale.ENOTFOUND {
+				t.Fatalf("expected not found error, got %v", err)
 			}
 		})
 	})
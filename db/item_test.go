@@ -59,7 +59,7 @@ func TestItemRepo_All(t *testing.T) {
 				GroupID:     &g.ExpenseGroupID,
 				PaidBy:      u.UserID,
 				SplitTypeID: 1,
-				Amount:      100,
+				Amount:      100_00,
 				Description: "test expense",
 				Timestamp:   time.Now(),
 				CreatedBy:   u.UserID,
@@ -67,7 +67,7 @@ func TestItemRepo_All(t *testing.T) {
 			})
 			i := MustCreateItem(t, tx, db.DB, &planetscale.Item{
 				Name:      "test item",
-				Price:     100,
+				Price:     100_00,
 				Quantity:  1,
 				ExpenseID: e.ExpenseID,
 			})
@@ -81,7 +81,7 @@ func TestItemRepo_All(t *testing.T) {
 			} else if got.Name != i.Name {
 				t.Errorf("expected item name %s, got %s", i.Name, got.Name)
 			} else if got.Price != i.Price {
-				t.Errorf("expected item price %f, got %f", i.Price, got.Price)
+				t.Errorf("expected item price %s, got %s", i.Price, got.Price)
 			} else if got.Quantity != i.Quantity {
 				t.Errorf("expected item quantity %d, got %d", i.Quantity, got.Quantity)
 			} else if got.ExpenseID != i.ExpenseID {
@@ -110,7 +110,7 @@ func TestItemRepo_All(t *testing.T) {
 			GroupID:     &g.ExpenseGroupID,
 			PaidBy:      u.UserID,
 			SplitTypeID: 1,
-			Amount:      100,
+			Amount:      100_00,
 			Description: "test expense",
 			Timestamp:   time.Now(),
 			CreatedBy:   u.UserID,
@@ -118,13 +118,13 @@ func TestItemRepo_All(t *testing.T) {
 		})
 		i := MustCreateItem(t, tx, db.DB, &planetscale.Item{
 			Name:      "test item",
-			Price:     100,
+			Price:     100_00,
 			Quantity:  1,
 			ExpenseID: e.ExpenseID,
 		})
 
 		updateName := "updated item"
-		updatePrice := float64(200)
+		updatePrice := planetscale.Money(200_00)
 		updateQuantity := int64(2)
 		update := &planetscale.ItemUpdate{
 			Name:     &updateName,
@@ -140,7 +140,7 @@ func TestItemRepo_All(t *testing.T) {
 		} else if got.Name != updateName {
 			t.Errorf("expected item name %s, got %s", updateName, got.Name)
 		} else if got.Price != updatePrice {
-			t.Errorf("expected item price %f, got %f", updatePrice, got.Price)
+			t.Errorf("expected item price %s, got %s", updatePrice, got.Price)
 		} else if got.Quantity != updateQuantity {
 			t.Errorf("expected item quantity %d, got %d", update.Quantity, got.Quantity)
 		} else if got.ExpenseID != i.ExpenseID {
@@ -181,7 +181,7 @@ func TestItemRepo_All(t *testing.T) {
 				GroupID:     &g.ExpenseGroupID,
 				PaidBy:      u.UserID,
 				SplitTypeID: 1,
-				Amount:      100,
+				Amount:      100_00,
 				Description: "test expense",
 				Timestamp:   time.Now(),
 				CreatedBy:   u.UserID,
@@ -189,7 +189,7 @@ func TestItemRepo_All(t *testing.T) {
 			})
 			i := MustCreateItem(t, tx, db.DB, &planetscale.Item{
 				Name:      "test item",
-				Price:     100,
+				Price:     100_00,
 				Quantity:  1,
 				ExpenseID: e.ExpenseID,
 			})
@@ -221,7 +221,7 @@ func TestItemRepo_All(t *testing.T) {
 				GroupID:     &g.ExpenseGroupID,
 				PaidBy:      u.UserID,
 				SplitTypeID: 1,
-				Amount:      100,
+				Amount:      100_00,
 				Description: "test expense",
 				Timestamp:   time.Now(),
 				CreatedBy:   u.UserID,
@@ -229,7 +229,7 @@ func TestItemRepo_All(t *testing.T) {
 			})
 			i := MustCreateItem(t, tx, db.DB, &planetscale.Item{
 				Name:      "test item",
-				Price:     100,
+				Price:     100_00,
 				Quantity:  1,
 				ExpenseID: e.ExpenseID,
 			})
@@ -248,7 +248,7 @@ func TestItemRepo_All(t *testing.T) {
 			} else if items[0].Name != i.Name {
 				t.Errorf("expected item name %s, got %s", i.Name, items[0].Name)
 			} else if items[0].Price != i.Price {
-				t.Errorf("expected item price %f, got %f", i.Price, items[0].Price)
+				t.Errorf("expected item price %s, got %s", i.Price, items[0].Price)
 			} else if items[0].Quantity != i.Quantity {
 				t.Errorf("expected item quantity %d, got %d", i.Quantity, items[0].Quantity)
 			} else if items[0].ExpenseID != i.ExpenseID {
@@ -0,0 +1 @@
+ALTER TABLE expense_participants DROP COLUMN shares;
@@ -0,0 +1 @@
+ALTER TABLE expense_participants ADD COLUMN shares INT NOT NULL DEFAULT 0 AFTER share_percentage; -- Number of shares the user holds for ShareBased splits
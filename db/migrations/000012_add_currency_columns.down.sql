@@ -0,0 +1,3 @@
+ALTER TABLE settlements DROP COLUMN currency;
+ALTER TABLE expenses DROP COLUMN currency;
+ALTER TABLE expense_groups DROP COLUMN base_currency;
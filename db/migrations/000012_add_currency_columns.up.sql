@@ -0,0 +1,3 @@
+ALTER TABLE expense_groups ADD COLUMN base_currency CHAR(3) NOT NULL DEFAULT 'USD' AFTER group_name;
+ALTER TABLE expenses ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'USD' AFTER amount;
+ALTER TABLE settlements ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'USD' AFTER amount;
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS fx_rates (
+    base_currency CHAR(3) NOT NULL,
+    quote_currency CHAR(3) NOT NULL,
+    rate DECIMAL(19,8) NOT NULL, -- price of one unit of base_currency in quote_currency
+    as_of DATE NOT NULL,
+    PRIMARY KEY (base_currency, quote_currency, as_of)
+);
@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS recurring_expenses (
+    recurring_expense_id INT AUTO_INCREMENT PRIMARY KEY,
+    group_id INT NOT NULL,
+    split_type_id INT NOT NULL,
+    paid_by VARCHAR(255) NOT NULL,
+    amount DECIMAL(19,4) NOT NULL,
+    currency CHAR(3) NOT NULL DEFAULT 'USD',
+    description VARCHAR(100),
+    participants JSON, -- template participants copied onto every occurrence
+    rule VARCHAR(255) NOT NULL, -- RRULE-like schedule, e.g. FREQ=MONTHLY;INTERVAL=1
+    start_at TIMESTAMP NOT NULL,
+    next_occurrence_at TIMESTAMP NULL, -- NULL once the schedule has ended
+    occurrence_count INT NOT NULL DEFAULT 0,
+    created_by VARCHAR(255),
+    updated_by VARCHAR(255),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX idx_recurring_expenses_next_occurrence_at (next_occurrence_at),
+    FOREIGN KEY (group_id) REFERENCES expense_groups(group_id),
+    FOREIGN KEY (split_type_id) REFERENCES split_types(split_type_id),
+    FOREIGN KEY (paid_by) REFERENCES users(user_id),
+    FOREIGN KEY (created_by) REFERENCES users(user_id),
+    FOREIGN KEY (updated_by) REFERENCES users(user_id)
+);
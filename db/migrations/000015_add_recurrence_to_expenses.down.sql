@@ -0,0 +1,4 @@
+ALTER TABLE expenses DROP FOREIGN KEY fk_expenses_recurring_expense;
+ALTER TABLE expenses DROP INDEX uq_expenses_recurrence;
+ALTER TABLE expenses DROP COLUMN recurrence_index;
+ALTER TABLE expenses DROP COLUMN recurring_expense_id;
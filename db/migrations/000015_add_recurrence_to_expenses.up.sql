@@ -0,0 +1,5 @@
+ALTER TABLE expenses ADD COLUMN recurring_expense_id INT NULL AFTER split_type_id;
+ALTER TABLE expenses ADD COLUMN recurrence_index INT NOT NULL DEFAULT 0 AFTER recurring_expense_id;
+-- each occurrence of a recurring expense can only be materialized once
+ALTER TABLE expenses ADD CONSTRAINT uq_expenses_recurrence UNIQUE (recurring_expense_id, recurrence_index);
+ALTER TABLE expenses ADD CONSTRAINT fk_expenses_recurring_expense FOREIGN KEY (recurring_expense_id) REFERENCES recurring_expenses(recurring_expense_id) ON DELETE SET NULL;
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS audit_events (
+    audit_event_id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    group_id INT NULL, -- no foreign key, history must outlive the group
+    entity_type VARCHAR(50) NOT NULL,
+    entity_id VARCHAR(255) NOT NULL,
+    action VARCHAR(20) NOT NULL,
+    actor_id VARCHAR(255) NOT NULL,
+    before_data JSON NULL, -- NULL for creates
+    after_data JSON NULL, -- NULL for deletes
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_audit_events_group (group_id, audit_event_id),
+    INDEX idx_audit_events_entity (entity_type, entity_id, audit_event_id)
+);
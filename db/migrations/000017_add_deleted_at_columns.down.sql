@@ -0,0 +1,3 @@
+ALTER TABLE expense_groups DROP COLUMN deleted_at;
+ALTER TABLE settlements DROP COLUMN deleted_at;
+ALTER TABLE expenses DROP COLUMN deleted_at;
@@ -0,0 +1,3 @@
+ALTER TABLE expenses ADD COLUMN deleted_at TIMESTAMP NULL;
+ALTER TABLE settlements ADD COLUMN deleted_at TIMESTAMP NULL;
+ALTER TABLE expense_groups ADD COLUMN deleted_at TIMESTAMP NULL;
@@ -0,0 +1,3 @@
+ALTER TABLE expense_participants DROP INDEX ft_expense_participants_note;
+ALTER TABLE items DROP INDEX ft_items_name;
+ALTER TABLE expenses DROP INDEX ft_expenses_description;
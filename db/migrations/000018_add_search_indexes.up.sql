@@ -0,0 +1,3 @@
+ALTER TABLE expenses ADD FULLTEXT INDEX ft_expenses_description (description);
+ALTER TABLE items ADD FULLTEXT INDEX ft_items_name (name);
+ALTER TABLE expense_participants ADD FULLTEXT INDEX ft_expense_participants_note (note);
@@ -0,0 +1,4 @@
+ALTER TABLE expenses DROP COLUMN discount;
+ALTER TABLE expenses DROP COLUMN service_charge;
+ALTER TABLE expenses DROP COLUMN tip;
+ALTER TABLE expenses DROP COLUMN tax;
@@ -0,0 +1,5 @@
+-- receipt level adjustments of item based expenses, allocated over the items
+ALTER TABLE expenses ADD COLUMN tax DECIMAL(19,4) NOT NULL DEFAULT 0 AFTER amount;
+ALTER TABLE expenses ADD COLUMN tip DECIMAL(19,4) NOT NULL DEFAULT 0 AFTER tax;
+ALTER TABLE expenses ADD COLUMN service_charge DECIMAL(19,4) NOT NULL DEFAULT 0 AFTER tip;
+ALTER TABLE expenses ADD COLUMN discount DECIMAL(19,4) NOT NULL DEFAULT 0 AFTER service_charge;
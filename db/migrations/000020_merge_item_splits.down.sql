@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS item_splits_nu (
+    item_split_id INT AUTO_INCREMENT PRIMARY KEY,
+    item_id INT NOT NULL,
+    user_id VARCHAR(255),
+    initials VARCHAR(5),
+    amount DECIMAL(19,4) NOT NULL,
+    FOREIGN KEY (item_id) REFERENCES items (item_id),
+    FOREIGN KEY (user_id) REFERENCES users (user_id)
+);
+
+-- guest splits cannot be held by item_splits without a user
+INSERT INTO item_splits_nu (item_id, user_id, initials, amount)
+SELECT item_id, NULL, LEFT(guest_name, 5), amount
+FROM item_splits
+WHERE user_id IS NULL;
+
+DELETE FROM item_splits WHERE user_id IS NULL;
+ALTER TABLE item_splits DROP COLUMN guest_name;
+ALTER TABLE item_splits MODIFY user_id VARCHAR(255) NOT NULL;
@@ -0,0 +1,9 @@
+-- splits are held by a user or by a named guest that a user can claim later
+ALTER TABLE item_splits MODIFY user_id VARCHAR(255) NULL;
+ALTER TABLE item_splits ADD COLUMN guest_name VARCHAR(100) NULL AFTER user_id;
+
+INSERT INTO item_splits (item_id, user_id, guest_name, amount)
+SELECT item_id, user_id, CASE WHEN user_id IS NULL THEN initials END, amount
+FROM item_splits_nu;
+
+DROP TABLE item_splits_nu;
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS attachments (
+    attachment_id INT AUTO_INCREMENT PRIMARY KEY,
+    expense_id INT NOT NULL,
+    file_name VARCHAR(255) NOT NULL,
+    content_type VARCHAR(100) NOT NULL,
+    size BIGINT NOT NULL,
+    storage_key VARCHAR(255) NOT NULL UNIQUE, -- where the file lives in the blob store
+    created_by VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (expense_id) REFERENCES expenses (expense_id),
+    FOREIGN KEY (created_by) REFERENCES users(user_id)
+);
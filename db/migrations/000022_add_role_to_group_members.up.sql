@@ -0,0 +1,22 @@
+ALTER TABLE group_members ADD COLUMN role VARCHAR(20) NOT NULL DEFAULT 'member'; -- owner, admin, member or viewer
+
+-- the creator of a group has owned it so far
+UPDATE group_members gm
+JOIN expense_groups g ON g.group_id = gm.group_id
+SET gm.role = 'owner'
+WHERE gm.user_id = g.created_by;
+
+-- groups their creator has left are owned by the member who joined first
+UPDATE group_members gm
+JOIN (
+    SELECT m.group_id, MIN(m.user_id) AS user_id
+    FROM group_members m
+    JOIN (
+        SELECT group_id, MIN(joined_at) AS joined_at
+        FROM group_members
+        GROUP BY group_id
+        HAVING SUM(role = 'owner') = 0
+    ) earliest ON earliest.group_id = m.group_id AND earliest.joined_at = m.joined_at
+    GROUP BY m.group_id
+) first_member ON first_member.group_id = gm.group_id AND first_member.user_id = gm.user_id
+SET gm.role = 'owner';
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS group_invites (
+    invite_id INT AUTO_INCREMENT PRIMARY KEY,
+    group_id INT NOT NULL,
+    email VARCHAR(255), -- NULL for shareable links
+    role VARCHAR(20) NOT NULL DEFAULT 'member',
+    created_by VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL,
+    accepted_by VARCHAR(255),
+    accepted_at TIMESTAMP NULL,
+    INDEX idx_group_invites_email (email),
+    FOREIGN KEY (group_id) REFERENCES expense_groups(group_id),
+    FOREIGN KEY (created_by) REFERENCES users(user_id),
+    FOREIGN KEY (accepted_by) REFERENCES users(user_id)
+);
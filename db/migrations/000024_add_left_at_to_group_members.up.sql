@@ -0,0 +1 @@
+ALTER TABLE group_members ADD COLUMN left_at TIMESTAMP NULL; -- set once a member leaves, NULL while active
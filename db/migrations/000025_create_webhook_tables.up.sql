@@ -0,0 +1,46 @@
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+    subscription_id INT AUTO_INCREMENT PRIMARY KEY,
+    group_id INT, -- NULL for the events of every group of user_id
+    user_id VARCHAR(255) NOT NULL,
+    url VARCHAR(2048) NOT NULL,
+    secret VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP NULL,
+    INDEX idx_webhook_subscriptions_user (user_id),
+    FOREIGN KEY (group_id) REFERENCES expense_groups(group_id),
+    FOREIGN KEY (user_id) REFERENCES users(user_id)
+);
+
+-- the outbox, written in the same transaction as the change it describes
+CREATE TABLE IF NOT EXISTS webhook_events (
+    event_id INT AUTO_INCREMENT PRIMARY KEY,
+    group_id INT,
+    event_type VARCHAR(50) NOT NULL, -- e.g. expense.created
+    actor_id VARCHAR(255) NOT NULL,
+    data JSON,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (group_id) REFERENCES expense_groups(group_id)
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    delivery_id INT AUTO_INCREMENT PRIMARY KEY,
+    event_id INT NOT NULL,
+    subscription_id INT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending', -- pending, delivered or failed
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    delivered_at TIMESTAMP NULL,
+    INDEX idx_webhook_deliveries_due (status, next_attempt_at),
+    FOREIGN KEY (event_id) REFERENCES webhook_events(event_id),
+    FOREIGN KEY (subscription_id) REFERENCES webhook_subscriptions(subscription_id)
+);
+
+CREATE TABLE IF NOT EXISTS webhook_delivery_attempts (
+    attempt_id INT AUTO_INCREMENT PRIMARY KEY,
+    delivery_id INT NOT NULL,
+    status_code INT, -- NULL when no response was received
+    error TEXT,
+    duration_ms INT NOT NULL,
+    attempted_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (delivery_id) REFERENCES webhook_deliveries(delivery_id)
+);
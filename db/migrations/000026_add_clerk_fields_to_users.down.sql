@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS clerk_messages;
+ALTER TABLE users DROP COLUMN deleted_at;
+ALTER TABLE users DROP COLUMN image_url;
@@ -0,0 +1,7 @@
+ALTER TABLE users ADD COLUMN image_url VARCHAR(1024);
+ALTER TABLE users ADD COLUMN deleted_at TIMESTAMP NULL; -- set once the account is deleted, the row is anonymized but kept for the ledger
+CREATE TABLE IF NOT EXISTS clerk_messages (
+    message_id VARCHAR(255) PRIMARY KEY, -- svix-id of the webhook
+    event_type VARCHAR(50) NOT NULL,
+    processed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS activity_reads;
+DROP TABLE IF EXISTS activities;
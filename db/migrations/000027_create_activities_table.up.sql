@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS activities (
+    activity_id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    group_id INT NULL, -- NULL for direct expenses, no foreign key so the feed outlives the group
+    expense_id INT NULL, -- set for expense and item activities
+    entity_type VARCHAR(50) NOT NULL,
+    entity_id VARCHAR(255) NOT NULL,
+    action VARCHAR(20) NOT NULL,
+    actor_id VARCHAR(255) NOT NULL,
+    subject VARCHAR(255) NOT NULL DEFAULT '',
+    user_id VARCHAR(255) NOT NULL DEFAULT '',
+    amount DECIMAL(19,4) NOT NULL DEFAULT 0,
+    currency CHAR(3) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_activities_group (group_id, created_at, activity_id),
+    INDEX idx_activities_expense (expense_id)
+);
+
+-- the last activity a user read, per group and in their own feed (group_id 0)
+CREATE TABLE IF NOT EXISTS activity_reads (
+    user_id VARCHAR(255) NOT NULL,
+    group_id INT NOT NULL,
+    activity_id BIGINT NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, group_id)
+);
@@ -0,0 +1,8 @@
+-- users without a row get every email
+CREATE TABLE IF NOT EXISTS notification_preferences (
+    user_id VARCHAR(255) PRIMARY KEY,
+    expense_added BOOLEAN NOT NULL DEFAULT TRUE,
+    digest BOOLEAN NOT NULL DEFAULT TRUE,
+    digest_sent_at TIMESTAMP NULL,
+    updated_at TIMESTAMP NULL -- last changed by the user
+);
@@ -0,0 +1,2 @@
+ALTER TABLE recurring_expenses DROP COLUMN failure;
+ALTER TABLE recurring_expenses DROP COLUMN paused_at;
@@ -0,0 +1,2 @@
+ALTER TABLE recurring_expenses ADD COLUMN paused_at TIMESTAMP NULL; -- set once an occurrence cannot be created, cleared by the next update
+ALTER TABLE recurring_expenses ADD COLUMN failure TEXT;
@@ -0,0 +1,136 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+
+	planetscale "github.com/harshav17/planet_scale"
+)
+
+type notificationPreferencesRepo struct {
+	db *DB
+}
+
+func NewNotificationPreferencesRepo(db *DB) *notificationPreferencesRepo {
+	return &notificationPreferencesRepo{
+		db: db,
+	}
+}
+
+// notificationPreferencesQuery selects the preferences of users, the
+// defaults for those without a row.
+const notificationPreferencesQuery = `
+	SELECT
+		u.user_id,
+		COALESCE(np.expense_added, TRUE),
+		COALESCE(np.digest, TRUE),
+		np.digest_sent_at,
+		np.updated_at,
+		u.email,
+		u.name
+	FROM users u
+	LEFT JOIN notification_preferences np ON np.user_id = u.user_id
+	`
+
+func (r *notificationPreferencesRepo) Get(tx *sql.Tx, userID string) (*planetscale.NotificationPreferences, error) {
+	where := &findWhereClause{}
+	where.Add("u.user_id", userID)
+
+	preferences, err := r.find(tx, where, "")
+	if err != nil {
+		return nil, err
+	}
+	if len(preferences) == 0 {
+		return nil, planetscale.Errorf(planetscale.ENOTFOUND, "no user found with ID %s", userID)
+	}
+	return preferences[0], nil
+}
+
+func (r *notificationPreferencesRepo) Update(tx *sql.Tx, userID string, update *planetscale.NotificationPreferencesUpdate) (*planetscale.NotificationPreferences, error) {
+	preferences, err := r.Get(tx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if update.ExpenseAdded != nil {
+		preferences.ExpenseAdded = *update.ExpenseAdded
+	}
+	if update.Digest != nil {
+		preferences.Digest = *update.Digest
+	}
+	preferences.UpdatedAt = time.Now().UTC().Truncate(time.Second)
+
+	query := `
+		INSERT INTO notification_preferences (user_id, expense_added, digest, updated_at)
+		VALUES (?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+			expense_added = VALUES(expense_added),
+			digest = VALUES(digest),
+			updated_at = VALUES(updated_at)`
+
+	_, err = tx.Exec(query, userID, preferences.ExpenseAdded, preferences.Digest, (*NullTime)(&preferences.UpdatedAt))
+	if err != nil {
+		return nil, err
+	}
+	slog.Info("updated notification preferences", slog.String("user_id", userID))
+
+	return preferences, nil
+}
+
+func (r *notificationPreferencesRepo) Find(tx *sql.Tx, filter planetscale.NotificationPreferencesFilter) ([]*planetscale.NotificationPreferences, error) {
+	where := &findWhereClause{}
+	where.AddDeleted("u.deleted_at", false)
+	if filter.UserIDs != nil {
+		where.AddIn("u.user_id", stringValues(filter.UserIDs)...)
+	}
+	if !filter.DigestDueAt.IsZero() {
+		sentBefore := filter.DigestDueAt.Add(-planetscale.DigestInterval)
+		where.AddOp("u.email", "<>", "")
+		where.AddExpr("COALESCE(np.digest, TRUE)")
+		where.AddExpr("(np.digest_sent_at IS NULL OR np.digest_sent_at <= ?)", (*NullTime)(&sentBefore))
+	}
+
+	page := "ORDER BY u.user_id"
+	if filter.Limit > 0 {
+		page += fmt.Sprintf(" LIMIT %d", filter.Limit)
+	}
+	return r.find(tx, where, page)
+}
+
+func (r *notificationPreferencesRepo) find(tx *sql.Tx, where *findWhereClause, page string) ([]*planetscale.NotificationPreferences, error) {
+	rows, err := tx.Query(notificationPreferencesQuery+where.ToClause()+" "+page, where.values...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var found []*planetscale.NotificationPreferences
+	for rows.Next() {
+		var preferences planetscale.NotificationPreferences
+		err := rows.Scan(&preferences.UserID, &preferences.ExpenseAdded, &preferences.Digest, (*NullTime)(&preferences.DigestSentAt), (*NullTime)(&preferences.UpdatedAt), &preferences.Email, &preferences.Name)
+		if err != nil {
+			return nil, err
+		}
+		found = append(found, &preferences)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return found, nil
+}
+
+func (r *notificationPreferencesRepo) MarkDigestSent(tx *sql.Tx, userID string, sentAt time.Time) error {
+	query := `
+		INSERT INTO notification_preferences (user_id, digest_sent_at) VALUES (?, ?)
+		ON DUPLICATE KEY UPDATE digest_sent_at = VALUES(digest_sent_at)`
+
+	_, err := tx.Exec(query, userID, (*NullTime)(&sentAt))
+	if err != nil {
+		return err
+	}
+	slog.Info("marked digest sent", slog.String("user_id", userID))
+
+	return nil
+}
@@ -0,0 +1,109 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	planetscale "github.com/harshav17/planet_scale"
+)
+
+func TestNotificationPreferencesRepo_All(t *testing.T) {
+	t.Parallel()
+
+	db := MustOpenDB(t)
+	defer MustCloseDB(t, db)
+	ctx := context.Background()
+
+	t.Run("Update Tests", func(t *testing.T) {
+		t.Run("starts from the defaults", func(t *testing.T) {
+			tx, err := db.db.BeginTx(ctx, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tx.Rollback()
+
+			alice := MustCreateUser(t, tx, db.DB, &planetscale.User{UserID: "alice", Name: "Alice", Email: "alice@example.com"})
+
+			repo := NewNotificationPreferencesRepo(db.DB)
+			got, err := repo.Get(tx, alice.UserID)
+			if err != nil {
+				t.Fatal(err)
+			} else if !got.ExpenseAdded || !got.Digest || got.Email != alice.Email {
+				t.Fatalf("expected every email to %s by default, got %+v", alice.Email, got)
+			}
+
+			off := false
+			if _, err := repo.Update(tx, alice.UserID, &planetscale.NotificationPreferencesUpdate{Digest: &off}); err != nil {
+				t.Fatal(err)
+			}
+			got, err = repo.Get(tx, alice.UserID)
+			if err != nil {
+				t.Fatal(err)
+			} else if !got.ExpenseAdded || got.Digest {
+				t.Fatalf("expected only the digest to be off, got %+v", got)
+			}
+
+			if _, err := repo.Get(tx, "nobody"); planetscale.ErrorCode(err) != planetscale.ENOTFOUND {
+				t.Fatalf("expected %s, got %v", planetscale.ENOTFOUND, err)
+			}
+		})
+	})
+
+	t.Run("Find Tests", func(t *testing.T) {
+		t.Run("digests due", func(t *testing.T) {
+			tx, err := db.db.BeginTx(ctx, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tx.Rollback()
+
+			now := time.Date(2024, 1, 8, 9, 0, 0, 0, time.UTC)
+			repo := NewNotificationPreferencesRepo(db.DB)
+			MustCreateUser(t, tx, db.DB, &planetscale.User{UserID: "alice", Name: "Alice", Email: "alice@example.com"})
+			MustCreateUser(t, tx, db.DB, &planetscale.User{UserID: "bob", Name: "Bob", Email: "bob@example.com"})
+			MustCreateUser(t, tx, db.DB, &planetscale.User{UserID: "carol", Name: "Carol", Email: "carol@example.com"})
+			MustCreateUser(t, tx, db.DB, &planetscale.User{UserID: "dave", Name: "Dave"})
+			MustCreateUser(t, tx, db.DB, &planetscale.User{UserID: "erin", Name: "Erin", Email: "erin@example.com"})
+
+			// bob got a digest a day ago, carol turned it off, dave has no
+			// address and the account of erin is deleted
+			if err := repo.MarkDigestSent(tx, "bob", now.Add(-24*time.Hour)); err != nil {
+				t.Fatal(err)
+			}
+			off := false
+			if _, err := repo.Update(tx, "carol", &planetscale.NotificationPreferencesUpdate{Digest: &off}); err != nil {
+				t.Fatal(err)
+			}
+			if err := NewUserRepo(db.DB).Delete(tx, "erin"); err != nil {
+				t.Fatal(err)
+			}
+
+			due, err := repo.Find(tx, planetscale.NotificationPreferencesFilter{DigestDueAt: now})
+			if err != nil {
+				t.Fatal(err)
+			} else if len(due) != 1 || due[0].UserID != "alice" {
+				t.Fatalf("expected only alice to be due, got %+v", due)
+			}
+
+			// a week later bob is due again
+			due, err = repo.Find(tx, planetscale.NotificationPreferencesFilter{DigestDueAt: now.Add(6 * 24 * time.Hour)})
+			if err != nil {
+				t.Fatal(err)
+			} else if len(due) != 2 || due[1].UserID != "bob" {
+				t.Fatalf("expected alice and bob to be due, got %+v", due)
+			} else if got, err := repo.Get(tx, "bob"); err != nil {
+				t.Fatal(err)
+			} else if !got.UpdatedAt.IsZero() {
+				t.Fatalf("expected the preferences of bob to be unchanged, got %s", got.UpdatedAt)
+			}
+
+			found, err := repo.Find(tx, planetscale.NotificationPreferencesFilter{UserIDs: []string{"carol", "dave"}})
+			if err != nil {
+				t.Fatal(err)
+			} else if len(found) != 2 || found[0].Digest || !found[1].Digest {
+				t.Fatalf("expected carol and dave, got %+v", found)
+			}
+		})
+	})
+}
@@ -0,0 +1,334 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	planetscale "github.com/harshav17/planet_scale"
+)
+
+type recurringExpenseRepo struct {
+	db *DB
+}
+
+func NewRecurringExpenseRepo(db *DB) *recurringExpenseRepo {
+	return &recurringExpenseRepo{
+		db: db,
+	}
+}
+
+const recurringExpenseColumns = `
+			recurring_expense_id,
+			group_id,
+			split_type_id,
+			paid_by,
+			amount,
+			currency,
+			description,
+			participants,
+			rule,
+			start_at,
+			next_occurrence_at,
+			occurrence_count,
+			created_at,
+			updated_at,
+			created_by,
+			updated_by,
+			paused_at,
+			failure`
+
+func (r *recurringExpenseRepo) Get(tx *sql.Tx, recurringExpenseID int64) (*planetscale.RecurringExpense, error) {
+	query := `SELECT ` + recurringExpenseColumns + ` FROM recurring_expenses WHERE recurring_expense_id = ?`
+
+	recurringExpense, err := scanRecurringExpense(tx.QueryRow(query, recurringExpenseID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, planetscale.Errorf(planetscale.ENOTFOUND, "no recurring expense found with ID %d", recurringExpenseID)
+		}
+		return nil, err
+	}
+	slog.Info("loaded recurring expense", slog.Int64("id", recurringExpense.RecurringExpenseID))
+
+	return recurringExpense, nil
+}
+
+func (r *recurringExpenseRepo) Create(tx *sql.Tx, recurringExpense *planetscale.RecurringExpense) error {
+	groupID := recurringExpense.GroupID
+	currency, err := resolveCurrency(tx, recurringExpense.Currency, &groupID)
+	if err != nil {
+		return err
+	}
+	recurringExpense.Currency = currency
+
+	participants, err := json.Marshal(recurringExpense.Participants)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO
+			recurring_expenses (
+				group_id,
+				split_type_id,
+				paid_by,
+				amount,
+				currency,
+				description,
+				participants,
+				rule,
+				start_at,
+				next_occurrence_at,
+				occurrence_count,
+				created_by,
+				updated_by
+			)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	result, err := tx.Exec(
+		query,
+		recurringExpense.GroupID,
+		recurringExpense.SplitTypeID,
+		recurringExpense.PaidBy,
+		recurringExpense.Amount,
+		recurringExpense.Currency,
+		recurringExpense.Description,
+		string(participants),
+		recurringExpense.Rule,
+		(*NullTime)(&recurringExpense.StartAt),
+		(*NullTime)(&recurringExpense.NextOccurrenceAt),
+		recurringExpense.OccurrenceCount,
+		recurringExpense.CreatedBy,
+		recurringExpense.CreatedBy,
+	)
+	if err != nil {
+		return err
+	}
+	recurringExpenseID, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	recurringExpense.RecurringExpenseID = recurringExpenseID
+	slog.Info("created recurring expense", slog.Int64("id", recurringExpense.RecurringExpenseID))
+
+	return nil
+}
+
+func (r *recurringExpenseRepo) Update(tx *sql.Tx, recurringExpenseID int64, update *planetscale.RecurringExpenseUpdate) (*planetscale.RecurringExpense, error) {
+	recurringExpense, err := r.Get(tx, recurringExpenseID)
+	if err != nil {
+		return nil, err
+	}
+
+	if update.SplitTypeID != nil {
+		recurringExpense.SplitTypeID = *update.SplitTypeID
+	}
+	if update.PaidBy != nil {
+		recurringExpense.PaidBy = *update.PaidBy
+	}
+	if update.Amount != nil {
+		recurringExpense.Amount = *update.Amount
+	}
+	if update.Currency != nil {
+		currency, err := planetscale.NormalizeCurrency(*update.Currency)
+		if err != nil {
+			return nil, err
+		}
+		recurringExpense.Currency = currency
+	}
+	if update.Description != nil {
+		recurringExpense.Description = *update.Description
+	}
+	if update.Participants != nil {
+		recurringExpense.Participants = update.Participants
+	}
+	if update.UpdatedBy != nil {
+		recurringExpense.UpdatedBy = *update.UpdatedBy
+	}
+
+	participants, err := json.Marshal(recurringExpense.Participants)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		UPDATE recurring_expenses SET
+			split_type_id = ?,
+			paid_by = ?,
+			amount = ?,
+			currency = ?,
+			description = ?,
+			participants = ?,
+			updated_by = ?,
+			-- the change may well fix what paused it
+			paused_at = NULL,
+			failure = NULL
+		WHERE recurring_expense_id = ?`
+
+	_, err = tx.Exec(
+		query,
+		recurringExpense.SplitTypeID,
+		recurringExpense.PaidBy,
+		recurringExpense.Amount,
+		recurringExpense.Currency,
+		recurringExpense.Description,
+		string(participants),
+		recurringExpense.UpdatedBy,
+		recurringExpenseID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	slog.Info("updated recurring expense", slog.Int64("id", recurringExpenseID))
+
+	return r.Get(tx, recurringExpenseID)
+}
+
+func (r *recurringExpenseRepo) Delete(tx *sql.Tx, recurringExpenseID int64) error {
+	query := `DELETE FROM recurring_expenses WHERE recurring_expense_id = ?`
+
+	result, err := tx.Exec(query, recurringExpenseID)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return planetscale.Errorf(planetscale.ENOTFOUND, "no recurring expense found with ID %d", recurringExpenseID)
+	}
+	slog.Info("deleted recurring expense", slog.Int64("id", recurringExpenseID))
+
+	return nil
+}
+
+func (r *recurringExpenseRepo) Find(tx *sql.Tx, filter planetscale.RecurringExpenseFilter) ([]*planetscale.RecurringExpense, error) {
+	where := &findWhereClause{}
+	if filter.GroupID != 0 {
+		where.Add("group_id", filter.GroupID)
+	}
+
+	query := `SELECT ` + recurringExpenseColumns + ` FROM recurring_expenses ` + where.ToClause()
+
+	return r.query(tx, query, where.values...)
+}
+
+func (r *recurringExpenseRepo) FindDue(tx *sql.Tx, now time.Time) ([]*planetscale.RecurringExpense, error) {
+	query := `
+		SELECT ` + recurringExpenseColumns + `
+		FROM recurring_expenses
+		WHERE next_occurrence_at IS NOT NULL AND next_occurrence_at <= ?
+			AND paused_at IS NULL
+			-- deleted groups stop recurring until they are restored
+			AND group_id NOT IN (SELECT group_id FROM expense_groups WHERE deleted_at IS NOT NULL)
+		ORDER BY next_occurrence_at`
+
+	return r.query(tx, query, (*NullTime)(&now))
+}
+
+func (r *recurringExpenseRepo) Advance(tx *sql.Tx, recurringExpenseID int64, occurrence int64, next time.Time) error {
+	query := `
+		UPDATE recurring_expenses SET
+			occurrence_count = ?,
+			next_occurrence_at = ?
+		WHERE recurring_expense_id = ? AND occurrence_count = ?`
+
+	result, err := tx.Exec(query, occurrence+1, (*NullTime)(&next), recurringExpenseID, occurrence)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return planetscale.Errorf(planetscale.ECONFLICT, "recurring expense %d is no longer at occurrence %d", recurringExpenseID, occurrence)
+	}
+	slog.Info("advanced recurring expense", slog.Int64("id", recurringExpenseID), slog.Int64("occurrence", occurrence))
+
+	return nil
+}
+
+func (r *recurringExpenseRepo) Pause(tx *sql.Tx, recurringExpenseID int64, failure string) error {
+	query := `UPDATE recurring_expenses SET paused_at = CURRENT_TIMESTAMP, failure = ? WHERE recurring_expense_id = ?`
+
+	result, err := tx.Exec(query, failure, recurringExpenseID)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return planetscale.Errorf(planetscale.ENOTFOUND, "no recurring expense found with ID %d", recurringExpenseID)
+	}
+	slog.Info("paused recurring expense", slog.Int64("id", recurringExpenseID), slog.String("failure", failure))
+
+	return nil
+}
+
+func (r *recurringExpenseRepo) query(tx *sql.Tx, query string, args ...interface{}) ([]*planetscale.RecurringExpense, error) {
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var recurringExpenses []*planetscale.RecurringExpense
+	for rows.Next() {
+		recurringExpense, err := scanRecurringExpense(rows)
+		if err != nil {
+			return nil, err
+		}
+		recurringExpenses = append(recurringExpenses, recurringExpense)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return recurringExpenses, nil
+}
+
+// scanRecurringExpense scans a row selected with recurringExpenseColumns.
+func scanRecurringExpense(row interface{ Scan(...interface{}) error }) (*planetscale.RecurringExpense, error) {
+	var recurringExpense planetscale.RecurringExpense
+	var description, failure sql.NullString
+	var participants []byte
+	err := row.Scan(
+		&recurringExpense.RecurringExpenseID,
+		&recurringExpense.GroupID,
+		&recurringExpense.SplitTypeID,
+		&recurringExpense.PaidBy,
+		&recurringExpense.Amount,
+		&recurringExpense.Currency,
+		&description,
+		&participants,
+		&recurringExpense.Rule,
+		(*NullTime)(&recurringExpense.StartAt),
+		(*NullTime)(&recurringExpense.NextOccurrenceAt),
+		&recurringExpense.OccurrenceCount,
+		(*NullTime)(&recurringExpense.CreatedAt),
+		(*NullTime)(&recurringExpense.UpdatedAt),
+		&recurringExpense.CreatedBy,
+		&recurringExpense.UpdatedBy,
+		(*NullTime)(&recurringExpense.PausedAt),
+		&failure,
+	)
+	if err != nil {
+		return nil, err
+	}
+	recurringExpense.Description = description.String
+	recurringExpense.Failure = failure.String
+
+	if len(participants) > 0 {
+		if err := json.Unmarshal(participants, &recurringExpense.Participants); err != nil {
+			return nil, fmt.Errorf("cannot decode participants of recurring expense %d: %w", recurringExpense.RecurringExpenseID, err)
+		}
+	}
+
+	return &recurringExpense, nil
+}
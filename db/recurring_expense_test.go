@@ -0,0 +1,185 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	planetscale "github.com/harshav17/planet_scale"
+)
+
+func MustCreateRecurringExpense(tb testing.TB, tx *sql.Tx, db *DB, r *planetscale.RecurringExpense) *planetscale.RecurringExpense {
+	tb.Helper()
+
+	if err := NewRecurringExpenseRepo(db).Create(tx, r); err != nil {
+		tb.Fatal(err)
+	}
+
+	return r
+}
+
+func TestRecurringExpenseRepo_All(t *testing.T) {
+	t.Parallel()
+
+	db := MustOpenDB(t)
+	defer MustCloseDB(t, db)
+	ctx := context.Background()
+
+	setup := func(t *testing.T, tx *sql.Tx) *planetscale.RecurringExpense {
+		u := MustCreateUser(t, tx, db.DB, &planetscale.User{
+			UserID: "test-user-id",
+			Name:   "test user",
+		})
+		g := MustCreateExpenseGroup(t, tx, db.DB, &planetscale.ExpenseGroup{
+			GroupName: "test group",
+			CreateBy:  u.UserID,
+		})
+		start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		return MustCreateRecurringExpense(t, tx, db.DB, &planetscale.RecurringExpense{
+			GroupID:     g.ExpenseGroupID,
+			SplitTypeID: planetscale.SplitTypeUnequal,
+			PaidBy:      u.UserID,
+			Amount:      1200_00,
+			Description: "rent",
+			Participants: []*planetscale.ExpenseParticipant{
+				{UserID: u.UserID, AmountOwed: 1200_00},
+			},
+			Rule:             "FREQ=MONTHLY",
+			StartAt:          start,
+			NextOccurrenceAt: start,
+			CreatedBy:        u.UserID,
+		})
+	}
+
+	t.Run("Get Tests", func(t *testing.T) {
+		t.Run("successful get", func(t *testing.T) {
+			tx, err := db.db.BeginTx(ctx, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tx.Rollback()
+
+			r := setup(t, tx)
+
+			got, err := NewRecurringExpenseRepo(db.DB).Get(tx, r.RecurringExpenseID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Rule != "FREQ=MONTHLY" || got.Amount != 1200_00 || got.Currency != "USD" {
+				t.Fatalf("unexpected recurring expense %+v", got)
+			}
+			if len(got.Participants) != 1 || got.Participants[0].AmountOwed != 1200_00 {
+				t.Fatalf("expected participants to round trip, got %+v", got.Participants)
+			}
+		})
+
+		t.Run("not found", func(t *testing.T) {
+			tx, err := db.db.BeginTx(ctx, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tx.Rollback()
+
+			_, err = NewRecurringExpenseRepo(db.DB).Get(tx, 12345)
+			if planetscale.ErrorCode(err) != planetscale.ENOTFOUND {
+				t.Fatalf("expected not found error, got %v", err)
+			}
+		})
+	})
+
+	t.Run("FindDue Tests", func(t *testing.T) {
+		t.Run("due and advanced", func(t *testing.T) {
+			tx, err := db.db.BeginTx(ctx, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tx.Rollback()
+
+			r := setup(t, tx)
+			repo := NewRecurringExpenseRepo(db.DB)
+
+			due, err := repo.FindDue(tx, time.Date(2023, 12, 31, 0, 0, 0, 0, time.UTC))
+			if err != nil {
+				t.Fatal(err)
+			} else if len(due) != 0 {
+				t.Fatalf("expected nothing due yet, got %d", len(due))
+			}
+
+			due, err = repo.FindDue(tx, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+			if err != nil {
+				t.Fatal(err)
+			} else if len(due) != 1 {
+				t.Fatalf("expected 1 due, got %d", len(due))
+			}
+
+			if err := repo.Advance(tx, r.RecurringExpenseID, 0, time.Time{}); err != nil {
+				t.Fatal(err)
+			}
+			if err := repo.Advance(tx, r.RecurringExpenseID, 0, time.Time{}); planetscale.ErrorCode(err) != planetscale.ECONFLICT {
+				t.Fatalf("expected conflict error, got %v", err)
+			}
+
+			due, err = repo.FindDue(tx, time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC))
+			if err != nil {
+				t.Fatal(err)
+			} else if len(due) != 0 {
+				t.Fatalf("expected ended schedule to never be due, got %d", len(due))
+			}
+		})
+
+		t.Run("paused until updated", func(t *testing.T) {
+			tx, err := db.db.BeginTx(ctx, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tx.Rollback()
+
+			r := setup(t, tx)
+			repo := NewRecurringExpenseRepo(db.DB)
+			now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+			if err := repo.Pause(tx, r.RecurringExpenseID, "participants are required"); err != nil {
+				t.Fatal(err)
+			}
+			if paused, err := repo.Get(tx, r.RecurringExpenseID); err != nil {
+				t.Fatal(err)
+			} else if paused.PausedAt.IsZero() || paused.Failure != "participants are required" {
+				t.Fatalf("expected the recurring expense to be paused, got %v and %q", paused.PausedAt, paused.Failure)
+			}
+			if due, err := repo.FindDue(tx, now); err != nil {
+				t.Fatal(err)
+			} else if len(due) != 0 {
+				t.Fatalf("expected paused schedule not to be due, got %d", len(due))
+			}
+
+			description := "rent"
+			if _, err := repo.Update(tx, r.RecurringExpenseID, &planetscale.RecurringExpenseUpdate{Description: &description}); err != nil {
+				t.Fatal(err)
+			}
+			if due, err := repo.FindDue(tx, now); err != nil {
+				t.Fatal(err)
+			} else if len(due) != 1 || !due[0].PausedAt.IsZero() || due[0].Failure != "" {
+				t.Fatalf("expected the update to resume the schedule, got %+v", due)
+			}
+		})
+	})
+
+	t.Run("Occurrence Tests", func(t *testing.T) {
+		t.Run("occurrence can only be created once", func(t *testing.T) {
+			tx, err := db.db.BeginTx(ctx, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tx.Rollback()
+
+			r := setup(t, tx)
+			MustCreateExpense(t, tx, db.DB, r.Expense(0, r.StartAt))
+
+			err = NewExpenseRepo(db.DB).Create(tx, r.Expense(0, r.StartAt))
+			if planetscale.ErrorCode(err) != planetscale.ECONFLICT {
+				t.Fatalf("expected conflict error, got %v", err)
+			}
+		})
+	})
+}
@@ -0,0 +1,99 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	planetscale "github.com/harshav17/planet_scale"
+)
+
+type searchRepo struct {
+	db *DB
+}
+
+func NewSearchRepo(db *DB) *searchRepo {
+	return &searchRepo{
+		db: db,
+	}
+}
+
+// searchSources are the FULLTEXT indexed columns that lead back to an
+// expense, see the add_search_indexes migration.
+var searchSources = []struct {
+	match  string
+	column string
+	join   string
+}{
+	{planetscale.SearchMatchExpense, "e.description", ""},
+	{planetscale.SearchMatchItem, "i.name", "JOIN items i ON i.expense_id = e.expense_id"},
+	{planetscale.SearchMatchNote, "ep.note", "JOIN expense_participants ep ON ep.expense_id = e.expense_id"},
+}
+
+func (r *searchRepo) Search(tx *sql.Tx, query planetscale.SearchQuery) ([]*planetscale.SearchHit, error) {
+	if len(query.GroupIDs) == 0 || strings.TrimSpace(query.Query) == "" {
+		return nil, nil
+	}
+	groupIDs := make([]interface{}, len(query.GroupIDs))
+	for i, groupID := range query.GroupIDs {
+		groupIDs[i] = groupID
+	}
+
+	var selects []string
+	var values []interface{}
+	for _, source := range searchSources {
+		where := &findWhereClause{}
+		where.AddIn("e.group_id", groupIDs...)
+		where.AddDeleted("e.deleted_at", false)
+		where.AddMatch(source.column, query.Query)
+
+		selects = append(selects, `
+			SELECT
+				e.group_id,
+				g.group_name,
+				e.expense_id,
+				e.description,
+				e.amount,
+				e.currency,
+				e.timestamp,
+				'`+source.match+`' AS match_type,
+				`+source.column+` AS match_text,
+				`+matchExpr(source.column)+` AS score
+			FROM expenses e
+			JOIN expense_groups g ON g.group_id = e.group_id
+			`+source.join+`
+			`+where.ToClause())
+		// the score in the select list comes before the where clause
+		values = append(values, query.Query)
+		values = append(values, where.values...)
+	}
+
+	sqlQuery := strings.Join(selects, " UNION ALL ") + `
+		ORDER BY score DESC, expense_id DESC`
+	if query.Limit > 0 {
+		sqlQuery += fmt.Sprintf(" LIMIT %d", query.Limit)
+	}
+
+	rows, err := tx.Query(sqlQuery, values...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hits []*planetscale.SearchHit
+	for rows.Next() {
+		var hit planetscale.SearchHit
+		err := rows.Scan(&hit.GroupID, &hit.GroupName, &hit.ExpenseID, &hit.ExpenseDescription, &hit.Amount, &hit.Currency, (*NullTime)(&hit.Timestamp), &hit.Match, &hit.MatchText, &hit.Score)
+		if err != nil {
+			return nil, err
+		}
+		hits = append(hits, &hit)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	slog.Info("searched expenses", slog.Int("hits", len(hits)))
+
+	return hits, nil
+}
@@ -19,11 +19,11 @@ func NewSettlementRepo(db *DB) *settlementRepo {
 }
 
 func (r *settlementRepo) Get(tx *sql.Tx, settlementID int64) (*planetscale.Settlement, error) {
-	query := `SELECT settlement_id, group_id, paid_by, paid_to, amount, timestamp FROM settlements WHERE settlement_id = ?`
+	query := `SELECT settlement_id, group_id, paid_by, paid_to, amount, currency, timestamp, deleted_at FROM settlements WHERE settlement_id = ? AND deleted_at IS NULL`
 
 	var settlement planetscale.Settlement
 	row := tx.QueryRow(query, settlementID)
-	err := row.Scan(&settlement.SettlementID, &settlement.GroupID, &settlement.PaidBy, &settlement.PaidTo, &settlement.Amount, (*NullTime)(&settlement.Timestamp))
+	err := row.Scan(&settlement.SettlementID, &settlement.GroupID, &settlement.PaidBy, &settlement.PaidTo, &settlement.Amount, &settlement.Currency, (*NullTime)(&settlement.Timestamp), (*NullTime)(&settlement.DeletedAt))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			// Handle no rows error specifically if needed
@@ -37,9 +37,16 @@ func (r *settlementRepo) Get(tx *sql.Tx, settlementID int64) (*planetscale.Settl
 }
 
 func (r *settlementRepo) Create(tx *sql.Tx, settlement *planetscale.Settlement) error {
-	query := `INSERT INTO settlements (group_id, paid_by, paid_to, amount) VALUES (?, ?, ?, ?)`
+	currency, err := resolveCurrency(tx, settlement.Currency, &settlement.GroupID)
+	if err != nil {
+		return err
+	}
+	settlement.Currency = currency
+
+	// settlements without a timestamp happen now
+	query := `INSERT INTO settlements (group_id, paid_by, paid_to, amount, currency, timestamp) VALUES (?, ?, ?, ?, ?, COALESCE(?, CURRENT_TIMESTAMP))`
 
-	result, err := tx.Exec(query, settlement.GroupID, settlement.PaidBy, settlement.PaidTo, settlement.Amount)
+	result, err := tx.Exec(query, settlement.GroupID, settlement.PaidBy, settlement.PaidTo, settlement.Amount, settlement.Currency, (*NullTime)(&settlement.Timestamp))
 	if err != nil {
 		return err
 	}
@@ -53,8 +60,9 @@ func (r *settlementRepo) Create(tx *sql.Tx, settlement *planetscale.Settlement)
 	return nil
 }
 
+// Delete soft deletes the settlement, it stays restorable from the group's trash.
 func (r *settlementRepo) Delete(tx *sql.Tx, settlementID int64) error {
-	query := `DELETE FROM settlements WHERE settlement_id = ?`
+	query := `UPDATE settlements SET deleted_at = CURRENT_TIMESTAMP WHERE settlement_id = ? AND deleted_at IS NULL`
 
 	result, err := tx.Exec(query, settlementID)
 	if err != nil {
@@ -72,8 +80,27 @@ func (r *settlementRepo) Delete(tx *sql.Tx, settlementID int64) error {
 	return nil
 }
 
+func (r *settlementRepo) Restore(tx *sql.Tx, settlementID int64) error {
+	query := `UPDATE settlements SET deleted_at = NULL WHERE settlement_id = ? AND deleted_at IS NOT NULL`
+
+	result, err := tx.Exec(query, settlementID)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return planetscale.Errorf(planetscale.ENOTFOUND, "no deleted settlement found with ID %d", settlementID)
+	}
+	slog.Info("restored settlement", slog.Int64("id", settlementID))
+
+	return nil
+}
+
 func (r *settlementRepo) Update(tx *sql.Tx, settlementID int64, settlement *planetscale.SettlementUpdate) (*planetscale.Settlement, error) {
-	query := `UPDATE settlements SET group_id = ? WHERE settlement_id = ?`
+	query := `UPDATE settlements SET group_id = ? WHERE settlement_id = ? AND deleted_at IS NULL`
 
 	result, err := tx.Exec(query, settlement.GroupID, settlementID)
 	if err != nil {
@@ -96,6 +123,32 @@ func (r *settlementRepo) Find(tx *sql.Tx, filter planetscale.SettlementFilter) (
 	if filter.GroupID != 0 {
 		where.Add("group_id", filter.GroupID)
 	}
+	if filter.GroupIDs != nil {
+		where.AddIn("group_id", int64Values(filter.GroupIDs)...)
+	}
+	where.AddDeleted("deleted_at", filter.Deleted)
+	if filter.PaidBy != nil {
+		where.AddIn("paid_by", stringValues(filter.PaidBy)...)
+	}
+	if filter.From != nil {
+		where.AddOp("timestamp", ">=", (*NullTime)(filter.From))
+	}
+	if filter.To != nil {
+		where.AddOp("timestamp", "<", (*NullTime)(filter.To))
+	}
+	if filter.MinAmount != nil {
+		where.AddOp("amount", ">=", *filter.MinAmount)
+	}
+	if filter.MaxAmount != nil {
+		where.AddOp("amount", "<=", *filter.MaxAmount)
+	}
+	page, err := findPage(where, "settlement_id", map[string]string{
+		planetscale.SortTimestamp: "timestamp",
+		planetscale.SortAmount:    "amount",
+	}, filter.Sort, filter.Cursor, filter.Limit)
+	if err != nil {
+		return nil, err
+	}
 
 	query := `
 		SELECT
@@ -104,9 +157,12 @@ func (r *settlementRepo) Find(tx *sql.Tx, filter planetscale.SettlementFilter) (
 			paid_by,
 			paid_to,
 			Amount,
-			timestamp
+			currency,
+			timestamp,
+			deleted_at
 		FROM settlements
-		` + where.ToClause()
+		` + where.ToClause() + `
+		` + page
 
 	rows, err := tx.Query(query, where.values...)
 	if err != nil {
@@ -116,7 +172,7 @@ func (r *settlementRepo) Find(tx *sql.Tx, filter planetscale.SettlementFilter) (
 	var settlements []*planetscale.Settlement
 	for rows.Next() {
 		var settlement planetscale.Settlement
-		err := rows.Scan(&settlement.SettlementID, &settlement.GroupID, &settlement.PaidBy, &settlement.PaidTo, &settlement.Amount, (*NullTime)(&settlement.Timestamp))
+		err := rows.Scan(&settlement.SettlementID, &settlement.GroupID, &settlement.PaidBy, &settlement.PaidTo, &settlement.Amount, &settlement.Currency, (*NullTime)(&settlement.Timestamp), (*NullTime)(&settlement.DeletedAt))
 		if err != nil {
 			return nil, err
 		}
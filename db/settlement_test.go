@@ -53,7 +53,7 @@ func TestSettlementRepo_All(t *testing.T) {
 				GroupID: g.ExpenseGroupID,
 				PaidBy:  u.UserID,
 				PaidTo:  u2.UserID,
-				Amount:  100,
+				Amount:  100_00,
 			}
 
 			if err := NewSettlementRepo(db.DB).Create(tx, s); err != nil {
@@ -77,7 +77,7 @@ func TestSettlementRepo_All(t *testing.T) {
 				GroupID: 100,
 				PaidBy:  u.UserID,
 				PaidTo:  u.UserID,
-				Amount:  100,
+				Amount:  100_00,
 			}
 
 			// TODO - return better error messages from the repo
@@ -113,13 +113,13 @@ func TestSettlementRepo_All(t *testing.T) {
 				GroupID: g.ExpenseGroupID,
 				PaidBy:  u.UserID,
 				PaidTo:  u2.UserID,
-				Amount:  100,
+				Amount:  100_00,
 			})
 
 			if got, err := NewSettlementRepo(db.DB).Get(tx, s.SettlementID); err != nil {
 				t.Fatal(err)
-			} else if got.Amount != 100 {
-				t.Fatalf("expected title to be %d, got %f", 100, got.Amount)
+			} else if got.Amount != 100_00 {
+				t.Fatalf("expected amount to be 100.00, got %s", got.Amount)
 			}
 		})
 
@@ -163,7 +163,7 @@ func TestSettlementRepo_All(t *testing.T) {
 				GroupID: g.ExpenseGroupID,
 				PaidBy:  u.UserID,
 				PaidTo:  u2.UserID,
-				Amount:  100,
+				Amount:  100_00,
 			})
 
 			if err := NewSettlementRepo(db.DB).Delete(tx, s.SettlementID); err != nil {
@@ -215,7 +215,7 @@ func TestSettlementRepo_All(t *testing.T) {
 				GroupID: g.ExpenseGroupID,
 				PaidBy:  u.UserID,
 				PaidTo:  u2.UserID,
-				Amount:  100,
+				Amount:  100_00,
 			})
 
 			su := &planetscale.SettlementUpdate{
@@ -272,7 +272,7 @@ func TestSettlementRepo_All(t *testing.T) {
 				GroupID: g.ExpenseGroupID,
 				PaidBy:  u.UserID,
 				PaidTo:  u2.UserID,
-				Amount:  100,
+				Amount:  100_00,
 			})
 
 			f := planetscale.SettlementFilter{
@@ -284,7 +284,7 @@ func TestSettlementRepo_All(t *testing.T) {
 			} else if len(got) != 1 {
 				t.Fatalf("expected 1 settlement, got %d", len(got))
 			} else if got[0].Amount != s.Amount {
-				t.Fatalf("expected title to be %f, got %f", s.Amount, got[0].Amount)
+				t.Fatalf("expected title to be %s, got %s", s.Amount, got[0].Amount)
 			}
 		})
 	})
@@ -19,11 +19,12 @@ func NewUserRepo(db *DB) *userRepo {
 }
 
 func (r *userRepo) Get(tx *sql.Tx, userID string) (*planetscale.User, error) {
-	query := `SELECT user_id, email, name, created_at FROM users WHERE user_id = ?`
+	query := `SELECT user_id, email, name, image_url, created_at, deleted_at FROM users WHERE user_id = ?`
 
 	var user planetscale.User
+	var imageURL sql.NullString
 	row := tx.QueryRow(query, userID)
-	err := row.Scan(&user.UserID, &user.Email, &user.Name, (*NullTime)(&user.CreatedAt))
+	err := row.Scan(&user.UserID, &user.Email, &user.Name, &imageURL, (*NullTime)(&user.CreatedAt), (*NullTime)(&user.DeletedAt))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			// Handle no rows error specifically if needed
@@ -31,15 +32,16 @@ func (r *userRepo) Get(tx *sql.Tx, userID string) (*planetscale.User, error) {
 		}
 		return nil, err
 	}
+	user.ImageURL = imageURL.String
 	slog.Info("loaded user", slog.String("id", user.UserID))
 
 	return &user, nil
 }
 
 func (r *userRepo) Create(tx *sql.Tx, user *planetscale.User) error {
-	query := `INSERT INTO users (user_id, email, name) VALUES (?, ?, ?)`
+	query := `INSERT INTO users (user_id, email, name, image_url) VALUES (?, ?, ?, ?)`
 
-	result, err := tx.Exec(query, user.UserID, user.Email, user.Name)
+	result, err := tx.Exec(query, user.UserID, user.Email, user.Name, user.ImageURL)
 	if err != nil {
 		return err
 	}
@@ -53,9 +55,20 @@ func (r *userRepo) Create(tx *sql.Tx, user *planetscale.User) error {
 }
 
 func (r *userRepo) Upsert(tx *sql.Tx, user *planetscale.User) error {
-	query := `INSERT INTO users (user_id, email, name) VALUES (?, ?, ?) ON DUPLICATE KEY UPDATE email = ?, name = ?`
+	// webhooks may arrive out of order, an update after the deletion must not
+	// bring the user's details back
+	query := `
+		INSERT INTO
+			users (user_id, email, name, image_url)
+		VALUES
+			(?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+			email = IF(deleted_at IS NULL, VALUES(email), email),
+			name = IF(deleted_at IS NULL, VALUES(name), name),
+			image_url = IF(deleted_at IS NULL, VALUES(image_url), image_url)
+	`
 
-	result, err := tx.Exec(query, user.UserID, user.Email, user.Name, user.Email, user.Name)
+	result, err := tx.Exec(query, user.UserID, user.Email, user.Name, user.ImageURL)
 	if err != nil {
 		return err
 	}
@@ -67,3 +80,32 @@ func (r *userRepo) Upsert(tx *sql.Tx, user *planetscale.User) error {
 
 	return nil
 }
+
+func (r *userRepo) Delete(tx *sql.Tx, userID string) error {
+	query := `
+		UPDATE
+			users
+		SET
+			email = '',
+			name = ?,
+			image_url = NULL,
+			deleted_at = CURRENT_TIMESTAMP
+		WHERE
+			user_id = ? AND deleted_at IS NULL
+	`
+
+	result, err := tx.Exec(query, planetscale.DeletedUserName, userID)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return planetscale.Errorf(planetscale.ENOTFOUND, "no user found with ID %s", userID)
+	}
+	slog.Info("deleted user", slog.String("id", userID))
+
+	return nil
+}
@@ -117,4 +117,44 @@ func TestUserRepo_All(t *testing.T) {
 			}
 		})
 	})
+	t.Run("Delete Tests", func(t *testing.T) {
+		t.Run("successful delete", func(t *testing.T) {
+			tx, err := db.db.BeginTx(ctx, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tx.Rollback()
+
+			u := MustCreateUser(t, tx, db.DB, &planetscale.User{
+				UserID:   "test-user-id",
+				Name:     "test user",
+				Email:    "test@user.com",
+				ImageURL: "https://img.example.com/test-user-id",
+			})
+			if err := NewUserRepo(db.DB).Delete(tx, u.UserID); err != nil {
+				t.Fatal(err)
+			}
+			if got, err := NewUserRepo(db.DB).Get(tx, u.UserID); err != nil {
+				t.Fatal(err)
+			} else if got.Name != planetscale.DeletedUserName || got.Email != "" || got.ImageURL != "" {
+				t.Fatalf("expected the user to be anonymized, got %v", got)
+			} else if got.DeletedAt.IsZero() {
+				t.Fatal("expected deleted at to be set")
+			}
+
+			// a late update does not bring the details back
+			if err := NewUserRepo(db.DB).Upsert(tx, u); err != nil {
+				t.Fatal(err)
+			}
+			if got, err := NewUserRepo(db.DB).Get(tx, u.UserID); err != nil {
+				t.Fatal(err)
+			} else if got.Name != planetscale.DeletedUserName {
+				t.Fatalf("expected name %s, got %s", planetscale.DeletedUserName, got.Name)
+			}
+
+			if err := NewUserRepo(db.DB).Delete(tx, u.UserID); planetscale.ErrorCode(err) != planetscale.ENOTFOUND {
+				t.Fatalf("expected ENOTFOUND, got %v", err)
+			}
+		})
+	})
 }
@@ -0,0 +1,195 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	planetscale "github.com/harshav17/planet_scale"
+)
+
+type webhookDeliveryRepo struct {
+	db *DB
+}
+
+func NewWebhookDeliveryRepo(db *DB) *webhookDeliveryRepo {
+	return &webhookDeliveryRepo{
+		db: db,
+	}
+}
+
+func (r *webhookDeliveryRepo) Update(tx *sql.Tx, deliveryID int64, update *planetscale.WebhookDeliveryUpdate) error {
+	query := `
+		UPDATE
+			webhook_deliveries
+		SET
+			status = COALESCE(?, status),
+			attempts = COALESCE(?, attempts),
+			next_attempt_at = COALESCE(?, next_attempt_at),
+			delivered_at = COALESCE(?, delivered_at)
+		WHERE
+			delivery_id = ?
+	`
+
+	result, err := tx.Exec(query, update.Status, update.Attempts, (*NullTime)(update.NextAttemptAt), (*NullTime)(update.DeliveredAt), deliveryID)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return planetscale.Errorf(planetscale.ENOTFOUND, "no webhook delivery found with ID %d", deliveryID)
+	}
+	slog.Info("updated webhook delivery", slog.Int64("id", deliveryID))
+
+	return nil
+}
+
+func (r *webhookDeliveryRepo) Find(tx *sql.Tx, filter planetscale.WebhookDeliveryFilter) ([]*planetscale.WebhookDelivery, error) {
+	where := &findWhereClause{}
+	// deliveries of deleted subscriptions are dropped
+	where.AddDeleted("s.deleted_at", false)
+	if filter.SubscriptionID != 0 {
+		where.Add("d.subscription_id", filter.SubscriptionID)
+	}
+	if !filter.DueAt.IsZero() {
+		where.Add("d.status", planetscale.WebhookDeliveryPending)
+		where.AddOp("d.next_attempt_at", "<=", (*NullTime)(&filter.DueAt))
+	}
+
+	order := ` ORDER BY d.delivery_id DESC`
+	if !filter.DueAt.IsZero() {
+		// oldest first so that events arrive roughly in order
+		order = ` ORDER BY d.next_attempt_at, d.delivery_id`
+	}
+	limit := ""
+	if filter.Limit > 0 {
+		limit = ` LIMIT ?`
+		where.values = append(where.values, filter.Limit)
+	}
+
+	query := `
+		SELECT
+			d.delivery_id,
+			d.event_id,
+			d.subscription_id,
+			d.status,
+			d.attempts,
+			d.next_attempt_at,
+			d.delivered_at,
+			e.group_id,
+			e.event_type,
+			e.actor_id,
+			e.data,
+			e.created_at,
+			s.group_id,
+			s.user_id,
+			s.url,
+			s.secret
+		FROM
+			webhook_deliveries d
+			JOIN webhook_events e ON e.event_id = d.event_id
+			JOIN webhook_subscriptions s ON s.subscription_id = d.subscription_id
+		` + where.ToClause() + order + limit
+
+	rows, err := tx.Query(query, where.values...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []*planetscale.WebhookDelivery
+	for rows.Next() {
+		var delivery planetscale.WebhookDelivery
+		var event planetscale.WebhookEvent
+		var subscription planetscale.WebhookSubscription
+		var data []byte
+		err := rows.Scan(
+			&delivery.DeliveryID,
+			&delivery.EventID,
+			&delivery.SubscriptionID,
+			&delivery.Status,
+			&delivery.Attempts,
+			(*NullTime)(&delivery.NextAttemptAt),
+			(*NullTime)(&delivery.DeliveredAt),
+			&event.GroupID,
+			&event.Type,
+			&event.ActorID,
+			&data,
+			(*NullTime)(&event.CreatedAt),
+			&subscription.GroupID,
+			&subscription.UserID,
+			&subscription.URL,
+			&subscription.Secret,
+		)
+		if err != nil {
+			return nil, err
+		}
+		event.EventID = delivery.EventID
+		if data != nil {
+			event.Data = json.RawMessage(data)
+		}
+		subscription.SubscriptionID = delivery.SubscriptionID
+		delivery.Event = &event
+		delivery.Subscription = &subscription
+		deliveries = append(deliveries, &delivery)
+	}
+	return deliveries, rows.Err()
+}
+
+func (r *webhookDeliveryRepo) CreateAttempt(tx *sql.Tx, attempt *planetscale.WebhookDeliveryAttempt) error {
+	if attempt.AttemptedAt.IsZero() {
+		attempt.AttemptedAt = time.Now().UTC().Truncate(time.Second)
+	}
+
+	query := `INSERT INTO webhook_delivery_attempts (delivery_id, status_code, error, duration_ms, attempted_at) VALUES (?, ?, ?, ?, ?)`
+
+	result, err := tx.Exec(query, attempt.DeliveryID, attempt.StatusCode, attempt.Error, attempt.Duration, (*NullTime)(&attempt.AttemptedAt))
+	if err != nil {
+		return err
+	}
+	attemptID, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	attempt.AttemptID = attemptID
+
+	return nil
+}
+
+func (r *webhookDeliveryRepo) FindAttempts(tx *sql.Tx, deliveryID int64) ([]*planetscale.WebhookDeliveryAttempt, error) {
+	query := `
+		SELECT
+			attempt_id,
+			delivery_id,
+			status_code,
+			error,
+			duration_ms,
+			attempted_at
+		FROM
+			webhook_delivery_attempts
+		WHERE
+			delivery_id = ?
+		ORDER BY attempt_id`
+
+	rows, err := tx.Query(query, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var attempts []*planetscale.WebhookDeliveryAttempt
+	for rows.Next() {
+		var attempt planetscale.WebhookDeliveryAttempt
+		var errorText sql.NullString
+		if err := rows.Scan(&attempt.AttemptID, &attempt.DeliveryID, &attempt.StatusCode, &errorText, &attempt.Duration, (*NullTime)(&attempt.AttemptedAt)); err != nil {
+			return nil, err
+		}
+		attempt.Error = errorText.String
+		attempts = append(attempts, &attempt)
+	}
+	return attempts, rows.Err()
+}
@@ -0,0 +1,77 @@
+package db
+
+import (
+	"database/sql"
+	"log/slog"
+	"time"
+
+	planetscale "github.com/harshav17/planet_scale"
+)
+
+type webhookEventRepo struct {
+	db *DB
+}
+
+func NewWebhookEventRepo(db *DB) *webhookEventRepo {
+	return &webhookEventRepo{
+		db: db,
+	}
+}
+
+func (r *webhookEventRepo) Create(tx *sql.Tx, event *planetscale.WebhookEvent) error {
+	data, err := marshalAuditData(event.Data)
+	if err != nil {
+		return err
+	}
+	event.CreatedAt = time.Now().UTC().Truncate(time.Second)
+
+	query := `INSERT INTO webhook_events (group_id, event_type, actor_id, data, created_at) VALUES (?, ?, ?, ?, ?)`
+
+	result, err := tx.Exec(query, event.GroupID, event.Type, event.ActorID, data, event.CreatedAt)
+	if err != nil {
+		return err
+	}
+	eventID, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	event.EventID = eventID
+
+	// fan out to the subscriptions of the group and to those of its members
+	// that cover all of their groups. Without a group, the subscriptions
+	// covering all groups of whoever paid for or takes part in the expense
+	// receive it.
+	query = `
+		INSERT INTO
+			webhook_deliveries
+			(event_id, subscription_id, next_attempt_at)
+		SELECT
+			?, s.subscription_id, ?
+		FROM
+			webhook_subscriptions s
+		WHERE
+			s.deleted_at IS NULL
+			AND (
+				s.group_id = ?
+				OR (s.group_id IS NULL AND s.user_id IN (
+					SELECT user_id FROM group_members WHERE group_id = ? AND left_at IS NULL
+				))
+				OR (? IS NULL AND s.group_id IS NULL AND s.user_id IN (
+					SELECT paid_by FROM expenses WHERE expense_id = ?
+					UNION SELECT user_id FROM expense_participants WHERE expense_id = ?
+				))
+			)
+	`
+
+	result, err = tx.Exec(query, event.EventID, event.CreatedAt, event.GroupID, event.GroupID, event.GroupID, event.ExpenseID, event.ExpenseID)
+	if err != nil {
+		return err
+	}
+	queued, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	slog.Info("created webhook event", slog.Int64("id", event.EventID), slog.String("type", event.Type), slog.Int64("deliveries", queued))
+
+	return nil
+}
@@ -0,0 +1,274 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	planetscale "github.com/harshav17/planet_scale"
+)
+
+func MustCreateWebhookSubscription(tb testing.TB, tx *sql.Tx, db *DB, s *planetscale.WebhookSubscription) *planetscale.WebhookSubscription {
+	tb.Helper()
+
+	if err := NewWebhookSubscriptionRepo(db).Create(tx, s); err != nil {
+		tb.Fatal(err)
+	}
+
+	return s
+}
+
+func TestWebhookEventRepo_All(t *testing.T) {
+	t.Parallel()
+
+	db := MustOpenDB(t)
+	defer MustCloseDB(t, db)
+	ctx := context.Background()
+
+	t.Run("Create Tests", func(t *testing.T) {
+		t.Run("queued for group and member subscriptions", func(t *testing.T) {
+			tx, err := db.db.BeginTx(ctx, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tx.Rollback()
+
+			u := MustCreateUser(t, tx, db.DB, &planetscale.User{
+				UserID: "test-user-id",
+				Name:   "test user",
+				Email:  "",
+			})
+			g := MustCreateExpenseGroup(t, tx, db.DB, &planetscale.ExpenseGroup{
+				GroupName: "test group",
+				CreateBy:  u.UserID,
+			})
+			MustCreateGroupMember(t, tx, db.DB, &planetscale.GroupMember{
+				GroupID: g.ExpenseGroupID,
+				UserID:  u.UserID,
+			})
+			other := MustCreateExpenseGroup(t, tx, db.DB, &planetscale.ExpenseGroup{
+				GroupName: "other group",
+				CreateBy:  u.UserID,
+			})
+
+			group := MustCreateWebhookSubscription(t, tx, db.DB, &planetscale.WebhookSubscription{
+				GroupID: &g.ExpenseGroupID,
+				UserID:  u.UserID,
+				URL:     "https://example.com/group",
+				Secret:  "whsec_test",
+			})
+			user := MustCreateWebhookSubscription(t, tx, db.DB, &planetscale.WebhookSubscription{
+				UserID: u.UserID,
+				URL:    "https://example.com/user",
+				Secret: "whsec_test",
+			})
+			MustCreateWebhookSubscription(t, tx, db.DB, &planetscale.WebhookSubscription{
+				GroupID: &other.ExpenseGroupID,
+				UserID:  u.UserID,
+				URL:     "https://example.com/other",
+				Secret:  "whsec_test",
+			})
+
+			event := &planetscale.WebhookEvent{
+				GroupID: &g.ExpenseGroupID,
+				Type:    "expense.created",
+				ActorID: u.UserID,
+				Data:    map[string]int{"expense_id": 1},
+			}
+			if err := NewWebhookEventRepo(db.DB).Create(tx, event); err != nil {
+				t.Fatal(err)
+			}
+
+			deliveries, err := NewWebhookDeliveryRepo(db.DB).Find(tx, planetscale.WebhookDeliveryFilter{
+				DueAt: time.Now().UTC().Add(time.Minute),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(deliveries) != 2 {
+				t.Fatalf("expected 2 deliveries, got %d", len(deliveries))
+			}
+			for _, d := range deliveries {
+				if d.SubscriptionID != group.SubscriptionID && d.SubscriptionID != user.SubscriptionID {
+					t.Fatalf("unexpected delivery to subscription %d", d.SubscriptionID)
+				} else if d.Event.Type != "expense.created" {
+					t.Fatalf("expected event type expense.created, got %s", d.Event.Type)
+				} else if d.Subscription.Secret != "whsec_test" {
+					t.Fatal("expected the secret to be loaded for signing")
+				}
+			}
+		})
+
+		t.Run("direct expenses are queued for the people involved", func(t *testing.T) {
+			tx, err := db.db.BeginTx(ctx, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tx.Rollback()
+
+			payer := MustCreateUser(t, tx, db.DB, &planetscale.User{
+				UserID: "test-user-id",
+				Name:   "test user",
+			})
+			friend := MustCreateUser(t, tx, db.DB, &planetscale.User{
+				UserID: "test-user-id-2",
+				Name:   "test friend",
+			})
+			stranger := MustCreateUser(t, tx, db.DB, &planetscale.User{
+				UserID: "test-user-id-3",
+				Name:   "test stranger",
+			})
+			e := MustCreateExpense(t, tx, db.DB, &planetscale.Expense{
+				PaidBy:      payer.UserID,
+				SplitTypeID: 1,
+				Amount:      20_00,
+				Description: "direct expense",
+				Timestamp:   time.Now(),
+				CreatedBy:   payer.UserID,
+				UpdatedBy:   payer.UserID,
+			})
+			MustCreateExpenseParticipant(t, tx, db.DB, &planetscale.ExpenseParticipant{
+				ExpenseID:  e.ExpenseID,
+				UserID:     friend.UserID,
+				AmountOwed: 10_00,
+			})
+
+			var involved []int64
+			for _, u := range []*planetscale.User{payer, friend} {
+				s := MustCreateWebhookSubscription(t, tx, db.DB, &planetscale.WebhookSubscription{
+					UserID: u.UserID,
+					URL:    "https://example.com/" + u.UserID,
+					Secret: "whsec_test",
+				})
+				involved = append(involved, s.SubscriptionID)
+			}
+			MustCreateWebhookSubscription(t, tx, db.DB, &planetscale.WebhookSubscription{
+				UserID: stranger.UserID,
+				URL:    "https://example.com/stranger",
+				Secret: "whsec_test",
+			})
+
+			event := planetscale.NewWebhookEvent(&planetscale.AuditEvent{
+				EntityType: planetscale.AuditEntityExpense,
+				EntityID:   "1",
+				Action:     planetscale.AuditActionCreate,
+				ActorID:    payer.UserID,
+				After:      e,
+			})
+			if err := NewWebhookEventRepo(db.DB).Create(tx, event); err != nil {
+				t.Fatal(err)
+			}
+
+			deliveries, err := NewWebhookDeliveryRepo(db.DB).Find(tx, planetscale.WebhookDeliveryFilter{
+				DueAt: time.Now().UTC().Add(time.Minute),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(deliveries) != 2 {
+				t.Fatalf("expected 2 deliveries, got %d", len(deliveries))
+			}
+			for _, d := range deliveries {
+				if d.SubscriptionID != involved[0] && d.SubscriptionID != involved[1] {
+					t.Fatalf("unexpected delivery to subscription %d", d.SubscriptionID)
+				}
+			}
+		})
+	})
+}
+
+func TestWebhookDeliveryRepo_All(t *testing.T) {
+	t.Parallel()
+
+	db := MustOpenDB(t)
+	defer MustCloseDB(t, db)
+	ctx := context.Background()
+
+	t.Run("Update Tests", func(t *testing.T) {
+		t.Run("attempts are recorded", func(t *testing.T) {
+			tx, err := db.db.BeginTx(ctx, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tx.Rollback()
+
+			u := MustCreateUser(t, tx, db.DB, &planetscale.User{
+				UserID: "test-user-id",
+				Name:   "test user",
+				Email:  "",
+			})
+			g := MustCreateExpenseGroup(t, tx, db.DB, &planetscale.ExpenseGroup{
+				GroupName: "test group",
+				CreateBy:  u.UserID,
+			})
+			s := MustCreateWebhookSubscription(t, tx, db.DB, &planetscale.WebhookSubscription{
+				GroupID: &g.ExpenseGroupID,
+				UserID:  u.UserID,
+				URL:     "https://example.com/group",
+				Secret:  "whsec_test",
+			})
+			if err := NewWebhookEventRepo(db.DB).Create(tx, &planetscale.WebhookEvent{
+				GroupID: &g.ExpenseGroupID,
+				Type:    "settlement.created",
+				ActorID: u.UserID,
+			}); err != nil {
+				t.Fatal(err)
+			}
+
+			repo := NewWebhookDeliveryRepo(db.DB)
+			deliveries, err := repo.Find(tx, planetscale.WebhookDeliveryFilter{SubscriptionID: s.SubscriptionID})
+			if err != nil {
+				t.Fatal(err)
+			} else if len(deliveries) != 1 {
+				t.Fatalf("expected 1 delivery, got %d", len(deliveries))
+			}
+			d := deliveries[0]
+
+			status := 500
+			if err := repo.CreateAttempt(tx, &planetscale.WebhookDeliveryAttempt{
+				DeliveryID: d.DeliveryID,
+				StatusCode: &status,
+				Error:      "500 Internal Server Error",
+			}); err != nil {
+				t.Fatal(err)
+			}
+			attempts := 1
+			next := time.Now().UTC().Add(time.Hour).Truncate(time.Second)
+			if err := repo.Update(tx, d.DeliveryID, &planetscale.WebhookDeliveryUpdate{
+				Attempts:      &attempts,
+				NextAttemptAt: &next,
+			}); err != nil {
+				t.Fatal(err)
+			}
+
+			due, err := repo.Find(tx, planetscale.WebhookDeliveryFilter{DueAt: time.Now().UTC()})
+			if err != nil {
+				t.Fatal(err)
+			} else if len(due) != 0 {
+				t.Fatalf("expected the retry to wait, got %d due", len(due))
+			}
+
+			log, err := repo.FindAttempts(tx, d.DeliveryID)
+			if err != nil {
+				t.Fatal(err)
+			} else if len(log) != 1 || *log[0].StatusCode != status {
+				t.Fatalf("expected 1 attempt with status %d, got %v", status, log)
+			}
+		})
+
+		t.Run("invalid delivery id", func(t *testing.T) {
+			tx, err := db.db.BeginTx(ctx, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tx.Rollback()
+
+			status := planetscale.WebhookDeliveryFailed
+			err = NewWebhookDeliveryRepo(db.DB).Update(tx, 0, &planetscale.WebhookDeliveryUpdate{Status: &status})
+			if planetscale.ErrorCode(err) != planetscale.ENOTFOUND {
+				t.Fatalf("expected ENOTFOUND, got %v", err)
+			}
+		})
+	})
+}
@@ -0,0 +1,133 @@
+package db
+
+import (
+	"database/sql"
+	"log/slog"
+	"time"
+
+	planetscale "github.com/harshav17/planet_scale"
+)
+
+type webhookSubscriptionRepo struct {
+	db *DB
+}
+
+func NewWebhookSubscriptionRepo(db *DB) *webhookSubscriptionRepo {
+	return &webhookSubscriptionRepo{
+		db: db,
+	}
+}
+
+func (r *webhookSubscriptionRepo) Get(tx *sql.Tx, subscriptionID int64) (*planetscale.WebhookSubscription, error) {
+	query := `
+		SELECT
+			subscription_id,
+			group_id,
+			user_id,
+			url,
+			created_at
+		FROM
+			webhook_subscriptions
+		WHERE
+			subscription_id = ? AND deleted_at IS NULL
+	`
+
+	var subscription planetscale.WebhookSubscription
+	row := tx.QueryRow(query, subscriptionID)
+	err := row.Scan(&subscription.SubscriptionID, &subscription.GroupID, &subscription.UserID, &subscription.URL, (*NullTime)(&subscription.CreatedAt))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, planetscale.Errorf(planetscale.ENOTFOUND, "no webhook subscription found with ID %d", subscriptionID)
+		}
+		return nil, err
+	}
+	return &subscription, nil
+}
+
+func (r *webhookSubscriptionRepo) Create(tx *sql.Tx, subscription *planetscale.WebhookSubscription) error {
+	subscription.CreatedAt = time.Now().UTC().Truncate(time.Second)
+
+	query := `
+		INSERT INTO
+			webhook_subscriptions
+			(group_id, user_id, url, secret, created_at)
+		VALUES
+			(?, ?, ?, ?, ?)
+	`
+
+	result, err := tx.Exec(query, subscription.GroupID, subscription.UserID, subscription.URL, subscription.Secret, subscription.CreatedAt)
+	if err != nil {
+		return err
+	}
+	subscriptionID, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	subscription.SubscriptionID = subscriptionID
+	slog.Info("created webhook subscription", slog.Int64("id", subscription.SubscriptionID))
+
+	return nil
+}
+
+// Delete soft deletes the subscription so that its deliveries stay on record.
+// Pending deliveries are not sent anymore.
+func (r *webhookSubscriptionRepo) Delete(tx *sql.Tx, subscriptionID int64) error {
+	query := `UPDATE webhook_subscriptions SET deleted_at = CURRENT_TIMESTAMP WHERE subscription_id = ? AND deleted_at IS NULL`
+
+	result, err := tx.Exec(query, subscriptionID)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return planetscale.Errorf(planetscale.ENOTFOUND, "no webhook subscription found with ID %d", subscriptionID)
+	}
+	slog.Info("deleted webhook subscription", slog.Int64("id", subscriptionID))
+
+	return nil
+}
+
+func (r *webhookSubscriptionRepo) Find(tx *sql.Tx, filter planetscale.WebhookSubscriptionFilter) ([]*planetscale.WebhookSubscription, error) {
+	where := &findWhereClause{}
+	where.AddDeleted("deleted_at", false)
+	if filter.GroupID != 0 {
+		where.Add("group_id", filter.GroupID)
+	}
+	if filter.UserID != "" {
+		where.Add("user_id", filter.UserID)
+	}
+	if filter.UserOnly {
+		where.AddIsNull("group_id")
+	}
+
+	query := `
+		SELECT
+			subscription_id,
+			group_id,
+			user_id,
+			url,
+			created_at
+		FROM
+			webhook_subscriptions
+		` + where.ToClause() + `
+		ORDER BY subscription_id`
+
+	rows, err := tx.Query(query, where.values...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var subscriptions []*planetscale.WebhookSubscription
+	for rows.Next() {
+		var subscription planetscale.WebhookSubscription
+		if err := rows.Scan(&subscription.SubscriptionID, &subscription.GroupID, &subscription.UserID, &subscription.URL, (*NullTime)(&subscription.CreatedAt)); err != nil {
+			return nil, err
+		}
+		subscriptions = append(subscriptions, &subscription)
+	}
+	return subscriptions, rows.Err()
+}
@@ -0,0 +1,81 @@
+package planetscale
+
+import (
+	"net/http"
+	"time"
+)
+
+type (
+	// Event is a committed change of a group pushed live to the members
+	// streaming its events, e.g. "expense.created" carrying the expense.
+	Event struct {
+		// EventID grows with every event published, whatever its group.
+		EventID   int64       `json:"event_id"`
+		GroupID   int64       `json:"group_id"`
+		Type      string      `json:"type"`
+		ActorID   string      `json:"actor_id"`
+		Data      interface{} `json:"data"`
+		CreatedAt time.Time   `json:"created_at"`
+	}
+
+	// EventHub fans events out to the subscribers of their group within this
+	// process. It keeps the latest events of each group so that clients can
+	// resume after reconnecting.
+	EventHub interface {
+		// Publish sets the id of the event and sends it to the subscribers of
+		// its group. Subscribers that fall behind are dropped, their events
+		// channel is closed.
+		Publish(event *Event)
+		// Subscribe streams the events of a group. Events after lastEventID
+		// that are still kept are replayed first, 0 replays nothing.
+		Subscribe(groupID int64, lastEventID int64) (*EventSubscription, error)
+		// Close ends every subscription, later ones fail.
+		Close() error
+	}
+
+	EventSubscription struct {
+		Events <-chan *Event
+		Replay []*Event
+		// Missed is set when events after lastEventID are no longer kept, or
+		// were published by an earlier process. The client has to reload.
+		Missed bool
+		// LastEventID is the id of the latest event published so far.
+		LastEventID int64
+		// Cancel stops the subscription. It is safe to call more than once.
+		Cancel func()
+	}
+
+	EventController interface {
+		HandleGetGroupEvents(w http.ResponseWriter, r *http.Request)
+	}
+)
+
+// NewEvent turns an audited change of a group into an event, named and
+// shaped like its webhook event. It returns nil for changes outside of a
+// group, such as direct expenses.
+func NewEvent(audit *AuditEvent) *Event {
+	if audit.GroupID == nil {
+		return nil
+	}
+	webhook := NewWebhookEvent(audit)
+	return &Event{
+		GroupID: *audit.GroupID,
+		Type:    webhook.Type,
+		ActorID: webhook.ActorID,
+		Data:    webhook.Data,
+	}
+}
+
+// Publish pushes committed changes to the members streaming the events of
+// their group. It must only be called once the transaction went through, and
+// does nothing without an event hub.
+func Publish(events EventHub, audits ...*AuditEvent) {
+	if events == nil {
+		return
+	}
+	for _, audit := range audits {
+		if event := NewEvent(audit); event != nil {
+			events.Publish(event)
+		}
+	}
+}
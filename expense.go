@@ -13,16 +13,30 @@ type (
 		GroupID     *int64    `json:"group_id"`
 		SplitTypeID int64     `json:"split_type_id"`
 		PaidBy      string    `json:"paid_by"`
-		Amount      float64   `json:"amount"`
+		Amount      Money     `json:"amount"`
+		Currency    string    `json:"currency"`
 		Description string    `json:"description"`
 		Timestamp   time.Time `json:"timestamp"`
 		CreatedAt   time.Time `json:"created_at"`
 		UpdatedAt   time.Time `json:"updated_at"`
 		CreatedBy   string    `json:"created_by"`
 		UpdatedBy   string    `json:"updated_by"`
+		DeletedAt   time.Time `json:"deleted_at"`
+
+		// receipt adjustments of item based expenses, allocated over the item
+		// consumers in proportion to what they had
+		Tax           Money `json:"tax"`
+		Tip           Money `json:"tip"`
+		ServiceCharge Money `json:"service_charge"`
+		Discount      Money `json:"discount"`
+
+		// set on expenses materialized from a RecurringExpense
+		RecurringExpenseID *int64 `json:"recurring_expense_id"`
+		RecurrenceIndex    int64  `json:"recurrence_index"`
 
 		PaidByUser   *User                 `json:"paid_by_user"`
 		Participants []*ExpenseParticipant `json:"participants"`
+		Items        []*Item               `json:"items"`
 
 		// for chatgpt use
 		ShareURL string `json:"share_url"`
@@ -33,22 +47,43 @@ type (
 		Create(tx *sql.Tx, expense *Expense) error
 		Upsert(tx *sql.Tx, expense *Expense) error
 		Delete(tx *sql.Tx, expenseID int64) error
+		Restore(tx *sql.Tx, expenseID int64) error
 		Update(tx *sql.Tx, expenseID int64, expense *ExpenseUpdate) (*Expense, error)
 		Find(tx *sql.Tx, filter ExpenseFilter) ([]*Expense, error)
 	}
 
 	ExpenseFilter struct {
-		GroupID int64
+		GroupID   int64
+		GroupIDs  []int64 // any of these groups
+		Direct    bool    // only expenses outside of any group
+		UserID    string  // only expenses the user paid or takes part in
+		Deleted   bool    // list the trash instead of live expenses
+		PaidBy    []string
+		From      *time.Time // inclusive
+		To        *time.Time // exclusive
+		MinAmount *Money
+		MaxAmount *Money
+
+		Sort   string // see SortTimestamp and SortAmount
+		Cursor string // from ExpenseCursor, continues after that expense
+		Limit  int    // 0 for no limit
 	}
 
 	ExpenseUpdate struct {
 		GroupID      *int64                `json:"group_id"`
 		PaidBy       *string               `json:"paid_by"`
-		Amount       *float64              `json:"amount"`
+		Amount       *Money                `json:"amount"`
+		Currency     *string               `json:"currency"`
 		Description  *string               `json:"description"`
 		Timestamp    *time.Time            `json:"timestamp"`
 		UpdatedBy    *string               `json:"updated_by"`
 		Participants []*ExpenseParticipant `json:"participants"`
+
+		// receipt adjustments, only item based expenses have them
+		Tax           *Money `json:"tax"`
+		Tip           *Money `json:"tip"`
+		ServiceCharge *Money `json:"service_charge"`
+		Discount      *Money `json:"discount"`
 	}
 
 	ExpenseConroller interface {
@@ -57,9 +92,47 @@ type (
 		HandleDeleteExpense(w http.ResponseWriter, r *http.Request)
 		HandlePatchExpense(w http.ResponseWriter, r *http.Request)
 		HandleGetGroupExpenses(w http.ResponseWriter, r *http.Request)
+		HandleGetExpenseHistory(w http.ResponseWriter, r *http.Request)
+		HandleRestoreExpense(w http.ResponseWriter, r *http.Request)
 	}
 
 	ExpenseService interface {
+		// CreateExpense adds an expense to its group, or directly between
+		// friends when it has none. Direct expenses have to list their
+		// participants and be created by the payer or one of them.
 		CreateExpense(ctx context.Context, expense *Expense) error
+		// UpdateExpense changes an expense on behalf of userID, who has to be
+		// allowed to edit it, and allocates it again for its split type. The
+		// items of an item based expense have to reconcile with its new
+		// amount and adjustments. It returns the expense before and after the
+		// change.
+		UpdateExpense(ctx context.Context, expenseID int64, update *ExpenseUpdate, userID string) (*Expense, *Expense, error)
+		// CreateItems adds items to an expense userID may edit. The items
+		// of an item based expense are its split: its amount grows by their
+		// cost and its participants are allocated again. The same goes for the
+		// other changes of items and their splits.
+		CreateItems(ctx context.Context, expenseID int64, items []*Item, userID string) error
+		UpdateItem(ctx context.Context, itemID int64, update *ItemUpdate, userID string) (*Item, error)
+		DeleteItem(ctx context.Context, itemID int64, userID string) error
+		CreateItemSplit(ctx context.Context, itemID int64, split *ItemSplit, userID string) error
+		UpdateItemSplit(ctx context.Context, itemID int64, itemSplitID int64, update *ItemSplitUpdate, userID string) (*ItemSplit, error)
+		DeleteItemSplit(ctx context.Context, itemID int64, itemSplitID int64, userID string) error
+		// ClaimItemSplit hands a guest split over to userID, who has to be a
+		// member of the expense's group, or take part in it when it has none.
+		ClaimItemSplit(ctx context.Context, itemSplitID int64, userID string) (*ItemSplit, error)
 	}
 )
+
+// Involves reports whether the user paid for, created or takes part in the
+// expense. Participants have to be loaded.
+func (e *Expense) Involves(userID string) bool {
+	if e.PaidBy == userID || e.CreatedBy == userID {
+		return true
+	}
+	for _, participant := range e.Participants {
+		if participant.UserID == userID {
+			return true
+		}
+	}
+	return false
+}
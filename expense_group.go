@@ -10,6 +10,7 @@ type (
 	ExpenseGroup struct {
 		ExpenseGroupID int64     `json:"group_id"`
 		GroupName      string    `json:"group_name"`
+		BaseCurrency   string    `json:"base_currency"`
 		CreatedAt      time.Time `json:"created_at"`
 		UpdatedAt      time.Time `json:"updated_at"`
 		CreateBy       string    `json:"created_by"`
@@ -21,6 +22,7 @@ type (
 		Create(tx *sql.Tx, group *ExpenseGroup) error
 		Update(tx *sql.Tx, groupID int64, update *ExpenseGroupUpdate) (*ExpenseGroup, error)
 		Delete(tx *sql.Tx, groupID int64) error
+		Restore(tx *sql.Tx, groupID int64) error
 		ListAllForUser(tx *sql.Tx, userID string) ([]*ExpenseGroup, error)
 	}
 
@@ -31,9 +33,17 @@ type (
 		HandleDeleteExpenseGroup(w http.ResponseWriter, r *http.Request)
 		HandleGetExpenseGroup(w http.ResponseWriter, r *http.Request)
 		HandleGetGroupBalances(w http.ResponseWriter, r *http.Request)
+		HandleGetSettlePlan(w http.ResponseWriter, r *http.Request)
+		HandlePostSettlePlan(w http.ResponseWriter, r *http.Request)
+		HandleGetGroupHistory(w http.ResponseWriter, r *http.Request)
+		HandleRestoreExpenseGroup(w http.ResponseWriter, r *http.Request)
+		HandleGetGroupTrash(w http.ResponseWriter, r *http.Request)
+		HandleExportLedger(w http.ResponseWriter, r *http.Request)
+		HandleImportLedger(w http.ResponseWriter, r *http.Request)
 	}
 
 	ExpenseGroupUpdate struct {
-		GroupName string `json:"group_name"`
+		GroupName    string  `json:"group_name"`
+		BaseCurrency *string `json:"base_currency"`
 	}
 )
@@ -6,8 +6,9 @@ type (
 	ExpenseParticipant struct {
 		ExpenseID       int64   `json:"expense_id"`
 		UserID          string  `json:"user_id"`
-		AmountOwed      float64 `json:"amount_owed"`
+		AmountOwed      Money   `json:"amount_owed"`
 		SharePercentage float64 `json:"share_percentage"`
+		Shares          int64   `json:"shares"`
 		Note            string  `json:"note"`
 	}
 
@@ -21,13 +22,15 @@ type (
 	}
 
 	ExpenseParticipantUpdate struct {
-		AmountOwed      *float64 `json:"amount_owed"`
+		AmountOwed      *Money   `json:"amount_owed"`
 		SharePercentage *float64 `json:"share_percentage"`
+		Shares          *int64   `json:"shares"`
 		SplitMethod     *string  `json:"split_method"`
 		Note            *string  `json:"note"`
 	}
 
 	ExpenseParticipantFilter struct {
-		ExpenseID int64
+		ExpenseID  int64
+		ExpenseIDs []int64 // any of these expenses
 	}
 )
@@ -0,0 +1,89 @@
+// Package fs keeps blobs, such as the files attached to expenses, on the
+// local filesystem.
+package fs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	planetscale "github.com/harshav17/planet_scale"
+)
+
+type blobStore struct {
+	root string
+}
+
+// NewBlobStore stores every blob as a file named after its key in root.
+func NewBlobStore(root string) *blobStore {
+	return &blobStore{
+		root: root,
+	}
+}
+
+// Open creates the root directory if it does not exist yet.
+func (s *blobStore) Open() error {
+	return os.MkdirAll(s.root, 0o750)
+}
+
+// Put writes to a temporary file first so a failed upload never leaves a
+// partial blob behind under key.
+func (s *blobStore) Put(ctx context.Context, key string, r io.Reader) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(s.root, ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+func (s *blobStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, planetscale.Errorf(planetscale.ENOTFOUND, "no blob found with key %s", key)
+	}
+	return f, err
+}
+
+func (s *blobStore) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// path keeps keys from reaching outside of the root directory.
+func (s *blobStore) path(key string) (string, error) {
+	if key == "" || strings.HasPrefix(key, ".") || strings.ContainsAny(key, `/\`) {
+		return "", planetscale.Errorf(planetscale.EINVALID, "invalid blob key %q", key)
+	}
+	return filepath.Join(s.root, key), nil
+}
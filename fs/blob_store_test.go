@@ -0,0 +1,72 @@
+package fs
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	planetscale "github.com/harshav17/planet_scale"
+)
+
+func TestBlobStore_All(t *testing.T) {
+	ctx := context.Background()
+	root := filepath.Join(t.TempDir(), "blobs")
+	s := NewBlobStore(root)
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("put, get and delete", func(t *testing.T) {
+		if err := s.Put(ctx, "test-key", strings.NewReader("receipt")); err != nil {
+			t.Fatal(err)
+		}
+
+		rc, err := s.Get(ctx, "test-key")
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		} else if string(b) != "receipt" {
+			t.Fatalf("expected %q, got %q", "receipt", b)
+		}
+
+		if err := s.Delete(ctx, "test-key"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.Get(ctx, "test-key"); planetscale.ErrorCode(err) != planetscale.ENOTFOUND {
+			t.Fatalf("expected ENOTFOUND, got %v", err)
+		}
+
+		// deleting again is fine
+		if err := s.Delete(ctx, "test-key"); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("no temporary files are left behind", func(t *testing.T) {
+		if err := s.Put(ctx, "test-key-2", strings.NewReader("receipt")); err != nil {
+			t.Fatal(err)
+		}
+
+		entries, err := os.ReadDir(root)
+		if err != nil {
+			t.Fatal(err)
+		} else if len(entries) != 1 || entries[0].Name() != "test-key-2" {
+			t.Fatalf("expected only the blob in %s, got %v", root, entries)
+		}
+	})
+
+	t.Run("keys cannot leave the root", func(t *testing.T) {
+		for _, key := range []string{"", "../test-key", "a/b", `a\b`, ".."} {
+			if err := s.Put(ctx, key, strings.NewReader("receipt")); planetscale.ErrorCode(err) != planetscale.EINVALID {
+				t.Fatalf("%q: expected EINVALID, got %v", key, err)
+			}
+		}
+	})
+}
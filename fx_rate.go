@@ -0,0 +1,57 @@
+package planetscale
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"strings"
+	"time"
+)
+
+// DefaultCurrency is used for groups and expenses created without a currency.
+const DefaultCurrency = "USD"
+
+type (
+	// FXRate is the price of one unit of BaseCurrency in QuoteCurrency on the
+	// AsOf date, e.g. base EUR, quote USD, rate 1.08.
+	FXRate struct {
+		BaseCurrency  string    `json:"base_currency"`
+		QuoteCurrency string    `json:"quote_currency"`
+		Rate          float64   `json:"rate"`
+		AsOf          time.Time `json:"as_of"`
+	}
+
+	FXRateRepo interface {
+		// GetAsOf returns the most recent rate published on or before asOf.
+		GetAsOf(tx *sql.Tx, baseCurrency, quoteCurrency string, asOf time.Time) (*FXRate, error)
+		Upsert(tx *sql.Tx, rate *FXRate) error
+		Find(tx *sql.Tx, filter FXRateFilter) ([]*FXRate, error)
+	}
+
+	FXRateFilter struct {
+		BaseCurrency  string
+		QuoteCurrency string
+	}
+
+	FXService interface {
+		// ImportCSV loads rates from CSV rows of the form
+		// "as_of,base_currency,quote_currency,rate" and returns how many were
+		// stored. A header row is skipped.
+		ImportCSV(ctx context.Context, r io.Reader) (int, error)
+	}
+)
+
+// NormalizeCurrency upper cases an ISO 4217 currency code and checks that it
+// is three letters long.
+func NormalizeCurrency(code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if len(code) != 3 {
+		return "", Errorf(EINVALID, "invalid currency code %q", code)
+	}
+	for _, r := range code {
+		if r < 'A' || r > 'Z' {
+			return "", Errorf(EINVALID, "invalid currency code %q", code)
+		}
+	}
+	return code, nil
+}
@@ -1,38 +1,134 @@
 package planetscale
 
 import (
+	"context"
 	"database/sql"
 	"net/http"
 	"time"
 )
 
+// Roles of group members, from most to least privileged. Every group has
+// exactly one owner.
+const (
+	RoleOwner  = "owner"
+	RoleAdmin  = "admin"
+	RoleMember = "member"
+	RoleViewer = "viewer"
+)
+
+// Permissions granted by roles.
+const (
+	// PermissionView lets a member see the group, its expenses and balances.
+	PermissionView Permission = "view"
+	// PermissionEdit lets a member add expenses, settlements and members, and
+	// change or delete what they created or paid.
+	PermissionEdit Permission = "edit"
+	// PermissionEditAll lets a member change or delete anything in the group.
+	PermissionEditAll Permission = "edit_all"
+	// PermissionManage lets a member rename the group and change the role of,
+	// or remove, members ranked below them.
+	PermissionManage Permission = "manage"
+	// PermissionOwn lets a member delete the group and hand it over.
+	PermissionOwn Permission = "own"
+)
+
+var rolePermissions = map[string][]Permission{
+	RoleOwner:  {PermissionView, PermissionEdit, PermissionEditAll, PermissionManage, PermissionOwn},
+	RoleAdmin:  {PermissionView, PermissionEdit, PermissionEditAll, PermissionManage},
+	RoleMember: {PermissionView, PermissionEdit},
+	RoleViewer: {PermissionView},
+}
+
+var roleRanks = map[string]int{
+	RoleOwner:  4,
+	RoleAdmin:  3,
+	RoleMember: 2,
+	RoleViewer: 1,
+}
+
 type (
+	Permission string
+
 	GroupMember struct {
 		GroupID  int64     `json:"group_id"`
 		UserID   string    `json:"user_id"`
+		Role     string    `json:"role"`
 		JoinedAt time.Time `json:"joined_at"`
+		// LeftAt is set once the member left or was removed. Former members
+		// keep their row so their expenses still count in the balances.
+		LeftAt time.Time `json:"left_at"`
 
 		User *User `json:"user"`
 	}
 
+	// GroupMemberRepo only gets active members, and Get only those of groups
+	// that are not deleted. Delete marks a member as inactive and Create
+	// brings an inactive member back.
 	GroupMemberRepo interface {
 		Get(tx *sql.Tx, groupID int64, userID string) (*GroupMember, error)
+		// GetIncludingDeletedGroup is Get for what stays reachable once the
+		// group is deleted, such as its trash.
+		GetIncludingDeletedGroup(tx *sql.Tx, groupID int64, userID string) (*GroupMember, error)
 		Create(tx *sql.Tx, group *GroupMember) error
+		Update(tx *sql.Tx, groupID int64, userID string, update *GroupMemberUpdate) (*GroupMember, error)
 		Delete(tx *sql.Tx, groupID int64, userID string) error
 		Find(tx *sql.Tx, filter GroupMemberFilter) ([]*GroupMember, error)
 	}
 
 	GroupMemberUpdate struct {
-		GroupName string `json:"group_name"`
+		Role *string `json:"role"`
 	}
 
 	GroupMemberFilter struct {
 		GroupID int64
+		// Inactive finds former members instead of active ones.
+		Inactive bool
+	}
+
+	// TransferOwnership names the member a group is handed over to.
+	TransferOwnership struct {
+		UserID string `json:"user_id"`
+	}
+
+	GroupMemberService interface {
+		// Remove marks the member as inactive. Members may leave on their
+		// own, except the owner, and admins remove those they outrank. It
+		// fails with ECONFLICT while their balance in the group is not
+		// settled, unless an admin sets force.
+		Remove(ctx context.Context, groupID int64, userID string, actorID string, force bool) error
 	}
 
 	GroupMemberController interface {
 		HandleGetGroupMembers(w http.ResponseWriter, r *http.Request)
 		HandlePostGroupMember(w http.ResponseWriter, r *http.Request)
+		HandlePatchGroupMember(w http.ResponseWriter, r *http.Request)
 		HandleDeleteGroupMember(w http.ResponseWriter, r *http.Request)
+		HandleTransferOwnership(w http.ResponseWriter, r *http.Request)
 	}
 )
+
+// ValidRole reports whether role is one of the known roles.
+func ValidRole(role string) bool {
+	_, ok := roleRanks[role]
+	return ok
+}
+
+// Can reports whether the member's role grants permission.
+func (m *GroupMember) Can(permission Permission) bool {
+	for _, p := range rolePermissions[m.Role] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// Active reports whether the member is still part of the group.
+func (m *GroupMember) Active() bool {
+	return m.LeftAt.IsZero()
+}
+
+// Outranks reports whether the member's role is above role.
+func (m *GroupMember) Outranks(role string) bool {
+	return roleRanks[m.Role] > roleRanks[role]
+}
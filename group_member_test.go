@@ -0,0 +1,41 @@
+package planetscale
+
+import "testing"
+
+func TestGroupMember_Can(t *testing.T) {
+	tests := []struct {
+		role       string
+		permission Permission
+		expected   bool
+	}{
+		{RoleOwner, PermissionOwn, true},
+		{RoleAdmin, PermissionOwn, false},
+		{RoleAdmin, PermissionManage, true},
+		{RoleMember, PermissionManage, false},
+		{RoleMember, PermissionEdit, true},
+		{RoleMember, PermissionEditAll, false},
+		{RoleViewer, PermissionEdit, false},
+		{RoleViewer, PermissionView, true},
+		{"", PermissionView, false},
+	}
+
+	for _, test := range tests {
+		member := &GroupMember{Role: test.role}
+		if got := member.Can(test.permission); got != test.expected {
+			t.Errorf("%q can %s: expected %v, got %v", test.role, test.permission, test.expected, got)
+		}
+	}
+}
+
+func TestGroupMember_Outranks(t *testing.T) {
+	owner := &GroupMember{Role: RoleOwner}
+	admin := &GroupMember{Role: RoleAdmin}
+
+	if !owner.Outranks(RoleAdmin) {
+		t.Error("expected the owner to outrank admins")
+	} else if admin.Outranks(RoleAdmin) {
+		t.Error("expected admins not to outrank each other")
+	} else if !admin.Outranks(RoleMember) {
+		t.Error("expected admins to outrank members")
+	}
+}
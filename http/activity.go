@@ -0,0 +1,266 @@
+package http
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	planetscale "github.com/harshav17/planet_scale"
+)
+
+// userFeed is the group id of the read marker of a user's own feed.
+const userFeed = 0
+
+type activityController struct {
+	repos *planetscale.RepoProvider
+	tm    planetscale.TransactionManager
+}
+
+func NewActivityController(repos *planetscale.RepoProvider, tm planetscale.TransactionManager) *activityController {
+	return &activityController{
+		repos: repos,
+		tm:    tm,
+	}
+}
+
+type findActivitiesResponse struct {
+	Activities []*planetscale.Activity `json:"activities"`
+	N          int                     `json:"n"`
+	NextCursor string                  `json:"next_cursor,omitempty"`
+	// LastReadID is the id of the last activity the caller read, activities
+	// of others after it are counted as unread.
+	LastReadID int64 `json:"last_read_activity_id"`
+	Unread     int   `json:"unread"`
+}
+
+type activityReadRequest struct {
+	ActivityID int64 `json:"activity_id"`
+}
+
+// HandleGetGroupActivity handles the GET /groups/{groupID}/activity endpoint.
+// It lists the activity of a group newest first, a page at a time.
+func (c *activityController) HandleGetGroupActivity(w http.ResponseWriter, r *http.Request) {
+	user, found := planetscale.UserFromContext(r.Context())
+	if !found {
+		Error(w, r, planetscale.Errorf(planetscale.ENOTFOUND, "user context not set"))
+		return
+	}
+
+	group32, err := strconv.Atoi(chi.URLParam(r, "groupID"))
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+	groupID := int64(group32)
+
+	params, err := parseListParams(r)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	var response findActivitiesResponse
+	getActivityFunc := func(tx *sql.Tx) error {
+		_, err := planetscale.CheckPermission(tx, c.repos, groupID, user.UserID, planetscale.PermissionView)
+		if err != nil {
+			return err
+		}
+
+		response, err = c.findActivities(tx, user.UserID, groupID, planetscale.ActivityFilter{
+			GroupID: groupID,
+			Cursor:  params.Cursor,
+			Limit:   params.Limit,
+		})
+		return err
+	}
+
+	err = c.tm.ExecuteInTx(r.Context(), getActivityFunc)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		Error(w, r, err)
+		return
+	}
+}
+
+// HandlePostGroupActivityRead handles the POST /groups/{groupID}/activity/read
+// endpoint. It marks the activity of a group read up to the activity_id of
+// the body, or up to the latest activity without one.
+func (c *activityController) HandlePostGroupActivityRead(w http.ResponseWriter, r *http.Request) {
+	user, found := planetscale.UserFromContext(r.Context())
+	if !found {
+		Error(w, r, planetscale.Errorf(planetscale.ENOTFOUND, "user context not set"))
+		return
+	}
+
+	group32, err := strconv.Atoi(chi.URLParam(r, "groupID"))
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+	groupID := int64(group32)
+
+	read, err := receiveActivityRead(w, r)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	markReadFunc := func(tx *sql.Tx) error {
+		_, err := planetscale.CheckPermission(tx, c.repos, groupID, user.UserID, planetscale.PermissionView)
+		if err != nil {
+			return err
+		}
+
+		return c.markRead(tx, user.UserID, groupID, read.ActivityID, planetscale.ActivityFilter{
+			GroupID: groupID,
+		})
+	}
+
+	err = c.tm.ExecuteInTx(r.Context(), markReadFunc)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleGetUserActivity handles the GET /me/activity endpoint. It lists the
+// activity of the caller's groups and direct expenses newest first.
+func (c *activityController) HandleGetUserActivity(w http.ResponseWriter, r *http.Request) {
+	user, found := planetscale.UserFromContext(r.Context())
+	if !found {
+		Error(w, r, planetscale.Errorf(planetscale.ENOTFOUND, "user context not set"))
+		return
+	}
+
+	params, err := parseListParams(r)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	var response findActivitiesResponse
+	getActivityFunc := func(tx *sql.Tx) error {
+		var err error
+		response, err = c.findActivities(tx, user.UserID, userFeed, planetscale.ActivityFilter{
+			UserID: user.UserID,
+			Cursor: params.Cursor,
+			Limit:  params.Limit,
+		})
+		return err
+	}
+
+	err = c.tm.ExecuteInTx(r.Context(), getActivityFunc)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		Error(w, r, err)
+		return
+	}
+}
+
+// HandlePostUserActivityRead handles the POST /me/activity/read endpoint, the
+// counterpart of HandlePostGroupActivityRead for the caller's own feed.
+func (c *activityController) HandlePostUserActivityRead(w http.ResponseWriter, r *http.Request) {
+	user, found := planetscale.UserFromContext(r.Context())
+	if !found {
+		Error(w, r, planetscale.Errorf(planetscale.ENOTFOUND, "user context not set"))
+		return
+	}
+
+	read, err := receiveActivityRead(w, r)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	markReadFunc := func(tx *sql.Tx) error {
+		return c.markRead(tx, user.UserID, userFeed, read.ActivityID, planetscale.ActivityFilter{
+			UserID: user.UserID,
+		})
+	}
+
+	err = c.tm.ExecuteInTx(r.Context(), markReadFunc)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// findActivities lists a page of a feed along with the unread count of the
+// user, which leaves out what they did themselves.
+func (c *activityController) findActivities(tx *sql.Tx, userID string, feed int64, filter planetscale.ActivityFilter) (findActivitiesResponse, error) {
+	var response findActivitiesResponse
+	activities, err := c.repos.Activity.Find(tx, filter)
+	if err != nil {
+		return response, err
+	}
+	if activities == nil {
+		activities = []*planetscale.Activity{}
+	}
+	response.Activities = activities
+	response.N = len(activities)
+	// a full page may be followed by more activities
+	if len(activities) == filter.Limit {
+		response.NextCursor = planetscale.ActivityCursor(activities[len(activities)-1])
+	}
+
+	response.LastReadID, err = c.repos.Activity.GetReadMarker(tx, userID, feed)
+	if err != nil {
+		return response, err
+	}
+	response.Unread, err = c.repos.Activity.Count(tx, planetscale.ActivityFilter{
+		GroupID:      filter.GroupID,
+		UserID:       filter.UserID,
+		After:        response.LastReadID,
+		ExcludeActor: userID,
+	})
+	return response, err
+}
+
+// markRead moves the read marker of a feed to activityID. It is capped at the
+// latest activity of the feed, so that what comes next is still unread.
+func (c *activityController) markRead(tx *sql.Tx, userID string, feed int64, activityID int64, filter planetscale.ActivityFilter) error {
+	filter.Limit = 1
+	latest, err := c.repos.Activity.Find(tx, filter)
+	if err != nil {
+		return err
+	}
+	if len(latest) == 0 {
+		return nil
+	}
+	if activityID == 0 || activityID > latest[0].ActivityID {
+		activityID = latest[0].ActivityID
+	}
+	return c.repos.Activity.MarkRead(tx, userID, feed, activityID)
+}
+
+// receiveActivityRead reads the optional body of a mark read request.
+func receiveActivityRead(w http.ResponseWriter, r *http.Request) (*activityReadRequest, error) {
+	read := &activityReadRequest{}
+	if r.ContentLength == 0 {
+		return read, nil
+	}
+	err := ReceiveJson(w, r, read)
+	if err != nil {
+		return nil, err
+	}
+	if read.ActivityID < 0 {
+		return nil, planetscale.Errorf(planetscale.EINVALID, "activity_id must be positive")
+	}
+	return read, nil
+}
@@ -0,0 +1,161 @@
+package http
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	planetscale "github.com/harshav17/planet_scale"
+	db_mock "github.com/harshav17/planet_scale/mock/db"
+)
+
+func TestHandleActivity_All(t *testing.T) {
+	server := MustOpenServer(t)
+	defer MustCloseServer(t, server.Server)
+
+	server.repos.GroupMember = mockGroupMembers(map[string]string{
+		"alice": planetscale.RoleOwner,
+		"bob":   planetscale.RoleViewer,
+	})
+
+	groupID := int64(1)
+	now := time.Now().UTC().Truncate(time.Second)
+	// newest first, like the repo returns them
+	activities := []*planetscale.Activity{
+		{ActivityID: 3, GroupID: &groupID, EntityType: planetscale.AuditEntitySettlement, Action: planetscale.AuditActionCreate, ActorID: "alice", UserID: "bob", Amount: 20_00, Currency: "USD", CreatedAt: now},
+		{ActivityID: 2, GroupID: &groupID, EntityType: planetscale.AuditEntityExpense, Action: planetscale.AuditActionCreate, ActorID: "bob", Subject: "Dinner", Amount: 40_00, Currency: "USD", CreatedAt: now},
+		{ActivityID: 1, GroupID: &groupID, EntityType: planetscale.AuditEntityGroupMember, Action: planetscale.AuditActionCreate, ActorID: "alice", UserID: "bob", CreatedAt: now},
+	}
+	markers := map[string]int64{}
+	server.repos.Activity = &db_mock.ActivityRepo{
+		FindFn: func(tx *sql.Tx, filter planetscale.ActivityFilter) ([]*planetscale.Activity, error) {
+			var found []*planetscale.Activity
+			for _, activity := range activities {
+				if filter.Limit > 0 && len(found) == filter.Limit {
+					break
+				}
+				activity.Render()
+				found = append(found, activity)
+			}
+			return found, nil
+		},
+		CountFn: func(tx *sql.Tx, filter planetscale.ActivityFilter) (int, error) {
+			count := 0
+			for _, activity := range activities {
+				if activity.ActivityID > filter.After && activity.ActorID != filter.ExcludeActor {
+					count++
+				}
+			}
+			return count, nil
+		},
+		GetReadMarkerFn: func(tx *sql.Tx, userID string, groupID int64) (int64, error) {
+			return markers[fmt.Sprintf("%s/%d", userID, groupID)], nil
+		},
+		MarkReadFn: func(tx *sql.Tx, userID string, groupID int64, activityID int64) error {
+			markers[fmt.Sprintf("%s/%d", userID, groupID)] = activityID
+			return nil
+		},
+	}
+
+	serve := func(t *testing.T, userID, method, url string, body any) *httptest.ResponseRecorder {
+		t.Helper()
+		var b []byte
+		if body != nil {
+			var err error
+			b, err = json.Marshal(body)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		req, err := http.NewRequest(method, url, bytes.NewReader(b))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+server.buildJWTForTesting(t, userID))
+
+		rr := httptest.NewRecorder()
+		server.router.ServeHTTP(rr, req)
+		return rr
+	}
+	feed := func(t *testing.T, rr *httptest.ResponseRecorder) findActivitiesResponse {
+		t.Helper()
+		if status := rr.Code; status != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d: %s", http.StatusOK, status, rr.Body)
+		}
+		var got findActivitiesResponse
+		if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+			t.Fatal(err)
+		}
+		return got
+	}
+
+	t.Run("GET /groups/1/activity", func(t *testing.T) {
+		t.Run("first page", func(t *testing.T) {
+			got := feed(t, serve(t, "bob", "GET", "/groups/1/activity?limit=2", nil))
+			if got.N != 2 || got.Activities[0].ActivityID != 3 {
+				t.Fatalf("expected the 2 latest activities, got %+v", got.Activities)
+			} else if got.Activities[0].Summary != "alice paid bob 20.00 USD" {
+				t.Fatalf("unexpected summary %q", got.Activities[0].Summary)
+			} else if got.NextCursor == "" {
+				t.Fatal("expected a next cursor")
+			} else if got.Unread != 2 || got.LastReadID != 0 {
+				t.Fatalf("expected 2 unread activities of others, got %d after %d", got.Unread, got.LastReadID)
+			}
+		})
+
+		t.Run("not a member", func(t *testing.T) {
+			rr := serve(t, "carol", "GET", "/groups/1/activity", nil)
+			if status := rr.Code; status != http.StatusNotFound {
+				t.Fatalf("expected status code %d, got %d", http.StatusNotFound, status)
+			}
+		})
+	})
+
+	t.Run("POST /groups/1/activity/read", func(t *testing.T) {
+		t.Run("up to an activity", func(t *testing.T) {
+			rr := serve(t, "bob", "POST", "/groups/1/activity/read", activityReadRequest{ActivityID: 1})
+			if status := rr.Code; status != http.StatusNoContent {
+				t.Fatalf("expected status code %d, got %d: %s", http.StatusNoContent, status, rr.Body)
+			}
+
+			got := feed(t, serve(t, "bob", "GET", "/groups/1/activity", nil))
+			if got.LastReadID != 1 || got.Unread != 1 {
+				t.Fatalf("expected 1 unread activity after 1, got %d after %d", got.Unread, got.LastReadID)
+			} else if got.NextCursor != "" {
+				t.Fatal("expected no next cursor")
+			}
+		})
+
+		t.Run("past the latest activity", func(t *testing.T) {
+			rr := serve(t, "bob", "POST", "/groups/1/activity/read", activityReadRequest{ActivityID: 100})
+			if status := rr.Code; status != http.StatusNoContent {
+				t.Fatalf("expected status code %d, got %d: %s", http.StatusNoContent, status, rr.Body)
+			} else if markers["bob/1"] != 3 {
+				t.Fatalf("expected the marker to stop at the latest activity, got %d", markers["bob/1"])
+			}
+		})
+	})
+
+	t.Run("GET /me/activity", func(t *testing.T) {
+		rr := serve(t, "alice", "POST", "/me/activity/read", nil)
+		if status := rr.Code; status != http.StatusNoContent {
+			t.Fatalf("expected status code %d, got %d: %s", http.StatusNoContent, status, rr.Body)
+		}
+
+		got := feed(t, serve(t, "alice", "GET", "/me/activity", nil))
+		if got.N != 3 {
+			t.Fatalf("expected 3 activities, got %d", got.N)
+		} else if got.LastReadID != 3 || got.Unread != 0 {
+			t.Fatalf("expected everything to be read, got %d after %d", got.Unread, got.LastReadID)
+		} else if markers["alice/0"] != 3 {
+			t.Fatalf("expected the marker of the user's own feed to be set, got %v", markers)
+		}
+	})
+}
@@ -0,0 +1,324 @@
+package http
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	planetscale "github.com/harshav17/planet_scale"
+)
+
+type attachmentController struct {
+	repos    *planetscale.RepoProvider
+	services *planetscale.ServiceProvider
+	tm       planetscale.TransactionManager
+}
+
+func NewAttachmentController(repos *planetscale.RepoProvider, services *planetscale.ServiceProvider, tm planetscale.TransactionManager) *attachmentController {
+	return &attachmentController{
+		repos:    repos,
+		services: services,
+		tm:       tm,
+	}
+}
+
+// HandlePostAttachment handles the POST /expenses/{expenseID}/attachments
+// endpoint. The file is sent as the "file" field of a multipart form.
+func (c *attachmentController) HandlePostAttachment(w http.ResponseWriter, r *http.Request) {
+	user, found := planetscale.UserFromContext(r.Context())
+	if !found {
+		Error(w, r, planetscale.Errorf(planetscale.ENOTFOUND, "user context not set"))
+		return
+	}
+
+	expense32, err := strconv.Atoi(chi.URLParam(r, "expenseID"))
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+	expenseID := int64(expense32)
+
+	// leave room for the rest of the form around the file
+	r.Body = http.MaxBytesReader(w, r.Body, planetscale.MaxAttachmentSize+1<<20)
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			Error(w, r, planetscale.Errorf(planetscale.EINVALID, "attachments cannot be larger than %d bytes", planetscale.MaxAttachmentSize))
+			return
+		}
+		Error(w, r, planetscale.Errorf(planetscale.EINVALID, "missing file: %s", err))
+		return
+	}
+	defer file.Close()
+
+	if header.Size > planetscale.MaxAttachmentSize {
+		Error(w, r, planetscale.Errorf(planetscale.EINVALID, "attachments cannot be larger than %d bytes", planetscale.MaxAttachmentSize))
+		return
+	}
+
+	// trust the content over what the client says it is
+	sniff := make([]byte, 512)
+	n, err := io.ReadFull(file, sniff)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		Error(w, r, err)
+		return
+	}
+	contentType := http.DetectContentType(sniff[:n])
+	if !planetscale.AttachmentContentTypes[contentType] {
+		Error(w, r, planetscale.Errorf(planetscale.EINVALID, "attachments of type %s are not allowed", contentType))
+		return
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	key, err := newAttachmentKey()
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	attachment := &planetscale.Attachment{
+		ExpenseID:   expenseID,
+		FileName:    filepath.Base(header.Filename),
+		ContentType: contentType,
+		Size:        header.Size,
+		Key:         key,
+		CreatedBy:   user.UserID,
+	}
+	var audit *planetscale.AuditEvent
+	createAttachmentFunc := func(tx *sql.Tx) error {
+		expense, _, err := planetscale.GetMemberExpense(tx, c.repos, expenseID, user.UserID, planetscale.PermissionEdit)
+		if err != nil {
+			return err
+		}
+
+		err = c.services.Blob.Put(r.Context(), key, file)
+		if err != nil {
+			return err
+		}
+
+		err = c.repos.Attachment.Create(tx, attachment)
+		if err != nil {
+			return err
+		}
+
+		audit = &planetscale.AuditEvent{
+			GroupID:    expense.GroupID,
+			EntityType: planetscale.AuditEntityAttachment,
+			EntityID:   strconv.FormatInt(attachment.AttachmentID, 10),
+			Action:     planetscale.AuditActionCreate,
+			ActorID:    user.UserID,
+			After:      attachment,
+		}
+		return c.repos.AuditEvent.Create(tx, audit)
+	}
+
+	err = c.tm.ExecuteInTx(r.Context(), createAttachmentFunc)
+	if err != nil {
+		// the blob may have been stored before the transaction failed
+		if err := c.services.Blob.Delete(r.Context(), key); err != nil {
+			LogError(r, err)
+		}
+		Error(w, r, err)
+		return
+	}
+	planetscale.Publish(c.services.Events, audit)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(attachment); err != nil {
+		Error(w, r, err)
+		return
+	}
+}
+
+type findAttachmentsResponse struct {
+	Attachments []*planetscale.Attachment `json:"attachments"`
+	N           int                       `json:"n"`
+}
+
+// HandleGetExpenseAttachments handles the GET /expenses/{expenseID}/attachments
+// endpoint.
+func (c *attachmentController) HandleGetExpenseAttachments(w http.ResponseWriter, r *http.Request) {
+	user, found := planetscale.UserFromContext(r.Context())
+	if !found {
+		Error(w, r, planetscale.Errorf(planetscale.ENOTFOUND, "user context not set"))
+		return
+	}
+
+	expense32, err := strconv.Atoi(chi.URLParam(r, "expenseID"))
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+	expenseID := int64(expense32)
+
+	var attachments []*planetscale.Attachment
+	getAttachmentsFunc := func(tx *sql.Tx) error {
+		_, _, err := planetscale.GetMemberExpense(tx, c.repos, expenseID, user.UserID, planetscale.PermissionView)
+		if err != nil {
+			return err
+		}
+
+		attachments, err = c.repos.Attachment.Find(tx, planetscale.AttachmentFilter{
+			ExpenseID: expenseID,
+		})
+		return err
+	}
+
+	err = c.tm.ExecuteInTx(r.Context(), getAttachmentsFunc)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(findAttachmentsResponse{
+		Attachments: attachments,
+		N:           len(attachments),
+	}); err != nil {
+		Error(w, r, err)
+		return
+	}
+}
+
+// HandleGetAttachment handles the GET /attachments/{attachmentID} endpoint,
+// which downloads the file itself.
+func (c *attachmentController) HandleGetAttachment(w http.ResponseWriter, r *http.Request) {
+	user, found := planetscale.UserFromContext(r.Context())
+	if !found {
+		Error(w, r, planetscale.Errorf(planetscale.ENOTFOUND, "user context not set"))
+		return
+	}
+
+	attachment32, err := strconv.Atoi(chi.URLParam(r, "attachmentID"))
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+	attachmentID := int64(attachment32)
+
+	var attachment *planetscale.Attachment
+	getAttachmentFunc := func(tx *sql.Tx) error {
+		attachment, _, _, err = c.getAttachment(tx, attachmentID, user.UserID, planetscale.PermissionView)
+		return err
+	}
+
+	err = c.tm.ExecuteInTx(r.Context(), getAttachmentFunc)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	blob, err := c.services.Blob.Get(r.Context(), attachment.Key)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+	defer blob.Close()
+
+	w.Header().Set("Content-Type", attachment.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(attachment.Size, 10))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("inline", map[string]string{"filename": attachment.FileName}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if _, err := io.Copy(w, blob); err != nil {
+		LogError(r, err)
+	}
+}
+
+// HandleDeleteAttachment handles the DELETE /attachments/{attachmentID}
+// endpoint.
+func (c *attachmentController) HandleDeleteAttachment(w http.ResponseWriter, r *http.Request) {
+	user, found := planetscale.UserFromContext(r.Context())
+	if !found {
+		Error(w, r, planetscale.Errorf(planetscale.ENOTFOUND, "user context not set"))
+		return
+	}
+
+	attachment32, err := strconv.Atoi(chi.URLParam(r, "attachmentID"))
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+	attachmentID := int64(attachment32)
+
+	var attachment *planetscale.Attachment
+	var audit *planetscale.AuditEvent
+	deleteAttachmentFunc := func(tx *sql.Tx) error {
+		var expense *planetscale.Expense
+		var member *planetscale.GroupMember
+		attachment, expense, member, err = c.getAttachment(tx, attachmentID, user.UserID, planetscale.PermissionEdit)
+		if err != nil {
+			return err
+		}
+		err = planetscale.CheckAuthor(member, attachment.CreatedBy)
+		if err != nil {
+			return err
+		}
+
+		err = c.repos.Attachment.Delete(tx, attachmentID)
+		if err != nil {
+			return err
+		}
+
+		audit = &planetscale.AuditEvent{
+			GroupID:    expense.GroupID,
+			EntityType: planetscale.AuditEntityAttachment,
+			EntityID:   strconv.FormatInt(attachmentID, 10),
+			Action:     planetscale.AuditActionDelete,
+			ActorID:    user.UserID,
+			Before:     attachment,
+		}
+		return c.repos.AuditEvent.Create(tx, audit)
+	}
+
+	err = c.tm.ExecuteInTx(r.Context(), deleteAttachmentFunc)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+	planetscale.Publish(c.services.Events, audit)
+
+	// the attachment is gone either way, a leftover blob is only logged
+	if err := c.services.Blob.Delete(r.Context(), attachment.Key); err != nil {
+		LogError(r, err)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getAttachment loads an attachment along with its expense and the user's
+// membership of the expense's group, which has to grant permission.
+func (c *attachmentController) getAttachment(tx *sql.Tx, attachmentID int64, userID string, permission planetscale.Permission) (*planetscale.Attachment, *planetscale.Expense, *planetscale.GroupMember, error) {
+	attachment, err := c.repos.Attachment.Get(tx, attachmentID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	expense, member, err := planetscale.GetMemberExpense(tx, c.repos, attachment.ExpenseID, userID, permission)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return attachment, expense, member, nil
+}
+
+// newAttachmentKey returns a random key to store an attachment under, so
+// nothing about the file or expense can be guessed from it.
+func newAttachmentKey() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
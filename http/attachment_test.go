@@ -0,0 +1,214 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	planetscale "github.com/harshav17/planet_scale"
+	db_mock "github.com/harshav17/planet_scale/mock/db"
+	service_mock "github.com/harshav17/planet_scale/mock/service"
+)
+
+func TestHandleAttachment_All(t *testing.T) {
+	server := MustOpenServer(t)
+	defer MustCloseServer(t, server.Server)
+
+	groupID := int64(1)
+	server.repos.Expense = &db_mock.ExpenseRepo{
+		GetFn: func(tx *sql.Tx, expenseID int64) (*planetscale.Expense, error) {
+			return &planetscale.Expense{ExpenseID: expenseID, GroupID: &groupID}, nil
+		},
+	}
+	server.repos.GroupMember = &db_mock.GroupMemberRepo{
+		GetFn: func(tx *sql.Tx, groupID int64, userID string) (*planetscale.GroupMember, error) {
+			if userID != "test-user-id" {
+				return nil, planetscale.Errorf(planetscale.ENOTFOUND, "not a member")
+			}
+			return &planetscale.GroupMember{GroupID: groupID, UserID: userID, Role: planetscale.RoleMember}, nil
+		},
+	}
+
+	png := append([]byte("\x89PNG\r\n\x1a\n"), bytes.Repeat([]byte{0}, 100)...)
+	blobs := map[string][]byte{}
+	server.services.Blob = &service_mock.BlobStore{
+		PutFn: func(ctx context.Context, key string, r io.Reader) error {
+			b, err := io.ReadAll(r)
+			blobs[key] = b
+			return err
+		},
+		GetFn: func(ctx context.Context, key string) (io.ReadCloser, error) {
+			b, ok := blobs[key]
+			if !ok {
+				return nil, planetscale.Errorf(planetscale.ENOTFOUND, "no blob found with key %s", key)
+			}
+			return io.NopCloser(bytes.NewReader(b)), nil
+		},
+		DeleteFn: func(ctx context.Context, key string) error {
+			delete(blobs, key)
+			return nil
+		},
+	}
+	attachments := map[int64]*planetscale.Attachment{}
+	server.repos.Attachment = &db_mock.AttachmentRepo{
+		CreateFn: func(tx *sql.Tx, attachment *planetscale.Attachment) error {
+			attachment.AttachmentID = int64(len(attachments) + 1)
+			attachments[attachment.AttachmentID] = attachment
+			return nil
+		},
+		GetFn: func(tx *sql.Tx, attachmentID int64) (*planetscale.Attachment, error) {
+			attachment, ok := attachments[attachmentID]
+			if !ok {
+				return nil, planetscale.Errorf(planetscale.ENOTFOUND, "no attachment found with ID %d", attachmentID)
+			}
+			return attachment, nil
+		},
+		FindFn: func(tx *sql.Tx, filter planetscale.AttachmentFilter) ([]*planetscale.Attachment, error) {
+			var found []*planetscale.Attachment
+			for _, attachment := range attachments {
+				if attachment.ExpenseID == filter.ExpenseID {
+					found = append(found, attachment)
+				}
+			}
+			return found, nil
+		},
+		DeleteFn: func(tx *sql.Tx, attachmentID int64) error {
+			delete(attachments, attachmentID)
+			return nil
+		},
+	}
+
+	serve := func(t *testing.T, userID string, req *http.Request) *httptest.ResponseRecorder {
+		t.Helper()
+		req.Header.Set("Authorization", "Bearer "+server.buildJWTForTesting(t, userID))
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(server.router.ServeHTTP)
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+	upload := func(t *testing.T, userID, fileName string, content []byte) *httptest.ResponseRecorder {
+		t.Helper()
+		var body bytes.Buffer
+		mw := multipart.NewWriter(&body)
+		fw, err := mw.CreateFormFile("file", fileName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := fw.Write(content); err != nil {
+			t.Fatal(err)
+		}
+		if err := mw.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		req, err := http.NewRequest("POST", "/expenses/1/attachments", &body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", mw.FormDataContentType())
+		return serve(t, userID, req)
+	}
+
+	t.Run("POST /expenses/:id/attachments", func(t *testing.T) {
+		t.Run("successful upload", func(t *testing.T) {
+			rr := upload(t, "test-user-id", "bill.png", png)
+			if status := rr.Code; status != http.StatusCreated {
+				t.Fatalf("expected status code %d, got %d: %s", http.StatusCreated, status, rr.Body)
+			}
+
+			var got planetscale.Attachment
+			if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			} else if got.FileName != "bill.png" || got.ContentType != "image/png" || got.Size != int64(len(png)) {
+				t.Fatalf("unexpected attachment %+v", got)
+			} else if len(blobs) != 1 {
+				t.Fatalf("expected 1 blob, got %d", len(blobs))
+			}
+		})
+
+		t.Run("content type is sniffed", func(t *testing.T) {
+			rr := upload(t, "test-user-id", "bill.png", []byte("<html><script>alert(1)</script></html>"))
+			if status := rr.Code; status != http.StatusBadRequest {
+				t.Fatalf("expected status code %d, got %d", http.StatusBadRequest, status)
+			}
+		})
+
+		t.Run("too large", func(t *testing.T) {
+			content := append(append([]byte{}, png...), make([]byte, planetscale.MaxAttachmentSize)...)
+			rr := upload(t, "test-user-id", "bill.png", content)
+			if status := rr.Code; status != http.StatusBadRequest {
+				t.Fatalf("expected status code %d, got %d", http.StatusBadRequest, status)
+			}
+		})
+
+		t.Run("non members cannot upload", func(t *testing.T) {
+			n := len(blobs)
+			rr := upload(t, "test-user-id-2", "bill.png", png)
+			if status := rr.Code; status != http.StatusNotFound {
+				t.Fatalf("expected status code %d, got %d", http.StatusNotFound, status)
+			} else if len(blobs) != n {
+				t.Fatalf("expected no blob to be left behind, got %d", len(blobs))
+			}
+		})
+	})
+
+	t.Run("GET /expenses/:id/attachments", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/expenses/1/attachments", nil)
+		rr := serve(t, "test-user-id", req)
+		if status := rr.Code; status != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, status)
+		}
+
+		var got findAttachmentsResponse
+		if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+			t.Fatal(err)
+		} else if got.N != 1 {
+			t.Fatalf("expected 1 attachment, got %d", got.N)
+		}
+		for key := range blobs {
+			if strings.Contains(rr.Body.String(), key) {
+				t.Fatal("expected the storage key not to be exposed")
+			}
+		}
+	})
+
+	t.Run("GET /attachments/:id", func(t *testing.T) {
+		t.Run("successful download", func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/attachments/1", nil)
+			rr := serve(t, "test-user-id", req)
+			if status := rr.Code; status != http.StatusOK {
+				t.Fatalf("expected status code %d, got %d", http.StatusOK, status)
+			} else if ct := rr.Header().Get("Content-Type"); ct != "image/png" {
+				t.Fatalf("expected content type image/png, got %s", ct)
+			} else if !bytes.Equal(rr.Body.Bytes(), png) {
+				t.Fatal("expected the uploaded file")
+			}
+		})
+
+		t.Run("non members cannot download", func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/attachments/1", nil)
+			rr := serve(t, "test-user-id-2", req)
+			if status := rr.Code; status != http.StatusNotFound {
+				t.Fatalf("expected status code %d, got %d", http.StatusNotFound, status)
+			}
+		})
+	})
+
+	t.Run("DELETE /attachments/:id", func(t *testing.T) {
+		req, _ := http.NewRequest("DELETE", "/attachments/1", nil)
+		rr := serve(t, "test-user-id", req)
+		if status := rr.Code; status != http.StatusNoContent {
+			t.Fatalf("expected status code %d, got %d", http.StatusNoContent, status)
+		} else if len(attachments) != 0 || len(blobs) != 0 {
+			t.Fatalf("expected the attachment and its blob to be deleted, got %d and %d", len(attachments), len(blobs))
+		}
+	})
+}
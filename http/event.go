@@ -0,0 +1,151 @@
+package http
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	planetscale "github.com/harshav17/planet_scale"
+)
+
+// EventHeartbeatInterval is how often an idle event stream sends a comment,
+// which keeps proxies from closing it.
+const EventHeartbeatInterval = 15 * time.Second
+
+// eventReset tells a client that it missed events and has to reload.
+const eventReset = "reset"
+
+type eventController struct {
+	repos    *planetscale.RepoProvider
+	services *planetscale.ServiceProvider
+	tm       planetscale.TransactionManager
+
+	heartbeat time.Duration
+}
+
+func NewEventController(repos *planetscale.RepoProvider, services *planetscale.ServiceProvider, tm planetscale.TransactionManager) *eventController {
+	return &eventController{
+		repos:     repos,
+		services:  services,
+		tm:        tm,
+		heartbeat: EventHeartbeatInterval,
+	}
+}
+
+// HandleGetGroupEvents handles the GET /groups/{groupID}/events endpoint. It
+// streams the changes of a group as server-sent events until the client goes
+// away, or is no longer allowed to view the group, which is checked again
+// with every heartbeat. Clients that reconnect with a Last-Event-ID header get the events
+// they missed, or a reset event when those are no longer kept.
+func (c *eventController) HandleGetGroupEvents(w http.ResponseWriter, r *http.Request) {
+	user, found := planetscale.UserFromContext(r.Context())
+	if !found {
+		Error(w, r, planetscale.Errorf(planetscale.ENOTFOUND, "user context not set"))
+		return
+	}
+
+	group32, err := strconv.Atoi(chi.URLParam(r, "groupID"))
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+	groupID := int64(group32)
+
+	var lastEventID int64
+	if id := r.Header.Get("Last-Event-ID"); id != "" {
+		lastEventID, err = strconv.ParseInt(id, 10, 64)
+		if err != nil || lastEventID < 0 {
+			Error(w, r, planetscale.Errorf(planetscale.EINVALID, "invalid Last-Event-ID"))
+			return
+		}
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		Error(w, r, planetscale.Errorf(planetscale.EINTERNAL, "streaming not supported"))
+		return
+	}
+
+	err = c.checkViewer(r.Context(), groupID, user.UserID)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	subscription, err := c.services.Events.Subscribe(groupID, lastEventID)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+	defer subscription.Cancel()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	if subscription.Missed {
+		err = writeEvent(w, &planetscale.Event{EventID: subscription.LastEventID, GroupID: groupID, Type: eventReset})
+		if err != nil {
+			return
+		}
+	}
+	for _, event := range subscription.Replay {
+		err = writeEvent(w, event)
+		if err != nil {
+			return
+		}
+	}
+	flusher.Flush()
+
+	ticker := time.NewTicker(c.heartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+			// members who left or were removed stop receiving events
+			if c.checkViewer(r.Context(), groupID, user.UserID) != nil {
+				return
+			}
+			_, err = io.WriteString(w, ": heartbeat\n\n")
+		case event, ok := <-subscription.Events:
+			// the hub shut down, or dropped the client for falling behind,
+			// in which case it reconnects and resumes
+			if !ok {
+				return
+			}
+			err = writeEvent(w, event)
+		}
+		// a failed write means the client went away
+		if err != nil {
+			return
+		}
+		flusher.Flush()
+	}
+}
+
+// checkViewer fails unless the user may view the group.
+func (c *eventController) checkViewer(ctx context.Context, groupID int64, userID string) error {
+	return c.tm.ExecuteInTx(ctx, func(tx *sql.Tx) error {
+		_, err := planetscale.CheckPermission(tx, c.repos, groupID, userID, planetscale.PermissionView)
+		return err
+	})
+}
+
+// writeEvent writes an event in the text/event-stream format.
+func writeEvent(w io.Writer, event *planetscale.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.EventID, event.Type, data)
+	return err
+}
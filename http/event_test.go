@@ -0,0 +1,190 @@
+package http
+
+import (
+	"bufio"
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	planetscale "github.com/harshav17/planet_scale"
+	db_mock "github.com/harshav17/planet_scale/mock/db"
+)
+
+func TestHandleGetGroupEvents(t *testing.T) {
+	server := MustOpenServer(t)
+
+	// streams check the members while the test removes one
+	var mu sync.Mutex
+	members := mockGroupMembers(map[string]string{
+		"alice": planetscale.RoleOwner,
+		"bob":   planetscale.RoleViewer,
+	})
+	server.repos.GroupMember = &db_mock.GroupMemberRepo{
+		GetFn: func(tx *sql.Tx, groupID int64, userID string) (*planetscale.GroupMember, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			return members.GetFn(tx, groupID, userID)
+		},
+	}
+	setRole := func(userID string, role string) {
+		mu.Lock()
+		defer mu.Unlock()
+		if role == "" {
+			members.DeleteFn(nil, 1, userID)
+		} else {
+			members.CreateFn(nil, &planetscale.GroupMember{GroupID: 1, UserID: userID, Role: role})
+		}
+	}
+	server.repos.Settlement = &db_mock.SettlementRepo{
+		CreateFn: func(tx *sql.Tx, settlement *planetscale.Settlement) error {
+			settlement.SettlementID = 1
+			return nil
+		},
+	}
+
+	// streams are read from a real connection, the recorder does not stream
+	ts := httptest.NewServer(server.router)
+	defer ts.Close()
+	client := &http.Client{Timeout: 5 * time.Second}
+
+	stream := func(t *testing.T, userID string, lastEventID int64) *http.Response {
+		t.Helper()
+		req, err := http.NewRequest("GET", ts.URL+"/groups/1/events", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Accept", "text/event-stream")
+		req.Header.Set("Authorization", "Bearer "+server.buildJWTForTesting(t, userID))
+		if lastEventID > 0 {
+			req.Header.Set("Last-Event-ID", strconv.FormatInt(lastEventID, 10))
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+	// next reads the fields of the next event of a stream, skipping comments.
+	next := func(t *testing.T, r *bufio.Reader) map[string]string {
+		t.Helper()
+		fields := map[string]string{}
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				t.Fatal(err)
+			}
+			line = strings.TrimSuffix(line, "\n")
+			if line == "" && len(fields) > 0 {
+				return fields
+			}
+			if name, value, ok := strings.Cut(line, ": "); ok && name != "" {
+				fields[name] = value
+			}
+		}
+	}
+	settle := func(t *testing.T) {
+		t.Helper()
+		body, err := json.Marshal(planetscale.Settlement{GroupID: 1, PaidBy: "alice", PaidTo: "bob", Amount: 20_00})
+		if err != nil {
+			t.Fatal(err)
+		}
+		req, err := http.NewRequest("POST", "/settlements", bytes.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+server.buildJWTForTesting(t, "alice"))
+		rr := httptest.NewRecorder()
+		server.router.ServeHTTP(rr, req)
+		if status := rr.Code; status != http.StatusCreated {
+			t.Fatalf("expected status code %d, got %d: %s", http.StatusCreated, status, rr.Body)
+		}
+	}
+
+	var lastEventID int64
+	t.Run("live", func(t *testing.T) {
+		resp := stream(t, "bob", 0)
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, resp.StatusCode)
+		} else if got := resp.Header.Get("Content-Type"); got != "text/event-stream" {
+			t.Fatalf("expected an event stream, got %q", got)
+		}
+
+		settle(t)
+		event := next(t, bufio.NewReader(resp.Body))
+		if event["event"] != "settlement.created" {
+			t.Fatalf("expected a settlement.created event, got %v", event)
+		}
+		var data planetscale.Event
+		if err := json.Unmarshal([]byte(event["data"]), &data); err != nil {
+			t.Fatal(err)
+		} else if data.ActorID != "alice" || data.GroupID != 1 {
+			t.Fatalf("unexpected event %+v", data)
+		}
+		lastEventID, _ = strconv.ParseInt(event["id"], 10, 64)
+	})
+
+	t.Run("resume", func(t *testing.T) {
+		settle(t)
+		resp := stream(t, "bob", lastEventID)
+		defer resp.Body.Close()
+
+		event := next(t, bufio.NewReader(resp.Body))
+		if event["id"] != strconv.FormatInt(lastEventID+1, 10) || event["event"] != "settlement.created" {
+			t.Fatalf("expected the settlement after %d to be replayed, got %v", lastEventID, event)
+		}
+	})
+
+	t.Run("resume after a restart", func(t *testing.T) {
+		resp := stream(t, "bob", 1)
+		defer resp.Body.Close()
+
+		event := next(t, bufio.NewReader(resp.Body))
+		if event["event"] != eventReset || event["id"] != strconv.FormatInt(lastEventID+1, 10) {
+			t.Fatalf("expected a reset to the latest event, got %v", event)
+		}
+	})
+
+	t.Run("not a member", func(t *testing.T) {
+		resp := stream(t, "carol", 0)
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusNotFound {
+			t.Fatalf("expected status code %d, got %d", http.StatusNotFound, resp.StatusCode)
+		}
+	})
+
+	t.Run("removed member", func(t *testing.T) {
+		resp := stream(t, "bob", 0)
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, resp.StatusCode)
+		}
+
+		setRole("bob", "")
+		defer setRole("bob", planetscale.RoleViewer)
+		if _, err := io.ReadAll(resp.Body); err != nil {
+			t.Fatalf("expected the stream to end, got %v", err)
+		}
+	})
+
+	t.Run("close", func(t *testing.T) {
+		resp := stream(t, "bob", 0)
+		defer resp.Body.Close()
+
+		if err := server.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := io.ReadAll(resp.Body); err != nil {
+			t.Fatalf("expected the stream to end, got %v", err)
+		}
+	})
+}
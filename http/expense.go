@@ -1,15 +1,21 @@
 package http
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	planetscale "github.com/harshav17/planet_scale"
 )
 
+// NotifyTimeout is the time given to email the users added to an expense,
+// which happens after the response is sent.
+const NotifyTimeout = 30 * time.Second
+
 type expenseController struct {
 	repos    *planetscale.RepoProvider
 	services *planetscale.ServiceProvider
@@ -38,16 +44,29 @@ func (c *expenseController) HandleGetGroupExpenses(w http.ResponseWriter, r *htt
 	}
 	groupID := int64(group32)
 
+	params, err := parseListParams(r)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
 	var expenses []*planetscale.Expense
 	getExpenseFunc := func(tx *sql.Tx) error {
-		// check if user is a member of the group
-		_, err = c.repos.GroupMember.Get(tx, groupID, user.UserID)
+		_, err = planetscale.CheckPermission(tx, c.repos, groupID, user.UserID, planetscale.PermissionView)
 		if err != nil {
 			return err
 		}
 
 		expenses, err = c.repos.Expense.Find(tx, planetscale.ExpenseFilter{
-			GroupID: groupID,
+			GroupID:   groupID,
+			PaidBy:    params.PaidBy,
+			From:      params.From,
+			To:        params.To,
+			MinAmount: params.MinAmount,
+			MaxAmount: params.MaxAmount,
+			Sort:      params.Sort,
+			Cursor:    params.Cursor,
+			Limit:     params.Limit,
 		})
 		if err != nil {
 			return err
@@ -61,10 +80,17 @@ func (c *expenseController) HandleGetGroupExpenses(w http.ResponseWriter, r *htt
 		return
 	}
 
+	// a full page may be followed by more expenses
+	var nextCursor string
+	if len(expenses) == params.Limit {
+		nextCursor = planetscale.ExpenseCursor(expenses[len(expenses)-1], params.Sort)
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(findExpensesResponse{
-		Expenses: expenses,
-		N:        len(expenses),
+		Expenses:   expenses,
+		N:          len(expenses),
+		NextCursor: nextCursor,
 	}); err != nil {
 		Error(w, r, err)
 		return
@@ -72,8 +98,9 @@ func (c *expenseController) HandleGetGroupExpenses(w http.ResponseWriter, r *htt
 }
 
 type findExpensesResponse struct {
-	Expenses []*planetscale.Expense `json:"expenses"`
-	N        int                    `json:"n"`
+	Expenses   []*planetscale.Expense `json:"expenses"`
+	N          int                    `json:"n"`
+	NextCursor string                 `json:"next_cursor,omitempty"`
 }
 
 func (c *expenseController) HandleGetExpense(w http.ResponseWriter, r *http.Request) {
@@ -97,18 +124,16 @@ func (c *expenseController) HandleGetExpense(w http.ResponseWriter, r *http.Requ
 			return err
 		}
 
-		// check if user is a member of the group
-		_, err = c.repos.GroupMember.Get(tx, *expense.GroupID, user.UserID)
-		if err != nil {
-			return err
-		}
-
 		// get expense participants
 		expense.Participants, err = c.repos.ExpenseParticipant.Find(tx, planetscale.ExpenseParticipantFilter{
 			ExpenseID: expenseID,
 		})
+		if err != nil {
+			return err
+		}
 
-		return nil
+		_, err = planetscale.CheckExpensePermission(tx, c.repos, expense, user.UserID, planetscale.PermissionView)
+		return err
 	}
 
 	err = c.tm.ExecuteInTx(r.Context(), getExpenseFunc)
@@ -144,6 +169,18 @@ func (c *expenseController) HandlePostExpense(w http.ResponseWriter, r *http.Req
 	}
 	expense.CreatedBy = user.UserID
 	expense.UpdatedBy = user.UserID
+
+	if expense.GroupID != nil {
+		err = c.tm.ExecuteInTx(r.Context(), func(tx *sql.Tx) error {
+			_, err := planetscale.CheckPermission(tx, c.repos, *expense.GroupID, user.UserID, planetscale.PermissionEdit)
+			return err
+		})
+		if err != nil {
+			Error(w, r, err)
+			return
+		}
+	}
+
 	err = c.services.Expense.CreateExpense(r.Context(), &expense)
 	if err != nil {
 		Error(w, r, err)
@@ -151,6 +188,8 @@ func (c *expenseController) HandlePostExpense(w http.ResponseWriter, r *http.Req
 	}
 	expense.ShareURL = "https://skwabbl.com/dashboard/expenses/" + strconv.FormatInt(expense.ExpenseID, 10)
 
+	c.notifyExpenseAdded(r, &expense, addedParticipants(nil, expense.Participants, user.UserID))
+
 	// Format returned data based on HTTP accept header.
 	w.WriteHeader(http.StatusCreated)
 	w.Header().Set("Content-Type", "application/json")
@@ -174,13 +213,20 @@ func (c *expenseController) HandleDeleteExpense(w http.ResponseWriter, r *http.R
 	}
 	expenseID := int64(expense32)
 
+	var attachments []*planetscale.Attachment
+	var audit *planetscale.AuditEvent
 	deleteExpenseFunc := func(tx *sql.Tx) error {
-		// check if the user is a member of the group
-		expense, err := c.repos.Expense.Get(tx, expenseID)
+		expense, member, err := planetscale.GetMemberExpense(tx, c.repos, expenseID, user.UserID, planetscale.PermissionEdit)
 		if err != nil {
 			return err
 		}
-		_, err = c.repos.GroupMember.Get(tx, *expense.GroupID, user.UserID)
+		err = planetscale.CheckAuthor(member, expense.CreatedBy, expense.PaidBy)
+		if err != nil {
+			return err
+		}
+		expense.Participants, err = c.repos.ExpenseParticipant.Find(tx, planetscale.ExpenseParticipantFilter{
+			ExpenseID: expenseID,
+		})
 		if err != nil {
 			return err
 		}
@@ -189,7 +235,38 @@ func (c *expenseController) HandleDeleteExpense(w http.ResponseWriter, r *http.R
 		if err != nil {
 			return err
 		}
-		return nil
+
+		// attachments are not kept in the trash, their files go with the expense
+		attachments, err = c.repos.Attachment.Find(tx, planetscale.AttachmentFilter{
+			ExpenseID: expenseID,
+		})
+		if err != nil {
+			return err
+		}
+		for _, attachment := range attachments {
+			err = c.repos.Attachment.Delete(tx, attachment.AttachmentID)
+			if err != nil {
+				return err
+			}
+		}
+
+		audit = &planetscale.AuditEvent{
+			GroupID:    expense.GroupID,
+			EntityType: planetscale.AuditEntityExpense,
+			EntityID:   strconv.FormatInt(expenseID, 10),
+			Action:     planetscale.AuditActionDelete,
+			ActorID:    user.UserID,
+			Before:     expense,
+		}
+		err = c.repos.AuditEvent.Create(tx, audit)
+		if err != nil {
+			return err
+		}
+		err = c.repos.WebhookEvent.Create(tx, planetscale.NewWebhookEvent(audit))
+		if err != nil {
+			return err
+		}
+		return c.repos.Activity.Create(tx, planetscale.NewActivity(audit))
 	}
 
 	err = c.tm.ExecuteInTx(r.Context(), deleteExpenseFunc)
@@ -197,11 +274,21 @@ func (c *expenseController) HandleDeleteExpense(w http.ResponseWriter, r *http.R
 		Error(w, r, err)
 		return
 	}
+	planetscale.Publish(c.services.Events, audit)
+
+	// the expense is gone either way, leftover blobs are only logged
+	for _, attachment := range attachments {
+		if err := c.services.Blob.Delete(r.Context(), attachment.Key); err != nil {
+			LogError(r, err)
+		}
+	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
 
-func (c *expenseController) HandlePatchExpense(w http.ResponseWriter, r *http.Request) {
+// HandleRestoreExpense handles the POST /expenses/{expenseID}/restore endpoint.
+// It brings a soft-deleted expense back out of the group's trash.
+func (c *expenseController) HandleRestoreExpense(w http.ResponseWriter, r *http.Request) {
 	user, found := planetscale.UserFromContext(r.Context())
 	if !found {
 		Error(w, r, planetscale.Errorf(planetscale.ENOTFOUND, "user context not set"))
@@ -215,87 +302,212 @@ func (c *expenseController) HandlePatchExpense(w http.ResponseWriter, r *http.Re
 	}
 	expenseID := int64(expense32)
 
-	var expenseUpdate planetscale.ExpenseUpdate
-	err = ReceiveJson(w, r, &expenseUpdate)
-	if err != nil {
-		Error(w, r, err)
-		return
-	}
-
 	var expense *planetscale.Expense
-	patchExpenseFunc := func(tx *sql.Tx) error {
-		// check if the user is a member of the group
-		foundExp, err := c.repos.Expense.Get(tx, expenseID)
+	var audit *planetscale.AuditEvent
+	restoreExpenseFunc := func(tx *sql.Tx) error {
+		err = c.repos.Expense.Restore(tx, expenseID)
 		if err != nil {
 			return err
 		}
-		_, err = c.repos.GroupMember.Get(tx, *foundExp.GroupID, user.UserID)
+
+		// deleted expenses are not visible until restored, the permission
+		// check rolls the restore back for anyone who could not delete it
+		var member *planetscale.GroupMember
+		expense, member, err = planetscale.GetMemberExpense(tx, c.repos, expenseID, user.UserID, planetscale.PermissionEdit)
+		if err != nil {
+			return err
+		}
+		err = planetscale.CheckAuthor(member, expense.CreatedBy, expense.PaidBy)
+		if err != nil {
+			return err
+		}
+		expense.Participants, err = c.repos.ExpenseParticipant.Find(tx, planetscale.ExpenseParticipantFilter{
+			ExpenseID: expenseID,
+		})
 		if err != nil {
 			return err
 		}
 
-		expense, err = c.repos.Expense.Update(tx, expenseID, &expenseUpdate)
+		audit = &planetscale.AuditEvent{
+			GroupID:    expense.GroupID,
+			EntityType: planetscale.AuditEntityExpense,
+			EntityID:   strconv.FormatInt(expenseID, 10),
+			Action:     planetscale.AuditActionRestore,
+			ActorID:    user.UserID,
+			After:      expense,
+		}
+		err = c.repos.AuditEvent.Create(tx, audit)
+		if err != nil {
+			return err
+		}
+		err = c.repos.WebhookEvent.Create(tx, planetscale.NewWebhookEvent(audit))
 		if err != nil {
 			return err
 		}
+		return c.repos.Activity.Create(tx, planetscale.NewActivity(audit))
+	}
 
-		// update expense participants
-		if expenseUpdate.Participants != nil {
-			// TODO conver all the queries in this block to Batch queries
-			// find existing participants
-			existingParticipants, err := c.repos.ExpenseParticipant.Find(tx, planetscale.ExpenseParticipantFilter{
-				ExpenseID: expenseID,
-			})
-			if err != nil {
-				return err
-			}
+	err = c.tm.ExecuteInTx(r.Context(), restoreExpenseFunc)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+	planetscale.Publish(c.services.Events, audit)
 
-			// delete participants that are not in the update
-			for _, existingParticipant := range existingParticipants {
-				found := false
-				for _, participant := range expenseUpdate.Participants {
-					if existingParticipant.UserID == participant.UserID {
-						found = true
-						break
-					}
-				}
-				if !found {
-					err := c.repos.ExpenseParticipant.Delete(tx, expenseID, existingParticipant.UserID)
-					if err != nil {
-						return err
-					}
-				}
-			}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(expense); err != nil {
+		Error(w, r, err)
+		return
+	}
+}
 
-			// upsert participants
-			for _, participant := range expenseUpdate.Participants {
-				err := c.repos.ExpenseParticipant.Upsert(tx, participant)
-				if err != nil {
-					return err
-				}
-			}
+func (c *expenseController) HandlePatchExpense(w http.ResponseWriter, r *http.Request) {
+	user, found := planetscale.UserFromContext(r.Context())
+	if !found {
+		Error(w, r, planetscale.Errorf(planetscale.ENOTFOUND, "user context not set"))
+		return
+	}
 
-			// get updated participants
-			expense.Participants, err = c.repos.ExpenseParticipant.Find(tx, planetscale.ExpenseParticipantFilter{
-				ExpenseID: expenseID,
-			})
-			if err != nil {
-				return err
+	expense32, err := strconv.Atoi(chi.URLParam(r, "expenseID"))
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+	expenseID := int64(expense32)
+
+	var expenseUpdate planetscale.ExpenseUpdate
+	err = ReceiveJson(w, r, &expenseUpdate)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	before, expense, err := c.services.Expense.UpdateExpense(r.Context(), expenseID, &expenseUpdate, user.UserID)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+	c.notifyExpenseAdded(r, expense, addedParticipants(before.Participants, expense.Participants, user.UserID))
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(expense); err != nil {
+		Error(w, r, err)
+		return
+	}
+}
+
+// HandleGetExpenseHistory handles the GET /expenses/{expenseID}/history endpoint.
+// History is kept after the expense is deleted, so access is checked against
+// the group recorded on the audit events rather than the expense itself.
+func (c *expenseController) HandleGetExpenseHistory(w http.ResponseWriter, r *http.Request) {
+	user, found := planetscale.UserFromContext(r.Context())
+	if !found {
+		Error(w, r, planetscale.Errorf(planetscale.ENOTFOUND, "user context not set"))
+		return
+	}
+
+	expense32, err := strconv.Atoi(chi.URLParam(r, "expenseID"))
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+	expenseID := int64(expense32)
+
+	var events []*planetscale.AuditEvent
+	getHistoryFunc := func(tx *sql.Tx) error {
+		events, err = c.repos.AuditEvent.Find(tx, planetscale.AuditEventFilter{
+			EntityType: planetscale.AuditEntityExpense,
+			EntityID:   strconv.FormatInt(expenseID, 10),
+		})
+		if err != nil {
+			return err
+		}
+		if len(events) == 0 {
+			return planetscale.Errorf(planetscale.ENOTFOUND, "no history for expense %d", expenseID)
+		}
+		if events[0].GroupID == nil {
+			// the expense may be gone, so involvement is read from the
+			// snapshots it left behind
+			if !involvedInHistory(events, user.UserID) {
+				return planetscale.Errorf(planetscale.ENOTFOUND, "no history for expense %d", expenseID)
 			}
+			return nil
 		}
 
-		return nil
+		_, err = planetscale.CheckPermission(tx, c.repos, *events[0].GroupID, user.UserID, planetscale.PermissionView)
+		return err
 	}
 
-	err = c.tm.ExecuteInTx(r.Context(), patchExpenseFunc)
+	err = c.tm.ExecuteInTx(r.Context(), getHistoryFunc)
 	if err != nil {
 		Error(w, r, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(expense); err != nil {
+	if err := json.NewEncoder(w).Encode(findAuditEventsResponse{
+		AuditEvents: events,
+		N:           len(events),
+	}); err != nil {
 		Error(w, r, err)
 		return
 	}
 }
+
+// involvedInHistory reports whether the user was involved in any version of
+// the expense recorded by events.
+func involvedInHistory(events []*planetscale.AuditEvent, userID string) bool {
+	for _, event := range events {
+		for _, snapshot := range []interface{}{event.Before, event.After} {
+			data, ok := snapshot.(json.RawMessage)
+			if !ok {
+				continue
+			}
+			var expense planetscale.Expense
+			if err := json.Unmarshal(data, &expense); err != nil {
+				continue
+			}
+			if expense.Involves(userID) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// notifyExpenseAdded emails the users added to an expense in the background,
+// so that a slow mail server does not hold up the request. A failed email does
+// not undo the change.
+func (c *expenseController) notifyExpenseAdded(r *http.Request, expense *planetscale.Expense, userIDs []string) {
+	if len(userIDs) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), NotifyTimeout)
+	go func() {
+		defer cancel()
+		err := c.services.Notifier.NotifyExpenseAdded(ctx, expense, userIDs)
+		if err != nil {
+			LogError(r, err)
+		}
+	}()
+}
+
+// addedParticipants returns the participants of after that are not in
+// before, leaving out the user who made the change.
+func addedParticipants(before []*planetscale.ExpenseParticipant, after []*planetscale.ExpenseParticipant, actorID string) []string {
+	var added []string
+	for _, participant := range after {
+		found := participant.UserID == actorID
+		for _, existing := range before {
+			if existing.UserID == participant.UserID {
+				found = true
+				break
+			}
+		}
+		if !found {
+			added = append(added, participant.UserID)
+		}
+	}
+	return added
+}
@@ -1,8 +1,12 @@
 package http
 
 import (
+	"bytes"
 	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"strconv"
 
@@ -80,6 +84,7 @@ func (c *expenseGroupController) HandlePostExpenseGroup(w http.ResponseWriter, r
 	expenseGroup.CreateBy = user.UserID
 	expenseGroup.UpdatedBy = user.UserID
 
+	var audit *planetscale.AuditEvent
 	createExpenseGroupFunc := func(tx *sql.Tx) error {
 		err = c.repos.ExpenseGroup.Create(tx, &expenseGroup)
 		if err != nil {
@@ -89,11 +94,21 @@ func (c *expenseGroupController) HandlePostExpenseGroup(w http.ResponseWriter, r
 		err = c.repos.GroupMember.Create(tx, &planetscale.GroupMember{
 			GroupID: expenseGroup.ExpenseGroupID,
 			UserID:  expenseGroup.CreateBy,
+			Role:    planetscale.RoleOwner,
 		})
 		if err != nil {
 			return err
 		}
-		return nil
+
+		audit = &planetscale.AuditEvent{
+			GroupID:    &expenseGroup.ExpenseGroupID,
+			EntityType: planetscale.AuditEntityGroup,
+			EntityID:   strconv.FormatInt(expenseGroup.ExpenseGroupID, 10),
+			Action:     planetscale.AuditActionCreate,
+			ActorID:    user.UserID,
+			After:      expenseGroup,
+		}
+		return c.repos.AuditEvent.Create(tx, audit)
 	}
 
 	err = c.tm.ExecuteInTx(r.Context(), createExpenseGroupFunc)
@@ -101,6 +116,7 @@ func (c *expenseGroupController) HandlePostExpenseGroup(w http.ResponseWriter, r
 		Error(w, r, err)
 		return
 	}
+	planetscale.Publish(c.services.Events, audit)
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(expenseGroup); err != nil {
@@ -131,21 +147,33 @@ func (c *expenseGroupController) HandlePatchExpenseGroup(w http.ResponseWriter,
 	}
 
 	var expenseGroup *planetscale.ExpenseGroup
+	var audit *planetscale.AuditEvent
 	patchExpenseGroupFunc := func(tx *sql.Tx) error {
-		expenseGroup, err = c.repos.ExpenseGroup.Get(tx, groupID)
+		_, err := planetscale.CheckPermission(tx, c.repos, groupID, user.UserID, planetscale.PermissionManage)
 		if err != nil {
 			return err
 		}
-		if expenseGroup.CreateBy != user.UserID {
-			// TODO: should the users of the group be able to update the group?
-			return planetscale.Errorf(planetscale.EUNAUTHORIZED, "user %s is not authorized to update expense group %d", user.UserID, groupID)
+
+		before, err := c.repos.ExpenseGroup.Get(tx, groupID)
+		if err != nil {
+			return err
 		}
 
 		expenseGroup, err = c.repos.ExpenseGroup.Update(tx, groupID, &update)
 		if err != nil {
 			return err
 		}
-		return nil
+
+		audit = &planetscale.AuditEvent{
+			GroupID:    &groupID,
+			EntityType: planetscale.AuditEntityGroup,
+			EntityID:   strconv.FormatInt(groupID, 10),
+			Action:     planetscale.AuditActionUpdate,
+			ActorID:    user.UserID,
+			Before:     before,
+			After:      expenseGroup,
+		}
+		return c.repos.AuditEvent.Create(tx, audit)
 	}
 
 	err = c.tm.ExecuteInTx(r.Context(), patchExpenseGroupFunc)
@@ -153,6 +181,7 @@ func (c *expenseGroupController) HandlePatchExpenseGroup(w http.ResponseWriter,
 		Error(w, r, err)
 		return
 	}
+	planetscale.Publish(c.services.Events, audit)
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(expenseGroup); err != nil {
@@ -175,20 +204,32 @@ func (c *expenseGroupController) HandleDeleteExpenseGroup(w http.ResponseWriter,
 	}
 	groupID := int64(group32)
 
+	var audit *planetscale.AuditEvent
 	deleteExpenseGroupFunc := func(tx *sql.Tx) error {
-		expenseGroup, err := c.repos.ExpenseGroup.Get(tx, groupID)
+		_, err := planetscale.CheckPermission(tx, c.repos, groupID, user.UserID, planetscale.PermissionOwn)
 		if err != nil {
 			return err
 		}
-		if expenseGroup.CreateBy != user.UserID {
-			return planetscale.Errorf(planetscale.EUNAUTHORIZED, "user %s is not authorized to update expense group %d", user.UserID, groupID)
+
+		expenseGroup, err := c.repos.ExpenseGroup.Get(tx, groupID)
+		if err != nil {
+			return err
 		}
 
 		err = c.repos.ExpenseGroup.Delete(tx, groupID)
 		if err != nil {
 			return err
 		}
-		return nil
+
+		audit = &planetscale.AuditEvent{
+			GroupID:    &groupID,
+			EntityType: planetscale.AuditEntityGroup,
+			EntityID:   strconv.FormatInt(groupID, 10),
+			Action:     planetscale.AuditActionDelete,
+			ActorID:    user.UserID,
+			Before:     expenseGroup,
+		}
+		return c.repos.AuditEvent.Create(tx, audit)
 	}
 
 	err = c.tm.ExecuteInTx(r.Context(), deleteExpenseGroupFunc)
@@ -196,11 +237,77 @@ func (c *expenseGroupController) HandleDeleteExpenseGroup(w http.ResponseWriter,
 		Error(w, r, err)
 		return
 	}
+	planetscale.Publish(c.services.Events, audit)
 
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// HandleRestoreExpenseGroup handles the POST /groups/{groupID}/restore endpoint.
+func (c *expenseGroupController) HandleRestoreExpenseGroup(w http.ResponseWriter, r *http.Request) {
+	user, found := planetscale.UserFromContext(r.Context())
+	if !found {
+		Error(w, r, planetscale.Errorf(planetscale.ENOTFOUND, "user context not set"))
+		return
+	}
+
+	group32, err := strconv.Atoi(chi.URLParam(r, "groupID"))
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+	groupID := int64(group32)
+
+	var expenseGroup *planetscale.ExpenseGroup
+	var audit *planetscale.AuditEvent
+	restoreExpenseGroupFunc := func(tx *sql.Tx) error {
+		err = c.repos.ExpenseGroup.Restore(tx, groupID)
+		if err != nil {
+			return err
+		}
+
+		// only the owner could delete the group, so only they may restore it
+		_, err = planetscale.CheckPermission(tx, c.repos, groupID, user.UserID, planetscale.PermissionOwn)
+		if err != nil {
+			return err
+		}
+
+		expenseGroup, err = c.repos.ExpenseGroup.Get(tx, groupID)
+		if err != nil {
+			return err
+		}
+
+		audit = &planetscale.AuditEvent{
+			GroupID:    &groupID,
+			EntityType: planetscale.AuditEntityGroup,
+			EntityID:   strconv.FormatInt(groupID, 10),
+			Action:     planetscale.AuditActionRestore,
+			ActorID:    user.UserID,
+			After:      expenseGroup,
+		}
+		return c.repos.AuditEvent.Create(tx, audit)
+	}
+
+	err = c.tm.ExecuteInTx(r.Context(), restoreExpenseGroupFunc)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+	planetscale.Publish(c.services.Events, audit)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(expenseGroup); err != nil {
+		Error(w, r, err)
+		return
+	}
+}
+
 func (c *expenseGroupController) HandleGetExpenseGroup(w http.ResponseWriter, r *http.Request) {
+	user, found := planetscale.UserFromContext(r.Context())
+	if !found {
+		Error(w, r, planetscale.Errorf(planetscale.ENOTFOUND, "user context not set"))
+		return
+	}
+
 	group32, err := strconv.Atoi(chi.URLParam(r, "groupID"))
 	if err != nil {
 		Error(w, r, err)
@@ -210,6 +317,11 @@ func (c *expenseGroupController) HandleGetExpenseGroup(w http.ResponseWriter, r
 
 	var expenseGroup *planetscale.ExpenseGroup
 	getExpenseGroupFunc := func(tx *sql.Tx) error {
+		_, err = planetscale.CheckPermission(tx, c.repos, groupID, user.UserID, planetscale.PermissionView)
+		if err != nil {
+			return err
+		}
+
 		expenseGroup, err = c.repos.ExpenseGroup.Get(tx, groupID)
 		if err != nil {
 			return err
@@ -241,6 +353,12 @@ func (c *expenseGroupController) HandleGetExpenseGroup(w http.ResponseWriter, r
 }
 
 func (c *expenseGroupController) HandleGetGroupBalances(w http.ResponseWriter, r *http.Request) {
+	user, found := planetscale.UserFromContext(r.Context())
+	if !found {
+		Error(w, r, planetscale.Errorf(planetscale.ENOTFOUND, "user context not set"))
+		return
+	}
+
 	group32, err := strconv.Atoi(chi.URLParam(r, "groupID"))
 	if err != nil {
 		Error(w, r, err)
@@ -248,6 +366,12 @@ func (c *expenseGroupController) HandleGetGroupBalances(w http.ResponseWriter, r
 	}
 	groupID := int64(group32)
 
+	err = c.checkPermission(r, groupID, user.UserID, planetscale.PermissionView)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
 	balances, err := c.services.Balance.GetGroupBalances(r.Context(), groupID)
 	if err != nil {
 		Error(w, r, err)
@@ -260,3 +384,281 @@ func (c *expenseGroupController) HandleGetGroupBalances(w http.ResponseWriter, r
 		return
 	}
 }
+
+// HandleGetSettlePlan handles the GET /groups/{groupID}/settle_plan endpoint.
+// It previews the payments that would settle the group without recording them.
+func (c *expenseGroupController) HandleGetSettlePlan(w http.ResponseWriter, r *http.Request) {
+	c.handleSettlePlan(w, r, false)
+}
+
+// HandlePostSettlePlan handles the POST /groups/{groupID}/settle_plan endpoint.
+// It records every payment of the plan as a settlement.
+func (c *expenseGroupController) HandlePostSettlePlan(w http.ResponseWriter, r *http.Request) {
+	c.handleSettlePlan(w, r, true)
+}
+
+func (c *expenseGroupController) handleSettlePlan(w http.ResponseWriter, r *http.Request, apply bool) {
+	user, found := planetscale.UserFromContext(r.Context())
+	if !found {
+		Error(w, r, planetscale.Errorf(planetscale.ENOTFOUND, "user context not set"))
+		return
+	}
+
+	group32, err := strconv.Atoi(chi.URLParam(r, "groupID"))
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+	groupID := int64(group32)
+
+	// previewing the plan only needs to see balances, applying it records
+	// settlements
+	permission := planetscale.PermissionView
+	if apply {
+		permission = planetscale.PermissionEdit
+	}
+	err = c.checkPermission(r, groupID, user.UserID, permission)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	plan, err := c.services.Balance.GetSettlePlan(r.Context(), groupID, apply)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	statusCode := http.StatusOK
+	if apply {
+		statusCode = http.StatusCreated
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	if err := json.NewEncoder(w).Encode(plan); err != nil {
+		Error(w, r, err)
+		return
+	}
+}
+
+type findAuditEventsResponse struct {
+	AuditEvents []*planetscale.AuditEvent `json:"audit_events"`
+	N           int                       `json:"n"`
+}
+
+// HandleGetGroupHistory handles the GET /groups/{groupID}/history endpoint.
+// It lists every recorded mutation within the group, oldest first.
+func (c *expenseGroupController) HandleGetGroupHistory(w http.ResponseWriter, r *http.Request) {
+	user, found := planetscale.UserFromContext(r.Context())
+	if !found {
+		Error(w, r, planetscale.Errorf(planetscale.ENOTFOUND, "user context not set"))
+		return
+	}
+
+	group32, err := strconv.Atoi(chi.URLParam(r, "groupID"))
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+	groupID := int64(group32)
+
+	var events []*planetscale.AuditEvent
+	getHistoryFunc := func(tx *sql.Tx) error {
+		_, err = planetscale.CheckPermission(tx, c.repos, groupID, user.UserID, planetscale.PermissionView)
+		if err != nil {
+			return err
+		}
+
+		events, err = c.repos.AuditEvent.Find(tx, planetscale.AuditEventFilter{
+			GroupID: groupID,
+		})
+		if err != nil {
+			return err
+		}
+		return nil
+	}
+
+	err = c.tm.ExecuteInTx(r.Context(), getHistoryFunc)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(findAuditEventsResponse{
+		AuditEvents: events,
+		N:           len(events),
+	}); err != nil {
+		Error(w, r, err)
+		return
+	}
+}
+
+type groupTrashResponse struct {
+	Expenses    []*planetscale.Expense    `json:"expenses"`
+	Settlements []*planetscale.Settlement `json:"settlements"`
+}
+
+// HandleGetGroupTrash handles the GET /groups/{groupID}/trash endpoint.
+// It lists the group's soft-deleted expenses and settlements.
+func (c *expenseGroupController) HandleGetGroupTrash(w http.ResponseWriter, r *http.Request) {
+	user, found := planetscale.UserFromContext(r.Context())
+	if !found {
+		Error(w, r, planetscale.Errorf(planetscale.ENOTFOUND, "user context not set"))
+		return
+	}
+
+	group32, err := strconv.Atoi(chi.URLParam(r, "groupID"))
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+	groupID := int64(group32)
+
+	var trash groupTrashResponse
+	getTrashFunc := func(tx *sql.Tx) error {
+		_, err = planetscale.CheckTrashPermission(tx, c.repos, groupID, user.UserID, planetscale.PermissionView)
+		if err != nil {
+			return err
+		}
+
+		trash.Expenses, err = c.repos.Expense.Find(tx, planetscale.ExpenseFilter{
+			GroupID: groupID,
+			Deleted: true,
+		})
+		if err != nil {
+			return err
+		}
+
+		trash.Settlements, err = c.repos.Settlement.Find(tx, planetscale.SettlementFilter{
+			GroupID: groupID,
+			Deleted: true,
+		})
+		if err != nil {
+			return err
+		}
+		return nil
+	}
+
+	err = c.tm.ExecuteInTx(r.Context(), getTrashFunc)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(trash); err != nil {
+		Error(w, r, err)
+		return
+	}
+}
+
+// checkPermission returns an error unless the user's role in the group
+// grants permission, for handlers that do their work outside a transaction.
+func (c *expenseGroupController) checkPermission(r *http.Request, groupID int64, userID string, permission planetscale.Permission) error {
+	checkPermissionFunc := func(tx *sql.Tx) error {
+		_, err := planetscale.CheckPermission(tx, c.repos, groupID, userID, permission)
+		return err
+	}
+	return c.tm.ExecuteInTx(r.Context(), checkPermissionFunc)
+}
+
+// HandleExportLedger handles the GET /groups/{groupID}/export.csv endpoint.
+func (c *expenseGroupController) HandleExportLedger(w http.ResponseWriter, r *http.Request) {
+	user, found := planetscale.UserFromContext(r.Context())
+	if !found {
+		Error(w, r, planetscale.Errorf(planetscale.ENOTFOUND, "user context not set"))
+		return
+	}
+
+	group32, err := strconv.Atoi(chi.URLParam(r, "groupID"))
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+	groupID := int64(group32)
+
+	err = c.checkPermission(r, groupID, user.UserID, planetscale.PermissionView)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"group-%d.csv\"", groupID))
+	err = c.services.Ledger.ExportCSV(r.Context(), groupID, w)
+	if err != nil {
+		// the status is gone once rows are streamed, the client sees a
+		// truncated file
+		LogError(r, err)
+		return
+	}
+}
+
+// HandleImportLedger handles the POST /groups/{groupID}/import endpoint. The
+// body is the CSV itself. With ?dry_run=true nothing is written and the
+// response only reports what would be imported and which rows are invalid.
+func (c *expenseGroupController) HandleImportLedger(w http.ResponseWriter, r *http.Request) {
+	user, found := planetscale.UserFromContext(r.Context())
+	if !found {
+		Error(w, r, planetscale.Errorf(planetscale.ENOTFOUND, "user context not set"))
+		return
+	}
+
+	group32, err := strconv.Atoi(chi.URLParam(r, "groupID"))
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+	groupID := int64(group32)
+
+	dryRun := false
+	if s := r.URL.Query().Get("dry_run"); s != "" {
+		dryRun, err = strconv.ParseBool(s)
+		if err != nil {
+			Error(w, r, planetscale.Errorf(planetscale.EINVALID, "invalid dry_run %q", s))
+			return
+		}
+	}
+
+	permission := planetscale.PermissionEdit
+	if dryRun {
+		permission = planetscale.PermissionView
+	}
+	err = c.checkPermission(r, groupID, user.UserID, permission)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, planetscale.MaxLedgerSize)
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			Error(w, r, planetscale.Errorf(planetscale.EINVALID, "ledgers cannot be larger than %d bytes", planetscale.MaxLedgerSize))
+			return
+		}
+		Error(w, r, err)
+		return
+	}
+
+	result, err := c.services.Ledger.ImportCSV(r.Context(), groupID, bytes.NewReader(body), dryRun)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	statusCode := http.StatusCreated
+	if dryRun {
+		statusCode = http.StatusOK
+	} else if len(result.Errors) > 0 {
+		statusCode = http.StatusBadRequest
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		Error(w, r, err)
+		return
+	}
+}
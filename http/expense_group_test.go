@@ -4,9 +4,11 @@ import (
 	"bytes"
 	"database/sql"
 	"encoding/json"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
+	"strings"
 	"testing"
 
 	planetscale "github.com/harshav17/planet_scale"
@@ -169,8 +171,10 @@ func TestHandleExpenseGroups_All(t *testing.T) {
 					return nil
 				},
 			}
+			var member *planetscale.GroupMember
 			server.repos.GroupMember = &db_mock.GroupMemberRepo{
 				CreateFn: func(tx *sql.Tx, gm *planetscale.GroupMember) error {
+					member = gm
 					return nil
 				},
 			}
@@ -209,6 +213,8 @@ func TestHandleExpenseGroups_All(t *testing.T) {
 				t.Fatalf("expected group name test group, got %s", got.GroupName)
 			} else if got.CreateBy != "test_user_id" {
 				t.Fatalf("expected create by test_user_id, got %s", got.CreateBy)
+			} else if member == nil || member.Role != planetscale.RoleOwner {
+				t.Fatalf("expected the creator to be added as owner, got %+v", member)
 			}
 		})
 
@@ -262,6 +268,7 @@ func TestHandleExpenseGroups_All(t *testing.T) {
 			expenseGroupUpdate := planetscale.ExpenseGroupUpdate{
 				GroupName: updateGroupName,
 			}
+			server.repos.GroupMember = mockGroupMembers(map[string]string{userID: planetscale.RoleAdmin})
 
 			server.repos.ExpenseGroup = &db_mock.ExpenseGroupRepo{
 				GetFn: func(tx *sql.Tx, groupID int64) (*planetscale.ExpenseGroup, error) {
@@ -307,7 +314,7 @@ func TestHandleExpenseGroups_All(t *testing.T) {
 			}
 		})
 
-		t.Run("unauthorized", func(t *testing.T) {
+		t.Run("forbidden", func(t *testing.T) {
 			expenseGroup := planetscale.ExpenseGroup{
 				GroupName:      "test group",
 				CreateBy:       "test-user-id-1",
@@ -316,6 +323,10 @@ func TestHandleExpenseGroups_All(t *testing.T) {
 			expenseGroupUpdate := planetscale.ExpenseGroupUpdate{
 				GroupName: "test group updated",
 			}
+			server.repos.GroupMember = mockGroupMembers(map[string]string{
+				"test-user-id-1": planetscale.RoleOwner,
+				"test-user-id-2": planetscale.RoleMember,
+			})
 
 			server.repos.ExpenseGroup = &db_mock.ExpenseGroupRepo{
 				GetFn: func(tx *sql.Tx, groupID int64) (*planetscale.ExpenseGroup, error) {
@@ -344,8 +355,8 @@ func TestHandleExpenseGroups_All(t *testing.T) {
 			handler := http.HandlerFunc(server.router.ServeHTTP)
 			handler.ServeHTTP(rr, req)
 
-			if status := rr.Code; status != http.StatusUnauthorized {
-				t.Errorf("expected status code %d, got %d", http.StatusUnauthorized, status)
+			if status := rr.Code; status != http.StatusForbidden {
+				t.Errorf("expected status code %d, got %d", http.StatusForbidden, status)
 			}
 		})
 	})
@@ -366,6 +377,7 @@ func TestHandleExpenseGroups_All(t *testing.T) {
 					return nil
 				},
 			}
+			server.repos.GroupMember = mockGroupMembers(map[string]string{userID: planetscale.RoleOwner})
 
 			token := server.buildJWTForTesting(t, userID)
 			req, err := http.NewRequest("DELETE", "/groups/1", nil)
@@ -384,7 +396,7 @@ func TestHandleExpenseGroups_All(t *testing.T) {
 			}
 		})
 
-		t.Run("unauthorized", func(t *testing.T) {
+		t.Run("forbidden", func(t *testing.T) {
 			expenseGroup := planetscale.ExpenseGroup{
 				GroupName:      "test group",
 				CreateBy:       "test-user-id-1",
@@ -399,6 +411,11 @@ func TestHandleExpenseGroups_All(t *testing.T) {
 					return nil
 				},
 			}
+			// admins manage the group, only the owner deletes it
+			server.repos.GroupMember = mockGroupMembers(map[string]string{
+				"test-user-id-1": planetscale.RoleOwner,
+				"test-user-id-2": planetscale.RoleAdmin,
+			})
 
 			token := server.buildJWTForTesting(t, "test-user-id-2")
 			req, err := http.NewRequest("DELETE", "/groups/1", nil)
@@ -412,8 +429,8 @@ func TestHandleExpenseGroups_All(t *testing.T) {
 			handler := http.HandlerFunc(server.router.ServeHTTP)
 			handler.ServeHTTP(rr, req)
 
-			if status := rr.Code; status != http.StatusUnauthorized {
-				t.Errorf("expected status code %d, got %d", http.StatusUnauthorized, status)
+			if status := rr.Code; status != http.StatusForbidden {
+				t.Errorf("expected status code %d, got %d", http.StatusForbidden, status)
 			}
 		})
 	})
@@ -431,6 +448,7 @@ func TestHandleExpenseGroups_All(t *testing.T) {
 					return &expenseGroup, nil
 				},
 			}
+			server.repos.GroupMember = mockGroupMembers(map[string]string{"test_user_id": planetscale.RoleViewer})
 
 			token := server.buildJWTForTesting(t, "test_user_id")
 			req, err := http.NewRequest("GET", "/groups/1", nil)
@@ -464,7 +482,7 @@ func TestHandleExpenseGroups_All(t *testing.T) {
 			balances := []*planetscale.Balance{
 				{
 					UserID: "test-user-id",
-					Amount: 100,
+					Amount: 100_00,
 				},
 			}
 
@@ -473,6 +491,7 @@ func TestHandleExpenseGroups_All(t *testing.T) {
 					return balances, nil
 				},
 			}
+			server.repos.GroupMember = mockGroupMembers(map[string]string{"test_user_id": planetscale.RoleViewer})
 
 			token := server.buildJWTForTesting(t, "test_user_id")
 			req, err := http.NewRequest("GET", "/groups/1/balances", nil)
@@ -499,8 +518,432 @@ func TestHandleExpenseGroups_All(t *testing.T) {
 				t.Errorf("expected 1 balance, got %d", len(got))
 			} else if got[0].UserID != "test-user-id" {
 				t.Errorf("expected user id test-user-id, got %s", got[0].UserID)
-			} else if got[0].Amount != 100 {
-				t.Errorf("expected amount 100, got %f", got[0].Amount)
+			} else if got[0].Amount != 100_00 {
+				t.Errorf("expected amount 100, got %s", got[0].Amount)
+			}
+		})
+	})
+	t.Run("GET /groups/:id/settle_plan", func(t *testing.T) {
+		t.Run("successful get", func(t *testing.T) {
+			server.repos.GroupMember = &db_mock.GroupMemberRepo{
+				GetFn: func(tx *sql.Tx, groupID int64, userID string) (*planetscale.GroupMember, error) {
+					return &planetscale.GroupMember{GroupID: groupID, UserID: userID, Role: planetscale.RoleMember}, nil
+				},
+			}
+			server.services.Balance = &service_mock.BalanceService{
+				GetSettlePlanFn: func(groupID int64, apply bool) (*planetscale.SettlePlan, error) {
+					if apply {
+						t.Fatal("expected plan not to be applied")
+					}
+					return &planetscale.SettlePlan{
+						GroupID: groupID,
+						Transfers: []*planetscale.Transfer{
+							{PaidBy: "test-user-id-2", PaidTo: "test_user_id", Amount: 50_00},
+						},
+					}, nil
+				},
+			}
+
+			token := server.buildJWTForTesting(t, "test_user_id")
+			req, err := http.NewRequest("GET", "/groups/1/settle_plan", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Accept", "application/json")
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(server.router.ServeHTTP)
+			handler.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != http.StatusOK {
+				t.Fatalf("expected status code %d, got %d", http.StatusOK, status)
+			}
+
+			var got planetscale.SettlePlan
+			err = json.Unmarshal(rr.Body.Bytes(), &got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got.Transfers) != 1 {
+				t.Errorf("expected 1 transfer, got %d", len(got.Transfers))
+			} else if got.Transfers[0].Amount != 50_00 {
+				t.Errorf("expected amount 50, got %s", got.Transfers[0].Amount)
+			}
+		})
+
+		t.Run("user not a member of group", func(t *testing.T) {
+			server.repos.GroupMember = &db_mock.GroupMemberRepo{
+				GetFn: func(tx *sql.Tx, groupID int64, userID string) (*planetscale.GroupMember, error) {
+					return nil, planetscale.Errorf(planetscale.ENOTFOUND, "no group member found with ID %d", groupID)
+				},
+			}
+
+			token := server.buildJWTForTesting(t, "test_user_id")
+			req, err := http.NewRequest("GET", "/groups/1/settle_plan", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Accept", "application/json")
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(server.router.ServeHTTP)
+			handler.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != http.StatusNotFound {
+				t.Errorf("expected status code %d, got %d", http.StatusNotFound, status)
+			}
+		})
+	})
+
+	t.Run("POST /groups/:id/settle_plan", func(t *testing.T) {
+		t.Run("successful apply", func(t *testing.T) {
+			server.repos.GroupMember = &db_mock.GroupMemberRepo{
+				GetFn: func(tx *sql.Tx, groupID int64, userID string) (*planetscale.GroupMember, error) {
+					return &planetscale.GroupMember{GroupID: groupID, UserID: userID, Role: planetscale.RoleMember}, nil
+				},
+			}
+			server.services.Balance = &service_mock.BalanceService{
+				GetSettlePlanFn: func(groupID int64, apply bool) (*planetscale.SettlePlan, error) {
+					if !apply {
+						t.Fatal("expected plan to be applied")
+					}
+					return &planetscale.SettlePlan{
+						GroupID: groupID,
+						Transfers: []*planetscale.Transfer{
+							{PaidBy: "test-user-id-2", PaidTo: "test_user_id", Amount: 50_00},
+						},
+						Settlements: []*planetscale.Settlement{
+							{SettlementID: 1, GroupID: groupID, PaidBy: "test-user-id-2", PaidTo: "test_user_id", Amount: 50_00},
+						},
+					}, nil
+				},
+			}
+
+			token := server.buildJWTForTesting(t, "test_user_id")
+			req, err := http.NewRequest("POST", "/groups/1/settle_plan", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Accept", "application/json")
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(server.router.ServeHTTP)
+			handler.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != http.StatusCreated {
+				t.Fatalf("expected status code %d, got %d", http.StatusCreated, status)
+			}
+
+			var got planetscale.SettlePlan
+			err = json.Unmarshal(rr.Body.Bytes(), &got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got.Settlements) != 1 {
+				t.Errorf("expected 1 settlement, got %d", len(got.Settlements))
+			}
+		})
+	})
+
+	t.Run("GET /groups/:id/history", func(t *testing.T) {
+		t.Run("successful get", func(t *testing.T) {
+			server.repos.GroupMember = &db_mock.GroupMemberRepo{
+				GetFn: func(tx *sql.Tx, groupID int64, userID string) (*planetscale.GroupMember, error) {
+					return &planetscale.GroupMember{GroupID: groupID, UserID: userID, Role: planetscale.RoleMember}, nil
+				},
+			}
+			server.repos.AuditEvent = &db_mock.AuditEventRepo{
+				FindFn: func(tx *sql.Tx, filter planetscale.AuditEventFilter) ([]*planetscale.AuditEvent, error) {
+					if filter.GroupID != 1 {
+						t.Fatalf("expected group id 1, got %d", filter.GroupID)
+					}
+					return []*planetscale.AuditEvent{
+						{AuditEventID: 1, GroupID: &filter.GroupID, EntityType: planetscale.AuditEntityGroup, EntityID: "1", Action: planetscale.AuditActionCreate, ActorID: "test_user_id"},
+						{AuditEventID: 2, GroupID: &filter.GroupID, EntityType: planetscale.AuditEntityExpense, EntityID: "1", Action: planetscale.AuditActionDelete, ActorID: "test_user_id"},
+					}, nil
+				},
+			}
+
+			token := server.buildJWTForTesting(t, "test_user_id")
+			req, err := http.NewRequest("GET", "/groups/1/history", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Accept", "application/json")
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(server.router.ServeHTTP)
+			handler.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != http.StatusOK {
+				t.Fatalf("expected status code %d, got %d", http.StatusOK, status)
+			}
+
+			var got findAuditEventsResponse
+			err = json.Unmarshal(rr.Body.Bytes(), &got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.N != 2 {
+				t.Errorf("expected 2 audit events, got %d", got.N)
+			} else if got.AuditEvents[1].Action != planetscale.AuditActionDelete {
+				t.Errorf("expected delete action, got %s", got.AuditEvents[1].Action)
+			}
+		})
+
+		t.Run("user not a member of group", func(t *testing.T) {
+			server.repos.GroupMember = &db_mock.GroupMemberRepo{
+				GetFn: func(tx *sql.Tx, groupID int64, userID string) (*planetscale.GroupMember, error) {
+					return nil, planetscale.Errorf(planetscale.ENOTFOUND, "no group member found with ID %d", groupID)
+				},
+			}
+
+			token := server.buildJWTForTesting(t, "test_user_id")
+			req, err := http.NewRequest("GET", "/groups/1/history", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Accept", "application/json")
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(server.router.ServeHTTP)
+			handler.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != http.StatusNotFound {
+				t.Errorf("expected status code %d, got %d", http.StatusNotFound, status)
+			}
+		})
+	})
+
+	t.Run("GET /groups/:id/trash", func(t *testing.T) {
+		t.Run("successful get", func(t *testing.T) {
+			groupID := int64(1)
+			// the trash stays reachable after the group is deleted
+			server.repos.GroupMember = &db_mock.GroupMemberRepo{
+				GetFn: func(tx *sql.Tx, groupID int64, userID string) (*planetscale.GroupMember, error) {
+					return nil, planetscale.Errorf(planetscale.ENOTFOUND, "no group member found with ID %d", groupID)
+				},
+				GetIncludingDeletedGroupFn: func(tx *sql.Tx, groupID int64, userID string) (*planetscale.GroupMember, error) {
+					return &planetscale.GroupMember{GroupID: groupID, UserID: userID, Role: planetscale.RoleMember}, nil
+				},
+			}
+			server.repos.Expense = &db_mock.ExpenseRepo{
+				FindFn: func(tx *sql.Tx, filter planetscale.ExpenseFilter) ([]*planetscale.Expense, error) {
+					if !filter.Deleted {
+						t.Fatal("expected deleted expenses to be listed")
+					}
+					return []*planetscale.Expense{{ExpenseID: 1, GroupID: &groupID}}, nil
+				},
+			}
+			server.repos.Settlement = &db_mock.SettlementRepo{
+				FindFn: func(tx *sql.Tx, filter planetscale.SettlementFilter) ([]*planetscale.Settlement, error) {
+					if !filter.Deleted {
+						t.Fatal("expected deleted settlements to be listed")
+					}
+					return nil, nil
+				},
+			}
+
+			token := server.buildJWTForTesting(t, "test_user_id")
+			req, err := http.NewRequest("GET", "/groups/1/trash", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Accept", "application/json")
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(server.router.ServeHTTP)
+			handler.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != http.StatusOK {
+				t.Fatalf("expected status code %d, got %d", http.StatusOK, status)
+			}
+
+			var got groupTrashResponse
+			err = json.Unmarshal(rr.Body.Bytes(), &got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got.Expenses) != 1 {
+				t.Errorf("expected 1 deleted expense, got %d", len(got.Expenses))
+			} else if len(got.Settlements) != 0 {
+				t.Errorf("expected no deleted settlements, got %d", len(got.Settlements))
+			}
+		})
+	})
+
+	t.Run("POST /groups/:id/restore", func(t *testing.T) {
+		t.Run("not the owner", func(t *testing.T) {
+			server.repos.ExpenseGroup = &db_mock.ExpenseGroupRepo{
+				RestoreFn: func(tx *sql.Tx, groupID int64) error {
+					return nil
+				},
+				GetFn: func(tx *sql.Tx, groupID int64) (*planetscale.ExpenseGroup, error) {
+					return &planetscale.ExpenseGroup{ExpenseGroupID: groupID, CreateBy: "someone-else"}, nil
+				},
+			}
+			server.repos.GroupMember = mockGroupMembers(map[string]string{
+				"someone-else": planetscale.RoleOwner,
+				"test_user_id": planetscale.RoleAdmin,
+			})
+
+			token := server.buildJWTForTesting(t, "test_user_id")
+			req, err := http.NewRequest("POST", "/groups/1/restore", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Accept", "application/json")
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(server.router.ServeHTTP)
+			handler.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != http.StatusForbidden {
+				t.Errorf("expected status code %d, got %d", http.StatusForbidden, status)
+			}
+		})
+	})
+
+	t.Run("POST /groups/:id/import", func(t *testing.T) {
+		t.Run("rows with errors are rejected", func(t *testing.T) {
+			server.repos.GroupMember = &db_mock.GroupMemberRepo{
+				GetFn: func(tx *sql.Tx, groupID int64, userID string) (*planetscale.GroupMember, error) {
+					return &planetscale.GroupMember{GroupID: groupID, UserID: userID, Role: planetscale.RoleMember}, nil
+				},
+			}
+			server.services.Ledger = &service_mock.LedgerService{
+				ImportCSVFn: func(groupID int64, r io.Reader, dryRun bool) (*planetscale.LedgerImport, error) {
+					if dryRun {
+						t.Fatal("expected a real import")
+					}
+					return &planetscale.LedgerImport{
+						Errors: []*planetscale.LedgerImportError{{Line: 2, Message: "invalid date"}},
+					}, nil
+				},
+			}
+
+			token := server.buildJWTForTesting(t, "test_user_id")
+			req, err := http.NewRequest("POST", "/groups/1/import", strings.NewReader("Date,Description,Category,Cost,Currency\n"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Content-Type", "text/csv")
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(server.router.ServeHTTP)
+			handler.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != http.StatusBadRequest {
+				t.Fatalf("expected status code %d, got %d", http.StatusBadRequest, status)
+			}
+
+			var got planetscale.LedgerImport
+			err = json.Unmarshal(rr.Body.Bytes(), &got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got.Errors) != 1 || got.Errors[0].Line != 2 {
+				t.Errorf("expected error on line 2, got %+v", got.Errors)
+			}
+		})
+
+		t.Run("dry run", func(t *testing.T) {
+			server.repos.GroupMember = &db_mock.GroupMemberRepo{
+				GetFn: func(tx *sql.Tx, groupID int64, userID string) (*planetscale.GroupMember, error) {
+					return &planetscale.GroupMember{GroupID: groupID, UserID: userID, Role: planetscale.RoleMember}, nil
+				},
+			}
+			server.services.Ledger = &service_mock.LedgerService{
+				ImportCSVFn: func(groupID int64, r io.Reader, dryRun bool) (*planetscale.LedgerImport, error) {
+					if !dryRun {
+						t.Fatal("expected a dry run")
+					}
+					return &planetscale.LedgerImport{DryRun: true, Expenses: 2}, nil
+				},
+			}
+
+			token := server.buildJWTForTesting(t, "test_user_id")
+			req, err := http.NewRequest("POST", "/groups/1/import?dry_run=true", strings.NewReader(""))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Content-Type", "text/csv")
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(server.router.ServeHTTP)
+			handler.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != http.StatusOK {
+				t.Errorf("expected status code %d, got %d", http.StatusOK, status)
+			}
+		})
+
+		t.Run("too large", func(t *testing.T) {
+			server.services.Ledger = &service_mock.LedgerService{
+				ImportCSVFn: func(groupID int64, r io.Reader, dryRun bool) (*planetscale.LedgerImport, error) {
+					t.Fatal("expected nothing to be imported")
+					return nil, nil
+				},
+			}
+
+			token := server.buildJWTForTesting(t, "test_user_id")
+			req, err := http.NewRequest("POST", "/groups/1/import", bytes.NewReader(make([]byte, planetscale.MaxLedgerSize+1)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Content-Type", "text/csv")
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(server.router.ServeHTTP)
+			handler.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != http.StatusBadRequest {
+				t.Errorf("expected status code %d, got %d", http.StatusBadRequest, status)
+			}
+		})
+	})
+
+	t.Run("GET /groups/:id/export.csv", func(t *testing.T) {
+		t.Run("successful export", func(t *testing.T) {
+			server.repos.GroupMember = &db_mock.GroupMemberRepo{
+				GetFn: func(tx *sql.Tx, groupID int64, userID string) (*planetscale.GroupMember, error) {
+					return &planetscale.GroupMember{GroupID: groupID, UserID: userID, Role: planetscale.RoleMember}, nil
+				},
+			}
+			server.services.Ledger = &service_mock.LedgerService{
+				ExportCSVFn: func(groupID int64, w io.Writer) error {
+					_, err := io.WriteString(w, "record,ref\n")
+					return err
+				},
+			}
+
+			token := server.buildJWTForTesting(t, "test_user_id")
+			req, err := http.NewRequest("GET", "/groups/1/export.csv", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(server.router.ServeHTTP)
+			handler.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != http.StatusOK {
+				t.Fatalf("expected status code %d, got %d", http.StatusOK, status)
+			} else if ct := rr.Header().Get("Content-Type"); ct != "text/csv" {
+				t.Errorf("expected text/csv, got %s", ct)
+			} else if rr.Body.String() != "record,ref\n" {
+				t.Errorf("unexpected body %q", rr.Body.String())
 			}
 		})
 	})
@@ -31,7 +31,7 @@ func TestHandleExpense_All(t *testing.T) {
 						{
 							GroupID:     &groupID,
 							PaidBy:      userID,
-							Amount:      100,
+							Amount:      100_00,
 							Description: "test expense",
 							Timestamp:   time.Now(),
 						},
@@ -43,6 +43,7 @@ func TestHandleExpense_All(t *testing.T) {
 					return &planetscale.GroupMember{
 						GroupID: 1,
 						UserID:  userID,
+						Role:    planetscale.RoleMember,
 					}, nil
 				},
 			}
@@ -52,7 +53,7 @@ func TestHandleExpense_All(t *testing.T) {
 						{
 							ExpenseID:       1,
 							UserID:          userID,
-							AmountOwed:      100,
+							AmountOwed:      100_00,
 							SharePercentage: 100,
 							Note:            "test expense",
 						},
@@ -98,7 +99,7 @@ func TestHandleExpense_All(t *testing.T) {
 						{
 							GroupID:     &groupID,
 							PaidBy:      userID,
-							Amount:      100,
+							Amount:      100_00,
 							Description: "test expense",
 							Timestamp:   time.Now(),
 						},
@@ -139,14 +140,27 @@ func TestHandleExpense_All(t *testing.T) {
 					return nil
 				},
 			}
+			server.repos.GroupMember = mockGroupMembers(map[string]string{userID: planetscale.RoleMember})
+			// emails are sent in the background
+			notified := make(chan []string, 1)
+			server.services.Notifier = &service_mock.Notifier{
+				NotifyExpenseAddedFn: func(expense *planetscale.Expense, userIDs []string) error {
+					notified <- userIDs
+					return nil
+				},
+			}
 
 			expense := planetscale.Expense{
 				GroupID:     &groupID,
 				PaidBy:      userID,
-				Amount:      100,
+				Amount:      100_00,
 				Description: "test expense",
 				Timestamp:   time.Now(),
 				SplitTypeID: 1,
+				Participants: []*planetscale.ExpenseParticipant{
+					{UserID: userID},
+					{UserID: "test-user-id-2"},
+				},
 			}
 
 			body, err := json.Marshal(expense)
@@ -185,6 +199,15 @@ func TestHandleExpense_All(t *testing.T) {
 			} else if got.PaidBy != userID {
 				t.Errorf("expected paid by %s, got %s", userID, got.PaidBy)
 			}
+
+			select {
+			case userIDs := <-notified:
+				if len(userIDs) != 1 || userIDs[0] != "test-user-id-2" {
+					t.Errorf("expected only the other participant to be notified, got %v", userIDs)
+				}
+			case <-time.After(time.Second):
+				t.Error("expected the other participant to be notified")
+			}
 		})
 	})
 
@@ -197,7 +220,7 @@ func TestHandleExpense_All(t *testing.T) {
 					return &planetscale.Expense{
 						GroupID:     &groupID,
 						PaidBy:      userID,
-						Amount:      100,
+						Amount:      100_00,
 						Description: "test expense",
 						Timestamp:   time.Now(),
 					}, nil
@@ -208,6 +231,7 @@ func TestHandleExpense_All(t *testing.T) {
 					return &planetscale.GroupMember{
 						GroupID: 1,
 						UserID:  userID,
+						Role:    planetscale.RoleMember,
 					}, nil
 				},
 			}
@@ -217,7 +241,7 @@ func TestHandleExpense_All(t *testing.T) {
 						{
 							ExpenseID:       1,
 							UserID:          userID,
-							AmountOwed:      100,
+							AmountOwed:      100_00,
 							SharePercentage: 100,
 							Note:            "test expense",
 						},
@@ -259,7 +283,7 @@ func TestHandleExpense_All(t *testing.T) {
 					return &planetscale.Expense{
 						GroupID:     &groupID,
 						PaidBy:      userID,
-						Amount:      100,
+						Amount:      100_00,
 						Description: "test expense",
 						Timestamp:   time.Now(),
 					}, nil
@@ -287,82 +311,89 @@ func TestHandleExpense_All(t *testing.T) {
 				t.Errorf("expected status code %d, got %d", http.StatusNotFound, status)
 			}
 		})
+		for _, tc := range []struct {
+			name   string
+			userID string
+			want   int
+		}{
+			{name: "participant gets expense without a group", userID: "test-user-id-2", want: http.StatusOK},
+			{name: "outsider cannot see expense without a group", userID: "test-user-id-3", want: http.StatusNotFound},
+		} {
+			t.Run(tc.name, func(t *testing.T) {
+				server.repos.Expense = &db_mock.ExpenseRepo{
+					GetFn: func(tx *sql.Tx, expenseID int64) (*planetscale.Expense, error) {
+						return &planetscale.Expense{
+							ExpenseID: expenseID,
+							PaidBy:    "test-user-id",
+							CreatedBy: "test-user-id",
+							Amount:    100_00,
+						}, nil
+					},
+				}
+				server.repos.ExpenseParticipant = &db_mock.ExpenseParticipantRepo{
+					FindFn: func(tx *sql.Tx, filter planetscale.ExpenseParticipantFilter) ([]*planetscale.ExpenseParticipant, error) {
+						return []*planetscale.ExpenseParticipant{
+							{ExpenseID: 1, UserID: "test-user-id", AmountOwed: 50_00},
+							{ExpenseID: 1, UserID: "test-user-id-2", AmountOwed: 50_00},
+						}, nil
+					},
+				}
+
+				token := server.buildJWTForTesting(t, tc.userID)
+				req, err := http.NewRequest("GET", "/expenses/1", nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				req.Header.Set("Accept", "application/json")
+				req.Header.Set("Authorization", "Bearer "+token)
+
+				rr := httptest.NewRecorder()
+				handler := http.HandlerFunc(server.router.ServeHTTP)
+				handler.ServeHTTP(rr, req)
+
+				if status := rr.Code; status != tc.want {
+					t.Errorf("expected status code %d, got %d", tc.want, status)
+				}
+			})
+		}
 	})
 
 	t.Run("PATCH /expenses/{id}", func(t *testing.T) {
 		t.Run("successful update", func(t *testing.T) {
 			userID := "test-user-id"
-			newAmount := float64(200)
+			newAmount := planetscale.Money(200_00)
 			groupID := int64(1)
-			server.repos.Expense = &db_mock.ExpenseRepo{
-				UpdateFn: func(tx *sql.Tx, expenseID int64, update *planetscale.ExpenseUpdate) (*planetscale.Expense, error) {
-					return &planetscale.Expense{
-						GroupID:     &groupID,
-						PaidBy:      userID,
-						Amount:      newAmount,
-						Description: "test expense",
-						Timestamp:   time.Now(),
-						CreatedBy:   userID,
-						UpdatedBy:   userID,
-					}, nil
-				},
-				GetFn: func(tx *sql.Tx, expenseID int64) (*planetscale.Expense, error) {
-					return &planetscale.Expense{
-						GroupID:     &groupID,
-						PaidBy:      userID,
-						Amount:      100,
-						Description: "test expense",
-						Timestamp:   time.Now(),
-						CreatedBy:   userID,
-						UpdatedBy:   userID,
-					}, nil
-				},
-			}
-			server.repos.GroupMember = &db_mock.GroupMemberRepo{
-				GetFn: func(tx *sql.Tx, groupID int64, userID string) (*planetscale.GroupMember, error) {
-					return &planetscale.GroupMember{
-						GroupID: 1,
-						UserID:  userID,
-					}, nil
-				},
-			}
-			findCallCount := 0
-			server.repos.ExpenseParticipant = &db_mock.ExpenseParticipantRepo{
-				FindFn: func(tx *sql.Tx, filter planetscale.ExpenseParticipantFilter) ([]*planetscale.ExpenseParticipant, error) {
-					findCallCount++
-					if findCallCount == 1 {
-						return []*planetscale.ExpenseParticipant{
-							{
-								ExpenseID:       1,
-								UserID:          userID,
-								AmountOwed:      100,
-								SharePercentage: 100,
-								Note:            "test expense",
-							},
-						}, nil
-					} else {
-						return []*planetscale.ExpenseParticipant{
-							{
-								ExpenseID:       1,
-								UserID:          userID,
-								AmountOwed:      200,
-								SharePercentage: 100,
-								Note:            "test expense",
-							},
-							{
-								ExpenseID:       1,
-								UserID:          "test-user-id-2",
-								AmountOwed:      200,
-								SharePercentage: 100,
-								Note:            "test expense",
-							},
-						}, nil
+			var gotUpdate *planetscale.ExpenseUpdate
+			server.services.Expense = &service_mock.ExpenseService{
+				UpdateExpenseFn: func(ctx context.Context, expenseID int64, update *planetscale.ExpenseUpdate, actorID string) (*planetscale.Expense, *planetscale.Expense, error) {
+					gotUpdate = update
+					if expenseID != 1 || actorID != userID {
+						t.Errorf("expected expense 1 updated by %s, got %d by %s", userID, expenseID, actorID)
 					}
+					before := &planetscale.Expense{
+						ExpenseID: 1,
+						GroupID:   &groupID,
+						PaidBy:    userID,
+						Amount:    100_00,
+						Participants: []*planetscale.ExpenseParticipant{
+							{ExpenseID: 1, UserID: userID},
+						},
+					}
+					after := &planetscale.Expense{
+						ExpenseID:    1,
+						GroupID:      &groupID,
+						PaidBy:       userID,
+						Amount:       *update.Amount,
+						Participants: update.Participants,
+					}
+					return before, after, nil
 				},
-				DeleteFn: func(tx *sql.Tx, expenseID int64, userID string) error {
-					return nil
-				},
-				UpsertFn: func(tx *sql.Tx, expense *planetscale.ExpenseParticipant) error {
+			}
+			// emails are sent in the background
+			notified := make(chan []string, 1)
+			server.services.Notifier = &service_mock.Notifier{
+				NotifyExpenseAddedFn: func(expense *planetscale.Expense, userIDs []string) error {
+					notified <- userIDs
 					return nil
 				},
 			}
@@ -370,16 +401,8 @@ func TestHandleExpense_All(t *testing.T) {
 			expenseUpdate := planetscale.ExpenseUpdate{
 				Amount: &newAmount,
 				Participants: []*planetscale.ExpenseParticipant{
-					{
-						ExpenseID: 1,
-						UserID:    userID,
-						Note:      "test expense",
-					},
-					{
-						ExpenseID: 1,
-						UserID:    "test-user-id-2",
-						Note:      "test expense",
-					},
+					{UserID: userID},
+					{UserID: "test-user-id-2"},
 				},
 			}
 
@@ -413,43 +436,29 @@ func TestHandleExpense_All(t *testing.T) {
 			if *got.GroupID != groupID {
 				t.Fatalf("expected group id 1, got %d", *got.GroupID)
 			} else if got.Amount != newAmount {
-				t.Fatalf("expected amount %f, got %f", newAmount, got.Amount)
+				t.Fatalf("expected amount %s, got %s", newAmount, got.Amount)
 			} else if len(got.Participants) != 2 {
 				t.Fatalf("expected 2 participants, got %d", len(got.Participants))
+			} else if gotUpdate == nil || len(gotUpdate.Participants) != 2 {
+				t.Fatalf("expected the update to be passed on, got %+v", gotUpdate)
+			}
+
+			select {
+			case userIDs := <-notified:
+				if len(userIDs) != 1 || userIDs[0] != "test-user-id-2" {
+					t.Fatalf("expected only the added participant to be notified, got %v", userIDs)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("expected the added participant to be notified")
 			}
 		})
 
 		t.Run("user not a member of group", func(t *testing.T) {
 			userID := "test-user-id"
-			newAmount := float64(200)
-			groupID := int64(1)
-			server.repos.Expense = &db_mock.ExpenseRepo{
-				UpdateFn: func(tx *sql.Tx, expenseID int64, update *planetscale.ExpenseUpdate) (*planetscale.Expense, error) {
-					return &planetscale.Expense{
-						GroupID:     &groupID,
-						PaidBy:      userID,
-						Amount:      newAmount,
-						Description: "test expense",
-						Timestamp:   time.Now(),
-						CreatedBy:   userID,
-						UpdatedBy:   userID,
-					}, nil
-				},
-				GetFn: func(tx *sql.Tx, expenseID int64) (*planetscale.Expense, error) {
-					return &planetscale.Expense{
-						GroupID:     &groupID,
-						PaidBy:      userID,
-						Amount:      100,
-						Description: "test expense",
-						Timestamp:   time.Now(),
-						CreatedBy:   userID,
-						UpdatedBy:   userID,
-					}, nil
-				},
-			}
-			server.repos.GroupMember = &db_mock.GroupMemberRepo{
-				GetFn: func(tx *sql.Tx, groupID int64, userID string) (*planetscale.GroupMember, error) {
-					return nil, planetscale.Errorf(planetscale.ENOTFOUND, "no group member found with ID %d", groupID)
+			newAmount := planetscale.Money(200_00)
+			server.services.Expense = &service_mock.ExpenseService{
+				UpdateExpenseFn: func(ctx context.Context, expenseID int64, update *planetscale.ExpenseUpdate, actorID string) (*planetscale.Expense, *planetscale.Expense, error) {
+					return nil, nil, planetscale.Errorf(planetscale.ENOTFOUND, "no group member found")
 				},
 			}
 
@@ -492,7 +501,7 @@ func TestHandleExpense_All(t *testing.T) {
 					return &planetscale.Expense{
 						GroupID:     &groupID,
 						PaidBy:      userID,
-						Amount:      100,
+						Amount:      100_00,
 						Description: "test expense",
 						Timestamp:   time.Now(),
 					}, nil
@@ -503,9 +512,27 @@ func TestHandleExpense_All(t *testing.T) {
 					return &planetscale.GroupMember{
 						GroupID: 1,
 						UserID:  userID,
+						Role:    planetscale.RoleMember,
 					}, nil
 				},
 			}
+			var deletedAttachments []int64
+			server.repos.Attachment = &db_mock.AttachmentRepo{
+				FindFn: func(tx *sql.Tx, filter planetscale.AttachmentFilter) ([]*planetscale.Attachment, error) {
+					return []*planetscale.Attachment{{AttachmentID: 1, ExpenseID: filter.ExpenseID, Key: "test-key"}}, nil
+				},
+				DeleteFn: func(tx *sql.Tx, attachmentID int64) error {
+					deletedAttachments = append(deletedAttachments, attachmentID)
+					return nil
+				},
+			}
+			var deletedBlobs []string
+			server.services.Blob = &service_mock.BlobStore{
+				DeleteFn: func(ctx context.Context, key string) error {
+					deletedBlobs = append(deletedBlobs, key)
+					return nil
+				},
+			}
 
 			token := server.buildJWTForTesting(t, userID)
 			req, err := http.NewRequest("DELETE", "/expenses/1", nil)
@@ -521,6 +548,8 @@ func TestHandleExpense_All(t *testing.T) {
 
 			if status := rr.Code; status != http.StatusNoContent {
 				t.Errorf("expected status code %d, got %d", http.StatusNoContent, status)
+			} else if len(deletedAttachments) != 1 || len(deletedBlobs) != 1 || deletedBlobs[0] != "test-key" {
+				t.Errorf("expected the attachment and its blob to be deleted, got %v and %v", deletedAttachments, deletedBlobs)
 			}
 		})
 
@@ -535,7 +564,7 @@ func TestHandleExpense_All(t *testing.T) {
 					return &planetscale.Expense{
 						GroupID:     &groupID,
 						PaidBy:      userID,
-						Amount:      100,
+						Amount:      100_00,
 						Description: "test expense",
 						Timestamp:   time.Now(),
 					}, nil
@@ -563,5 +592,323 @@ func TestHandleExpense_All(t *testing.T) {
 				t.Errorf("expected status code %d, got %d", http.StatusNotFound, status)
 			}
 		})
+
+		for _, tc := range []struct {
+			name string
+			role string
+			want int
+		}{
+			{name: "members cannot delete what others added", role: planetscale.RoleMember, want: http.StatusForbidden},
+			{name: "viewers cannot delete", role: planetscale.RoleViewer, want: http.StatusForbidden},
+			{name: "admins delete anything", role: planetscale.RoleAdmin, want: http.StatusNoContent},
+		} {
+			t.Run(tc.name, func(t *testing.T) {
+				groupID := int64(1)
+				deleted := false
+				server.repos.Expense = &db_mock.ExpenseRepo{
+					DeleteFn: func(tx *sql.Tx, expenseID int64) error {
+						deleted = true
+						return nil
+					},
+					GetFn: func(tx *sql.Tx, expenseID int64) (*planetscale.Expense, error) {
+						return &planetscale.Expense{
+							ExpenseID: expenseID,
+							GroupID:   &groupID,
+							PaidBy:    "someone-else",
+							CreatedBy: "someone-else",
+							Amount:    100_00,
+						}, nil
+					},
+				}
+				server.repos.GroupMember = mockGroupMembers(map[string]string{"test-user-id": tc.role})
+				server.repos.Attachment = &db_mock.AttachmentRepo{
+					FindFn: func(tx *sql.Tx, filter planetscale.AttachmentFilter) ([]*planetscale.Attachment, error) {
+						return nil, nil
+					},
+				}
+
+				token := server.buildJWTForTesting(t, "test-user-id")
+				req, err := http.NewRequest("DELETE", "/expenses/1", nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				req.Header.Set("Accept", "application/json")
+				req.Header.Set("Authorization", "Bearer "+token)
+
+				rr := httptest.NewRecorder()
+				handler := http.HandlerFunc(server.router.ServeHTTP)
+				handler.ServeHTTP(rr, req)
+
+				if status := rr.Code; status != tc.want {
+					t.Errorf("expected status code %d, got %d", tc.want, status)
+				} else if deleted != (tc.want == http.StatusNoContent) {
+					t.Errorf("expected deleted to be %t", !deleted)
+				}
+			})
+		}
+
+		t.Run("participants cannot delete what others added without a group", func(t *testing.T) {
+			deleted := false
+			server.repos.Expense = &db_mock.ExpenseRepo{
+				DeleteFn: func(tx *sql.Tx, expenseID int64) error {
+					deleted = true
+					return nil
+				},
+				GetFn: func(tx *sql.Tx, expenseID int64) (*planetscale.Expense, error) {
+					return &planetscale.Expense{
+						ExpenseID: expenseID,
+						PaidBy:    "someone-else",
+						CreatedBy: "someone-else",
+						Amount:    100_00,
+					}, nil
+				},
+			}
+			server.repos.ExpenseParticipant = &db_mock.ExpenseParticipantRepo{
+				FindFn: func(tx *sql.Tx, filter planetscale.ExpenseParticipantFilter) ([]*planetscale.ExpenseParticipant, error) {
+					return []*planetscale.ExpenseParticipant{
+						{ExpenseID: 1, UserID: "someone-else", AmountOwed: 50_00},
+						{ExpenseID: 1, UserID: "test-user-id", AmountOwed: 50_00},
+					}, nil
+				},
+			}
+
+			token := server.buildJWTForTesting(t, "test-user-id")
+			req, err := http.NewRequest("DELETE", "/expenses/1", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Accept", "application/json")
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(server.router.ServeHTTP)
+			handler.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != http.StatusForbidden {
+				t.Errorf("expected status code %d, got %d", http.StatusForbidden, status)
+			} else if deleted {
+				t.Error("expected the expense not to be deleted")
+			}
+		})
+	})
+
+	t.Run("GET /expenses/{id}/history", func(t *testing.T) {
+		t.Run("history of deleted expense", func(t *testing.T) {
+			groupID := int64(1)
+			server.repos.AuditEvent = &db_mock.AuditEventRepo{
+				FindFn: func(tx *sql.Tx, filter planetscale.AuditEventFilter) ([]*planetscale.AuditEvent, error) {
+					if filter.EntityType != planetscale.AuditEntityExpense || filter.EntityID != "1" {
+						t.Fatalf("expected expense 1 filter, got %s %s", filter.EntityType, filter.EntityID)
+					}
+					return []*planetscale.AuditEvent{
+						{AuditEventID: 1, GroupID: &groupID, EntityType: planetscale.AuditEntityExpense, EntityID: "1", Action: planetscale.AuditActionCreate, ActorID: "test_user_id"},
+						{AuditEventID: 2, GroupID: &groupID, EntityType: planetscale.AuditEntityExpense, EntityID: "1", Action: planetscale.AuditActionDelete, ActorID: "test_user_id"},
+					}, nil
+				},
+			}
+			server.repos.GroupMember = &db_mock.GroupMemberRepo{
+				GetFn: func(tx *sql.Tx, groupID int64, userID string) (*planetscale.GroupMember, error) {
+					return &planetscale.GroupMember{GroupID: groupID, UserID: userID, Role: planetscale.RoleMember}, nil
+				},
+			}
+
+			token := server.buildJWTForTesting(t, "test_user_id")
+			req, err := http.NewRequest("GET", "/expenses/1/history", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Accept", "application/json")
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(server.router.ServeHTTP)
+			handler.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != http.StatusOK {
+				t.Fatalf("expected status code %d, got %d", http.StatusOK, status)
+			}
+
+			var got findAuditEventsResponse
+			err = json.Unmarshal(rr.Body.Bytes(), &got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.N != 2 {
+				t.Errorf("expected 2 audit events, got %d", got.N)
+			}
+		})
+
+		t.Run("no history", func(t *testing.T) {
+			server.repos.AuditEvent = &db_mock.AuditEventRepo{
+				FindFn: func(tx *sql.Tx, filter planetscale.AuditEventFilter) ([]*planetscale.AuditEvent, error) {
+					return nil, nil
+				},
+			}
+
+			token := server.buildJWTForTesting(t, "test_user_id")
+			req, err := http.NewRequest("GET", "/expenses/1/history", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Accept", "application/json")
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(server.router.ServeHTTP)
+			handler.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != http.StatusNotFound {
+				t.Errorf("expected status code %d, got %d", http.StatusNotFound, status)
+			}
+		})
+	})
+
+	t.Run("POST /expenses/{id}/restore", func(t *testing.T) {
+		t.Run("successful restore", func(t *testing.T) {
+			groupID := int64(1)
+			var restored []int64
+			server.repos.Expense = &db_mock.ExpenseRepo{
+				RestoreFn: func(tx *sql.Tx, expenseID int64) error {
+					restored = append(restored, expenseID)
+					return nil
+				},
+				GetFn: func(tx *sql.Tx, expenseID int64) (*planetscale.Expense, error) {
+					return &planetscale.Expense{ExpenseID: expenseID, GroupID: &groupID, Amount: 100_00, CreatedBy: "test_user_id"}, nil
+				},
+			}
+			server.repos.ExpenseParticipant = &db_mock.ExpenseParticipantRepo{
+				FindFn: func(tx *sql.Tx, filter planetscale.ExpenseParticipantFilter) ([]*planetscale.ExpenseParticipant, error) {
+					return nil, nil
+				},
+			}
+			server.repos.GroupMember = &db_mock.GroupMemberRepo{
+				GetFn: func(tx *sql.Tx, groupID int64, userID string) (*planetscale.GroupMember, error) {
+					return &planetscale.GroupMember{GroupID: groupID, UserID: userID, Role: planetscale.RoleMember}, nil
+				},
+			}
+			var events []*planetscale.AuditEvent
+			server.repos.AuditEvent = &db_mock.AuditEventRepo{
+				CreateFn: func(tx *sql.Tx, event *planetscale.AuditEvent) error {
+					events = append(events, event)
+					return nil
+				},
+			}
+
+			token := server.buildJWTForTesting(t, "test_user_id")
+			req, err := http.NewRequest("POST", "/expenses/1/restore", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Accept", "application/json")
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(server.router.ServeHTTP)
+			handler.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != http.StatusOK {
+				t.Fatalf("expected status code %d, got %d", http.StatusOK, status)
+			}
+			if len(restored) != 1 || restored[0] != 1 {
+				t.Errorf("expected expense 1 to be restored, got %v", restored)
+			} else if len(events) != 1 || events[0].Action != planetscale.AuditActionRestore {
+				t.Errorf("expected a restore audit event, got %d events", len(events))
+			}
+		})
+
+		t.Run("nothing to restore", func(t *testing.T) {
+			server.repos.Expense = &db_mock.ExpenseRepo{
+				RestoreFn: func(tx *sql.Tx, expenseID int64) error {
+					return planetscale.Errorf(planetscale.ENOTFOUND, "no deleted expense found with ID %d", expenseID)
+				},
+			}
+
+			token := server.buildJWTForTesting(t, "test_user_id")
+			req, err := http.NewRequest("POST", "/expenses/1/restore", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Accept", "application/json")
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(server.router.ServeHTTP)
+			handler.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != http.StatusNotFound {
+				t.Errorf("expected status code %d, got %d", http.StatusNotFound, status)
+			}
+		})
+	})
+
+	t.Run("GET /groups/1/expenses?limit", func(t *testing.T) {
+		t.Run("full page returns next cursor", func(t *testing.T) {
+			groupID := int64(1)
+			server.repos.Expense = &db_mock.ExpenseRepo{
+				FindFn: func(tx *sql.Tx, filter planetscale.ExpenseFilter) ([]*planetscale.Expense, error) {
+					if filter.Limit != 2 || filter.Sort != "-amount" {
+						t.Fatalf("expected limit 2 sorted by -amount, got %d %s", filter.Limit, filter.Sort)
+					} else if len(filter.PaidBy) != 2 || filter.From == nil || filter.MinAmount == nil || *filter.MinAmount != 10_00 {
+						t.Fatalf("expected paid_by, from and min_amount filters, got %+v", filter)
+					}
+					return []*planetscale.Expense{
+						{ExpenseID: 3, GroupID: &groupID, Amount: 30_00},
+						{ExpenseID: 2, GroupID: &groupID, Amount: 20_00},
+					}, nil
+				},
+			}
+			server.repos.GroupMember = &db_mock.GroupMemberRepo{
+				GetFn: func(tx *sql.Tx, groupID int64, userID string) (*planetscale.GroupMember, error) {
+					return &planetscale.GroupMember{GroupID: groupID, UserID: userID, Role: planetscale.RoleMember}, nil
+				},
+			}
+
+			token := server.buildJWTForTesting(t, "test_user_id")
+			req, err := http.NewRequest("GET", "/groups/1/expenses?limit=2&sort=-amount&paid_by=a,b&from=2024-01-01&min_amount=10", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Accept", "application/json")
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(server.router.ServeHTTP)
+			handler.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != http.StatusOK {
+				t.Fatalf("expected status code %d, got %d", http.StatusOK, status)
+			}
+
+			var got findExpensesResponse
+			err = json.Unmarshal(rr.Body.Bytes(), &got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			cursor, err := planetscale.DecodeCursor(got.NextCursor)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cursor.ID != 2 || cursor.Value != "20.00" {
+				t.Errorf("expected cursor after expense 2, got %+v", cursor)
+			}
+		})
+
+		t.Run("invalid limit", func(t *testing.T) {
+			token := server.buildJWTForTesting(t, "test_user_id")
+			req, err := http.NewRequest("GET", "/groups/1/expenses?limit=1000", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Accept", "application/json")
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(server.router.ServeHTTP)
+			handler.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != http.StatusBadRequest {
+				t.Errorf("expected status code %d, got %d", http.StatusBadRequest, status)
+			}
+		})
 	})
 }
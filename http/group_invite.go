@@ -0,0 +1,244 @@
+package http
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	planetscale "github.com/harshav17/planet_scale"
+)
+
+type groupInviteController struct {
+	repos    *planetscale.RepoProvider
+	services *planetscale.ServiceProvider
+	tm       planetscale.TransactionManager
+}
+
+func NewGroupInviteController(repos *planetscale.RepoProvider, services *planetscale.ServiceProvider, tm planetscale.TransactionManager) *groupInviteController {
+	return &groupInviteController{
+		repos:    repos,
+		services: services,
+		tm:       tm,
+	}
+}
+
+// HandlePostGroupInvite handles the POST /groups/{groupID}/invites endpoint.
+// With an email the invite is meant for that person only, without one the
+// returned token is a link anyone can use to join. Without a secret to sign
+// them there are no tokens, email invites are then accepted on sign up only.
+func (c *groupInviteController) HandlePostGroupInvite(w http.ResponseWriter, r *http.Request) {
+	user, found := planetscale.UserFromContext(r.Context())
+	if !found {
+		Error(w, r, planetscale.Errorf(planetscale.ENOTFOUND, "user context not set"))
+		return
+	}
+
+	group32, err := strconv.Atoi(chi.URLParam(r, "groupID"))
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	var body planetscale.GroupInvite
+	err = ReceiveJson(w, r, &body)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	invite := &planetscale.GroupInvite{
+		GroupID:   int64(group32),
+		Role:      body.Role,
+		CreatedBy: user.UserID,
+	}
+	if body.Email != nil {
+		addr, err := mail.ParseAddress(*body.Email)
+		if err != nil {
+			Error(w, r, planetscale.Errorf(planetscale.EINVALID, "invalid email %q", *body.Email))
+			return
+		}
+		email := strings.ToLower(addr.Address)
+		invite.Email = &email
+	}
+	if invite.Email == nil && c.services.InviteToken == nil {
+		Error(w, r, planetscale.Errorf(planetscale.ENOTIMPLEMENTED, "invite links are disabled"))
+		return
+	}
+	if invite.Role == "" {
+		invite.Role = planetscale.RoleMember
+	}
+	if !planetscale.ValidRole(invite.Role) || invite.Role == planetscale.RoleOwner {
+		Error(w, r, planetscale.Errorf(planetscale.EINVALID, "invalid role %q", invite.Role))
+		return
+	}
+
+	var audit *planetscale.AuditEvent
+	createInviteFunc := func(tx *sql.Tx) error {
+		actor, err := planetscale.CheckPermission(tx, c.repos, invite.GroupID, user.UserID, planetscale.PermissionEdit)
+		if err != nil {
+			return err
+		}
+		err = planetscale.CheckAddRole(actor, invite.Role)
+		if err != nil {
+			return err
+		}
+
+		err = c.repos.GroupInvite.Create(tx, invite)
+		if err != nil {
+			return err
+		}
+
+		// a copy, so that the token signed below is not published either
+		created := *invite
+		audit = &planetscale.AuditEvent{
+			GroupID:    &invite.GroupID,
+			EntityType: planetscale.AuditEntityGroupInvite,
+			EntityID:   strconv.FormatInt(invite.InviteID, 10),
+			Action:     planetscale.AuditActionCreate,
+			ActorID:    user.UserID,
+			After:      &created,
+		}
+		err = c.repos.AuditEvent.Create(tx, audit)
+		if err != nil {
+			return err
+		}
+
+		// signed last so the token stays out of the audit log
+		if c.services.InviteToken != nil {
+			invite.Token, err = c.services.InviteToken.Sign(invite)
+		}
+		return err
+	}
+
+	err = c.tm.ExecuteInTx(r.Context(), createInviteFunc)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+	planetscale.Publish(c.services.Events, audit)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(invite); err != nil {
+		Error(w, r, err)
+		return
+	}
+}
+
+// HandleAcceptInvite handles the POST /invites/{token}/accept endpoint, which
+// adds the authenticated user to the group they were invited to.
+func (c *groupInviteController) HandleAcceptInvite(w http.ResponseWriter, r *http.Request) {
+	user, found := planetscale.UserFromContext(r.Context())
+	if !found {
+		Error(w, r, planetscale.Errorf(planetscale.ENOTFOUND, "user context not set"))
+		return
+	}
+
+	if c.services.InviteToken == nil {
+		Error(w, r, planetscale.Errorf(planetscale.ENOTIMPLEMENTED, "invite links are disabled"))
+		return
+	}
+	inviteID, err := c.services.InviteToken.Verify(chi.URLParam(r, "token"))
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	var member *planetscale.GroupMember
+	var audit *planetscale.AuditEvent
+	acceptInviteFunc := func(tx *sql.Tx) error {
+		invite, err := c.repos.GroupInvite.Get(tx, inviteID)
+		if err != nil {
+			return err
+		}
+
+		// the context only carries the user's ID, email invites need the rest
+		invitee, err := c.repos.User.Get(tx, user.UserID)
+		if err != nil {
+			return err
+		}
+
+		member, audit, err = acceptInvite(tx, c.repos, invite, invitee)
+		return err
+	}
+
+	err = c.tm.ExecuteInTx(r.Context(), acceptInviteFunc)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+	planetscale.Publish(c.services.Events, audit)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(member); err != nil {
+		Error(w, r, err)
+		return
+	}
+}
+
+// acceptInvite adds user to the group of the invite with the role it grants.
+// Invites sent to an email only work for the user with that email, and are
+// used up once accepted. Invites to deleted groups are not found. The audit of the new member is returned for publishing
+// once the transaction commits.
+func acceptInvite(tx *sql.Tx, repos *planetscale.RepoProvider, invite *planetscale.GroupInvite, user *planetscale.User) (*planetscale.GroupMember, *planetscale.AuditEvent, error) {
+	if invite.Expired(time.Now()) {
+		return nil, nil, planetscale.Errorf(planetscale.EINVALID, "invite has expired")
+	}
+	if invite.Email != nil && !strings.EqualFold(*invite.Email, user.Email) {
+		return nil, nil, planetscale.Errorf(planetscale.EFORBIDDEN, "this invite was sent to someone else")
+	}
+
+	// deleted groups are left out
+	_, err := repos.ExpenseGroup.Get(tx, invite.GroupID)
+	if err != nil {
+		return nil, nil, planetscale.Errorf(planetscale.ENOTFOUND, "no group found with ID %d", invite.GroupID)
+	}
+
+	_, err = repos.GroupMember.Get(tx, invite.GroupID, user.UserID)
+	if err == nil {
+		return nil, nil, planetscale.Errorf(planetscale.ECONFLICT, "you are already a member of this group")
+	} else if planetscale.ErrorCode(err) != planetscale.ENOTFOUND {
+		return nil, nil, err
+	}
+
+	if invite.Email != nil {
+		err = repos.GroupInvite.Accept(tx, invite.InviteID, user.UserID)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	member := &planetscale.GroupMember{
+		GroupID: invite.GroupID,
+		UserID:  user.UserID,
+		Role:    invite.Role,
+	}
+	err = repos.GroupMember.Create(tx, member)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	audit := &planetscale.AuditEvent{
+		GroupID:    &invite.GroupID,
+		EntityType: planetscale.AuditEntityGroupMember,
+		EntityID:   user.UserID,
+		Action:     planetscale.AuditActionCreate,
+		ActorID:    user.UserID,
+		After:      member,
+	}
+	err = repos.AuditEvent.Create(tx, audit)
+	if err != nil {
+		return nil, nil, err
+	}
+	err = repos.Activity.Create(tx, planetscale.NewActivity(audit))
+	if err != nil {
+		return nil, nil, err
+	}
+	return member, audit, nil
+}
@@ -0,0 +1,266 @@
+package http
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	planetscale "github.com/harshav17/planet_scale"
+	db_mock "github.com/harshav17/planet_scale/mock/db"
+	service_mock "github.com/harshav17/planet_scale/mock/service"
+)
+
+func TestHandleGroupInvites_All(t *testing.T) {
+	server := MustOpenServer(t)
+	defer MustCloseServer(t, server.Server)
+
+	invites := map[int64]*planetscale.GroupInvite{}
+	server.repos.GroupInvite = &db_mock.GroupInviteRepo{
+		CreateFn: func(tx *sql.Tx, invite *planetscale.GroupInvite) error {
+			invite.InviteID = int64(len(invites) + 1)
+			invite.ExpiresAt = time.Now().Add(planetscale.InviteTTL)
+			invites[invite.InviteID] = invite
+			return nil
+		},
+		GetFn: func(tx *sql.Tx, inviteID int64) (*planetscale.GroupInvite, error) {
+			invite, ok := invites[inviteID]
+			if !ok {
+				return nil, planetscale.Errorf(planetscale.ENOTFOUND, "no invite found with ID %d", inviteID)
+			}
+			return invite, nil
+		},
+		AcceptFn: func(tx *sql.Tx, inviteID int64, userID string) error {
+			invites[inviteID].AcceptedBy = &userID
+			return nil
+		},
+	}
+	// tokens are just the invite ID, signing is covered by the service tests
+	server.services.InviteToken = &service_mock.InviteTokenService{
+		SignFn: func(invite *planetscale.GroupInvite) (string, error) {
+			return strconv.FormatInt(invite.InviteID, 10), nil
+		},
+		VerifyFn: func(token string) (int64, error) {
+			inviteID, err := strconv.ParseInt(token, 10, 64)
+			if err != nil {
+				return 0, planetscale.Errorf(planetscale.EINVALID, "invalid invite token")
+			}
+			return inviteID, nil
+		},
+	}
+	server.repos.User = &db_mock.UserRepo{
+		GetFn: func(tx *sql.Tx, userID string) (*planetscale.User, error) {
+			return &planetscale.User{UserID: userID, Email: userID + "@example.com"}, nil
+		},
+	}
+	deletedGroups := map[int64]bool{}
+	server.repos.ExpenseGroup = &db_mock.ExpenseGroupRepo{
+		GetFn: func(tx *sql.Tx, groupID int64) (*planetscale.ExpenseGroup, error) {
+			if deletedGroups[groupID] {
+				return nil, fmt.Errorf("no expense group found with ID %d", groupID)
+			}
+			return &planetscale.ExpenseGroup{ExpenseGroupID: groupID, GroupName: "test group"}, nil
+		},
+	}
+
+	serve := func(t *testing.T, userID, method, url string, body any) *httptest.ResponseRecorder {
+		t.Helper()
+		b, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req, err := http.NewRequest(method, url, bytes.NewReader(b))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+server.buildJWTForTesting(t, userID))
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(server.router.ServeHTTP)
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+	invite := func(t *testing.T, body planetscale.GroupInvite) planetscale.GroupInvite {
+		t.Helper()
+		rr := serve(t, "owner", "POST", "/groups/1/invites", body)
+		if status := rr.Code; status != http.StatusCreated {
+			t.Fatalf("expected status code %d, got %d: %s", http.StatusCreated, status, rr.Body)
+		}
+
+		var got planetscale.GroupInvite
+		if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+			t.Fatal(err)
+		}
+		return got
+	}
+
+	t.Run("POST /groups/1/invites", func(t *testing.T) {
+		server.repos.GroupMember = mockGroupMembers(map[string]string{
+			"owner":  planetscale.RoleOwner,
+			"member": planetscale.RoleMember,
+			"viewer": planetscale.RoleViewer,
+		})
+
+		t.Run("email invite", func(t *testing.T) {
+			email := "Friend@Example.com"
+			got := invite(t, planetscale.GroupInvite{Email: &email})
+			if got.Token == "" {
+				t.Fatal("expected a token")
+			} else if got.Email == nil || *got.Email != "friend@example.com" {
+				t.Fatalf("expected the email to be normalized, got %v", got.Email)
+			} else if got.Role != planetscale.RoleMember {
+				t.Fatalf("expected role %s, got %s", planetscale.RoleMember, got.Role)
+			}
+		})
+
+		t.Run("invalid email", func(t *testing.T) {
+			email := "not an email"
+			rr := serve(t, "owner", "POST", "/groups/1/invites", planetscale.GroupInvite{Email: &email})
+			if status := rr.Code; status != http.StatusBadRequest {
+				t.Fatalf("expected status code %d, got %d", http.StatusBadRequest, status)
+			}
+		})
+
+		t.Run("members cannot invite admins", func(t *testing.T) {
+			rr := serve(t, "member", "POST", "/groups/1/invites", planetscale.GroupInvite{Role: planetscale.RoleAdmin})
+			if status := rr.Code; status != http.StatusForbidden {
+				t.Fatalf("expected status code %d, got %d", http.StatusForbidden, status)
+			}
+		})
+
+		t.Run("viewers cannot invite", func(t *testing.T) {
+			rr := serve(t, "viewer", "POST", "/groups/1/invites", planetscale.GroupInvite{})
+			if status := rr.Code; status != http.StatusForbidden {
+				t.Fatalf("expected status code %d, got %d", http.StatusForbidden, status)
+			}
+		})
+
+		t.Run("non members cannot invite", func(t *testing.T) {
+			rr := serve(t, "stranger", "POST", "/groups/1/invites", planetscale.GroupInvite{})
+			if status := rr.Code; status != http.StatusNotFound {
+				t.Fatalf("expected status code %d, got %d", http.StatusNotFound, status)
+			}
+		})
+	})
+
+	t.Run("POST /invites/:token/accept", func(t *testing.T) {
+		t.Run("link invite", func(t *testing.T) {
+			roles := map[string]string{"owner": planetscale.RoleOwner}
+			server.repos.GroupMember = mockGroupMembers(roles)
+			link := invite(t, planetscale.GroupInvite{Role: planetscale.RoleViewer})
+
+			for _, userID := range []string{"first", "second"} {
+				rr := serve(t, userID, "POST", "/invites/"+link.Token+"/accept", nil)
+				if status := rr.Code; status != http.StatusCreated {
+					t.Fatalf("expected status code %d, got %d: %s", http.StatusCreated, status, rr.Body)
+				} else if roles[userID] != planetscale.RoleViewer {
+					t.Fatalf("expected %s to join as a viewer, got %q", userID, roles[userID])
+				}
+			}
+		})
+
+		t.Run("email invite", func(t *testing.T) {
+			roles := map[string]string{"owner": planetscale.RoleOwner}
+			server.repos.GroupMember = mockGroupMembers(roles)
+			email := "friend@example.com"
+			sent := invite(t, planetscale.GroupInvite{Email: &email})
+
+			rr := serve(t, "someone-else", "POST", "/invites/"+sent.Token+"/accept", nil)
+			if status := rr.Code; status != http.StatusForbidden {
+				t.Fatalf("expected status code %d, got %d", http.StatusForbidden, status)
+			}
+
+			rr = serve(t, "friend", "POST", "/invites/"+sent.Token+"/accept", nil)
+			if status := rr.Code; status != http.StatusCreated {
+				t.Fatalf("expected status code %d, got %d: %s", http.StatusCreated, status, rr.Body)
+			} else if roles["friend"] != planetscale.RoleMember {
+				t.Fatalf("expected friend to join as a member, got %q", roles["friend"])
+			} else if accepted := invites[sent.InviteID].AcceptedBy; accepted == nil || *accepted != "friend" {
+				t.Fatalf("expected the invite to be used up, got %v", accepted)
+			}
+		})
+
+		t.Run("already a member", func(t *testing.T) {
+			server.repos.GroupMember = mockGroupMembers(map[string]string{"owner": planetscale.RoleOwner})
+			link := invite(t, planetscale.GroupInvite{})
+
+			rr := serve(t, "owner", "POST", "/invites/"+link.Token+"/accept", nil)
+			if status := rr.Code; status != http.StatusConflict {
+				t.Fatalf("expected status code %d, got %d", http.StatusConflict, status)
+			}
+		})
+
+		t.Run("expired", func(t *testing.T) {
+			roles := map[string]string{"owner": planetscale.RoleOwner}
+			server.repos.GroupMember = mockGroupMembers(roles)
+			link := invite(t, planetscale.GroupInvite{})
+			invites[link.InviteID].ExpiresAt = time.Now().Add(-time.Minute)
+
+			rr := serve(t, "late", "POST", "/invites/"+link.Token+"/accept", nil)
+			if status := rr.Code; status != http.StatusBadRequest {
+				t.Fatalf("expected status code %d, got %d", http.StatusBadRequest, status)
+			} else if _, ok := roles["late"]; ok {
+				t.Fatal("expected no member to be added")
+			}
+		})
+
+		t.Run("deleted group", func(t *testing.T) {
+			roles := map[string]string{"owner": planetscale.RoleOwner}
+			server.repos.GroupMember = mockGroupMembers(roles)
+			link := invite(t, planetscale.GroupInvite{})
+			deletedGroups[1] = true
+			defer delete(deletedGroups, 1)
+
+			rr := serve(t, "late", "POST", "/invites/"+link.Token+"/accept", nil)
+			if status := rr.Code; status != http.StatusNotFound {
+				t.Fatalf("expected status code %d, got %d", http.StatusNotFound, status)
+			} else if _, ok := roles["late"]; ok {
+				t.Fatal("expected no member to be added")
+			}
+		})
+
+		t.Run("invalid token", func(t *testing.T) {
+			rr := serve(t, "anyone", "POST", "/invites/forged/accept", nil)
+			if status := rr.Code; status != http.StatusBadRequest {
+				t.Fatalf("expected status code %d, got %d", http.StatusBadRequest, status)
+			} else if !strings.Contains(rr.Body.String(), "invalid invite token") {
+				t.Fatalf("unexpected error %s", rr.Body)
+			}
+		})
+	})
+
+	t.Run("without an invite secret", func(t *testing.T) {
+		server.services.InviteToken = nil
+		server.repos.GroupMember = mockGroupMembers(map[string]string{
+			"owner": planetscale.RoleOwner,
+		})
+
+		t.Run("link invites are disabled", func(t *testing.T) {
+			rr := serve(t, "owner", "POST", "/groups/1/invites", planetscale.GroupInvite{})
+			if status := rr.Code; status != http.StatusNotImplemented {
+				t.Fatalf("expected status code %d, got %d", http.StatusNotImplemented, status)
+			}
+
+			rr = serve(t, "anyone", "POST", "/invites/1/accept", nil)
+			if status := rr.Code; status != http.StatusNotImplemented {
+				t.Fatalf("expected status code %d, got %d", http.StatusNotImplemented, status)
+			}
+		})
+
+		t.Run("email invite", func(t *testing.T) {
+			email := "friend@example.com"
+			got := invite(t, planetscale.GroupInvite{Email: &email})
+			if got.Token != "" {
+				t.Fatalf("expected no token, got %s", got.Token)
+			}
+		})
+	})
+}
@@ -11,12 +11,13 @@ import (
 )
 
 type groupMemberController struct {
-	repos *planetscale.RepoProvider
-	tm    planetscale.TransactionManager
+	repos    *planetscale.RepoProvider
+	services *planetscale.ServiceProvider
+	tm       planetscale.TransactionManager
 }
 
-func NewGroupMemberController(repos *planetscale.RepoProvider, tm planetscale.TransactionManager) *groupMemberController {
-	return &groupMemberController{repos: repos, tm: tm}
+func NewGroupMemberController(repos *planetscale.RepoProvider, services *planetscale.ServiceProvider, tm planetscale.TransactionManager) *groupMemberController {
+	return &groupMemberController{repos: repos, services: services, tm: tm}
 }
 
 func (c *groupMemberController) HandleGetGroupMembers(w http.ResponseWriter, r *http.Request) {
@@ -33,16 +34,27 @@ func (c *groupMemberController) HandleGetGroupMembers(w http.ResponseWriter, r *
 	}
 	groupID := int64(group32)
 
+	// former members are listed apart so that their expenses can still be
+	// attributed to a name
+	inactive := false
+	if s := r.URL.Query().Get("inactive"); s != "" {
+		inactive, err = strconv.ParseBool(s)
+		if err != nil {
+			Error(w, r, planetscale.Errorf(planetscale.EINVALID, "invalid inactive %q", s))
+			return
+		}
+	}
+
 	var groupMembers []*planetscale.GroupMember
 	getGroupMemberFunc := func(tx *sql.Tx) error {
-		// check if user is a member of the group
-		_, err = c.repos.GroupMember.Get(tx, groupID, user.UserID)
+		_, err = planetscale.CheckPermission(tx, c.repos, groupID, user.UserID, planetscale.PermissionView)
 		if err != nil {
 			return err
 		}
 
 		groupMembers, err = c.repos.GroupMember.Find(tx, planetscale.GroupMemberFilter{
-			GroupID: groupID,
+			GroupID:  groupID,
+			Inactive: inactive,
 		})
 		if err != nil {
 			return err
@@ -95,16 +107,26 @@ func (c *groupMemberController) HandlePostGroupMember(w http.ResponseWriter, r *
 	}
 
 	var groupMember planetscale.GroupMember
-	groupMember.GroupID = int64(group32)
-
 	if err := json.NewDecoder(r.Body).Decode(&groupMember); err != nil {
 		Error(w, r, err)
 		return
 	}
+	groupMember.GroupID = int64(group32)
+	if groupMember.Role == "" {
+		groupMember.Role = planetscale.RoleMember
+	}
+	if !planetscale.ValidRole(groupMember.Role) || groupMember.Role == planetscale.RoleOwner {
+		Error(w, r, planetscale.Errorf(planetscale.EINVALID, "invalid role %q", groupMember.Role))
+		return
+	}
 
+	var audit *planetscale.AuditEvent
 	createGroupMemberFunc := func(tx *sql.Tx) error {
-		// check if user is a member of the group
-		_, err = c.repos.GroupMember.Get(tx, groupMember.GroupID, user.UserID)
+		actor, err := planetscale.CheckPermission(tx, c.repos, groupMember.GroupID, user.UserID, planetscale.PermissionEdit)
+		if err != nil {
+			return err
+		}
+		err = planetscale.CheckAddRole(actor, groupMember.Role)
 		if err != nil {
 			return err
 		}
@@ -113,7 +135,20 @@ func (c *groupMemberController) HandlePostGroupMember(w http.ResponseWriter, r *
 		if err != nil {
 			return err
 		}
-		return nil
+
+		audit = &planetscale.AuditEvent{
+			GroupID:    &groupMember.GroupID,
+			EntityType: planetscale.AuditEntityGroupMember,
+			EntityID:   groupMember.UserID,
+			Action:     planetscale.AuditActionCreate,
+			ActorID:    user.UserID,
+			After:      &groupMember,
+		}
+		err = c.repos.AuditEvent.Create(tx, audit)
+		if err != nil {
+			return err
+		}
+		return c.repos.Activity.Create(tx, planetscale.NewActivity(audit))
 	}
 
 	err = c.tm.ExecuteInTx(r.Context(), createGroupMemberFunc)
@@ -121,6 +156,7 @@ func (c *groupMemberController) HandlePostGroupMember(w http.ResponseWriter, r *
 		Error(w, r, err)
 		return
 	}
+	planetscale.Publish(c.services.Events, audit)
 
 	w.WriteHeader(http.StatusCreated)
 	w.Header().Set("Content-Type", "application/json")
@@ -130,7 +166,9 @@ func (c *groupMemberController) HandlePostGroupMember(w http.ResponseWriter, r *
 	}
 }
 
-func (c *groupMemberController) HandleDeleteGroupMember(w http.ResponseWriter, r *http.Request) {
+// HandlePatchGroupMember handles the PATCH /groups/{groupID}/members/{userID}
+// endpoint, which changes a member's role.
+func (c *groupMemberController) HandlePatchGroupMember(w http.ResponseWriter, r *http.Request) {
 	user, found := planetscale.UserFromContext(r.Context())
 	if !found {
 		Error(w, r, planetscale.Errorf(planetscale.ENOTFOUND, "user context not set"))
@@ -145,21 +183,100 @@ func (c *groupMemberController) HandleDeleteGroupMember(w http.ResponseWriter, r
 	groupID := int64(group32)
 	userID := chi.URLParam(r, "userID")
 
-	deleteGroupMemberFunc := func(tx *sql.Tx) error {
-		// check if user is a member of the group
-		_, err = c.repos.GroupMember.Get(tx, groupID, user.UserID)
+	var update planetscale.GroupMemberUpdate
+	err = ReceiveJson(w, r, &update)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+	if update.Role != nil && (!planetscale.ValidRole(*update.Role) || *update.Role == planetscale.RoleOwner) {
+		Error(w, r, planetscale.Errorf(planetscale.EINVALID, "invalid role %q, ownership is changed with a transfer", *update.Role))
+		return
+	}
+
+	var member *planetscale.GroupMember
+	var audit *planetscale.AuditEvent
+	patchGroupMemberFunc := func(tx *sql.Tx) error {
+		actor, err := planetscale.CheckPermission(tx, c.repos, groupID, user.UserID, planetscale.PermissionManage)
+		if err != nil {
+			return err
+		}
+		if userID == actor.UserID {
+			return planetscale.Errorf(planetscale.EFORBIDDEN, "you cannot change your own role")
+		}
+
+		before, err := c.repos.GroupMember.Get(tx, groupID, userID)
 		if err != nil {
 			return err
 		}
+		if !actor.Outranks(before.Role) || (update.Role != nil && !actor.Outranks(*update.Role)) {
+			return planetscale.Errorf(planetscale.EFORBIDDEN, "a group %s can only manage roles below their own", actor.Role)
+		}
 
-		err = c.repos.GroupMember.Delete(tx, groupID, userID)
+		member, err = c.repos.GroupMember.Update(tx, groupID, userID, &update)
 		if err != nil {
 			return err
 		}
-		return nil
+
+		audit = &planetscale.AuditEvent{
+			GroupID:    &groupID,
+			EntityType: planetscale.AuditEntityGroupMember,
+			EntityID:   userID,
+			Action:     planetscale.AuditActionUpdate,
+			ActorID:    user.UserID,
+			Before:     before,
+			After:      member,
+		}
+		err = c.repos.AuditEvent.Create(tx, audit)
+		if err != nil {
+			return err
+		}
+		return c.repos.Activity.Create(tx, planetscale.NewActivity(audit))
 	}
 
-	err = c.tm.ExecuteInTx(r.Context(), deleteGroupMemberFunc)
+	err = c.tm.ExecuteInTx(r.Context(), patchGroupMemberFunc)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+	planetscale.Publish(c.services.Events, audit)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(member); err != nil {
+		Error(w, r, err)
+		return
+	}
+}
+
+// HandleDeleteGroupMember handles the DELETE /groups/{groupID}/members/{userID}
+// endpoint. The member is marked as inactive rather than deleted so that their
+// expenses keep counting in the balances. Members with an outstanding balance
+// can only be removed by an admin passing force=true.
+func (c *groupMemberController) HandleDeleteGroupMember(w http.ResponseWriter, r *http.Request) {
+	user, found := planetscale.UserFromContext(r.Context())
+	if !found {
+		Error(w, r, planetscale.Errorf(planetscale.ENOTFOUND, "user context not set"))
+		return
+	}
+
+	group32, err := strconv.Atoi(chi.URLParam(r, "groupID"))
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+	groupID := int64(group32)
+	userID := chi.URLParam(r, "userID")
+
+	force := false
+	if s := r.URL.Query().Get("force"); s != "" {
+		force, err = strconv.ParseBool(s)
+		if err != nil {
+			Error(w, r, planetscale.Errorf(planetscale.EINVALID, "invalid force %q", s))
+			return
+		}
+	}
+
+	err = c.services.GroupMember.Remove(r.Context(), groupID, userID, user.UserID, force)
 	if err != nil {
 		Error(w, r, err)
 		return
@@ -167,3 +284,101 @@ func (c *groupMemberController) HandleDeleteGroupMember(w http.ResponseWriter, r
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// HandleTransferOwnership handles the POST /groups/{groupID}/transfer_ownership
+// endpoint. The new owner has to be a member already, the old owner stays on
+// as an admin.
+func (c *groupMemberController) HandleTransferOwnership(w http.ResponseWriter, r *http.Request) {
+	user, found := planetscale.UserFromContext(r.Context())
+	if !found {
+		Error(w, r, planetscale.Errorf(planetscale.ENOTFOUND, "user context not set"))
+		return
+	}
+
+	group32, err := strconv.Atoi(chi.URLParam(r, "groupID"))
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+	groupID := int64(group32)
+
+	var transfer planetscale.TransferOwnership
+	err = ReceiveJson(w, r, &transfer)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+	if transfer.UserID == "" {
+		Error(w, r, planetscale.Errorf(planetscale.EINVALID, "user_id is required"))
+		return
+	}
+
+	var members []*planetscale.GroupMember
+	var audits []*planetscale.AuditEvent
+	transferOwnershipFunc := func(tx *sql.Tx) error {
+		owner, err := planetscale.CheckPermission(tx, c.repos, groupID, user.UserID, planetscale.PermissionOwn)
+		if err != nil {
+			return err
+		}
+		if transfer.UserID == owner.UserID {
+			return planetscale.Errorf(planetscale.EINVALID, "you already own this group")
+		}
+
+		before, err := c.repos.GroupMember.Get(tx, groupID, transfer.UserID)
+		if err != nil {
+			return planetscale.Errorf(planetscale.ENOTFOUND, "the new owner has to be a member of this group")
+		}
+
+		for _, change := range []struct {
+			before *planetscale.GroupMember
+			role   string
+		}{
+			{before: owner, role: planetscale.RoleAdmin},
+			{before: before, role: planetscale.RoleOwner},
+		} {
+			after, err := c.repos.GroupMember.Update(tx, groupID, change.before.UserID, &planetscale.GroupMemberUpdate{
+				Role: &change.role,
+			})
+			if err != nil {
+				return err
+			}
+
+			audit := &planetscale.AuditEvent{
+				GroupID:    &groupID,
+				EntityType: planetscale.AuditEntityGroupMember,
+				EntityID:   after.UserID,
+				Action:     planetscale.AuditActionUpdate,
+				ActorID:    user.UserID,
+				Before:     change.before,
+				After:      after,
+			}
+			err = c.repos.AuditEvent.Create(tx, audit)
+			if err != nil {
+				return err
+			}
+			err = c.repos.Activity.Create(tx, planetscale.NewActivity(audit))
+			if err != nil {
+				return err
+			}
+			audits = append(audits, audit)
+			members = append(members, after)
+		}
+		return nil
+	}
+
+	err = c.tm.ExecuteInTx(r.Context(), transferOwnershipFunc)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+	planetscale.Publish(c.services.Events, audits...)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(findGroupMembersResponse{
+		GroupMembers: members,
+		N:            len(members),
+	}); err != nil {
+		Error(w, r, err)
+		return
+	}
+}
@@ -10,6 +10,7 @@ import (
 
 	planetscale "github.com/harshav17/planet_scale"
 	db_mock "github.com/harshav17/planet_scale/mock/db"
+	service_mock "github.com/harshav17/planet_scale/mock/service"
 )
 
 func TestHandleGroupMembers_All(t *testing.T) {
@@ -32,6 +33,7 @@ func TestHandleGroupMembers_All(t *testing.T) {
 					return &planetscale.GroupMember{
 						GroupID: 1,
 						UserID:  user_id,
+						Role:    planetscale.RoleMember,
 					}, nil
 				},
 			}
@@ -102,6 +104,7 @@ func TestHandleGroupMembers_All(t *testing.T) {
 					return &planetscale.GroupMember{
 						GroupID: 1,
 						UserID:  "test-user-id",
+						Role:    planetscale.RoleMember,
 					}, nil
 				},
 			}
@@ -175,10 +178,20 @@ func TestHandleGroupMembers_All(t *testing.T) {
 					return &planetscale.GroupMember{
 						GroupID: 1,
 						UserID:  userID,
+						Role:    planetscale.RoleMember,
 					}, nil
 				},
 			}
 
+			server.services.GroupMember = service_mock.GroupMemberService{
+				RemoveFn: func(groupID int64, userID string, actorID string, force bool) error {
+					if actorID != userID {
+						t.Errorf("expected %s to remove themselves, got %s", userID, actorID)
+					}
+					return nil
+				},
+			}
+
 			token := server.buildJWTForTesting(t, userID)
 			req, err := http.NewRequest("DELETE", "/groups/1/members/test-user-id", nil)
 			if err != nil {
@@ -197,9 +210,9 @@ func TestHandleGroupMembers_All(t *testing.T) {
 		})
 
 		t.Run("user not part of the group", func(t *testing.T) {
-			server.repos.GroupMember = &db_mock.GroupMemberRepo{
-				GetFn: func(tx *sql.Tx, groupID int64, userID string) (*planetscale.GroupMember, error) {
-					return nil, planetscale.Errorf(planetscale.ENOTFOUND, "user not found")
+			server.services.GroupMember = service_mock.GroupMemberService{
+				RemoveFn: func(groupID int64, userID string, actorID string, force bool) error {
+					return planetscale.Errorf(planetscale.ENOTFOUND, "user not found")
 				},
 			}
 
@@ -221,3 +234,241 @@ func TestHandleGroupMembers_All(t *testing.T) {
 		})
 	})
 }
+
+// mockGroupMembers returns a GroupMemberRepo backed by roles, a map of user
+// IDs to their role in the group. Updates and deletes are applied to it.
+func mockGroupMembers(roles map[string]string) *db_mock.GroupMemberRepo {
+	return &db_mock.GroupMemberRepo{
+		GetFn: func(tx *sql.Tx, groupID int64, userID string) (*planetscale.GroupMember, error) {
+			role, ok := roles[userID]
+			if !ok {
+				return nil, planetscale.Errorf(planetscale.ENOTFOUND, "user not found")
+			}
+			return &planetscale.GroupMember{GroupID: groupID, UserID: userID, Role: role}, nil
+		},
+		CreateFn: func(tx *sql.Tx, groupMember *planetscale.GroupMember) error {
+			roles[groupMember.UserID] = groupMember.Role
+			return nil
+		},
+		UpdateFn: func(tx *sql.Tx, groupID int64, userID string, update *planetscale.GroupMemberUpdate) (*planetscale.GroupMember, error) {
+			if _, ok := roles[userID]; !ok {
+				return nil, planetscale.Errorf(planetscale.ENOTFOUND, "user not found")
+			}
+			if update.Role != nil {
+				roles[userID] = *update.Role
+			}
+			return &planetscale.GroupMember{GroupID: groupID, UserID: userID, Role: roles[userID]}, nil
+		},
+		DeleteFn: func(tx *sql.Tx, groupID int64, userID string) error {
+			delete(roles, userID)
+			return nil
+		},
+	}
+}
+
+// mockGroupMemberService returns a GroupMemberService that removes members
+// from roles, refusing those in balances unless forced. Who may remove whom
+// is covered by the service tests.
+func mockGroupMemberService(roles map[string]string, balances map[string]planetscale.Money) service_mock.GroupMemberService {
+	return service_mock.GroupMemberService{
+		RemoveFn: func(groupID int64, userID string, actorID string, force bool) error {
+			if balances[userID] != 0 && !force {
+				return planetscale.Errorf(planetscale.ECONFLICT, "outstanding balance")
+			}
+			delete(roles, userID)
+			return nil
+		},
+	}
+}
+
+func TestHandleGroupMembers_Roles(t *testing.T) {
+	server := MustOpenServer(t)
+	defer MustCloseServer(t, server.Server)
+
+	serve := func(t *testing.T, userID, method, url string, body any) *httptest.ResponseRecorder {
+		t.Helper()
+		b, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req, err := http.NewRequest(method, url, bytes.NewReader(b))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+server.buildJWTForTesting(t, userID))
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(server.router.ServeHTTP)
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+	role := func(role string) planetscale.GroupMemberUpdate {
+		return planetscale.GroupMemberUpdate{Role: &role}
+	}
+
+	t.Run("POST /groups/1/members", func(t *testing.T) {
+		t.Run("members cannot add admins", func(t *testing.T) {
+			roles := map[string]string{"member": planetscale.RoleMember}
+			server.repos.GroupMember = mockGroupMembers(roles)
+
+			rr := serve(t, "member", "POST", "/groups/1/members", planetscale.GroupMember{UserID: "new", Role: planetscale.RoleAdmin})
+			if status := rr.Code; status != http.StatusForbidden {
+				t.Fatalf("expected status code %d, got %d", http.StatusForbidden, status)
+			} else if _, ok := roles["new"]; ok {
+				t.Fatal("expected no member to be added")
+			}
+		})
+
+		t.Run("viewers cannot add members", func(t *testing.T) {
+			server.repos.GroupMember = mockGroupMembers(map[string]string{"viewer": planetscale.RoleViewer})
+
+			rr := serve(t, "viewer", "POST", "/groups/1/members", planetscale.GroupMember{UserID: "new"})
+			if status := rr.Code; status != http.StatusForbidden {
+				t.Fatalf("expected status code %d, got %d", http.StatusForbidden, status)
+			}
+		})
+
+		t.Run("nobody is added as owner", func(t *testing.T) {
+			server.repos.GroupMember = mockGroupMembers(map[string]string{"owner": planetscale.RoleOwner})
+
+			rr := serve(t, "owner", "POST", "/groups/1/members", planetscale.GroupMember{UserID: "new", Role: planetscale.RoleOwner})
+			if status := rr.Code; status != http.StatusBadRequest {
+				t.Fatalf("expected status code %d, got %d", http.StatusBadRequest, status)
+			}
+		})
+	})
+
+	t.Run("PATCH /groups/1/members/user-id", func(t *testing.T) {
+		t.Run("owner promotes a member", func(t *testing.T) {
+			roles := map[string]string{"owner": planetscale.RoleOwner, "member": planetscale.RoleMember}
+			server.repos.GroupMember = mockGroupMembers(roles)
+
+			rr := serve(t, "owner", "PATCH", "/groups/1/members/member", role(planetscale.RoleAdmin))
+			if status := rr.Code; status != http.StatusOK {
+				t.Fatalf("expected status code %d, got %d: %s", http.StatusOK, status, rr.Body)
+			}
+
+			var got planetscale.GroupMember
+			if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			} else if got.Role != planetscale.RoleAdmin || roles["member"] != planetscale.RoleAdmin {
+				t.Fatalf("expected member to be an admin, got %s", got.Role)
+			}
+		})
+
+		t.Run("admins cannot promote to admin", func(t *testing.T) {
+			roles := map[string]string{"admin": planetscale.RoleAdmin, "member": planetscale.RoleMember}
+			server.repos.GroupMember = mockGroupMembers(roles)
+
+			rr := serve(t, "admin", "PATCH", "/groups/1/members/member", role(planetscale.RoleAdmin))
+			if status := rr.Code; status != http.StatusForbidden {
+				t.Fatalf("expected status code %d, got %d", http.StatusForbidden, status)
+			} else if roles["member"] != planetscale.RoleMember {
+				t.Fatalf("expected role to be unchanged, got %s", roles["member"])
+			}
+		})
+
+		t.Run("members cannot change roles", func(t *testing.T) {
+			server.repos.GroupMember = mockGroupMembers(map[string]string{"member": planetscale.RoleMember, "viewer": planetscale.RoleViewer})
+
+			rr := serve(t, "member", "PATCH", "/groups/1/members/viewer", role(planetscale.RoleMember))
+			if status := rr.Code; status != http.StatusForbidden {
+				t.Fatalf("expected status code %d, got %d", http.StatusForbidden, status)
+			}
+		})
+
+		t.Run("ownership is not granted by role", func(t *testing.T) {
+			server.repos.GroupMember = mockGroupMembers(map[string]string{"owner": planetscale.RoleOwner, "admin": planetscale.RoleAdmin})
+
+			rr := serve(t, "owner", "PATCH", "/groups/1/members/admin", role(planetscale.RoleOwner))
+			if status := rr.Code; status != http.StatusBadRequest {
+				t.Fatalf("expected status code %d, got %d", http.StatusBadRequest, status)
+			}
+		})
+	})
+
+	t.Run("DELETE /groups/1/members/user-id", func(t *testing.T) {
+		t.Run("admin removes a member", func(t *testing.T) {
+			roles := map[string]string{"admin": planetscale.RoleAdmin, "member": planetscale.RoleMember}
+			server.repos.GroupMember = mockGroupMembers(roles)
+			server.services.GroupMember = mockGroupMemberService(roles, nil)
+
+			rr := serve(t, "admin", "DELETE", "/groups/1/members/member", nil)
+			if status := rr.Code; status != http.StatusNoContent {
+				t.Fatalf("expected status code %d, got %d", http.StatusNoContent, status)
+			} else if _, ok := roles["member"]; ok {
+				t.Fatal("expected member to be removed")
+			}
+		})
+
+		t.Run("member with a balance cannot leave", func(t *testing.T) {
+			roles := map[string]string{"member": planetscale.RoleMember}
+			server.repos.GroupMember = mockGroupMembers(roles)
+			server.services.GroupMember = mockGroupMemberService(roles, map[string]planetscale.Money{"member": -20_00})
+
+			rr := serve(t, "member", "DELETE", "/groups/1/members/member", nil)
+			if status := rr.Code; status != http.StatusConflict {
+				t.Fatalf("expected status code %d, got %d", http.StatusConflict, status)
+			} else if _, ok := roles["member"]; !ok {
+				t.Fatal("expected member to stay")
+			}
+		})
+
+		t.Run("admin forces removal of a member with a balance", func(t *testing.T) {
+			roles := map[string]string{"admin": planetscale.RoleAdmin, "member": planetscale.RoleMember}
+			server.repos.GroupMember = mockGroupMembers(roles)
+			server.services.GroupMember = mockGroupMemberService(roles, map[string]planetscale.Money{"member": -20_00})
+
+			rr := serve(t, "admin", "DELETE", "/groups/1/members/member?force=true", nil)
+			if status := rr.Code; status != http.StatusNoContent {
+				t.Fatalf("expected status code %d, got %d: %s", http.StatusNoContent, status, rr.Body)
+			} else if _, ok := roles["member"]; ok {
+				t.Fatal("expected member to be removed")
+			}
+		})
+
+		t.Run("invalid force", func(t *testing.T) {
+			rr := serve(t, "admin", "DELETE", "/groups/1/members/member?force=maybe", nil)
+			if status := rr.Code; status != http.StatusBadRequest {
+				t.Fatalf("expected status code %d, got %d", http.StatusBadRequest, status)
+			}
+		})
+	})
+
+	t.Run("POST /groups/1/transfer_ownership", func(t *testing.T) {
+		t.Run("successful transfer", func(t *testing.T) {
+			roles := map[string]string{"owner": planetscale.RoleOwner, "member": planetscale.RoleMember}
+			server.repos.GroupMember = mockGroupMembers(roles)
+
+			rr := serve(t, "owner", "POST", "/groups/1/transfer_ownership", planetscale.TransferOwnership{UserID: "member"})
+			if status := rr.Code; status != http.StatusOK {
+				t.Fatalf("expected status code %d, got %d: %s", http.StatusOK, status, rr.Body)
+			} else if roles["member"] != planetscale.RoleOwner || roles["owner"] != planetscale.RoleAdmin {
+				t.Fatalf("expected roles to be swapped, got %v", roles)
+			}
+		})
+
+		t.Run("admins cannot transfer", func(t *testing.T) {
+			roles := map[string]string{"owner": planetscale.RoleOwner, "admin": planetscale.RoleAdmin}
+			server.repos.GroupMember = mockGroupMembers(roles)
+
+			rr := serve(t, "admin", "POST", "/groups/1/transfer_ownership", planetscale.TransferOwnership{UserID: "admin"})
+			if status := rr.Code; status != http.StatusForbidden {
+				t.Fatalf("expected status code %d, got %d", http.StatusForbidden, status)
+			} else if roles["owner"] != planetscale.RoleOwner {
+				t.Fatalf("expected the owner to be unchanged, got %v", roles)
+			}
+		})
+
+		t.Run("new owner has to be a member", func(t *testing.T) {
+			server.repos.GroupMember = mockGroupMembers(map[string]string{"owner": planetscale.RoleOwner})
+
+			rr := serve(t, "owner", "POST", "/groups/1/transfer_ownership", planetscale.TransferOwnership{UserID: "stranger"})
+			if status := rr.Code; status != http.StatusNotFound {
+				t.Fatalf("expected status code %d, got %d", http.StatusNotFound, status)
+			}
+		})
+	})
+}
@@ -5,6 +5,9 @@ import (
 	"io"
 	"log/slog"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	planetscale "github.com/harshav17/planet_scale"
 )
@@ -66,7 +69,7 @@ type ErrorResponse struct {
 
 // LogError logs an error with the HTTP route information.
 func LogError(r *http.Request, err error) {
-	slog.Error("[http] error: %s %s: %s", r.Method, r.URL.Path, err)
+	slog.Error("[http] error", slog.String("method", r.Method), slog.String("path", r.URL.Path), slog.Any("err", err))
 }
 
 func MustBeContentType(r *http.Request, contentType ContentType) error {
@@ -94,6 +97,7 @@ var codes = map[string]int{
 	planetscale.ENOTFOUND:       http.StatusNotFound,
 	planetscale.ENOTIMPLEMENTED: http.StatusNotImplemented,
 	planetscale.EUNAUTHORIZED:   http.StatusUnauthorized,
+	planetscale.EFORBIDDEN:      http.StatusForbidden,
 	planetscale.EINTERNAL:       http.StatusInternalServerError,
 }
 
@@ -104,3 +108,86 @@ func ErrorStatusCode(code string) int {
 	}
 	return http.StatusInternalServerError
 }
+
+// listParams holds the paging and filtering query parameters shared by list
+// endpoints.
+type listParams struct {
+	Limit     int
+	Cursor    string
+	Sort      string
+	PaidBy    []string
+	From      *time.Time
+	To        *time.Time
+	MinAmount *planetscale.Money
+	MaxAmount *planetscale.Money
+}
+
+// parseListParams reads limit, cursor, sort, paid_by, from, to, min_amount and
+// max_amount from the query string. from and to take RFC 3339 timestamps or
+// plain dates, paid_by may be repeated or comma separated.
+func parseListParams(r *http.Request) (listParams, error) {
+	query := r.URL.Query()
+	params := listParams{
+		Limit:  planetscale.DefaultPageSize,
+		Cursor: query.Get("cursor"),
+		Sort:   query.Get("sort"),
+	}
+
+	if s := query.Get("limit"); s != "" {
+		limit, err := strconv.Atoi(s)
+		if err != nil || limit < 1 || limit > planetscale.MaxPageSize {
+			return params, planetscale.Errorf(planetscale.EINVALID, "limit must be between 1 and %d", planetscale.MaxPageSize)
+		}
+		params.Limit = limit
+	}
+	if _, _, err := planetscale.ParseSort(params.Sort); err != nil {
+		return params, err
+	}
+
+	for _, v := range query["paid_by"] {
+		for _, userID := range strings.Split(v, ",") {
+			if userID != "" {
+				params.PaidBy = append(params.PaidBy, userID)
+			}
+		}
+	}
+
+	var err error
+	if params.From, err = parseTimeParam(query.Get("from"), "from"); err != nil {
+		return params, err
+	}
+	if params.To, err = parseTimeParam(query.Get("to"), "to"); err != nil {
+		return params, err
+	}
+	if params.MinAmount, err = parseMoneyParam(query.Get("min_amount")); err != nil {
+		return params, err
+	}
+	if params.MaxAmount, err = parseMoneyParam(query.Get("max_amount")); err != nil {
+		return params, err
+	}
+
+	return params, nil
+}
+
+func parseTimeParam(s string, name string) (*time.Time, error) {
+	if s == "" {
+		return nil, nil
+	}
+	for _, layout := range []string{time.RFC3339, time.DateOnly} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return &t, nil
+		}
+	}
+	return nil, planetscale.Errorf(planetscale.EINVALID, "invalid %s %q", name, s)
+}
+
+func parseMoneyParam(s string) (*planetscale.Money, error) {
+	if s == "" {
+		return nil, nil
+	}
+	m, err := planetscale.ParseMoney(s)
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
@@ -3,8 +3,12 @@ package http
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
+	"strconv"
 
+	"github.com/go-chi/chi/v5"
 	planetscale "github.com/harshav17/planet_scale"
 )
 
@@ -23,6 +27,12 @@ func NewItemController(repos *planetscale.RepoProvider, services *planetscale.Se
 }
 
 func (c *itemController) HandlePostItem(w http.ResponseWriter, r *http.Request) {
+	user, found := planetscale.UserFromContext(r.Context())
+	if !found {
+		Error(w, r, planetscale.Errorf(planetscale.ENOTFOUND, "user context not set"))
+		return
+	}
+
 	var item planetscale.Item
 	err := json.NewDecoder(r.Body).Decode(&item)
 	if err != nil {
@@ -30,15 +40,57 @@ func (c *itemController) HandlePostItem(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	createItemFunc := func(tx *sql.Tx) error {
-		err := c.repos.Item.Create(tx, &item)
+	err = c.services.Expense.CreateItems(r.Context(), item.ExpenseID, []*planetscale.Item{&item}, user.UserID)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(item); err != nil {
+		Error(w, r, err)
+		return
+	}
+}
+
+type findItemsResponse struct {
+	Items []*planetscale.Item `json:"items"`
+	N     int                 `json:"n"`
+}
+
+// HandleGetExpenseItems handles the GET /expenses/{expenseID}/items endpoint.
+func (c *itemController) HandleGetExpenseItems(w http.ResponseWriter, r *http.Request) {
+	user, found := planetscale.UserFromContext(r.Context())
+	if !found {
+		Error(w, r, planetscale.Errorf(planetscale.ENOTFOUND, "user context not set"))
+		return
+	}
+
+	expense32, err := strconv.Atoi(chi.URLParam(r, "expenseID"))
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+	expenseID := int64(expense32)
+
+	var items []*planetscale.Item
+	getItemsFunc := func(tx *sql.Tx) error {
+		_, _, err := planetscale.GetMemberExpense(tx, c.repos, expenseID, user.UserID, planetscale.PermissionView)
 		if err != nil {
 			return err
 		}
 
-		for _, split := range item.Splits {
-			split.ItemID = item.ItemID
-			err = c.repos.ItemSplitNu.Create(tx, split)
+		items, err = c.repos.Item.Find(tx, planetscale.ItemFilter{
+			ExpenseID: expenseID,
+		})
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			item.Splits, err = c.repos.ItemSplit.Find(tx, planetscale.ItemSplitFilter{
+				ItemID: item.ItemID,
+			})
 			if err != nil {
 				return err
 			}
@@ -46,11 +98,391 @@ func (c *itemController) HandlePostItem(w http.ResponseWriter, r *http.Request)
 		return nil
 	}
 
-	err = c.tm.ExecuteInTx(r.Context(), createItemFunc)
+	err = c.tm.ExecuteInTx(r.Context(), getItemsFunc)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(findItemsResponse{
+		Items: items,
+		N:     len(items),
+	}); err != nil {
+		Error(w, r, err)
+		return
+	}
+}
+
+// HandleGetItem handles the GET /items/{itemID} endpoint.
+func (c *itemController) HandleGetItem(w http.ResponseWriter, r *http.Request) {
+	user, found := planetscale.UserFromContext(r.Context())
+	if !found {
+		Error(w, r, planetscale.Errorf(planetscale.ENOTFOUND, "user context not set"))
+		return
+	}
+
+	item32, err := strconv.Atoi(chi.URLParam(r, "itemID"))
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+	itemID := int64(item32)
+
+	var item *planetscale.Item
+	getItemFunc := func(tx *sql.Tx) error {
+		item, _, err = c.getItem(tx, itemID, user.UserID, planetscale.PermissionView)
+		if err != nil {
+			return err
+		}
+
+		item.Splits, err = c.repos.ItemSplit.Find(tx, planetscale.ItemSplitFilter{
+			ItemID: itemID,
+		})
+		return err
+	}
+
+	err = c.tm.ExecuteInTx(r.Context(), getItemFunc)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(item); err != nil {
+		Error(w, r, err)
+		return
+	}
+}
+
+// HandlePatchItem handles the PATCH /items/{itemID} endpoint.
+func (c *itemController) HandlePatchItem(w http.ResponseWriter, r *http.Request) {
+	user, found := planetscale.UserFromContext(r.Context())
+	if !found {
+		Error(w, r, planetscale.Errorf(planetscale.ENOTFOUND, "user context not set"))
+		return
+	}
+
+	item32, err := strconv.Atoi(chi.URLParam(r, "itemID"))
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+	itemID := int64(item32)
+
+	var update planetscale.ItemUpdate
+	err = ReceiveJson(w, r, &update)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+	item, err := c.services.Expense.UpdateItem(r.Context(), itemID, &update, user.UserID)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(item); err != nil {
+		Error(w, r, err)
+		return
+	}
+}
+
+// HandleDeleteItem handles the DELETE /items/{itemID} endpoint. The splits of
+// the item are deleted along with it.
+func (c *itemController) HandleDeleteItem(w http.ResponseWriter, r *http.Request) {
+	user, found := planetscale.UserFromContext(r.Context())
+	if !found {
+		Error(w, r, planetscale.Errorf(planetscale.ENOTFOUND, "user context not set"))
+		return
+	}
+
+	item32, err := strconv.Atoi(chi.URLParam(r, "itemID"))
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+	itemID := int64(item32)
+
+	err = c.services.Expense.DeleteItem(r.Context(), itemID, user.UserID)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type findItemSplitsResponse struct {
+	ItemSplits []*planetscale.ItemSplit `json:"item_splits"`
+	N          int                      `json:"n"`
+}
+
+// HandleGetItemSplits handles the GET /items/{itemID}/splits endpoint.
+func (c *itemController) HandleGetItemSplits(w http.ResponseWriter, r *http.Request) {
+	user, found := planetscale.UserFromContext(r.Context())
+	if !found {
+		Error(w, r, planetscale.Errorf(planetscale.ENOTFOUND, "user context not set"))
+		return
+	}
+
+	item32, err := strconv.Atoi(chi.URLParam(r, "itemID"))
 	if err != nil {
 		Error(w, r, err)
 		return
 	}
+	itemID := int64(item32)
+
+	var splits []*planetscale.ItemSplit
+	getSplitsFunc := func(tx *sql.Tx) error {
+		_, _, err := c.getItem(tx, itemID, user.UserID, planetscale.PermissionView)
+		if err != nil {
+			return err
+		}
+
+		splits, err = c.repos.ItemSplit.Find(tx, planetscale.ItemSplitFilter{
+			ItemID: itemID,
+		})
+		return err
+	}
 
+	err = c.tm.ExecuteInTx(r.Context(), getSplitsFunc)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(findItemSplitsResponse{
+		ItemSplits: splits,
+		N:          len(splits),
+	}); err != nil {
+		Error(w, r, err)
+		return
+	}
+}
+
+// HandlePostItemSplit handles the POST /items/{itemID}/splits endpoint.
+func (c *itemController) HandlePostItemSplit(w http.ResponseWriter, r *http.Request) {
+	user, found := planetscale.UserFromContext(r.Context())
+	if !found {
+		Error(w, r, planetscale.Errorf(planetscale.ENOTFOUND, "user context not set"))
+		return
+	}
+
+	item32, err := strconv.Atoi(chi.URLParam(r, "itemID"))
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+	itemID := int64(item32)
+
+	var split planetscale.ItemSplit
+	err = ReceiveJson(w, r, &split)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+	err = c.services.Expense.CreateItemSplit(r.Context(), itemID, &split, user.UserID)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(split); err != nil {
+		Error(w, r, err)
+		return
+	}
+}
+
+// HandlePatchItemSplit handles the PATCH /items/{itemID}/splits/{itemSplitID}
+// endpoint.
+func (c *itemController) HandlePatchItemSplit(w http.ResponseWriter, r *http.Request) {
+	user, found := planetscale.UserFromContext(r.Context())
+	if !found {
+		Error(w, r, planetscale.Errorf(planetscale.ENOTFOUND, "user context not set"))
+		return
+	}
+
+	item32, err := strconv.Atoi(chi.URLParam(r, "itemID"))
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+	itemID := int64(item32)
+
+	itemSplit32, err := strconv.Atoi(chi.URLParam(r, "itemSplitID"))
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+	itemSplitID := int64(itemSplit32)
+
+	var update planetscale.ItemSplitUpdate
+	err = ReceiveJson(w, r, &update)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+	split, err := c.services.Expense.UpdateItemSplit(r.Context(), itemID, itemSplitID, &update, user.UserID)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(split); err != nil {
+		Error(w, r, err)
+		return
+	}
+}
+
+// HandleDeleteItemSplit handles the DELETE /items/{itemID}/splits/{itemSplitID}
+// endpoint.
+func (c *itemController) HandleDeleteItemSplit(w http.ResponseWriter, r *http.Request) {
+	user, found := planetscale.UserFromContext(r.Context())
+	if !found {
+		Error(w, r, planetscale.Errorf(planetscale.ENOTFOUND, "user context not set"))
+		return
+	}
+
+	item32, err := strconv.Atoi(chi.URLParam(r, "itemID"))
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+	itemID := int64(item32)
+
+	itemSplit32, err := strconv.Atoi(chi.URLParam(r, "itemSplitID"))
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+	itemSplitID := int64(itemSplit32)
+
+	err = c.services.Expense.DeleteItemSplit(r.Context(), itemID, itemSplitID, user.UserID)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleClaimItemSplit handles the POST /item_splits/{itemSplitID}/claim
+// endpoint, which hands a guest split over to the current user.
+func (c *itemController) HandleClaimItemSplit(w http.ResponseWriter, r *http.Request) {
+	user, found := planetscale.UserFromContext(r.Context())
+	if !found {
+		Error(w, r, planetscale.Errorf(planetscale.ENOTFOUND, "user context not set"))
+		return
+	}
+
+	itemSplit32, err := strconv.Atoi(chi.URLParam(r, "itemSplitID"))
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+	itemSplitID := int64(itemSplit32)
+
+	itemSplit, err := c.services.Expense.ClaimItemSplit(r.Context(), itemSplitID, user.UserID)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(itemSplit); err != nil {
+		Error(w, r, err)
+		return
+	}
+}
+
+// HandlePostReceipt handles the POST /expenses/{expenseID}/receipt endpoint.
+// The body is the plain text of the receipt and the response is the draft
+// itemization read from it. With ?confirm=true the items are also created.
+func (c *itemController) HandlePostReceipt(w http.ResponseWriter, r *http.Request) {
+	user, found := planetscale.UserFromContext(r.Context())
+	if !found {
+		Error(w, r, planetscale.Errorf(planetscale.ENOTFOUND, "user context not set"))
+		return
+	}
+
+	expense32, err := strconv.Atoi(chi.URLParam(r, "expenseID"))
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+	expenseID := int64(expense32)
+
+	confirm := false
+	if s := r.URL.Query().Get("confirm"); s != "" {
+		confirm, err = strconv.ParseBool(s)
+		if err != nil {
+			Error(w, r, planetscale.Errorf(planetscale.EINVALID, "invalid confirm %q", s))
+			return
+		}
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, planetscale.MaxReceiptSize)
+	text, err := io.ReadAll(r.Body)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			Error(w, r, planetscale.Errorf(planetscale.EINVALID, "receipts cannot be larger than %d bytes", planetscale.MaxReceiptSize))
+			return
+		}
+		Error(w, r, err)
+		return
+	}
+
+	receipt, err := c.services.Receipt.Parse(string(text))
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	if confirm {
+		err = c.services.Expense.CreateItems(r.Context(), expenseID, receipt.Items, user.UserID)
+	} else {
+		// a draft is only shown to those who could confirm it
+		err = c.tm.ExecuteInTx(r.Context(), func(tx *sql.Tx) error {
+			_, _, err := planetscale.GetMemberExpense(tx, c.repos, expenseID, user.UserID, planetscale.PermissionEdit)
+			return err
+		})
+	}
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	statusCode := http.StatusOK
+	if confirm {
+		statusCode = http.StatusCreated
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	if err := json.NewEncoder(w).Encode(receipt); err != nil {
+		Error(w, r, err)
+		return
+	}
+}
+
+// getItem loads an item along with its expense, which has to belong to a group
+// where the user's role grants permission.
+func (c *itemController) getItem(tx *sql.Tx, itemID int64, userID string, permission planetscale.Permission) (*planetscale.Item, *planetscale.Expense, error) {
+	item, err := c.repos.Item.Get(tx, itemID)
+	if err != nil {
+		return nil, nil, planetscale.Errorf(planetscale.ENOTFOUND, "no item found with ID %d", itemID)
+	}
+
+	expense, _, err := planetscale.GetMemberExpense(tx, c.repos, item.ExpenseID, userID, permission)
+	if err != nil {
+		return nil, nil, err
+	}
+	return item, expense, nil
 }
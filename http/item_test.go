@@ -2,48 +2,64 @@ package http
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	planetscale "github.com/harshav17/planet_scale"
 	db_mock "github.com/harshav17/planet_scale/mock/db"
+	service_mock "github.com/harshav17/planet_scale/mock/service"
 )
 
 func TestHnadleItem_All(t *testing.T) {
 	server := MustOpenServer(t)
 	defer MustCloseServer(t, server.Server)
 
+	groupID := int64(1)
+	server.repos.Expense = &db_mock.ExpenseRepo{
+		GetFn: func(tx *sql.Tx, expenseID int64) (*planetscale.Expense, error) {
+			return &planetscale.Expense{ExpenseID: expenseID, GroupID: &groupID, SplitTypeID: planetscale.SplitTypeItemBased}, nil
+		},
+	}
+	server.repos.GroupMember = &db_mock.GroupMemberRepo{
+		GetFn: func(tx *sql.Tx, groupID int64, userID string) (*planetscale.GroupMember, error) {
+			if userID != "test-user-id" && userID != "test-user-id-2" {
+				return nil, planetscale.Errorf(planetscale.ENOTFOUND, "not a member")
+			}
+			return &planetscale.GroupMember{GroupID: groupID, UserID: userID, Role: planetscale.RoleMember}, nil
+		},
+	}
+
 	t.Run("POST /items", func(t *testing.T) {
 		t.Run("successful create", func(t *testing.T) {
 			userID := "test-user-id"
 			userID2 := "test-user-id-2"
 			item := &planetscale.Item{
 				Name:      "test-item",
-				Price:     10.0,
+				Price:     10_00,
 				Quantity:  1,
 				ExpenseID: 1,
-				Splits: []*planetscale.ItemSplitNU{
+				Splits: []*planetscale.ItemSplit{
 					{
 						UserID: &userID,
-						Amount: 10.0,
+						Amount: 10_00,
 					},
 					{
 						UserID: &userID2,
-						Amount: 0.0,
+						Amount: 0,
 					},
 				},
 			}
-			server.repos.Item = &db_mock.ItemRepo{
-				CreateFn: func(tx *sql.Tx, item *planetscale.Item) error {
-					item.ItemID = 1
-					return nil
-				},
-			}
-			server.repos.ItemSplitNu = &db_mock.ItemSplitNURepo{
-				CreateFn: func(tx *sql.Tx, split *planetscale.ItemSplitNU) error {
+			server.services.Expense = &service_mock.ExpenseService{
+				CreateItemsFn: func(ctx context.Context, expenseID int64, items []*planetscale.Item, actorID string) error {
+					if expenseID != 1 || len(items) != 1 || len(items[0].Splits) != 2 || actorID != userID {
+						t.Errorf("unexpected items %+v of expense %d by %s", items, expenseID, actorID)
+					}
+					items[0].ItemID = 1
 					return nil
 				},
 			}
@@ -69,6 +85,350 @@ func TestHnadleItem_All(t *testing.T) {
 			if status := rr.Code; status != http.StatusCreated {
 				t.Fatalf("expected status code %d, got %d", http.StatusCreated, status)
 			}
+			var got planetscale.Item
+			if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			} else if got.ItemID != 1 {
+				t.Fatalf("expected item id 1, got %d", got.ItemID)
+			}
+		})
+	})
+
+	t.Run("POST /item_splits/:id/claim", func(t *testing.T) {
+		t.Run("successful claim", func(t *testing.T) {
+			server.services.Expense = &service_mock.ExpenseService{
+				ClaimItemSplitFn: func(ctx context.Context, itemSplitID int64, userID string) (*planetscale.ItemSplit, error) {
+					return &planetscale.ItemSplit{ItemSplitID: itemSplitID, ItemID: 1, UserID: &userID}, nil
+				},
+			}
+
+			token := server.buildJWTForTesting(t, "test-user-id")
+			req, err := http.NewRequest("POST", "/item_splits/2/claim", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(server.router.ServeHTTP)
+			handler.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != http.StatusOK {
+				t.Fatalf("expected status code %d, got %d", http.StatusOK, status)
+			}
+			var got planetscale.ItemSplit
+			if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			} else if got.ItemSplitID != 2 || got.UserID == nil || *got.UserID != "test-user-id" {
+				t.Fatalf("unexpected item split %+v", got)
+			}
+		})
+
+		t.Run("already claimed", func(t *testing.T) {
+			server.services.Expense = &service_mock.ExpenseService{
+				ClaimItemSplitFn: func(ctx context.Context, itemSplitID int64, userID string) (*planetscale.ItemSplit, error) {
+					return nil, planetscale.Errorf(planetscale.ECONFLICT, "item split %d is already claimed", itemSplitID)
+				},
+			}
+
+			token := server.buildJWTForTesting(t, "test-user-id")
+			req, err := http.NewRequest("POST", "/item_splits/2/claim", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(server.router.ServeHTTP)
+			handler.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != http.StatusConflict {
+				t.Fatalf("expected status code %d, got %d", http.StatusConflict, status)
+			}
+		})
+	})
+	server.repos.Item = &db_mock.ItemRepo{
+		GetFn: func(tx *sql.Tx, itemID int64) (*planetscale.Item, error) {
+			return &planetscale.Item{ItemID: itemID, Name: "test-item", Price: 10_00, Quantity: 1, ExpenseID: 1}, nil
+		},
+		FindFn: func(tx *sql.Tx, filter planetscale.ItemFilter) ([]*planetscale.Item, error) {
+			return []*planetscale.Item{{ItemID: 1, Name: "test-item", Price: 10_00, Quantity: 1, ExpenseID: filter.ExpenseID}}, nil
+		},
+		UpdateFn: func(tx *sql.Tx, itemID int64, update *planetscale.ItemUpdate) (*planetscale.Item, error) {
+			return &planetscale.Item{ItemID: itemID, Name: *update.Name, Price: 10_00, Quantity: 1, ExpenseID: 1}, nil
+		},
+		DeleteFn: func(tx *sql.Tx, itemID int64) error {
+			return nil
+		},
+	}
+	var deletedSplits []int64
+	server.repos.ItemSplit = &db_mock.ItemSplitRepo{
+		GetFn: func(tx *sql.Tx, itemSplitID int64) (*planetscale.ItemSplit, error) {
+			return &planetscale.ItemSplit{ItemSplitID: itemSplitID, ItemID: 1, Amount: 5_00}, nil
+		},
+		FindFn: func(tx *sql.Tx, filter planetscale.ItemSplitFilter) ([]*planetscale.ItemSplit, error) {
+			userID := "test-user-id"
+			return []*planetscale.ItemSplit{{ItemSplitID: 1, ItemID: filter.ItemID, UserID: &userID}}, nil
+		},
+		CreateFn: func(tx *sql.Tx, split *planetscale.ItemSplit) error {
+			split.ItemSplitID = 3
+			return nil
+		},
+		DeleteFn: func(tx *sql.Tx, itemSplitID int64) error {
+			deletedSplits = append(deletedSplits, itemSplitID)
+			return nil
+		},
+	}
+
+	serve := func(t *testing.T, userID, method, url string, body any) *httptest.ResponseRecorder {
+		t.Helper()
+		var reader *bytes.Reader
+		if body != nil {
+			b, err := json.Marshal(body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			reader = bytes.NewReader(b)
+		} else {
+			reader = bytes.NewReader(nil)
+		}
+		req, err := http.NewRequest(method, url, reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+server.buildJWTForTesting(t, userID))
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(server.router.ServeHTTP)
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("GET /expenses/:id/items", func(t *testing.T) {
+		rr := serve(t, "test-user-id", "GET", "/expenses/1/items", nil)
+		if status := rr.Code; status != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, status)
+		}
+
+		var got findItemsResponse
+		if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+			t.Fatal(err)
+		} else if got.N != 1 || len(got.Items[0].Splits) != 1 {
+			t.Fatalf("expected 1 item with its split, got %+v", got)
+		}
+	})
+
+	t.Run("non members cannot see items", func(t *testing.T) {
+		for _, url := range []string{"/expenses/1/items", "/items/1", "/items/1/splits"} {
+			rr := serve(t, "test-user-id-3", "GET", url, nil)
+			if status := rr.Code; status != http.StatusNotFound {
+				t.Fatalf("%s: expected status code %d, got %d", url, http.StatusNotFound, status)
+			}
+		}
+	})
+
+	t.Run("PATCH /items/:id", func(t *testing.T) {
+		server.services.Expense = &service_mock.ExpenseService{
+			UpdateItemFn: func(ctx context.Context, itemID int64, update *planetscale.ItemUpdate, userID string) (*planetscale.Item, error) {
+				return &planetscale.Item{ItemID: itemID, Name: *update.Name, Price: 10_00, Quantity: 1, ExpenseID: 1}, nil
+			},
+		}
+
+		name := "renamed"
+		rr := serve(t, "test-user-id", "PATCH", "/items/1", planetscale.ItemUpdate{Name: &name})
+		if status := rr.Code; status != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, status)
+		}
+
+		var got planetscale.Item
+		if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+			t.Fatal(err)
+		} else if got.Name != name {
+			t.Fatalf("expected name %s, got %s", name, got.Name)
+		}
+	})
+
+	t.Run("DELETE /items/:id", func(t *testing.T) {
+		var deleted []int64
+		server.services.Expense = &service_mock.ExpenseService{
+			DeleteItemFn: func(ctx context.Context, itemID int64, userID string) error {
+				deleted = append(deleted, itemID)
+				return nil
+			},
+		}
+
+		rr := serve(t, "test-user-id", "DELETE", "/items/1", nil)
+		if status := rr.Code; status != http.StatusNoContent {
+			t.Fatalf("expected status code %d, got %d", http.StatusNoContent, status)
+		} else if len(deleted) != 1 || deleted[0] != 1 {
+			t.Fatalf("expected item 1 to be deleted, got %v", deleted)
+		}
+	})
+
+	t.Run("POST /items/:id/splits", func(t *testing.T) {
+		t.Run("guest split", func(t *testing.T) {
+			server.services.Expense = &service_mock.ExpenseService{
+				CreateItemSplitFn: func(ctx context.Context, itemID int64, split *planetscale.ItemSplit, userID string) error {
+					split.ItemID = itemID
+					split.ItemSplitID = 3
+					return nil
+				},
+			}
+
+			guest := "JD"
+			rr := serve(t, "test-user-id", "POST", "/items/1/splits", planetscale.ItemSplit{GuestName: &guest})
+			if status := rr.Code; status != http.StatusCreated {
+				t.Fatalf("expected status code %d, got %d", http.StatusCreated, status)
+			}
+			var got planetscale.ItemSplit
+			if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			} else if got.ItemSplitID != 3 || got.ItemID != 1 {
+				t.Fatalf("unexpected item split %+v", got)
+			}
+		})
+
+		t.Run("items no longer reconcile", func(t *testing.T) {
+			server.services.Expense = &service_mock.ExpenseService{
+				CreateItemSplitFn: func(ctx context.Context, itemID int64, split *planetscale.ItemSplit, userID string) error {
+					return planetscale.Errorf(planetscale.EINVALID, "items and adjustments add up to 10.00, expected 20.00")
+				},
+			}
+
+			userID := "test-user-id-2"
+			rr := serve(t, "test-user-id", "POST", "/items/1/splits", planetscale.ItemSplit{UserID: &userID})
+			if status := rr.Code; status != http.StatusBadRequest {
+				t.Fatalf("expected status code %d, got %d", http.StatusBadRequest, status)
+			}
+		})
+	})
+
+	t.Run("PATCH /items/:id/splits/:id", func(t *testing.T) {
+		server.services.Expense = &service_mock.ExpenseService{
+			UpdateItemSplitFn: func(ctx context.Context, itemID int64, itemSplitID int64, update *planetscale.ItemSplitUpdate, userID string) (*planetscale.ItemSplit, error) {
+				return &planetscale.ItemSplit{ItemSplitID: itemSplitID, ItemID: itemID, Amount: *update.Amount}, nil
+			},
+		}
+
+		amount := planetscale.Money(4_00)
+		rr := serve(t, "test-user-id", "PATCH", "/items/1/splits/2", planetscale.ItemSplitUpdate{Amount: &amount})
+		if status := rr.Code; status != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, status)
+		}
+		var got planetscale.ItemSplit
+		if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+			t.Fatal(err)
+		} else if got.ItemSplitID != 2 || got.Amount != amount {
+			t.Fatalf("unexpected item split %+v", got)
+		}
+	})
+
+	t.Run("DELETE /items/:id/splits/:id", func(t *testing.T) {
+		var deleted [][2]int64
+		server.services.Expense = &service_mock.ExpenseService{
+			DeleteItemSplitFn: func(ctx context.Context, itemID int64, itemSplitID int64, userID string) error {
+				deleted = append(deleted, [2]int64{itemID, itemSplitID})
+				return nil
+			},
+		}
+
+		rr := serve(t, "test-user-id", "DELETE", "/items/1/splits/2", nil)
+		if status := rr.Code; status != http.StatusNoContent {
+			t.Fatalf("expected status code %d, got %d", http.StatusNoContent, status)
+		} else if len(deleted) != 1 || deleted[0] != [2]int64{1, 2} {
+			t.Fatalf("expected split 2 of item 1 to be deleted, got %v", deleted)
+		}
+	})
+
+	t.Run("POST /expenses/:id/receipt", func(t *testing.T) {
+		server.services.Receipt = &service_mock.ReceiptParser{
+			ParseFn: func(text string) (*planetscale.Receipt, error) {
+				if text == "" {
+					return nil, planetscale.Errorf(planetscale.EINVALID, "no items found on receipt")
+				}
+				return &planetscale.Receipt{
+					Items: []*planetscale.Item{
+						{Name: "Beer", Price: 6_00, Quantity: 2},
+						{Name: "Burger", Price: 14_50, Quantity: 1},
+					},
+					Subtotal: 26_50,
+					Total:    26_50,
+				}, nil
+			},
+		}
+		var created []*planetscale.Item
+		server.services.Expense = &service_mock.ExpenseService{
+			CreateItemsFn: func(ctx context.Context, expenseID int64, items []*planetscale.Item, userID string) error {
+				for _, item := range items {
+					item.ExpenseID = expenseID
+					created = append(created, item)
+				}
+				return nil
+			},
+		}
+
+		postReceipt := func(t *testing.T, userID, url, text string) *httptest.ResponseRecorder {
+			t.Helper()
+			req, err := http.NewRequest("POST", url, strings.NewReader(text))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Content-Type", "text/plain")
+			req.Header.Set("Authorization", "Bearer "+server.buildJWTForTesting(t, userID))
+
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(server.router.ServeHTTP)
+			handler.ServeHTTP(rr, req)
+			return rr
+		}
+
+		t.Run("draft", func(t *testing.T) {
+			created = nil
+			rr := postReceipt(t, "test-user-id", "/expenses/1/receipt", "2 x Beer 12.00\nBurger 14.50")
+			if status := rr.Code; status != http.StatusOK {
+				t.Fatalf("expected status code %d, got %d", http.StatusOK, status)
+			} else if len(created) != 0 {
+				t.Fatalf("expected no items to be created, got %d", len(created))
+			}
+
+			var got planetscale.Receipt
+			if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			} else if len(got.Items) != 2 || got.Total != 26_50 {
+				t.Fatalf("unexpected receipt %+v", got)
+			}
+		})
+
+		t.Run("confirm", func(t *testing.T) {
+			created = nil
+			rr := postReceipt(t, "test-user-id", "/expenses/1/receipt?confirm=true", "2 x Beer 12.00\nBurger 14.50")
+			if status := rr.Code; status != http.StatusCreated {
+				t.Fatalf("expected status code %d, got %d", http.StatusCreated, status)
+			} else if len(created) != 2 || created[0].ExpenseID != 1 {
+				t.Fatalf("expected 2 items created on expense 1, got %+v", created)
+			}
+		})
+
+		t.Run("no items", func(t *testing.T) {
+			rr := postReceipt(t, "test-user-id", "/expenses/1/receipt", "")
+			if status := rr.Code; status != http.StatusBadRequest {
+				t.Fatalf("expected status code %d, got %d", http.StatusBadRequest, status)
+			}
+		})
+
+		t.Run("non members", func(t *testing.T) {
+			rr := postReceipt(t, "test-user-id-3", "/expenses/1/receipt", "Burger 14.50")
+			if status := rr.Code; status != http.StatusNotFound {
+				t.Fatalf("expected status code %d, got %d", http.StatusNotFound, status)
+			}
+		})
+
+		t.Run("too large", func(t *testing.T) {
+			rr := postReceipt(t, "test-user-id", "/expenses/1/receipt", strings.Repeat("Burger 14.50\n", planetscale.MaxReceiptSize/10))
+			if status := rr.Code; status != http.StatusBadRequest {
+				t.Fatalf("expected status code %d, got %d", http.StatusBadRequest, status)
+			}
 		})
 	})
 }
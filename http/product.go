@@ -125,7 +125,7 @@ func handlePostProductHTML(r *http.Request) (*planetscale.Product, error) {
 	product := &planetscale.Product{}
 	r.ParseForm()
 	product.Name = r.FormValue("name")
-	price, err := strconv.ParseFloat(r.FormValue("price"), 64)
+	price, err := planetscale.ParseMoney(r.FormValue("price"))
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,368 @@
+package http
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	planetscale "github.com/harshav17/planet_scale"
+)
+
+type recurringExpenseController struct {
+	repos    *planetscale.RepoProvider
+	services *planetscale.ServiceProvider
+	tm       planetscale.TransactionManager
+}
+
+func NewRecurringExpenseController(repos *planetscale.RepoProvider, services *planetscale.ServiceProvider, tm planetscale.TransactionManager) *recurringExpenseController {
+	return &recurringExpenseController{
+		repos:    repos,
+		services: services,
+		tm:       tm,
+	}
+}
+
+// HandleGetRecurringExpenses handles the GET /groups/{groupID}/recurring endpoint.
+func (c *recurringExpenseController) HandleGetRecurringExpenses(w http.ResponseWriter, r *http.Request) {
+	user, found := planetscale.UserFromContext(r.Context())
+	if !found {
+		Error(w, r, planetscale.Errorf(planetscale.ENOTFOUND, "user context not set"))
+		return
+	}
+
+	group32, err := strconv.Atoi(chi.URLParam(r, "groupID"))
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+	groupID := int64(group32)
+
+	var recurringExpenses []*planetscale.RecurringExpense
+	getRecurringExpensesFunc := func(tx *sql.Tx) error {
+		_, err = planetscale.CheckPermission(tx, c.repos, groupID, user.UserID, planetscale.PermissionView)
+		if err != nil {
+			return err
+		}
+
+		recurringExpenses, err = c.repos.RecurringExpense.Find(tx, planetscale.RecurringExpenseFilter{
+			GroupID: groupID,
+		})
+		if err != nil {
+			return err
+		}
+		return nil
+	}
+
+	err = c.tm.ExecuteInTx(r.Context(), getRecurringExpensesFunc)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(findRecurringExpensesResponse{
+		RecurringExpenses: recurringExpenses,
+		N:                 len(recurringExpenses),
+	}); err != nil {
+		Error(w, r, err)
+		return
+	}
+}
+
+type findRecurringExpensesResponse struct {
+	RecurringExpenses []*planetscale.RecurringExpense `json:"recurring_expenses"`
+	N                 int                             `json:"n"`
+}
+
+// HandlePostRecurringExpense handles the POST /groups/{groupID}/recurring endpoint.
+func (c *recurringExpenseController) HandlePostRecurringExpense(w http.ResponseWriter, r *http.Request) {
+	user, found := planetscale.UserFromContext(r.Context())
+	if !found {
+		Error(w, r, planetscale.Errorf(planetscale.ENOTFOUND, "user context not set"))
+		return
+	}
+
+	group32, err := strconv.Atoi(chi.URLParam(r, "groupID"))
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+	groupID := int64(group32)
+
+	var recurringExpense planetscale.RecurringExpense
+	err = ReceiveJson(w, r, &recurringExpense)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	rule, err := planetscale.ParseRecurrenceRule(recurringExpense.Rule)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+	if recurringExpense.Amount <= 0 {
+		Error(w, r, planetscale.Errorf(planetscale.EINVALID, "expense amount must be greater than zero"))
+		return
+	}
+	if recurringExpense.StartAt.IsZero() {
+		recurringExpense.StartAt = time.Now().UTC().Truncate(time.Second)
+	}
+	next, ok := rule.Occurrence(recurringExpense.StartAt, 0)
+	if !ok {
+		Error(w, r, planetscale.Errorf(planetscale.EINVALID, "recurrence rule %q has no occurrences", recurringExpense.Rule))
+		return
+	}
+
+	recurringExpense.GroupID = groupID
+	if recurringExpense.SplitTypeID == 0 {
+		recurringExpense.SplitTypeID = planetscale.SplitTypeEqual
+	}
+	if recurringExpense.PaidBy == "" {
+		recurringExpense.PaidBy = user.UserID
+	}
+	recurringExpense.NextOccurrenceAt = next
+	recurringExpense.OccurrenceCount = 0
+	recurringExpense.CreatedBy = user.UserID
+	recurringExpense.UpdatedBy = user.UserID
+
+	var audit *planetscale.AuditEvent
+	createRecurringExpenseFunc := func(tx *sql.Tx) error {
+		_, err = planetscale.CheckPermission(tx, c.repos, groupID, user.UserID, planetscale.PermissionEdit)
+		if err != nil {
+			return err
+		}
+
+		// validate paid by user is a member of the group
+		_, err = c.repos.GroupMember.Get(tx, groupID, recurringExpense.PaidBy)
+		if err != nil {
+			return planetscale.Errorf(planetscale.ENOTFOUND, "paid by user is not a member of this group")
+		}
+
+		err = c.repos.RecurringExpense.Create(tx, &recurringExpense)
+		if err != nil {
+			return err
+		}
+
+		audit = &planetscale.AuditEvent{
+			GroupID:    &groupID,
+			EntityType: planetscale.AuditEntityRecurringExpense,
+			EntityID:   strconv.FormatInt(recurringExpense.RecurringExpenseID, 10),
+			Action:     planetscale.AuditActionCreate,
+			ActorID:    user.UserID,
+			After:      recurringExpense,
+		}
+		return c.repos.AuditEvent.Create(tx, audit)
+	}
+
+	err = c.tm.ExecuteInTx(r.Context(), createRecurringExpenseFunc)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+	planetscale.Publish(c.services.Events, audit)
+
+	w.WriteHeader(http.StatusCreated)
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(recurringExpense); err != nil {
+		Error(w, r, err)
+		return
+	}
+}
+
+// HandleGetRecurringExpense handles the GET /groups/{groupID}/recurring/{recurringExpenseID} endpoint.
+func (c *recurringExpenseController) HandleGetRecurringExpense(w http.ResponseWriter, r *http.Request) {
+	user, found := planetscale.UserFromContext(r.Context())
+	if !found {
+		Error(w, r, planetscale.Errorf(planetscale.ENOTFOUND, "user context not set"))
+		return
+	}
+
+	groupID, recurringExpenseID, err := recurringExpenseURLParams(r)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	var recurringExpense *planetscale.RecurringExpense
+	getRecurringExpenseFunc := func(tx *sql.Tx) error {
+		recurringExpense, _, err = c.getGroupRecurringExpense(tx, groupID, recurringExpenseID, user.UserID, planetscale.PermissionView)
+		if err != nil {
+			return err
+		}
+		return nil
+	}
+
+	err = c.tm.ExecuteInTx(r.Context(), getRecurringExpenseFunc)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(recurringExpense); err != nil {
+		Error(w, r, err)
+		return
+	}
+}
+
+// HandlePatchRecurringExpense handles the PATCH /groups/{groupID}/recurring/{recurringExpenseID} endpoint.
+func (c *recurringExpenseController) HandlePatchRecurringExpense(w http.ResponseWriter, r *http.Request) {
+	user, found := planetscale.UserFromContext(r.Context())
+	if !found {
+		Error(w, r, planetscale.Errorf(planetscale.ENOTFOUND, "user context not set"))
+		return
+	}
+
+	groupID, recurringExpenseID, err := recurringExpenseURLParams(r)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	var update planetscale.RecurringExpenseUpdate
+	err = ReceiveJson(w, r, &update)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+	if update.Amount != nil && *update.Amount <= 0 {
+		Error(w, r, planetscale.Errorf(planetscale.EINVALID, "expense amount must be greater than zero"))
+		return
+	}
+	update.UpdatedBy = &user.UserID
+
+	var recurringExpense *planetscale.RecurringExpense
+	var audit *planetscale.AuditEvent
+	patchRecurringExpenseFunc := func(tx *sql.Tx) error {
+		before, member, err := c.getGroupRecurringExpense(tx, groupID, recurringExpenseID, user.UserID, planetscale.PermissionEdit)
+		if err != nil {
+			return err
+		}
+		err = planetscale.CheckAuthor(member, before.CreatedBy, before.PaidBy)
+		if err != nil {
+			return err
+		}
+
+		if update.PaidBy != nil {
+			_, err = c.repos.GroupMember.Get(tx, groupID, *update.PaidBy)
+			if err != nil {
+				return planetscale.Errorf(planetscale.ENOTFOUND, "paid by user is not a member of this group")
+			}
+		}
+
+		recurringExpense, err = c.repos.RecurringExpense.Update(tx, recurringExpenseID, &update)
+		if err != nil {
+			return err
+		}
+
+		audit = &planetscale.AuditEvent{
+			GroupID:    &groupID,
+			EntityType: planetscale.AuditEntityRecurringExpense,
+			EntityID:   strconv.FormatInt(recurringExpenseID, 10),
+			Action:     planetscale.AuditActionUpdate,
+			ActorID:    user.UserID,
+			Before:     before,
+			After:      recurringExpense,
+		}
+		return c.repos.AuditEvent.Create(tx, audit)
+	}
+
+	err = c.tm.ExecuteInTx(r.Context(), patchRecurringExpenseFunc)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+	planetscale.Publish(c.services.Events, audit)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(recurringExpense); err != nil {
+		Error(w, r, err)
+		return
+	}
+}
+
+// HandleDeleteRecurringExpense handles the DELETE /groups/{groupID}/recurring/{recurringExpenseID} endpoint.
+// Expenses that were already materialized are kept.
+func (c *recurringExpenseController) HandleDeleteRecurringExpense(w http.ResponseWriter, r *http.Request) {
+	user, found := planetscale.UserFromContext(r.Context())
+	if !found {
+		Error(w, r, planetscale.Errorf(planetscale.ENOTFOUND, "user context not set"))
+		return
+	}
+
+	groupID, recurringExpenseID, err := recurringExpenseURLParams(r)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	var audit *planetscale.AuditEvent
+	deleteRecurringExpenseFunc := func(tx *sql.Tx) error {
+		before, member, err := c.getGroupRecurringExpense(tx, groupID, recurringExpenseID, user.UserID, planetscale.PermissionEdit)
+		if err != nil {
+			return err
+		}
+		err = planetscale.CheckAuthor(member, before.CreatedBy, before.PaidBy)
+		if err != nil {
+			return err
+		}
+
+		err = c.repos.RecurringExpense.Delete(tx, recurringExpenseID)
+		if err != nil {
+			return err
+		}
+
+		audit = &planetscale.AuditEvent{
+			GroupID:    &groupID,
+			EntityType: planetscale.AuditEntityRecurringExpense,
+			EntityID:   strconv.FormatInt(recurringExpenseID, 10),
+			Action:     planetscale.AuditActionDelete,
+			ActorID:    user.UserID,
+			Before:     before,
+		}
+		return c.repos.AuditEvent.Create(tx, audit)
+	}
+
+	err = c.tm.ExecuteInTx(r.Context(), deleteRecurringExpenseFunc)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+	planetscale.Publish(c.services.Events, audit)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getGroupRecurringExpense loads a recurring expense after checking that it
+// belongs to the group and that userID's role in that group grants permission.
+func (c *recurringExpenseController) getGroupRecurringExpense(tx *sql.Tx, groupID, recurringExpenseID int64, userID string, permission planetscale.Permission) (*planetscale.RecurringExpense, *planetscale.GroupMember, error) {
+	member, err := planetscale.CheckPermission(tx, c.repos, groupID, userID, permission)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	recurringExpense, err := c.repos.RecurringExpense.Get(tx, recurringExpenseID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if recurringExpense.GroupID != groupID {
+		return nil, nil, planetscale.Errorf(planetscale.ENOTFOUND, "no recurring expense found with ID %d", recurringExpenseID)
+	}
+	return recurringExpense, member, nil
+}
+
+func recurringExpenseURLParams(r *http.Request) (int64, int64, error) {
+	group32, err := strconv.Atoi(chi.URLParam(r, "groupID"))
+	if err != nil {
+		return 0, 0, err
+	}
+	recurring32, err := strconv.Atoi(chi.URLParam(r, "recurringExpenseID"))
+	if err != nil {
+		return 0, 0, err
+	}
+	return int64(group32), int64(recurring32), nil
+}
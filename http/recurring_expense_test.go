@@ -0,0 +1,195 @@
+package http
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	planetscale "github.com/harshav17/planet_scale"
+	db_mock "github.com/harshav17/planet_scale/mock/db"
+)
+
+func TestHandleRecurringExpenses_All(t *testing.T) {
+	server := MustOpenServer(t)
+	defer MustCloseServer(t, server.Server)
+
+	memberRepo := &db_mock.GroupMemberRepo{
+		GetFn: func(tx *sql.Tx, groupID int64, userID string) (*planetscale.GroupMember, error) {
+			return &planetscale.GroupMember{
+				GroupID: groupID,
+				UserID:  userID,
+				Role:    planetscale.RoleMember,
+			}, nil
+		},
+	}
+
+	t.Run("POST /groups/1/recurring", func(t *testing.T) {
+		t.Run("successful create", func(t *testing.T) {
+			var created *planetscale.RecurringExpense
+			server.repos.GroupMember = memberRepo
+			server.repos.RecurringExpense = &db_mock.RecurringExpenseRepo{
+				CreateFn: func(tx *sql.Tx, recurringExpense *planetscale.RecurringExpense) error {
+					recurringExpense.RecurringExpenseID = 1
+					created = recurringExpense
+					return nil
+				},
+			}
+
+			body := []byte(`{"amount": 1200.00, "description": "rent", "rule": "FREQ=MONTHLY", "start_at": "2024-01-01T00:00:00Z"}`)
+			token := server.buildJWTForTesting(t, "test_user_id")
+			req, err := http.NewRequest("POST", "/groups/1/recurring", bytes.NewReader(body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(server.router.ServeHTTP)
+			handler.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != http.StatusCreated {
+				t.Fatalf("expected status code %d, got %d: %s", http.StatusCreated, status, rr.Body.String())
+			}
+			if created.GroupID != 1 || created.PaidBy != "test_user_id" || created.SplitTypeID != planetscale.SplitTypeEqual {
+				t.Errorf("unexpected recurring expense %+v", created)
+			}
+			if !created.NextOccurrenceAt.Equal(created.StartAt) {
+				t.Errorf("expected first occurrence at %v, got %v", created.StartAt, created.NextOccurrenceAt)
+			}
+		})
+
+		t.Run("invalid rule", func(t *testing.T) {
+			server.repos.GroupMember = memberRepo
+
+			body := []byte(`{"amount": 1200.00, "rule": "FREQ=HOURLY"}`)
+			token := server.buildJWTForTesting(t, "test_user_id")
+			req, err := http.NewRequest("POST", "/groups/1/recurring", bytes.NewReader(body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(server.router.ServeHTTP)
+			handler.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != http.StatusBadRequest {
+				t.Errorf("expected status code %d, got %d", http.StatusBadRequest, status)
+			}
+		})
+	})
+
+	t.Run("GET /groups/1/recurring", func(t *testing.T) {
+		t.Run("successful find", func(t *testing.T) {
+			server.repos.GroupMember = memberRepo
+			server.repos.RecurringExpense = &db_mock.RecurringExpenseRepo{
+				FindFn: func(tx *sql.Tx, filter planetscale.RecurringExpenseFilter) ([]*planetscale.RecurringExpense, error) {
+					return []*planetscale.RecurringExpense{
+						{
+							RecurringExpenseID: 1,
+							GroupID:            filter.GroupID,
+						},
+					}, nil
+				},
+			}
+
+			token := server.buildJWTForTesting(t, "test_user_id")
+			req, err := http.NewRequest("GET", "/groups/1/recurring", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Accept", "application/json")
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(server.router.ServeHTTP)
+			handler.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != http.StatusOK {
+				t.Fatalf("expected status code %d, got %d", http.StatusOK, status)
+			}
+
+			var got findRecurringExpensesResponse
+			err = json.Unmarshal(rr.Body.Bytes(), &got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.N != 1 || got.RecurringExpenses[0].GroupID != 1 {
+				t.Errorf("unexpected response %+v", got)
+			}
+		})
+	})
+
+	t.Run("GET /groups/1/recurring/1", func(t *testing.T) {
+		t.Run("recurring expense of another group", func(t *testing.T) {
+			server.repos.GroupMember = memberRepo
+			server.repos.RecurringExpense = &db_mock.RecurringExpenseRepo{
+				GetFn: func(tx *sql.Tx, recurringExpenseID int64) (*planetscale.RecurringExpense, error) {
+					return &planetscale.RecurringExpense{
+						RecurringExpenseID: recurringExpenseID,
+						GroupID:            2,
+					}, nil
+				},
+			}
+
+			token := server.buildJWTForTesting(t, "test_user_id")
+			req, err := http.NewRequest("GET", "/groups/1/recurring/1", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Accept", "application/json")
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(server.router.ServeHTTP)
+			handler.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != http.StatusNotFound {
+				t.Errorf("expected status code %d, got %d", http.StatusNotFound, status)
+			}
+		})
+	})
+
+	t.Run("DELETE /groups/1/recurring/1", func(t *testing.T) {
+		t.Run("successful delete", func(t *testing.T) {
+			deleted := false
+			server.repos.GroupMember = memberRepo
+			server.repos.RecurringExpense = &db_mock.RecurringExpenseRepo{
+				GetFn: func(tx *sql.Tx, recurringExpenseID int64) (*planetscale.RecurringExpense, error) {
+					return &planetscale.RecurringExpense{
+						RecurringExpenseID: recurringExpenseID,
+						GroupID:            1,
+						CreatedBy:          "test_user_id",
+					}, nil
+				},
+				DeleteFn: func(tx *sql.Tx, recurringExpenseID int64) error {
+					deleted = true
+					return nil
+				},
+			}
+
+			token := server.buildJWTForTesting(t, "test_user_id")
+			req, err := http.NewRequest("DELETE", "/groups/1/recurring/1", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(server.router.ServeHTTP)
+			handler.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != http.StatusNoContent {
+				t.Errorf("expected status code %d, got %d", http.StatusNoContent, status)
+			}
+			if !deleted {
+				t.Error("expected recurring expense to be deleted")
+			}
+		})
+	})
+}
@@ -0,0 +1,94 @@
+package http
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	planetscale "github.com/harshav17/planet_scale"
+)
+
+type searchController struct {
+	repos *planetscale.RepoProvider
+	tm    planetscale.TransactionManager
+}
+
+func NewSearchController(repos *planetscale.RepoProvider, tm planetscale.TransactionManager) *searchController {
+	return &searchController{
+		repos: repos,
+		tm:    tm,
+	}
+}
+
+type searchResponse struct {
+	Hits []*planetscale.SearchHit `json:"hits"`
+	N    int                      `json:"n"`
+}
+
+// HandleSearch handles the GET /search?q= endpoint. It searches expense
+// descriptions, item names and participant notes across the caller's groups,
+// best matches first.
+func (c *searchController) HandleSearch(w http.ResponseWriter, r *http.Request) {
+	user, found := planetscale.UserFromContext(r.Context())
+	if !found {
+		Error(w, r, planetscale.Errorf(planetscale.ENOTFOUND, "user context not set"))
+		return
+	}
+
+	q := strings.TrimSpace(r.URL.Query().Get("q"))
+	if q == "" {
+		Error(w, r, planetscale.Errorf(planetscale.EINVALID, "q is required"))
+		return
+	}
+	limit := planetscale.DefaultPageSize
+	if s := r.URL.Query().Get("limit"); s != "" {
+		var err error
+		limit, err = strconv.Atoi(s)
+		if err != nil || limit < 1 || limit > planetscale.MaxPageSize {
+			Error(w, r, planetscale.Errorf(planetscale.EINVALID, "limit must be between 1 and %d", planetscale.MaxPageSize))
+			return
+		}
+	}
+
+	var hits []*planetscale.SearchHit
+	searchFunc := func(tx *sql.Tx) error {
+		groups, err := c.repos.ExpenseGroup.ListAllForUser(tx, user.UserID)
+		if err != nil {
+			return err
+		}
+		if len(groups) == 0 {
+			return nil
+		}
+
+		groupIDs := make([]int64, len(groups))
+		for i, group := range groups {
+			groupIDs[i] = group.ExpenseGroupID
+		}
+		hits, err = c.repos.Search.Search(tx, planetscale.SearchQuery{
+			Query:    q,
+			GroupIDs: groupIDs,
+			Limit:    limit,
+		})
+		if err != nil {
+			return err
+		}
+		return nil
+	}
+
+	err := c.tm.ExecuteInTx(r.Context(), searchFunc)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(searchResponse{
+		Hits: hits,
+		N:    len(hits),
+	}); err != nil {
+		Error(w, r, err)
+		return
+	}
+}
@@ -0,0 +1,116 @@
+package http
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	planetscale "github.com/harshav17/planet_scale"
+	db_mock "github.com/harshav17/planet_scale/mock/db"
+)
+
+func TestHandleSearch_All(t *testing.T) {
+	t.Parallel()
+
+	server := MustOpenServer(t)
+	defer MustCloseServer(t, server.Server)
+
+	t.Run("GET /search", func(t *testing.T) {
+		t.Run("successful search", func(t *testing.T) {
+			server.repos.ExpenseGroup = &db_mock.ExpenseGroupRepo{
+				ListAllForUserFn: func(tx *sql.Tx, userID string) ([]*planetscale.ExpenseGroup, error) {
+					return []*planetscale.ExpenseGroup{{ExpenseGroupID: 1}, {ExpenseGroupID: 2}}, nil
+				},
+			}
+			server.repos.Search = &db_mock.SearchRepo{
+				SearchFn: func(tx *sql.Tx, query planetscale.SearchQuery) ([]*planetscale.SearchHit, error) {
+					if query.Query != "dinner" {
+						t.Fatalf("expected query dinner, got %s", query.Query)
+					} else if len(query.GroupIDs) != 2 || query.GroupIDs[0] != 1 || query.GroupIDs[1] != 2 {
+						t.Fatalf("expected search scoped to groups 1 and 2, got %v", query.GroupIDs)
+					}
+					return []*planetscale.SearchHit{
+						{GroupID: 1, ExpenseID: 3, ExpenseDescription: "dinner in march", Match: planetscale.SearchMatchExpense, Score: 1.5},
+					}, nil
+				},
+			}
+
+			token := server.buildJWTForTesting(t, "test_user_id")
+			req, err := http.NewRequest("GET", "/search?q=dinner", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Accept", "application/json")
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(server.router.ServeHTTP)
+			handler.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != http.StatusOK {
+				t.Fatalf("expected status code %d, got %d", http.StatusOK, status)
+			}
+
+			var got searchResponse
+			err = json.Unmarshal(rr.Body.Bytes(), &got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.N != 1 {
+				t.Errorf("expected 1 hit, got %d", got.N)
+			} else if got.Hits[0].ExpenseID != 3 {
+				t.Errorf("expected expense 3, got %d", got.Hits[0].ExpenseID)
+			}
+		})
+
+		t.Run("user without groups", func(t *testing.T) {
+			server.repos.ExpenseGroup = &db_mock.ExpenseGroupRepo{
+				ListAllForUserFn: func(tx *sql.Tx, userID string) ([]*planetscale.ExpenseGroup, error) {
+					return nil, nil
+				},
+			}
+			server.repos.Search = &db_mock.SearchRepo{
+				SearchFn: func(tx *sql.Tx, query planetscale.SearchQuery) ([]*planetscale.SearchHit, error) {
+					t.Fatal("expected no search without groups")
+					return nil, nil
+				},
+			}
+
+			token := server.buildJWTForTesting(t, "test_user_id")
+			req, err := http.NewRequest("GET", "/search?q=dinner", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Accept", "application/json")
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(server.router.ServeHTTP)
+			handler.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != http.StatusOK {
+				t.Errorf("expected status code %d, got %d", http.StatusOK, status)
+			}
+		})
+
+		t.Run("missing query", func(t *testing.T) {
+			token := server.buildJWTForTesting(t, "test_user_id")
+			req, err := http.NewRequest("GET", "/search", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Accept", "application/json")
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(server.router.ServeHTTP)
+			handler.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != http.StatusBadRequest {
+				t.Errorf("expected status code %d, got %d", http.StatusBadRequest, status)
+			}
+		})
+	})
+}
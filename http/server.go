@@ -13,19 +13,17 @@ import (
 	"github.com/go-chi/cors"
 	planetscale "github.com/harshav17/planet_scale"
 	docs "github.com/harshav17/planet_scale/docs"
+	views "github.com/harshav17/planet_scale/templates"
 	utilities "github.com/harshav17/planet_scale/utilites"
 	slogchi "github.com/samber/slog-chi"
 )
 
 var (
-	//go:embed templates/*
-	templateFS embed.FS
-
 	//go:embed css/*
 	css embed.FS
 
 	//parsed templates
-	templates = template.Must(template.ParseFS(templateFS, "templates/*"))
+	templates = template.Must(template.ParseFS(views.Templates, "*.html"))
 )
 
 // ShutdownTimeout is the time given for outstanding requests to finish before shutdown.
@@ -36,13 +34,15 @@ type Server struct {
 	server     *http.Server
 	router     chi.Router
 	middleware *Middleware
+	events     planetscale.EventHub
 }
 
-func NewServer(controllers *planetscale.ControllerProvider, middleware *Middleware) *Server {
+func NewServer(controllers *planetscale.ControllerProvider, middleware *Middleware, events planetscale.EventHub) *Server {
 	s := &Server{
 		server:     &http.Server{},
 		router:     chi.NewRouter(),
 		middleware: middleware,
+		events:     events,
 	}
 
 	logger := utilities.GetLogger()
@@ -102,20 +102,61 @@ func NewServer(controllers *planetscale.ControllerProvider, middleware *Middlewa
 				r.Route("/members", func(r chi.Router) {
 					r.Get("/", controllers.GroupMember.HandleGetGroupMembers)
 					r.Post("/", controllers.GroupMember.HandlePostGroupMember)
+					r.Patch("/{userID}", controllers.GroupMember.HandlePatchGroupMember)
 					r.Delete("/{userID}", controllers.GroupMember.HandleDeleteGroupMember)
 				})
+				r.Post("/transfer_ownership", controllers.GroupMember.HandleTransferOwnership)
+				r.Post("/invites", controllers.GroupInvite.HandlePostGroupInvite)
+				r.Get("/webhooks", controllers.Webhook.HandleGetGroupWebhooks)
+				r.Post("/webhooks", controllers.Webhook.HandlePostGroupWebhook)
 				r.Get("/expenses", controllers.Expense.HandleGetGroupExpenses)
 				r.Get("/settlements", controllers.Settlement.HandleGetGroupSettlements)
 				r.Get("/balances", controllers.ExpenseGroup.HandleGetGroupBalances)
+				r.Get("/settle_plan", controllers.ExpenseGroup.HandleGetSettlePlan)
+				r.Post("/settle_plan", controllers.ExpenseGroup.HandlePostSettlePlan)
+				r.Get("/history", controllers.ExpenseGroup.HandleGetGroupHistory)
+				r.Get("/activity", controllers.Activity.HandleGetGroupActivity)
+				r.Post("/activity/read", controllers.Activity.HandlePostGroupActivityRead)
+				r.Get("/events", controllers.Event.HandleGetGroupEvents)
+				r.Get("/trash", controllers.ExpenseGroup.HandleGetGroupTrash)
+				r.Post("/restore", controllers.ExpenseGroup.HandleRestoreExpenseGroup)
+				r.Get("/export.csv", controllers.ExpenseGroup.HandleExportLedger)
+				r.Post("/import", controllers.ExpenseGroup.HandleImportLedger)
+				r.Route("/recurring", func(r chi.Router) {
+					r.Get("/", controllers.RecurringExpense.HandleGetRecurringExpenses)
+					r.Post("/", controllers.RecurringExpense.HandlePostRecurringExpense)
+					r.Route("/{recurringExpenseID}", func(r chi.Router) {
+						r.Get("/", controllers.RecurringExpense.HandleGetRecurringExpense)
+						r.Patch("/", controllers.RecurringExpense.HandlePatchRecurringExpense)
+						r.Delete("/", controllers.RecurringExpense.HandleDeleteRecurringExpense)
+					})
+				})
 			})
 		})
 
+		r.Post("/invites/{token}/accept", controllers.GroupInvite.HandleAcceptInvite)
+
+		r.Route("/me", func(r chi.Router) {
+			r.Get("/balances", controllers.User.HandleGetUserBalance)
+			r.Get("/dashboard", controllers.User.HandleGetDashboard)
+			r.Get("/notifications", controllers.User.HandleGetNotificationPreferences)
+			r.Patch("/notifications", controllers.User.HandlePatchNotificationPreferences)
+			r.Get("/activity", controllers.Activity.HandleGetUserActivity)
+			r.Post("/activity/read", controllers.Activity.HandlePostUserActivityRead)
+		})
+
 		r.Route("/expenses", func(r chi.Router) {
 			r.Post("/", controllers.Expense.HandlePostExpense)
 			r.Route("/{expenseID}", func(r chi.Router) {
 				r.Patch("/", controllers.Expense.HandlePatchExpense)
 				r.Delete("/", controllers.Expense.HandleDeleteExpense)
 				r.Get("/", controllers.Expense.HandleGetExpense)
+				r.Get("/history", controllers.Expense.HandleGetExpenseHistory)
+				r.Get("/items", controllers.Item.HandleGetExpenseItems)
+				r.Post("/receipt", controllers.Item.HandlePostReceipt)
+				r.Get("/attachments", controllers.Attachment.HandleGetExpenseAttachments)
+				r.Post("/attachments", controllers.Attachment.HandlePostAttachment)
+				r.Post("/restore", controllers.Expense.HandleRestoreExpense)
 			})
 		})
 
@@ -125,6 +166,7 @@ func NewServer(controllers *planetscale.ControllerProvider, middleware *Middlewa
 				r.Patch("/", controllers.Settlement.HandlePatchSettlement)
 				r.Delete("/", controllers.Settlement.HandleDeleteSettlement)
 				r.Get("/", controllers.Settlement.HandleGetSettlement)
+				r.Post("/restore", controllers.Settlement.HandleRestoreSettlement)
 			})
 		})
 
@@ -134,7 +176,38 @@ func NewServer(controllers *planetscale.ControllerProvider, middleware *Middlewa
 
 		r.Route("/items", func(r chi.Router) {
 			r.Post("/", controllers.Item.HandlePostItem)
+			r.Route("/{itemID}", func(r chi.Router) {
+				r.Get("/", controllers.Item.HandleGetItem)
+				r.Patch("/", controllers.Item.HandlePatchItem)
+				r.Delete("/", controllers.Item.HandleDeleteItem)
+				r.Route("/splits", func(r chi.Router) {
+					r.Get("/", controllers.Item.HandleGetItemSplits)
+					r.Post("/", controllers.Item.HandlePostItemSplit)
+					r.Patch("/{itemSplitID}", controllers.Item.HandlePatchItemSplit)
+					r.Delete("/{itemSplitID}", controllers.Item.HandleDeleteItemSplit)
+				})
+			})
+		})
+
+		r.Route("/item_splits", func(r chi.Router) {
+			r.Post("/{itemSplitID}/claim", controllers.Item.HandleClaimItemSplit)
 		})
+
+		r.Route("/attachments", func(r chi.Router) {
+			r.Get("/{attachmentID}", controllers.Attachment.HandleGetAttachment)
+			r.Delete("/{attachmentID}", controllers.Attachment.HandleDeleteAttachment)
+		})
+
+		r.Route("/webhooks", func(r chi.Router) {
+			r.Get("/", controllers.Webhook.HandleGetUserWebhooks)
+			r.Post("/", controllers.Webhook.HandlePostUserWebhook)
+			r.Route("/{subscriptionID}", func(r chi.Router) {
+				r.Delete("/", controllers.Webhook.HandleDeleteWebhook)
+				r.Get("/deliveries", controllers.Webhook.HandleGetWebhookDeliveries)
+			})
+		})
+
+		r.Get("/search", controllers.Search.HandleSearch)
 	})
 
 	s.server.Handler = s.router
@@ -152,8 +225,13 @@ func (s *Server) Open() error {
 	return nil
 }
 
-// Close gracefully shuts down the server.
+// Close gracefully shuts down the server. Event streams never go idle on their
+// own, so they are ended first.
 func (s *Server) Close() error {
+	if err := s.events.Close(); err != nil {
+		return err
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), ShutdownTimeout)
 	defer cancel()
 	return s.server.Shutdown(ctx)
@@ -14,6 +14,8 @@ import (
 	"github.com/clerkinc/clerk-sdk-go/clerk"
 	planetscale "github.com/harshav17/planet_scale"
 	db_mock "github.com/harshav17/planet_scale/mock/db"
+	service_mock "github.com/harshav17/planet_scale/mock/service"
+	"github.com/harshav17/planet_scale/service"
 	"github.com/patrickmn/go-cache"
 	svix "github.com/svix/svix-webhooks/go"
 	"gopkg.in/square/go-jose.v2"
@@ -49,24 +51,54 @@ func MustOpenServer(tb testing.TB) TestServer {
 			return nil
 		},
 	}
+	repos.AuditEvent = db_mock.AuditEventRepo{
+		CreateFn: func(tx *sql.Tx, event *planetscale.AuditEvent) error {
+			return nil
+		},
+	}
+	repos.WebhookEvent = db_mock.WebhookEventRepo{
+		CreateFn: func(tx *sql.Tx, event *planetscale.WebhookEvent) error {
+			return nil
+		},
+	}
+	repos.Activity = db_mock.ActivityRepo{
+		CreateFn: func(tx *sql.Tx, activity *planetscale.Activity) error {
+			return nil
+		},
+	}
 
 	services := planetscale.ServiceProvider{}
+	services.Events = service.NewEventHub()
+	services.Notifier = service_mock.Notifier{
+		NotifyExpenseAddedFn: func(expense *planetscale.Expense, userIDs []string) error {
+			return nil
+		},
+	}
 
 	controllers := planetscale.ControllerProvider{}
 	controllers.Product = NewProductController(&repos, &tm)
 	controllers.ExpenseGroup = NewExpenseGroupController(&repos, &services, &tm)
-	controllers.GroupMember = NewGroupMemberController(&repos, &tm)
+	controllers.GroupMember = NewGroupMemberController(&repos, &services, &tm)
 	controllers.Expense = NewExpenseController(&repos, &services, &tm)
-	controllers.Settlement = NewSettlementController(&repos, &tm)
+	controllers.Settlement = NewSettlementController(&repos, &services, &tm)
 	controllers.SplitType = NewSplitTypeController(&repos, &tm)
-	controllers.User = NewUserController(&repos, &tm, &svix.Webhook{})
+	controllers.User = NewUserController(&repos, &services, &tm, &svix.Webhook{})
 	controllers.Item = NewItemController(&repos, &services, &tm)
+	controllers.RecurringExpense = NewRecurringExpenseController(&repos, &services, &tm)
+	controllers.Search = NewSearchController(&repos, &tm)
+	controllers.Attachment = NewAttachmentController(&repos, &services, &tm)
+	controllers.GroupInvite = NewGroupInviteController(&repos, &services, &tm)
+	controllers.Webhook = NewWebhookController(&repos, &services, &tm)
+	controllers.Activity = NewActivityController(&repos, &tm)
+	events := NewEventController(&repos, &services, &tm)
+	events.heartbeat = 50 * time.Millisecond
+	controllers.Event = events
 
 	c := cache.New(5*time.Minute, 10*time.Minute)
 	client, _ := clerk.NewClient("test", clerk.WithBaseURL("http://localhost:8080"))
 	middleware := NewMiddleware(&repos, &tm, c, &client)
 
-	server := NewServer(&controllers, middleware)
+	server := NewServer(&controllers, middleware, services.Events)
 
 	// handle JWT cycles
 	jwk := generateJWK(tb)
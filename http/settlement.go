@@ -11,14 +11,16 @@ import (
 )
 
 type settlementController struct {
-	repos *planetscale.RepoProvider
-	tm    planetscale.TransactionManager
+	repos    *planetscale.RepoProvider
+	services *planetscale.ServiceProvider
+	tm       planetscale.TransactionManager
 }
 
-func NewSettlementController(repos *planetscale.RepoProvider, tm planetscale.TransactionManager) *settlementController {
+func NewSettlementController(repos *planetscale.RepoProvider, services *planetscale.ServiceProvider, tm planetscale.TransactionManager) *settlementController {
 	return &settlementController{
-		repos: repos,
-		tm:    tm,
+		repos:    repos,
+		services: services,
+		tm:       tm,
 	}
 }
 
@@ -37,16 +39,29 @@ func (c *settlementController) HandleGetGroupSettlements(w http.ResponseWriter,
 	}
 	groupID := int64(group32)
 
+	params, err := parseListParams(r)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
 	var settlements []*planetscale.Settlement
 	getSettlementFunc := func(tx *sql.Tx) error {
-		// validate user is a member of the group
-		_, err = c.repos.GroupMember.Get(tx, groupID, user.UserID)
+		_, err = planetscale.CheckPermission(tx, c.repos, groupID, user.UserID, planetscale.PermissionView)
 		if err != nil {
 			return err
 		}
 
 		settlements, err = c.repos.Settlement.Find(tx, planetscale.SettlementFilter{
-			GroupID: groupID,
+			GroupID:   groupID,
+			PaidBy:    params.PaidBy,
+			From:      params.From,
+			To:        params.To,
+			MinAmount: params.MinAmount,
+			MaxAmount: params.MaxAmount,
+			Sort:      params.Sort,
+			Cursor:    params.Cursor,
+			Limit:     params.Limit,
 		})
 		if err != nil {
 			return err
@@ -60,10 +75,17 @@ func (c *settlementController) HandleGetGroupSettlements(w http.ResponseWriter,
 		return
 	}
 
+	// a full page may be followed by more settlements
+	var nextCursor string
+	if len(settlements) == params.Limit {
+		nextCursor = planetscale.SettlementCursor(settlements[len(settlements)-1], params.Sort)
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(findSettlementsResponse{
 		Settlements: settlements,
 		N:           len(settlements),
+		NextCursor:  nextCursor,
 	}); err != nil {
 		Error(w, r, err)
 		return
@@ -73,6 +95,7 @@ func (c *settlementController) HandleGetGroupSettlements(w http.ResponseWriter,
 type findSettlementsResponse struct {
 	Settlements []*planetscale.Settlement `json:"settlements"`
 	N           int                       `json:"n"`
+	NextCursor  string                    `json:"next_cursor,omitempty"`
 }
 
 func (c *settlementController) HandlePostSettlement(w http.ResponseWriter, r *http.Request) {
@@ -89,12 +112,11 @@ func (c *settlementController) HandlePostSettlement(w http.ResponseWriter, r *ht
 		return
 	}
 
+	var audit *planetscale.AuditEvent
 	createSettlementFunc := func(tx *sql.Tx) error {
-		// validate user is a member of the group
-		_, err = c.repos.GroupMember.Get(tx, settlement.GroupID, user.UserID)
+		_, err = planetscale.CheckPermission(tx, c.repos, settlement.GroupID, user.UserID, planetscale.PermissionEdit)
 		if err != nil {
-			// rewrap error to add more context
-			return planetscale.Errorf(planetscale.ENOTFOUND, "you are not a member of this group")
+			return err
 		}
 
 		// validate paid by user is context user
@@ -112,7 +134,24 @@ func (c *settlementController) HandlePostSettlement(w http.ResponseWriter, r *ht
 		if err != nil {
 			return err
 		}
-		return nil
+
+		audit = &planetscale.AuditEvent{
+			GroupID:    &settlement.GroupID,
+			EntityType: planetscale.AuditEntitySettlement,
+			EntityID:   strconv.FormatInt(settlement.SettlementID, 10),
+			Action:     planetscale.AuditActionCreate,
+			ActorID:    user.UserID,
+			After:      settlement,
+		}
+		err = c.repos.AuditEvent.Create(tx, audit)
+		if err != nil {
+			return err
+		}
+		err = c.repos.WebhookEvent.Create(tx, planetscale.NewWebhookEvent(audit))
+		if err != nil {
+			return err
+		}
+		return c.repos.Activity.Create(tx, planetscale.NewActivity(audit))
 	}
 
 	err = c.tm.ExecuteInTx(r.Context(), createSettlementFunc)
@@ -120,6 +159,7 @@ func (c *settlementController) HandlePostSettlement(w http.ResponseWriter, r *ht
 		Error(w, r, err)
 		return
 	}
+	planetscale.Publish(c.services.Events, audit)
 
 	w.WriteHeader(http.StatusCreated)
 	w.Header().Set("Content-Type", "application/json")
@@ -150,13 +190,8 @@ func (c *settlementController) HandleGetSettlement(w http.ResponseWriter, r *htt
 			return err
 		}
 
-		// validate user is a member of the group
-		_, err := c.repos.GroupMember.Get(tx, settlement.GroupID, user.UserID)
-		if err != nil {
-			return err
-		}
-
-		return nil
+		_, err := planetscale.CheckPermission(tx, c.repos, settlement.GroupID, user.UserID, planetscale.PermissionView)
+		return err
 	}
 
 	err = c.tm.ExecuteInTx(r.Context(), getSettlementFunc)
@@ -193,18 +228,44 @@ func (c *settlementController) HandlePatchSettlement(w http.ResponseWriter, r *h
 		return
 	}
 
+	var audit *planetscale.AuditEvent
 	updateSettlementFunc := func(tx *sql.Tx) error {
-		// validate user is a member of the group
-		_, err = c.repos.GroupMember.Get(tx, *settlement.GroupID, user.UserID)
+		before, err := c.repos.Settlement.Get(tx, settlementID)
 		if err != nil {
 			return err
 		}
 
-		_, err = c.repos.Settlement.Update(tx, settlementID, &settlement)
+		member, err := planetscale.CheckPermission(tx, c.repos, before.GroupID, user.UserID, planetscale.PermissionEdit)
 		if err != nil {
 			return err
 		}
-		return nil
+		err = planetscale.CheckAuthor(member, before.PaidBy, before.PaidTo)
+		if err != nil {
+			return err
+		}
+		after, err := c.repos.Settlement.Update(tx, settlementID, &settlement)
+		if err != nil {
+			return err
+		}
+
+		audit = &planetscale.AuditEvent{
+			GroupID:    &before.GroupID,
+			EntityType: planetscale.AuditEntitySettlement,
+			EntityID:   strconv.FormatInt(settlementID, 10),
+			Action:     planetscale.AuditActionUpdate,
+			ActorID:    user.UserID,
+			Before:     before,
+			After:      after,
+		}
+		err = c.repos.AuditEvent.Create(tx, audit)
+		if err != nil {
+			return err
+		}
+		err = c.repos.WebhookEvent.Create(tx, planetscale.NewWebhookEvent(audit))
+		if err != nil {
+			return err
+		}
+		return c.repos.Activity.Create(tx, planetscale.NewActivity(audit))
 	}
 
 	err = c.tm.ExecuteInTx(r.Context(), updateSettlementFunc)
@@ -212,6 +273,7 @@ func (c *settlementController) HandlePatchSettlement(w http.ResponseWriter, r *h
 		Error(w, r, err)
 		return
 	}
+	planetscale.Publish(c.services.Events, audit)
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(settlement); err != nil {
@@ -234,14 +296,18 @@ func (c *settlementController) HandleDeleteSettlement(w http.ResponseWriter, r *
 	}
 	settlementID := int64(settlement32)
 
+	var audit *planetscale.AuditEvent
 	deleteSettlementFunc := func(tx *sql.Tx) error {
-		// validate user is a member of the group
 		settlement, err := c.repos.Settlement.Get(tx, settlementID)
 		if err != nil {
 			return err
 		}
 
-		_, err = c.repos.GroupMember.Get(tx, settlement.GroupID, user.UserID)
+		member, err := planetscale.CheckPermission(tx, c.repos, settlement.GroupID, user.UserID, planetscale.PermissionEdit)
+		if err != nil {
+			return err
+		}
+		err = planetscale.CheckAuthor(member, settlement.PaidBy, settlement.PaidTo)
 		if err != nil {
 			return err
 		}
@@ -250,7 +316,24 @@ func (c *settlementController) HandleDeleteSettlement(w http.ResponseWriter, r *
 		if err != nil {
 			return err
 		}
-		return nil
+
+		audit = &planetscale.AuditEvent{
+			GroupID:    &settlement.GroupID,
+			EntityType: planetscale.AuditEntitySettlement,
+			EntityID:   strconv.FormatInt(settlementID, 10),
+			Action:     planetscale.AuditActionDelete,
+			ActorID:    user.UserID,
+			Before:     settlement,
+		}
+		err = c.repos.AuditEvent.Create(tx, audit)
+		if err != nil {
+			return err
+		}
+		err = c.repos.WebhookEvent.Create(tx, planetscale.NewWebhookEvent(audit))
+		if err != nil {
+			return err
+		}
+		return c.repos.Activity.Create(tx, planetscale.NewActivity(audit))
 	}
 
 	err = c.tm.ExecuteInTx(r.Context(), deleteSettlementFunc)
@@ -258,6 +341,77 @@ func (c *settlementController) HandleDeleteSettlement(w http.ResponseWriter, r *
 		Error(w, r, err)
 		return
 	}
+	planetscale.Publish(c.services.Events, audit)
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// HandleRestoreSettlement handles the POST /settlements/{settlementID}/restore endpoint.
+func (c *settlementController) HandleRestoreSettlement(w http.ResponseWriter, r *http.Request) {
+	user, found := planetscale.UserFromContext(r.Context())
+	if !found {
+		Error(w, r, planetscale.Errorf(planetscale.ENOTFOUND, "user context not set"))
+		return
+	}
+
+	settlement32, err := strconv.Atoi(chi.URLParam(r, "settlementID"))
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+	settlementID := int64(settlement32)
+
+	var settlement *planetscale.Settlement
+	var audit *planetscale.AuditEvent
+	restoreSettlementFunc := func(tx *sql.Tx) error {
+		err = c.repos.Settlement.Restore(tx, settlementID)
+		if err != nil {
+			return err
+		}
+
+		// rolls the restore back for anyone who could not delete it
+		settlement, err = c.repos.Settlement.Get(tx, settlementID)
+		if err != nil {
+			return err
+		}
+		member, err := planetscale.CheckPermission(tx, c.repos, settlement.GroupID, user.UserID, planetscale.PermissionEdit)
+		if err != nil {
+			return err
+		}
+		err = planetscale.CheckAuthor(member, settlement.PaidBy, settlement.PaidTo)
+		if err != nil {
+			return err
+		}
+
+		audit = &planetscale.AuditEvent{
+			GroupID:    &settlement.GroupID,
+			EntityType: planetscale.AuditEntitySettlement,
+			EntityID:   strconv.FormatInt(settlementID, 10),
+			Action:     planetscale.AuditActionRestore,
+			ActorID:    user.UserID,
+			After:      settlement,
+		}
+		err = c.repos.AuditEvent.Create(tx, audit)
+		if err != nil {
+			return err
+		}
+		err = c.repos.WebhookEvent.Create(tx, planetscale.NewWebhookEvent(audit))
+		if err != nil {
+			return err
+		}
+		return c.repos.Activity.Create(tx, planetscale.NewActivity(audit))
+	}
+
+	err = c.tm.ExecuteInTx(r.Context(), restoreSettlementFunc)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+	planetscale.Publish(c.services.Events, audit)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(settlement); err != nil {
+		Error(w, r, err)
+		return
+	}
+}
@@ -32,6 +32,7 @@ func TestHandleSettlements_All(t *testing.T) {
 					return &planetscale.GroupMember{
 						GroupID: 1,
 						UserID:  "test_user_id",
+						Role:    planetscale.RoleMember,
 					}, nil
 				},
 			}
@@ -103,6 +104,7 @@ func TestHandleSettlements_All(t *testing.T) {
 					return &planetscale.GroupMember{
 						GroupID: 1,
 						UserID:  contextUserID,
+						Role:    planetscale.RoleMember,
 					}, nil
 				},
 			}
@@ -177,6 +179,7 @@ func TestHandleSettlements_All(t *testing.T) {
 					return &planetscale.GroupMember{
 						GroupID: 1,
 						UserID:  contextUserID,
+						Role:    planetscale.RoleMember,
 					}, nil
 				},
 			}
@@ -219,6 +222,7 @@ func TestHandleSettlements_All(t *testing.T) {
 					return &planetscale.GroupMember{
 						GroupID: 1,
 						UserID:  contextUserID,
+						Role:    planetscale.RoleMember,
 					}, nil
 				},
 			}
@@ -277,6 +281,7 @@ func TestHandleSettlements_All(t *testing.T) {
 					return &planetscale.GroupMember{
 						GroupID: 1,
 						UserID:  userID,
+						Role:    planetscale.RoleMember,
 					}, nil
 				},
 			}
@@ -348,8 +353,11 @@ func TestHandleSettlements_All(t *testing.T) {
 		t.Run("successful update", func(t *testing.T) {
 			userID := "test_user_id"
 			server.repos.Settlement = &db_mock.SettlementRepo{
+				GetFn: func(tx *sql.Tx, settlementID int64) (*planetscale.Settlement, error) {
+					return &planetscale.Settlement{SettlementID: settlementID, GroupID: 1, PaidBy: userID}, nil
+				},
 				UpdateFn: func(tx *sql.Tx, settlementID int64, settlement *planetscale.SettlementUpdate) (*planetscale.Settlement, error) {
-					return nil, nil
+					return &planetscale.Settlement{SettlementID: settlementID, GroupID: 1}, nil
 				},
 			}
 			server.repos.GroupMember = &db_mock.GroupMemberRepo{
@@ -357,6 +365,7 @@ func TestHandleSettlements_All(t *testing.T) {
 					return &planetscale.GroupMember{
 						GroupID: 1,
 						UserID:  userID,
+						Role:    planetscale.RoleMember,
 					}, nil
 				},
 			}
@@ -442,6 +451,7 @@ func TestHandleSettlements_All(t *testing.T) {
 					return &planetscale.Settlement{
 						SettlementID: 1,
 						GroupID:      1,
+						PaidBy:       "test_user_id",
 					}, nil
 				},
 			}
@@ -450,6 +460,7 @@ func TestHandleSettlements_All(t *testing.T) {
 					return &planetscale.GroupMember{
 						GroupID: 1,
 						UserID:  "test_user_id",
+						Role:    planetscale.RoleMember,
 					}, nil
 				},
 			}
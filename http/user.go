@@ -4,26 +4,142 @@ import (
 	"database/sql"
 	"encoding/json"
 	"io"
+	"log/slog"
 	"net/http"
+	"strings"
 
 	planetscale "github.com/harshav17/planet_scale"
 	svix "github.com/svix/svix-webhooks/go"
 )
 
 type userController struct {
-	repos *planetscale.RepoProvider
-	tm    planetscale.TransactionManager
-	wh    *svix.Webhook
+	repos    *planetscale.RepoProvider
+	services *planetscale.ServiceProvider
+	tm       planetscale.TransactionManager
+	wh       *svix.Webhook
 }
 
-func NewUserController(repos *planetscale.RepoProvider, tm planetscale.TransactionManager, wh *svix.Webhook) *userController {
+func NewUserController(repos *planetscale.RepoProvider, services *planetscale.ServiceProvider, tm planetscale.TransactionManager, wh *svix.Webhook) *userController {
 	return &userController{
-		repos: repos,
-		tm:    tm,
-		wh:    wh,
+		repos:    repos,
+		services: services,
+		tm:       tm,
+		wh:       wh,
 	}
 }
 
+// HandleGetUserBalance handles the GET /me/balances endpoint. It totals what
+// the caller is owed and owes across their groups and direct expenses, in the
+// currency of the optional currency query param.
+func (c *userController) HandleGetUserBalance(w http.ResponseWriter, r *http.Request) {
+	user, found := planetscale.UserFromContext(r.Context())
+	if !found {
+		Error(w, r, planetscale.Errorf(planetscale.ENOTFOUND, "user context not set"))
+		return
+	}
+
+	balance, err := c.services.Balance.GetUserBalance(r.Context(), user.UserID, r.URL.Query().Get("currency"))
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(balance); err != nil {
+		Error(w, r, err)
+		return
+	}
+}
+
+// HandleGetDashboard handles the GET /me/dashboard endpoint. It returns the
+// caller's groups with their balance in each, their totals and the latest
+// expenses and settlements in a single response.
+func (c *userController) HandleGetDashboard(w http.ResponseWriter, r *http.Request) {
+	user, found := planetscale.UserFromContext(r.Context())
+	if !found {
+		Error(w, r, planetscale.Errorf(planetscale.ENOTFOUND, "user context not set"))
+		return
+	}
+
+	dashboard, err := c.services.Balance.GetDashboard(r.Context(), user.UserID, r.URL.Query().Get("currency"))
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(dashboard); err != nil {
+		Error(w, r, err)
+		return
+	}
+}
+
+// HandleGetNotificationPreferences handles the GET /me/notifications endpoint.
+func (c *userController) HandleGetNotificationPreferences(w http.ResponseWriter, r *http.Request) {
+	user, found := planetscale.UserFromContext(r.Context())
+	if !found {
+		Error(w, r, planetscale.Errorf(planetscale.ENOTFOUND, "user context not set"))
+		return
+	}
+
+	var preferences *planetscale.NotificationPreferences
+	getPreferencesFunc := func(tx *sql.Tx) error {
+		var err error
+		preferences, err = c.repos.NotificationPreferences.Get(tx, user.UserID)
+		return err
+	}
+
+	err := c.tm.ExecuteInTx(r.Context(), getPreferencesFunc)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(preferences); err != nil {
+		Error(w, r, err)
+		return
+	}
+}
+
+// HandlePatchNotificationPreferences handles the PATCH /me/notifications
+// endpoint. It turns the emails the caller gets on or off.
+func (c *userController) HandlePatchNotificationPreferences(w http.ResponseWriter, r *http.Request) {
+	user, found := planetscale.UserFromContext(r.Context())
+	if !found {
+		Error(w, r, planetscale.Errorf(planetscale.ENOTFOUND, "user context not set"))
+		return
+	}
+
+	var update planetscale.NotificationPreferencesUpdate
+	err := ReceiveJson(w, r, &update)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	var preferences *planetscale.NotificationPreferences
+	updatePreferencesFunc := func(tx *sql.Tx) error {
+		var err error
+		preferences, err = c.repos.NotificationPreferences.Update(tx, user.UserID, &update)
+		return err
+	}
+
+	err = c.tm.ExecuteInTx(r.Context(), updatePreferencesFunc)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(preferences); err != nil {
+		Error(w, r, err)
+		return
+	}
+}
+
+// HandlePutUser handles the Clerk user webhooks. svix delivers messages at
+// least once, so each message is processed only the first time it arrives.
 func (c *userController) HandlePutUser(w http.ResponseWriter, r *http.Request) {
 	payload, err := io.ReadAll(r.Body)
 	if err != nil {
@@ -43,27 +159,196 @@ func (c *userController) HandlePutUser(w http.ResponseWriter, r *http.Request) {
 		Error(w, r, err)
 		return
 	}
+	if response.Data.Id == "" {
+		Error(w, r, planetscale.Errorf(planetscale.EINVALID, "user id required"))
+		return
+	}
 
-	// convert the clerk payload to a user
-	user := planetscale.User{
-		UserID: response.Data.Id,
-		Email:  response.Data.EmailAddresses[0].EmailAddress,
-		Name:   response.Data.FirstName + " " + response.Data.LastName,
+	var audits []*planetscale.AuditEvent
+	message := &planetscale.ClerkMessage{
+		MessageID: r.Header.Get("svix-id"),
+		Type:      response.Type,
 	}
+	putUserFunc := func(tx *sql.Tx) error {
+		err := c.repos.ClerkMessage.Create(tx, message)
+		if planetscale.ErrorCode(err) == planetscale.ECONFLICT {
+			slog.Info("skipped processed clerk message", slog.String("id", message.MessageID))
+			return nil
+		} else if err != nil {
+			return err
+		}
 
-	upsertUserFunc := func(tx *sql.Tx) error {
-		err := c.repos.User.Upsert(tx, &user)
-		if err != nil {
+		switch response.Type {
+		case planetscale.ClerkUserCreated, planetscale.ClerkUserUpdated:
+			audits, err = c.saveUser(tx, &response.Data)
 			return err
+		case planetscale.ClerkUserDeleted:
+			audits, err = c.deleteUser(tx, response.Data.Id)
+			return err
+		default:
+			slog.Info("ignored clerk message", slog.String("id", message.MessageID), slog.String("type", response.Type))
+			return nil
 		}
-		return nil
 	}
 
-	err = c.tm.ExecuteInTx(r.Context(), upsertUserFunc)
+	err = c.tm.ExecuteInTx(r.Context(), putUserFunc)
 	if err != nil {
 		Error(w, r, err)
 		return
 	}
+	planetscale.Publish(c.services.Events, audits...)
 
 	w.WriteHeader(http.StatusOK)
 }
+
+// saveUser stores the user's profile and joins the groups they were invited
+// to by email. Updates join them too, for emails verified after signing up.
+// It returns the audits of the groups joined.
+func (c *userController) saveUser(tx *sql.Tx, data *planetscale.ClerkUserPayload) ([]*planetscale.AuditEvent, error) {
+	user := data.User()
+	err := c.repos.User.Upsert(tx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	var audits []*planetscale.AuditEvent
+	for _, address := range data.EmailAddresses {
+		if address.Verification.Status != "verified" {
+			continue
+		}
+		invites, err := c.repos.GroupInvite.Find(tx, planetscale.GroupInviteFilter{
+			Email:   strings.ToLower(address.EmailAddress),
+			Pending: true,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		invitee := *user
+		invitee.Email = address.EmailAddress
+		for _, invite := range invites {
+			_, audit, err := acceptInvite(tx, c.repos, invite, &invitee)
+			if code := planetscale.ErrorCode(err); code == planetscale.ECONFLICT || code == planetscale.ENOTFOUND {
+				// already a member, or the group is gone, the invite is
+				// simply left unused
+				continue
+			} else if err != nil {
+				return nil, err
+			}
+			audits = append(audits, audit)
+		}
+	}
+	return audits, nil
+}
+
+// deleteUser anonymizes the user and has them leave their groups. Their
+// expenses and settlements stay, so the balances of everyone else are
+// unchanged. Groups they owned are handed to the next in line, so that every
+// group keeps its owner. It returns the audits of the groups left.
+func (c *userController) deleteUser(tx *sql.Tx, userID string) ([]*planetscale.AuditEvent, error) {
+	err := c.repos.User.Delete(tx, userID)
+	if planetscale.ErrorCode(err) == planetscale.ENOTFOUND {
+		// never synced, or already deleted
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	groups, err := c.repos.ExpenseGroup.ListAllForUser(tx, userID)
+	if err != nil {
+		return nil, err
+	}
+	var audits []*planetscale.AuditEvent
+	for _, group := range groups {
+		groupID := group.ExpenseGroupID
+		member, err := c.repos.GroupMember.Get(tx, groupID, userID)
+		if err != nil {
+			return nil, err
+		}
+		if member.Role == planetscale.RoleOwner {
+			audit, err := c.handOver(tx, groupID, userID)
+			if err != nil {
+				return nil, err
+			}
+			if audit != nil {
+				audits = append(audits, audit)
+			}
+		}
+		err = c.repos.GroupMember.Delete(tx, groupID, userID)
+		if err != nil {
+			return nil, err
+		}
+		audit := &planetscale.AuditEvent{
+			GroupID:    &groupID,
+			EntityType: planetscale.AuditEntityGroupMember,
+			EntityID:   userID,
+			Action:     planetscale.AuditActionDelete,
+			ActorID:    userID,
+			Before:     member,
+		}
+		err = c.repos.AuditEvent.Create(tx, audit)
+		if err != nil {
+			return nil, err
+		}
+		err = c.repos.Activity.Create(tx, planetscale.NewActivity(audit))
+		if err != nil {
+			return nil, err
+		}
+		audits = append(audits, audit)
+	}
+	return audits, nil
+}
+
+// handOver makes the highest ranked member of the group besides ownerID its
+// owner, the one who joined first among equals. It returns the audit of the
+// new owner, or nil when nobody else is left.
+func (c *userController) handOver(tx *sql.Tx, groupID int64, ownerID string) (*planetscale.AuditEvent, error) {
+	members, err := c.repos.GroupMember.Find(tx, planetscale.GroupMemberFilter{
+		GroupID: groupID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var next *planetscale.GroupMember
+	for _, member := range members {
+		if member.UserID == ownerID {
+			continue
+		}
+		if next == nil || member.Outranks(next.Role) ||
+			(member.Role == next.Role && (member.JoinedAt.Before(next.JoinedAt) ||
+				(member.JoinedAt.Equal(next.JoinedAt) && member.UserID < next.UserID))) {
+			next = member
+		}
+	}
+	if next == nil {
+		return nil, nil
+	}
+
+	role := planetscale.RoleOwner
+	after, err := c.repos.GroupMember.Update(tx, groupID, next.UserID, &planetscale.GroupMemberUpdate{
+		Role: &role,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	audit := &planetscale.AuditEvent{
+		GroupID:    &groupID,
+		EntityType: planetscale.AuditEntityGroupMember,
+		EntityID:   after.UserID,
+		Action:     planetscale.AuditActionUpdate,
+		ActorID:    ownerID,
+		Before:     next,
+		After:      after,
+	}
+	err = c.repos.AuditEvent.Create(tx, audit)
+	if err != nil {
+		return nil, err
+	}
+	err = c.repos.Activity.Create(tx, planetscale.NewActivity(audit))
+	if err != nil {
+		return nil, err
+	}
+	return audit, nil
+}
@@ -0,0 +1,426 @@
+package http
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	planetscale "github.com/harshav17/planet_scale"
+	db_mock "github.com/harshav17/planet_scale/mock/db"
+	service_mock "github.com/harshav17/planet_scale/mock/service"
+	svix "github.com/svix/svix-webhooks/go"
+)
+
+func TestHandlePutUser_All(t *testing.T) {
+	server := MustOpenServer(t)
+	defer MustCloseServer(t, server.Server)
+
+	users := map[string]*planetscale.User{}
+	server.repos.User = db_mock.UserRepo{
+		UpsertFn: func(tx *sql.Tx, user *planetscale.User) error {
+			users[user.UserID] = user
+			return nil
+		},
+		DeleteFn: func(tx *sql.Tx, userID string) error {
+			user, ok := users[userID]
+			if !ok {
+				return planetscale.Errorf(planetscale.ENOTFOUND, "no user found with ID %s", userID)
+			}
+			user.Email = ""
+			user.Name = planetscale.DeletedUserName
+			return nil
+		},
+	}
+	processed := map[string]bool{}
+	server.repos.ClerkMessage = db_mock.ClerkMessageRepo{
+		CreateFn: func(tx *sql.Tx, message *planetscale.ClerkMessage) error {
+			if processed[message.MessageID] {
+				return planetscale.Errorf(planetscale.ECONFLICT, "clerk message %s was already processed", message.MessageID)
+			}
+			processed[message.MessageID] = true
+			return nil
+		},
+	}
+	server.repos.GroupInvite = &db_mock.GroupInviteRepo{
+		FindFn: func(tx *sql.Tx, filter planetscale.GroupInviteFilter) ([]*planetscale.GroupInvite, error) {
+			return nil, nil
+		},
+	}
+
+	// the test server verifies with an empty secret
+	wh := &svix.Webhook{}
+	serve := func(t *testing.T, msgID string, body any) *httptest.ResponseRecorder {
+		t.Helper()
+		payload, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		now := time.Now()
+		signature, err := wh.Sign(msgID, now, payload)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req, err := http.NewRequest("POST", "/wh/put_user", bytes.NewReader(payload))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("svix-id", msgID)
+		req.Header.Set("svix-timestamp", strconv.FormatInt(now.Unix(), 10))
+		req.Header.Set("svix-signature", signature)
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(server.router.ServeHTTP)
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+	clerkUser := func(eventType string, data planetscale.ClerkUserPayload) planetscale.ClerkPayload[planetscale.ClerkUserPayload] {
+		return planetscale.ClerkPayload[planetscale.ClerkUserPayload]{
+			Data:   data,
+			Object: "event",
+			Type:   eventType,
+		}
+	}
+
+	t.Run("user.created", func(t *testing.T) {
+		rr := serve(t, "msg_created", clerkUser(planetscale.ClerkUserCreated, planetscale.ClerkUserPayload{
+			Id:        "user_1",
+			FirstName: "Test",
+			LastName:  "User",
+			EmailAddresses: []planetscale.ClerkEmailAddress{
+				{Id: "idn_secondary", EmailAddress: "old@example.com"},
+				{Id: "idn_primary", EmailAddress: "test@example.com"},
+			},
+			PrimaryEmailAddressID: "idn_primary",
+			ProfileImageURL:       "https://img.clerk.com/user_1",
+		}))
+		if status := rr.Code; status != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d: %s", http.StatusOK, status, rr.Body)
+		}
+
+		user := users["user_1"]
+		if user == nil {
+			t.Fatal("expected the user to be stored")
+		} else if user.Email != "test@example.com" {
+			t.Fatalf("expected the primary email, got %q", user.Email)
+		} else if user.Name != "Test User" {
+			t.Fatalf("expected name Test User, got %q", user.Name)
+		} else if user.ImageURL != "https://img.clerk.com/user_1" {
+			t.Fatalf("expected the profile image, got %q", user.ImageURL)
+		}
+	})
+
+	t.Run("user.updated without email", func(t *testing.T) {
+		rr := serve(t, "msg_updated", clerkUser(planetscale.ClerkUserUpdated, planetscale.ClerkUserPayload{
+			Id:        "user_2",
+			FirstName: "Phone",
+		}))
+		if status := rr.Code; status != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d: %s", http.StatusOK, status, rr.Body)
+		} else if user := users["user_2"]; user == nil || user.Email != "" {
+			t.Fatalf("expected a user without email, got %v", user)
+		}
+	})
+
+	t.Run("redelivered message", func(t *testing.T) {
+		users["user_1"].Name = "Renamed"
+		rr := serve(t, "msg_created", clerkUser(planetscale.ClerkUserCreated, planetscale.ClerkUserPayload{
+			Id:        "user_1",
+			FirstName: "Test",
+		}))
+		if status := rr.Code; status != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d: %s", http.StatusOK, status, rr.Body)
+		} else if users["user_1"].Name != "Renamed" {
+			t.Fatal("expected the message to be skipped")
+		}
+	})
+
+	t.Run("user.deleted", func(t *testing.T) {
+		left := map[int64]bool{}
+		server.repos.ExpenseGroup = &db_mock.ExpenseGroupRepo{
+			ListAllForUserFn: func(tx *sql.Tx, userID string) ([]*planetscale.ExpenseGroup, error) {
+				return []*planetscale.ExpenseGroup{{ExpenseGroupID: 1}, {ExpenseGroupID: 2}}, nil
+			},
+		}
+		server.repos.GroupMember = &db_mock.GroupMemberRepo{
+			GetFn: func(tx *sql.Tx, groupID int64, userID string) (*planetscale.GroupMember, error) {
+				return &planetscale.GroupMember{GroupID: groupID, UserID: userID}, nil
+			},
+			DeleteFn: func(tx *sql.Tx, groupID int64, userID string) error {
+				left[groupID] = true
+				return nil
+			},
+		}
+
+		rr := serve(t, "msg_deleted", clerkUser(planetscale.ClerkUserDeleted, planetscale.ClerkUserPayload{
+			Id:      "user_1",
+			Deleted: true,
+		}))
+		if status := rr.Code; status != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d: %s", http.StatusOK, status, rr.Body)
+		} else if user := users["user_1"]; user.Name != planetscale.DeletedUserName || user.Email != "" {
+			t.Fatalf("expected the user to be anonymized, got %v", user)
+		} else if !left[1] || !left[2] {
+			t.Fatalf("expected the user to leave both groups, got %v", left)
+		}
+
+		// unknown users have nothing to delete
+		rr = serve(t, "msg_deleted_unknown", clerkUser(planetscale.ClerkUserDeleted, planetscale.ClerkUserPayload{
+			Id:      "user_unknown",
+			Deleted: true,
+		}))
+		if status := rr.Code; status != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d: %s", http.StatusOK, status, rr.Body)
+		}
+	})
+
+	t.Run("user.deleted owner", func(t *testing.T) {
+		joined := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		members := []*planetscale.GroupMember{
+			{GroupID: 1, UserID: "user_2", Role: planetscale.RoleOwner, JoinedAt: joined},
+			{GroupID: 1, UserID: "member_early", Role: planetscale.RoleMember, JoinedAt: joined},
+			{GroupID: 1, UserID: "admin_late", Role: planetscale.RoleAdmin, JoinedAt: joined.Add(time.Hour)},
+			{GroupID: 1, UserID: "admin_early", Role: planetscale.RoleAdmin, JoinedAt: joined.Add(time.Minute)},
+		}
+		server.repos.ExpenseGroup = &db_mock.ExpenseGroupRepo{
+			ListAllForUserFn: func(tx *sql.Tx, userID string) ([]*planetscale.ExpenseGroup, error) {
+				return []*planetscale.ExpenseGroup{{ExpenseGroupID: 1}}, nil
+			},
+		}
+		var promoted string
+		server.repos.GroupMember = &db_mock.GroupMemberRepo{
+			GetFn: func(tx *sql.Tx, groupID int64, userID string) (*planetscale.GroupMember, error) {
+				return members[0], nil
+			},
+			FindFn: func(tx *sql.Tx, filter planetscale.GroupMemberFilter) ([]*planetscale.GroupMember, error) {
+				return members, nil
+			},
+			UpdateFn: func(tx *sql.Tx, groupID int64, userID string, update *planetscale.GroupMemberUpdate) (*planetscale.GroupMember, error) {
+				if promoted != "" {
+					t.Fatalf("expected a single promotion, got %s and %s", promoted, userID)
+				} else if update.Role == nil || *update.Role != planetscale.RoleOwner {
+					t.Fatalf("expected the owner role, got %v", update.Role)
+				}
+				promoted = userID
+				return &planetscale.GroupMember{GroupID: groupID, UserID: userID, Role: *update.Role}, nil
+			},
+			DeleteFn: func(tx *sql.Tx, groupID int64, userID string) error {
+				if promoted == "" {
+					t.Fatal("expected the group to be handed over before the owner leaves")
+				}
+				return nil
+			},
+		}
+		var audited []*planetscale.AuditEvent
+		server.repos.AuditEvent = db_mock.AuditEventRepo{
+			CreateFn: func(tx *sql.Tx, event *planetscale.AuditEvent) error {
+				audited = append(audited, event)
+				return nil
+			},
+		}
+
+		rr := serve(t, "msg_deleted_owner", clerkUser(planetscale.ClerkUserDeleted, planetscale.ClerkUserPayload{
+			Id:      "user_2",
+			Deleted: true,
+		}))
+		if status := rr.Code; status != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d: %s", http.StatusOK, status, rr.Body)
+		} else if promoted != "admin_early" {
+			t.Fatalf("expected the earliest admin to become owner, got %q", promoted)
+		} else if len(audited) != 2 {
+			t.Fatalf("expected 2 audits, got %d", len(audited))
+		} else if audited[0].Action != planetscale.AuditActionUpdate || audited[0].EntityID != "admin_early" {
+			t.Fatalf("expected the promotion to be audited first, got %v", audited[0])
+		} else if audited[1].Action != planetscale.AuditActionDelete || audited[1].EntityID != "user_2" {
+			t.Fatalf("expected the owner leaving to be audited, got %v", audited[1])
+		}
+	})
+
+	t.Run("invalid signature", func(t *testing.T) {
+		req, err := http.NewRequest("POST", "/wh/put_user", bytes.NewReader([]byte(`{}`)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("svix-id", "msg_forged")
+		req.Header.Set("svix-timestamp", strconv.FormatInt(time.Now().Unix(), 10))
+		req.Header.Set("svix-signature", "v1,forged")
+
+		rr := httptest.NewRecorder()
+		server.router.ServeHTTP(rr, req)
+		if rr.Code == http.StatusOK {
+			t.Fatal("expected the request to be rejected")
+		} else if processed["msg_forged"] {
+			t.Fatal("expected the message not to be recorded")
+		}
+	})
+}
+
+func TestHandleGetUserBalance(t *testing.T) {
+	server := MustOpenServer(t)
+	defer MustCloseServer(t, server.Server)
+
+	var gotCurrency string
+	server.services.Balance = &service_mock.BalanceService{
+		GetUserBalanceFn: func(userID, currency string) (*planetscale.UserBalance, error) {
+			gotCurrency = currency
+			return &planetscale.UserBalance{
+				UserID:   userID,
+				Currency: "EUR",
+				Amount:   5_00,
+				Owed:     20_00,
+				Owing:    15_00,
+				Friends:  map[string]planetscale.Money{"test-user-id-3": -15_00},
+			}, nil
+		},
+	}
+
+	token := server.buildJWTForTesting(t, "test-user-id")
+	req, err := http.NewRequest("GET", "/me/balances?currency=EUR", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	rr := httptest.NewRecorder()
+	server.router.ServeHTTP(rr, req)
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("expected status code %d, got %d", http.StatusOK, status)
+	}
+
+	var got planetscale.UserBalance
+	err = json.Unmarshal(rr.Body.Bytes(), &got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotCurrency != "EUR" {
+		t.Errorf("expected currency EUR to be passed on, got %q", gotCurrency)
+	} else if got.UserID != "test-user-id" || got.Amount != 5_00 || got.Friends["test-user-id-3"] != -15_00 {
+		t.Errorf("unexpected balance %+v", got)
+	}
+}
+
+func TestHandleGetDashboard(t *testing.T) {
+	server := MustOpenServer(t)
+	defer MustCloseServer(t, server.Server)
+
+	server.services.Balance = &service_mock.BalanceService{
+		GetDashboardFn: func(userID, currency string) (*planetscale.Dashboard, error) {
+			return &planetscale.Dashboard{
+				UserID:   userID,
+				Currency: "USD",
+				Owed:     15_00,
+				Groups: []*planetscale.DashboardGroup{
+					{ExpenseGroup: &planetscale.ExpenseGroup{ExpenseGroupID: 1, GroupName: "test group"}, Balance: 15_00},
+				},
+				RecentExpenses: []*planetscale.Expense{{ExpenseID: 1}},
+			}, nil
+		},
+	}
+
+	token := server.buildJWTForTesting(t, "test-user-id")
+	req, err := http.NewRequest("GET", "/me/dashboard", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	rr := httptest.NewRecorder()
+	server.router.ServeHTTP(rr, req)
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("expected status code %d, got %d", http.StatusOK, status)
+	}
+
+	// groups are flat, with their balance next to their fields
+	var got struct {
+		UserID string `json:"user_id"`
+		Groups []struct {
+			GroupID   int64             `json:"group_id"`
+			GroupName string            `json:"group_name"`
+			Balance   planetscale.Money `json:"balance"`
+		} `json:"groups"`
+		RecentExpenses []*planetscale.Expense `json:"recent_expenses"`
+	}
+	err = json.Unmarshal(rr.Body.Bytes(), &got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.UserID != "test-user-id" {
+		t.Errorf("expected user id test-user-id, got %s", got.UserID)
+	} else if len(got.Groups) != 1 || got.Groups[0].GroupID != 1 || got.Groups[0].GroupName != "test group" || got.Groups[0].Balance != 15_00 {
+		t.Errorf("unexpected groups %+v", got.Groups)
+	} else if len(got.RecentExpenses) != 1 {
+		t.Errorf("expected 1 recent expense, got %d", len(got.RecentExpenses))
+	}
+}
+
+func TestHandleNotificationPreferences(t *testing.T) {
+	server := MustOpenServer(t)
+	defer MustCloseServer(t, server.Server)
+
+	preferences := map[string]*planetscale.NotificationPreferences{}
+	server.repos.NotificationPreferences = &db_mock.NotificationPreferencesRepo{
+		GetFn: func(tx *sql.Tx, userID string) (*planetscale.NotificationPreferences, error) {
+			if p, ok := preferences[userID]; ok {
+				return p, nil
+			}
+			return &planetscale.NotificationPreferences{UserID: userID, ExpenseAdded: true, Digest: true}, nil
+		},
+		UpdateFn: func(tx *sql.Tx, userID string, update *planetscale.NotificationPreferencesUpdate) (*planetscale.NotificationPreferences, error) {
+			p := &planetscale.NotificationPreferences{UserID: userID, ExpenseAdded: true, Digest: true}
+			if update.ExpenseAdded != nil {
+				p.ExpenseAdded = *update.ExpenseAdded
+			}
+			if update.Digest != nil {
+				p.Digest = *update.Digest
+			}
+			preferences[userID] = p
+			return p, nil
+		},
+	}
+
+	serve := func(t *testing.T, method string, body string) planetscale.NotificationPreferences {
+		t.Helper()
+		req, err := http.NewRequest(method, "/me/notifications", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+server.buildJWTForTesting(t, "test-user-id"))
+
+		rr := httptest.NewRecorder()
+		server.router.ServeHTTP(rr, req)
+		if status := rr.Code; status != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d: %s", http.StatusOK, status, rr.Body)
+		}
+		var got planetscale.NotificationPreferences
+		if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+			t.Fatal(err)
+		}
+		return got
+	}
+
+	t.Run("defaults", func(t *testing.T) {
+		got := serve(t, "GET", "")
+		if !got.ExpenseAdded || !got.Digest {
+			t.Fatalf("expected every email by default, got %+v", got)
+		}
+	})
+
+	t.Run("turn the digest off", func(t *testing.T) {
+		serve(t, "PATCH", `{"digest": false}`)
+
+		got := serve(t, "GET", "")
+		if !got.ExpenseAdded || got.Digest {
+			t.Fatalf("expected only the digest to be off, got %+v", got)
+		}
+	})
+}
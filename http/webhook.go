@@ -0,0 +1,386 @@
+package http
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	planetscale "github.com/harshav17/planet_scale"
+)
+
+// webhookDeliveriesLimit is how many of the latest deliveries are listed.
+const webhookDeliveriesLimit = 50
+
+type webhookController struct {
+	repos    *planetscale.RepoProvider
+	services *planetscale.ServiceProvider
+	tm       planetscale.TransactionManager
+}
+
+func NewWebhookController(repos *planetscale.RepoProvider, services *planetscale.ServiceProvider, tm planetscale.TransactionManager) *webhookController {
+	return &webhookController{
+		repos:    repos,
+		services: services,
+		tm:       tm,
+	}
+}
+
+type findWebhookSubscriptionsResponse struct {
+	Subscriptions []*planetscale.WebhookSubscription `json:"subscriptions"`
+	N             int                                `json:"n"`
+}
+
+// HandleGetGroupWebhooks handles the GET /groups/{groupID}/webhooks endpoint.
+func (c *webhookController) HandleGetGroupWebhooks(w http.ResponseWriter, r *http.Request) {
+	user, found := planetscale.UserFromContext(r.Context())
+	if !found {
+		Error(w, r, planetscale.Errorf(planetscale.ENOTFOUND, "user context not set"))
+		return
+	}
+
+	group32, err := strconv.Atoi(chi.URLParam(r, "groupID"))
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+	groupID := int64(group32)
+
+	var subscriptions []*planetscale.WebhookSubscription
+	getWebhooksFunc := func(tx *sql.Tx) error {
+		_, err := planetscale.CheckPermission(tx, c.repos, groupID, user.UserID, planetscale.PermissionManage)
+		if err != nil {
+			return err
+		}
+
+		subscriptions, err = c.repos.WebhookSubscription.Find(tx, planetscale.WebhookSubscriptionFilter{
+			GroupID: groupID,
+		})
+		return err
+	}
+
+	err = c.tm.ExecuteInTx(r.Context(), getWebhooksFunc)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(findWebhookSubscriptionsResponse{
+		Subscriptions: subscriptions,
+		N:             len(subscriptions),
+	}); err != nil {
+		Error(w, r, err)
+		return
+	}
+}
+
+// HandlePostGroupWebhook handles the POST /groups/{groupID}/webhooks endpoint,
+// which sends the group's expense and settlement changes to a URL.
+func (c *webhookController) HandlePostGroupWebhook(w http.ResponseWriter, r *http.Request) {
+	user, found := planetscale.UserFromContext(r.Context())
+	if !found {
+		Error(w, r, planetscale.Errorf(planetscale.ENOTFOUND, "user context not set"))
+		return
+	}
+
+	group32, err := strconv.Atoi(chi.URLParam(r, "groupID"))
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+	groupID := int64(group32)
+
+	subscription, err := receiveWebhookSubscription(w, r, user.UserID)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+	subscription.GroupID = &groupID
+
+	var audit *planetscale.AuditEvent
+	createWebhookFunc := func(tx *sql.Tx) error {
+		_, err := planetscale.CheckPermission(tx, c.repos, groupID, user.UserID, planetscale.PermissionManage)
+		if err != nil {
+			return err
+		}
+		audit, err = c.createSubscription(tx, subscription)
+		return err
+	}
+
+	err = c.tm.ExecuteInTx(r.Context(), createWebhookFunc)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+	planetscale.Publish(c.services.Events, audit)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(subscription); err != nil {
+		Error(w, r, err)
+		return
+	}
+}
+
+// HandleGetUserWebhooks handles the GET /webhooks endpoint, which lists the
+// caller's subscriptions to all of their groups.
+func (c *webhookController) HandleGetUserWebhooks(w http.ResponseWriter, r *http.Request) {
+	user, found := planetscale.UserFromContext(r.Context())
+	if !found {
+		Error(w, r, planetscale.Errorf(planetscale.ENOTFOUND, "user context not set"))
+		return
+	}
+
+	var subscriptions []*planetscale.WebhookSubscription
+	getWebhooksFunc := func(tx *sql.Tx) error {
+		var err error
+		subscriptions, err = c.repos.WebhookSubscription.Find(tx, planetscale.WebhookSubscriptionFilter{
+			UserID:   user.UserID,
+			UserOnly: true,
+		})
+		return err
+	}
+
+	err := c.tm.ExecuteInTx(r.Context(), getWebhooksFunc)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(findWebhookSubscriptionsResponse{
+		Subscriptions: subscriptions,
+		N:             len(subscriptions),
+	}); err != nil {
+		Error(w, r, err)
+		return
+	}
+}
+
+// HandlePostUserWebhook handles the POST /webhooks endpoint. The subscription
+// gets the events of every group the caller is a member of at the time, and
+// of the direct expenses they are involved in.
+func (c *webhookController) HandlePostUserWebhook(w http.ResponseWriter, r *http.Request) {
+	user, found := planetscale.UserFromContext(r.Context())
+	if !found {
+		Error(w, r, planetscale.Errorf(planetscale.ENOTFOUND, "user context not set"))
+		return
+	}
+
+	subscription, err := receiveWebhookSubscription(w, r, user.UserID)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	createWebhookFunc := func(tx *sql.Tx) error {
+		// outside of any group, so there is nothing to publish
+		_, err := c.createSubscription(tx, subscription)
+		return err
+	}
+
+	err = c.tm.ExecuteInTx(r.Context(), createWebhookFunc)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(subscription); err != nil {
+		Error(w, r, err)
+		return
+	}
+}
+
+// HandleDeleteWebhook handles the DELETE /webhooks/{subscriptionID} endpoint.
+func (c *webhookController) HandleDeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	user, found := planetscale.UserFromContext(r.Context())
+	if !found {
+		Error(w, r, planetscale.Errorf(planetscale.ENOTFOUND, "user context not set"))
+		return
+	}
+
+	subscription32, err := strconv.Atoi(chi.URLParam(r, "subscriptionID"))
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+	subscriptionID := int64(subscription32)
+
+	var audit *planetscale.AuditEvent
+	deleteWebhookFunc := func(tx *sql.Tx) error {
+		subscription, err := c.getSubscription(tx, subscriptionID, user.UserID)
+		if err != nil {
+			return err
+		}
+
+		err = c.repos.WebhookSubscription.Delete(tx, subscriptionID)
+		if err != nil {
+			return err
+		}
+
+		audit = &planetscale.AuditEvent{
+			GroupID:    subscription.GroupID,
+			EntityType: planetscale.AuditEntityWebhookSubscription,
+			EntityID:   strconv.FormatInt(subscriptionID, 10),
+			Action:     planetscale.AuditActionDelete,
+			ActorID:    user.UserID,
+			Before:     subscription,
+		}
+		return c.repos.AuditEvent.Create(tx, audit)
+	}
+
+	err = c.tm.ExecuteInTx(r.Context(), deleteWebhookFunc)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+	planetscale.Publish(c.services.Events, audit)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type findWebhookDeliveriesResponse struct {
+	Deliveries []*planetscale.WebhookDelivery `json:"deliveries"`
+	N          int                            `json:"n"`
+}
+
+// HandleGetWebhookDeliveries handles the GET /webhooks/{subscriptionID}/deliveries
+// endpoint. It lists the latest deliveries along with each of their attempts.
+func (c *webhookController) HandleGetWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	user, found := planetscale.UserFromContext(r.Context())
+	if !found {
+		Error(w, r, planetscale.Errorf(planetscale.ENOTFOUND, "user context not set"))
+		return
+	}
+
+	subscription32, err := strconv.Atoi(chi.URLParam(r, "subscriptionID"))
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+	subscriptionID := int64(subscription32)
+
+	var deliveries []*planetscale.WebhookDelivery
+	getDeliveriesFunc := func(tx *sql.Tx) error {
+		_, err := c.getSubscription(tx, subscriptionID, user.UserID)
+		if err != nil {
+			return err
+		}
+
+		deliveries, err = c.repos.WebhookDelivery.Find(tx, planetscale.WebhookDeliveryFilter{
+			SubscriptionID: subscriptionID,
+			Limit:          webhookDeliveriesLimit,
+		})
+		if err != nil {
+			return err
+		}
+		for _, delivery := range deliveries {
+			delivery.AttemptLog, err = c.repos.WebhookDelivery.FindAttempts(tx, delivery.DeliveryID)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	err = c.tm.ExecuteInTx(r.Context(), getDeliveriesFunc)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(findWebhookDeliveriesResponse{
+		Deliveries: deliveries,
+		N:          len(deliveries),
+	}); err != nil {
+		Error(w, r, err)
+		return
+	}
+}
+
+// getSubscription loads a subscription the user may manage: their own, or one
+// of a group they administer.
+func (c *webhookController) getSubscription(tx *sql.Tx, subscriptionID int64, userID string) (*planetscale.WebhookSubscription, error) {
+	subscription, err := c.repos.WebhookSubscription.Get(tx, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if subscription.GroupID != nil {
+		_, err = planetscale.CheckPermission(tx, c.repos, *subscription.GroupID, userID, planetscale.PermissionManage)
+		if err != nil {
+			return nil, err
+		}
+	} else if subscription.UserID != userID {
+		// hidden like groups are from outsiders
+		return nil, planetscale.Errorf(planetscale.ENOTFOUND, "no webhook subscription found with ID %d", subscriptionID)
+	}
+	return subscription, nil
+}
+
+func (c *webhookController) createSubscription(tx *sql.Tx, subscription *planetscale.WebhookSubscription) (*planetscale.AuditEvent, error) {
+	err := c.repos.WebhookSubscription.Create(tx, subscription)
+	if err != nil {
+		return nil, err
+	}
+
+	// the secret stays out of the audit log, and out of the published events
+	after := *subscription
+	after.Secret = ""
+	audit := &planetscale.AuditEvent{
+		GroupID:    subscription.GroupID,
+		EntityType: planetscale.AuditEntityWebhookSubscription,
+		EntityID:   strconv.FormatInt(subscription.SubscriptionID, 10),
+		Action:     planetscale.AuditActionCreate,
+		ActorID:    subscription.UserID,
+		After:      &after,
+	}
+	err = c.repos.AuditEvent.Create(tx, audit)
+	if err != nil {
+		return nil, err
+	}
+	return audit, nil
+}
+
+// receiveWebhookSubscription reads the subscription in the request body and
+// gives it a fresh secret.
+func receiveWebhookSubscription(w http.ResponseWriter, r *http.Request, userID string) (*planetscale.WebhookSubscription, error) {
+	var body planetscale.WebhookSubscription
+	err := ReceiveJson(w, r, &body)
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := url.Parse(body.URL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return nil, planetscale.Errorf(planetscale.EINVALID, "invalid url %q", body.URL)
+	}
+
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return nil, err
+	}
+	return &planetscale.WebhookSubscription{
+		UserID: userID,
+		URL:    u.String(),
+		Secret: secret,
+	}, nil
+}
+
+// newWebhookSecret returns a random secret in the format svix uses, so that
+// subscribers can verify payloads with any svix library.
+func newWebhookSecret() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + base64.StdEncoding.EncodeToString(b), nil
+}
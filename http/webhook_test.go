@@ -0,0 +1,142 @@
+package http
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	planetscale "github.com/harshav17/planet_scale"
+	db_mock "github.com/harshav17/planet_scale/mock/db"
+)
+
+func TestHandleWebhooks_All(t *testing.T) {
+	server := MustOpenServer(t)
+	defer MustCloseServer(t, server.Server)
+
+	server.repos.GroupMember = mockGroupMembers(map[string]string{
+		"owner":  planetscale.RoleOwner,
+		"admin":  planetscale.RoleAdmin,
+		"member": planetscale.RoleMember,
+	})
+	subscriptions := map[int64]*planetscale.WebhookSubscription{}
+	server.repos.WebhookSubscription = &db_mock.WebhookSubscriptionRepo{
+		CreateFn: func(tx *sql.Tx, subscription *planetscale.WebhookSubscription) error {
+			subscription.SubscriptionID = int64(len(subscriptions) + 1)
+			stored := *subscription
+			subscriptions[subscription.SubscriptionID] = &stored
+			return nil
+		},
+		GetFn: func(tx *sql.Tx, subscriptionID int64) (*planetscale.WebhookSubscription, error) {
+			subscription, ok := subscriptions[subscriptionID]
+			if !ok {
+				return nil, planetscale.Errorf(planetscale.ENOTFOUND, "no webhook subscription found with ID %d", subscriptionID)
+			}
+			return subscription, nil
+		},
+		DeleteFn: func(tx *sql.Tx, subscriptionID int64) error {
+			delete(subscriptions, subscriptionID)
+			return nil
+		},
+	}
+
+	serve := func(t *testing.T, userID, method, url string, body any) *httptest.ResponseRecorder {
+		t.Helper()
+		b, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req, err := http.NewRequest(method, url, bytes.NewReader(b))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+server.buildJWTForTesting(t, userID))
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(server.router.ServeHTTP)
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("POST /groups/1/webhooks", func(t *testing.T) {
+		t.Run("admin subscribes", func(t *testing.T) {
+			rr := serve(t, "admin", "POST", "/groups/1/webhooks", planetscale.WebhookSubscription{URL: "https://example.com/hook"})
+			if status := rr.Code; status != http.StatusCreated {
+				t.Fatalf("expected status code %d, got %d: %s", http.StatusCreated, status, rr.Body)
+			}
+
+			var got planetscale.WebhookSubscription
+			if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasPrefix(got.Secret, "whsec_") {
+				t.Fatalf("expected a svix secret, got %q", got.Secret)
+			} else if got.GroupID == nil || *got.GroupID != 1 {
+				t.Fatalf("expected group 1, got %v", got.GroupID)
+			} else if got.UserID != "admin" {
+				t.Fatalf("expected user admin, got %s", got.UserID)
+			}
+		})
+
+		t.Run("members cannot subscribe", func(t *testing.T) {
+			rr := serve(t, "member", "POST", "/groups/1/webhooks", planetscale.WebhookSubscription{URL: "https://example.com/hook"})
+			if status := rr.Code; status != http.StatusForbidden {
+				t.Fatalf("expected status code %d, got %d", http.StatusForbidden, status)
+			}
+		})
+
+		t.Run("invalid url", func(t *testing.T) {
+			for _, url := range []string{"", "example.com/hook", "ftp://example.com/hook"} {
+				rr := serve(t, "owner", "POST", "/groups/1/webhooks", planetscale.WebhookSubscription{URL: url})
+				if status := rr.Code; status != http.StatusBadRequest {
+					t.Fatalf("%q: expected status code %d, got %d", url, http.StatusBadRequest, status)
+				}
+			}
+		})
+	})
+
+	t.Run("DELETE /webhooks/1", func(t *testing.T) {
+		t.Run("others cannot delete a user subscription", func(t *testing.T) {
+			rr := serve(t, "member", "POST", "/webhooks", planetscale.WebhookSubscription{URL: "https://example.com/mine"})
+			if status := rr.Code; status != http.StatusCreated {
+				t.Fatalf("expected status code %d, got %d: %s", http.StatusCreated, status, rr.Body)
+			}
+			var got planetscale.WebhookSubscription
+			if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+			url := "/webhooks/" + strconv.FormatInt(got.SubscriptionID, 10)
+
+			rr = serve(t, "owner", "DELETE", url, nil)
+			if status := rr.Code; status != http.StatusNotFound {
+				t.Fatalf("expected status code %d, got %d", http.StatusNotFound, status)
+			}
+
+			rr = serve(t, "member", "DELETE", url, nil)
+			if status := rr.Code; status != http.StatusNoContent {
+				t.Fatalf("expected status code %d, got %d: %s", http.StatusNoContent, status, rr.Body)
+			} else if _, ok := subscriptions[got.SubscriptionID]; ok {
+				t.Fatal("expected the subscription to be deleted")
+			}
+		})
+
+		t.Run("members cannot delete a group subscription", func(t *testing.T) {
+			rr := serve(t, "owner", "POST", "/groups/1/webhooks", planetscale.WebhookSubscription{URL: "https://example.com/group"})
+			var got planetscale.WebhookSubscription
+			if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+
+			rr = serve(t, "member", "DELETE", "/webhooks/"+strconv.FormatInt(got.SubscriptionID, 10), nil)
+			if status := rr.Code; status != http.StatusForbidden {
+				t.Fatalf("expected status code %d, got %d", http.StatusForbidden, status)
+			}
+		})
+	})
+}
@@ -0,0 +1,65 @@
+package planetscale
+
+import (
+	"database/sql"
+	"net/http"
+	"time"
+)
+
+// InviteTTL is how long an invite can be accepted for.
+const InviteTTL = 7 * 24 * time.Hour
+
+type (
+	// GroupInvite lets someone who is not a member yet join a group. Invites
+	// sent to an email can be accepted once, by the user with that email, and
+	// are accepted automatically when that user signs up. Invites without an
+	// email are shareable links anyone can accept until they expire.
+	GroupInvite struct {
+		InviteID   int64     `json:"invite_id"`
+		GroupID    int64     `json:"group_id"`
+		Email      *string   `json:"email"`
+		Role       string    `json:"role"`
+		CreatedBy  string    `json:"created_by"`
+		CreatedAt  time.Time `json:"created_at"`
+		ExpiresAt  time.Time `json:"expires_at"`
+		AcceptedBy *string   `json:"accepted_by"`
+		AcceptedAt time.Time `json:"accepted_at"`
+
+		// Token is only set on invites that were just created, it is not
+		// stored and cannot be recovered.
+		Token string `json:"token,omitempty"`
+	}
+
+	GroupInviteRepo interface {
+		Get(tx *sql.Tx, inviteID int64) (*GroupInvite, error)
+		Create(tx *sql.Tx, invite *GroupInvite) error
+		Accept(tx *sql.Tx, inviteID int64, userID string) error
+		Find(tx *sql.Tx, filter GroupInviteFilter) ([]*GroupInvite, error)
+	}
+
+	GroupInviteFilter struct {
+		GroupID int64
+		Email   string
+		// Pending only finds invites that were neither accepted nor expired.
+		Pending bool
+	}
+
+	// InviteTokenService signs invites so that they can be shared as links
+	// without storing a secret for each of them.
+	InviteTokenService interface {
+		Sign(invite *GroupInvite) (string, error)
+		// Verify fails with EINVALID when the token was tampered with or has
+		// expired, and returns the ID of the invite otherwise.
+		Verify(token string) (int64, error)
+	}
+
+	GroupInviteController interface {
+		HandlePostGroupInvite(w http.ResponseWriter, r *http.Request)
+		HandleAcceptInvite(w http.ResponseWriter, r *http.Request)
+	}
+)
+
+// Expired reports whether the invite can no longer be accepted at now.
+func (i *GroupInvite) Expired(now time.Time) bool {
+	return !now.Before(i.ExpiresAt)
+}
@@ -7,13 +7,13 @@ import (
 
 type (
 	Item struct {
-		ItemID    int64   `json:"item_id"`
-		Name      string  `json:"name"`
-		Price     float64 `json:"price"`
-		Quantity  int64   `json:"quantity"`
-		ExpenseID int64   `json:"expense_id"`
+		ItemID    int64  `json:"item_id"`
+		Name      string `json:"name"`
+		Price     Money  `json:"price"`
+		Quantity  int64  `json:"quantity"`
+		ExpenseID int64  `json:"expense_id"`
 
-		Splits []*ItemSplitNU `json:"splits"`
+		Splits []*ItemSplit `json:"splits"`
 	}
 
 	ItemRepo interface {
@@ -25,16 +25,27 @@ type (
 	}
 
 	ItemFilter struct {
-		ExpenseID int64 `json:"expense_id"`
+		ExpenseID  int64   `json:"expense_id"`
+		ExpenseIDs []int64 `json:"expense_ids"`
 	}
 
 	ItemUpdate struct {
-		Name     *string  `json:"name"`
-		Price    *float64 `json:"price"`
-		Quantity *int64   `json:"quantity"`
+		Name     *string `json:"name"`
+		Price    *Money  `json:"price"`
+		Quantity *int64  `json:"quantity"`
 	}
 
 	ItemController interface {
 		HandlePostItem(w http.ResponseWriter, r *http.Request)
+		HandleGetItem(w http.ResponseWriter, r *http.Request)
+		HandlePatchItem(w http.ResponseWriter, r *http.Request)
+		HandleDeleteItem(w http.ResponseWriter, r *http.Request)
+		HandleGetExpenseItems(w http.ResponseWriter, r *http.Request)
+		HandleGetItemSplits(w http.ResponseWriter, r *http.Request)
+		HandlePostItemSplit(w http.ResponseWriter, r *http.Request)
+		HandlePatchItemSplit(w http.ResponseWriter, r *http.Request)
+		HandleDeleteItemSplit(w http.ResponseWriter, r *http.Request)
+		HandleClaimItemSplit(w http.ResponseWriter, r *http.Request)
+		HandlePostReceipt(w http.ResponseWriter, r *http.Request)
 	}
 )
@@ -3,11 +3,14 @@ package planetscale
 import "database/sql"
 
 type (
+	// ItemSplit is the part of an item one consumer had. It is held either by
+	// a user or by a named guest placeholder, which a user can claim later on.
 	ItemSplit struct {
 		ItemSplitID int64   `json:"item_split_id"`
 		ItemID      int64   `json:"item_id"`
-		UserID      string  `json:"user_id"`
-		Amount      float64 `json:"amount"`
+		UserID      *string `json:"user_id"`
+		GuestName   *string `json:"guest_name"`
+		Amount      Money   `json:"amount"`
 	}
 
 	ItemSplitRepo interface {
@@ -16,13 +19,27 @@ type (
 		Update(tx *sql.Tx, itemSplitID int64, itemSplit *ItemSplitUpdate) (*ItemSplit, error)
 		Delete(tx *sql.Tx, itemSplitID int64) error
 		Find(tx *sql.Tx, filter ItemSplitFilter) ([]*ItemSplit, error)
+		Claim(tx *sql.Tx, itemSplitID int64, userID string) (*ItemSplit, error)
 	}
 
 	ItemSplitUpdate struct {
-		Amount *float64 `json:"amount"`
+		Amount *Money `json:"amount"`
 	}
 
 	ItemSplitFilter struct {
-		ItemID int64 `json:"item_id"`
+		ItemID  int64   `json:"item_id"`
+		ItemIDs []int64 `json:"item_ids"`
 	}
 )
+
+// Validate reports whether the split is held by a user or a guest.
+func (s *ItemSplit) Validate() error {
+	if s.UserID == nil && s.GuestName == nil {
+		return Errorf(EINVALID, "item split needs a user id or a guest name")
+	} else if s.UserID != nil && *s.UserID == "" {
+		return Errorf(EINVALID, "item split user id cannot be empty")
+	} else if s.GuestName != nil && *s.GuestName == "" {
+		return Errorf(EINVALID, "item split guest name cannot be empty")
+	}
+	return nil
+}
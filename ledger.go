@@ -0,0 +1,54 @@
+package planetscale
+
+import (
+	"context"
+	"io"
+)
+
+// MaxLedgerSize is the largest ledger CSV that can be imported at once.
+const MaxLedgerSize = 10 << 20
+
+// Record types of a ledger CSV, the first column of every row. Participant,
+// item and item split rows refer to their expense through the ref column, an
+// item split belongs to the item row before it.
+const (
+	LedgerRecordExpense     = "expense"
+	LedgerRecordParticipant = "participant"
+	LedgerRecordItem        = "item"
+	LedgerRecordItemSplit   = "item_split"
+	LedgerRecordSettlement  = "settlement"
+)
+
+// LedgerHeader is the header row of a ledger CSV as written by ExportCSV.
+// Columns were only ever added at the end, CSVs written before that are read
+// as if the missing columns were empty.
+var LedgerHeader = []string{"record", "ref", "date", "description", "paid_by", "paid_to", "user_id", "amount", "currency", "split_type_id", "share_percentage", "quantity", "note", "shares", "tax", "tip", "service_charge", "discount"}
+
+type (
+	// LedgerImport reports the outcome of importing a ledger CSV. Nothing is
+	// written when Errors is not empty or when DryRun is set.
+	LedgerImport struct {
+		DryRun      bool                 `json:"dry_run"`
+		Expenses    int                  `json:"expenses"`
+		Settlements int                  `json:"settlements"`
+		Errors      []*LedgerImportError `json:"errors"`
+	}
+
+	LedgerImportError struct {
+		Line    int    `json:"line"`
+		Message string `json:"message"`
+	}
+
+	LedgerService interface {
+		// ExportCSV writes the group's expenses with their participants,
+		// items and item splits, followed by its settlements. Participants of
+		// equal splits are written with their computed share.
+		ExportCSV(ctx context.Context, groupID int64, w io.Writer) error
+		// ImportCSV reads either a CSV written by ExportCSV or a Splitwise
+		// export and adds its expenses and settlements to the group. Every
+		// user must be a member of the group, Splitwise columns are matched
+		// to members by name. Expenses are allocated again for their split
+		// type, as they are when created.
+		ImportCSV(ctx context.Context, groupID int64, r io.Reader, dryRun bool) (*LedgerImport, error)
+	}
+)
@@ -0,0 +1,35 @@
+package db_mock
+
+import (
+	"database/sql"
+
+	planetscale "github.com/harshav17/planet_scale"
+)
+
+type ActivityRepo struct {
+	CreateFn        func(tx *sql.Tx, activity *planetscale.Activity) error
+	FindFn          func(tx *sql.Tx, filter planetscale.ActivityFilter) ([]*planetscale.Activity, error)
+	CountFn         func(tx *sql.Tx, filter planetscale.ActivityFilter) (int, error)
+	GetReadMarkerFn func(tx *sql.Tx, userID string, groupID int64) (int64, error)
+	MarkReadFn      func(tx *sql.Tx, userID string, groupID int64, activityID int64) error
+}
+
+func (s ActivityRepo) Create(tx *sql.Tx, activity *planetscale.Activity) error {
+	return s.CreateFn(tx, activity)
+}
+
+func (s ActivityRepo) Find(tx *sql.Tx, filter planetscale.ActivityFilter) ([]*planetscale.Activity, error) {
+	return s.FindFn(tx, filter)
+}
+
+func (s ActivityRepo) Count(tx *sql.Tx, filter planetscale.ActivityFilter) (int, error) {
+	return s.CountFn(tx, filter)
+}
+
+func (s ActivityRepo) GetReadMarker(tx *sql.Tx, userID string, groupID int64) (int64, error) {
+	return s.GetReadMarkerFn(tx, userID, groupID)
+}
+
+func (s ActivityRepo) MarkRead(tx *sql.Tx, userID string, groupID int64, activityID int64) error {
+	return s.MarkReadFn(tx, userID, groupID, activityID)
+}
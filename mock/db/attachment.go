@@ -0,0 +1,30 @@
+package db_mock
+
+import (
+	"database/sql"
+
+	planetscale "github.com/harshav17/planet_scale"
+)
+
+type AttachmentRepo struct {
+	GetFn    func(tx *sql.Tx, attachmentID int64) (*planetscale.Attachment, error)
+	CreateFn func(tx *sql.Tx, attachment *planetscale.Attachment) error
+	DeleteFn func(tx *sql.Tx, attachmentID int64) error
+	FindFn   func(tx *sql.Tx, filter planetscale.AttachmentFilter) ([]*planetscale.Attachment, error)
+}
+
+func (s AttachmentRepo) Get(tx *sql.Tx, attachmentID int64) (*planetscale.Attachment, error) {
+	return s.GetFn(tx, attachmentID)
+}
+
+func (s AttachmentRepo) Create(tx *sql.Tx, attachment *planetscale.Attachment) error {
+	return s.CreateFn(tx, attachment)
+}
+
+func (s AttachmentRepo) Delete(tx *sql.Tx, attachmentID int64) error {
+	return s.DeleteFn(tx, attachmentID)
+}
+
+func (s AttachmentRepo) Find(tx *sql.Tx, filter planetscale.AttachmentFilter) ([]*planetscale.Attachment, error) {
+	return s.FindFn(tx, filter)
+}
@@ -0,0 +1,20 @@
+package db_mock
+
+import (
+	"database/sql"
+
+	planetscale "github.com/harshav17/planet_scale"
+)
+
+type AuditEventRepo struct {
+	CreateFn func(tx *sql.Tx, event *planetscale.AuditEvent) error
+	FindFn   func(tx *sql.Tx, filter planetscale.AuditEventFilter) ([]*planetscale.AuditEvent, error)
+}
+
+func (s AuditEventRepo) Create(tx *sql.Tx, event *planetscale.AuditEvent) error {
+	return s.CreateFn(tx, event)
+}
+
+func (s AuditEventRepo) Find(tx *sql.Tx, filter planetscale.AuditEventFilter) ([]*planetscale.AuditEvent, error) {
+	return s.FindFn(tx, filter)
+}
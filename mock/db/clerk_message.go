@@ -0,0 +1,15 @@
+package db_mock
+
+import (
+	"database/sql"
+
+	planetscale "github.com/harshav17/planet_scale"
+)
+
+type ClerkMessageRepo struct {
+	CreateFn func(tx *sql.Tx, message *planetscale.ClerkMessage) error
+}
+
+func (s ClerkMessageRepo) Create(tx *sql.Tx, message *planetscale.ClerkMessage) error {
+	return s.CreateFn(tx, message)
+}
@@ -7,12 +7,13 @@ import (
 )
 
 type ExpenseRepo struct {
-	GetFn    func(tx *sql.Tx, expenseID int64) (*planetscale.Expense, error)
-	CreateFn func(tx *sql.Tx, expense *planetscale.Expense) error
-	UpsertFn func(tx *sql.Tx, expense *planetscale.Expense) error
-	DeleteFn func(tx *sql.Tx, expenseID int64) error
-	UpdateFn func(tx *sql.Tx, expenseID int64, expense *planetscale.ExpenseUpdate) (*planetscale.Expense, error)
-	FindFn   func(tx *sql.Tx, filter planetscale.ExpenseFilter) ([]*planetscale.Expense, error)
+	GetFn     func(tx *sql.Tx, expenseID int64) (*planetscale.Expense, error)
+	CreateFn  func(tx *sql.Tx, expense *planetscale.Expense) error
+	UpsertFn  func(tx *sql.Tx, expense *planetscale.Expense) error
+	DeleteFn  func(tx *sql.Tx, expenseID int64) error
+	RestoreFn func(tx *sql.Tx, expenseID int64) error
+	UpdateFn  func(tx *sql.Tx, expenseID int64, expense *planetscale.ExpenseUpdate) (*planetscale.Expense, error)
+	FindFn    func(tx *sql.Tx, filter planetscale.ExpenseFilter) ([]*planetscale.Expense, error)
 }
 
 func (s ExpenseRepo) Get(tx *sql.Tx, expenseID int64) (*planetscale.Expense, error) {
@@ -31,6 +32,10 @@ func (s ExpenseRepo) Delete(tx *sql.Tx, expenseID int64) error {
 	return s.DeleteFn(tx, expenseID)
 }
 
+func (s ExpenseRepo) Restore(tx *sql.Tx, expenseID int64) error {
+	return s.RestoreFn(tx, expenseID)
+}
+
 func (s ExpenseRepo) Update(tx *sql.Tx, expenseID int64, expense *planetscale.ExpenseUpdate) (*planetscale.Expense, error) {
 	return s.UpdateFn(tx, expenseID, expense)
 }
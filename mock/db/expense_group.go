@@ -12,6 +12,7 @@ type ExpenseGroupRepo struct {
 	CreateFn         func(tx *sql.Tx, group *planetscale.ExpenseGroup) error
 	UpdateFn         func(tx *sql.Tx, groupID int64, group *planetscale.ExpenseGroupUpdate) (*planetscale.ExpenseGroup, error)
 	DeleteFn         func(tx *sql.Tx, groupID int64) error
+	RestoreFn        func(tx *sql.Tx, groupID int64) error
 }
 
 func (s ExpenseGroupRepo) ListAllForUser(tx *sql.Tx, userID string) ([]*planetscale.ExpenseGroup, error) {
@@ -33,3 +34,7 @@ func (s ExpenseGroupRepo) Update(tx *sql.Tx, groupID int64, group *planetscale.E
 func (s ExpenseGroupRepo) Delete(tx *sql.Tx, groupID int64) error {
 	return s.DeleteFn(tx, groupID)
 }
+
+func (s ExpenseGroupRepo) Restore(tx *sql.Tx, groupID int64) error {
+	return s.RestoreFn(tx, groupID)
+}
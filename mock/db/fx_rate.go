@@ -0,0 +1,26 @@
+package db_mock
+
+import (
+	"database/sql"
+	"time"
+
+	planetscale "github.com/harshav17/planet_scale"
+)
+
+type FXRateRepo struct {
+	GetAsOfFn func(tx *sql.Tx, baseCurrency, quoteCurrency string, asOf time.Time) (*planetscale.FXRate, error)
+	UpsertFn  func(tx *sql.Tx, rate *planetscale.FXRate) error
+	FindFn    func(tx *sql.Tx, filter planetscale.FXRateFilter) ([]*planetscale.FXRate, error)
+}
+
+func (s FXRateRepo) GetAsOf(tx *sql.Tx, baseCurrency, quoteCurrency string, asOf time.Time) (*planetscale.FXRate, error) {
+	return s.GetAsOfFn(tx, baseCurrency, quoteCurrency, asOf)
+}
+
+func (s FXRateRepo) Upsert(tx *sql.Tx, rate *planetscale.FXRate) error {
+	return s.UpsertFn(tx, rate)
+}
+
+func (s FXRateRepo) Find(tx *sql.Tx, filter planetscale.FXRateFilter) ([]*planetscale.FXRate, error) {
+	return s.FindFn(tx, filter)
+}
@@ -0,0 +1,30 @@
+package db_mock
+
+import (
+	"database/sql"
+
+	planetscale "github.com/harshav17/planet_scale"
+)
+
+type GroupInviteRepo struct {
+	GetFn    func(tx *sql.Tx, inviteID int64) (*planetscale.GroupInvite, error)
+	CreateFn func(tx *sql.Tx, invite *planetscale.GroupInvite) error
+	AcceptFn func(tx *sql.Tx, inviteID int64, userID string) error
+	FindFn   func(tx *sql.Tx, filter planetscale.GroupInviteFilter) ([]*planetscale.GroupInvite, error)
+}
+
+func (s GroupInviteRepo) Get(tx *sql.Tx, inviteID int64) (*planetscale.GroupInvite, error) {
+	return s.GetFn(tx, inviteID)
+}
+
+func (s GroupInviteRepo) Create(tx *sql.Tx, invite *planetscale.GroupInvite) error {
+	return s.CreateFn(tx, invite)
+}
+
+func (s GroupInviteRepo) Accept(tx *sql.Tx, inviteID int64, userID string) error {
+	return s.AcceptFn(tx, inviteID, userID)
+}
+
+func (s GroupInviteRepo) Find(tx *sql.Tx, filter planetscale.GroupInviteFilter) ([]*planetscale.GroupInvite, error) {
+	return s.FindFn(tx, filter)
+}
@@ -7,20 +7,30 @@ import (
 )
 
 type GroupMemberRepo struct {
-	GetFn    func(tx *sql.Tx, groupID int64, userID string) (*planetscale.GroupMember, error)
-	CreateFn func(tx *sql.Tx, group *planetscale.GroupMember) error
-	DeleteFn func(tx *sql.Tx, groupID int64, userID string) error
-	FindFn   func(tx *sql.Tx, filter planetscale.GroupMemberFilter) ([]*planetscale.GroupMember, error)
+	GetFn                      func(tx *sql.Tx, groupID int64, userID string) (*planetscale.GroupMember, error)
+	GetIncludingDeletedGroupFn func(tx *sql.Tx, groupID int64, userID string) (*planetscale.GroupMember, error)
+	CreateFn                   func(tx *sql.Tx, group *planetscale.GroupMember) error
+	UpdateFn                   func(tx *sql.Tx, groupID int64, userID string, update *planetscale.GroupMemberUpdate) (*planetscale.GroupMember, error)
+	DeleteFn                   func(tx *sql.Tx, groupID int64, userID string) error
+	FindFn                     func(tx *sql.Tx, filter planetscale.GroupMemberFilter) ([]*planetscale.GroupMember, error)
 }
 
 func (s GroupMemberRepo) Get(tx *sql.Tx, groupID int64, userID string) (*planetscale.GroupMember, error) {
 	return s.GetFn(tx, groupID, userID)
 }
 
+func (s GroupMemberRepo) GetIncludingDeletedGroup(tx *sql.Tx, groupID int64, userID string) (*planetscale.GroupMember, error) {
+	return s.GetIncludingDeletedGroupFn(tx, groupID, userID)
+}
+
 func (s GroupMemberRepo) Create(tx *sql.Tx, group *planetscale.GroupMember) error {
 	return s.CreateFn(tx, group)
 }
 
+func (s GroupMemberRepo) Update(tx *sql.Tx, groupID int64, userID string, update *planetscale.GroupMemberUpdate) (*planetscale.GroupMember, error) {
+	return s.UpdateFn(tx, groupID, userID, update)
+}
+
 func (s GroupMemberRepo) Delete(tx *sql.Tx, groupID int64, userID string) error {
 	return s.DeleteFn(tx, groupID, userID)
 }
@@ -12,6 +12,7 @@ type ItemSplitRepo struct {
 	UpdateFn func(tx *sql.Tx, itemSplitID int64, itemSplit *planetscale.ItemSplitUpdate) (*planetscale.ItemSplit, error)
 	DeleteFn func(tx *sql.Tx, itemSplitID int64) error
 	FindFn   func(tx *sql.Tx, filter planetscale.ItemSplitFilter) ([]*planetscale.ItemSplit, error)
+	ClaimFn  func(tx *sql.Tx, itemSplitID int64, userID string) (*planetscale.ItemSplit, error)
 }
 
 func (s ItemSplitRepo) Get(tx *sql.Tx, itemSplitID int64) (*planetscale.ItemSplit, error) {
@@ -33,3 +34,7 @@ func (s ItemSplitRepo) Delete(tx *sql.Tx, itemSplitID int64) error {
 func (s ItemSplitRepo) Find(tx *sql.Tx, filter planetscale.ItemSplitFilter) ([]*planetscale.ItemSplit, error) {
 	return s.FindFn(tx, filter)
 }
+
+func (s ItemSplitRepo) Claim(tx *sql.Tx, itemSplitID int64, userID string) (*planetscale.ItemSplit, error) {
+	return s.ClaimFn(tx, itemSplitID, userID)
+}
@@ -0,0 +1,31 @@
+package db_mock
+
+import (
+	"database/sql"
+	"time"
+
+	planetscale "github.com/harshav17/planet_scale"
+)
+
+type NotificationPreferencesRepo struct {
+	GetFn            func(tx *sql.Tx, userID string) (*planetscale.NotificationPreferences, error)
+	UpdateFn         func(tx *sql.Tx, userID string, update *planetscale.NotificationPreferencesUpdate) (*planetscale.NotificationPreferences, error)
+	FindFn           func(tx *sql.Tx, filter planetscale.NotificationPreferencesFilter) ([]*planetscale.NotificationPreferences, error)
+	MarkDigestSentFn func(tx *sql.Tx, userID string, sentAt time.Time) error
+}
+
+func (s NotificationPreferencesRepo) Get(tx *sql.Tx, userID string) (*planetscale.NotificationPreferences, error) {
+	return s.GetFn(tx, userID)
+}
+
+func (s NotificationPreferencesRepo) Update(tx *sql.Tx, userID string, update *planetscale.NotificationPreferencesUpdate) (*planetscale.NotificationPreferences, error) {
+	return s.UpdateFn(tx, userID, update)
+}
+
+func (s NotificationPreferencesRepo) Find(tx *sql.Tx, filter planetscale.NotificationPreferencesFilter) ([]*planetscale.NotificationPreferences, error) {
+	return s.FindFn(tx, filter)
+}
+
+func (s NotificationPreferencesRepo) MarkDigestSent(tx *sql.Tx, userID string, sentAt time.Time) error {
+	return s.MarkDigestSentFn(tx, userID, sentAt)
+}
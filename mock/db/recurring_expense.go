@@ -0,0 +1,51 @@
+package db_mock
+
+import (
+	"database/sql"
+	"time"
+
+	planetscale "github.com/harshav17/planet_scale"
+)
+
+type RecurringExpenseRepo struct {
+	GetFn     func(tx *sql.Tx, recurringExpenseID int64) (*planetscale.RecurringExpense, error)
+	CreateFn  func(tx *sql.Tx, recurringExpense *planetscale.RecurringExpense) error
+	UpdateFn  func(tx *sql.Tx, recurringExpenseID int64, update *planetscale.RecurringExpenseUpdate) (*planetscale.RecurringExpense, error)
+	DeleteFn  func(tx *sql.Tx, recurringExpenseID int64) error
+	FindFn    func(tx *sql.Tx, filter planetscale.RecurringExpenseFilter) ([]*planetscale.RecurringExpense, error)
+	FindDueFn func(tx *sql.Tx, now time.Time) ([]*planetscale.RecurringExpense, error)
+	AdvanceFn func(tx *sql.Tx, recurringExpenseID int64, occurrence int64, next time.Time) error
+	PauseFn   func(tx *sql.Tx, recurringExpenseID int64, failure string) error
+}
+
+func (s RecurringExpenseRepo) Get(tx *sql.Tx, recurringExpenseID int64) (*planetscale.RecurringExpense, error) {
+	return s.GetFn(tx, recurringExpenseID)
+}
+
+func (s RecurringExpenseRepo) Create(tx *sql.Tx, recurringExpense *planetscale.RecurringExpense) error {
+	return s.CreateFn(tx, recurringExpense)
+}
+
+func (s RecurringExpenseRepo) Update(tx *sql.Tx, recurringExpenseID int64, update *planetscale.RecurringExpenseUpdate) (*planetscale.RecurringExpense, error) {
+	return s.UpdateFn(tx, recurringExpenseID, update)
+}
+
+func (s RecurringExpenseRepo) Delete(tx *sql.Tx, recurringExpenseID int64) error {
+	return s.DeleteFn(tx, recurringExpenseID)
+}
+
+func (s RecurringExpenseRepo) Find(tx *sql.Tx, filter planetscale.RecurringExpenseFilter) ([]*planetscale.RecurringExpense, error) {
+	return s.FindFn(tx, filter)
+}
+
+func (s RecurringExpenseRepo) FindDue(tx *sql.Tx, now time.Time) ([]*planetscale.RecurringExpense, error) {
+	return s.FindDueFn(tx, now)
+}
+
+func (s RecurringExpenseRepo) Advance(tx *sql.Tx, recurringExpenseID int64, occurrence int64, next time.Time) error {
+	return s.AdvanceFn(tx, recurringExpenseID, occurrence, next)
+}
+
+func (s RecurringExpenseRepo) Pause(tx *sql.Tx, recurringExpenseID int64, failure string) error {
+	return s.PauseFn(tx, recurringExpenseID, failure)
+}
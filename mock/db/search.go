@@ -0,0 +1,15 @@
+package db_mock
+
+import (
+	"database/sql"
+
+	planetscale "github.com/harshav17/planet_scale"
+)
+
+type SearchRepo struct {
+	SearchFn func(tx *sql.Tx, query planetscale.SearchQuery) ([]*planetscale.SearchHit, error)
+}
+
+func (s SearchRepo) Search(tx *sql.Tx, query planetscale.SearchQuery) ([]*planetscale.SearchHit, error) {
+	return s.SearchFn(tx, query)
+}
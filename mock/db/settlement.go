@@ -7,11 +7,12 @@ import (
 )
 
 type SettlementRepo struct {
-	GetFn    func(tx *sql.Tx, settlementID int64) (*planetscale.Settlement, error)
-	CreateFn func(tx *sql.Tx, settlement *planetscale.Settlement) error
-	DeleteFn func(tx *sql.Tx, settlementID int64) error
-	UpdateFn func(tx *sql.Tx, settlementID int64, settlement *planetscale.SettlementUpdate) (*planetscale.Settlement, error)
-	FindFn   func(tx *sql.Tx, filter planetscale.SettlementFilter) ([]*planetscale.Settlement, error)
+	GetFn     func(tx *sql.Tx, settlementID int64) (*planetscale.Settlement, error)
+	CreateFn  func(tx *sql.Tx, settlement *planetscale.Settlement) error
+	DeleteFn  func(tx *sql.Tx, settlementID int64) error
+	RestoreFn func(tx *sql.Tx, settlementID int64) error
+	UpdateFn  func(tx *sql.Tx, settlementID int64, settlement *planetscale.SettlementUpdate) (*planetscale.Settlement, error)
+	FindFn    func(tx *sql.Tx, filter planetscale.SettlementFilter) ([]*planetscale.Settlement, error)
 }
 
 func (s SettlementRepo) Get(tx *sql.Tx, settlementID int64) (*planetscale.Settlement, error) {
@@ -26,6 +27,10 @@ func (s SettlementRepo) Delete(tx *sql.Tx, settlementID int64) error {
 	return s.DeleteFn(tx, settlementID)
 }
 
+func (s SettlementRepo) Restore(tx *sql.Tx, settlementID int64) error {
+	return s.RestoreFn(tx, settlementID)
+}
+
 func (s SettlementRepo) Update(tx *sql.Tx, settlementID int64, settlement *planetscale.SettlementUpdate) (*planetscale.Settlement, error) {
 	return s.UpdateFn(tx, settlementID, settlement)
 }
@@ -10,6 +10,7 @@ type UserRepo struct {
 	GetFn    func(tx *sql.Tx, userID string) (*planetscale.User, error)
 	CreateFn func(tx *sql.Tx, user *planetscale.User) error
 	UpsertFn func(tx *sql.Tx, user *planetscale.User) error
+	DeleteFn func(tx *sql.Tx, userID string) error
 }
 
 func (s UserRepo) Get(tx *sql.Tx, userID string) (*planetscale.User, error) {
@@ -23,3 +24,7 @@ func (s UserRepo) Create(tx *sql.Tx, user *planetscale.User) error {
 func (s UserRepo) Upsert(tx *sql.Tx, user *planetscale.User) error {
 	return s.UpsertFn(tx, user)
 }
+
+func (s UserRepo) Delete(tx *sql.Tx, userID string) error {
+	return s.DeleteFn(tx, userID)
+}
@@ -0,0 +1,30 @@
+package db_mock
+
+import (
+	"database/sql"
+
+	planetscale "github.com/harshav17/planet_scale"
+)
+
+type WebhookDeliveryRepo struct {
+	UpdateFn        func(tx *sql.Tx, deliveryID int64, update *planetscale.WebhookDeliveryUpdate) error
+	FindFn          func(tx *sql.Tx, filter planetscale.WebhookDeliveryFilter) ([]*planetscale.WebhookDelivery, error)
+	CreateAttemptFn func(tx *sql.Tx, attempt *planetscale.WebhookDeliveryAttempt) error
+	FindAttemptsFn  func(tx *sql.Tx, deliveryID int64) ([]*planetscale.WebhookDeliveryAttempt, error)
+}
+
+func (s WebhookDeliveryRepo) Update(tx *sql.Tx, deliveryID int64, update *planetscale.WebhookDeliveryUpdate) error {
+	return s.UpdateFn(tx, deliveryID, update)
+}
+
+func (s WebhookDeliveryRepo) Find(tx *sql.Tx, filter planetscale.WebhookDeliveryFilter) ([]*planetscale.WebhookDelivery, error) {
+	return s.FindFn(tx, filter)
+}
+
+func (s WebhookDeliveryRepo) CreateAttempt(tx *sql.Tx, attempt *planetscale.WebhookDeliveryAttempt) error {
+	return s.CreateAttemptFn(tx, attempt)
+}
+
+func (s WebhookDeliveryRepo) FindAttempts(tx *sql.Tx, deliveryID int64) ([]*planetscale.WebhookDeliveryAttempt, error) {
+	return s.FindAttemptsFn(tx, deliveryID)
+}
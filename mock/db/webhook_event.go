@@ -0,0 +1,15 @@
+package db_mock
+
+import (
+	"database/sql"
+
+	planetscale "github.com/harshav17/planet_scale"
+)
+
+type WebhookEventRepo struct {
+	CreateFn func(tx *sql.Tx, event *planetscale.WebhookEvent) error
+}
+
+func (s WebhookEventRepo) Create(tx *sql.Tx, event *planetscale.WebhookEvent) error {
+	return s.CreateFn(tx, event)
+}
@@ -0,0 +1,30 @@
+package db_mock
+
+import (
+	"database/sql"
+
+	planetscale "github.com/harshav17/planet_scale"
+)
+
+type WebhookSubscriptionRepo struct {
+	GetFn    func(tx *sql.Tx, subscriptionID int64) (*planetscale.WebhookSubscription, error)
+	CreateFn func(tx *sql.Tx, subscription *planetscale.WebhookSubscription) error
+	DeleteFn func(tx *sql.Tx, subscriptionID int64) error
+	FindFn   func(tx *sql.Tx, filter planetscale.WebhookSubscriptionFilter) ([]*planetscale.WebhookSubscription, error)
+}
+
+func (s WebhookSubscriptionRepo) Get(tx *sql.Tx, subscriptionID int64) (*planetscale.WebhookSubscription, error) {
+	return s.GetFn(tx, subscriptionID)
+}
+
+func (s WebhookSubscriptionRepo) Create(tx *sql.Tx, subscription *planetscale.WebhookSubscription) error {
+	return s.CreateFn(tx, subscription)
+}
+
+func (s WebhookSubscriptionRepo) Delete(tx *sql.Tx, subscriptionID int64) error {
+	return s.DeleteFn(tx, subscriptionID)
+}
+
+func (s WebhookSubscriptionRepo) Find(tx *sql.Tx, filter planetscale.WebhookSubscriptionFilter) ([]*planetscale.WebhookSubscription, error) {
+	return s.FindFn(tx, filter)
+}
@@ -8,8 +8,23 @@ import (
 
 type BalanceService struct {
 	GetGroupBalancesFn func(groupID int64) ([]*planetscale.Balance, error)
+	GetSettlePlanFn    func(groupID int64, apply bool) (*planetscale.SettlePlan, error)
+	GetUserBalanceFn   func(userID string, currency string) (*planetscale.UserBalance, error)
+	GetDashboardFn     func(userID string, currency string) (*planetscale.Dashboard, error)
 }
 
 func (s BalanceService) GetGroupBalances(ctx context.Context, groupID int64) ([]*planetscale.Balance, error) {
 	return s.GetGroupBalancesFn(groupID)
 }
+
+func (s BalanceService) GetSettlePlan(ctx context.Context, groupID int64, apply bool) (*planetscale.SettlePlan, error) {
+	return s.GetSettlePlanFn(groupID, apply)
+}
+
+func (s BalanceService) GetUserBalance(ctx context.Context, userID string, currency string) (*planetscale.UserBalance, error) {
+	return s.GetUserBalanceFn(userID, currency)
+}
+
+func (s BalanceService) GetDashboard(ctx context.Context, userID string, currency string) (*planetscale.Dashboard, error) {
+	return s.GetDashboardFn(userID, currency)
+}
@@ -0,0 +1,24 @@
+package service_mock
+
+import (
+	"context"
+	"io"
+)
+
+type BlobStore struct {
+	PutFn    func(ctx context.Context, key string, r io.Reader) error
+	GetFn    func(ctx context.Context, key string) (io.ReadCloser, error)
+	DeleteFn func(ctx context.Context, key string) error
+}
+
+func (s BlobStore) Put(ctx context.Context, key string, r io.Reader) error {
+	return s.PutFn(ctx, key, r)
+}
+
+func (s BlobStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	return s.GetFn(ctx, key)
+}
+
+func (s BlobStore) Delete(ctx context.Context, key string) error {
+	return s.DeleteFn(ctx, key)
+}
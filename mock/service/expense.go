@@ -7,9 +7,49 @@ import (
 )
 
 type ExpenseService struct {
-	CreateExpenseFn func(ctx context.Context, expense *planetscale.Expense) error
+	CreateExpenseFn   func(ctx context.Context, expense *planetscale.Expense) error
+	UpdateExpenseFn   func(ctx context.Context, expenseID int64, update *planetscale.ExpenseUpdate, userID string) (*planetscale.Expense, *planetscale.Expense, error)
+	CreateItemsFn     func(ctx context.Context, expenseID int64, items []*planetscale.Item, userID string) error
+	UpdateItemFn      func(ctx context.Context, itemID int64, update *planetscale.ItemUpdate, userID string) (*planetscale.Item, error)
+	DeleteItemFn      func(ctx context.Context, itemID int64, userID string) error
+	CreateItemSplitFn func(ctx context.Context, itemID int64, split *planetscale.ItemSplit, userID string) error
+	UpdateItemSplitFn func(ctx context.Context, itemID int64, itemSplitID int64, update *planetscale.ItemSplitUpdate, userID string) (*planetscale.ItemSplit, error)
+	DeleteItemSplitFn func(ctx context.Context, itemID int64, itemSplitID int64, userID string) error
+	ClaimItemSplitFn  func(ctx context.Context, itemSplitID int64, userID string) (*planetscale.ItemSplit, error)
 }
 
 func (s ExpenseService) CreateExpense(ctx context.Context, expense *planetscale.Expense) error {
 	return s.CreateExpenseFn(ctx, expense)
 }
+
+func (s ExpenseService) UpdateExpense(ctx context.Context, expenseID int64, update *planetscale.ExpenseUpdate, userID string) (*planetscale.Expense, *planetscale.Expense, error) {
+	return s.UpdateExpenseFn(ctx, expenseID, update, userID)
+}
+
+func (s ExpenseService) CreateItems(ctx context.Context, expenseID int64, items []*planetscale.Item, userID string) error {
+	return s.CreateItemsFn(ctx, expenseID, items, userID)
+}
+
+func (s ExpenseService) UpdateItem(ctx context.Context, itemID int64, update *planetscale.ItemUpdate, userID string) (*planetscale.Item, error) {
+	return s.UpdateItemFn(ctx, itemID, update, userID)
+}
+
+func (s ExpenseService) DeleteItem(ctx context.Context, itemID int64, userID string) error {
+	return s.DeleteItemFn(ctx, itemID, userID)
+}
+
+func (s ExpenseService) CreateItemSplit(ctx context.Context, itemID int64, split *planetscale.ItemSplit, userID string) error {
+	return s.CreateItemSplitFn(ctx, itemID, split, userID)
+}
+
+func (s ExpenseService) UpdateItemSplit(ctx context.Context, itemID int64, itemSplitID int64, update *planetscale.ItemSplitUpdate, userID string) (*planetscale.ItemSplit, error) {
+	return s.UpdateItemSplitFn(ctx, itemID, itemSplitID, update, userID)
+}
+
+func (s ExpenseService) DeleteItemSplit(ctx context.Context, itemID int64, itemSplitID int64, userID string) error {
+	return s.DeleteItemSplitFn(ctx, itemID, itemSplitID, userID)
+}
+
+func (s ExpenseService) ClaimItemSplit(ctx context.Context, itemSplitID int64, userID string) (*planetscale.ItemSplit, error) {
+	return s.ClaimItemSplitFn(ctx, itemSplitID, userID)
+}
@@ -0,0 +1,13 @@
+package service_mock
+
+import (
+	"context"
+)
+
+type GroupMemberService struct {
+	RemoveFn func(groupID int64, userID string, actorID string, force bool) error
+}
+
+func (s GroupMemberService) Remove(ctx context.Context, groupID int64, userID string, actorID string, force bool) error {
+	return s.RemoveFn(groupID, userID, actorID, force)
+}
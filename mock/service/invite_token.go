@@ -0,0 +1,18 @@
+package service_mock
+
+import (
+	planetscale "github.com/harshav17/planet_scale"
+)
+
+type InviteTokenService struct {
+	SignFn   func(invite *planetscale.GroupInvite) (string, error)
+	VerifyFn func(token string) (int64, error)
+}
+
+func (s InviteTokenService) Sign(invite *planetscale.GroupInvite) (string, error) {
+	return s.SignFn(invite)
+}
+
+func (s InviteTokenService) Verify(token string) (int64, error) {
+	return s.VerifyFn(token)
+}
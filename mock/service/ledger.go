@@ -0,0 +1,21 @@
+package service_mock
+
+import (
+	"context"
+	"io"
+
+	planetscale "github.com/harshav17/planet_scale"
+)
+
+type LedgerService struct {
+	ExportCSVFn func(groupID int64, w io.Writer) error
+	ImportCSVFn func(groupID int64, r io.Reader, dryRun bool) (*planetscale.LedgerImport, error)
+}
+
+func (s LedgerService) ExportCSV(ctx context.Context, groupID int64, w io.Writer) error {
+	return s.ExportCSVFn(groupID, w)
+}
+
+func (s LedgerService) ImportCSV(ctx context.Context, groupID int64, r io.Reader, dryRun bool) (*planetscale.LedgerImport, error) {
+	return s.ImportCSVFn(groupID, r, dryRun)
+}
@@ -0,0 +1,15 @@
+package service_mock
+
+import (
+	"context"
+
+	planetscale "github.com/harshav17/planet_scale"
+)
+
+type Mailer struct {
+	SendFn func(email *planetscale.Email) error
+}
+
+func (s Mailer) Send(ctx context.Context, email *planetscale.Email) error {
+	return s.SendFn(email)
+}
@@ -0,0 +1,21 @@
+package service_mock
+
+import (
+	"context"
+	"time"
+
+	planetscale "github.com/harshav17/planet_scale"
+)
+
+type Notifier struct {
+	NotifyExpenseAddedFn func(expense *planetscale.Expense, userIDs []string) error
+	SendDigestsFn        func(now time.Time) (int, error)
+}
+
+func (s Notifier) NotifyExpenseAdded(ctx context.Context, expense *planetscale.Expense, userIDs []string) error {
+	return s.NotifyExpenseAddedFn(expense, userIDs)
+}
+
+func (s Notifier) SendDigests(ctx context.Context, now time.Time) (int, error) {
+	return s.SendDigestsFn(now)
+}
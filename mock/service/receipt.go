@@ -0,0 +1,13 @@
+package service_mock
+
+import (
+	planetscale "github.com/harshav17/planet_scale"
+)
+
+type ReceiptParser struct {
+	ParseFn func(text string) (*planetscale.Receipt, error)
+}
+
+func (p ReceiptParser) Parse(text string) (*planetscale.Receipt, error) {
+	return p.ParseFn(text)
+}
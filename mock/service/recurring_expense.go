@@ -0,0 +1,14 @@
+package service_mock
+
+import (
+	"context"
+	"time"
+)
+
+type RecurringExpenseService struct {
+	MaterializeDueFn func(now time.Time) (int, error)
+}
+
+func (s RecurringExpenseService) MaterializeDue(ctx context.Context, now time.Time) (int, error) {
+	return s.MaterializeDueFn(now)
+}
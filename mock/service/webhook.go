@@ -0,0 +1,14 @@
+package service_mock
+
+import (
+	"context"
+	"time"
+)
+
+type WebhookService struct {
+	DeliverDueFn func(now time.Time) (int, error)
+}
+
+func (s WebhookService) DeliverDue(ctx context.Context, now time.Time) (int, error) {
+	return s.DeliverDueFn(now)
+}
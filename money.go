@@ -0,0 +1,253 @@
+package planetscale
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Money is an exact amount of money in minor units (cents).
+//
+// Amounts are added and subtracted with the regular integer operators. Use
+// Allocate or Split to divide an amount so that no cent is ever lost.
+type Money int64
+
+// MoneyFromFloat converts a float to Money, rounding half away from zero to
+// the nearest cent. It is meant for tests and literals, not for parsing user
+// input.
+func MoneyFromFloat(f float64) Money {
+	return Money(math.Round(f * 100))
+}
+
+// ParseMoney parses a decimal string such as "12.34" or "-0.5" into Money.
+// Digits beyond the cent are only accepted when they are zero, so "1.2500"
+// parses but "1.255" does not.
+func ParseMoney(s string) (Money, error) {
+	return parseMoney(s, false)
+}
+
+func parseMoney(s string, round bool) (Money, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, Errorf(EINVALID, "invalid amount %q", s)
+	}
+
+	negative := false
+	switch s[0] {
+	case '-':
+		negative = true
+		s = s[1:]
+	case '+':
+		s = s[1:]
+	}
+
+	whole, frac, _ := strings.Cut(s, ".")
+	if whole == "" && frac == "" {
+		return 0, Errorf(EINVALID, "invalid amount %q", s)
+	}
+	for _, part := range []string{whole, frac} {
+		for _, r := range part {
+			if r < '0' || r > '9' {
+				return 0, Errorf(EINVALID, "invalid amount %q", s)
+			}
+		}
+	}
+
+	var units int64
+	if whole != "" {
+		var err error
+		units, err = strconv.ParseInt(whole, 10, 64)
+		if err != nil || units > math.MaxInt64/100 {
+			return 0, Errorf(EINVALID, "amount %q is out of range", s)
+		}
+	}
+
+	var cents int64
+	for i := 0; i < 2; i++ {
+		cents *= 10
+		if i < len(frac) {
+			cents += int64(frac[i] - '0')
+		}
+	}
+	if len(frac) > 2 {
+		extra := strings.TrimRight(frac[2:], "0")
+		if extra != "" && !round {
+			return 0, Errorf(EINVALID, "amount %q has more than two decimal places", s)
+		}
+		if extra != "" && extra[0] >= '5' {
+			cents++
+		}
+	}
+
+	m := Money(units*100 + cents)
+	if negative {
+		m = -m
+	}
+	return m, nil
+}
+
+// String formats the amount with exactly two decimal places.
+func (m Money) String() string {
+	sign := ""
+	cents := int64(m)
+	if cents < 0 {
+		sign = "-"
+		cents = -cents
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, cents/100, cents%100)
+}
+
+// Float64 returns the amount as a float. Only use it for display or for
+// ratios, never to do arithmetic on amounts.
+func (m Money) Float64() float64 {
+	return float64(m) / 100
+}
+
+// Abs returns the absolute amount.
+func (m Money) Abs() Money {
+	if m < 0 {
+		return -m
+	}
+	return m
+}
+
+// Allocate divides the amount in proportion to the given weights. Cents that
+// cannot be divided evenly are handed out one at a time starting with the
+// first weight, so the parts always add up to the original amount and the
+// result only depends on the order of the weights.
+//
+// Weights must not be negative and at least one must be positive.
+func (m Money) Allocate(weights []int64) ([]Money, error) {
+	var total int64
+	for _, weight := range weights {
+		if weight < 0 {
+			return nil, Errorf(EINVALID, "allocation weights cannot be negative")
+		}
+		total += weight
+	}
+	if total == 0 {
+		return nil, Errorf(EINVALID, "allocation weights must add up to more than zero")
+	}
+
+	sign := Money(1)
+	amount := m
+	if amount < 0 {
+		sign = -1
+		amount = -amount
+	}
+
+	parts := make([]Money, len(weights))
+	var allocated Money
+	for i, weight := range weights {
+		parts[i] = Money(mulDiv(int64(amount), weight, total))
+		allocated += parts[i]
+	}
+	for i := 0; allocated < amount; i = (i + 1) % len(parts) {
+		if weights[i] == 0 {
+			continue
+		}
+		parts[i]++
+		allocated++
+	}
+
+	for i := range parts {
+		parts[i] *= sign
+	}
+	return parts, nil
+}
+
+// Split divides the amount into n parts that differ by at most one cent.
+func (m Money) Split(n int) []Money {
+	if n <= 0 {
+		return nil
+	}
+	weights := make([]int64, n)
+	for i := range weights {
+		weights[i] = 1
+	}
+	parts, _ := m.Allocate(weights)
+	return parts
+}
+
+// Convert multiplies the amount by an exchange rate, rounding half away from
+// zero to the nearest cent.
+func (m Money) Convert(rate float64) Money {
+	return Money(math.Round(float64(m) * rate))
+}
+
+// mulDiv returns floor(a*b/c) for non-negative operands without overflowing
+// for any realistic amount.
+func mulDiv(a, b, c int64) int64 {
+	return (a/c)*b + (a%c)*b/c
+}
+
+// MarshalJSON encodes the amount as a JSON number with two decimal places.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON accepts either a JSON number or a string. The literal is
+// parsed as a decimal so that values such as 0.1 are never rounded through a
+// float.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = unquoted
+	}
+	// JSON numbers may use exponents, which a float handles exactly enough
+	if strings.ContainsAny(s, "eE") {
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return Errorf(EINVALID, "invalid amount %q", s)
+		}
+		*m = MoneyFromFloat(f)
+		return nil
+	}
+
+	parsed, err := ParseMoney(s)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// Scan reads a DECIMAL column. Values stored with more than two decimal
+// places are rounded to the nearest cent.
+func (m *Money) Scan(value interface{}) error {
+	var s string
+	switch v := value.(type) {
+	case nil:
+		*m = 0
+		return nil
+	case []byte:
+		s = string(v)
+	case string:
+		s = v
+	case int64:
+		*m = Money(v * 100)
+		return nil
+	case float64:
+		*m = MoneyFromFloat(v)
+		return nil
+	default:
+		return fmt.Errorf("Money: cannot scan %T", value)
+	}
+
+	parsed, err := parseMoney(s, true)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// Value formats the amount as a decimal string for the database.
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
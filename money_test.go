@@ -0,0 +1,177 @@
+package planetscale
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Money
+		wantErr bool
+	}{
+		{in: "0", want: 0},
+		{in: "12", want: 12_00},
+		{in: "12.3", want: 12_30},
+		{in: "12.34", want: 12_34},
+		{in: "-0.05", want: -5},
+		{in: ".5", want: 50},
+		{in: "100.0000", want: 100_00},
+		{in: "0.1", want: 10},
+		{in: "1.255", wantErr: true},
+		{in: "", wantErr: true},
+		{in: "12a", wantErr: true},
+		{in: "1.2.3", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.in, func(t *testing.T) {
+			got, err := ParseMoney(test.in)
+			if test.wantErr {
+				if ErrorCode(err) != EINVALID {
+					t.Fatalf("expected invalid error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.want {
+				t.Errorf("expected %d, got %d", test.want, got)
+			}
+		})
+	}
+}
+
+func TestMoney_String(t *testing.T) {
+	tests := map[Money]string{
+		0:        "0.00",
+		5:        "0.05",
+		12_34:    "12.34",
+		-12_30:   "-12.30",
+		10000_00: "10000.00",
+	}
+	for m, want := range tests {
+		if got := m.String(); got != want {
+			t.Errorf("expected %s, got %s", want, got)
+		}
+	}
+}
+
+func TestMoney_Allocate(t *testing.T) {
+	t.Run("even split keeps every cent", func(t *testing.T) {
+		parts := Money(100_00).Split(3)
+		want := []Money{33_34, 33_33, 33_33}
+		for i := range want {
+			if parts[i] != want[i] {
+				t.Errorf("part %d: expected %s, got %s", i, want[i], parts[i])
+			}
+		}
+	})
+
+	t.Run("weighted split", func(t *testing.T) {
+		parts, err := Money(10_00).Allocate([]int64{1, 2})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if parts[0] != 3_34 || parts[1] != 6_66 {
+			t.Errorf("expected [3.34 6.66], got %v", parts)
+		}
+	})
+
+	t.Run("zero weights get nothing", func(t *testing.T) {
+		parts, err := Money(1).Allocate([]int64{0, 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if parts[0] != 0 || parts[1] != 1 {
+			t.Errorf("expected [0.00 0.01], got %v", parts)
+		}
+	})
+
+	t.Run("negative amounts", func(t *testing.T) {
+		parts := Money(-100).Split(3)
+		var total Money
+		for _, part := range parts {
+			total += part
+		}
+		if total != -100 {
+			t.Errorf("expected parts to add up to -1.00, got %s", total)
+		} else if parts[0] != -34 {
+			t.Errorf("expected first part -0.34, got %s", parts[0])
+		}
+	})
+
+	t.Run("invalid weights", func(t *testing.T) {
+		if _, err := Money(100).Allocate([]int64{0, 0}); ErrorCode(err) != EINVALID {
+			t.Errorf("expected invalid error, got %v", err)
+		}
+		if _, err := Money(100).Allocate([]int64{-1, 2}); ErrorCode(err) != EINVALID {
+			t.Errorf("expected invalid error, got %v", err)
+		}
+	})
+}
+
+func TestMoney_Convert(t *testing.T) {
+	for _, tc := range []struct {
+		amount Money
+		rate   float64
+		want   Money
+	}{
+		{100_00, 1.1, 110_00},
+		{33_33, 1.085, 36_16},
+		{1_00, 0.005, 1},
+		{-1_00, 0.005, -1},
+	} {
+		if got := tc.amount.Convert(tc.rate); got != tc.want {
+			t.Errorf("%s at %v: expected %s, got %s", tc.amount, tc.rate, tc.want, got)
+		}
+	}
+}
+
+func TestMoney_JSON(t *testing.T) {
+	var got struct {
+		A Money `json:"a"`
+		B Money `json:"b"`
+		C Money `json:"c"`
+	}
+	err := json.Unmarshal([]byte(`{"a": 0.1, "b": "12.30", "c": 1e2}`), &got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.A != 10 || got.B != 12_30 || got.C != 100_00 {
+		t.Errorf("unexpected values %+v", got)
+	}
+
+	b, err := json.Marshal(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `{"a":0.10,"b":12.30,"c":100.00}` {
+		t.Errorf("unexpected json %s", b)
+	}
+
+	if err := json.Unmarshal([]byte(`{"a": 0.125}`), &got); ErrorCode(err) != EINVALID {
+		t.Errorf("expected invalid error, got %v", err)
+	}
+}
+
+func TestMoney_Scan(t *testing.T) {
+	var m Money
+	if err := m.Scan([]byte("33.3333")); err != nil {
+		t.Fatal(err)
+	} else if m != 33_33 {
+		t.Errorf("expected 33.33, got %s", m)
+	}
+	if err := m.Scan([]byte("66.6667")); err != nil {
+		t.Fatal(err)
+	} else if m != 66_67 {
+		t.Errorf("expected 66.67, got %s", m)
+	}
+	if err := m.Scan(nil); err != nil {
+		t.Fatal(err)
+	} else if m != 0 {
+		t.Errorf("expected 0.00, got %s", m)
+	}
+}
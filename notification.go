@@ -0,0 +1,75 @@
+package planetscale
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// DigestInterval is how often a user gets a digest of their balances.
+const DigestInterval = 7 * 24 * time.Hour
+
+type (
+	// NotificationPreferences are the emails a user wants. Users who never
+	// changed them get every email.
+	NotificationPreferences struct {
+		UserID string `json:"user_id"`
+		// ExpenseAdded emails the user when someone else adds them to an
+		// expense.
+		ExpenseAdded bool `json:"expense_added"`
+		// Digest emails the user a summary of their balances every
+		// DigestInterval.
+		Digest       bool      `json:"digest"`
+		DigestSentAt time.Time `json:"digest_sent_at"`
+		UpdatedAt    time.Time `json:"updated_at"`
+
+		// set when found, the address emails are sent to
+		Email string `json:"-"`
+		Name  string `json:"-"`
+	}
+
+	NotificationPreferencesUpdate struct {
+		ExpenseAdded *bool `json:"expense_added"`
+		Digest       *bool `json:"digest"`
+	}
+
+	NotificationPreferencesRepo interface {
+		// Get returns the defaults for users who never changed them.
+		Get(tx *sql.Tx, userID string) (*NotificationPreferences, error)
+		// Update changes the preferences that are set in update, starting
+		// from the defaults for users who never changed them.
+		Update(tx *sql.Tx, userID string, update *NotificationPreferencesUpdate) (*NotificationPreferences, error)
+		// Find lists the preferences of users that were not deleted, with
+		// defaults for those who never changed them.
+		Find(tx *sql.Tx, filter NotificationPreferencesFilter) ([]*NotificationPreferences, error)
+		MarkDigestSent(tx *sql.Tx, userID string, sentAt time.Time) error
+	}
+
+	NotificationPreferencesFilter struct {
+		UserIDs []string
+		// DigestDueAt finds users with an email address who want a digest
+		// and did not get one since DigestDueAt minus DigestInterval.
+		DigestDueAt time.Time
+		Limit       int
+	}
+
+	Email struct {
+		To      string
+		Subject string
+		Text    string
+	}
+
+	Mailer interface {
+		Send(ctx context.Context, email *Email) error
+	}
+
+	// Notifier emails users about what happens to them, as far as their
+	// preferences allow.
+	Notifier interface {
+		// NotifyExpenseAdded tells the users that were added to an expense.
+		NotifyExpenseAdded(ctx context.Context, expense *Expense, userIDs []string) error
+		// SendDigests sends all digests due at now and returns how many were
+		// sent. Digests that cannot be sent are tried again a day later.
+		SendDigests(ctx context.Context, now time.Time) (int, error)
+	}
+)
@@ -0,0 +1,81 @@
+package planetscale
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// Sort fields accepted by list filters. Prefix a field with "-" to sort in
+// descending order. Without a sort field lists are in insertion order.
+const (
+	SortTimestamp = "timestamp"
+	SortAmount    = "amount"
+)
+
+// Page sizes used by list endpoints.
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 200
+)
+
+// Cursor marks the last item of a page, the next page starts right after it.
+// Clients only ever see it encoded as an opaque string.
+type Cursor struct {
+	Value string `json:"v,omitempty"` // value of the sort field
+	ID    int64  `json:"id"`
+}
+
+// Encode returns the opaque form of the cursor.
+func (c Cursor) Encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// DecodeCursor parses a cursor previously returned by Encode.
+func DecodeCursor(s string) (Cursor, error) {
+	var c Cursor
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, Errorf(EINVALID, "invalid cursor")
+	}
+	if err := json.Unmarshal(b, &c); err != nil {
+		return c, Errorf(EINVALID, "invalid cursor")
+	}
+	return c, nil
+}
+
+// ParseSort validates sort and splits it into the field and its direction.
+func ParseSort(sort string) (field string, desc bool, err error) {
+	field = strings.TrimPrefix(sort, "-")
+	desc = field != sort
+	switch field {
+	case "":
+		return "", desc, nil
+	case SortTimestamp, SortAmount:
+		return field, desc, nil
+	}
+	return "", false, Errorf(EINVALID, "invalid sort %q", sort)
+}
+
+// ExpenseCursor returns the cursor of the page ending with expense.
+func ExpenseCursor(expense *Expense, sort string) string {
+	return newCursor(sort, expense.ExpenseID, expense.Timestamp, expense.Amount).Encode()
+}
+
+// SettlementCursor returns the cursor of the page ending with settlement.
+func SettlementCursor(settlement *Settlement, sort string) string {
+	return newCursor(sort, settlement.SettlementID, settlement.Timestamp, settlement.Amount).Encode()
+}
+
+func newCursor(sort string, id int64, timestamp time.Time, amount Money) Cursor {
+	c := Cursor{ID: id}
+	switch field, _, _ := ParseSort(sort); field {
+	case SortTimestamp:
+		c.Value = timestamp.UTC().Format(time.RFC3339)
+	case SortAmount:
+		c.Value = amount.String()
+	}
+	return c
+}
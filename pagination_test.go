@@ -0,0 +1,72 @@
+package planetscale
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSort(t *testing.T) {
+	tests := []struct {
+		in      string
+		field   string
+		desc    bool
+		wantErr bool
+	}{
+		{in: ""},
+		{in: "timestamp", field: SortTimestamp},
+		{in: "-timestamp", field: SortTimestamp, desc: true},
+		{in: "-amount", field: SortAmount, desc: true},
+		{in: "description", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.in, func(t *testing.T) {
+			field, desc, err := ParseSort(test.in)
+			if test.wantErr {
+				if ErrorCode(err) != EINVALID {
+					t.Fatalf("expected invalid error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if field != test.field || desc != test.desc {
+				t.Fatalf("expected %s desc=%v, got %s desc=%v", test.field, test.desc, field, desc)
+			}
+		})
+	}
+}
+
+func TestExpenseCursor(t *testing.T) {
+	expense := &Expense{
+		ExpenseID: 42,
+		Amount:    12_34,
+		Timestamp: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+
+	tests := []struct {
+		sort  string
+		value string
+	}{
+		{sort: "", value: ""},
+		{sort: "-timestamp", value: "2024-01-02T03:04:05Z"},
+		{sort: "amount", value: "12.34"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.sort, func(t *testing.T) {
+			c, err := DecodeCursor(ExpenseCursor(expense, test.sort))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.ID != 42 || c.Value != test.value {
+				t.Fatalf("expected cursor {%s 42}, got {%s %d}", test.value, c.Value, c.ID)
+			}
+		})
+	}
+
+	if _, err := DecodeCursor("not a cursor"); ErrorCode(err) != EINVALID {
+		t.Fatalf("expected invalid error, got %v", err)
+	}
+}
@@ -0,0 +1,121 @@
+package planetscale
+
+import (
+	"database/sql"
+)
+
+// CheckPermission loads the user's membership of the group and fails unless
+// their role grants permission. Outsiders get the repo's ENOTFOUND so the
+// group stays hidden from them, members without the permission get EFORBIDDEN.
+func CheckPermission(tx *sql.Tx, repos *RepoProvider, groupID int64, userID string, permission Permission) (*GroupMember, error) {
+	member, err := repos.GroupMember.Get(tx, groupID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !member.Can(permission) {
+		return nil, Errorf(EFORBIDDEN, "a group %s cannot %s", member.Role, permission)
+	}
+	return member, nil
+}
+
+// CheckTrashPermission is CheckPermission for what stays reachable once the
+// group is deleted. Everything else treats a deleted group as gone.
+func CheckTrashPermission(tx *sql.Tx, repos *RepoProvider, groupID int64, userID string, permission Permission) (*GroupMember, error) {
+	member, err := repos.GroupMember.GetIncludingDeletedGroup(tx, groupID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !member.Can(permission) {
+		return nil, Errorf(EFORBIDDEN, "a group %s cannot %s", member.Role, permission)
+	}
+	return member, nil
+}
+
+// CheckExpensePermission is CheckPermission for the group of the expense.
+// Expenses outside of any group have no roles: everyone involved in one may
+// use it like a group member would, and it stays hidden from everybody else.
+func CheckExpensePermission(tx *sql.Tx, repos *RepoProvider, expense *Expense, userID string, permission Permission) (*GroupMember, error) {
+	if expense.GroupID != nil {
+		return CheckPermission(tx, repos, *expense.GroupID, userID, permission)
+	}
+
+	err := CheckInvolved(tx, repos, expense, userID)
+	if ErrorCode(err) == ENOTFOUND {
+		return nil, Errorf(ENOTFOUND, "no expense found with ID %d", expense.ExpenseID)
+	} else if err != nil {
+		return nil, err
+	}
+	member := &GroupMember{
+		UserID: userID,
+		Role:   RoleMember,
+	}
+	if !member.Can(permission) {
+		return nil, Errorf(EFORBIDDEN, "nobody can %s an expense without a group", permission)
+	}
+	return member, nil
+}
+
+// CheckInvolved fails unless the user could be part of the expense: a member
+// of its group, or one of the people involved when it has none.
+func CheckInvolved(tx *sql.Tx, repos *RepoProvider, expense *Expense, userID string) error {
+	if expense.GroupID != nil {
+		_, err := repos.GroupMember.Get(tx, *expense.GroupID, userID)
+		if err != nil {
+			return Errorf(ENOTFOUND, "user %s is not a member of this group", userID)
+		}
+		return nil
+	}
+
+	if expense.Participants == nil {
+		var err error
+		expense.Participants, err = repos.ExpenseParticipant.Find(tx, ExpenseParticipantFilter{
+			ExpenseID: expense.ExpenseID,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if !expense.Involves(userID) {
+		return Errorf(ENOTFOUND, "user %s is not part of this expense", userID)
+	}
+	return nil
+}
+
+// CheckAuthor fails unless the member is one of authors, usually whoever
+// created or paid something, or may edit anything in the group.
+func CheckAuthor(member *GroupMember, authors ...string) error {
+	if member.Can(PermissionEditAll) {
+		return nil
+	}
+	for _, author := range authors {
+		if author == member.UserID {
+			return nil
+		}
+	}
+	return Errorf(EFORBIDDEN, "only admins can change what other members added")
+}
+
+// CheckAddRole fails unless the member may bring someone into the group as
+// role. Anyone who can edit may add members, only higher ranks hand out more.
+func CheckAddRole(member *GroupMember, role string) error {
+	if role != RoleMember && !member.Outranks(role) {
+		return Errorf(EFORBIDDEN, "a group %s cannot add a %s", member.Role, role)
+	}
+	return nil
+}
+
+// GetMemberExpense loads an expense along with the user's membership of its
+// group, which has to grant permission. See CheckExpensePermission for
+// expenses without a group.
+func GetMemberExpense(tx *sql.Tx, repos *RepoProvider, expenseID int64, userID string, permission Permission) (*Expense, *GroupMember, error) {
+	expense, err := repos.Expense.Get(tx, expenseID)
+	if err != nil {
+		return nil, nil, Errorf(ENOTFOUND, "no expense found with ID %d", expenseID)
+	}
+
+	member, err := CheckExpensePermission(tx, repos, expense, userID, permission)
+	if err != nil {
+		return nil, nil, err
+	}
+	return expense, member, nil
+}
@@ -7,9 +7,9 @@ import (
 
 type (
 	Product struct {
-		ID    int64   `json:"ID"`
-		Name  string  `json:"name"`
-		Price float64 `json:"price"`
+		ID    int64  `json:"ID"`
+		Name  string `json:"name"`
+		Price Money  `json:"price"`
 	}
 
 	ProductRepo interface {
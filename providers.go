@@ -2,32 +2,61 @@ package planetscale
 
 type (
 	ControllerProvider struct {
-		Product      ProductController
-		ExpenseGroup ExpenseGroupController
-		GroupMember  GroupMemberController
-		Expense      ExpenseConroller
-		Settlement   SettlementController
-		SplitType    SplitTypeController
-		User         UserController
-		Item         ItemController
+		Product          ProductController
+		ExpenseGroup     ExpenseGroupController
+		GroupMember      GroupMemberController
+		Expense          ExpenseConroller
+		Settlement       SettlementController
+		SplitType        SplitTypeController
+		User             UserController
+		Item             ItemController
+		RecurringExpense RecurringExpenseController
+		Search           SearchController
+		Attachment       AttachmentController
+		GroupInvite      GroupInviteController
+		Webhook          WebhookController
+		Activity         ActivityController
+		Event            EventController
 	}
 
 	RepoProvider struct {
-		Product            ProductRepo
-		ExpenseGroup       ExpenseGroupRepo
-		GroupMember        GroupMemberRepo
-		Expense            ExpenseRepo
-		ExpenseParticipant ExpenseParticipantRepo
-		Settlement         SettlementRepo
-		SplitType          SplitTypeRepo
-		Item               ItemRepo
-		ItemSplit          ItemSplitRepo
-		ItemSplitNu        ItemSplitNURepo
-		User               UserRepo
+		Product                 ProductRepo
+		ExpenseGroup            ExpenseGroupRepo
+		GroupMember             GroupMemberRepo
+		Expense                 ExpenseRepo
+		ExpenseParticipant      ExpenseParticipantRepo
+		Settlement              SettlementRepo
+		SplitType               SplitTypeRepo
+		Item                    ItemRepo
+		ItemSplit               ItemSplitRepo
+		User                    UserRepo
+		FXRate                  FXRateRepo
+		RecurringExpense        RecurringExpenseRepo
+		AuditEvent              AuditEventRepo
+		Search                  SearchRepo
+		Attachment              AttachmentRepo
+		GroupInvite             GroupInviteRepo
+		WebhookSubscription     WebhookSubscriptionRepo
+		WebhookEvent            WebhookEventRepo
+		WebhookDelivery         WebhookDeliveryRepo
+		ClerkMessage            ClerkMessageRepo
+		Activity                ActivityRepo
+		NotificationPreferences NotificationPreferencesRepo
 	}
 
 	ServiceProvider struct {
-		Balance BalanceService
-		Expense ExpenseService
+		Balance          BalanceService
+		Expense          ExpenseService
+		FX               FXService
+		RecurringExpense RecurringExpenseService
+		Ledger           LedgerService
+		Receipt          ReceiptParser
+		Blob             BlobStore
+		InviteToken      InviteTokenService
+		GroupMember      GroupMemberService
+		Webhook          WebhookService
+		Events           EventHub
+		Mailer           Mailer
+		Notifier         Notifier
 	}
 )
@@ -0,0 +1,26 @@
+package planetscale
+
+// MaxReceiptSize is the most receipt text that is read for a single receipt.
+const MaxReceiptSize = 1 << 20
+
+type (
+	// Receipt is a draft itemization read from the text of a receipt. Nothing
+	// is stored until the items are confirmed.
+	Receipt struct {
+		Items         []*Item `json:"items"`
+		Subtotal      Money   `json:"subtotal"`
+		Tax           Money   `json:"tax"`
+		Tip           Money   `json:"tip"`
+		ServiceCharge Money   `json:"service_charge"`
+		Discount      Money   `json:"discount"`
+		Total         Money   `json:"total"`
+
+		// lines that did not add up, for the user to double check
+		Warnings []string `json:"warnings"`
+	}
+
+	ReceiptParser interface {
+		// Parse reads plain receipt text, such as the output of an OCR tool.
+		Parse(text string) (*Receipt, error)
+	}
+)
@@ -0,0 +1,172 @@
+// Package receipt reads the plain text of a receipt, as produced by any OCR
+// tool, into a draft itemization.
+package receipt
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	planetscale "github.com/harshav17/planet_scale"
+)
+
+type parser struct{}
+
+func NewParser() *parser {
+	return &parser{}
+}
+
+var (
+	// an amount at the end of a line, optionally followed by the tax flag
+	// many registers print, e.g. "4.50 T"
+	amountPattern = regexp.MustCompile(`(?i)\s(-?\s?[$€£]?\s?-?\d{1,3}(?:[,.]\d{3})*[.,]\d{2}|-?[$€£]?-?\d+[.,]\d{2})(?:\s+[a-z])?$`)
+
+	// "2 x Beer", "2x Beer", "2 Beer"
+	leadingQuantityPattern = regexp.MustCompile(`(?i)^(\d{1,3})(?:\s*x\s+|x|\s+)([^\d\s].*)$`)
+	// "Beer x2", "Beer x 2"
+	trailingQuantityPattern = regexp.MustCompile(`(?i)^(.+?)\s+x\s?(\d{1,3})$`)
+	// "2 @ 5.00" before the line total
+	unitPricePattern = regexp.MustCompile(`(?i)^(.*?)\s*(\d{1,3})\s*@\s*[$€£]?(\d+[.,]\d{2})(?:\s*(?:ea|each))?$`)
+
+	// labels of the summary lines, checked in order
+	summaryLabels = []struct {
+		kind    string
+		pattern *regexp.Regexp
+	}{
+		{"ignore", regexp.MustCompile(`(?i)\b(change|cash|tendered|visa|mastercard|amex|debit|credit|card|payment|paid|balance)\b`)},
+		{"subtotal", regexp.MustCompile(`(?i)\bsub\s*-?\s*total\b`)},
+		{"service_charge", regexp.MustCompile(`(?i)\bservice(\s+charge|\s+fee)?\b`)},
+		{"tip", regexp.MustCompile(`(?i)\b(tip|gratuity)\b`)},
+		{"tax", regexp.MustCompile(`(?i)\b(tax|vat|gst|hst|pst|tva|iva|mwst)\b`)},
+		{"discount", regexp.MustCompile(`(?i)\b(discount|coupon|promo|savings|voucher)\b`)},
+		{"total", regexp.MustCompile(`(?i)\b(total|amount\s+due)\b`)},
+	}
+)
+
+// Parse reads one item per line that ends in an amount. Lines for the
+// subtotal, tax, tip, service charge, discount and total are recognized by
+// their label, payment lines are skipped, and negative amounts are treated as
+// discounts. Parse fails with EINVALID when no item is found.
+func (p *parser) Parse(text string) (*planetscale.Receipt, error) {
+	receipt := &planetscale.Receipt{}
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.Join(strings.Fields(line), " ")
+		match := amountPattern.FindStringSubmatchIndex(line)
+		if match == nil {
+			continue
+		}
+		label := strings.TrimSpace(line[:match[0]])
+		amount, err := parseAmount(line[match[2]:match[3]])
+		if err != nil {
+			continue
+		}
+
+		kind := "item"
+		for _, summary := range summaryLabels {
+			if summary.pattern.MatchString(label) {
+				kind = summary.kind
+				break
+			}
+		}
+		if kind == "item" && amount < 0 {
+			kind = "discount"
+		}
+
+		switch kind {
+		case "subtotal":
+			receipt.Subtotal = amount
+		case "service_charge":
+			receipt.ServiceCharge += amount
+		case "tip":
+			receipt.Tip += amount
+		case "tax":
+			receipt.Tax += amount
+		case "discount":
+			receipt.Discount += amount.Abs()
+		case "total":
+			receipt.Total = amount
+		case "item":
+			if label == "" {
+				continue
+			}
+			receipt.Items = append(receipt.Items, parseItem(label, amount))
+		}
+	}
+
+	if len(receipt.Items) == 0 {
+		return nil, planetscale.Errorf(planetscale.EINVALID, "no items found on receipt")
+	}
+	check(receipt)
+	return receipt, nil
+}
+
+// parseItem splits the quantity off the label of an item line. The item
+// keeps its line total as a single unit when the total does not divide evenly
+// by the quantity.
+func parseItem(label string, total planetscale.Money) *planetscale.Item {
+	item := &planetscale.Item{Name: label, Price: total, Quantity: 1}
+
+	var quantity int64
+	if match := unitPricePattern.FindStringSubmatch(label); match != nil {
+		item.Name = strings.TrimSpace(match[1])
+		quantity, _ = strconv.ParseInt(match[2], 10, 64)
+	} else if match := leadingQuantityPattern.FindStringSubmatch(label); match != nil {
+		item.Name = strings.TrimSpace(match[2])
+		quantity, _ = strconv.ParseInt(match[1], 10, 64)
+	} else if match := trailingQuantityPattern.FindStringSubmatch(label); match != nil {
+		item.Name = strings.TrimSpace(match[1])
+		quantity, _ = strconv.ParseInt(match[2], 10, 64)
+	}
+
+	if quantity > 1 && total%planetscale.Money(quantity) == 0 {
+		item.Quantity = quantity
+		item.Price = total / planetscale.Money(quantity)
+	} else if quantity > 1 {
+		item.Name = label
+	}
+	if item.Name == "" {
+		item.Name = label
+	}
+	return item
+}
+
+// check warns about a receipt whose items or adjustments do not add up to the
+// subtotal and total printed on it.
+func check(receipt *planetscale.Receipt) {
+	var subtotal planetscale.Money
+	for _, item := range receipt.Items {
+		subtotal += item.Price * planetscale.Money(item.Quantity)
+	}
+	if receipt.Subtotal == 0 {
+		receipt.Subtotal = subtotal
+	} else if receipt.Subtotal != subtotal {
+		receipt.Warnings = append(receipt.Warnings, "items add up to "+subtotal.String()+", the receipt says "+receipt.Subtotal.String())
+	}
+
+	total := receipt.Subtotal + receipt.Tax + receipt.Tip + receipt.ServiceCharge - receipt.Discount
+	if receipt.Total == 0 {
+		receipt.Total = total
+	} else if receipt.Total != total {
+		receipt.Warnings = append(receipt.Warnings, "subtotal and adjustments add up to "+total.String()+", the receipt says "+receipt.Total.String())
+	}
+}
+
+// parseAmount reads an amount with an optional currency symbol and either a
+// decimal point or a decimal comma.
+func parseAmount(s string) (planetscale.Money, error) {
+	s = strings.NewReplacer("$", "", "€", "", "£", "", " ", "").Replace(s)
+	negative := strings.Contains(s, "-")
+	s = strings.ReplaceAll(s, "-", "")
+
+	// the last separator is the decimal one, any other groups thousands
+	decimal := len(s) - 3
+	s = strings.NewReplacer(",", "", ".", "").Replace(s[:decimal]) + "." + s[decimal+1:]
+	amount, err := planetscale.ParseMoney(s)
+	if err != nil {
+		return 0, err
+	}
+	if negative {
+		amount = -amount
+	}
+	return amount, nil
+}
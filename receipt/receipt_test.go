@@ -0,0 +1,104 @@
+package receipt
+
+import (
+	"testing"
+
+	planetscale "github.com/harshav17/planet_scale"
+)
+
+func TestParser_Parse(t *testing.T) {
+	t.Run("restaurant receipt", func(t *testing.T) {
+		text := `
+			THE GOLDEN FORK
+			123 Main St
+			Table 4      Server: Ann
+
+			2 x Beer              12.00
+			Burger                14.50 T
+			Fries x3               9.00
+			Salad 2 @ 6.25        12.50
+			Happy hour            -2.00
+			Subtotal              46.00
+			Sales Tax 8%           3.68
+			Service charge         4.60
+			Tip                    7.00
+			TOTAL                 59.28
+			VISA ****1234         59.28
+			Change                 0.00
+		`
+
+		receipt, err := NewParser().Parse(text)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := []planetscale.Item{
+			{Name: "Beer", Price: 6_00, Quantity: 2},
+			{Name: "Burger", Price: 14_50, Quantity: 1},
+			{Name: "Fries", Price: 3_00, Quantity: 3},
+			{Name: "Salad", Price: 6_25, Quantity: 2},
+		}
+		if len(receipt.Items) != len(expected) {
+			t.Fatalf("expected %d items, got %d", len(expected), len(receipt.Items))
+		}
+		for i, item := range receipt.Items {
+			if item.Name != expected[i].Name || item.Price != expected[i].Price || item.Quantity != expected[i].Quantity {
+				t.Errorf("expected item %+v, got %+v", expected[i], *item)
+			}
+		}
+
+		if receipt.Subtotal != 46_00 || receipt.Tax != 3_68 || receipt.ServiceCharge != 4_60 || receipt.Tip != 7_00 || receipt.Discount != 2_00 || receipt.Total != 59_28 {
+			t.Fatalf("unexpected summary %+v", receipt)
+		}
+		// the subtotal is printed after the happy hour discount
+		if len(receipt.Warnings) != 1 {
+			t.Fatalf("expected a warning about the subtotal, got %v", receipt.Warnings)
+		}
+	})
+
+	t.Run("decimal commas and currency symbols", func(t *testing.T) {
+		text := "Cafe au lait   €3,50\nCroissant x2   €4,00\nTVA            0,75\nTotal   €8,25\n"
+
+		receipt, err := NewParser().Parse(text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(receipt.Items) != 2 || receipt.Items[0].Price != 3_50 || receipt.Items[1].Price != 2_00 || receipt.Items[1].Quantity != 2 {
+			t.Fatalf("unexpected items %+v %+v", receipt.Items[0], receipt.Items[1])
+		}
+		if receipt.Subtotal != 7_50 || receipt.Tax != 75 || len(receipt.Warnings) != 0 {
+			t.Fatalf("unexpected receipt %+v", receipt)
+		}
+	})
+
+	t.Run("thousands separators", func(t *testing.T) {
+		receipt, err := NewParser().Parse("Laptop   $1,299.99\nVAT   $260.00\nTotal   $1,559.99")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if receipt.Items[0].Price != 1299_99 || receipt.Tax != 260_00 || receipt.Total != 1559_99 || len(receipt.Warnings) != 0 {
+			t.Fatalf("unexpected receipt %+v", receipt)
+		}
+	})
+
+	t.Run("uneven quantity keeps the line total", func(t *testing.T) {
+		receipt, err := NewParser().Parse("3 Donuts   5.00")
+		if err != nil {
+			t.Fatal(err)
+		}
+		item := receipt.Items[0]
+		if item.Name != "3 Donuts" || item.Price != 5_00 || item.Quantity != 1 {
+			t.Fatalf("unexpected item %+v", item)
+		}
+		if receipt.Subtotal != 5_00 || receipt.Total != 5_00 {
+			t.Fatalf("expected the totals to be filled in, got %+v", receipt)
+		}
+	})
+
+	t.Run("no items", func(t *testing.T) {
+		_, err := NewParser().Parse("Thank you for visiting!\nTotal 0.00")
+		if planetscale.ErrorCode(err) != planetscale.EINVALID {
+			t.Fatalf("expected EINVALID, got %v", err)
+		}
+	})
+}
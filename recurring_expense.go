@@ -0,0 +1,231 @@
+package planetscale
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Recurrence frequencies understood by RecurrenceRule.
+const (
+	FrequencyDaily   = "DAILY"
+	FrequencyWeekly  = "WEEKLY"
+	FrequencyMonthly = "MONTHLY"
+	FrequencyYearly  = "YEARLY"
+)
+
+type (
+	// RecurringExpense is a template that is turned into a regular Expense
+	// every time its schedule comes due.
+	RecurringExpense struct {
+		RecurringExpenseID int64                 `json:"recurring_expense_id"`
+		GroupID            int64                 `json:"group_id"`
+		SplitTypeID        int64                 `json:"split_type_id"`
+		PaidBy             string                `json:"paid_by"`
+		Amount             Money                 `json:"amount"`
+		Currency           string                `json:"currency"`
+		Description        string                `json:"description"`
+		Participants       []*ExpenseParticipant `json:"participants"`
+
+		// Rule is an RRULE-like schedule such as "FREQ=MONTHLY;INTERVAL=1".
+		// See ParseRecurrenceRule.
+		Rule    string    `json:"rule"`
+		StartAt time.Time `json:"start_at"`
+		// NextOccurrenceAt is zero once the schedule has run out.
+		NextOccurrenceAt time.Time `json:"next_occurrence_at"`
+		// OccurrenceCount is the number of expenses materialized so far, which
+		// is also the index of the next occurrence.
+		OccurrenceCount int64 `json:"occurrence_count"`
+		// PausedAt is set once an occurrence could not be created for a
+		// reason retrying does not fix, which Failure describes. Updating
+		// the recurring expense resumes it.
+		PausedAt time.Time `json:"paused_at"`
+		Failure  string    `json:"failure"`
+
+		CreatedAt time.Time `json:"created_at"`
+		UpdatedAt time.Time `json:"updated_at"`
+		CreatedBy string    `json:"created_by"`
+		UpdatedBy string    `json:"updated_by"`
+	}
+
+	RecurringExpenseRepo interface {
+		Get(tx *sql.Tx, recurringExpenseID int64) (*RecurringExpense, error)
+		Create(tx *sql.Tx, recurringExpense *RecurringExpense) error
+		Update(tx *sql.Tx, recurringExpenseID int64, update *RecurringExpenseUpdate) (*RecurringExpense, error)
+		Delete(tx *sql.Tx, recurringExpenseID int64) error
+		Find(tx *sql.Tx, filter RecurringExpenseFilter) ([]*RecurringExpense, error)
+		// FindDue returns the recurring expenses whose next occurrence is at
+		// or before now, unless they are paused.
+		FindDue(tx *sql.Tx, now time.Time) ([]*RecurringExpense, error)
+		// Advance moves the schedule past occurrence. It fails with ECONFLICT
+		// when the occurrence count is no longer the expected one.
+		Advance(tx *sql.Tx, recurringExpenseID int64, occurrence int64, next time.Time) error
+		// Pause stops the schedule until the next update because of failure.
+		Pause(tx *sql.Tx, recurringExpenseID int64, failure string) error
+	}
+
+	RecurringExpenseFilter struct {
+		GroupID int64
+	}
+
+	// The schedule itself cannot be changed, delete and recreate the
+	// recurring expense instead.
+	RecurringExpenseUpdate struct {
+		SplitTypeID  *int64                `json:"split_type_id"`
+		PaidBy       *string               `json:"paid_by"`
+		Amount       *Money                `json:"amount"`
+		Currency     *string               `json:"currency"`
+		Description  *string               `json:"description"`
+		Participants []*ExpenseParticipant `json:"participants"`
+		UpdatedBy    *string               `json:"updated_by"`
+	}
+
+	RecurringExpenseController interface {
+		HandleGetRecurringExpenses(w http.ResponseWriter, r *http.Request)
+		HandlePostRecurringExpense(w http.ResponseWriter, r *http.Request)
+		HandleGetRecurringExpense(w http.ResponseWriter, r *http.Request)
+		HandlePatchRecurringExpense(w http.ResponseWriter, r *http.Request)
+		HandleDeleteRecurringExpense(w http.ResponseWriter, r *http.Request)
+	}
+
+	RecurringExpenseService interface {
+		// MaterializeDue creates an expense for every occurrence that is due
+		// at now and returns how many were created.
+		MaterializeDue(ctx context.Context, now time.Time) (int, error)
+	}
+
+	// RecurrenceRule is the subset of RFC 5545 RRULE that recurring expenses
+	// support: FREQ, INTERVAL, COUNT and UNTIL.
+	RecurrenceRule struct {
+		Frequency string
+		Interval  int
+		// Count limits the number of occurrences, zero means no limit.
+		Count int64
+		// Until is the last moment an occurrence may fall on, zero means no
+		// limit.
+		Until time.Time
+	}
+)
+
+// Expense builds the expense for the occurrence at index n, which falls on
+// at.
+func (r *RecurringExpense) Expense(n int64, at time.Time) *Expense {
+	groupID := r.GroupID
+	recurringExpenseID := r.RecurringExpenseID
+	expense := &Expense{
+		GroupID:            &groupID,
+		SplitTypeID:        r.SplitTypeID,
+		PaidBy:             r.PaidBy,
+		Amount:             r.Amount,
+		Currency:           r.Currency,
+		Description:        r.Description,
+		Timestamp:          at,
+		CreatedBy:          r.CreatedBy,
+		UpdatedBy:          r.CreatedBy,
+		RecurringExpenseID: &recurringExpenseID,
+		RecurrenceIndex:    n,
+	}
+	// CreateExpense fills in the participants, so every occurrence needs
+	// its own copies
+	for _, participant := range r.Participants {
+		p := *participant
+		p.ExpenseID = 0
+		expense.Participants = append(expense.Participants, &p)
+	}
+	return expense
+}
+
+// ParseRecurrenceRule parses a rule such as "FREQ=MONTHLY;INTERVAL=2;COUNT=6"
+// or "FREQ=WEEKLY;UNTIL=20241231". UNTIL accepts a date or a UTC date-time in
+// the RRULE basic format.
+func ParseRecurrenceRule(s string) (*RecurrenceRule, error) {
+	rule := &RecurrenceRule{Interval: 1}
+	for _, part := range strings.Split(strings.TrimPrefix(strings.TrimSpace(s), "RRULE:"), ";") {
+		if part == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, Errorf(EINVALID, "invalid recurrence rule part %q", part)
+		}
+
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			rule.Frequency = strings.ToUpper(value)
+		case "INTERVAL":
+			interval, err := strconv.Atoi(value)
+			if err != nil || interval < 1 {
+				return nil, Errorf(EINVALID, "invalid recurrence interval %q", value)
+			}
+			rule.Interval = interval
+		case "COUNT":
+			count, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || count < 1 {
+				return nil, Errorf(EINVALID, "invalid recurrence count %q", value)
+			}
+			rule.Count = count
+		case "UNTIL":
+			until, err := time.Parse("20060102T150405Z", value)
+			if err != nil {
+				until, err = time.Parse("20060102", value)
+			}
+			if err != nil {
+				return nil, Errorf(EINVALID, "invalid recurrence until %q", value)
+			}
+			rule.Until = until
+		default:
+			return nil, Errorf(EINVALID, "unsupported recurrence rule part %q", key)
+		}
+	}
+
+	switch rule.Frequency {
+	case FrequencyDaily, FrequencyWeekly, FrequencyMonthly, FrequencyYearly:
+	case "":
+		return nil, Errorf(EINVALID, "recurrence rule requires FREQ")
+	default:
+		return nil, Errorf(EINVALID, "unsupported recurrence frequency %q", rule.Frequency)
+	}
+	return rule, nil
+}
+
+// Occurrence returns the time of the occurrence at index n of a schedule that
+// starts at start, or false when the schedule has ended by then. Monthly and
+// yearly schedules that start late in the month fall on the last day of
+// shorter months.
+func (r *RecurrenceRule) Occurrence(start time.Time, n int64) (time.Time, bool) {
+	if n < 0 || (r.Count > 0 && n >= r.Count) {
+		return time.Time{}, false
+	}
+
+	steps := int(n) * r.Interval
+	var at time.Time
+	switch r.Frequency {
+	case FrequencyDaily:
+		at = start.AddDate(0, 0, steps)
+	case FrequencyWeekly:
+		at = start.AddDate(0, 0, 7*steps)
+	case FrequencyMonthly:
+		at = addMonths(start, steps)
+	case FrequencyYearly:
+		at = addMonths(start, 12*steps)
+	default:
+		return time.Time{}, false
+	}
+
+	if !r.Until.IsZero() && at.After(r.Until) {
+		return time.Time{}, false
+	}
+	return at, true
+}
+
+// addMonths adds months to t, clamping the day to the length of the target
+// month instead of overflowing into the next one.
+func addMonths(t time.Time, months int) time.Time {
+	year, month, day := t.Date()
+	first := time.Date(year, month+time.Month(months), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	lastDay := first.AddDate(0, 1, -1).Day()
+	return first.AddDate(0, 0, min(day, lastDay)-1)
+}
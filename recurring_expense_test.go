@@ -0,0 +1,119 @@
+package planetscale
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRecurrenceRule(t *testing.T) {
+	t.Run("valid rules", func(t *testing.T) {
+		for input, want := range map[string]RecurrenceRule{
+			"FREQ=MONTHLY":                        {Frequency: FrequencyMonthly, Interval: 1},
+			"RRULE:freq=weekly;interval=2":        {Frequency: FrequencyWeekly, Interval: 2},
+			"FREQ=DAILY;COUNT=3":                  {Frequency: FrequencyDaily, Interval: 1, Count: 3},
+			"FREQ=YEARLY;UNTIL=20251231":          {Frequency: FrequencyYearly, Interval: 1, Until: time.Date(2025, 12, 31, 0, 0, 0, 0, time.UTC)},
+			"FREQ=MONTHLY;UNTIL=20251231T120000Z": {Frequency: FrequencyMonthly, Interval: 1, Until: time.Date(2025, 12, 31, 12, 0, 0, 0, time.UTC)},
+		} {
+			got, err := ParseRecurrenceRule(input)
+			if err != nil {
+				t.Fatalf("%s: %v", input, err)
+			}
+			if *got != want {
+				t.Errorf("%s: expected %+v, got %+v", input, want, *got)
+			}
+		}
+	})
+
+	t.Run("invalid rules", func(t *testing.T) {
+		for _, input := range []string{
+			"",
+			"INTERVAL=1",
+			"FREQ=HOURLY",
+			"FREQ=DAILY;INTERVAL=0",
+			"FREQ=DAILY;COUNT=x",
+			"FREQ=DAILY;UNTIL=tomorrow",
+			"FREQ=DAILY;BYDAY=MO",
+			"FREQ",
+		} {
+			if _, err := ParseRecurrenceRule(input); ErrorCode(err) != EINVALID {
+				t.Errorf("%q: expected invalid error, got %v", input, err)
+			}
+		}
+	})
+}
+
+func TestRecurrenceRule_Occurrence(t *testing.T) {
+	start := time.Date(2024, 1, 31, 9, 0, 0, 0, time.UTC)
+
+	t.Run("monthly clamps to the end of shorter months", func(t *testing.T) {
+		rule := RecurrenceRule{Frequency: FrequencyMonthly, Interval: 1}
+		for n, want := range []time.Time{
+			time.Date(2024, 1, 31, 9, 0, 0, 0, time.UTC),
+			time.Date(2024, 2, 29, 9, 0, 0, 0, time.UTC),
+			time.Date(2024, 3, 31, 9, 0, 0, 0, time.UTC),
+			time.Date(2024, 4, 30, 9, 0, 0, 0, time.UTC),
+		} {
+			got, ok := rule.Occurrence(start, int64(n))
+			if !ok || !got.Equal(want) {
+				t.Errorf("occurrence %d: expected %v, got %v (%v)", n, want, got, ok)
+			}
+		}
+	})
+
+	t.Run("weekly with interval", func(t *testing.T) {
+		rule := RecurrenceRule{Frequency: FrequencyWeekly, Interval: 2}
+		got, ok := rule.Occurrence(start, 2)
+		if want := start.AddDate(0, 0, 28); !ok || !got.Equal(want) {
+			t.Errorf("expected %v, got %v (%v)", want, got, ok)
+		}
+	})
+
+	t.Run("count and until end the schedule", func(t *testing.T) {
+		rule := RecurrenceRule{Frequency: FrequencyDaily, Interval: 1, Count: 2}
+		if _, ok := rule.Occurrence(start, 1); !ok {
+			t.Error("expected occurrence 1 to exist")
+		}
+		if _, ok := rule.Occurrence(start, 2); ok {
+			t.Error("expected occurrence 2 to be past the count")
+		}
+
+		rule = RecurrenceRule{Frequency: FrequencyYearly, Interval: 1, Until: time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)}
+		if _, ok := rule.Occurrence(start, 1); !ok {
+			t.Error("expected occurrence 1 to exist")
+		}
+		if _, ok := rule.Occurrence(start, 2); ok {
+			t.Error("expected occurrence 2 to be past until")
+		}
+	})
+}
+
+func TestRecurringExpense_Expense(t *testing.T) {
+	recurringExpense := &RecurringExpense{
+		RecurringExpenseID: 7,
+		GroupID:            1,
+		SplitTypeID:        SplitTypeUnequal,
+		PaidBy:             "test-user-id",
+		Amount:             100_00,
+		Currency:           "EUR",
+		Description:        "rent",
+		Participants: []*ExpenseParticipant{
+			{UserID: "test-user-id", AmountOwed: 50_00},
+			{UserID: "test-user-id-2", AmountOwed: 50_00},
+		},
+		CreatedBy: "test-user-id",
+	}
+	at := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	expense := recurringExpense.Expense(3, at)
+	if *expense.GroupID != 1 || *expense.RecurringExpenseID != 7 || expense.RecurrenceIndex != 3 {
+		t.Fatalf("unexpected expense %+v", expense)
+	}
+	if !expense.Timestamp.Equal(at) || expense.Amount != 100_00 || expense.Currency != "EUR" {
+		t.Fatalf("unexpected expense %+v", expense)
+	}
+
+	expense.Participants[0].ExpenseID = 42
+	if recurringExpense.Participants[0].ExpenseID != 0 {
+		t.Fatal("expected participants to be copied")
+	}
+}
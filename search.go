@@ -0,0 +1,46 @@
+package planetscale
+
+import (
+	"database/sql"
+	"net/http"
+	"time"
+)
+
+// Where a search hit matched.
+const (
+	SearchMatchExpense = "expense" // the expense description
+	SearchMatchItem    = "item"    // the name of one of the expense's items
+	SearchMatchNote    = "note"    // a participant's note on the expense
+)
+
+type (
+	// SearchHit is an expense that matched a search, along with the text that
+	// matched. An expense can be hit more than once, e.g. by its description
+	// and by one of its items.
+	SearchHit struct {
+		GroupID            int64     `json:"group_id"`
+		GroupName          string    `json:"group_name"`
+		ExpenseID          int64     `json:"expense_id"`
+		ExpenseDescription string    `json:"expense_description"`
+		Amount             Money     `json:"amount"`
+		Currency           string    `json:"currency"`
+		Timestamp          time.Time `json:"timestamp"`
+		Match              string    `json:"match"`
+		MatchText          string    `json:"match_text"`
+		Score              float64   `json:"score"`
+	}
+
+	SearchRepo interface {
+		Search(tx *sql.Tx, query SearchQuery) ([]*SearchHit, error)
+	}
+
+	SearchQuery struct {
+		Query    string
+		GroupIDs []int64 // only expenses in these groups are searched
+		Limit    int
+	}
+
+	SearchController interface {
+		HandleSearch(w http.ResponseWriter, r *http.Request)
+	}
+)
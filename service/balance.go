@@ -3,167 +3,611 @@ package service
 import (
 	"context"
 	"database/sql"
+	"sort"
+	"strconv"
+	"time"
 
 	planetscale "github.com/harshav17/planet_scale"
 )
 
 type balanceService struct {
-	repos *planetscale.RepoProvider
-	tm    planetscale.TransactionManager
+	repos  *planetscale.RepoProvider
+	tm     planetscale.TransactionManager
+	events planetscale.EventHub
 }
 
-func NewBalanceService(repoProvider *planetscale.RepoProvider, tm planetscale.TransactionManager) *balanceService {
+func NewBalanceService(repoProvider *planetscale.RepoProvider, tm planetscale.TransactionManager, events planetscale.EventHub) *balanceService {
 	return &balanceService{
-		repos: repoProvider,
-		tm:    tm,
+		repos:  repoProvider,
+		tm:     tm,
+		events: events,
 	}
 }
 
 func (s *balanceService) GetGroupBalances(ctx context.Context, groupID int64) ([]*planetscale.Balance, error) {
 	var balances []*planetscale.Balance
 	getBalancesFunc := func(tx *sql.Tx) error {
-		expenses, err := s.repos.Expense.Find(tx, planetscale.ExpenseFilter{
-			GroupID: groupID,
-		})
+		var err error
+		balances, err = s.groupBalances(tx, groupID)
 		if err != nil {
 			return err
 		}
 
-		settlements, err := s.repos.Settlement.Find(tx, planetscale.SettlementFilter{
-			GroupID: groupID,
-		})
+		return nil
+	}
+
+	err := s.tm.ExecuteInTx(ctx, getBalancesFunc)
+	if err != nil {
+		return nil, err
+	}
+
+	return balances, nil
+}
+
+func (s *balanceService) GetSettlePlan(ctx context.Context, groupID int64, apply bool) (*planetscale.SettlePlan, error) {
+	plan := &planetscale.SettlePlan{
+		GroupID: groupID,
+	}
+	var audits []*planetscale.AuditEvent
+	getSettlePlanFunc := func(tx *sql.Tx) error {
+		balances, err := s.groupBalances(tx, groupID)
+		if err != nil {
+			return err
+		}
+		plan.Transfers = simplifyDebts(balances)
+
+		if !apply {
+			return nil
+		}
+		for _, transfer := range plan.Transfers {
+			settlement := &planetscale.Settlement{
+				GroupID: groupID,
+				PaidBy:  transfer.PaidBy,
+				PaidTo:  transfer.PaidTo,
+				Amount:  transfer.Amount,
+			}
+			err := s.repos.Settlement.Create(tx, settlement)
+			if err != nil {
+				return err
+			}
+			audit := &planetscale.AuditEvent{
+				GroupID:    &settlement.GroupID,
+				EntityType: planetscale.AuditEntitySettlement,
+				EntityID:   strconv.FormatInt(settlement.SettlementID, 10),
+				Action:     planetscale.AuditActionCreate,
+				ActorID:    planetscale.ActorFromContext(ctx, transfer.PaidBy),
+				After:      settlement,
+			}
+			err = s.repos.AuditEvent.Create(tx, audit)
+			if err != nil {
+				return err
+			}
+			err = s.repos.WebhookEvent.Create(tx, planetscale.NewWebhookEvent(audit))
+			if err != nil {
+				return err
+			}
+			err = s.repos.Activity.Create(tx, planetscale.NewActivity(audit))
+			if err != nil {
+				return err
+			}
+			plan.Settlements = append(plan.Settlements, settlement)
+			audits = append(audits, audit)
+		}
+		return nil
+	}
+
+	err := s.tm.ExecuteInTx(ctx, getSettlePlanFunc)
+	if err != nil {
+		return nil, err
+	}
+	planetscale.Publish(s.events, audits...)
+
+	return plan, nil
+}
+
+func (s *balanceService) GetUserBalance(ctx context.Context, userID string, currency string) (*planetscale.UserBalance, error) {
+	currency, err := balanceCurrency(currency)
+	if err != nil {
+		return nil, err
+	}
+
+	var total *planetscale.UserBalance
+	getBalanceFunc := func(tx *sql.Tx) error {
+		groups, err := s.repos.ExpenseGroup.ListAllForUser(tx, userID)
 		if err != nil {
 			return err
 		}
+		total, _, _, err = s.userBalance(tx, userID, currency, groups)
+		return err
+	}
 
-		balances, err = s.calculateBalances(tx, expenses, settlements)
+	err = s.tm.ExecuteInTx(ctx, getBalanceFunc)
+	if err != nil {
+		return nil, err
+	}
+
+	return total, nil
+}
+
+func (s *balanceService) GetDashboard(ctx context.Context, userID string, currency string) (*planetscale.Dashboard, error) {
+	currency, err := balanceCurrency(currency)
+	if err != nil {
+		return nil, err
+	}
+
+	var dashboard *planetscale.Dashboard
+	getDashboardFunc := func(tx *sql.Tx) error {
+		groups, err := s.repos.ExpenseGroup.ListAllForUser(tx, userID)
 		if err != nil {
 			return err
 		}
+		total, expenses, settlements, err := s.userBalance(tx, userID, currency, groups)
+		if err != nil {
+			return err
+		}
+
+		dashboard = &planetscale.Dashboard{
+			UserID:            total.UserID,
+			Currency:          total.Currency,
+			Amount:            total.Amount,
+			Owed:              total.Owed,
+			Owing:             total.Owing,
+			Groups:            make([]*planetscale.DashboardGroup, len(groups)),
+			Friends:           total.Friends,
+			RecentExpenses:    []*planetscale.Expense{},
+			RecentSettlements: []*planetscale.Settlement{},
+		}
+		for i, group := range groups {
+			dashboard.Groups[i] = &planetscale.DashboardGroup{
+				ExpenseGroup: group,
+				Balance:      total.Groups[i].Amount,
+			}
+		}
 
+		// the balances already loaded every live expense and settlement, the
+		// latest ones are picked from those rather than queried again
+		sort.Slice(expenses, func(i, j int) bool {
+			if !expenses[i].Timestamp.Equal(expenses[j].Timestamp) {
+				return expenses[i].Timestamp.After(expenses[j].Timestamp)
+			}
+			return expenses[i].ExpenseID > expenses[j].ExpenseID
+		})
+		if len(expenses) > planetscale.DashboardRecentSize {
+			expenses = expenses[:planetscale.DashboardRecentSize]
+		}
+		dashboard.RecentExpenses = append(dashboard.RecentExpenses, expenses...)
+
+		sort.Slice(settlements, func(i, j int) bool {
+			if !settlements[i].Timestamp.Equal(settlements[j].Timestamp) {
+				return settlements[i].Timestamp.After(settlements[j].Timestamp)
+			}
+			return settlements[i].SettlementID > settlements[j].SettlementID
+		})
+		if len(settlements) > planetscale.DashboardRecentSize {
+			settlements = settlements[:planetscale.DashboardRecentSize]
+		}
+		dashboard.RecentSettlements = append(dashboard.RecentSettlements, settlements...)
 		return nil
 	}
 
-	err := s.tm.ExecuteInTx(ctx, getBalancesFunc)
+	err = s.tm.ExecuteInTx(ctx, getDashboardFunc)
 	if err != nil {
 		return nil, err
 	}
 
-	return balances, nil
+	return dashboard, nil
 }
 
-func (s *balanceService) calculateBalances(tx *sql.Tx, expenses []*planetscale.Expense, settlements []*planetscale.Settlement) ([]*planetscale.Balance, error) {
-	// compile a list of balance records
-	balances := make(map[string]*planetscale.Balance)
-	for _, expense := range expenses {
-		if _, ok := balances[expense.PaidBy]; !ok {
-			balances[expense.PaidBy] = &planetscale.Balance{
-				UserID:       expense.PaidBy,
-				BalanceItems: map[string]float64{},
-			}
+// balanceCurrency validates the currency a user's balance is totalled in.
+func balanceCurrency(currency string) (string, error) {
+	if currency == "" {
+		return planetscale.DefaultCurrency, nil
+	}
+	return planetscale.NormalizeCurrency(currency)
+}
+
+// userBalance totals the balances of a user in their groups and with their
+// friends. The expenses and settlements of every group are loaded in a fixed
+// number of queries, however many groups there are, and returned together
+// with the direct expenses of the user. total.Groups is in the order of
+// groups.
+func (s *balanceService) userBalance(tx *sql.Tx, userID string, currency string, groups []*planetscale.ExpenseGroup) (*planetscale.UserBalance, []*planetscale.Expense, []*planetscale.Settlement, error) {
+	total := &planetscale.UserBalance{
+		UserID:   userID,
+		Currency: currency,
+		Groups:   []*planetscale.Balance{},
+		Friends:  map[string]planetscale.Money{},
+	}
+	add := func(amount planetscale.Money) {
+		if amount > 0 {
+			total.Owed += amount
+		} else {
+			total.Owing -= amount
 		}
-		balances[expense.PaidBy].Amount += expense.Amount
+	}
 
-		if expense.SplitTypeID == 1 { // TODO load from DB
-			s.handleEqualSplitType(tx, balances, expense)
-		} else if expense.SplitTypeID == 3 {
-			s.handleItemizedSplitType(tx, balances, expense)
+	var expenses []*planetscale.Expense
+	var settlements []*planetscale.Settlement
+	if len(groups) > 0 {
+		groupIDs := make([]int64, len(groups))
+		for i, group := range groups {
+			groupIDs[i] = group.ExpenseGroupID
+		}
+		var err error
+		expenses, err = s.repos.Expense.Find(tx, planetscale.ExpenseFilter{
+			GroupIDs: groupIDs,
+		})
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		settlements, err = s.repos.Settlement.Find(tx, planetscale.SettlementFilter{
+			GroupIDs: groupIDs,
+		})
+		if err != nil {
+			return nil, nil, nil, err
 		}
 	}
+	direct, err := s.repos.Expense.Find(tx, planetscale.ExpenseFilter{
+		Direct: true,
+		UserID: userID,
+	})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	expenses = append(expenses, direct...)
+	err = s.loadShares(tx, expenses)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	groupExpenses := make(map[int64][]*planetscale.Expense)
+	for _, expense := range expenses {
+		if expense.GroupID != nil {
+			groupExpenses[*expense.GroupID] = append(groupExpenses[*expense.GroupID], expense)
+		}
+	}
+	groupSettlements := make(map[int64][]*planetscale.Settlement)
 	for _, settlement := range settlements {
-		if _, ok := balances[settlement.PaidBy]; !ok {
-			balances[settlement.PaidBy] = &planetscale.Balance{
-				UserID: settlement.PaidBy,
+		groupSettlements[settlement.GroupID] = append(groupSettlements[settlement.GroupID], settlement)
+	}
+
+	// group balances are in the base currency of their group, the total
+	// converts them at today's rate
+	fx := newFXConverter(tx, s.repos.FXRate, currency)
+	for _, group := range groups {
+		groupFX := newFXConverter(tx, s.repos.FXRate, group.BaseCurrency)
+		balances, err := s.calculateBalances(tx, groupFX, groupExpenses[group.ExpenseGroupID], groupSettlements[group.ExpenseGroupID])
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		balance := &planetscale.Balance{
+			UserID:       userID,
+			Currency:     group.BaseCurrency,
+			BalanceItems: map[string]planetscale.Money{},
+		}
+		for _, b := range balances {
+			if b.UserID == userID {
+				balance = b
 			}
 		}
-		balances[settlement.PaidBy].Amount += settlement.Amount
-
-		// update paidBy user's balance items
-		if _, ok := balances[settlement.PaidBy].BalanceItems[settlement.PaidTo]; !ok {
-			balances[settlement.PaidBy].BalanceItems[settlement.PaidTo] = 0
+		balance.ExpenseGroupID = group.ExpenseGroupID
+		total.Groups = append(total.Groups, balance)
+		if balance.Amount == 0 {
+			continue
 		}
-		balances[settlement.PaidBy].BalanceItems[settlement.PaidTo] += settlement.Amount
-		balances[settlement.PaidTo].BalanceItems[settlement.PaidBy] -= settlement.Amount
 
-		if _, ok := balances[settlement.PaidTo]; !ok {
-			balances[settlement.PaidTo] = &planetscale.Balance{
-				UserID: settlement.PaidTo,
-			}
+		rate, err := fx.rate(balance.Currency, time.Time{})
+		if err != nil {
+			return nil, nil, nil, err
 		}
-		balances[settlement.PaidTo].Amount -= settlement.Amount
+		add(balance.Amount.Convert(rate))
 	}
 
-	// convert map to slice
-	var balanceSlice []*planetscale.Balance
+	// direct expenses have no group currency, they are converted as of the
+	// day they were spent like those of a group
+	balances, err := s.calculateBalances(tx, fx, direct, nil)
+	if err != nil {
+		return nil, nil, nil, err
+	}
 	for _, balance := range balances {
-		// truncate to 2 decimal places
-		balance.Amount = float64(int64(balance.Amount*100)) / 100
-		balanceSlice = append(balanceSlice, balance)
+		if balance.UserID != userID {
+			continue
+		}
+		for friendID, amount := range balance.BalanceItems {
+			if amount == 0 {
+				continue
+			}
+			total.Friends[friendID] = amount
+			add(amount)
+		}
 	}
-	return balanceSlice, nil
+
+	total.Amount = total.Owed - total.Owing
+	return total, expenses, settlements, nil
 }
 
-func (s *balanceService) handleEqualSplitType(tx *sql.Tx, balances map[string]*planetscale.Balance, expense *planetscale.Expense) error {
+// loadShares loads the participants of expenses, and the items and splits of
+// the item based ones, with one query each instead of one per expense.
+func (s *balanceService) loadShares(tx *sql.Tx, expenses []*planetscale.Expense) error {
+	if len(expenses) == 0 {
+		return nil
+	}
+
+	byID := make(map[int64]*planetscale.Expense, len(expenses))
+	expenseIDs := make([]int64, len(expenses))
+	var itemized []int64
+	for i, expense := range expenses {
+		byID[expense.ExpenseID] = expense
+		expenseIDs[i] = expense.ExpenseID
+		expense.Participants = []*planetscale.ExpenseParticipant{}
+		if expense.SplitTypeID == planetscale.SplitTypeItemBased {
+			expense.Items = []*planetscale.Item{}
+			itemized = append(itemized, expense.ExpenseID)
+		}
+	}
+
 	participants, err := s.repos.ExpenseParticipant.Find(tx, planetscale.ExpenseParticipantFilter{
-		ExpenseID: expense.ExpenseID,
+		ExpenseIDs: expenseIDs,
 	})
 	if err != nil {
 		return err
 	}
-
 	for _, participant := range participants {
-		if _, ok := balances[participant.UserID]; !ok {
-			balances[participant.UserID] = &planetscale.Balance{
-				UserID:       participant.UserID,
-				BalanceItems: map[string]float64{},
-			}
+		if expense, ok := byID[participant.ExpenseID]; ok {
+			expense.Participants = append(expense.Participants, participant)
 		}
-		balances[participant.UserID].Amount -= expense.Amount / float64(len(participants))
+	}
 
-		if participant.UserID == expense.PaidBy {
-			continue
+	if len(itemized) == 0 {
+		return nil
+	}
+	items, err := s.repos.Item.Find(tx, planetscale.ItemFilter{
+		ExpenseIDs: itemized,
+	})
+	if err != nil {
+		return err
+	}
+	if len(items) == 0 {
+		return nil
+	}
+	itemsByID := make(map[int64]*planetscale.Item, len(items))
+	itemIDs := make([]int64, len(items))
+	for i, item := range items {
+		item.Splits = []*planetscale.ItemSplit{}
+		itemsByID[item.ItemID] = item
+		itemIDs[i] = item.ItemID
+		if expense, ok := byID[item.ExpenseID]; ok {
+			expense.Items = append(expense.Items, item)
+		}
+	}
+	splits, err := s.repos.ItemSplit.Find(tx, planetscale.ItemSplitFilter{
+		ItemIDs: itemIDs,
+	})
+	if err != nil {
+		return err
+	}
+	for _, split := range splits {
+		if item, ok := itemsByID[split.ItemID]; ok {
+			item.Splits = append(item.Splits, split)
 		}
-		balances[participant.UserID].BalanceItems[expense.PaidBy] -= expense.Amount / float64(len(participants))
-		balances[expense.PaidBy].BalanceItems[participant.UserID] += expense.Amount / float64(len(participants))
 	}
 	return nil
 }
 
-func (s *balanceService) handleItemizedSplitType(tx *sql.Tx, balances map[string]*planetscale.Balance, expense *planetscale.Expense) error {
-	items, err := s.repos.Item.Find(tx, planetscale.ItemFilter{
-		ExpenseID: expense.ExpenseID,
+func (s *balanceService) groupBalances(tx *sql.Tx, groupID int64) ([]*planetscale.Balance, error) {
+	group, err := s.repos.ExpenseGroup.Get(tx, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	expenses, err := s.repos.Expense.Find(tx, planetscale.ExpenseFilter{
+		GroupID: groupID,
 	})
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	// TODO what about taxes?
+	settlements, err := s.repos.Settlement.Find(tx, planetscale.SettlementFilter{
+		GroupID: groupID,
+	})
+	if err != nil {
+		return nil, err
+	}
 
-	for _, item := range items {
-		itemSplits, err := s.repos.ItemSplit.Find(tx, planetscale.ItemSplitFilter{
-			ItemID: item.ItemID,
-		})
+	fx := newFXConverter(tx, s.repos.FXRate, group.BaseCurrency)
+	return s.calculateBalances(tx, fx, expenses, settlements)
+}
+
+// share is the part of an expense that a single user owes to whoever paid it.
+type share struct {
+	userID string
+	amount planetscale.Money
+}
+
+// calculateBalances nets every expense and settlement of a group into one
+// balance per user, converting all amounts into the group's base currency.
+func (s *balanceService) calculateBalances(tx *sql.Tx, fx *fxConverter, expenses []*planetscale.Expense, settlements []*planetscale.Settlement) ([]*planetscale.Balance, error) {
+	// compile a list of balance records
+	balances := make(map[string]*planetscale.Balance)
+	balanceFor := func(userID string) *planetscale.Balance {
+		if _, ok := balances[userID]; !ok {
+			balances[userID] = &planetscale.Balance{
+				UserID:       userID,
+				Currency:     fx.base,
+				BalanceItems: map[string]planetscale.Money{},
+			}
+		}
+		return balances[userID]
+	}
+
+	for _, expense := range expenses {
+		var shares []share
+		var err error
+		switch expense.SplitTypeID {
+		case planetscale.SplitTypeEqual:
+			shares, err = s.equalShares(tx, expense)
+		case planetscale.SplitTypeItemBased:
+			shares, err = s.itemizedShares(tx, expense)
+		case planetscale.SplitTypeUnequal, planetscale.SplitTypeShareBased, planetscale.SplitTypePercentageBased:
+			shares, err = s.amountOwedShares(tx, expense)
+		}
 		if err != nil {
-			return err
+			return nil, err
 		}
-		amount := item.Price / float64(len(itemSplits))
 
-		for _, itemSplit := range itemSplits {
-			if _, ok := balances[itemSplit.UserID]; !ok {
-				balances[itemSplit.UserID] = &planetscale.Balance{
-					UserID:       itemSplit.UserID,
-					BalanceItems: map[string]float64{},
-				}
-			}
-			balances[itemSplit.UserID].Amount -= amount
+		rate, err := fx.rate(expense.Currency, expense.Timestamp)
+		if err != nil {
+			return nil, err
+		}
+		paid, shares, err := convertShares(expense.Amount, shares, rate)
+		if err != nil {
+			return nil, err
+		}
+
+		balanceFor(expense.PaidBy).Amount += paid
+		for _, share := range shares {
+			balanceFor(share.userID).Amount -= share.amount
 
-			if itemSplit.UserID == expense.PaidBy {
+			if share.userID == expense.PaidBy {
 				continue
 			}
-			balances[itemSplit.UserID].BalanceItems[expense.PaidBy] -= amount
-			balances[expense.PaidBy].BalanceItems[itemSplit.UserID] += amount
+			balances[share.userID].BalanceItems[expense.PaidBy] -= share.amount
+			balances[expense.PaidBy].BalanceItems[share.userID] += share.amount
 		}
 	}
+	for _, settlement := range settlements {
+		rate, err := fx.rate(settlement.Currency, settlement.Timestamp)
+		if err != nil {
+			return nil, err
+		}
+		amount := settlement.Amount.Convert(rate)
 
-	return nil
+		balanceFor(settlement.PaidBy).Amount += amount
+		balanceFor(settlement.PaidTo).Amount -= amount
+
+		// update paidBy user's balance items
+		balances[settlement.PaidBy].BalanceItems[settlement.PaidTo] += amount
+		balances[settlement.PaidTo].BalanceItems[settlement.PaidBy] -= amount
+	}
+
+	// convert map to slice
+	var balanceSlice []*planetscale.Balance
+	for _, balance := range balances {
+		balanceSlice = append(balanceSlice, balance)
+	}
+	return balanceSlice, nil
+}
+
+// convertShares converts an expense amount and its shares with the given
+// rate. The converted total is allocated back over the shares so that they
+// still add up to what the payer is credited and no cent is lost.
+func convertShares(paid planetscale.Money, shares []share, rate float64) (planetscale.Money, []share, error) {
+	if rate == 1 {
+		return paid, shares, nil
+	}
+
+	var owed planetscale.Money
+	weights := make([]int64, len(shares))
+	for i, share := range shares {
+		weights[i] = int64(share.amount)
+		owed += share.amount
+	}
+
+	convertedPaid := paid.Convert(rate)
+	convertedOwed := owed.Convert(rate)
+	if owed == paid {
+		convertedOwed = convertedPaid
+	}
+	if owed == 0 {
+		return convertedPaid, shares, nil
+	}
+
+	amounts, err := convertedOwed.Allocate(weights)
+	if err != nil {
+		return 0, nil, err
+	}
+	converted := make([]share, len(shares))
+	for i, share := range shares {
+		converted[i] = share
+		converted[i].amount = amounts[i]
+	}
+	return convertedPaid, converted, nil
+}
+
+func (s *balanceService) equalShares(tx *sql.Tx, expense *planetscale.Expense) ([]share, error) {
+	participants, err := s.participants(tx, expense)
+	if err != nil {
+		return nil, err
+	}
+
+	// penny distribution: the first participants absorb the cents that do
+	// not divide evenly
+	amounts := expense.Amount.Split(len(participants))
+	shares := make([]share, len(participants))
+	for i, participant := range participants {
+		shares[i] = share{userID: participant.UserID, amount: amounts[i]}
+	}
+	return shares, nil
+}
+
+// participants returns the participants of an expense, taking those loaded by
+// loadShares when there are.
+func (s *balanceService) participants(tx *sql.Tx, expense *planetscale.Expense) ([]*planetscale.ExpenseParticipant, error) {
+	if expense.Participants != nil {
+		return expense.Participants, nil
+	}
+	return s.repos.ExpenseParticipant.Find(tx, planetscale.ExpenseParticipantFilter{
+		ExpenseID: expense.ExpenseID,
+	})
+}
+
+// amountOwedShares handles split types whose participants carry an explicit
+// amount owed, which CreateExpense computes for Unequal, ShareBased and
+// PercentageBased expenses.
+func (s *balanceService) amountOwedShares(tx *sql.Tx, expense *planetscale.Expense) ([]share, error) {
+	participants, err := s.participants(tx, expense)
+	if err != nil {
+		return nil, err
+	}
+
+	shares := make([]share, len(participants))
+	for i, participant := range participants {
+		shares[i] = share{userID: participant.UserID, amount: participant.AmountOwed}
+	}
+	return shares, nil
+}
+
+// itemizedShares allocates an item based expense from its items. Expenses
+// whose items carry no splits, such as imported ones, fall back to the amounts
+// owed of their participants.
+func (s *balanceService) itemizedShares(tx *sql.Tx, expense *planetscale.Expense) ([]share, error) {
+	items := expense.Items
+	if items == nil {
+		var err error
+		items, err = s.repos.Item.Find(tx, planetscale.ItemFilter{
+			ExpenseID: expense.ExpenseID,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			item.Splits, err = s.repos.ItemSplit.Find(tx, planetscale.ItemSplitFilter{
+				ItemID: item.ItemID,
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	hasSplits := false
+	for _, item := range items {
+		hasSplits = hasSplits || len(item.Splits) > 0
+	}
+	if !hasSplits {
+		return s.amountOwedShares(tx, expense)
+	}
+
+	shares, _, err := allocateItems(items, expense.PaidBy, receiptAdjustment(expense))
+	return shares, err
 }
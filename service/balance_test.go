@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"testing"
+	"time"
 
 	planetscale "github.com/harshav17/planet_scale"
 	db_mock "github.com/harshav17/planet_scale/mock/db"
@@ -49,14 +50,14 @@ func TestBalanceService_GetGroupBalances_TwoParticipants(t *testing.T) {
 			ExpenseID:   1,
 			GroupID:     &groupID,
 			PaidBy:      "test-user-id",
-			Amount:      200,
+			Amount:      200_00,
 			SplitTypeID: 1,
 		},
 		{
 			ExpenseID:   2,
 			GroupID:     &groupID,
 			PaidBy:      "test-user-id-2",
-			Amount:      100,
+			Amount:      100_00,
 			SplitTypeID: 1,
 		},
 	}
@@ -70,16 +71,16 @@ func TestBalanceService_GetGroupBalances_TwoParticipants(t *testing.T) {
 			expected: []*planetscale.Balance{
 				{
 					UserID: "test-user-id",
-					Amount: 50,
-					BalanceItems: map[string]float64{
-						"test-user-id-2": 50,
+					Amount: 50_00,
+					BalanceItems: map[string]planetscale.Money{
+						"test-user-id-2": 50_00,
 					},
 				},
 				{
 					UserID: "test-user-id-2",
-					Amount: -50,
-					BalanceItems: map[string]float64{
-						"test-user-id": -50,
+					Amount: -50_00,
+					BalanceItems: map[string]planetscale.Money{
+						"test-user-id": -50_00,
 					},
 				},
 			},
@@ -93,7 +94,7 @@ func TestBalanceService_GetGroupBalances_TwoParticipants(t *testing.T) {
 					GroupID:      1,
 					PaidBy:       "test-user-id-2",
 					PaidTo:       "test-user-id",
-					Amount:       50,
+					Amount:       50_00,
 				},
 			},
 			participants: twoExpenseParticipants,
@@ -101,14 +102,14 @@ func TestBalanceService_GetGroupBalances_TwoParticipants(t *testing.T) {
 				{
 					UserID: "test-user-id",
 					Amount: 0,
-					BalanceItems: map[string]float64{
+					BalanceItems: map[string]planetscale.Money{
 						"test-user-id-2": 0,
 					},
 				},
 				{
 					UserID: "test-user-id-2",
 					Amount: 0,
-					BalanceItems: map[string]float64{
+					BalanceItems: map[string]planetscale.Money{
 						"test-user-id": 0,
 					},
 				},
@@ -123,14 +124,14 @@ func TestBalanceService_GetGroupBalances_TwoParticipants(t *testing.T) {
 					GroupID:      1,
 					PaidBy:       "test-user-id-2",
 					PaidTo:       "test-user-id",
-					Amount:       25,
+					Amount:       25_00,
 				},
 				{
 					SettlementID: 2,
 					GroupID:      1,
 					PaidBy:       "test-user-id-2",
 					PaidTo:       "test-user-id",
-					Amount:       25,
+					Amount:       25_00,
 				},
 			},
 			participants: twoExpenseParticipants,
@@ -140,14 +141,14 @@ func TestBalanceService_GetGroupBalances_TwoParticipants(t *testing.T) {
 				{
 					UserID: "test-user-id",
 					Amount: 0,
-					BalanceItems: map[string]float64{
+					BalanceItems: map[string]planetscale.Money{
 						"test-user-id-2": 0,
 					},
 				},
 				{
 					UserID: "test-user-id-2",
 					Amount: 0,
-					BalanceItems: map[string]float64{
+					BalanceItems: map[string]planetscale.Money{
 						"test-user-id": 0,
 					},
 				},
@@ -197,14 +198,14 @@ func TestBalanceService_GetGroupBalances_ThreeParticipants(t *testing.T) {
 			ExpenseID:   1,
 			GroupID:     &groupID,
 			PaidBy:      "test-user-id",
-			Amount:      300,
+			Amount:      300_00,
 			SplitTypeID: 1,
 		},
 		{
 			ExpenseID:   2,
 			GroupID:     &groupID,
 			PaidBy:      "test-user-id-2",
-			Amount:      200,
+			Amount:      200_00,
 			SplitTypeID: 1,
 		},
 	}
@@ -220,26 +221,82 @@ func TestBalanceService_GetGroupBalances_ThreeParticipants(t *testing.T) {
 			expected: []*planetscale.Balance{
 				{
 					UserID: "test-user-id",
-					Amount: 200,
-					BalanceItems: map[string]float64{
-						"test-user-id-2": 100,
-						"test-user-id-3": 100,
+					Amount: 200_00,
+					BalanceItems: map[string]planetscale.Money{
+						"test-user-id-2": 100_00,
+						"test-user-id-3": 100_00,
 					},
 				},
 				{
 					UserID: "test-user-id-2",
 					Amount: 0,
-					BalanceItems: map[string]float64{
-						"test-user-id":   -100,
-						"test-user-id-3": 100,
+					BalanceItems: map[string]planetscale.Money{
+						"test-user-id":   -100_00,
+						"test-user-id-3": 100_00,
 					},
 				},
 				{
 					UserID: "test-user-id-3",
-					Amount: -200,
-					BalanceItems: map[string]float64{
-						"test-user-id":   -100,
-						"test-user-id-2": -100,
+					Amount: -200_00,
+					BalanceItems: map[string]planetscale.Money{
+						"test-user-id":   -100_00,
+						"test-user-id-2": -100_00,
+					},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			testGetBalancesHelper(t, test.expenses, test.settlements, test.participants, test.items, test.itemSplits, test.expected)
+		})
+	}
+}
+
+func TestBalanceService_GetGroupBalances_PennyDistribution(t *testing.T) {
+	groupID := int64(1)
+	tests := []test{
+		{
+			name: "100 split three ways",
+			expenses: []*planetscale.Expense{
+				{
+					ExpenseID:   1,
+					GroupID:     &groupID,
+					PaidBy:      "test-user-id",
+					Amount:      100_00,
+					SplitTypeID: planetscale.SplitTypeEqual,
+				},
+			},
+			settlements: []*planetscale.Settlement{},
+			participants: []*planetscale.ExpenseParticipant{
+				{ExpenseID: 1, UserID: "test-user-id"},
+				{ExpenseID: 1, UserID: "test-user-id-2"},
+				{ExpenseID: 1, UserID: "test-user-id-3"},
+			},
+			items:      []*planetscale.Item{},
+			itemSplits: []*planetscale.ItemSplit{},
+			expected: []*planetscale.Balance{
+				{
+					UserID: "test-user-id",
+					Amount: 66_66,
+					BalanceItems: map[string]planetscale.Money{
+						"test-user-id-2": 33_33,
+						"test-user-id-3": 33_33,
+					},
+				},
+				{
+					UserID: "test-user-id-2",
+					Amount: -33_33,
+					BalanceItems: map[string]planetscale.Money{
+						"test-user-id": -33_33,
+					},
+				},
+				{
+					UserID: "test-user-id-3",
+					Amount: -33_33,
+					BalanceItems: map[string]planetscale.Money{
+						"test-user-id": -33_33,
 					},
 				},
 			},
@@ -255,25 +312,26 @@ func TestBalanceService_GetGroupBalances_ThreeParticipants(t *testing.T) {
 
 func TestBalanceService_GetGroupBalances_ItemizedSplit(t *testing.T) {
 	groupID := int64(1)
+	userID, userID2, userID3 := "test-user-id", "test-user-id-2", "test-user-id-3"
 	var items = []*planetscale.Item{
 		{
 			ItemID:    1,
 			Name:      "test item",
-			Price:     100,
+			Price:     100_00,
 			Quantity:  1,
 			ExpenseID: 1,
 		},
 		{
 			ItemID:    2,
 			Name:      "test item 2",
-			Price:     200,
+			Price:     200_00,
 			Quantity:  2,
 			ExpenseID: 1,
 		},
 		{
 			ItemID:    3,
 			Name:      "test item 3",
-			Price:     300,
+			Price:     300_00,
 			Quantity:  3,
 			ExpenseID: 1,
 		},
@@ -283,32 +341,32 @@ func TestBalanceService_GetGroupBalances_ItemizedSplit(t *testing.T) {
 		{
 			ItemSplitID: 1,
 			ItemID:      1,
-			UserID:      "test-user-id",
+			UserID:      &userID,
 		},
 		{
 			ItemSplitID: 2,
 			ItemID:      2,
-			UserID:      "test-user-id",
+			UserID:      &userID,
 		},
 		{
 			ItemSplitID: 3,
 			ItemID:      2,
-			UserID:      "test-user-id-2",
+			UserID:      &userID2,
 		},
 		{
 			ItemSplitID: 4,
 			ItemID:      3,
-			UserID:      "test-user-id",
+			UserID:      &userID,
 		},
 		{
 			ItemSplitID: 5,
 			ItemID:      3,
-			UserID:      "test-user-id-2",
+			UserID:      &userID2,
 		},
 		{
 			ItemSplitID: 6,
 			ItemID:      3,
-			UserID:      "test-user-id-3",
+			UserID:      &userID3,
 		},
 	}
 
@@ -317,7 +375,20 @@ func TestBalanceService_GetGroupBalances_ItemizedSplit(t *testing.T) {
 			ExpenseID:   1,
 			GroupID:     &groupID,
 			PaidBy:      "test-user-id",
-			Amount:      600,
+			Amount:      1400_00,
+			SplitTypeID: 3,
+		},
+	}
+
+	var expenseWithAdjustments = []*planetscale.Expense{
+		{
+			ExpenseID:   1,
+			GroupID:     &groupID,
+			PaidBy:      "test-user-id",
+			Amount:      1500_00,
+			Tax:         70_00,
+			Tip:         90_00,
+			Discount:    60_00,
 			SplitTypeID: 3,
 		},
 	}
@@ -333,24 +404,216 @@ func TestBalanceService_GetGroupBalances_ItemizedSplit(t *testing.T) {
 			expected: []*planetscale.Balance{
 				{
 					UserID: "test-user-id",
-					Amount: 300,
-					BalanceItems: map[string]float64{
-						"test-user-id-2": 200,
-						"test-user-id-3": 100,
+					Amount: 800_00,
+					BalanceItems: map[string]planetscale.Money{
+						"test-user-id-2": 500_00,
+						"test-user-id-3": 300_00,
+					},
+				},
+				{
+					UserID: "test-user-id-2",
+					Amount: -500_00,
+					BalanceItems: map[string]planetscale.Money{
+						"test-user-id": -500_00,
+					},
+				},
+				{
+					UserID: "test-user-id-3",
+					Amount: -300_00,
+					BalanceItems: map[string]planetscale.Money{
+						"test-user-id": -300_00,
+					},
+				},
+			},
+		},
+		{
+			name:         "adjustments are shared in proportion to the items",
+			expenses:     expenseWithAdjustments,
+			settlements:  []*planetscale.Settlement{},
+			participants: []*planetscale.ExpenseParticipant{},
+			items:        items,
+			itemSplits:   itemSplits,
+			expected: []*planetscale.Balance{
+				{
+					UserID: "test-user-id",
+					Amount: 857_14,
+					BalanceItems: map[string]planetscale.Money{
+						"test-user-id-2": 535_72,
+						"test-user-id-3": 321_42,
+					},
+				},
+				{
+					UserID: "test-user-id-2",
+					Amount: -535_72,
+					BalanceItems: map[string]planetscale.Money{
+						"test-user-id": -535_72,
+					},
+				},
+				{
+					UserID: "test-user-id-3",
+					Amount: -321_42,
+					BalanceItems: map[string]planetscale.Money{
+						"test-user-id": -321_42,
+					},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			testGetBalancesHelper(t, test.expenses, test.settlements, test.participants, test.items, test.itemSplits, test.expected)
+		})
+	}
+}
+
+func TestBalanceService_GetGroupBalances_GuestItemSplit(t *testing.T) {
+	groupID := int64(1)
+	userID2, guest := "test-user-id-2", "JD"
+	items := []*planetscale.Item{
+		{ItemID: 1, Name: "test item", Price: 15_00, Quantity: 2, ExpenseID: 1},
+	}
+	itemSplits := []*planetscale.ItemSplit{
+		{ItemSplitID: 1, ItemID: 1, UserID: &userID2},
+		{ItemSplitID: 2, ItemID: 1, GuestName: &guest},
+	}
+	expenses := []*planetscale.Expense{
+		{ExpenseID: 1, GroupID: &groupID, PaidBy: "test-user-id", Amount: 30_00, SplitTypeID: planetscale.SplitTypeItemBased},
+	}
+
+	// the payer covers the guest until the split is claimed
+	testGetBalancesHelper(t, expenses, []*planetscale.Settlement{}, []*planetscale.ExpenseParticipant{}, items, itemSplits, []*planetscale.Balance{
+		{
+			UserID: "test-user-id",
+			Amount: 15_00,
+			BalanceItems: map[string]planetscale.Money{
+				"test-user-id-2": 15_00,
+			},
+		},
+		{
+			UserID: "test-user-id-2",
+			Amount: -15_00,
+			BalanceItems: map[string]planetscale.Money{
+				"test-user-id": -15_00,
+			},
+		},
+	})
+}
+
+func TestBalanceService_GetGroupBalances_AmountOwedSplit(t *testing.T) {
+	groupID := int64(1)
+	var participants = []*planetscale.ExpenseParticipant{
+		{
+			ExpenseID:  1,
+			UserID:     "test-user-id",
+			AmountOwed: 30_00,
+		},
+		{
+			ExpenseID:  1,
+			UserID:     "test-user-id-2",
+			AmountOwed: 70_00,
+		},
+		{
+			ExpenseID:  2,
+			UserID:     "test-user-id",
+			AmountOwed: 15_00,
+		},
+		{
+			ExpenseID:  2,
+			UserID:     "test-user-id-3",
+			AmountOwed: 45_00,
+		},
+	}
+
+	var expenses = []*planetscale.Expense{
+		{
+			ExpenseID:   1,
+			GroupID:     &groupID,
+			PaidBy:      "test-user-id",
+			Amount:      100_00,
+			SplitTypeID: planetscale.SplitTypeUnequal,
+		},
+		{
+			ExpenseID:   2,
+			GroupID:     &groupID,
+			PaidBy:      "test-user-id",
+			Amount:      60_00,
+			SplitTypeID: planetscale.SplitTypePercentageBased,
+		},
+	}
+
+	tests := []test{
+		{
+			name:         "2 and 3 owe 1",
+			expenses:     expenses,
+			settlements:  []*planetscale.Settlement{},
+			participants: participants,
+			items:        []*planetscale.Item{},
+			itemSplits:   []*planetscale.ItemSplit{},
+			expected: []*planetscale.Balance{
+				{
+					UserID: "test-user-id",
+					Amount: 115_00,
+					BalanceItems: map[string]planetscale.Money{
+						"test-user-id-2": 70_00,
+						"test-user-id-3": 45_00,
 					},
 				},
 				{
 					UserID: "test-user-id-2",
-					Amount: -200,
-					BalanceItems: map[string]float64{
-						"test-user-id": -200,
+					Amount: -70_00,
+					BalanceItems: map[string]planetscale.Money{
+						"test-user-id": -70_00,
 					},
 				},
 				{
 					UserID: "test-user-id-3",
-					Amount: -100,
-					BalanceItems: map[string]float64{
-						"test-user-id": -100,
+					Amount: -45_00,
+					BalanceItems: map[string]planetscale.Money{
+						"test-user-id": -45_00,
+					},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			testGetBalancesHelper(t, test.expenses, test.settlements, test.participants, test.items, test.itemSplits, test.expected)
+		})
+	}
+}
+
+func TestBalanceService_GetGroupBalances_SettlementOnly(t *testing.T) {
+	tests := []test{
+		{
+			name:     "settlement without expenses",
+			expenses: []*planetscale.Expense{},
+			settlements: []*planetscale.Settlement{
+				{
+					SettlementID: 1,
+					GroupID:      1,
+					PaidBy:       "test-user-id-2",
+					PaidTo:       "test-user-id",
+					Amount:       20_00,
+				},
+			},
+			participants: []*planetscale.ExpenseParticipant{},
+			items:        []*planetscale.Item{},
+			itemSplits:   []*planetscale.ItemSplit{},
+			expected: []*planetscale.Balance{
+				{
+					UserID: "test-user-id",
+					Amount: -20_00,
+					BalanceItems: map[string]planetscale.Money{
+						"test-user-id-2": -20_00,
+					},
+				},
+				{
+					UserID: "test-user-id-2",
+					Amount: 20_00,
+					BalanceItems: map[string]planetscale.Money{
+						"test-user-id": 20_00,
 					},
 				},
 			},
@@ -377,7 +640,13 @@ func testGetBalancesHelper(
 		return fn(nil)
 	}
 	repos := planetscale.RepoProvider{}
-	balanceService := NewBalanceService(&repos, tm)
+	balanceService := NewBalanceService(&repos, tm, nil)
+
+	balanceService.repos.ExpenseGroup = &db_mock.ExpenseGroupRepo{
+		GetFn: func(tx *sql.Tx, groupID int64) (*planetscale.ExpenseGroup, error) {
+			return &planetscale.ExpenseGroup{ExpenseGroupID: groupID, BaseCurrency: "USD"}, nil
+		},
+	}
 
 	balanceService.repos.Expense = &db_mock.ExpenseRepo{
 		FindFn: func(tx *sql.Tx, filter planetscale.ExpenseFilter) ([]*planetscale.Expense, error) {
@@ -493,3 +762,117 @@ func compareBalances(expected []*planetscale.Balance, got []*planetscale.Balance
 
 	return true
 }
+
+func TestBalanceService_GetUserBalance(t *testing.T) {
+	groupID := int64(1)
+	tm := db_mock.TransactionManager{}
+	tm.ExecuteInTxFn = func(ctx context.Context, fn func(*sql.Tx) error) error {
+		return fn(nil)
+	}
+	balanceService := NewBalanceService(&planetscale.RepoProvider{}, tm, nil)
+
+	balanceService.repos.ExpenseGroup = &db_mock.ExpenseGroupRepo{
+		ListAllForUserFn: func(tx *sql.Tx, userID string) ([]*planetscale.ExpenseGroup, error) {
+			return []*planetscale.ExpenseGroup{
+				{ExpenseGroupID: 1, BaseCurrency: "USD"},
+				{ExpenseGroupID: 2, BaseCurrency: "EUR"},
+			}, nil
+		},
+	}
+	// test-user-id paid 40 in group 1 for both of them and got 5 back, group 2
+	// has nothing yet and test-user-id-3 paid 30 directly for both of them
+	var queries int
+	balanceService.repos.Expense = &db_mock.ExpenseRepo{
+		FindFn: func(tx *sql.Tx, filter planetscale.ExpenseFilter) ([]*planetscale.Expense, error) {
+			queries++
+			switch {
+			case filter.Direct:
+				return []*planetscale.Expense{
+					{ExpenseID: 2, PaidBy: "test-user-id-3", Amount: 30_00, Currency: "USD", SplitTypeID: planetscale.SplitTypeEqual, Timestamp: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+				}, nil
+			case len(filter.GroupIDs) == 2:
+				return []*planetscale.Expense{
+					{ExpenseID: 1, GroupID: &groupID, PaidBy: "test-user-id", Amount: 40_00, Currency: "USD", SplitTypeID: planetscale.SplitTypeEqual, Timestamp: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+				}, nil
+			}
+			return nil, nil
+		},
+	}
+	balanceService.repos.Settlement = &db_mock.SettlementRepo{
+		FindFn: func(tx *sql.Tx, filter planetscale.SettlementFilter) ([]*planetscale.Settlement, error) {
+			queries++
+			return []*planetscale.Settlement{
+				{SettlementID: 1, GroupID: groupID, PaidBy: "test-user-id-2", PaidTo: "test-user-id", Amount: 5_00, Currency: "USD"},
+			}, nil
+		},
+	}
+	participants := []*planetscale.ExpenseParticipant{
+		{ExpenseID: 1, UserID: "test-user-id"},
+		{ExpenseID: 1, UserID: "test-user-id-2"},
+		{ExpenseID: 2, UserID: "test-user-id"},
+		{ExpenseID: 2, UserID: "test-user-id-3"},
+	}
+	balanceService.repos.ExpenseParticipant = &db_mock.ExpenseParticipantRepo{
+		FindFn: func(tx *sql.Tx, filter planetscale.ExpenseParticipantFilter) ([]*planetscale.ExpenseParticipant, error) {
+			queries++
+			var found []*planetscale.ExpenseParticipant
+			for _, expenseID := range filter.ExpenseIDs {
+				found = append(found, filterParitipantsByExpenseID(participants, expenseID)...)
+			}
+			return found, nil
+		},
+	}
+
+	t.Run("user balance", func(t *testing.T) {
+		queries = 0
+		balance, err := balanceService.GetUserBalance(context.Background(), "test-user-id", "usd")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if balance.Currency != "USD" {
+			t.Fatalf("expected currency USD, got %s", balance.Currency)
+		} else if len(balance.Groups) != 2 {
+			t.Fatalf("expected 2 groups, got %d", len(balance.Groups))
+		} else if balance.Groups[0].Amount != 15_00 || balance.Groups[1].Amount != 0 || balance.Groups[1].Currency != "EUR" {
+			t.Fatalf("expected 15 in group 1 and nothing in group 2, got %+v %+v", balance.Groups[0], balance.Groups[1])
+		} else if balance.Friends["test-user-id-3"] != -15_00 {
+			t.Fatalf("expected to owe test-user-id-3 15, got %s", balance.Friends["test-user-id-3"])
+		} else if balance.Owed != 15_00 || balance.Owing != 15_00 || balance.Amount != 0 {
+			t.Fatalf("expected owed 15, owing 15 and net 0, got %s %s %s", balance.Owed, balance.Owing, balance.Amount)
+		} else if queries != 4 {
+			t.Fatalf("expected 4 queries, got %d", queries)
+		}
+	})
+
+	t.Run("dashboard", func(t *testing.T) {
+		queries = 0
+		dashboard, err := balanceService.GetDashboard(context.Background(), "test-user-id", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if dashboard.Currency != "USD" {
+			t.Fatalf("expected currency USD, got %s", dashboard.Currency)
+		} else if len(dashboard.Groups) != 2 || dashboard.Groups[0].ExpenseGroupID != 1 || dashboard.Groups[0].Balance != 15_00 {
+			t.Fatalf("expected a balance of 15 in group 1, got %+v", dashboard.Groups)
+		} else if dashboard.Owed != 15_00 || dashboard.Owing != 15_00 {
+			t.Fatalf("expected owed 15 and owing 15, got %s %s", dashboard.Owed, dashboard.Owing)
+		} else if len(dashboard.RecentExpenses) != 2 || dashboard.RecentExpenses[0].ExpenseID != 2 {
+			t.Fatalf("expected the direct expense to be the latest, got %+v", dashboard.RecentExpenses)
+		} else if len(dashboard.RecentExpenses[0].Participants) != 2 {
+			t.Fatalf("expected the participants to be loaded, got %d", len(dashboard.RecentExpenses[0].Participants))
+		} else if len(dashboard.RecentSettlements) != 1 {
+			t.Fatalf("expected 1 settlement, got %d", len(dashboard.RecentSettlements))
+		} else if queries != 4 {
+			t.Fatalf("expected 4 queries, got %d", queries)
+		}
+	})
+
+	t.Run("invalid currency", func(t *testing.T) {
+		_, err := balanceService.GetDashboard(context.Background(), "test-user-id", "dollars")
+		if planetscale.ErrorCode(err) != planetscale.EINVALID {
+			t.Fatalf("expected invalid error, got %v", err)
+		}
+	})
+}
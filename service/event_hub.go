@@ -0,0 +1,143 @@
+package service
+
+import (
+	"sync"
+	"time"
+
+	planetscale "github.com/harshav17/planet_scale"
+)
+
+const (
+	// eventBufferSize is how many of the latest events of a group are kept
+	// for clients that reconnect.
+	eventBufferSize = 100
+	// eventSubscriberBuffer is how many events a subscriber may fall behind
+	// before it is dropped.
+	eventSubscriberBuffer = 16
+)
+
+type eventHub struct {
+	mu sync.Mutex
+	// event ids start at the time the hub was created, so that they keep
+	// growing across restarts and ids of an earlier process can be told
+	// apart
+	firstID int64
+	nextID  int64
+	groups  map[int64]*eventGroup
+	closed  bool
+
+	// Returns the current time. Defaults to time.Now().
+	// Can be mocked for tests.
+	Now func() time.Time
+}
+
+type eventGroup struct {
+	events []*planetscale.Event
+	// dropped is the id of the latest event pushed out of events
+	dropped     int64
+	subscribers map[chan *planetscale.Event]struct{}
+}
+
+func NewEventHub() *eventHub {
+	firstID := time.Now().UnixMilli()
+	return &eventHub{
+		firstID: firstID,
+		nextID:  firstID,
+		groups:  make(map[int64]*eventGroup),
+		Now:     time.Now,
+	}
+}
+
+func (h *eventHub) Publish(event *planetscale.Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return
+	}
+
+	event.EventID = h.nextID
+	event.CreatedAt = h.Now().UTC()
+	h.nextID++
+
+	group := h.group(event.GroupID)
+	group.events = append(group.events, event)
+	if len(group.events) > eventBufferSize {
+		group.dropped = group.events[0].EventID
+		group.events = group.events[1:]
+	}
+
+	for ch := range group.subscribers {
+		select {
+		case ch <- event:
+		default:
+			// the client reconnects and resumes from the buffer
+			delete(group.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+func (h *eventHub) Subscribe(groupID int64, lastEventID int64) (*planetscale.EventSubscription, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return nil, planetscale.Errorf(planetscale.EINTERNAL, "event hub closed")
+	}
+
+	group := h.group(groupID)
+	subscription := &planetscale.EventSubscription{
+		LastEventID: h.nextID - 1,
+	}
+	if lastEventID > 0 {
+		if lastEventID < h.firstID-1 || lastEventID < group.dropped || lastEventID >= h.nextID {
+			subscription.Missed = true
+		} else {
+			for _, event := range group.events {
+				if event.EventID > lastEventID {
+					subscription.Replay = append(subscription.Replay, event)
+				}
+			}
+		}
+	}
+
+	ch := make(chan *planetscale.Event, eventSubscriberBuffer)
+	group.subscribers[ch] = struct{}{}
+	subscription.Events = ch
+	subscription.Cancel = func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if _, ok := group.subscribers[ch]; ok {
+			delete(group.subscribers, ch)
+			close(ch)
+		}
+	}
+	return subscription, nil
+}
+
+func (h *eventHub) Close() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return nil
+	}
+	h.closed = true
+	for _, group := range h.groups {
+		for ch := range group.subscribers {
+			delete(group.subscribers, ch)
+			close(ch)
+		}
+	}
+	return nil
+}
+
+// group returns the events and subscribers of a group, h.mu must be held.
+func (h *eventHub) group(groupID int64) *eventGroup {
+	group, ok := h.groups[groupID]
+	if !ok {
+		group = &eventGroup{
+			subscribers: make(map[chan *planetscale.Event]struct{}),
+		}
+		h.groups[groupID] = group
+	}
+	return group
+}
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"testing"
+
+	planetscale "github.com/harshav17/planet_scale"
+)
+
+func TestEventHub(t *testing.T) {
+	publish := func(hub *eventHub, groupID int64, n int) []*planetscale.Event {
+		var events []*planetscale.Event
+		for i := 0; i < n; i++ {
+			event := &planetscale.Event{GroupID: groupID, Type: "expense.created"}
+			hub.Publish(event)
+			events = append(events, event)
+		}
+		return events
+	}
+
+	t.Run("live events of a group", func(t *testing.T) {
+		hub := NewEventHub()
+		subscription, err := hub.Subscribe(1, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer subscription.Cancel()
+
+		publish(hub, 2, 1)
+		published := publish(hub, 1, 1)[0]
+		select {
+		case event := <-subscription.Events:
+			if event.EventID != published.EventID {
+				t.Fatalf("expected event %d, got %d", published.EventID, event.EventID)
+			}
+		default:
+			t.Fatal("expected an event")
+		}
+		if len(subscription.Events) != 0 {
+			t.Fatal("expected no events of other groups")
+		}
+	})
+
+	t.Run("resume", func(t *testing.T) {
+		hub := NewEventHub()
+		events := publish(hub, 1, 3)
+
+		subscription, err := hub.Subscribe(1, events[0].EventID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer subscription.Cancel()
+		if subscription.Missed {
+			t.Fatal("expected nothing to be missed")
+		} else if len(subscription.Replay) != 2 || subscription.Replay[0] != events[1] {
+			t.Fatalf("expected the last 2 events to be replayed, got %+v", subscription.Replay)
+		} else if subscription.LastEventID != events[2].EventID {
+			t.Fatalf("expected last event %d, got %d", events[2].EventID, subscription.LastEventID)
+		}
+	})
+
+	t.Run("missed", func(t *testing.T) {
+		hub := NewEventHub()
+		events := publish(hub, 1, eventBufferSize+2)
+
+		tests := []struct {
+			name        string
+			lastEventID int64
+		}{
+			{"no longer kept", events[0].EventID},
+			{"earlier process", 1},
+			{"unknown", events[len(events)-1].EventID + 1},
+		}
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				subscription, err := hub.Subscribe(1, test.lastEventID)
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer subscription.Cancel()
+				if !subscription.Missed || subscription.Replay != nil {
+					t.Fatalf("expected missed events without replay, got %+v", subscription)
+				}
+			})
+		}
+
+		// the oldest event kept follows the one seen last
+		subscription, err := hub.Subscribe(1, events[1].EventID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer subscription.Cancel()
+		if subscription.Missed || len(subscription.Replay) != eventBufferSize {
+			t.Fatalf("expected %d events to be replayed, got %d", eventBufferSize, len(subscription.Replay))
+		}
+	})
+
+	t.Run("slow subscriber", func(t *testing.T) {
+		hub := NewEventHub()
+		subscription, err := hub.Subscribe(1, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer subscription.Cancel()
+
+		publish(hub, 1, eventSubscriberBuffer+1)
+		n := 0
+		for range subscription.Events {
+			n++
+		}
+		if n != eventSubscriberBuffer {
+			t.Fatalf("expected %d events before being dropped, got %d", eventSubscriberBuffer, n)
+		}
+	})
+
+	t.Run("close", func(t *testing.T) {
+		hub := NewEventHub()
+		subscription, err := hub.Subscribe(1, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := hub.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := <-subscription.Events; ok {
+			t.Fatal("expected the subscription to end")
+		}
+		subscription.Cancel()
+
+		_, err = hub.Subscribe(1, 0)
+		if planetscale.ErrorCode(err) != planetscale.EINTERNAL {
+			t.Fatalf("expected %s, got %v", planetscale.EINTERNAL, err)
+		}
+		if err := hub.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
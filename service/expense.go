@@ -3,30 +3,42 @@ package service
 import (
 	"context"
 	"database/sql"
+	"math"
+	"strconv"
 
 	planetscale "github.com/harshav17/planet_scale"
 )
 
 type expenseService struct {
-	repos *planetscale.RepoProvider
-	tm    planetscale.TransactionManager
+	repos  *planetscale.RepoProvider
+	tm     planetscale.TransactionManager
+	events planetscale.EventHub
 }
 
-func NewExpenseService(repoProvider *planetscale.RepoProvider, tm planetscale.TransactionManager) *expenseService {
+func NewExpenseService(repoProvider *planetscale.RepoProvider, tm planetscale.TransactionManager, events planetscale.EventHub) *expenseService {
 	return &expenseService{
-		repos: repoProvider,
-		tm:    tm,
+		repos:  repoProvider,
+		tm:     tm,
+		events: events,
 	}
 }
 
 func (s *expenseService) CreateExpense(ctx context.Context, expense *planetscale.Expense) error {
+	// validate and compute participant amounts up front so that a bad request
+	// never opens a transaction
+	if err := allocate(expense); err != nil {
+		return err
+	}
+
+	var audit *planetscale.AuditEvent
 	createExpenseFunc := func(tx *sql.Tx) error {
 		err := s.repos.Expense.Create(tx, expense)
 		if err != nil {
 			return err
 		}
 
-		if expense.SplitTypeID == 1 {
+		switch expense.SplitTypeID {
+		case planetscale.SplitTypeEqual:
 			if len(expense.Participants) == 0 {
 				members, err := s.repos.GroupMember.Find(tx, planetscale.GroupMemberFilter{
 					GroupID: *expense.GroupID,
@@ -55,15 +67,629 @@ func (s *expenseService) CreateExpense(ctx context.Context, expense *planetscale
 					}
 				}
 			}
+		case planetscale.SplitTypeUnequal, planetscale.SplitTypeShareBased, planetscale.SplitTypePercentageBased:
+			for _, participant := range expense.Participants {
+				participant.ExpenseID = expense.ExpenseID
+				err := s.repos.ExpenseParticipant.Create(tx, participant)
+				if err != nil {
+					return err
+				}
+			}
+		case planetscale.SplitTypeItemBased:
+			for _, item := range expense.Items {
+				item.ExpenseID = expense.ExpenseID
+				err := s.repos.Item.Create(tx, item)
+				if err != nil {
+					return err
+				}
+				for _, itemSplit := range item.Splits {
+					itemSplit.ItemID = item.ItemID
+					err := s.repos.ItemSplit.Create(tx, itemSplit)
+					if err != nil {
+						return err
+					}
+				}
+			}
+			for _, participant := range expense.Participants {
+				participant.ExpenseID = expense.ExpenseID
+				err := s.repos.ExpenseParticipant.Create(tx, participant)
+				if err != nil {
+					return err
+				}
+			}
 		}
 
-		return nil
+		audit = &planetscale.AuditEvent{
+			GroupID:    expense.GroupID,
+			EntityType: planetscale.AuditEntityExpense,
+			EntityID:   strconv.FormatInt(expense.ExpenseID, 10),
+			Action:     planetscale.AuditActionCreate,
+			ActorID:    planetscale.ActorFromContext(ctx, expense.CreatedBy),
+			After:      expense,
+		}
+		err = s.repos.AuditEvent.Create(tx, audit)
+		if err != nil {
+			return err
+		}
+		err = s.repos.WebhookEvent.Create(tx, planetscale.NewWebhookEvent(audit))
+		if err != nil {
+			return err
+		}
+		return s.repos.Activity.Create(tx, planetscale.NewActivity(audit))
 	}
 
 	err := s.tm.ExecuteInTx(ctx, createExpenseFunc)
 	if err != nil {
 		return err
 	}
+	planetscale.Publish(s.events, audit)
+	return nil
+}
+
+func (s *expenseService) UpdateExpense(ctx context.Context, expenseID int64, update *planetscale.ExpenseUpdate, userID string) (*planetscale.Expense, *planetscale.Expense, error) {
+	if update.Amount != nil && *update.Amount <= 0 {
+		return nil, nil, planetscale.Errorf(planetscale.EINVALID, "expense amount must be greater than zero")
+	}
+	update.UpdatedBy = &userID
+
+	var before, after *planetscale.Expense
+	var audit *planetscale.AuditEvent
+	updateExpenseFunc := func(tx *sql.Tx) error {
+		var member *planetscale.GroupMember
+		var err error
+		before, member, err = planetscale.GetMemberExpense(tx, s.repos, expenseID, userID, planetscale.PermissionEdit)
+		if err != nil {
+			return err
+		}
+		err = planetscale.CheckAuthor(member, before.CreatedBy, before.PaidBy)
+		if err != nil {
+			return err
+		}
+		// the adjustments of a receipt are reconciled with its items below
+		adjusted := update.Tax != nil || update.Tip != nil || update.ServiceCharge != nil || update.Discount != nil
+		if adjusted && before.SplitTypeID != planetscale.SplitTypeItemBased {
+			return planetscale.Errorf(planetscale.EINVALID, "only item based expenses have tax, tip, service charge or discount")
+		}
+		// moving an expense takes the same permission in the group it moves to
+		if update.GroupID != nil {
+			_, err = planetscale.CheckPermission(tx, s.repos, *update.GroupID, userID, planetscale.PermissionEdit)
+			if err != nil {
+				return err
+			}
+		}
+		before.Participants, err = s.repos.ExpenseParticipant.Find(tx, planetscale.ExpenseParticipantFilter{
+			ExpenseID: expenseID,
+		})
+		if err != nil {
+			return err
+		}
+
+		after, err = s.repos.Expense.Update(tx, expenseID, update)
+		if err != nil {
+			return err
+		}
+
+		if after.SplitTypeID == planetscale.SplitTypeItemBased {
+			// the participants of an item based expense follow its items
+			if update.Participants != nil {
+				return planetscale.Errorf(planetscale.EINVALID, "participants of an item based expense come from its items")
+			}
+			err = s.reallocateItemized(tx, after)
+			if err != nil {
+				return err
+			}
+			after.Participants, err = s.repos.ExpenseParticipant.Find(tx, planetscale.ExpenseParticipantFilter{
+				ExpenseID: expenseID,
+			})
+			if err != nil {
+				return err
+			}
+			if after.GroupID == nil {
+				err = validateDirect(after)
+			}
+		} else {
+			err = s.replaceParticipants(tx, before, after, update.Participants)
+		}
+		if err != nil {
+			return err
+		}
+
+		audit = &planetscale.AuditEvent{
+			GroupID:    after.GroupID,
+			EntityType: planetscale.AuditEntityExpense,
+			EntityID:   strconv.FormatInt(expenseID, 10),
+			Action:     planetscale.AuditActionUpdate,
+			ActorID:    userID,
+			Before:     before,
+			After:      after,
+		}
+		err = s.repos.AuditEvent.Create(tx, audit)
+		if err != nil {
+			return err
+		}
+		err = s.repos.WebhookEvent.Create(tx, planetscale.NewWebhookEvent(audit))
+		if err != nil {
+			return err
+		}
+		return s.repos.Activity.Create(tx, planetscale.NewActivity(audit))
+	}
+
+	err := s.tm.ExecuteInTx(ctx, updateExpenseFunc)
+	if err != nil {
+		return nil, nil, err
+	}
+	planetscale.Publish(s.events, audit)
+	return before, after, nil
+}
+
+// replaceParticipants allocates the updated expense over participants, or its
+// current participants when nil, and stores the result in their place.
+func (s *expenseService) replaceParticipants(tx *sql.Tx, before *planetscale.Expense, after *planetscale.Expense, participants []*planetscale.ExpenseParticipant) error {
+	if participants == nil {
+		// copies, so that before keeps what was owed until now
+		for _, participant := range before.Participants {
+			copied := *participant
+			participants = append(participants, &copied)
+		}
+	}
+	for _, participant := range participants {
+		participant.ExpenseID = after.ExpenseID
+	}
+	after.Participants = participants
+	if err := allocate(after); err != nil {
+		return err
+	}
+
+	for _, existing := range before.Participants {
+		found := false
+		for _, participant := range after.Participants {
+			if existing.UserID == participant.UserID {
+				found = true
+				break
+			}
+		}
+		if !found {
+			err := s.repos.ExpenseParticipant.Delete(tx, after.ExpenseID, existing.UserID)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	for _, participant := range after.Participants {
+		err := s.repos.ExpenseParticipant.Upsert(tx, participant)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *expenseService) ClaimItemSplit(ctx context.Context, itemSplitID int64, userID string) (*planetscale.ItemSplit, error) {
+	var claimed *planetscale.ItemSplit
+	var audit *planetscale.AuditEvent
+	claimFunc := func(tx *sql.Tx) error {
+		itemSplit, err := s.repos.ItemSplit.Get(tx, itemSplitID)
+		if err != nil {
+			return planetscale.Errorf(planetscale.ENOTFOUND, "no item split found with ID %d", itemSplitID)
+		}
+		item, err := s.repos.Item.Get(tx, itemSplit.ItemID)
+		if err != nil {
+			return err
+		}
+		expense, err := s.repos.Expense.Get(tx, item.ExpenseID)
+		if err != nil {
+			return err
+		}
+		if expense.GroupID == nil {
+			expense.Participants, err = s.repos.ExpenseParticipant.Find(tx, planetscale.ExpenseParticipantFilter{
+				ExpenseID: expense.ExpenseID,
+			})
+			if err != nil {
+				return err
+			}
+			if !expense.Involves(userID) {
+				return planetscale.Errorf(planetscale.ENOTFOUND, "no item split found with ID %d", itemSplitID)
+			}
+		} else {
+			member, err := s.repos.GroupMember.Get(tx, *expense.GroupID, userID)
+			if err != nil {
+				return planetscale.Errorf(planetscale.ENOTFOUND, "you are not a member of this group")
+			}
+			if !member.Can(planetscale.PermissionEdit) {
+				return planetscale.Errorf(planetscale.EFORBIDDEN, "a group %s cannot claim items", member.Role)
+			}
+		}
+
+		claimed, err = s.repos.ItemSplit.Claim(tx, itemSplitID, userID)
+		if err != nil {
+			return err
+		}
+
+		if expense.SplitTypeID == planetscale.SplitTypeItemBased {
+			if err := s.reallocateItemized(tx, expense); err != nil {
+				return err
+			}
+		}
+
+		audit = &planetscale.AuditEvent{
+			GroupID:    expense.GroupID,
+			EntityType: planetscale.AuditEntityItemSplit,
+			EntityID:   strconv.FormatInt(itemSplitID, 10),
+			Action:     planetscale.AuditActionUpdate,
+			ActorID:    planetscale.ActorFromContext(ctx, userID),
+			Before:     itemSplit,
+			After:      claimed,
+		}
+		return s.recordAudit(tx, audit)
+	}
+
+	err := s.tm.ExecuteInTx(ctx, claimFunc)
+	if err != nil {
+		return nil, err
+	}
+	planetscale.Publish(s.events, audit)
+	return claimed, nil
+}
+
+// reallocateItemized replaces the participants of an item based expense with
+// what its items currently add up to.
+func (s *expenseService) reallocateItemized(tx *sql.Tx, expense *planetscale.Expense) error {
+	items, err := s.repos.Item.Find(tx, planetscale.ItemFilter{
+		ExpenseID: expense.ExpenseID,
+	})
+	if err != nil {
+		return err
+	}
+	for _, item := range items {
+		item.Splits, err = s.repos.ItemSplit.Find(tx, planetscale.ItemSplitFilter{
+			ItemID: item.ItemID,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	shares, subtotal, err := allocateItems(items, expense.PaidBy, receiptAdjustment(expense))
+	if err != nil {
+		return err
+	}
+	if err := reconcile(expense, subtotal); err != nil {
+		return err
+	}
+
+	existing, err := s.repos.ExpenseParticipant.Find(tx, planetscale.ExpenseParticipantFilter{
+		ExpenseID: expense.ExpenseID,
+	})
+	if err != nil {
+		return err
+	}
+	owed := make(map[string]bool)
+	for _, share := range shares {
+		owed[share.userID] = true
+	}
+	notes := make(map[string]string)
+	for _, participant := range existing {
+		notes[participant.UserID] = participant.Note
+		if !owed[participant.UserID] {
+			err := s.repos.ExpenseParticipant.Delete(tx, expense.ExpenseID, participant.UserID)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	for _, share := range shares {
+		err := s.repos.ExpenseParticipant.Upsert(tx, &planetscale.ExpenseParticipant{
+			ExpenseID:       expense.ExpenseID,
+			UserID:          share.userID,
+			AmountOwed:      share.amount,
+			SharePercentage: roundPercentage(float64(share.amount) / float64(expense.Amount) * 100),
+			Note:            notes[share.userID],
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// allocate validates the participants of an expense for its split type and
+// works out what each of them owes. Every write of an expense goes through it.
+func allocate(expense *planetscale.Expense) error {
+	var err error
+	switch expense.SplitTypeID {
+	case planetscale.SplitTypeEqual:
+		// listed participants are checked, the group's members are not
+		if len(expense.Participants) > 0 {
+			err = validateParticipants(expense)
+		}
+	case planetscale.SplitTypeUnequal:
+		err = allocateUnequal(expense)
+	case planetscale.SplitTypeShareBased:
+		err = allocateByShares(expense)
+	case planetscale.SplitTypePercentageBased:
+		err = allocateByPercentage(expense)
+	case planetscale.SplitTypeItemBased:
+		err = allocateItemized(expense)
+	}
+	if err != nil {
+		return err
+	}
+	if expense.GroupID == nil {
+		return validateDirect(expense)
+	}
+	return nil
+}
+
+// allocateUnequal validates that the explicit amounts owed add up to the
+// expense amount and fills in each participant's share percentage.
+func allocateUnequal(expense *planetscale.Expense) error {
+	if err := validateParticipants(expense); err != nil {
+		return err
+	}
+
+	var total planetscale.Money
+	for _, participant := range expense.Participants {
+		if participant.AmountOwed < 0 {
+			return planetscale.Errorf(planetscale.EINVALID, "amount owed for user %s cannot be negative", participant.UserID)
+		}
+		total += participant.AmountOwed
+	}
+	if total != expense.Amount {
+		return planetscale.Errorf(planetscale.EINVALID, "amounts owed add up to %s, expected %s", total, expense.Amount)
+	}
+
+	for _, participant := range expense.Participants {
+		participant.SharePercentage = roundPercentage(float64(participant.AmountOwed) / float64(expense.Amount) * 100)
+	}
+	return nil
+}
+
+// allocateByShares splits the expense amount in proportion to each
+// participant's shares.
+func allocateByShares(expense *planetscale.Expense) error {
+	if err := validateParticipants(expense); err != nil {
+		return err
+	}
+
+	var totalShares int64
+	weights := make([]int64, len(expense.Participants))
+	for i, participant := range expense.Participants {
+		if participant.Shares <= 0 {
+			return planetscale.Errorf(planetscale.EINVALID, "shares for user %s must be greater than zero", participant.UserID)
+		}
+		weights[i] = participant.Shares
+		totalShares += participant.Shares
+	}
+
+	amounts, err := expense.Amount.Allocate(weights)
+	if err != nil {
+		return err
+	}
+	for i, participant := range expense.Participants {
+		participant.AmountOwed = amounts[i]
+		participant.SharePercentage = roundPercentage(float64(participant.Shares) / float64(totalShares) * 100)
+	}
+	return nil
+}
+
+// allocateByPercentage validates that the percentages add up to 100 and
+// computes each participant's amount owed from them.
+func allocateByPercentage(expense *planetscale.Expense) error {
+	if err := validateParticipants(expense); err != nil {
+		return err
+	}
+
+	// work in hundredths of a percent, the precision of share_percentage
+	var total int64
+	weights := make([]int64, len(expense.Participants))
+	for i, participant := range expense.Participants {
+		if participant.SharePercentage <= 0 {
+			return planetscale.Errorf(planetscale.EINVALID, "share percentage for user %s must be greater than zero", participant.UserID)
+		}
+		weights[i] = int64(math.Round(participant.SharePercentage * 100))
+		total += weights[i]
+	}
+	if total != 100*100 {
+		return planetscale.Errorf(planetscale.EINVALID, "share percentages add up to %.2f, expected 100", float64(total)/100)
+	}
 
+	amounts, err := expense.Amount.Allocate(weights)
+	if err != nil {
+		return err
+	}
+	for i, participant := range expense.Participants {
+		participant.AmountOwed = amounts[i]
+	}
 	return nil
 }
+
+// allocateItemized validates that the items and receipt adjustments of an
+// item based expense reconcile with the expense amount and derives the
+// participants and their amounts owed from the item consumers.
+func allocateItemized(expense *planetscale.Expense) error {
+	if expense.Amount <= 0 {
+		return planetscale.Errorf(planetscale.EINVALID, "expense amount must be greater than zero")
+	}
+	if len(expense.Items) == 0 {
+		return planetscale.Errorf(planetscale.EINVALID, "items are required for split type %d", expense.SplitTypeID)
+	}
+	for _, item := range expense.Items {
+		if len(item.Splits) == 0 {
+			return planetscale.Errorf(planetscale.EINVALID, "item %q has no consumers", item.Name)
+		}
+	}
+
+	shares, subtotal, err := allocateItems(expense.Items, expense.PaidBy, receiptAdjustment(expense))
+	if err != nil {
+		return err
+	}
+	if err := reconcile(expense, subtotal); err != nil {
+		return err
+	}
+
+	expense.Participants = make([]*planetscale.ExpenseParticipant, len(shares))
+	for i, share := range shares {
+		expense.Participants[i] = &planetscale.ExpenseParticipant{
+			UserID:          share.userID,
+			AmountOwed:      share.amount,
+			SharePercentage: roundPercentage(float64(share.amount) / float64(expense.Amount) * 100),
+		}
+	}
+	return nil
+}
+
+// reconcile checks that the items of an item based expense, subtotal, and
+// its receipt adjustments add up to the expense amount. Both the expense
+// itself and every change of its items are checked with it.
+func reconcile(expense *planetscale.Expense, subtotal planetscale.Money) error {
+	if expense.Tax < 0 || expense.Tip < 0 || expense.ServiceCharge < 0 || expense.Discount < 0 {
+		return planetscale.Errorf(planetscale.EINVALID, "tax, tip, service charge and discount cannot be negative")
+	}
+	if total := subtotal + receiptAdjustment(expense); total != expense.Amount {
+		return planetscale.Errorf(planetscale.EINVALID, "items and adjustments add up to %s, expected %s", total, expense.Amount)
+	}
+	return nil
+}
+
+// receiptAdjustment is the net amount a receipt adds on top of its items.
+func receiptAdjustment(expense *planetscale.Expense) planetscale.Money {
+	return expense.Tax + expense.Tip + expense.ServiceCharge - expense.Discount
+}
+
+// allocateItems works out what each consumer owes for items, in order of first
+// appearance, and returns it along with the items' subtotal. An item costs its
+// price times its quantity. Splits with an amount take that part of the item
+// and the rest of it is divided equally over the splits without one. The
+// adjustment is allocated in proportion to what each consumer had.
+//
+// Guests are not users, so the payer covers their splits until they are
+// claimed, as well as items without any splits. The amounts of the splits are
+// filled in with their part of the item.
+func allocateItems(items []*planetscale.Item, paidBy string, adjustment planetscale.Money) ([]share, planetscale.Money, error) {
+	var subtotal planetscale.Money
+	var shares []share
+	index := make(map[string]int)
+	for _, item := range items {
+		quantity := item.Quantity
+		if quantity == 0 {
+			quantity = 1
+		}
+		if quantity < 0 {
+			return nil, 0, planetscale.Errorf(planetscale.EINVALID, "quantity of item %q cannot be negative", item.Name)
+		} else if item.Price < 0 {
+			return nil, 0, planetscale.Errorf(planetscale.EINVALID, "price of item %q cannot be negative", item.Name)
+		}
+		total := itemCost(item)
+		subtotal += total
+		if len(item.Splits) == 0 {
+			// nobody has claimed any of it yet
+			item.Splits = []*planetscale.ItemSplit{{UserID: &paidBy}}
+		}
+
+		remainder := total
+		var unassigned []*planetscale.ItemSplit
+		for _, itemSplit := range item.Splits {
+			if err := itemSplit.Validate(); err != nil {
+				return nil, 0, err
+			} else if itemSplit.Amount < 0 {
+				return nil, 0, planetscale.Errorf(planetscale.EINVALID, "split amounts for item %q cannot be negative", item.Name)
+			} else if itemSplit.Amount == 0 {
+				unassigned = append(unassigned, itemSplit)
+			}
+			remainder -= itemSplit.Amount
+		}
+		if remainder < 0 || (remainder > 0 && len(unassigned) == 0) {
+			return nil, 0, planetscale.Errorf(planetscale.EINVALID, "amounts for item %q add up to %s, expected %s", item.Name, total-remainder, total)
+		}
+		for i, amount := range remainder.Split(len(unassigned)) {
+			unassigned[i].Amount = amount
+		}
+
+		for _, itemSplit := range item.Splits {
+			userID := paidBy
+			if itemSplit.UserID != nil {
+				userID = *itemSplit.UserID
+			}
+			i, ok := index[userID]
+			if !ok {
+				i = len(shares)
+				index[userID] = i
+				shares = append(shares, share{userID: userID})
+			}
+			shares[i].amount += itemSplit.Amount
+		}
+	}
+
+	if adjustment != 0 {
+		if subtotal == 0 {
+			return nil, 0, planetscale.Errorf(planetscale.EINVALID, "adjustments cannot be allocated over items that add up to zero")
+		}
+		weights := make([]int64, len(shares))
+		for i, share := range shares {
+			weights[i] = int64(share.amount)
+		}
+		amounts, err := adjustment.Allocate(weights)
+		if err != nil {
+			return nil, 0, err
+		}
+		for i := range shares {
+			shares[i].amount += amounts[i]
+		}
+	}
+	return shares, subtotal, nil
+}
+
+// validateDirect checks an expense outside of any group. There are no group
+// members to split it with, so its participants have to be listed, and only
+// someone who is part of it may add it.
+func validateDirect(expense *planetscale.Expense) error {
+	if len(expense.Participants) == 0 {
+		return planetscale.Errorf(planetscale.EINVALID, "participants are required for expenses without a group")
+	}
+	if expense.RecurringExpenseID != nil {
+		return planetscale.Errorf(planetscale.EINVALID, "recurring expenses need a group")
+	}
+
+	shared := false
+	for _, participant := range expense.Participants {
+		if participant.UserID != expense.PaidBy {
+			shared = true
+		}
+	}
+	if !shared {
+		return planetscale.Errorf(planetscale.EINVALID, "an expense without a group has to be shared with someone besides the payer")
+	}
+
+	involved := expense.PaidBy == expense.CreatedBy
+	for _, participant := range expense.Participants {
+		involved = involved || participant.UserID == expense.CreatedBy
+	}
+	if !involved {
+		return planetscale.Errorf(planetscale.EFORBIDDEN, "expenses without a group can only be added by the payer or a participant")
+	}
+	return nil
+}
+
+func validateParticipants(expense *planetscale.Expense) error {
+	if expense.Amount <= 0 {
+		return planetscale.Errorf(planetscale.EINVALID, "expense amount must be greater than zero")
+	}
+	if len(expense.Participants) == 0 {
+		return planetscale.Errorf(planetscale.EINVALID, "participants are required for split type %d", expense.SplitTypeID)
+	}
+
+	seen := make(map[string]bool)
+	for _, participant := range expense.Participants {
+		if participant.UserID == "" {
+			return planetscale.Errorf(planetscale.EINVALID, "participant user id is required")
+		}
+		if seen[participant.UserID] {
+			return planetscale.Errorf(planetscale.EINVALID, "user %s is listed more than once", participant.UserID)
+		}
+		seen[participant.UserID] = true
+	}
+	return nil
+}
+
+func roundPercentage(percentage float64) float64 {
+	return math.Round(percentage*100) / 100
+}
@@ -24,7 +24,8 @@ func TestExpenseService_CreateExpense(t *testing.T) {
 		expense := &planetscale.Expense{
 			GroupID:     &groupID,
 			PaidBy:      "test-user-id",
-			Amount:      100,
+			CreatedBy:   "test-user-id",
+			Amount:      100_00,
 			SplitTypeID: 1,
 			Participants: []*planetscale.ExpenseParticipant{
 				participant,
@@ -37,7 +38,29 @@ func TestExpenseService_CreateExpense(t *testing.T) {
 		tm.ExecuteInTxFn = func(ctx context.Context, fn func(*sql.Tx) error) error {
 			return fn(nil)
 		}
-		expenseService := NewExpenseService(repoProvider, tm)
+		expenseService := NewExpenseService(repoProvider, tm, nil)
+
+		var events []*planetscale.AuditEvent
+		expenseService.repos.AuditEvent = &db_mock.AuditEventRepo{
+			CreateFn: func(tx *sql.Tx, event *planetscale.AuditEvent) error {
+				events = append(events, event)
+				return nil
+			},
+		}
+		var webhookEvents []*planetscale.WebhookEvent
+		expenseService.repos.WebhookEvent = &db_mock.WebhookEventRepo{
+			CreateFn: func(tx *sql.Tx, event *planetscale.WebhookEvent) error {
+				webhookEvents = append(webhookEvents, event)
+				return nil
+			},
+		}
+		var activities []*planetscale.Activity
+		expenseService.repos.Activity = &db_mock.ActivityRepo{
+			CreateFn: func(tx *sql.Tx, activity *planetscale.Activity) error {
+				activities = append(activities, activity)
+				return nil
+			},
+		}
 
 		expenseService.repos.Expense = &db_mock.ExpenseRepo{
 			CreateFn: func(tx *sql.Tx, expense *planetscale.Expense) error {
@@ -63,6 +86,16 @@ func TestExpenseService_CreateExpense(t *testing.T) {
 			t.Fatalf("expected expense participant expense id to be 1, got %d", expense.Participants[0].ExpenseID)
 		} else if expense.Participants[1].ExpenseID != 1 {
 			t.Fatalf("expected expense participant expense id to be 1, got %d", expense.Participants[1].ExpenseID)
+		} else if len(events) != 1 {
+			t.Fatalf("expected 1 audit event, got %d", len(events))
+		} else if events[0].Action != planetscale.AuditActionCreate || events[0].EntityID != "1" {
+			t.Fatalf("expected create audit event for expense 1, got %s %s", events[0].Action, events[0].EntityID)
+		} else if events[0].ActorID != "test-user-id" {
+			t.Fatalf("expected actor to fall back to creator, got %s", events[0].ActorID)
+		} else if len(webhookEvents) != 1 || webhookEvents[0].Type != "expense.created" {
+			t.Fatalf("expected 1 expense.created webhook event, got %d", len(webhookEvents))
+		} else if len(activities) != 1 || *activities[0].ExpenseID != 1 || activities[0].Amount != 100_00 {
+			t.Fatalf("expected 1 activity for expense 1, got %d", len(activities))
 		}
 	})
 
@@ -70,7 +103,7 @@ func TestExpenseService_CreateExpense(t *testing.T) {
 		expense := &planetscale.Expense{
 			GroupID:     &groupID,
 			PaidBy:      "test-user-id",
-			Amount:      100,
+			Amount:      100_00,
 			SplitTypeID: 1,
 		}
 
@@ -79,7 +112,22 @@ func TestExpenseService_CreateExpense(t *testing.T) {
 		tm.ExecuteInTxFn = func(ctx context.Context, fn func(*sql.Tx) error) error {
 			return fn(nil)
 		}
-		expenseService := NewExpenseService(repoProvider, tm)
+		expenseService := NewExpenseService(repoProvider, tm, nil)
+		expenseService.repos.AuditEvent = &db_mock.AuditEventRepo{
+			CreateFn: func(tx *sql.Tx, event *planetscale.AuditEvent) error {
+				return nil
+			},
+		}
+		expenseService.repos.WebhookEvent = &db_mock.WebhookEventRepo{
+			CreateFn: func(tx *sql.Tx, event *planetscale.WebhookEvent) error {
+				return nil
+			},
+		}
+		expenseService.repos.Activity = &db_mock.ActivityRepo{
+			CreateFn: func(tx *sql.Tx, activity *planetscale.Activity) error {
+				return nil
+			},
+		}
 
 		expenseService.repos.Expense = &db_mock.ExpenseRepo{
 			CreateFn: func(tx *sql.Tx, expense *planetscale.Expense) error {
@@ -122,4 +170,686 @@ func TestExpenseService_CreateExpense(t *testing.T) {
 			t.Fatalf("expected user id to be test-user-id-2, got %s", expense.Participants[1].UserID)
 		}
 	})
+	t.Run("create unequal split type", func(t *testing.T) {
+		expense := &planetscale.Expense{
+			GroupID:     &groupID,
+			PaidBy:      "test-user-id",
+			Amount:      100_00,
+			SplitTypeID: planetscale.SplitTypeUnequal,
+			Participants: []*planetscale.ExpenseParticipant{
+				{UserID: "test-user-id", AmountOwed: 75_00},
+				{UserID: "test-user-id-2", AmountOwed: 25_00},
+			},
+		}
+
+		var created []*planetscale.ExpenseParticipant
+		expenseService := newTestExpenseService(&created)
+
+		err := expenseService.CreateExpense(context.Background(), expense)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(created) != 2 {
+			t.Fatalf("expected 2 expense participants, got %d", len(created))
+		} else if created[0].SharePercentage != 75 {
+			t.Fatalf("expected share percentage to be 75, got %f", created[0].SharePercentage)
+		} else if created[1].SharePercentage != 25 {
+			t.Fatalf("expected share percentage to be 25, got %f", created[1].SharePercentage)
+		}
+	})
+
+	t.Run("unequal split amounts do not add up", func(t *testing.T) {
+		expense := &planetscale.Expense{
+			GroupID:     &groupID,
+			PaidBy:      "test-user-id",
+			Amount:      100_00,
+			SplitTypeID: planetscale.SplitTypeUnequal,
+			Participants: []*planetscale.ExpenseParticipant{
+				{UserID: "test-user-id", AmountOwed: 75_00},
+				{UserID: "test-user-id-2", AmountOwed: 20_00},
+			},
+		}
+
+		var created []*planetscale.ExpenseParticipant
+		expenseService := newTestExpenseService(&created)
+
+		err := expenseService.CreateExpense(context.Background(), expense)
+		if planetscale.ErrorCode(err) != planetscale.EINVALID {
+			t.Fatalf("expected invalid error, got %v", err)
+		} else if len(created) != 0 {
+			t.Fatalf("expected no expense participants, got %d", len(created))
+		}
+	})
+
+	t.Run("create share based split type", func(t *testing.T) {
+		expense := &planetscale.Expense{
+			GroupID:     &groupID,
+			PaidBy:      "test-user-id",
+			Amount:      100_00,
+			SplitTypeID: planetscale.SplitTypeShareBased,
+			Participants: []*planetscale.ExpenseParticipant{
+				{UserID: "test-user-id", Shares: 1},
+				{UserID: "test-user-id-2", Shares: 1},
+				{UserID: "test-user-id-3", Shares: 1},
+			},
+		}
+
+		var created []*planetscale.ExpenseParticipant
+		expenseService := newTestExpenseService(&created)
+
+		err := expenseService.CreateExpense(context.Background(), expense)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if created[0].AmountOwed != 33_34 {
+			t.Fatalf("expected amount owed to be 33.34, got %s", created[0].AmountOwed)
+		} else if created[1].AmountOwed != 33_33 {
+			t.Fatalf("expected amount owed to be 33.33, got %s", created[1].AmountOwed)
+		} else if created[2].AmountOwed != 33_33 {
+			t.Fatalf("expected amount owed to be 33.33, got %s", created[2].AmountOwed)
+		}
+	})
+
+	t.Run("share based split without shares", func(t *testing.T) {
+		expense := &planetscale.Expense{
+			GroupID:     &groupID,
+			PaidBy:      "test-user-id",
+			Amount:      100_00,
+			SplitTypeID: planetscale.SplitTypeShareBased,
+			Participants: []*planetscale.ExpenseParticipant{
+				{UserID: "test-user-id", Shares: 2},
+				{UserID: "test-user-id-2"},
+			},
+		}
+
+		var created []*planetscale.ExpenseParticipant
+		expenseService := newTestExpenseService(&created)
+
+		err := expenseService.CreateExpense(context.Background(), expense)
+		if planetscale.ErrorCode(err) != planetscale.EINVALID {
+			t.Fatalf("expected invalid error, got %v", err)
+		}
+	})
+
+	t.Run("create percentage based split type", func(t *testing.T) {
+		expense := &planetscale.Expense{
+			GroupID:     &groupID,
+			PaidBy:      "test-user-id",
+			Amount:      80_00,
+			SplitTypeID: planetscale.SplitTypePercentageBased,
+			Participants: []*planetscale.ExpenseParticipant{
+				{UserID: "test-user-id", SharePercentage: 60},
+				{UserID: "test-user-id-2", SharePercentage: 40},
+			},
+		}
+
+		var created []*planetscale.ExpenseParticipant
+		expenseService := newTestExpenseService(&created)
+
+		err := expenseService.CreateExpense(context.Background(), expense)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if created[0].AmountOwed != 48_00 {
+			t.Fatalf("expected amount owed to be 48, got %s", created[0].AmountOwed)
+		} else if created[1].AmountOwed != 32_00 {
+			t.Fatalf("expected amount owed to be 32, got %s", created[1].AmountOwed)
+		}
+	})
+
+	t.Run("percentages do not add up to 100", func(t *testing.T) {
+		expense := &planetscale.Expense{
+			GroupID:     &groupID,
+			PaidBy:      "test-user-id",
+			Amount:      80_00,
+			SplitTypeID: planetscale.SplitTypePercentageBased,
+			Participants: []*planetscale.ExpenseParticipant{
+				{UserID: "test-user-id", SharePercentage: 60},
+				{UserID: "test-user-id-2", SharePercentage: 60},
+			},
+		}
+
+		var created []*planetscale.ExpenseParticipant
+		expenseService := newTestExpenseService(&created)
+
+		err := expenseService.CreateExpense(context.Background(), expense)
+		if planetscale.ErrorCode(err) != planetscale.EINVALID {
+			t.Fatalf("expected invalid error, got %v", err)
+		}
+	})
+
+	t.Run("create item based split type", func(t *testing.T) {
+		userID, userID2 := "test-user-id", "test-user-id-2"
+		expense := &planetscale.Expense{
+			GroupID:     &groupID,
+			PaidBy:      "test-user-id",
+			Amount:      55_00,
+			Tax:         4_00,
+			Tip:         6_00,
+			Discount:    5_00,
+			SplitTypeID: planetscale.SplitTypeItemBased,
+			Items: []*planetscale.Item{
+				{Name: "beer", Price: 5_00, Quantity: 4, Splits: []*planetscale.ItemSplit{
+					{UserID: &userID, Amount: 15_00},
+					{UserID: &userID2},
+				}},
+				{Name: "pizza", Price: 30_00, Quantity: 1, Splits: []*planetscale.ItemSplit{
+					{UserID: &userID2},
+				}},
+			},
+		}
+
+		var created []*planetscale.ExpenseParticipant
+		expenseService := newTestExpenseService(&created)
+		var createdSplits []*planetscale.ItemSplit
+		expenseService.repos.Item = &db_mock.ItemRepo{
+			CreateFn: func(tx *sql.Tx, item *planetscale.Item) error {
+				item.ItemID = 1
+				return nil
+			},
+		}
+		expenseService.repos.ItemSplit = &db_mock.ItemSplitRepo{
+			CreateFn: func(tx *sql.Tx, itemSplit *planetscale.ItemSplit) error {
+				createdSplits = append(createdSplits, itemSplit)
+				return nil
+			},
+		}
+
+		err := expenseService.CreateExpense(context.Background(), expense)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(createdSplits) != 3 || createdSplits[1].Amount != 5_00 {
+			t.Fatalf("expected the rest of the beer to go to test-user-id-2, got %+v", createdSplits)
+		}
+		// 15 and 35 of items, 5 of adjustments shared 3:7
+		if len(created) != 2 {
+			t.Fatalf("expected 2 expense participants, got %d", len(created))
+		} else if created[0].AmountOwed != 16_50 {
+			t.Fatalf("expected amount owed to be 16.50, got %s", created[0].AmountOwed)
+		} else if created[1].AmountOwed != 38_50 {
+			t.Fatalf("expected amount owed to be 38.50, got %s", created[1].AmountOwed)
+		}
+	})
+
+	t.Run("items and adjustments do not reconcile", func(t *testing.T) {
+		guest := "JD"
+		expense := &planetscale.Expense{
+			GroupID:     &groupID,
+			PaidBy:      "test-user-id",
+			Amount:      46_00,
+			Tax:         4_00,
+			SplitTypeID: planetscale.SplitTypeItemBased,
+			Items: []*planetscale.Item{
+				{Name: "pizza", Price: 23_00, Quantity: 2, Splits: []*planetscale.ItemSplit{
+					{GuestName: &guest},
+				}},
+			},
+		}
+
+		var created []*planetscale.ExpenseParticipant
+		expenseService := newTestExpenseService(&created)
+
+		err := expenseService.CreateExpense(context.Background(), expense)
+		if planetscale.ErrorCode(err) != planetscale.EINVALID {
+			t.Fatalf("expected invalid error, got %v", err)
+		} else if len(created) != 0 {
+			t.Fatalf("expected no expense participants, got %d", len(created))
+		}
+	})
+
+	t.Run("create without a group", func(t *testing.T) {
+		expense := &planetscale.Expense{
+			PaidBy:      "test-user-id",
+			CreatedBy:   "test-user-id",
+			Amount:      30_00,
+			SplitTypeID: planetscale.SplitTypeEqual,
+			Participants: []*planetscale.ExpenseParticipant{
+				{UserID: "test-user-id"},
+				{UserID: "test-user-id-2"},
+			},
+		}
+
+		var created []*planetscale.ExpenseParticipant
+		expenseService := newTestExpenseService(&created)
+
+		err := expenseService.CreateExpense(context.Background(), expense)
+		if err != nil {
+			t.Fatal(err)
+		} else if len(created) != 2 {
+			t.Fatalf("expected 2 expense participants, got %d", len(created))
+		}
+	})
+
+	t.Run("invalid expenses without a group", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			expense  *planetscale.Expense
+			expected string
+		}{
+			{"no participants", &planetscale.Expense{
+				PaidBy:      "test-user-id",
+				CreatedBy:   "test-user-id",
+				Amount:      30_00,
+				SplitTypeID: planetscale.SplitTypeEqual,
+			}, planetscale.EINVALID},
+			{"only the payer", &planetscale.Expense{
+				PaidBy:       "test-user-id",
+				CreatedBy:    "test-user-id",
+				Amount:       30_00,
+				SplitTypeID:  planetscale.SplitTypeEqual,
+				Participants: []*planetscale.ExpenseParticipant{{UserID: "test-user-id"}},
+			}, planetscale.EINVALID},
+			{"created by an outsider", &planetscale.Expense{
+				PaidBy:       "test-user-id",
+				CreatedBy:    "test-user-id-3",
+				Amount:       30_00,
+				SplitTypeID:  planetscale.SplitTypeEqual,
+				Participants: []*planetscale.ExpenseParticipant{{UserID: "test-user-id-2"}},
+			}, planetscale.EFORBIDDEN},
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				var created []*planetscale.ExpenseParticipant
+				expenseService := newTestExpenseService(&created)
+
+				err := expenseService.CreateExpense(context.Background(), test.expense)
+				if planetscale.ErrorCode(err) != test.expected {
+					t.Fatalf("expected %s error, got %v", test.expected, err)
+				} else if len(created) != 0 {
+					t.Fatalf("expected no expense participants, got %d", len(created))
+				}
+			})
+		}
+	})
+}
+
+func TestExpenseService_UpdateExpense(t *testing.T) {
+	groupID := int64(1)
+	userID, userID2 := "test-user-id", "test-user-id-2"
+
+	type changes struct {
+		upserted []*planetscale.ExpenseParticipant
+		deleted  []string
+	}
+	newUpdateService := func(splitTypeID int64, participants []*planetscale.ExpenseParticipant) (*expenseService, *changes) {
+		var c changes
+		expenseService := newTestExpenseService(&c.upserted)
+		expense := func() *planetscale.Expense {
+			return &planetscale.Expense{ExpenseID: 1, GroupID: &groupID, PaidBy: userID, Amount: 100_00, SplitTypeID: splitTypeID, CreatedBy: userID}
+		}
+		expenseService.repos.Expense = &db_mock.ExpenseRepo{
+			GetFn: func(tx *sql.Tx, expenseID int64) (*planetscale.Expense, error) {
+				return expense(), nil
+			},
+			UpdateFn: func(tx *sql.Tx, expenseID int64, update *planetscale.ExpenseUpdate) (*planetscale.Expense, error) {
+				updated := expense()
+				if update.Amount != nil {
+					updated.Amount = *update.Amount
+				}
+				if update.Tax != nil {
+					updated.Tax = *update.Tax
+				}
+				if update.Discount != nil {
+					updated.Discount = *update.Discount
+				}
+				return updated, nil
+			},
+		}
+		expenseService.repos.GroupMember = &db_mock.GroupMemberRepo{
+			GetFn: func(tx *sql.Tx, groupID int64, userID string) (*planetscale.GroupMember, error) {
+				return &planetscale.GroupMember{GroupID: groupID, UserID: userID, Role: planetscale.RoleMember}, nil
+			},
+		}
+		expenseService.repos.ExpenseParticipant = &db_mock.ExpenseParticipantRepo{
+			FindFn: func(tx *sql.Tx, filter planetscale.ExpenseParticipantFilter) ([]*planetscale.ExpenseParticipant, error) {
+				return participants, nil
+			},
+			UpsertFn: func(tx *sql.Tx, participant *planetscale.ExpenseParticipant) error {
+				c.upserted = append(c.upserted, participant)
+				return nil
+			},
+			DeleteFn: func(tx *sql.Tx, expenseID int64, userID string) error {
+				c.deleted = append(c.deleted, userID)
+				return nil
+			},
+		}
+		return expenseService, &c
+	}
+
+	t.Run("unequal split with new participants", func(t *testing.T) {
+		expenseService, c := newUpdateService(planetscale.SplitTypeUnequal, []*planetscale.ExpenseParticipant{
+			{ExpenseID: 1, UserID: userID, AmountOwed: 100_00, SharePercentage: 100},
+		})
+
+		amount := planetscale.Money(200_00)
+		before, after, err := expenseService.UpdateExpense(context.Background(), 1, &planetscale.ExpenseUpdate{
+			Amount: &amount,
+			Participants: []*planetscale.ExpenseParticipant{
+				{UserID: userID2, AmountOwed: 150_00},
+				{UserID: "test-user-id-3", AmountOwed: 50_00},
+			},
+		}, userID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if before.Amount != 100_00 || len(before.Participants) != 1 {
+			t.Fatalf("unexpected expense before the update %+v", before)
+		} else if after.Amount != amount || len(after.Participants) != 2 {
+			t.Fatalf("unexpected expense after the update %+v", after)
+		} else if len(c.deleted) != 1 || c.deleted[0] != userID {
+			t.Fatalf("expected %s to be removed, got %v", userID, c.deleted)
+		} else if len(c.upserted) != 2 {
+			t.Fatalf("expected 2 participants, got %d", len(c.upserted))
+		}
+		for _, participant := range c.upserted {
+			if participant.ExpenseID != 1 {
+				t.Errorf("expected participant of expense 1, got %d", participant.ExpenseID)
+			}
+		}
+		if got := c.upserted[0]; got.SharePercentage != 75 {
+			t.Errorf("expected share percentage 75, got %v", got.SharePercentage)
+		}
+	})
+
+	t.Run("share based split is allocated again", func(t *testing.T) {
+		expenseService, c := newUpdateService(planetscale.SplitTypeShareBased, []*planetscale.ExpenseParticipant{
+			{ExpenseID: 1, UserID: userID, Shares: 1, AmountOwed: 25_00},
+			{ExpenseID: 1, UserID: userID2, Shares: 3, AmountOwed: 75_00},
+		})
+
+		amount := planetscale.Money(40_00)
+		before, _, err := expenseService.UpdateExpense(context.Background(), 1, &planetscale.ExpenseUpdate{
+			Amount: &amount,
+		}, userID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(c.upserted) != 2 {
+			t.Fatalf("expected 2 participants, got %d", len(c.upserted))
+		} else if c.upserted[0].AmountOwed != 10_00 || c.upserted[1].AmountOwed != 30_00 {
+			t.Fatalf("expected 10 and 30 owed, got %s and %s", c.upserted[0].AmountOwed, c.upserted[1].AmountOwed)
+		} else if before.Participants[0].AmountOwed != 25_00 {
+			t.Fatalf("expected the expense before the update to keep its amounts, got %s", before.Participants[0].AmountOwed)
+		}
+	})
+
+	t.Run("unequal amounts no longer add up", func(t *testing.T) {
+		expenseService, c := newUpdateService(planetscale.SplitTypeUnequal, []*planetscale.ExpenseParticipant{
+			{ExpenseID: 1, UserID: userID, AmountOwed: 100_00},
+		})
+
+		amount := planetscale.Money(200_00)
+		_, _, err := expenseService.UpdateExpense(context.Background(), 1, &planetscale.ExpenseUpdate{
+			Amount: &amount,
+		}, userID)
+		if planetscale.ErrorCode(err) != planetscale.EINVALID {
+			t.Fatalf("expected invalid error, got %v", err)
+		} else if len(c.upserted) != 0 {
+			t.Fatalf("expected no participants, got %d", len(c.upserted))
+		}
+	})
+
+	t.Run("item based split takes no participants", func(t *testing.T) {
+		expenseService, _ := newUpdateService(planetscale.SplitTypeItemBased, nil)
+
+		_, _, err := expenseService.UpdateExpense(context.Background(), 1, &planetscale.ExpenseUpdate{
+			Participants: []*planetscale.ExpenseParticipant{{UserID: userID}},
+		}, userID)
+		if planetscale.ErrorCode(err) != planetscale.EINVALID {
+			t.Fatalf("expected invalid error, got %v", err)
+		}
+	})
+
+	t.Run("item based adjustments are allocated again", func(t *testing.T) {
+		newAdjustedService := func() (*expenseService, *changes) {
+			expenseService, c := newUpdateService(planetscale.SplitTypeItemBased, []*planetscale.ExpenseParticipant{
+				{ExpenseID: 1, UserID: userID, AmountOwed: 50_00},
+				{ExpenseID: 1, UserID: userID2, AmountOwed: 50_00},
+			})
+			expenseService.repos.Item = &db_mock.ItemRepo{
+				FindFn: func(tx *sql.Tx, filter planetscale.ItemFilter) ([]*planetscale.Item, error) {
+					return []*planetscale.Item{
+						{ItemID: 1, ExpenseID: 1, Price: 75_00, Quantity: 1},
+						{ItemID: 2, ExpenseID: 1, Price: 25_00, Quantity: 1},
+					}, nil
+				},
+			}
+			expenseService.repos.ItemSplit = &db_mock.ItemSplitRepo{
+				FindFn: func(tx *sql.Tx, filter planetscale.ItemSplitFilter) ([]*planetscale.ItemSplit, error) {
+					if filter.ItemID == 1 {
+						return []*planetscale.ItemSplit{{ItemID: 1, UserID: &userID}}, nil
+					}
+					return []*planetscale.ItemSplit{{ItemID: 2, UserID: &userID2}}, nil
+				},
+			}
+			return expenseService, c
+		}
+
+		expenseService, c := newAdjustedService()
+		amount, tax := planetscale.Money(120_00), planetscale.Money(20_00)
+		_, _, err := expenseService.UpdateExpense(context.Background(), 1, &planetscale.ExpenseUpdate{
+			Amount: &amount,
+			Tax:    &tax,
+		}, userID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(c.upserted) != 2 {
+			t.Fatalf("expected 2 participants, got %d", len(c.upserted))
+		} else if c.upserted[0].AmountOwed != 90_00 || c.upserted[1].AmountOwed != 30_00 {
+			t.Fatalf("expected 90 and 30 owed, got %s and %s", c.upserted[0].AmountOwed, c.upserted[1].AmountOwed)
+		}
+
+		// a discount without a new amount no longer reconciles
+		expenseService, c = newAdjustedService()
+		discount := planetscale.Money(10_00)
+		_, _, err = expenseService.UpdateExpense(context.Background(), 1, &planetscale.ExpenseUpdate{
+			Discount: &discount,
+		}, userID)
+		if planetscale.ErrorCode(err) != planetscale.EINVALID {
+			t.Fatalf("expected invalid error, got %v", err)
+		} else if len(c.upserted) != 0 {
+			t.Fatalf("expected no participants, got %d", len(c.upserted))
+		}
+	})
+
+	t.Run("only item based expenses take adjustments", func(t *testing.T) {
+		expenseService, _ := newUpdateService(planetscale.SplitTypeEqual, nil)
+
+		tax := planetscale.Money(5_00)
+		_, _, err := expenseService.UpdateExpense(context.Background(), 1, &planetscale.ExpenseUpdate{
+			Tax: &tax,
+		}, userID)
+		if planetscale.ErrorCode(err) != planetscale.EINVALID {
+			t.Fatalf("expected invalid error, got %v", err)
+		}
+	})
+
+	t.Run("members cannot update what others added", func(t *testing.T) {
+		expenseService, _ := newUpdateService(planetscale.SplitTypeEqual, nil)
+
+		description := "taken over"
+		_, _, err := expenseService.UpdateExpense(context.Background(), 1, &planetscale.ExpenseUpdate{
+			Description: &description,
+		}, userID2)
+		if planetscale.ErrorCode(err) != planetscale.EFORBIDDEN {
+			t.Fatalf("expected forbidden error, got %v", err)
+		}
+	})
+}
+
+func TestExpenseService_ClaimItemSplit(t *testing.T) {
+	groupID := int64(1)
+	userID, userID2, guest := "test-user-id", "test-user-id-2", "JD"
+
+	newClaimService := func(itemSplit *planetscale.ItemSplit) (*expenseService, *[]*planetscale.ExpenseParticipant) {
+		var upserted []*planetscale.ExpenseParticipant
+		expenseService := newTestExpenseService(&upserted)
+		expenseService.repos.Expense = &db_mock.ExpenseRepo{
+			GetFn: func(tx *sql.Tx, expenseID int64) (*planetscale.Expense, error) {
+				return &planetscale.Expense{ExpenseID: expenseID, GroupID: &groupID, PaidBy: userID, Amount: 30_00, SplitTypeID: planetscale.SplitTypeItemBased}, nil
+			},
+		}
+		expenseService.repos.Item = &db_mock.ItemRepo{
+			GetFn: func(tx *sql.Tx, itemID int64) (*planetscale.Item, error) {
+				return &planetscale.Item{ItemID: itemID, ExpenseID: 1, Price: 30_00, Quantity: 1}, nil
+			},
+			FindFn: func(tx *sql.Tx, filter planetscale.ItemFilter) ([]*planetscale.Item, error) {
+				return []*planetscale.Item{{ItemID: 1, ExpenseID: 1, Price: 30_00, Quantity: 1}}, nil
+			},
+		}
+		expenseService.repos.ItemSplit = &db_mock.ItemSplitRepo{
+			GetFn: func(tx *sql.Tx, itemSplitID int64) (*planetscale.ItemSplit, error) {
+				return itemSplit, nil
+			},
+			ClaimFn: func(tx *sql.Tx, itemSplitID int64, userID string) (*planetscale.ItemSplit, error) {
+				itemSplit.UserID = &userID
+				return itemSplit, nil
+			},
+			FindFn: func(tx *sql.Tx, filter planetscale.ItemSplitFilter) ([]*planetscale.ItemSplit, error) {
+				return []*planetscale.ItemSplit{{ItemSplitID: 1, ItemID: 1, UserID: &userID}, itemSplit}, nil
+			},
+		}
+		expenseService.repos.GroupMember = &db_mock.GroupMemberRepo{
+			GetFn: func(tx *sql.Tx, groupID int64, userID string) (*planetscale.GroupMember, error) {
+				if userID != userID2 {
+					return nil, planetscale.Errorf(planetscale.ENOTFOUND, "not a member")
+				}
+				return &planetscale.GroupMember{GroupID: groupID, UserID: userID, Role: planetscale.RoleMember}, nil
+			},
+		}
+		expenseService.repos.ExpenseParticipant = &db_mock.ExpenseParticipantRepo{
+			FindFn: func(tx *sql.Tx, filter planetscale.ExpenseParticipantFilter) ([]*planetscale.ExpenseParticipant, error) {
+				return []*planetscale.ExpenseParticipant{{ExpenseID: 1, UserID: userID, AmountOwed: 30_00, Note: "covered JD"}}, nil
+			},
+			UpsertFn: func(tx *sql.Tx, participant *planetscale.ExpenseParticipant) error {
+				upserted = append(upserted, participant)
+				return nil
+			},
+		}
+		return expenseService, &upserted
+	}
+
+	t.Run("successful claim", func(t *testing.T) {
+		expenseService, upserted := newClaimService(&planetscale.ItemSplit{ItemSplitID: 2, ItemID: 1, GuestName: &guest})
+		var webhooks []*planetscale.WebhookEvent
+		expenseService.repos.WebhookEvent = &db_mock.WebhookEventRepo{
+			CreateFn: func(tx *sql.Tx, event *planetscale.WebhookEvent) error {
+				webhooks = append(webhooks, event)
+				return nil
+			},
+		}
+		var activity []*planetscale.Activity
+		expenseService.repos.Activity = &db_mock.ActivityRepo{
+			CreateFn: func(tx *sql.Tx, created *planetscale.Activity) error {
+				activity = append(activity, created)
+				return nil
+			},
+		}
+
+		claimed, err := expenseService.ClaimItemSplit(context.Background(), 2, userID2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(webhooks) != 1 || len(activity) != 1 {
+			t.Fatalf("expected the claim to be sent and shown, got %d webhook and %d activity rows", len(webhooks), len(activity))
+		}
+
+		if claimed.UserID == nil || *claimed.UserID != userID2 {
+			t.Fatalf("expected split to be claimed by %s, got %v", userID2, claimed.UserID)
+		}
+		// the payer no longer covers the guest's half
+		if len(*upserted) != 2 {
+			t.Fatalf("expected 2 expense participants, got %d", len(*upserted))
+		} else if got := (*upserted)[0]; got.UserID != userID || got.AmountOwed != 15_00 || got.Note != "covered JD" {
+			t.Fatalf("unexpected participant %+v", got)
+		} else if got := (*upserted)[1]; got.UserID != userID2 || got.AmountOwed != 15_00 {
+			t.Fatalf("unexpected participant %+v", got)
+		}
+	})
+
+	t.Run("items no longer reconcile", func(t *testing.T) {
+		expenseService, upserted := newClaimService(&planetscale.ItemSplit{ItemSplitID: 2, ItemID: 1, GuestName: &guest})
+		expenseService.repos.Expense = &db_mock.ExpenseRepo{
+			GetFn: func(tx *sql.Tx, expenseID int64) (*planetscale.Expense, error) {
+				return &planetscale.Expense{ExpenseID: expenseID, GroupID: &groupID, PaidBy: userID, Amount: 40_00, SplitTypeID: planetscale.SplitTypeItemBased}, nil
+			},
+		}
+
+		_, err := expenseService.ClaimItemSplit(context.Background(), 2, userID2)
+		if planetscale.ErrorCode(err) != planetscale.EINVALID {
+			t.Fatalf("expected invalid error, got %v", err)
+		} else if len(*upserted) != 0 {
+			t.Fatalf("expected no expense participants, got %d", len(*upserted))
+		}
+	})
+
+	t.Run("non member cannot claim", func(t *testing.T) {
+		expenseService, _ := newClaimService(&planetscale.ItemSplit{ItemSplitID: 2, ItemID: 1, GuestName: &guest})
+
+		_, err := expenseService.ClaimItemSplit(context.Background(), 2, "test-user-id-3")
+		if planetscale.ErrorCode(err) != planetscale.ENOTFOUND {
+			t.Fatalf("expected not found error, got %v", err)
+		}
+	})
+
+	t.Run("viewer cannot claim", func(t *testing.T) {
+		expenseService, _ := newClaimService(&planetscale.ItemSplit{ItemSplitID: 2, ItemID: 1, GuestName: &guest})
+		expenseService.repos.GroupMember = &db_mock.GroupMemberRepo{
+			GetFn: func(tx *sql.Tx, groupID int64, userID string) (*planetscale.GroupMember, error) {
+				return &planetscale.GroupMember{GroupID: groupID, UserID: userID, Role: planetscale.RoleViewer}, nil
+			},
+		}
+
+		_, err := expenseService.ClaimItemSplit(context.Background(), 2, userID2)
+		if planetscale.ErrorCode(err) != planetscale.EFORBIDDEN {
+			t.Fatalf("expected forbidden error, got %v", err)
+		}
+	})
+}
+
+func newTestExpenseService(created *[]*planetscale.ExpenseParticipant) *expenseService {
+	repoProvider := &planetscale.RepoProvider{}
+	tm := db_mock.TransactionManager{}
+	tm.ExecuteInTxFn = func(ctx context.Context, fn func(*sql.Tx) error) error {
+		return fn(nil)
+	}
+	expenseService := NewExpenseService(repoProvider, tm, nil)
+	expenseService.repos.AuditEvent = &db_mock.AuditEventRepo{
+		CreateFn: func(tx *sql.Tx, event *planetscale.AuditEvent) error {
+			return nil
+		},
+	}
+	expenseService.repos.WebhookEvent = &db_mock.WebhookEventRepo{
+		CreateFn: func(tx *sql.Tx, event *planetscale.WebhookEvent) error {
+			return nil
+		},
+	}
+	expenseService.repos.Activity = &db_mock.ActivityRepo{
+		CreateFn: func(tx *sql.Tx, activity *planetscale.Activity) error {
+			return nil
+		},
+	}
+
+	expenseService.repos.Expense = &db_mock.ExpenseRepo{
+		CreateFn: func(tx *sql.Tx, expense *planetscale.Expense) error {
+			expense.ExpenseID = 1
+			return nil
+		},
+	}
+	expenseService.repos.ExpenseParticipant = &db_mock.ExpenseParticipantRepo{
+		CreateFn: func(tx *sql.Tx, expenseParticipant *planetscale.ExpenseParticipant) error {
+			*created = append(*created, expenseParticipant)
+			return nil
+		},
+	}
+	return expenseService
 }
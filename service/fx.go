@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	planetscale "github.com/harshav17/planet_scale"
+)
+
+type fxService struct {
+	repos *planetscale.RepoProvider
+	tm    planetscale.TransactionManager
+}
+
+func NewFXService(repoProvider *planetscale.RepoProvider, tm planetscale.TransactionManager) *fxService {
+	return &fxService{
+		repos: repoProvider,
+		tm:    tm,
+	}
+}
+
+func (s *fxService) ImportCSV(ctx context.Context, r io.Reader) (int, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 4
+	reader.TrimLeadingSpace = true
+
+	var rates []*planetscale.FXRate
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return 0, planetscale.Errorf(planetscale.EINVALID, "line %d: %s", line, err)
+		}
+		if line == 1 && strings.EqualFold(record[0], "as_of") {
+			continue
+		}
+
+		rate, err := parseFXRate(record)
+		if err != nil {
+			return 0, planetscale.Errorf(planetscale.EINVALID, "line %d: %s", line, planetscale.ErrorMessage(err))
+		}
+		rates = append(rates, rate)
+	}
+
+	importFunc := func(tx *sql.Tx) error {
+		for _, rate := range rates {
+			err := s.repos.FXRate.Upsert(tx, rate)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	err := s.tm.ExecuteInTx(ctx, importFunc)
+	if err != nil {
+		return 0, err
+	}
+
+	return len(rates), nil
+}
+
+func parseFXRate(record []string) (*planetscale.FXRate, error) {
+	asOf, err := time.Parse(time.DateOnly, record[0])
+	if err != nil {
+		return nil, planetscale.Errorf(planetscale.EINVALID, "invalid date %q", record[0])
+	}
+	base, err := planetscale.NormalizeCurrency(record[1])
+	if err != nil {
+		return nil, err
+	}
+	quote, err := planetscale.NormalizeCurrency(record[2])
+	if err != nil {
+		return nil, err
+	}
+	rate, err := strconv.ParseFloat(strings.TrimSpace(record[3]), 64)
+	if err != nil || rate <= 0 {
+		return nil, planetscale.Errorf(planetscale.EINVALID, "invalid rate %q", record[3])
+	}
+
+	return &planetscale.FXRate{
+		BaseCurrency:  base,
+		QuoteCurrency: quote,
+		Rate:          rate,
+		AsOf:          asOf,
+	}, nil
+}
+
+// fxConverter looks up the rates that convert amounts into a group's base
+// currency, caching them for the lifetime of a single balance calculation.
+type fxConverter struct {
+	tx    *sql.Tx
+	repo  planetscale.FXRateRepo
+	base  string
+	rates map[string]float64
+}
+
+func newFXConverter(tx *sql.Tx, repo planetscale.FXRateRepo, base string) *fxConverter {
+	return &fxConverter{
+		tx:    tx,
+		repo:  repo,
+		base:  base,
+		rates: make(map[string]float64),
+	}
+}
+
+// rate returns the factor that converts an amount in currency into the base
+// currency on the given day. Either direction of the pair may be stored.
+func (c *fxConverter) rate(currency string, at time.Time) (float64, error) {
+	if currency == "" || currency == c.base {
+		return 1, nil
+	}
+	if at.IsZero() {
+		at = time.Now()
+	}
+
+	key := currency + "/" + at.UTC().Format(time.DateOnly)
+	if rate, ok := c.rates[key]; ok {
+		return rate, nil
+	}
+
+	var rate float64
+	fx, err := c.repo.GetAsOf(c.tx, currency, c.base, at)
+	switch {
+	case err == nil:
+		rate = fx.Rate
+	case planetscale.ErrorCode(err) == planetscale.ENOTFOUND:
+		fx, err = c.repo.GetAsOf(c.tx, c.base, currency, at)
+		if planetscale.ErrorCode(err) == planetscale.ENOTFOUND {
+			return 0, planetscale.Errorf(planetscale.EINVALID, "no exchange rate from %s to %s as of %s", currency, c.base, at.UTC().Format(time.DateOnly))
+		} else if err != nil {
+			return 0, err
+		}
+		rate = 1 / fx.Rate
+	default:
+		return 0, err
+	}
+
+	c.rates[key] = rate
+	return rate, nil
+}
@@ -0,0 +1,229 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	planetscale "github.com/harshav17/planet_scale"
+	db_mock "github.com/harshav17/planet_scale/mock/db"
+)
+
+func TestFXService_ImportCSV(t *testing.T) {
+	newService := func(upserted *[]*planetscale.FXRate) *fxService {
+		tm := db_mock.TransactionManager{}
+		tm.ExecuteInTxFn = func(ctx context.Context, fn func(*sql.Tx) error) error {
+			return fn(nil)
+		}
+		fxService := NewFXService(&planetscale.RepoProvider{}, tm)
+		fxService.repos.FXRate = &db_mock.FXRateRepo{
+			UpsertFn: func(tx *sql.Tx, rate *planetscale.FXRate) error {
+				*upserted = append(*upserted, rate)
+				return nil
+			},
+		}
+		return fxService
+	}
+
+	t.Run("header is skipped and codes are normalized", func(t *testing.T) {
+		var upserted []*planetscale.FXRate
+		n, err := newService(&upserted).ImportCSV(context.Background(), strings.NewReader(
+			"as_of,base_currency,quote_currency,rate\n"+
+				"2024-01-01,eur,usd,1.1\n"+
+				"2024-01-02, GBP, USD, 1.27\n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != 2 || len(upserted) != 2 {
+			t.Fatalf("expected 2 rates, got %d (%d upserted)", n, len(upserted))
+		}
+		if got := upserted[0]; got.BaseCurrency != "EUR" || got.QuoteCurrency != "USD" || got.Rate != 1.1 || !got.AsOf.Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)) {
+			t.Fatalf("unexpected rate %+v", got)
+		}
+		if got := upserted[1]; got.BaseCurrency != "GBP" || got.Rate != 1.27 {
+			t.Fatalf("unexpected rate %+v", got)
+		}
+	})
+
+	t.Run("invalid rows are rejected before anything is stored", func(t *testing.T) {
+		for name, input := range map[string]string{
+			"bad date":     "01/01/2024,EUR,USD,1.1\n",
+			"bad currency": "2024-01-01,EURO,USD,1.1\n",
+			"bad rate":     "2024-01-01,EUR,USD,-1\n",
+			"short row":    "2024-01-01,EUR,USD\n",
+		} {
+			t.Run(name, func(t *testing.T) {
+				var upserted []*planetscale.FXRate
+				_, err := newService(&upserted).ImportCSV(context.Background(), strings.NewReader("2024-01-01,GBP,USD,1.27\n"+input))
+				if planetscale.ErrorCode(err) != planetscale.EINVALID {
+					t.Fatalf("expected invalid error, got %v", err)
+				}
+				if len(upserted) != 0 {
+					t.Fatalf("expected no rates to be stored, got %d", len(upserted))
+				}
+			})
+		}
+	})
+}
+
+func TestFXConverter_Rate(t *testing.T) {
+	var lookups int
+	repo := &db_mock.FXRateRepo{
+		GetAsOfFn: func(tx *sql.Tx, baseCurrency, quoteCurrency string, asOf time.Time) (*planetscale.FXRate, error) {
+			lookups++
+			switch baseCurrency + "/" + quoteCurrency {
+			case "EUR/USD":
+				return &planetscale.FXRate{BaseCurrency: "EUR", QuoteCurrency: "USD", Rate: 1.1}, nil
+			case "USD/GBP":
+				return &planetscale.FXRate{BaseCurrency: "USD", QuoteCurrency: "GBP", Rate: 0.8}, nil
+			}
+			return nil, planetscale.Errorf(planetscale.ENOTFOUND, "no rate")
+		},
+	}
+	at := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("base currency needs no lookup", func(t *testing.T) {
+		lookups = 0
+		fx := newFXConverter(nil, repo, "USD")
+		if rate, err := fx.rate("USD", at); err != nil || rate != 1 {
+			t.Fatalf("expected rate 1, got %v (%v)", rate, err)
+		}
+		if lookups != 0 {
+			t.Fatalf("expected no lookups, got %d", lookups)
+		}
+	})
+
+	t.Run("direct rate is cached", func(t *testing.T) {
+		lookups = 0
+		fx := newFXConverter(nil, repo, "USD")
+		for i := 0; i < 2; i++ {
+			if rate, err := fx.rate("EUR", at); err != nil || rate != 1.1 {
+				t.Fatalf("expected rate 1.1, got %v (%v)", rate, err)
+			}
+		}
+		if lookups != 1 {
+			t.Fatalf("expected 1 lookup, got %d", lookups)
+		}
+	})
+
+	t.Run("inverse rate", func(t *testing.T) {
+		fx := newFXConverter(nil, repo, "USD")
+		if rate, err := fx.rate("GBP", at); err != nil || rate != 1.25 {
+			t.Fatalf("expected rate 1.25, got %v (%v)", rate, err)
+		}
+	})
+
+	t.Run("missing rate", func(t *testing.T) {
+		fx := newFXConverter(nil, repo, "USD")
+		if _, err := fx.rate("JPY", at); planetscale.ErrorCode(err) != planetscale.EINVALID {
+			t.Fatalf("expected invalid error, got %v", err)
+		}
+	})
+}
+
+func TestBalanceService_GetGroupBalances_MultiCurrency(t *testing.T) {
+	groupID := int64(1)
+	tm := db_mock.TransactionManager{}
+	tm.ExecuteInTxFn = func(ctx context.Context, fn func(*sql.Tx) error) error {
+		return fn(nil)
+	}
+	balanceService := NewBalanceService(&planetscale.RepoProvider{}, tm, nil)
+	balanceService.repos.ExpenseGroup = &db_mock.ExpenseGroupRepo{
+		GetFn: func(tx *sql.Tx, groupID int64) (*planetscale.ExpenseGroup, error) {
+			return &planetscale.ExpenseGroup{ExpenseGroupID: groupID, BaseCurrency: "USD"}, nil
+		},
+	}
+	balanceService.repos.Expense = &db_mock.ExpenseRepo{
+		FindFn: func(tx *sql.Tx, filter planetscale.ExpenseFilter) ([]*planetscale.Expense, error) {
+			return []*planetscale.Expense{
+				{
+					ExpenseID:   1,
+					GroupID:     &groupID,
+					PaidBy:      "test-user-id",
+					Amount:      100_00,
+					Currency:    "EUR",
+					SplitTypeID: planetscale.SplitTypeEqual,
+				},
+			}, nil
+		},
+	}
+	balanceService.repos.Settlement = &db_mock.SettlementRepo{
+		FindFn: func(tx *sql.Tx, filter planetscale.SettlementFilter) ([]*planetscale.Settlement, error) {
+			return []*planetscale.Settlement{
+				{
+					SettlementID: 1,
+					GroupID:      groupID,
+					PaidBy:       "test-user-id-2",
+					PaidTo:       "test-user-id",
+					Amount:       30_00,
+					Currency:     "GBP",
+				},
+			}, nil
+		},
+	}
+	balanceService.repos.ExpenseParticipant = &db_mock.ExpenseParticipantRepo{
+		FindFn: func(tx *sql.Tx, filter planetscale.ExpenseParticipantFilter) ([]*planetscale.ExpenseParticipant, error) {
+			return []*planetscale.ExpenseParticipant{
+				{ExpenseID: 1, UserID: "test-user-id"},
+				{ExpenseID: 1, UserID: "test-user-id-2"},
+				{ExpenseID: 1, UserID: "test-user-id-3"},
+			}, nil
+		},
+	}
+	balanceService.repos.FXRate = &db_mock.FXRateRepo{
+		GetAsOfFn: func(tx *sql.Tx, baseCurrency, quoteCurrency string, asOf time.Time) (*planetscale.FXRate, error) {
+			switch baseCurrency + "/" + quoteCurrency {
+			case "EUR/USD":
+				return &planetscale.FXRate{Rate: 1.1}, nil
+			case "USD/GBP":
+				return &planetscale.FXRate{Rate: 0.8}, nil
+			}
+			return nil, planetscale.Errorf(planetscale.ENOTFOUND, "no rate")
+		},
+	}
+
+	balances, err := balanceService.GetGroupBalances(context.Background(), groupID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// EUR 100.00 at 1.1 is USD 110.00, split 36.68/36.66/36.66, and GBP 30.00
+	// at 1/0.8 is USD 37.50
+	expected := []*planetscale.Balance{
+		{
+			UserID: "test-user-id",
+			Amount: 35_82,
+			BalanceItems: map[string]planetscale.Money{
+				"test-user-id-2": -84,
+				"test-user-id-3": 36_66,
+			},
+		},
+		{
+			UserID: "test-user-id-2",
+			Amount: 84,
+			BalanceItems: map[string]planetscale.Money{
+				"test-user-id": 84,
+			},
+		},
+		{
+			UserID: "test-user-id-3",
+			Amount: -36_66,
+			BalanceItems: map[string]planetscale.Money{
+				"test-user-id": -36_66,
+			},
+		},
+	}
+	if !compareBalances(expected, balances) {
+		for _, b := range balances {
+			t.Errorf("%+v", b)
+		}
+		t.Fatal("Expected and actual balances do not match.")
+	}
+	for _, balance := range balances {
+		if balance.Currency != "USD" {
+			t.Fatalf("expected balances in USD, got %s", balance.Currency)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+
+	planetscale "github.com/harshav17/planet_scale"
+)
+
+type groupMemberService struct {
+	repos    *planetscale.RepoProvider
+	tm       planetscale.TransactionManager
+	balances *balanceService
+	events   planetscale.EventHub
+}
+
+func NewGroupMemberService(repoProvider *planetscale.RepoProvider, tm planetscale.TransactionManager, events planetscale.EventHub) *groupMemberService {
+	return &groupMemberService{
+		repos:    repoProvider,
+		tm:       tm,
+		balances: NewBalanceService(repoProvider, tm, events),
+		events:   events,
+	}
+}
+
+func (s *groupMemberService) Remove(ctx context.Context, groupID int64, userID string, actorID string, force bool) error {
+	var audit *planetscale.AuditEvent
+	removeFunc := func(tx *sql.Tx) error {
+		actor, err := planetscale.CheckPermission(tx, s.repos, groupID, actorID, planetscale.PermissionView)
+		if err != nil {
+			return err
+		}
+
+		member, err := s.repos.GroupMember.Get(tx, groupID, userID)
+		if err != nil {
+			return err
+		}
+
+		// members may leave on their own, except the owner who has to hand
+		// the group over first
+		if member.UserID == actor.UserID {
+			if member.Role == planetscale.RoleOwner {
+				return planetscale.Errorf(planetscale.EFORBIDDEN, "the owner has to transfer ownership before leaving the group")
+			}
+		} else if !actor.Can(planetscale.PermissionManage) || !actor.Outranks(member.Role) {
+			return planetscale.Errorf(planetscale.EFORBIDDEN, "a group %s cannot remove a %s", actor.Role, member.Role)
+		}
+
+		// leaving an unsettled balance behind is up to the admins
+		if force && !actor.Can(planetscale.PermissionManage) {
+			return planetscale.Errorf(planetscale.EFORBIDDEN, "only admins can remove a member with an outstanding balance")
+		}
+
+		// the balance is read in the same transaction so that nobody adds an
+		// expense for the member in between
+		balances, err := s.balances.groupBalances(tx, groupID)
+		if err != nil {
+			return err
+		}
+		for _, balance := range balances {
+			if balance.UserID == userID && balance.Amount != 0 && !force {
+				return planetscale.Errorf(planetscale.ECONFLICT, "%s has an outstanding balance of %s %s, settle up before leaving the group", userID, balance.Amount, balance.Currency)
+			}
+		}
+
+		// the row stays so that past expenses keep pointing at a member
+		err = s.repos.GroupMember.Delete(tx, groupID, userID)
+		if err != nil {
+			return err
+		}
+
+		audit = &planetscale.AuditEvent{
+			GroupID:    &groupID,
+			EntityType: planetscale.AuditEntityGroupMember,
+			EntityID:   userID,
+			Action:     planetscale.AuditActionDelete,
+			ActorID:    actorID,
+			Before:     member,
+		}
+		err = s.repos.AuditEvent.Create(tx, audit)
+		if err != nil {
+			return err
+		}
+		return s.repos.Activity.Create(tx, planetscale.NewActivity(audit))
+	}
+
+	err := s.tm.ExecuteInTx(ctx, removeFunc)
+	if err != nil {
+		return err
+	}
+	planetscale.Publish(s.events, audit)
+	return nil
+}
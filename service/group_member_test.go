@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	planetscale "github.com/harshav17/planet_scale"
+	db_mock "github.com/harshav17/planet_scale/mock/db"
+)
+
+func TestGroupMemberService_Remove(t *testing.T) {
+	groupID := int64(1)
+	expenses := []*planetscale.Expense{
+		{
+			ExpenseID:   1,
+			GroupID:     &groupID,
+			PaidBy:      "test-user-id",
+			Amount:      100_00,
+			SplitTypeID: planetscale.SplitTypeEqual,
+		},
+	}
+	participants := []*planetscale.ExpenseParticipant{
+		{ExpenseID: 1, UserID: "test-user-id"},
+		{ExpenseID: 1, UserID: "test-user-id-2"},
+	}
+
+	roles := map[string]string{
+		"test-user-id":   planetscale.RoleOwner,
+		"test-user-id-2": planetscale.RoleMember,
+		"test-user-id-3": planetscale.RoleMember,
+		"test-user-id-4": planetscale.RoleAdmin,
+	}
+	newService := func(removed *[]string) *groupMemberService {
+		tm := db_mock.TransactionManager{}
+		tm.ExecuteInTxFn = func(ctx context.Context, fn func(*sql.Tx) error) error {
+			return fn(nil)
+		}
+		groupMemberService := NewGroupMemberService(&planetscale.RepoProvider{}, tm, nil)
+		groupMemberService.repos.GroupMember = &db_mock.GroupMemberRepo{
+			GetFn: func(tx *sql.Tx, groupID int64, userID string) (*planetscale.GroupMember, error) {
+				role, ok := roles[userID]
+				if !ok {
+					return nil, planetscale.Errorf(planetscale.ENOTFOUND, "user not found")
+				}
+				return &planetscale.GroupMember{GroupID: groupID, UserID: userID, Role: role}, nil
+			},
+			DeleteFn: func(tx *sql.Tx, groupID int64, userID string) error {
+				*removed = append(*removed, userID)
+				return nil
+			},
+		}
+		groupMemberService.repos.ExpenseGroup = &db_mock.ExpenseGroupRepo{
+			GetFn: func(tx *sql.Tx, groupID int64) (*planetscale.ExpenseGroup, error) {
+				return &planetscale.ExpenseGroup{ExpenseGroupID: groupID, BaseCurrency: "USD"}, nil
+			},
+		}
+		groupMemberService.repos.Expense = &db_mock.ExpenseRepo{
+			FindFn: func(tx *sql.Tx, filter planetscale.ExpenseFilter) ([]*planetscale.Expense, error) {
+				return expenses, nil
+			},
+		}
+		groupMemberService.repos.Settlement = &db_mock.SettlementRepo{
+			FindFn: func(tx *sql.Tx, filter planetscale.SettlementFilter) ([]*planetscale.Settlement, error) {
+				return nil, nil
+			},
+		}
+		groupMemberService.repos.ExpenseParticipant = &db_mock.ExpenseParticipantRepo{
+			FindFn: func(tx *sql.Tx, filter planetscale.ExpenseParticipantFilter) ([]*planetscale.ExpenseParticipant, error) {
+				return filterParitipantsByExpenseID(participants, filter.ExpenseID), nil
+			},
+		}
+		groupMemberService.repos.AuditEvent = &db_mock.AuditEventRepo{
+			CreateFn: func(tx *sql.Tx, event *planetscale.AuditEvent) error {
+				return nil
+			},
+		}
+		groupMemberService.repos.Activity = &db_mock.ActivityRepo{
+			CreateFn: func(tx *sql.Tx, activity *planetscale.Activity) error {
+				return nil
+			},
+		}
+		return groupMemberService
+	}
+
+	t.Run("outstanding balance", func(t *testing.T) {
+		var removed []string
+		err := newService(&removed).Remove(context.Background(), groupID, "test-user-id-2", "test-user-id-2", false)
+		if planetscale.ErrorCode(err) != planetscale.ECONFLICT {
+			t.Fatalf("expected ECONFLICT, got %v", err)
+		} else if len(removed) != 0 {
+			t.Fatalf("expected no member to be removed, got %v", removed)
+		}
+	})
+
+	t.Run("forced", func(t *testing.T) {
+		var removed []string
+		err := newService(&removed).Remove(context.Background(), groupID, "test-user-id-2", "test-user-id-4", true)
+		if err != nil {
+			t.Fatal(err)
+		} else if len(removed) != 1 || removed[0] != "test-user-id-2" {
+			t.Fatalf("expected test-user-id-2 to be removed, got %v", removed)
+		}
+	})
+
+	t.Run("no expenses", func(t *testing.T) {
+		var removed []string
+		err := newService(&removed).Remove(context.Background(), groupID, "test-user-id-3", "test-user-id-3", false)
+		if err != nil {
+			t.Fatal(err)
+		} else if len(removed) != 1 {
+			t.Fatalf("expected 1 member to be removed, got %v", removed)
+		}
+	})
+
+	t.Run("members cannot force leaving", func(t *testing.T) {
+		var removed []string
+		err := newService(&removed).Remove(context.Background(), groupID, "test-user-id-2", "test-user-id-2", true)
+		if planetscale.ErrorCode(err) != planetscale.EFORBIDDEN {
+			t.Fatalf("expected EFORBIDDEN, got %v", err)
+		} else if len(removed) != 0 {
+			t.Fatalf("expected no member to be removed, got %v", removed)
+		}
+	})
+
+	t.Run("members cannot remove others", func(t *testing.T) {
+		var removed []string
+		err := newService(&removed).Remove(context.Background(), groupID, "test-user-id-3", "test-user-id-2", false)
+		if planetscale.ErrorCode(err) != planetscale.EFORBIDDEN {
+			t.Fatalf("expected EFORBIDDEN, got %v", err)
+		} else if len(removed) != 0 {
+			t.Fatalf("expected no member to be removed, got %v", removed)
+		}
+	})
+
+	t.Run("admins cannot remove the owner", func(t *testing.T) {
+		var removed []string
+		err := newService(&removed).Remove(context.Background(), groupID, "test-user-id", "test-user-id-4", true)
+		if planetscale.ErrorCode(err) != planetscale.EFORBIDDEN {
+			t.Fatalf("expected EFORBIDDEN, got %v", err)
+		}
+	})
+
+	t.Run("owner cannot leave", func(t *testing.T) {
+		var removed []string
+		err := newService(&removed).Remove(context.Background(), groupID, "test-user-id", "test-user-id", true)
+		if planetscale.ErrorCode(err) != planetscale.EFORBIDDEN {
+			t.Fatalf("expected EFORBIDDEN, got %v", err)
+		} else if len(removed) != 0 {
+			t.Fatalf("expected no member to be removed, got %v", removed)
+		}
+	})
+
+	t.Run("non members cannot remove anyone", func(t *testing.T) {
+		var removed []string
+		err := newService(&removed).Remove(context.Background(), groupID, "test-user-id-3", "stranger", false)
+		if planetscale.ErrorCode(err) != planetscale.ENOTFOUND {
+			t.Fatalf("expected ENOTFOUND, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	planetscale "github.com/harshav17/planet_scale"
+)
+
+type inviteTokenService struct {
+	secret []byte
+	now    func() time.Time
+}
+
+func NewInviteTokenService(secret []byte) *inviteTokenService {
+	return &inviteTokenService{
+		secret: secret,
+		now:    time.Now,
+	}
+}
+
+// Sign returns a token of the form <invite id>.<expiry>.<signature>, the
+// expiry being a unix timestamp, so it can be checked before any lookup.
+func (s *inviteTokenService) Sign(invite *planetscale.GroupInvite) (string, error) {
+	if invite.InviteID == 0 || invite.ExpiresAt.IsZero() {
+		return "", planetscale.Errorf(planetscale.EINVALID, "cannot sign an invite that was not created")
+	}
+
+	payload := fmt.Sprintf("%d.%d", invite.InviteID, invite.ExpiresAt.Unix())
+	return payload + "." + s.signature(payload), nil
+}
+
+func (s *inviteTokenService) Verify(token string) (int64, error) {
+	invalid := planetscale.Errorf(planetscale.EINVALID, "invalid invite token")
+
+	i := strings.LastIndexByte(token, '.')
+	if i < 0 {
+		return 0, invalid
+	}
+	payload, signature := token[:i], token[i+1:]
+	if !hmac.Equal([]byte(signature), []byte(s.signature(payload))) {
+		return 0, invalid
+	}
+
+	inviteID, expiresAt, ok := strings.Cut(payload, ".")
+	if !ok {
+		return 0, invalid
+	}
+	id, err := strconv.ParseInt(inviteID, 10, 64)
+	if err != nil {
+		return 0, invalid
+	}
+	expires, err := strconv.ParseInt(expiresAt, 10, 64)
+	if err != nil {
+		return 0, invalid
+	}
+	if !s.now().Before(time.Unix(expires, 0)) {
+		return 0, planetscale.Errorf(planetscale.EINVALID, "invite has expired")
+	}
+	return id, nil
+}
+
+func (s *inviteTokenService) signature(payload string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
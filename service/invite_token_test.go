@@ -0,0 +1,65 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	planetscale "github.com/harshav17/planet_scale"
+)
+
+func TestInviteTokenService(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	invite := &planetscale.GroupInvite{InviteID: 42, ExpiresAt: now.Add(planetscale.InviteTTL)}
+
+	newService := func(secret string) *inviteTokenService {
+		s := NewInviteTokenService([]byte(secret))
+		s.now = func() time.Time { return now }
+		return s
+	}
+
+	t.Run("round trip", func(t *testing.T) {
+		s := newService("secret")
+		token, err := s.Sign(invite)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		inviteID, err := s.Verify(token)
+		if err != nil {
+			t.Fatal(err)
+		} else if inviteID != 42 {
+			t.Fatalf("expected invite 42, got %d", inviteID)
+		}
+	})
+
+	t.Run("rejected tokens", func(t *testing.T) {
+		token, err := newService("secret").Sign(invite)
+		if err != nil {
+			t.Fatal(err)
+		}
+		forged, err := newService("other secret").Sign(invite)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expired := newService("secret")
+		expired.now = func() time.Time { return invite.ExpiresAt }
+
+		for name, tc := range map[string]struct {
+			service *inviteTokenService
+			token   string
+		}{
+			"other secret":    {newService("secret"), forged},
+			"other invite":    {newService("secret"), strings.Replace(token, "42.", "43.", 1)},
+			"extended expiry": {newService("secret"), strings.Replace(token, ".", ".9", 1)},
+			"expired":         {expired, token},
+			"garbage":         {newService("secret"), "garbage"},
+		} {
+			t.Run(name, func(t *testing.T) {
+				if _, err := tc.service.Verify(tc.token); planetscale.ErrorCode(err) != planetscale.EINVALID {
+					t.Fatalf("expected EINVALID, got %v", err)
+				}
+			})
+		}
+	})
+}
@@ -0,0 +1,448 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+
+	planetscale "github.com/harshav17/planet_scale"
+)
+
+func (s *expenseService) CreateItems(ctx context.Context, expenseID int64, items []*planetscale.Item, userID string) error {
+	for _, item := range items {
+		if err := validateItem(item); err != nil {
+			return err
+		}
+		for _, split := range item.Splits {
+			if err := validateItemSplit(split); err != nil {
+				return err
+			}
+		}
+	}
+
+	var audits []*planetscale.AuditEvent
+	createItemsFunc := func(tx *sql.Tx) error {
+		expense, member, err := planetscale.GetMemberExpense(tx, s.repos, expenseID, userID, planetscale.PermissionEdit)
+		if err != nil {
+			return err
+		}
+		err = planetscale.CheckAuthor(member, expense.CreatedBy, expense.PaidBy)
+		if err != nil {
+			return err
+		}
+
+		var added planetscale.Money
+		for _, item := range items {
+			item.ExpenseID = expenseID
+			err = s.repos.Item.Create(tx, item)
+			if err != nil {
+				return err
+			}
+			for _, split := range item.Splits {
+				// splits can only be held by members, everybody else is a guest
+				if split.UserID != nil {
+					err = planetscale.CheckInvolved(tx, s.repos, expense, *split.UserID)
+					if err != nil {
+						return err
+					}
+				}
+				split.ItemID = item.ItemID
+				err = s.repos.ItemSplit.Create(tx, split)
+				if err != nil {
+					return err
+				}
+			}
+			added += itemCost(item)
+
+			audit := &planetscale.AuditEvent{
+				GroupID:    expense.GroupID,
+				EntityType: planetscale.AuditEntityItem,
+				EntityID:   strconv.FormatInt(item.ItemID, 10),
+				Action:     planetscale.AuditActionCreate,
+				ActorID:    userID,
+				After:      item,
+			}
+			err = s.recordAudit(tx, audit)
+			if err != nil {
+				return err
+			}
+			audits = append(audits, audit)
+		}
+
+		audit, err := s.itemsChanged(tx, expense, added, userID)
+		if err != nil {
+			return err
+		}
+		if audit != nil {
+			audits = append(audits, audit)
+		}
+		return nil
+	}
+
+	err := s.tm.ExecuteInTx(ctx, createItemsFunc)
+	if err != nil {
+		return err
+	}
+	planetscale.Publish(s.events, audits...)
+	return nil
+}
+
+func (s *expenseService) UpdateItem(ctx context.Context, itemID int64, update *planetscale.ItemUpdate, userID string) (*planetscale.Item, error) {
+	if update.Price != nil && *update.Price < 0 {
+		return nil, planetscale.Errorf(planetscale.EINVALID, "price cannot be negative")
+	} else if update.Quantity != nil && *update.Quantity < 1 {
+		return nil, planetscale.Errorf(planetscale.EINVALID, "quantity must be at least 1")
+	}
+
+	var item *planetscale.Item
+	var audits []*planetscale.AuditEvent
+	updateItemFunc := func(tx *sql.Tx) error {
+		before, expense, err := s.getItem(tx, itemID, userID)
+		if err != nil {
+			return err
+		}
+
+		item, err = s.repos.Item.Update(tx, itemID, update)
+		if err != nil {
+			return err
+		}
+
+		audit := &planetscale.AuditEvent{
+			GroupID:    expense.GroupID,
+			EntityType: planetscale.AuditEntityItem,
+			EntityID:   strconv.FormatInt(itemID, 10),
+			Action:     planetscale.AuditActionUpdate,
+			ActorID:    userID,
+			Before:     before,
+			After:      item,
+		}
+		err = s.recordAudit(tx, audit)
+		if err != nil {
+			return err
+		}
+		audits = append(audits, audit)
+
+		audit, err = s.itemsChanged(tx, expense, itemCost(item)-itemCost(before), userID)
+		if err != nil {
+			return err
+		}
+		if audit != nil {
+			audits = append(audits, audit)
+		}
+		return nil
+	}
+
+	err := s.tm.ExecuteInTx(ctx, updateItemFunc)
+	if err != nil {
+		return nil, err
+	}
+	planetscale.Publish(s.events, audits...)
+	return item, nil
+}
+
+func (s *expenseService) DeleteItem(ctx context.Context, itemID int64, userID string) error {
+	var audits []*planetscale.AuditEvent
+	deleteItemFunc := func(tx *sql.Tx) error {
+		item, expense, err := s.getItem(tx, itemID, userID)
+		if err != nil {
+			return err
+		}
+
+		item.Splits, err = s.repos.ItemSplit.Find(tx, planetscale.ItemSplitFilter{
+			ItemID: itemID,
+		})
+		if err != nil {
+			return err
+		}
+		for _, split := range item.Splits {
+			err = s.repos.ItemSplit.Delete(tx, split.ItemSplitID)
+			if err != nil {
+				return err
+			}
+		}
+
+		err = s.repos.Item.Delete(tx, itemID)
+		if err != nil {
+			return err
+		}
+
+		audit := &planetscale.AuditEvent{
+			GroupID:    expense.GroupID,
+			EntityType: planetscale.AuditEntityItem,
+			EntityID:   strconv.FormatInt(itemID, 10),
+			Action:     planetscale.AuditActionDelete,
+			ActorID:    userID,
+			Before:     item,
+		}
+		err = s.recordAudit(tx, audit)
+		if err != nil {
+			return err
+		}
+		audits = append(audits, audit)
+
+		audit, err = s.itemsChanged(tx, expense, -itemCost(item), userID)
+		if err != nil {
+			return err
+		}
+		if audit != nil {
+			audits = append(audits, audit)
+		}
+		return nil
+	}
+
+	err := s.tm.ExecuteInTx(ctx, deleteItemFunc)
+	if err != nil {
+		return err
+	}
+	planetscale.Publish(s.events, audits...)
+	return nil
+}
+
+func (s *expenseService) CreateItemSplit(ctx context.Context, itemID int64, split *planetscale.ItemSplit, userID string) error {
+	if err := validateItemSplit(split); err != nil {
+		return err
+	}
+
+	var audit *planetscale.AuditEvent
+	createSplitFunc := func(tx *sql.Tx) error {
+		_, expense, err := s.getItem(tx, itemID, userID)
+		if err != nil {
+			return err
+		}
+
+		// splits can only be held by members, everybody else is a guest
+		if split.UserID != nil {
+			err = planetscale.CheckInvolved(tx, s.repos, expense, *split.UserID)
+			if err != nil {
+				return err
+			}
+		}
+
+		split.ItemID = itemID
+		err = s.repos.ItemSplit.Create(tx, split)
+		if err != nil {
+			return err
+		}
+
+		audit = &planetscale.AuditEvent{
+			GroupID:    expense.GroupID,
+			EntityType: planetscale.AuditEntityItemSplit,
+			EntityID:   strconv.FormatInt(split.ItemSplitID, 10),
+			Action:     planetscale.AuditActionCreate,
+			ActorID:    userID,
+			After:      split,
+		}
+		err = s.recordAudit(tx, audit)
+		if err != nil {
+			return err
+		}
+		_, err = s.itemsChanged(tx, expense, 0, userID)
+		return err
+	}
+
+	err := s.tm.ExecuteInTx(ctx, createSplitFunc)
+	if err != nil {
+		return err
+	}
+	planetscale.Publish(s.events, audit)
+	return nil
+}
+
+func (s *expenseService) UpdateItemSplit(ctx context.Context, itemID int64, itemSplitID int64, update *planetscale.ItemSplitUpdate, userID string) (*planetscale.ItemSplit, error) {
+	if update.Amount != nil && *update.Amount < 0 {
+		return nil, planetscale.Errorf(planetscale.EINVALID, "amount cannot be negative")
+	}
+
+	var split *planetscale.ItemSplit
+	var audit *planetscale.AuditEvent
+	updateSplitFunc := func(tx *sql.Tx) error {
+		_, expense, err := s.getItem(tx, itemID, userID)
+		if err != nil {
+			return err
+		}
+		before, err := s.getItemSplit(tx, itemID, itemSplitID)
+		if err != nil {
+			return err
+		}
+
+		split, err = s.repos.ItemSplit.Update(tx, itemSplitID, update)
+		if err != nil {
+			return err
+		}
+
+		audit = &planetscale.AuditEvent{
+			GroupID:    expense.GroupID,
+			EntityType: planetscale.AuditEntityItemSplit,
+			EntityID:   strconv.FormatInt(itemSplitID, 10),
+			Action:     planetscale.AuditActionUpdate,
+			ActorID:    userID,
+			Before:     before,
+			After:      split,
+		}
+		err = s.recordAudit(tx, audit)
+		if err != nil {
+			return err
+		}
+		_, err = s.itemsChanged(tx, expense, 0, userID)
+		return err
+	}
+
+	err := s.tm.ExecuteInTx(ctx, updateSplitFunc)
+	if err != nil {
+		return nil, err
+	}
+	planetscale.Publish(s.events, audit)
+	return split, nil
+}
+
+func (s *expenseService) DeleteItemSplit(ctx context.Context, itemID int64, itemSplitID int64, userID string) error {
+	var audit *planetscale.AuditEvent
+	deleteSplitFunc := func(tx *sql.Tx) error {
+		_, expense, err := s.getItem(tx, itemID, userID)
+		if err != nil {
+			return err
+		}
+		split, err := s.getItemSplit(tx, itemID, itemSplitID)
+		if err != nil {
+			return err
+		}
+
+		err = s.repos.ItemSplit.Delete(tx, itemSplitID)
+		if err != nil {
+			return err
+		}
+
+		audit = &planetscale.AuditEvent{
+			GroupID:    expense.GroupID,
+			EntityType: planetscale.AuditEntityItemSplit,
+			EntityID:   strconv.FormatInt(itemSplitID, 10),
+			Action:     planetscale.AuditActionDelete,
+			ActorID:    userID,
+			Before:     split,
+		}
+		err = s.recordAudit(tx, audit)
+		if err != nil {
+			return err
+		}
+		_, err = s.itemsChanged(tx, expense, 0, userID)
+		return err
+	}
+
+	err := s.tm.ExecuteInTx(ctx, deleteSplitFunc)
+	if err != nil {
+		return err
+	}
+	planetscale.Publish(s.events, audit)
+	return nil
+}
+
+// itemsChanged keeps an item based expense in line with its items once they
+// changed. The amount moves along with the subtotal, by added, and the
+// participants are allocated again, which fails unless everything still
+// reconciles. The audit of the new amount is returned, if there is one.
+// Items of other expenses are only a record of the receipt.
+func (s *expenseService) itemsChanged(tx *sql.Tx, expense *planetscale.Expense, added planetscale.Money, userID string) (*planetscale.AuditEvent, error) {
+	if expense.SplitTypeID != planetscale.SplitTypeItemBased {
+		return nil, nil
+	}
+
+	var audit *planetscale.AuditEvent
+	if added != 0 {
+		amount := expense.Amount + added
+		if amount <= 0 {
+			return nil, planetscale.Errorf(planetscale.EINVALID, "expense amount must be greater than zero")
+		}
+		after, err := s.repos.Expense.Update(tx, expense.ExpenseID, &planetscale.ExpenseUpdate{
+			Amount:    &amount,
+			UpdatedBy: &userID,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		audit = &planetscale.AuditEvent{
+			GroupID:    expense.GroupID,
+			EntityType: planetscale.AuditEntityExpense,
+			EntityID:   strconv.FormatInt(expense.ExpenseID, 10),
+			Action:     planetscale.AuditActionUpdate,
+			ActorID:    userID,
+			Before:     expense,
+			After:      after,
+		}
+		err = s.recordAudit(tx, audit)
+		if err != nil {
+			return nil, err
+		}
+		expense = after
+	}
+	return audit, s.reallocateItemized(tx, expense)
+}
+
+// recordAudit stores an audited change along with its webhook event and the
+// activity it shows up as.
+func (s *expenseService) recordAudit(tx *sql.Tx, audit *planetscale.AuditEvent) error {
+	err := s.repos.AuditEvent.Create(tx, audit)
+	if err != nil {
+		return err
+	}
+	err = s.repos.WebhookEvent.Create(tx, planetscale.NewWebhookEvent(audit))
+	if err != nil {
+		return err
+	}
+	return s.repos.Activity.Create(tx, planetscale.NewActivity(audit))
+}
+
+// getItem loads an item along with its expense, which userID has to be allowed
+// to edit. Unless they may edit everything, it has to be theirs.
+func (s *expenseService) getItem(tx *sql.Tx, itemID int64, userID string) (*planetscale.Item, *planetscale.Expense, error) {
+	item, err := s.repos.Item.Get(tx, itemID)
+	if err != nil {
+		return nil, nil, planetscale.Errorf(planetscale.ENOTFOUND, "no item found with ID %d", itemID)
+	}
+
+	expense, member, err := planetscale.GetMemberExpense(tx, s.repos, item.ExpenseID, userID, planetscale.PermissionEdit)
+	if err != nil {
+		return nil, nil, err
+	}
+	err = planetscale.CheckAuthor(member, expense.CreatedBy, expense.PaidBy)
+	if err != nil {
+		return nil, nil, err
+	}
+	return item, expense, nil
+}
+
+// getItemSplit loads a split of the item.
+func (s *expenseService) getItemSplit(tx *sql.Tx, itemID int64, itemSplitID int64) (*planetscale.ItemSplit, error) {
+	split, err := s.repos.ItemSplit.Get(tx, itemSplitID)
+	if err != nil || split.ItemID != itemID {
+		return nil, planetscale.Errorf(planetscale.ENOTFOUND, "no item split found with ID %d", itemSplitID)
+	}
+	return split, nil
+}
+
+func validateItem(item *planetscale.Item) error {
+	if item.Price < 0 {
+		return planetscale.Errorf(planetscale.EINVALID, "price of item %q cannot be negative", item.Name)
+	} else if item.Quantity < 0 {
+		return planetscale.Errorf(planetscale.EINVALID, "quantity of item %q cannot be negative", item.Name)
+	}
+	return nil
+}
+
+func validateItemSplit(split *planetscale.ItemSplit) error {
+	if err := split.Validate(); err != nil {
+		return err
+	} else if split.Amount < 0 {
+		return planetscale.Errorf(planetscale.EINVALID, "amount cannot be negative")
+	}
+	return nil
+}
+
+// itemCost is what an item adds to the subtotal, its price times its quantity.
+func itemCost(item *planetscale.Item) planetscale.Money {
+	quantity := item.Quantity
+	if quantity == 0 {
+		quantity = 1
+	}
+	return item.Price * planetscale.Money(quantity)
+}
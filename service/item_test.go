@@ -0,0 +1,368 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	planetscale "github.com/harshav17/planet_scale"
+	db_mock "github.com/harshav17/planet_scale/mock/db"
+)
+
+func TestExpenseService_Items(t *testing.T) {
+	groupID := int64(1)
+	userID, userID2 := "test-user-id", "test-user-id-2"
+
+	type store struct {
+		expense  *planetscale.Expense
+		items    map[int64]*planetscale.Item
+		splits   map[int64]*planetscale.ItemSplit
+		upserted []*planetscale.ExpenseParticipant
+		deleted  []int64
+		audits   []*planetscale.AuditEvent
+		webhooks []*planetscale.WebhookEvent
+		activity []*planetscale.Activity
+	}
+	// newItemService starts out with a 30.00 item based expense for a single
+	// item, split between both users.
+	newItemService := func(splitTypeID int64) (*expenseService, *store) {
+		s := &store{
+			expense: &planetscale.Expense{ExpenseID: 1, GroupID: &groupID, PaidBy: userID, Amount: 30_00, SplitTypeID: splitTypeID, CreatedBy: userID},
+			items: map[int64]*planetscale.Item{
+				1: {ItemID: 1, ExpenseID: 1, Name: "Pizza", Price: 30_00, Quantity: 1},
+			},
+			splits: map[int64]*planetscale.ItemSplit{
+				1: {ItemSplitID: 1, ItemID: 1, UserID: &userID},
+				2: {ItemSplitID: 2, ItemID: 1, UserID: &userID2},
+			},
+		}
+		expenseService := newTestExpenseService(new([]*planetscale.ExpenseParticipant))
+		expenseService.repos.AuditEvent = &db_mock.AuditEventRepo{
+			CreateFn: func(tx *sql.Tx, event *planetscale.AuditEvent) error {
+				s.audits = append(s.audits, event)
+				return nil
+			},
+		}
+		expenseService.repos.WebhookEvent = &db_mock.WebhookEventRepo{
+			CreateFn: func(tx *sql.Tx, event *planetscale.WebhookEvent) error {
+				s.webhooks = append(s.webhooks, event)
+				return nil
+			},
+		}
+		expenseService.repos.Activity = &db_mock.ActivityRepo{
+			CreateFn: func(tx *sql.Tx, activity *planetscale.Activity) error {
+				s.activity = append(s.activity, activity)
+				return nil
+			},
+		}
+		expenseService.repos.Expense = &db_mock.ExpenseRepo{
+			GetFn: func(tx *sql.Tx, expenseID int64) (*planetscale.Expense, error) {
+				expense := *s.expense
+				return &expense, nil
+			},
+			UpdateFn: func(tx *sql.Tx, expenseID int64, update *planetscale.ExpenseUpdate) (*planetscale.Expense, error) {
+				if update.Amount != nil {
+					s.expense.Amount = *update.Amount
+				}
+				expense := *s.expense
+				return &expense, nil
+			},
+		}
+		expenseService.repos.GroupMember = &db_mock.GroupMemberRepo{
+			GetFn: func(tx *sql.Tx, groupID int64, userID string) (*planetscale.GroupMember, error) {
+				if userID != "test-user-id" && userID != "test-user-id-2" {
+					return nil, planetscale.Errorf(planetscale.ENOTFOUND, "not a member")
+				}
+				return &planetscale.GroupMember{GroupID: groupID, UserID: userID, Role: planetscale.RoleMember}, nil
+			},
+		}
+		expenseService.repos.Item = &db_mock.ItemRepo{
+			GetFn: func(tx *sql.Tx, itemID int64) (*planetscale.Item, error) {
+				item, ok := s.items[itemID]
+				if !ok {
+					return nil, planetscale.Errorf(planetscale.ENOTFOUND, "item not found")
+				}
+				copied := *item
+				return &copied, nil
+			},
+			CreateFn: func(tx *sql.Tx, item *planetscale.Item) error {
+				item.ItemID = int64(len(s.items) + 1)
+				copied := *item
+				s.items[item.ItemID] = &copied
+				return nil
+			},
+			UpdateFn: func(tx *sql.Tx, itemID int64, update *planetscale.ItemUpdate) (*planetscale.Item, error) {
+				item := s.items[itemID]
+				if update.Price != nil {
+					item.Price = *update.Price
+				}
+				copied := *item
+				return &copied, nil
+			},
+			DeleteFn: func(tx *sql.Tx, itemID int64) error {
+				delete(s.items, itemID)
+				return nil
+			},
+			FindFn: func(tx *sql.Tx, filter planetscale.ItemFilter) ([]*planetscale.Item, error) {
+				var items []*planetscale.Item
+				for id := int64(1); id <= int64(len(s.items)+1); id++ {
+					if item, ok := s.items[id]; ok {
+						copied := *item
+						copied.Splits = nil
+						items = append(items, &copied)
+					}
+				}
+				return items, nil
+			},
+		}
+		expenseService.repos.ItemSplit = &db_mock.ItemSplitRepo{
+			GetFn: func(tx *sql.Tx, itemSplitID int64) (*planetscale.ItemSplit, error) {
+				split, ok := s.splits[itemSplitID]
+				if !ok {
+					return nil, planetscale.Errorf(planetscale.ENOTFOUND, "item split not found")
+				}
+				copied := *split
+				return &copied, nil
+			},
+			CreateFn: func(tx *sql.Tx, split *planetscale.ItemSplit) error {
+				split.ItemSplitID = int64(len(s.splits) + len(s.deleted) + 1)
+				copied := *split
+				s.splits[split.ItemSplitID] = &copied
+				return nil
+			},
+			UpdateFn: func(tx *sql.Tx, itemSplitID int64, update *planetscale.ItemSplitUpdate) (*planetscale.ItemSplit, error) {
+				split := s.splits[itemSplitID]
+				if update.Amount != nil {
+					split.Amount = *update.Amount
+				}
+				copied := *split
+				return &copied, nil
+			},
+			DeleteFn: func(tx *sql.Tx, itemSplitID int64) error {
+				delete(s.splits, itemSplitID)
+				s.deleted = append(s.deleted, itemSplitID)
+				return nil
+			},
+			FindFn: func(tx *sql.Tx, filter planetscale.ItemSplitFilter) ([]*planetscale.ItemSplit, error) {
+				var splits []*planetscale.ItemSplit
+				for id := int64(1); id <= int64(len(s.splits)+len(s.deleted)); id++ {
+					if split, ok := s.splits[id]; ok && split.ItemID == filter.ItemID {
+						copied := *split
+						splits = append(splits, &copied)
+					}
+				}
+				return splits, nil
+			},
+		}
+		expenseService.repos.ExpenseParticipant = &db_mock.ExpenseParticipantRepo{
+			FindFn: func(tx *sql.Tx, filter planetscale.ExpenseParticipantFilter) ([]*planetscale.ExpenseParticipant, error) {
+				return []*planetscale.ExpenseParticipant{
+					{ExpenseID: 1, UserID: userID, AmountOwed: 15_00},
+					{ExpenseID: 1, UserID: userID2, AmountOwed: 15_00},
+				}, nil
+			},
+			UpsertFn: func(tx *sql.Tx, participant *planetscale.ExpenseParticipant) error {
+				s.upserted = append(s.upserted, participant)
+				return nil
+			},
+			DeleteFn: func(tx *sql.Tx, expenseID int64, userID string) error {
+				return nil
+			},
+		}
+		return expenseService, s
+	}
+	owed := func(participants []*planetscale.ExpenseParticipant) map[string]planetscale.Money {
+		owed := make(map[string]planetscale.Money)
+		for _, participant := range participants {
+			owed[participant.UserID] = participant.AmountOwed
+		}
+		return owed
+	}
+
+	t.Run("new items grow the expense", func(t *testing.T) {
+		expenseService, s := newItemService(planetscale.SplitTypeItemBased)
+
+		err := expenseService.CreateItems(context.Background(), 1, []*planetscale.Item{
+			{Name: "Wine", Price: 10_00, Quantity: 2, Splits: []*planetscale.ItemSplit{{UserID: &userID2}}},
+		}, userID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if s.expense.Amount != 50_00 {
+			t.Fatalf("expected amount 50.00, got %s", s.expense.Amount)
+		} else if got := owed(s.upserted); got[userID] != 15_00 || got[userID2] != 35_00 {
+			t.Fatalf("unexpected amounts owed %v", got)
+		}
+		// the item and the new amount of the expense
+		if len(s.audits) != 2 || len(s.webhooks) != 2 || len(s.activity) != 2 {
+			t.Fatalf("expected 2 audit, webhook and activity rows, got %d, %d and %d", len(s.audits), len(s.webhooks), len(s.activity))
+		} else if s.audits[1].EntityType != planetscale.AuditEntityExpense {
+			t.Fatalf("expected the expense update to be audited, got %s", s.audits[1].EntityType)
+		}
+	})
+
+	t.Run("new price moves the amount", func(t *testing.T) {
+		expenseService, s := newItemService(planetscale.SplitTypeItemBased)
+
+		price := planetscale.Money(20_00)
+		item, err := expenseService.UpdateItem(context.Background(), 1, &planetscale.ItemUpdate{Price: &price}, userID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if item.Price != price {
+			t.Fatalf("expected price %s, got %s", price, item.Price)
+		} else if s.expense.Amount != 20_00 {
+			t.Fatalf("expected amount 20.00, got %s", s.expense.Amount)
+		} else if got := owed(s.upserted); got[userID] != 10_00 || got[userID2] != 10_00 {
+			t.Fatalf("unexpected amounts owed %v", got)
+		}
+	})
+
+	t.Run("deleting an item deletes its splits", func(t *testing.T) {
+		expenseService, s := newItemService(planetscale.SplitTypeItemBased)
+		s.expense.Amount = 40_00
+		s.items[2] = &planetscale.Item{ItemID: 2, ExpenseID: 1, Name: "Wine", Price: 10_00, Quantity: 1}
+
+		err := expenseService.DeleteItem(context.Background(), 1, userID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(s.deleted) != 2 {
+			t.Fatalf("expected 2 item splits to be deleted, got %v", s.deleted)
+		} else if s.expense.Amount != 10_00 {
+			t.Fatalf("expected amount 10.00, got %s", s.expense.Amount)
+		} else if got := owed(s.upserted); got[userID] != 10_00 || len(got) != 1 {
+			t.Fatalf("unexpected amounts owed %v", got)
+		}
+	})
+
+	t.Run("split changes are allocated again", func(t *testing.T) {
+		expenseService, s := newItemService(planetscale.SplitTypeItemBased)
+
+		amount := planetscale.Money(10_00)
+		_, err := expenseService.UpdateItemSplit(context.Background(), 1, 2, &planetscale.ItemSplitUpdate{Amount: &amount}, userID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := owed(s.upserted); got[userID] != 20_00 || got[userID2] != 10_00 {
+			t.Fatalf("unexpected amounts owed %v", got)
+		} else if len(s.webhooks) != 1 || len(s.activity) != 1 {
+			t.Fatalf("expected a webhook and activity row, got %d and %d", len(s.webhooks), len(s.activity))
+		}
+	})
+
+	t.Run("items no longer reconcile", func(t *testing.T) {
+		expenseService, s := newItemService(planetscale.SplitTypeItemBased)
+		s.expense.Amount = 40_00
+
+		guest := "JD"
+		err := expenseService.CreateItemSplit(context.Background(), 1, &planetscale.ItemSplit{GuestName: &guest}, userID)
+		if planetscale.ErrorCode(err) != planetscale.EINVALID {
+			t.Fatalf("expected invalid error, got %v", err)
+		} else if len(s.upserted) != 0 {
+			t.Fatalf("expected no expense participants, got %d", len(s.upserted))
+		}
+	})
+
+	t.Run("split amounts exceed the item", func(t *testing.T) {
+		expenseService, _ := newItemService(planetscale.SplitTypeItemBased)
+
+		amount := planetscale.Money(40_00)
+		_, err := expenseService.UpdateItemSplit(context.Background(), 1, 1, &planetscale.ItemSplitUpdate{Amount: &amount}, userID)
+		if planetscale.ErrorCode(err) != planetscale.EINVALID {
+			t.Fatalf("expected invalid error, got %v", err)
+		}
+	})
+
+	t.Run("items of other split types are a record", func(t *testing.T) {
+		expenseService, s := newItemService(planetscale.SplitTypeEqual)
+
+		price := planetscale.Money(20_00)
+		_, err := expenseService.UpdateItem(context.Background(), 1, &planetscale.ItemUpdate{Price: &price}, userID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if s.expense.Amount != 30_00 {
+			t.Fatalf("expected amount to stay 30.00, got %s", s.expense.Amount)
+		} else if len(s.upserted) != 0 {
+			t.Fatalf("expected no expense participants, got %d", len(s.upserted))
+		} else if len(s.audits) != 1 || len(s.webhooks) != 1 || len(s.activity) != 1 {
+			t.Fatalf("expected the item update to be recorded, got %d, %d and %d", len(s.audits), len(s.webhooks), len(s.activity))
+		}
+	})
+
+	t.Run("split of another item", func(t *testing.T) {
+		expenseService, s := newItemService(planetscale.SplitTypeItemBased)
+		s.items[2] = &planetscale.Item{ItemID: 2, ExpenseID: 1, Name: "Wine", Price: 0, Quantity: 1}
+
+		err := expenseService.DeleteItemSplit(context.Background(), 2, 1, userID)
+		if planetscale.ErrorCode(err) != planetscale.ENOTFOUND {
+			t.Fatalf("expected not found error, got %v", err)
+		} else if len(s.deleted) != 0 {
+			t.Fatalf("expected no item splits to be deleted, got %v", s.deleted)
+		}
+	})
+
+	t.Run("splits are held by members", func(t *testing.T) {
+		expenseService, _ := newItemService(planetscale.SplitTypeItemBased)
+
+		outsider := "test-user-id-3"
+		err := expenseService.CreateItemSplit(context.Background(), 1, &planetscale.ItemSplit{UserID: &outsider}, userID)
+		if planetscale.ErrorCode(err) != planetscale.ENOTFOUND {
+			t.Fatalf("expected not found error, got %v", err)
+		}
+	})
+
+	t.Run("non members cannot add items", func(t *testing.T) {
+		expenseService, s := newItemService(planetscale.SplitTypeItemBased)
+
+		err := expenseService.CreateItems(context.Background(), 1, []*planetscale.Item{{Name: "Wine", Price: 10_00}}, "test-user-id-3")
+		if planetscale.ErrorCode(err) != planetscale.ENOTFOUND {
+			t.Fatalf("expected not found error, got %v", err)
+		} else if len(s.items) != 1 {
+			t.Fatalf("expected no items to be created, got %d", len(s.items))
+		}
+	})
+
+	t.Run("members only change their own expenses", func(t *testing.T) {
+		price := planetscale.Money(40_00)
+		amount := planetscale.Money(10_00)
+		changes := map[string]func(expenseService *expenseService) error{
+			"create items": func(expenseService *expenseService) error {
+				return expenseService.CreateItems(context.Background(), 1, []*planetscale.Item{{Name: "Wine", Price: 10_00}}, userID2)
+			},
+			"update item": func(expenseService *expenseService) error {
+				_, err := expenseService.UpdateItem(context.Background(), 1, &planetscale.ItemUpdate{Price: &price}, userID2)
+				return err
+			},
+			"delete item": func(expenseService *expenseService) error {
+				return expenseService.DeleteItem(context.Background(), 1, userID2)
+			},
+			"create split": func(expenseService *expenseService) error {
+				return expenseService.CreateItemSplit(context.Background(), 1, &planetscale.ItemSplit{UserID: &userID2}, userID2)
+			},
+			"update split": func(expenseService *expenseService) error {
+				_, err := expenseService.UpdateItemSplit(context.Background(), 1, 2, &planetscale.ItemSplitUpdate{Amount: &amount}, userID2)
+				return err
+			},
+			"delete split": func(expenseService *expenseService) error {
+				return expenseService.DeleteItemSplit(context.Background(), 1, 2, userID2)
+			},
+		}
+		for name, change := range changes {
+			expenseService, s := newItemService(planetscale.SplitTypeItemBased)
+
+			err := change(expenseService)
+			if planetscale.ErrorCode(err) != planetscale.EFORBIDDEN {
+				t.Fatalf("%s: expected forbidden error, got %v", name, err)
+			} else if len(s.audits) != 0 || len(s.items) != 1 || len(s.splits) != 2 || s.items[1].Price != 30_00 {
+				t.Fatalf("%s: expected nothing to change", name)
+			}
+		}
+	})
+}
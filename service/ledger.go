@@ -0,0 +1,861 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	planetscale "github.com/harshav17/planet_scale"
+)
+
+// Columns of planetscale.LedgerHeader.
+const (
+	ledgerColRecord = iota
+	ledgerColRef
+	ledgerColDate
+	ledgerColDescription
+	ledgerColPaidBy
+	ledgerColPaidTo
+	ledgerColUserID
+	ledgerColAmount
+	ledgerColCurrency
+	ledgerColSplitTypeID
+	ledgerColSharePercentage
+	ledgerColQuantity
+	ledgerColNote
+	ledgerColShares
+	ledgerColTax
+	ledgerColTip
+	ledgerColServiceCharge
+	ledgerColDiscount
+)
+
+// splitwiseHeader starts the header of a Splitwise export, one balance column
+// per member follows.
+var splitwiseHeader = []string{"Date", "Description", "Category", "Cost", "Currency"}
+
+type ledgerService struct {
+	repos  *planetscale.RepoProvider
+	tm     planetscale.TransactionManager
+	events planetscale.EventHub
+}
+
+func NewLedgerService(repoProvider *planetscale.RepoProvider, tm planetscale.TransactionManager, events planetscale.EventHub) *ledgerService {
+	return &ledgerService{
+		repos:  repoProvider,
+		tm:     tm,
+		events: events,
+	}
+}
+
+func (s *ledgerService) ExportCSV(ctx context.Context, groupID int64, w io.Writer) error {
+	writer := csv.NewWriter(w)
+
+	exportFunc := func(tx *sql.Tx) error {
+		err := writer.Write(planetscale.LedgerHeader)
+		if err != nil {
+			return err
+		}
+
+		expenses, err := s.repos.Expense.Find(tx, planetscale.ExpenseFilter{
+			GroupID: groupID,
+		})
+		if err != nil {
+			return err
+		}
+		for _, expense := range expenses {
+			ref := strconv.FormatInt(expense.ExpenseID, 10)
+			row := newLedgerRow(planetscale.LedgerRecordExpense, ref)
+			row[ledgerColDate] = expense.Timestamp.UTC().Format(time.RFC3339)
+			row[ledgerColDescription] = expense.Description
+			row[ledgerColPaidBy] = expense.PaidBy
+			row[ledgerColAmount] = expense.Amount.String()
+			row[ledgerColCurrency] = expense.Currency
+			row[ledgerColSplitTypeID] = strconv.FormatInt(expense.SplitTypeID, 10)
+			row[ledgerColTax] = formatLedgerMoney(expense.Tax)
+			row[ledgerColTip] = formatLedgerMoney(expense.Tip)
+			row[ledgerColServiceCharge] = formatLedgerMoney(expense.ServiceCharge)
+			row[ledgerColDiscount] = formatLedgerMoney(expense.Discount)
+			if err := writer.Write(row); err != nil {
+				return err
+			}
+
+			participants, err := s.repos.ExpenseParticipant.Find(tx, planetscale.ExpenseParticipantFilter{
+				ExpenseID: expense.ExpenseID,
+			})
+			if err != nil {
+				return err
+			}
+			// equal splits are only worked out for balances, the ledger spells
+			// them out the same way
+			var equal []planetscale.Money
+			if expense.SplitTypeID == planetscale.SplitTypeEqual {
+				equal = expense.Amount.Split(len(participants))
+			}
+			for i, participant := range participants {
+				row := newLedgerRow(planetscale.LedgerRecordParticipant, ref)
+				row[ledgerColUserID] = participant.UserID
+				row[ledgerColAmount] = participant.AmountOwed.String()
+				if equal != nil {
+					row[ledgerColAmount] = equal[i].String()
+				}
+				row[ledgerColSharePercentage] = strconv.FormatFloat(participant.SharePercentage, 'f', 2, 64)
+				row[ledgerColNote] = participant.Note
+				if participant.Shares != 0 {
+					row[ledgerColShares] = strconv.FormatInt(participant.Shares, 10)
+				}
+				if err := writer.Write(row); err != nil {
+					return err
+				}
+			}
+
+			items, err := s.repos.Item.Find(tx, planetscale.ItemFilter{
+				ExpenseID: expense.ExpenseID,
+			})
+			if err != nil {
+				return err
+			}
+			for _, item := range items {
+				row := newLedgerRow(planetscale.LedgerRecordItem, ref)
+				row[ledgerColDescription] = item.Name
+				row[ledgerColAmount] = item.Price.String()
+				row[ledgerColQuantity] = strconv.FormatInt(item.Quantity, 10)
+				if err := writer.Write(row); err != nil {
+					return err
+				}
+
+				splits, err := s.repos.ItemSplit.Find(tx, planetscale.ItemSplitFilter{
+					ItemID: item.ItemID,
+				})
+				if err != nil {
+					return err
+				}
+				if len(splits) == 0 && expense.SplitTypeID == planetscale.SplitTypeItemBased {
+					// the payer covers what nobody claimed, an import needs
+					// that spelled out
+					splits = []*planetscale.ItemSplit{{UserID: &expense.PaidBy}}
+				}
+				for _, split := range splits {
+					row := newLedgerRow(planetscale.LedgerRecordItemSplit, ref)
+					if split.UserID != nil {
+						row[ledgerColUserID] = *split.UserID
+					} else if split.GuestName != nil {
+						row[ledgerColDescription] = *split.GuestName
+					}
+					row[ledgerColAmount] = formatLedgerMoney(split.Amount)
+					if err := writer.Write(row); err != nil {
+						return err
+					}
+				}
+			}
+		}
+
+		settlements, err := s.repos.Settlement.Find(tx, planetscale.SettlementFilter{
+			GroupID: groupID,
+		})
+		if err != nil {
+			return err
+		}
+		for _, settlement := range settlements {
+			row := newLedgerRow(planetscale.LedgerRecordSettlement, strconv.FormatInt(settlement.SettlementID, 10))
+			row[ledgerColDate] = settlement.Timestamp.UTC().Format(time.RFC3339)
+			row[ledgerColPaidBy] = settlement.PaidBy
+			row[ledgerColPaidTo] = settlement.PaidTo
+			row[ledgerColAmount] = settlement.Amount.String()
+			row[ledgerColCurrency] = settlement.Currency
+			if err := writer.Write(row); err != nil {
+				return err
+			}
+		}
+
+		writer.Flush()
+		return writer.Error()
+	}
+
+	return s.tm.ExecuteInTx(ctx, exportFunc)
+}
+
+func (s *ledgerService) ImportCSV(ctx context.Context, groupID int64, r io.Reader, dryRun bool) (*planetscale.LedgerImport, error) {
+	// parse and validate everything that does not need the database up front
+	ledger, err := parseLedger(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var audits []*planetscale.AuditEvent
+	importFunc := func(tx *sql.Tx) error {
+		members, err := s.repos.GroupMember.Find(tx, planetscale.GroupMemberFilter{
+			GroupID: groupID,
+		})
+		if err != nil {
+			return err
+		}
+		ledger.resolveUsers(members)
+		ledger.allocate(groupID, members)
+		if len(ledger.errors) > 0 || dryRun {
+			return nil
+		}
+
+		for _, imported := range ledger.expenses {
+			expense := imported.expense
+			expense.CreatedBy = planetscale.ActorFromContext(ctx, expense.PaidBy)
+			err := s.repos.Expense.Create(tx, expense)
+			if err != nil {
+				return err
+			}
+			for _, participant := range expense.Participants {
+				participant.ExpenseID = expense.ExpenseID
+				err := s.repos.ExpenseParticipant.Create(tx, participant)
+				if err != nil {
+					return err
+				}
+			}
+			for _, item := range expense.Items {
+				item.ExpenseID = expense.ExpenseID
+				err := s.repos.Item.Create(tx, item)
+				if err != nil {
+					return err
+				}
+				for _, split := range item.Splits {
+					split.ItemID = item.ItemID
+					err := s.repos.ItemSplit.Create(tx, split)
+					if err != nil {
+						return err
+					}
+				}
+			}
+
+			audit := &planetscale.AuditEvent{
+				GroupID:    &groupID,
+				EntityType: planetscale.AuditEntityExpense,
+				EntityID:   strconv.FormatInt(expense.ExpenseID, 10),
+				Action:     planetscale.AuditActionCreate,
+				ActorID:    expense.CreatedBy,
+				After:      expense,
+			}
+			err = s.repos.AuditEvent.Create(tx, audit)
+			if err != nil {
+				return err
+			}
+			err = s.repos.WebhookEvent.Create(tx, planetscale.NewWebhookEvent(audit))
+			if err != nil {
+				return err
+			}
+			err = s.repos.Activity.Create(tx, planetscale.NewActivity(audit))
+			if err != nil {
+				return err
+			}
+			audits = append(audits, audit)
+		}
+
+		for _, imported := range ledger.settlements {
+			settlement := imported.settlement
+			settlement.GroupID = groupID
+			err := s.repos.Settlement.Create(tx, settlement)
+			if err != nil {
+				return err
+			}
+
+			audit := &planetscale.AuditEvent{
+				GroupID:    &groupID,
+				EntityType: planetscale.AuditEntitySettlement,
+				EntityID:   strconv.FormatInt(settlement.SettlementID, 10),
+				Action:     planetscale.AuditActionCreate,
+				ActorID:    planetscale.ActorFromContext(ctx, settlement.PaidBy),
+				After:      settlement,
+			}
+			err = s.repos.AuditEvent.Create(tx, audit)
+			if err != nil {
+				return err
+			}
+			err = s.repos.WebhookEvent.Create(tx, planetscale.NewWebhookEvent(audit))
+			if err != nil {
+				return err
+			}
+			err = s.repos.Activity.Create(tx, planetscale.NewActivity(audit))
+			if err != nil {
+				return err
+			}
+			audits = append(audits, audit)
+		}
+		return nil
+	}
+
+	err = s.tm.ExecuteInTx(ctx, importFunc)
+	if err != nil {
+		return nil, err
+	}
+	planetscale.Publish(s.events, audits...)
+
+	sort.SliceStable(ledger.errors, func(i, j int) bool {
+		return ledger.errors[i].Line < ledger.errors[j].Line
+	})
+	return &planetscale.LedgerImport{
+		DryRun:      dryRun,
+		Expenses:    len(ledger.expenses),
+		Settlements: len(ledger.settlements),
+		Errors:      ledger.errors,
+	}, nil
+}
+
+func newLedgerRow(record, ref string) []string {
+	row := make([]string, len(planetscale.LedgerHeader))
+	row[ledgerColRecord] = record
+	row[ledgerColRef] = ref
+	return row
+}
+
+type importedExpense struct {
+	line    int
+	expense *planetscale.Expense
+}
+
+type importedSettlement struct {
+	line       int
+	settlement *planetscale.Settlement
+}
+
+// parsedLedger holds the rows of an imported CSV. Until resolveUsers runs,
+// users are referred to by id, or by name for Splitwise exports.
+type parsedLedger struct {
+	byName      bool
+	expenses    []*importedExpense
+	settlements []*importedSettlement
+	errors      []*planetscale.LedgerImportError
+}
+
+func (l *parsedLedger) addError(line int, err error) {
+	l.errors = append(l.errors, &planetscale.LedgerImportError{
+		Line:    line,
+		Message: planetscale.ErrorMessage(err),
+	})
+}
+
+// resolveUsers replaces user references with the ids of the group's members
+// and records an error for every reference that is not a member.
+func (l *parsedLedger) resolveUsers(members []*planetscale.GroupMember) {
+	userIDs := make(map[string]string)
+	for _, member := range members {
+		key := member.UserID
+		if l.byName {
+			if member.User == nil {
+				continue
+			}
+			key = strings.ToLower(strings.TrimSpace(member.User.Name))
+			if _, ok := userIDs[key]; ok {
+				// two members share the name, neither can be matched
+				userIDs[key] = ""
+				continue
+			}
+		}
+		userIDs[key] = member.UserID
+	}
+
+	resolve := func(line int, ref *string) {
+		key := *ref
+		if l.byName {
+			key = strings.ToLower(strings.TrimSpace(key))
+		}
+		userID, ok := userIDs[key]
+		if !ok {
+			l.addError(line, planetscale.Errorf(planetscale.EINVALID, "%s is not a member of the group", *ref))
+			return
+		} else if userID == "" {
+			l.addError(line, planetscale.Errorf(planetscale.EINVALID, "more than one member is named %s", *ref))
+			return
+		}
+		*ref = userID
+	}
+
+	for _, imported := range l.expenses {
+		resolve(imported.line, &imported.expense.PaidBy)
+		for _, participant := range imported.expense.Participants {
+			resolve(imported.line, &participant.UserID)
+		}
+		for _, item := range imported.expense.Items {
+			for _, split := range item.Splits {
+				if split.UserID != nil {
+					resolve(imported.line, split.UserID)
+				}
+			}
+		}
+	}
+	for _, imported := range l.settlements {
+		resolve(imported.line, &imported.settlement.PaidBy)
+		resolve(imported.line, &imported.settlement.PaidTo)
+	}
+}
+
+// allocate works out what the participants of every expense owe for its split
+// type, as creating the expense would, and records an error for every expense
+// that does not add up. It runs once users are resolved.
+func (l *parsedLedger) allocate(groupID int64, members []*planetscale.GroupMember) {
+	for _, imported := range l.expenses {
+		expense := imported.expense
+		expense.GroupID = &groupID
+		if err := allocate(expense); err != nil {
+			l.addError(imported.line, err)
+			continue
+		}
+
+		if expense.SplitTypeID != planetscale.SplitTypeEqual {
+			continue
+		}
+		// equal splits are worked out along with balances, the amounts in the
+		// ledger are only there to be read
+		if len(expense.Participants) == 0 {
+			for _, member := range members {
+				expense.Participants = append(expense.Participants, &planetscale.ExpenseParticipant{
+					UserID: member.UserID,
+				})
+			}
+		}
+		for _, participant := range expense.Participants {
+			participant.AmountOwed = 0
+		}
+	}
+}
+
+// parseLedger reads a CSV in either the export layout or the Splitwise
+// layout, telling them apart by the header row. Problems with single rows are
+// collected on the ledger, only an unreadable file fails outright.
+func parseLedger(r io.Reader) (*parsedLedger, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, planetscale.Errorf(planetscale.EINVALID, "csv is empty")
+	} else if err != nil {
+		return nil, planetscale.Errorf(planetscale.EINVALID, "line 1: %s", err)
+	}
+
+	ledger := &parsedLedger{}
+	switch {
+	case hasPrefixFold(header, planetscale.LedgerHeader[:2]):
+		if len(header) > len(planetscale.LedgerHeader) || !hasPrefixFold(header, planetscale.LedgerHeader[:len(header)]) {
+			return nil, planetscale.Errorf(planetscale.EINVALID, "unrecognized csv header")
+		}
+		err = parseExportLedger(reader, len(header), ledger)
+	case hasPrefixFold(header, splitwiseHeader):
+		ledger.byName = true
+		err = parseSplitwiseLedger(reader, header[len(splitwiseHeader):], ledger)
+	default:
+		return nil, planetscale.Errorf(planetscale.EINVALID, "unrecognized csv header")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ledger, nil
+}
+
+// parseExportLedger reads the rows of a CSV written by ExportCSV, which has
+// the first columns of planetscale.LedgerHeader.
+func parseExportLedger(reader *csv.Reader, columns int, ledger *parsedLedger) error {
+	refs := make(map[string]*importedExpense)
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		line, _ := reader.FieldPos(0)
+		if err != nil {
+			return planetscale.Errorf(planetscale.EINVALID, "line %d: %s", line, err)
+		}
+		if len(record) != columns {
+			ledger.addError(line, planetscale.Errorf(planetscale.EINVALID, "expected %d columns, got %d", columns, len(record)))
+			continue
+		}
+		// columns added since the file was written are empty
+		record = append(record, make([]string, len(planetscale.LedgerHeader)-columns)...)
+
+		switch record[ledgerColRecord] {
+		case planetscale.LedgerRecordExpense:
+			expense, err := parseLedgerExpense(record)
+			if err != nil {
+				ledger.addError(line, err)
+				continue
+			}
+			if _, ok := refs[record[ledgerColRef]]; ok {
+				ledger.addError(line, planetscale.Errorf(planetscale.EINVALID, "duplicate expense ref %q", record[ledgerColRef]))
+				continue
+			}
+			imported := &importedExpense{line: line, expense: expense}
+			refs[record[ledgerColRef]] = imported
+			ledger.expenses = append(ledger.expenses, imported)
+		case planetscale.LedgerRecordParticipant:
+			imported, ok := refs[record[ledgerColRef]]
+			if !ok {
+				ledger.addError(line, planetscale.Errorf(planetscale.EINVALID, "unknown expense ref %q", record[ledgerColRef]))
+				continue
+			}
+			participant, err := parseLedgerParticipant(record)
+			if err != nil {
+				ledger.addError(line, err)
+				continue
+			}
+			imported.expense.Participants = append(imported.expense.Participants, participant)
+		case planetscale.LedgerRecordItem:
+			imported, ok := refs[record[ledgerColRef]]
+			if !ok {
+				ledger.addError(line, planetscale.Errorf(planetscale.EINVALID, "unknown expense ref %q", record[ledgerColRef]))
+				continue
+			}
+			item, err := parseLedgerItem(record)
+			if err != nil {
+				ledger.addError(line, err)
+				continue
+			}
+			imported.expense.Items = append(imported.expense.Items, item)
+		case planetscale.LedgerRecordItemSplit:
+			imported, ok := refs[record[ledgerColRef]]
+			if !ok {
+				ledger.addError(line, planetscale.Errorf(planetscale.EINVALID, "unknown expense ref %q", record[ledgerColRef]))
+				continue
+			}
+			items := imported.expense.Items
+			if len(items) == 0 {
+				ledger.addError(line, planetscale.Errorf(planetscale.EINVALID, "item split before any item of expense ref %q", record[ledgerColRef]))
+				continue
+			}
+			split, err := parseLedgerItemSplit(record)
+			if err != nil {
+				ledger.addError(line, err)
+				continue
+			}
+			item := items[len(items)-1]
+			item.Splits = append(item.Splits, split)
+		case planetscale.LedgerRecordSettlement:
+			settlement, err := parseLedgerSettlement(record)
+			if err != nil {
+				ledger.addError(line, err)
+				continue
+			}
+			ledger.settlements = append(ledger.settlements, &importedSettlement{line: line, settlement: settlement})
+		default:
+			ledger.addError(line, planetscale.Errorf(planetscale.EINVALID, "unknown record %q", record[ledgerColRecord]))
+		}
+	}
+}
+
+func parseLedgerExpense(record []string) (*planetscale.Expense, error) {
+	timestamp, err := parseLedgerDate(record[ledgerColDate])
+	if err != nil {
+		return nil, err
+	}
+	amount, err := planetscale.ParseMoney(record[ledgerColAmount])
+	if err != nil {
+		return nil, err
+	}
+	currency, err := parseLedgerCurrency(record[ledgerColCurrency])
+	if err != nil {
+		return nil, err
+	}
+	if record[ledgerColPaidBy] == "" {
+		return nil, planetscale.Errorf(planetscale.EINVALID, "paid_by is required")
+	}
+	var adjustments [4]planetscale.Money
+	for i, col := range []int{ledgerColTax, ledgerColTip, ledgerColServiceCharge, ledgerColDiscount} {
+		adjustments[i], err = parseLedgerMoney(record[col])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// without a split type the participant amounts are taken as they are
+	splitTypeID := planetscale.SplitTypeUnequal
+	if s := record[ledgerColSplitTypeID]; s != "" {
+		splitTypeID, err = strconv.ParseInt(s, 10, 64)
+		if err != nil || splitTypeID < planetscale.SplitTypeEqual || splitTypeID > planetscale.SplitTypePercentageBased {
+			return nil, planetscale.Errorf(planetscale.EINVALID, "invalid split type %q", s)
+		}
+	}
+
+	return &planetscale.Expense{
+		PaidBy:        record[ledgerColPaidBy],
+		Amount:        amount,
+		Currency:      currency,
+		Description:   record[ledgerColDescription],
+		Timestamp:     timestamp,
+		SplitTypeID:   splitTypeID,
+		Tax:           adjustments[0],
+		Tip:           adjustments[1],
+		ServiceCharge: adjustments[2],
+		Discount:      adjustments[3],
+	}, nil
+}
+
+func parseLedgerParticipant(record []string) (*planetscale.ExpenseParticipant, error) {
+	if record[ledgerColUserID] == "" {
+		return nil, planetscale.Errorf(planetscale.EINVALID, "user_id is required")
+	}
+	amountOwed, err := planetscale.ParseMoney(record[ledgerColAmount])
+	if err != nil {
+		return nil, err
+	}
+	var sharePercentage float64
+	if s := record[ledgerColSharePercentage]; s != "" {
+		sharePercentage, err = strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, planetscale.Errorf(planetscale.EINVALID, "invalid share percentage %q", s)
+		}
+	}
+	var shares int64
+	if s := record[ledgerColShares]; s != "" {
+		shares, err = strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return nil, planetscale.Errorf(planetscale.EINVALID, "invalid shares %q", s)
+		}
+	}
+
+	return &planetscale.ExpenseParticipant{
+		UserID:          record[ledgerColUserID],
+		AmountOwed:      amountOwed,
+		SharePercentage: sharePercentage,
+		Shares:          shares,
+		Note:            record[ledgerColNote],
+	}, nil
+}
+
+func parseLedgerItem(record []string) (*planetscale.Item, error) {
+	price, err := planetscale.ParseMoney(record[ledgerColAmount])
+	if err != nil {
+		return nil, err
+	}
+	quantity := int64(1)
+	if s := record[ledgerColQuantity]; s != "" {
+		quantity, err = strconv.ParseInt(s, 10, 64)
+		if err != nil || quantity < 1 {
+			return nil, planetscale.Errorf(planetscale.EINVALID, "invalid quantity %q", s)
+		}
+	}
+
+	return &planetscale.Item{
+		Name:     record[ledgerColDescription],
+		Price:    price,
+		Quantity: quantity,
+	}, nil
+}
+
+// parseLedgerItemSplit reads an item split, held by a user or by the guest
+// named in the description column. Without an amount the split takes an
+// equal part of what is left of the item.
+func parseLedgerItemSplit(record []string) (*planetscale.ItemSplit, error) {
+	amount, err := parseLedgerMoney(record[ledgerColAmount])
+	if err != nil {
+		return nil, err
+	}
+
+	split := &planetscale.ItemSplit{Amount: amount}
+	if userID := record[ledgerColUserID]; userID != "" {
+		split.UserID = &userID
+	}
+	if guestName := record[ledgerColDescription]; guestName != "" {
+		split.GuestName = &guestName
+	}
+	if err := split.Validate(); err != nil {
+		return nil, err
+	}
+	return split, nil
+}
+
+func parseLedgerSettlement(record []string) (*planetscale.Settlement, error) {
+	timestamp, err := parseLedgerDate(record[ledgerColDate])
+	if err != nil {
+		return nil, err
+	}
+	amount, err := planetscale.ParseMoney(record[ledgerColAmount])
+	if err != nil {
+		return nil, err
+	}
+	if amount <= 0 {
+		return nil, planetscale.Errorf(planetscale.EINVALID, "settlement amount must be greater than zero")
+	}
+	currency, err := parseLedgerCurrency(record[ledgerColCurrency])
+	if err != nil {
+		return nil, err
+	}
+	if record[ledgerColPaidBy] == "" || record[ledgerColPaidTo] == "" {
+		return nil, planetscale.Errorf(planetscale.EINVALID, "paid_by and paid_to are required")
+	}
+
+	return &planetscale.Settlement{
+		PaidBy:    record[ledgerColPaidBy],
+		PaidTo:    record[ledgerColPaidTo],
+		Amount:    amount,
+		Currency:  currency,
+		Timestamp: timestamp,
+	}, nil
+}
+
+// parseSplitwiseLedger reads the rows of a Splitwise export. Each member
+// column holds that member's net balance for the row: positive for the payer,
+// negative for everyone who owes a share. Rows in the "Payment" category are
+// settlements.
+func parseSplitwiseLedger(reader *csv.Reader, names []string, ledger *parsedLedger) error {
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		line, _ := reader.FieldPos(0)
+		if err != nil {
+			return planetscale.Errorf(planetscale.EINVALID, "line %d: %s", line, err)
+		}
+		if isBlankRecord(record) || (len(record) > 1 && strings.EqualFold(record[1], "Total balance")) {
+			continue
+		}
+		if len(record) != len(splitwiseHeader)+len(names) {
+			ledger.addError(line, planetscale.Errorf(planetscale.EINVALID, "expected %d columns, got %d", len(splitwiseHeader)+len(names), len(record)))
+			continue
+		}
+
+		err = parseSplitwiseRow(record, names, ledger, line)
+		if err != nil {
+			ledger.addError(line, err)
+		}
+	}
+}
+
+func parseSplitwiseRow(record []string, names []string, ledger *parsedLedger, line int) error {
+	timestamp, err := parseLedgerDate(record[0])
+	if err != nil {
+		return err
+	}
+	cost, err := planetscale.ParseMoney(record[3])
+	if err != nil {
+		return err
+	}
+	currency, err := parseLedgerCurrency(record[4])
+	if err != nil {
+		return err
+	}
+
+	var payers, owers []string
+	balances := make(map[string]planetscale.Money)
+	for i, name := range names {
+		s := record[len(splitwiseHeader)+i]
+		if s == "" {
+			continue
+		}
+		balance, err := planetscale.ParseMoney(s)
+		if err != nil {
+			return err
+		}
+		balances[name] = balance
+		if balance > 0 {
+			payers = append(payers, name)
+		} else if balance < 0 {
+			owers = append(owers, name)
+		}
+	}
+	if len(payers) != 1 {
+		return planetscale.Errorf(planetscale.EINVALID, "expected exactly one payer, found %d", len(payers))
+	}
+	payer := payers[0]
+
+	if strings.EqualFold(record[2], "Payment") {
+		if len(owers) != 1 {
+			return planetscale.Errorf(planetscale.EINVALID, "expected a payment to exactly one member, found %d", len(owers))
+		}
+		ledger.settlements = append(ledger.settlements, &importedSettlement{
+			line: line,
+			settlement: &planetscale.Settlement{
+				PaidBy:    payer,
+				PaidTo:    owers[0],
+				Amount:    cost,
+				Currency:  currency,
+				Timestamp: timestamp,
+			},
+		})
+		return nil
+	}
+
+	expense := &planetscale.Expense{
+		PaidBy:      payer,
+		Amount:      cost,
+		Currency:    currency,
+		Description: record[1],
+		Timestamp:   timestamp,
+		SplitTypeID: planetscale.SplitTypeUnequal,
+	}
+	// the payer's balance is what they paid less their own share
+	if share := cost - balances[payer]; share > 0 {
+		expense.Participants = append(expense.Participants, &planetscale.ExpenseParticipant{
+			UserID:     payer,
+			AmountOwed: share,
+		})
+	}
+	for _, name := range owers {
+		expense.Participants = append(expense.Participants, &planetscale.ExpenseParticipant{
+			UserID:     name,
+			AmountOwed: -balances[name],
+		})
+	}
+	ledger.expenses = append(ledger.expenses, &importedExpense{line: line, expense: expense})
+	return nil
+}
+
+// parseLedgerDate accepts the RFC 3339 timestamps written by ExportCSV and
+// the plain dates of a Splitwise export.
+func parseLedgerDate(s string) (time.Time, error) {
+	for _, layout := range []string{time.RFC3339, time.DateOnly} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, planetscale.Errorf(planetscale.EINVALID, "invalid date %q", s)
+}
+
+// parseLedgerCurrency leaves an empty currency empty so that the group's base
+// currency applies.
+func parseLedgerCurrency(s string) (string, error) {
+	if strings.TrimSpace(s) == "" {
+		return "", nil
+	}
+	return planetscale.NormalizeCurrency(s)
+}
+
+// parseLedgerMoney reads an optional amount, an empty column is zero.
+func parseLedgerMoney(s string) (planetscale.Money, error) {
+	if strings.TrimSpace(s) == "" {
+		return 0, nil
+	}
+	return planetscale.ParseMoney(s)
+}
+
+// formatLedgerMoney leaves a zero amount out of optional columns.
+func formatLedgerMoney(m planetscale.Money) string {
+	if m == 0 {
+		return ""
+	}
+	return m.String()
+}
+
+func hasPrefixFold(record []string, prefix []string) bool {
+	if len(record) < len(prefix) {
+		return false
+	}
+	for i, s := range prefix {
+		if !strings.EqualFold(strings.TrimSpace(record[i]), s) {
+			return false
+		}
+	}
+	return true
+}
+
+func isBlankRecord(record []string) bool {
+	for _, s := range record {
+		if strings.TrimSpace(s) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,323 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	planetscale "github.com/harshav17/planet_scale"
+	db_mock "github.com/harshav17/planet_scale/mock/db"
+)
+
+type ledgerRecorder struct {
+	expenses     []*planetscale.Expense
+	participants []*planetscale.ExpenseParticipant
+	items        []*planetscale.Item
+	itemSplits   []*planetscale.ItemSplit
+	settlements  []*planetscale.Settlement
+}
+
+func newTestLedgerService(recorded *ledgerRecorder) *ledgerService {
+	tm := db_mock.TransactionManager{}
+	tm.ExecuteInTxFn = func(ctx context.Context, fn func(*sql.Tx) error) error {
+		return fn(nil)
+	}
+	ledgerService := NewLedgerService(&planetscale.RepoProvider{}, tm, nil)
+	ledgerService.repos.GroupMember = &db_mock.GroupMemberRepo{
+		FindFn: func(tx *sql.Tx, filter planetscale.GroupMemberFilter) ([]*planetscale.GroupMember, error) {
+			return []*planetscale.GroupMember{
+				{GroupID: filter.GroupID, UserID: "user-alice", User: &planetscale.User{Name: "Alice"}},
+				{GroupID: filter.GroupID, UserID: "user-bob", User: &planetscale.User{Name: "Bob"}},
+			}, nil
+		},
+	}
+	ledgerService.repos.Expense = &db_mock.ExpenseRepo{
+		CreateFn: func(tx *sql.Tx, expense *planetscale.Expense) error {
+			expense.ExpenseID = int64(len(recorded.expenses) + 1)
+			recorded.expenses = append(recorded.expenses, expense)
+			return nil
+		},
+	}
+	ledgerService.repos.ExpenseParticipant = &db_mock.ExpenseParticipantRepo{
+		CreateFn: func(tx *sql.Tx, participant *planetscale.ExpenseParticipant) error {
+			recorded.participants = append(recorded.participants, participant)
+			return nil
+		},
+	}
+	ledgerService.repos.Item = &db_mock.ItemRepo{
+		CreateFn: func(tx *sql.Tx, item *planetscale.Item) error {
+			item.ItemID = int64(len(recorded.items) + 1)
+			recorded.items = append(recorded.items, item)
+			return nil
+		},
+	}
+	ledgerService.repos.ItemSplit = &db_mock.ItemSplitRepo{
+		CreateFn: func(tx *sql.Tx, split *planetscale.ItemSplit) error {
+			recorded.itemSplits = append(recorded.itemSplits, split)
+			return nil
+		},
+	}
+	ledgerService.repos.Settlement = &db_mock.SettlementRepo{
+		CreateFn: func(tx *sql.Tx, settlement *planetscale.Settlement) error {
+			settlement.SettlementID = int64(len(recorded.settlements) + 1)
+			recorded.settlements = append(recorded.settlements, settlement)
+			return nil
+		},
+	}
+	ledgerService.repos.AuditEvent = &db_mock.AuditEventRepo{
+		CreateFn: func(tx *sql.Tx, event *planetscale.AuditEvent) error {
+			return nil
+		},
+	}
+	ledgerService.repos.WebhookEvent = &db_mock.WebhookEventRepo{
+		CreateFn: func(tx *sql.Tx, event *planetscale.WebhookEvent) error {
+			return nil
+		},
+	}
+	ledgerService.repos.Activity = &db_mock.ActivityRepo{
+		CreateFn: func(tx *sql.Tx, activity *planetscale.Activity) error {
+			return nil
+		},
+	}
+	return ledgerService
+}
+
+func TestLedgerService_ImportCSV(t *testing.T) {
+	groupID := int64(1)
+
+	// written before shares and receipt adjustments were exported
+	legacyHeader := strings.Join(planetscale.LedgerHeader[:13], ",")
+
+	t.Run("export layout", func(t *testing.T) {
+		var recorded ledgerRecorder
+		result, err := newTestLedgerService(&recorded).ImportCSV(context.Background(), groupID, strings.NewReader(
+			legacyHeader+"\n"+
+				"expense,7,2024-03-01T19:30:00Z,dinner,user-alice,,,60.00,EUR,2,,,\n"+
+				"participant,7,,,,,user-alice,20.00,,,33.33,,\n"+
+				"participant,7,,,,,user-bob,40.00,,,66.67,,had the steak\n"+
+				"item,7,,,steak,,,40.00,,,,1,\n"+
+				"settlement,3,2024-03-02T00:00:00Z,,user-bob,user-alice,,40.00,EUR,,,,\n"), false)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(result.Errors) != 0 {
+			t.Fatalf("expected no errors, got %+v", result.Errors[0])
+		} else if result.Expenses != 1 || result.Settlements != 1 {
+			t.Fatalf("expected 1 expense and 1 settlement, got %d and %d", result.Expenses, result.Settlements)
+		}
+		if len(recorded.expenses) != 1 || *recorded.expenses[0].GroupID != groupID || recorded.expenses[0].Amount != 60_00 {
+			t.Fatalf("unexpected expenses %+v", recorded.expenses)
+		} else if len(recorded.participants) != 2 || recorded.participants[1].ExpenseID != 1 || recorded.participants[1].Note != "had the steak" {
+			t.Fatalf("unexpected participants %+v", recorded.participants)
+		} else if len(recorded.items) != 1 || recorded.items[0].ExpenseID != 1 {
+			t.Fatalf("unexpected items %+v", recorded.items)
+		} else if len(recorded.settlements) != 1 || recorded.settlements[0].PaidTo != "user-alice" {
+			t.Fatalf("unexpected settlements %+v", recorded.settlements)
+		} else if !recorded.settlements[0].Timestamp.Equal(time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC)) {
+			t.Fatalf("expected settlement date to be kept, got %v", recorded.settlements[0].Timestamp)
+		}
+	})
+
+	t.Run("splitwise layout", func(t *testing.T) {
+		var recorded ledgerRecorder
+		result, err := newTestLedgerService(&recorded).ImportCSV(context.Background(), groupID, strings.NewReader(
+			"Date,Description,Category,Cost,Currency,Alice,Bob\n"+
+				"\n"+
+				"2024-03-01,Dinner,Dining out,60.00,USD,40.00,-40.00\n"+
+				"2024-03-02,Settle up,Payment,40.00,USD,-40.00,40.00\n"+
+				"\n"+
+				"2024-03-05,Total balance, , ,USD,0.00,0.00\n"), false)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(result.Errors) != 0 {
+			t.Fatalf("expected no errors, got %+v", result.Errors[0])
+		}
+		if len(recorded.expenses) != 1 || recorded.expenses[0].PaidBy != "user-alice" {
+			t.Fatalf("unexpected expenses %+v", recorded.expenses)
+		}
+		owed := make(map[string]planetscale.Money)
+		for _, participant := range recorded.participants {
+			owed[participant.UserID] = participant.AmountOwed
+		}
+		if owed["user-alice"] != 20_00 || owed["user-bob"] != 40_00 {
+			t.Fatalf("expected alice to owe 20 and bob 40, got %v", owed)
+		}
+		if len(recorded.settlements) != 1 || recorded.settlements[0].PaidBy != "user-bob" || recorded.settlements[0].PaidTo != "user-alice" {
+			t.Fatalf("unexpected settlements %+v", recorded.settlements)
+		}
+	})
+
+	t.Run("invalid rows are reported and nothing is written", func(t *testing.T) {
+		var recorded ledgerRecorder
+		result, err := newTestLedgerService(&recorded).ImportCSV(context.Background(), groupID, strings.NewReader(
+			legacyHeader+"\n"+
+				"expense,1,2024-03-01,lunch,user-alice,,,30.00,,,,,\n"+
+				"participant,1,,,,,user-alice,10.00,,,,,\n"+
+				"participant,1,,,,,user-carol,20.00,,,,,\n"+
+				"participant,2,,,,,user-bob,20.00,,,,,\n"+
+				"expense,3,yesterday,taxi,user-bob,,,12.00,,,,,\n"), false)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(result.Errors) != 3 {
+			t.Fatalf("expected 3 errors, got %d", len(result.Errors))
+		}
+		if got := result.Errors[0]; got.Line != 2 || !strings.Contains(got.Message, "user-carol") {
+			t.Fatalf("expected non-member error on line 2, got %+v", got)
+		} else if got := result.Errors[1]; got.Line != 5 {
+			t.Fatalf("expected unknown ref error on line 5, got %+v", got)
+		} else if got := result.Errors[2]; got.Line != 6 {
+			t.Fatalf("expected invalid date error on line 6, got %+v", got)
+		}
+		if len(recorded.expenses) != 0 {
+			t.Fatalf("expected nothing to be written, got %d expenses", len(recorded.expenses))
+		}
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		var recorded ledgerRecorder
+		result, err := newTestLedgerService(&recorded).ImportCSV(context.Background(), groupID, strings.NewReader(
+			"Date,Description,Category,Cost,Currency,Alice,Bob\n"+
+				"2024-03-01,Dinner,Dining out,60.00,USD,30.00,-30.00\n"), true)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !result.DryRun || result.Expenses != 1 || len(result.Errors) != 0 {
+			t.Fatalf("unexpected result %+v", result)
+		} else if len(recorded.expenses) != 0 {
+			t.Fatalf("expected nothing to be written, got %d expenses", len(recorded.expenses))
+		}
+	})
+
+	t.Run("unrecognized header", func(t *testing.T) {
+		var recorded ledgerRecorder
+		_, err := newTestLedgerService(&recorded).ImportCSV(context.Background(), groupID, strings.NewReader("a,b,c\n"), false)
+		if planetscale.ErrorCode(err) != planetscale.EINVALID {
+			t.Fatalf("expected invalid error, got %v", err)
+		}
+	})
+}
+
+func TestLedgerService_ExportCSV(t *testing.T) {
+	groupID := int64(1)
+	alice, guest := "user-alice", "JD"
+	var recorded ledgerRecorder
+	ledgerService := newTestLedgerService(&recorded)
+	ledgerService.repos.Expense = &db_mock.ExpenseRepo{
+		FindFn: func(tx *sql.Tx, filter planetscale.ExpenseFilter) ([]*planetscale.Expense, error) {
+			return []*planetscale.Expense{
+				{ExpenseID: 7, GroupID: &groupID, PaidBy: "user-alice", Amount: 60_00, Currency: "EUR", Description: "dinner", SplitTypeID: planetscale.SplitTypeUnequal, Timestamp: time.Date(2024, 3, 1, 19, 30, 0, 0, time.UTC)},
+				{ExpenseID: 8, GroupID: &groupID, PaidBy: "user-bob", Amount: 10_01, Currency: "EUR", Description: "taxi", SplitTypeID: planetscale.SplitTypeEqual, Timestamp: time.Date(2024, 3, 1, 23, 0, 0, 0, time.UTC)},
+				{ExpenseID: 9, GroupID: &groupID, PaidBy: "user-alice", Amount: 33_00, Currency: "EUR", Description: "pizza", SplitTypeID: planetscale.SplitTypeItemBased, Tax: 3_00, Timestamp: time.Date(2024, 3, 2, 12, 0, 0, 0, time.UTC)},
+			}, nil
+		},
+	}
+	ledgerService.repos.ExpenseParticipant = &db_mock.ExpenseParticipantRepo{
+		FindFn: func(tx *sql.Tx, filter planetscale.ExpenseParticipantFilter) ([]*planetscale.ExpenseParticipant, error) {
+			switch filter.ExpenseID {
+			case 7:
+				return []*planetscale.ExpenseParticipant{
+					{ExpenseID: 7, UserID: "user-alice", AmountOwed: 20_00, SharePercentage: 33.33},
+					{ExpenseID: 7, UserID: "user-bob", AmountOwed: 40_00, SharePercentage: 66.67},
+				}, nil
+			case 8:
+				// equal splits store no amounts
+				return []*planetscale.ExpenseParticipant{
+					{ExpenseID: 8, UserID: "user-alice"},
+					{ExpenseID: 8, UserID: "user-bob"},
+				}, nil
+			}
+			return []*planetscale.ExpenseParticipant{
+				{ExpenseID: 9, UserID: "user-alice", AmountOwed: 33_00, SharePercentage: 100},
+			}, nil
+		},
+	}
+	ledgerService.repos.Item = &db_mock.ItemRepo{
+		FindFn: func(tx *sql.Tx, filter planetscale.ItemFilter) ([]*planetscale.Item, error) {
+			if filter.ExpenseID != 9 {
+				return nil, nil
+			}
+			return []*planetscale.Item{
+				{ItemID: 1, ExpenseID: 9, Name: "margherita", Price: 20_00, Quantity: 1},
+				{ItemID: 2, ExpenseID: 9, Name: "beer", Price: 5_00, Quantity: 2},
+			}, nil
+		},
+	}
+	ledgerService.repos.ItemSplit = &db_mock.ItemSplitRepo{
+		FindFn: func(tx *sql.Tx, filter planetscale.ItemSplitFilter) ([]*planetscale.ItemSplit, error) {
+			if filter.ItemID != 1 {
+				return nil, nil
+			}
+			return []*planetscale.ItemSplit{
+				{ItemSplitID: 1, ItemID: 1, UserID: &alice, Amount: 12_00},
+				{ItemSplitID: 2, ItemID: 1, GuestName: &guest, Amount: 8_00},
+			}, nil
+		},
+	}
+	ledgerService.repos.Settlement = &db_mock.SettlementRepo{
+		FindFn: func(tx *sql.Tx, filter planetscale.SettlementFilter) ([]*planetscale.Settlement, error) {
+			return []*planetscale.Settlement{
+				{SettlementID: 3, GroupID: groupID, PaidBy: "user-bob", PaidTo: "user-alice", Amount: 40_00, Currency: "EUR"},
+			}, nil
+		},
+	}
+
+	var buf bytes.Buffer
+	err := ledgerService.ExportCSV(context.Background(), groupID, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 15 {
+		t.Fatalf("expected header and 14 rows, got %d lines", len(lines))
+	} else if lines[1] != "expense,7,2024-03-01T19:30:00Z,dinner,user-alice,,,60.00,EUR,2,,,,,,,," {
+		t.Fatalf("unexpected expense row %q", lines[1])
+	} else if lines[5] != "participant,8,,,,,user-alice,5.01,,,0.00,,,,,,," {
+		t.Fatalf("expected equal share in participant row, got %q", lines[5])
+	} else if lines[7] != "expense,9,2024-03-02T12:00:00Z,pizza,user-alice,,,33.00,EUR,3,,,,,3.00,,," {
+		t.Fatalf("expected tax in expense row, got %q", lines[7])
+	} else if lines[13] != "item_split,9,,,,,user-alice,,,,,,,,,,," {
+		t.Fatalf("expected unclaimed item to be covered by the payer, got %q", lines[13])
+	}
+
+	// the export can be imported again
+	reimported := ledgerRecorder{}
+	result, err := newTestLedgerService(&reimported).ImportCSV(context.Background(), groupID, &buf, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Errors) != 0 {
+		t.Fatalf("expected no errors, got %+v", result.Errors[0])
+	} else if result.Expenses != 3 || result.Settlements != 1 {
+		t.Fatalf("unexpected result %+v", result)
+	}
+
+	equal := reimported.expenses[1]
+	if equal.SplitTypeID != planetscale.SplitTypeEqual || len(equal.Participants) != 2 {
+		t.Fatalf("unexpected equal split expense %+v", equal)
+	}
+	for _, participant := range equal.Participants {
+		if participant.AmountOwed != 0 {
+			t.Fatalf("expected equal split to be split again, got %s owed by %s", participant.AmountOwed, participant.UserID)
+		}
+	}
+
+	itemized := reimported.expenses[2]
+	if itemized.Tax != 3_00 || len(itemized.Items) != 2 || len(reimported.itemSplits) != 3 {
+		t.Fatalf("unexpected item based expense %+v with %d item splits", itemized, len(reimported.itemSplits))
+	} else if split := reimported.itemSplits[1]; split.GuestName == nil || *split.GuestName != guest || split.ItemID != 1 {
+		t.Fatalf("unexpected guest split %+v", split)
+	} else if len(itemized.Participants) != 1 || itemized.Participants[0].AmountOwed != 33_00 {
+		// alice covers the guest, the beers and the tax
+		t.Fatalf("unexpected participants %+v", itemized.Participants)
+	}
+}
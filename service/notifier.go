@@ -0,0 +1,333 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/mail"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	planetscale "github.com/harshav17/planet_scale"
+	views "github.com/harshav17/planet_scale/templates"
+)
+
+// digestBatchSize is how many due digests are loaded at once.
+const digestBatchSize = 100
+
+// digestRetryDelay is how long a digest that could not be sent waits before
+// it is tried again.
+const digestRetryDelay = 24 * time.Hour
+
+// parsed email templates, each defines its subject as email-<name>.subject
+var templates = template.Must(template.ParseFS(views.Templates, "email-*.txt"))
+
+type notifier struct {
+	repos    *planetscale.RepoProvider
+	services *planetscale.ServiceProvider
+	tm       planetscale.TransactionManager
+}
+
+// NewNotifier sends its emails through services.Mailer. Nothing is sent
+// without one.
+func NewNotifier(repoProvider *planetscale.RepoProvider, services *planetscale.ServiceProvider, tm planetscale.TransactionManager) *notifier {
+	return &notifier{
+		repos:    repoProvider,
+		services: services,
+		tm:       tm,
+	}
+}
+
+type expenseAddedEmail struct {
+	Name        string
+	Actor       string
+	Description string
+	Amount      planetscale.Money
+	Currency    string
+	Share       planetscale.Money
+	Group       string
+	URL         string
+}
+
+func (s *notifier) NotifyExpenseAdded(ctx context.Context, expense *planetscale.Expense, userIDs []string) error {
+	if s.services.Mailer == nil || len(userIDs) == 0 {
+		return nil
+	}
+
+	var recipients []*planetscale.NotificationPreferences
+	data := expenseAddedEmail{
+		Actor:       expense.CreatedBy,
+		Description: expense.Description,
+		Amount:      expense.Amount,
+		Currency:    expense.Currency,
+		URL:         "https://skwabbl.com/dashboard/expenses/" + strconv.FormatInt(expense.ExpenseID, 10),
+	}
+	if data.Description == "" {
+		data.Description = "an expense"
+	}
+	findRecipientsFunc := func(tx *sql.Tx) error {
+		// the creator is only loaded for their name
+		found, err := s.repos.NotificationPreferences.Find(tx, planetscale.NotificationPreferencesFilter{
+			UserIDs: append(userIDs[:len(userIDs):len(userIDs)], expense.CreatedBy),
+		})
+		if err != nil {
+			return err
+		}
+		for _, preferences := range found {
+			if preferences.UserID == expense.CreatedBy {
+				if preferences.Name != "" {
+					data.Actor = preferences.Name
+				}
+			} else if preferences.ExpenseAdded && preferences.Email != "" {
+				recipients = append(recipients, preferences)
+			}
+		}
+
+		if expense.GroupID != nil {
+			group, err := s.repos.ExpenseGroup.Get(tx, *expense.GroupID)
+			if err != nil {
+				return err
+			}
+			data.Group = group.GroupName
+		}
+		return nil
+	}
+
+	err := s.tm.ExecuteInTx(ctx, findRecipientsFunc)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, recipient := range recipients {
+		data.Name = recipient.Name
+		data.Share = 0
+		for _, participant := range expense.Participants {
+			if participant.UserID == recipient.UserID {
+				data.Share = participant.AmountOwed
+			}
+		}
+
+		email, err := renderEmail("expense-added", recipient, data)
+		if err == nil {
+			err = s.services.Mailer.Send(ctx, email)
+		}
+		if err != nil {
+			slog.Error("cannot notify expense participant", slog.Int64("expense_id", expense.ExpenseID), slog.String("user_id", recipient.UserID), slog.Any("err", err))
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+type digestEmail struct {
+	Name  string
+	Owing []digestBalance
+	Owed  []digestBalance
+}
+
+type digestBalance struct {
+	Group    string
+	Amount   planetscale.Money
+	Currency string
+}
+
+func (s *notifier) SendDigests(ctx context.Context, now time.Time) (int, error) {
+	if s.services.Mailer == nil {
+		return 0, nil
+	}
+
+	// every digest of a batch is marked, so the next batch moves on
+	run := &digestRun{}
+	for ctx.Err() == nil {
+		due, err := s.sendDigestBatch(ctx, now, run)
+		if err != nil {
+			run.errs = append(run.errs, err)
+			break
+		}
+		if due < digestBatchSize {
+			break
+		}
+	}
+	return run.sent, errors.Join(run.errs...)
+}
+
+// digestRun counts the digests sent by SendDigests and collects those that
+// could not be.
+type digestRun struct {
+	sent int
+	errs []error
+}
+
+// sendDigestBatch sends the next batch of digests due at now and returns how
+// many were due. Digests that could not be sent are marked as if they were
+// sent digestRetryDelay ahead of their next one, so that they are tried again
+// then rather than with every run.
+func (s *notifier) sendDigestBatch(ctx context.Context, now time.Time, run *digestRun) (int, error) {
+	var due []*planetscale.NotificationPreferences
+	groups := map[string][]*planetscale.ExpenseGroup{}
+	findDueFunc := func(tx *sql.Tx) error {
+		var err error
+		due, err = s.repos.NotificationPreferences.Find(tx, planetscale.NotificationPreferencesFilter{
+			DigestDueAt: now,
+			Limit:       digestBatchSize,
+		})
+		if err != nil {
+			return err
+		}
+		for _, preferences := range due {
+			groups[preferences.UserID], err = s.repos.ExpenseGroup.ListAllForUser(tx, preferences.UserID)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	err := s.tm.ExecuteInTx(ctx, findDueFunc)
+	if err != nil {
+		return 0, err
+	}
+
+	// members of the same group share its balances
+	balances := map[int64][]*planetscale.Balance{}
+	for _, preferences := range due {
+		data := digestEmail{Name: preferences.Name}
+		for _, group := range groups[preferences.UserID] {
+			groupBalances, ok := balances[group.ExpenseGroupID]
+			if !ok {
+				groupBalances, err = s.services.Balance.GetGroupBalances(ctx, group.ExpenseGroupID)
+				if err != nil {
+					return len(due), err
+				}
+				balances[group.ExpenseGroupID] = groupBalances
+			}
+
+			for _, balance := range groupBalances {
+				if balance.UserID != preferences.UserID {
+					continue
+				}
+				line := digestBalance{Group: group.GroupName, Amount: balance.Amount.Abs(), Currency: balance.Currency}
+				if balance.Amount < 0 {
+					data.Owing = append(data.Owing, line)
+				} else if balance.Amount > 0 {
+					data.Owed = append(data.Owed, line)
+				}
+			}
+		}
+
+		// settled up users are not emailed, but wait for the next digest
+		// all the same
+		sentAt := now
+		if len(data.Owing) > 0 || len(data.Owed) > 0 {
+			email, err := renderEmail("digest", preferences, data)
+			if err == nil {
+				err = s.services.Mailer.Send(ctx, email)
+			}
+			if err != nil {
+				slog.Error("cannot send digest", slog.String("user_id", preferences.UserID), slog.Any("err", err))
+				run.errs = append(run.errs, err)
+				sentAt = now.Add(digestRetryDelay - planetscale.DigestInterval)
+			} else {
+				run.sent++
+			}
+		}
+
+		err = s.tm.ExecuteInTx(ctx, func(tx *sql.Tx) error {
+			return s.repos.NotificationPreferences.MarkDigestSent(tx, preferences.UserID, sentAt)
+		})
+		if err != nil {
+			return len(due), err
+		}
+	}
+
+	return len(due), nil
+}
+
+// renderEmail renders the email template name to the user of preferences.
+func renderEmail(name string, preferences *planetscale.NotificationPreferences, data interface{}) (*planetscale.Email, error) {
+	var subject, text strings.Builder
+	if err := templates.ExecuteTemplate(&subject, "email-"+name+".subject", data); err != nil {
+		return nil, err
+	}
+	if err := templates.ExecuteTemplate(&text, "email-"+name+".txt", data); err != nil {
+		return nil, err
+	}
+
+	to := &mail.Address{Name: preferences.Name, Address: preferences.Email}
+	return &planetscale.Email{
+		To:      to.String(),
+		Subject: subject.String(),
+		Text:    text.String(),
+	}, nil
+}
+
+// DigestWorker sends the balance digests that are due in the background.
+type DigestWorker struct {
+	service  planetscale.Notifier
+	interval time.Duration
+
+	// Returns the current time. Defaults to time.Now().
+	// Can be mocked for tests.
+	Now func() time.Time
+
+	cancel func()
+	done   chan struct{}
+}
+
+func NewDigestWorker(service planetscale.Notifier, interval time.Duration) *DigestWorker {
+	return &DigestWorker{
+		service:  service,
+		interval: interval,
+		Now:      time.Now,
+	}
+}
+
+// Open starts the worker. Digests that came due while the process was down
+// are sent right away.
+func (w *DigestWorker) Open() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	w.cancel = cancel
+	w.done = make(chan struct{})
+
+	go func() {
+		defer close(w.done)
+
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+		for {
+			w.run(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Close stops the worker and waits for a run in progress to finish.
+func (w *DigestWorker) Close() error {
+	if w.cancel == nil {
+		return nil
+	}
+	w.cancel()
+	<-w.done
+	return nil
+}
+
+func (w *DigestWorker) run(ctx context.Context) {
+	sent, err := w.service.SendDigests(ctx, w.Now().UTC().Truncate(time.Second))
+	if err != nil {
+		slog.Error("[digests] send", slog.Any("err", err))
+	}
+	if sent > 0 {
+		slog.Info("[digests] sent", slog.Int("count", sent))
+	}
+}
@@ -0,0 +1,266 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	planetscale "github.com/harshav17/planet_scale"
+	db_mock "github.com/harshav17/planet_scale/mock/db"
+	service_mock "github.com/harshav17/planet_scale/mock/service"
+)
+
+func TestNotifier_NotifyExpenseAdded(t *testing.T) {
+	groupID := int64(1)
+	users := map[string]*planetscale.NotificationPreferences{
+		"alice": {UserID: "alice", Name: "Alice", Email: "alice@example.com", ExpenseAdded: true},
+		"bob":   {UserID: "bob", Name: "Bob", Email: "bob@example.com", ExpenseAdded: true},
+		"carol": {UserID: "carol", Name: "Carol", Email: "carol@example.com"},
+		"dave":  {UserID: "dave", Name: "Dave", ExpenseAdded: true},
+	}
+
+	var sent []*planetscale.Email
+	tm := db_mock.TransactionManager{}
+	tm.ExecuteInTxFn = func(ctx context.Context, fn func(*sql.Tx) error) error {
+		return fn(nil)
+	}
+	repos := &planetscale.RepoProvider{}
+	repos.NotificationPreferences = &db_mock.NotificationPreferencesRepo{
+		FindFn: func(tx *sql.Tx, filter planetscale.NotificationPreferencesFilter) ([]*planetscale.NotificationPreferences, error) {
+			var found []*planetscale.NotificationPreferences
+			for _, userID := range filter.UserIDs {
+				found = append(found, users[userID])
+			}
+			return found, nil
+		},
+	}
+	repos.ExpenseGroup = &db_mock.ExpenseGroupRepo{
+		GetFn: func(tx *sql.Tx, groupID int64) (*planetscale.ExpenseGroup, error) {
+			return &planetscale.ExpenseGroup{ExpenseGroupID: groupID, GroupName: "Trip"}, nil
+		},
+	}
+	services := &planetscale.ServiceProvider{}
+	services.Mailer = &service_mock.Mailer{
+		SendFn: func(email *planetscale.Email) error {
+			sent = append(sent, email)
+			return nil
+		},
+	}
+	notifier := NewNotifier(repos, services, tm)
+
+	expense := &planetscale.Expense{
+		ExpenseID:   7,
+		GroupID:     &groupID,
+		Description: "Dinner",
+		Amount:      40_00,
+		Currency:    "USD",
+		CreatedBy:   "alice",
+		Participants: []*planetscale.ExpenseParticipant{
+			{UserID: "alice", AmountOwed: 10_00},
+			{UserID: "bob", AmountOwed: 30_00},
+		},
+	}
+	// carol turned these emails off and dave has no address
+	err := notifier.NotifyExpenseAdded(context.Background(), expense, []string{"bob", "carol", "dave"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(sent) != 1 {
+		t.Fatalf("expected 1 email, got %d", len(sent))
+	}
+	email := sent[0]
+	if email.To != `"Bob" <bob@example.com>` {
+		t.Errorf("unexpected recipient %q", email.To)
+	} else if email.Subject != "Alice added you to Dinner" {
+		t.Errorf("unexpected subject %q", email.Subject)
+	} else if !strings.Contains(email.Text, "Alice added you to Dinner (40.00 USD) in Trip. Your share is 30.00 USD.") {
+		t.Errorf("unexpected text %q", email.Text)
+	} else if !strings.Contains(email.Text, "https://skwabbl.com/dashboard/expenses/7") {
+		t.Errorf("expected a link to the expense, got %q", email.Text)
+	}
+}
+
+func TestNotifier_SendDigests(t *testing.T) {
+	now := time.Date(2024, 1, 8, 9, 0, 0, 0, time.UTC)
+	due := []*planetscale.NotificationPreferences{
+		{UserID: "alice", Name: "Alice", Email: "alice@example.com", Digest: true},
+		{UserID: "bob", Name: "Bob", Email: "bob@example.com", Digest: true},
+		{UserID: "carol", Name: "Carol", Email: "carol@example.com", Digest: true},
+	}
+	groups := map[string][]*planetscale.ExpenseGroup{
+		"alice": {{ExpenseGroupID: 1, GroupName: "Trip"}, {ExpenseGroupID: 2, GroupName: "Flat"}},
+		"bob":   {{ExpenseGroupID: 1, GroupName: "Trip"}},
+		"carol": {{ExpenseGroupID: 2, GroupName: "Flat"}},
+	}
+	balances := map[int64][]*planetscale.Balance{
+		1: {
+			{ExpenseGroupID: 1, UserID: "alice", Amount: -20_00, Currency: "USD"},
+			{ExpenseGroupID: 1, UserID: "bob", Amount: 20_00, Currency: "USD"},
+		},
+		2: {
+			{ExpenseGroupID: 2, UserID: "alice", Amount: 5_00, Currency: "EUR"},
+			{ExpenseGroupID: 2, UserID: "carol", Amount: -5_00, Currency: "EUR"},
+		},
+	}
+
+	var sent []*planetscale.Email
+	var marked []string
+	markedAt := map[string]time.Time{}
+	balanceQueries := 0
+	tm := db_mock.TransactionManager{}
+	tm.ExecuteInTxFn = func(ctx context.Context, fn func(*sql.Tx) error) error {
+		return fn(nil)
+	}
+	repos := &planetscale.RepoProvider{}
+	repos.NotificationPreferences = &db_mock.NotificationPreferencesRepo{
+		FindFn: func(tx *sql.Tx, filter planetscale.NotificationPreferencesFilter) ([]*planetscale.NotificationPreferences, error) {
+			if !filter.DigestDueAt.Equal(now) {
+				t.Fatalf("expected digests due at %s, got %s", now, filter.DigestDueAt)
+			}
+			return due, nil
+		},
+		MarkDigestSentFn: func(tx *sql.Tx, userID string, sentAt time.Time) error {
+			marked = append(marked, userID)
+			markedAt[userID] = sentAt
+			return nil
+		},
+	}
+	repos.ExpenseGroup = &db_mock.ExpenseGroupRepo{
+		ListAllForUserFn: func(tx *sql.Tx, userID string) ([]*planetscale.ExpenseGroup, error) {
+			return groups[userID], nil
+		},
+	}
+	services := &planetscale.ServiceProvider{}
+	services.Balance = &service_mock.BalanceService{
+		GetGroupBalancesFn: func(groupID int64) ([]*planetscale.Balance, error) {
+			balanceQueries++
+			return balances[groupID], nil
+		},
+	}
+	services.Mailer = &service_mock.Mailer{
+		SendFn: func(email *planetscale.Email) error {
+			sent = append(sent, email)
+			return nil
+		},
+	}
+	notifier := NewNotifier(repos, services, tm)
+
+	t.Run("due digests", func(t *testing.T) {
+		n, err := notifier.SendDigests(context.Background(), now)
+		if err != nil {
+			t.Fatal(err)
+		} else if n != 3 || len(sent) != 3 {
+			t.Fatalf("expected 3 digests, got %d", n)
+		} else if balanceQueries != 2 {
+			t.Fatalf("expected the balances of each group to be loaded once, got %d queries", balanceQueries)
+		} else if len(marked) != 3 {
+			t.Fatalf("expected 3 digests marked sent, got %v", marked)
+		}
+
+		alice := sent[0]
+		if alice.Subject != "You owe money in 1 group" {
+			t.Errorf("unexpected subject %q", alice.Subject)
+		} else if !strings.Contains(alice.Text, "You owe:\n- 20.00 USD in Trip\n") {
+			t.Errorf("expected what alice owes, got %q", alice.Text)
+		} else if !strings.Contains(alice.Text, "You are owed:\n- 5.00 EUR in Flat\n") {
+			t.Errorf("expected what alice is owed, got %q", alice.Text)
+		}
+		if bob := sent[1]; bob.Subject != "Your balances this week" || strings.Contains(bob.Text, "You owe") {
+			t.Errorf("unexpected digest of bob %q: %q", bob.Subject, bob.Text)
+		}
+	})
+
+	t.Run("settled up", func(t *testing.T) {
+		sent, marked = nil, nil
+		due = []*planetscale.NotificationPreferences{
+			{UserID: "dave", Name: "Dave", Email: "dave@example.com", Digest: true},
+		}
+
+		n, err := notifier.SendDigests(context.Background(), now)
+		if err != nil {
+			t.Fatal(err)
+		} else if n != 0 || len(sent) != 0 {
+			t.Fatalf("expected no digest, got %d", n)
+		} else if len(marked) != 1 {
+			t.Fatal("expected the digest to be marked sent anyway")
+		}
+	})
+
+	t.Run("failed digests are retried later", func(t *testing.T) {
+		sent, marked = nil, nil
+		due = []*planetscale.NotificationPreferences{
+			{UserID: "alice", Name: "Alice", Email: "alice@example.com", Digest: true},
+			{UserID: "bob", Name: "Bob", Email: "bob@example.com", Digest: true},
+		}
+		mailer := services.Mailer
+		defer func() { services.Mailer = mailer }()
+		services.Mailer = &service_mock.Mailer{
+			SendFn: func(email *planetscale.Email) error {
+				if strings.Contains(email.To, "alice") {
+					return errors.New("mailbox unavailable")
+				}
+				sent = append(sent, email)
+				return nil
+			},
+		}
+
+		n, err := notifier.SendDigests(context.Background(), now)
+		if err == nil {
+			t.Fatal("expected the failed digest to be reported")
+		} else if n != 1 || len(marked) != 2 {
+			t.Fatalf("expected 1 digest sent and 2 marked, got %d and %v", n, marked)
+		} else if got, expected := markedAt["alice"], now.Add(digestRetryDelay-planetscale.DigestInterval); !got.Equal(expected) {
+			t.Fatalf("expected the digest of alice to be retried after %s, marked at %s", digestRetryDelay, got)
+		} else if !markedAt["bob"].Equal(now) {
+			t.Fatalf("expected the digest of bob to be marked sent at %s, got %s", now, markedAt["bob"])
+		}
+	})
+
+	t.Run("every batch is sent", func(t *testing.T) {
+		sent, marked = nil, nil
+		var batches [][]*planetscale.NotificationPreferences
+		for len(batches) < 2 {
+			var batch []*planetscale.NotificationPreferences
+			for i := 0; i < digestBatchSize; i++ {
+				batch = append(batch, &planetscale.NotificationPreferences{UserID: "carol", Name: "Carol", Email: "carol@example.com", Digest: true})
+			}
+			batches = append(batches, batch)
+		}
+		batches = append(batches, due[:1])
+		finder := repos.NotificationPreferences
+		defer func() { repos.NotificationPreferences = finder }()
+		repos.NotificationPreferences = &db_mock.NotificationPreferencesRepo{
+			FindFn: func(tx *sql.Tx, filter planetscale.NotificationPreferencesFilter) ([]*planetscale.NotificationPreferences, error) {
+				if len(batches) == 0 {
+					t.Fatal("expected no more batches to be loaded")
+				}
+				batch := batches[0]
+				batches = batches[1:]
+				return batch, nil
+			},
+			MarkDigestSentFn: func(tx *sql.Tx, userID string, sentAt time.Time) error {
+				marked = append(marked, userID)
+				return nil
+			},
+		}
+
+		n, err := notifier.SendDigests(context.Background(), now)
+		if err != nil {
+			t.Fatal(err)
+		} else if expected := 2*digestBatchSize + 1; n != expected || len(marked) != expected {
+			t.Fatalf("expected %d digests, got %d sent and %d marked", expected, n, len(marked))
+		}
+	})
+
+	t.Run("without a mailer", func(t *testing.T) {
+		notifier := NewNotifier(repos, &planetscale.ServiceProvider{}, tm)
+		n, err := notifier.SendDigests(context.Background(), now)
+		if err != nil || n != 0 {
+			t.Fatalf("expected nothing to be sent, got %d, %v", n, err)
+		}
+	})
+}
@@ -0,0 +1,188 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+
+	planetscale "github.com/harshav17/planet_scale"
+)
+
+type recurringExpenseService struct {
+	repos    *planetscale.RepoProvider
+	expenses planetscale.ExpenseService
+	tm       planetscale.TransactionManager
+}
+
+func NewRecurringExpenseService(repoProvider *planetscale.RepoProvider, expenseService planetscale.ExpenseService, tm planetscale.TransactionManager) *recurringExpenseService {
+	return &recurringExpenseService{
+		repos:    repoProvider,
+		expenses: expenseService,
+		tm:       tm,
+	}
+}
+
+func (s *recurringExpenseService) MaterializeDue(ctx context.Context, now time.Time) (int, error) {
+	var due []*planetscale.RecurringExpense
+	findDueFunc := func(tx *sql.Tx) error {
+		var err error
+		due, err = s.repos.RecurringExpense.FindDue(tx, now)
+		if err != nil {
+			return err
+		}
+		return nil
+	}
+
+	err := s.tm.ExecuteInTx(ctx, findDueFunc)
+	if err != nil {
+		return 0, err
+	}
+
+	// one broken template must not hold up the others
+	var created int
+	var errs []error
+	for _, recurringExpense := range due {
+		n, err := s.materialize(ctx, recurringExpense, now)
+		created += n
+		if err != nil {
+			slog.Error("cannot materialize recurring expense", slog.Int64("id", recurringExpense.RecurringExpenseID), slog.Any("err", err))
+			errs = append(errs, err)
+		}
+	}
+
+	return created, errors.Join(errs...)
+}
+
+// materialize creates every occurrence of recurringExpense that is due at now.
+//
+// Each expense is created before the schedule is advanced. Expenses carry
+// their recurrence index under a unique key, so if the process dies in
+// between, the retry sees ECONFLICT and only advances the schedule. That
+// makes every occurrence appear exactly once, also with several schedulers.
+//
+// Occurrences that are invalid, or refer to something that is gone, fail the
+// same way every time. The schedule is paused instead, until its recurring
+// expense is updated.
+func (s *recurringExpenseService) materialize(ctx context.Context, recurringExpense *planetscale.RecurringExpense, now time.Time) (int, error) {
+	rule, err := planetscale.ParseRecurrenceRule(recurringExpense.Rule)
+	if err != nil {
+		return 0, s.pause(ctx, recurringExpense, err)
+	}
+
+	var created int
+	for !recurringExpense.NextOccurrenceAt.IsZero() && !recurringExpense.NextOccurrenceAt.After(now) {
+		occurrence := recurringExpense.OccurrenceCount
+		expense := recurringExpense.Expense(occurrence, recurringExpense.NextOccurrenceAt)
+
+		err := s.expenses.CreateExpense(ctx, expense)
+		switch {
+		case err == nil:
+			created++
+		case planetscale.ErrorCode(err) == planetscale.ECONFLICT:
+			// already materialized, only the schedule fell behind
+		case planetscale.ErrorCode(err) == planetscale.EINVALID, planetscale.ErrorCode(err) == planetscale.ENOTFOUND:
+			return created, s.pause(ctx, recurringExpense, err)
+		default:
+			return created, err
+		}
+
+		next, _ := rule.Occurrence(recurringExpense.StartAt, occurrence+1)
+		advanceFunc := func(tx *sql.Tx) error {
+			return s.repos.RecurringExpense.Advance(tx, recurringExpense.RecurringExpenseID, occurrence, next)
+		}
+		err = s.tm.ExecuteInTx(ctx, advanceFunc)
+		if planetscale.ErrorCode(err) == planetscale.ECONFLICT {
+			// another scheduler got here first and owns the rest
+			return created, nil
+		} else if err != nil {
+			return created, err
+		}
+
+		recurringExpense.OccurrenceCount = occurrence + 1
+		recurringExpense.NextOccurrenceAt = next
+	}
+
+	return created, nil
+}
+
+// pause stops the schedule of recurringExpense because of err, which it
+// returns.
+func (s *recurringExpenseService) pause(ctx context.Context, recurringExpense *planetscale.RecurringExpense, err error) error {
+	pauseFunc := func(tx *sql.Tx) error {
+		return s.repos.RecurringExpense.Pause(tx, recurringExpense.RecurringExpenseID, planetscale.ErrorMessage(err))
+	}
+	if pauseErr := s.tm.ExecuteInTx(ctx, pauseFunc); pauseErr != nil {
+		return errors.Join(err, pauseErr)
+	}
+	return err
+}
+
+// RecurringExpenseScheduler materializes due recurring expenses in the
+// background.
+type RecurringExpenseScheduler struct {
+	service  planetscale.RecurringExpenseService
+	interval time.Duration
+
+	// Returns the current time. Defaults to time.Now().
+	// Can be mocked for tests.
+	Now func() time.Time
+
+	cancel func()
+	done   chan struct{}
+}
+
+func NewRecurringExpenseScheduler(service planetscale.RecurringExpenseService, interval time.Duration) *RecurringExpenseScheduler {
+	return &RecurringExpenseScheduler{
+		service:  service,
+		interval: interval,
+		Now:      time.Now,
+	}
+}
+
+// Open starts the scheduler. The first run happens right away so that
+// occurrences missed while the process was down are caught up on start.
+func (s *RecurringExpenseScheduler) Open() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	s.done = make(chan struct{})
+
+	go func() {
+		defer close(s.done)
+
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+		for {
+			s.run(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Close stops the scheduler and waits for a run in progress to finish.
+func (s *RecurringExpenseScheduler) Close() error {
+	if s.cancel == nil {
+		return nil
+	}
+	s.cancel()
+	<-s.done
+	return nil
+}
+
+func (s *RecurringExpenseScheduler) run(ctx context.Context) {
+	created, err := s.service.MaterializeDue(ctx, s.Now())
+	if err != nil {
+		slog.Error("[scheduler] recurring expenses", slog.Any("err", err))
+	}
+	if created > 0 {
+		slog.Info("[scheduler] materialized recurring expenses", slog.Int("count", created))
+	}
+}
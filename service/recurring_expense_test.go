@@ -0,0 +1,197 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	planetscale "github.com/harshav17/planet_scale"
+	db_mock "github.com/harshav17/planet_scale/mock/db"
+	service_mock "github.com/harshav17/planet_scale/mock/service"
+)
+
+func TestRecurringExpenseService_MaterializeDue(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)
+
+	type advance struct {
+		occurrence int64
+		next       time.Time
+	}
+
+	newService := func(recurringExpense *planetscale.RecurringExpense, createExpense func(expense *planetscale.Expense) error, advanced *[]advance) *recurringExpenseService {
+		tm := db_mock.TransactionManager{}
+		tm.ExecuteInTxFn = func(ctx context.Context, fn func(*sql.Tx) error) error {
+			return fn(nil)
+		}
+		expenseService := &service_mock.ExpenseService{
+			CreateExpenseFn: func(ctx context.Context, expense *planetscale.Expense) error {
+				return createExpense(expense)
+			},
+		}
+		recurringExpenseService := NewRecurringExpenseService(&planetscale.RepoProvider{}, expenseService, tm)
+		recurringExpenseService.repos.RecurringExpense = &db_mock.RecurringExpenseRepo{
+			FindDueFn: func(tx *sql.Tx, now time.Time) ([]*planetscale.RecurringExpense, error) {
+				return []*planetscale.RecurringExpense{recurringExpense}, nil
+			},
+			AdvanceFn: func(tx *sql.Tx, recurringExpenseID int64, occurrence int64, next time.Time) error {
+				*advanced = append(*advanced, advance{occurrence, next})
+				return nil
+			},
+		}
+		return recurringExpenseService
+	}
+
+	newRecurringExpense := func(rule string) *planetscale.RecurringExpense {
+		return &planetscale.RecurringExpense{
+			RecurringExpenseID: 1,
+			GroupID:            1,
+			SplitTypeID:        planetscale.SplitTypeEqual,
+			PaidBy:             "test-user-id",
+			Amount:             1000_00,
+			Description:        "rent",
+			Rule:               rule,
+			StartAt:            start,
+			NextOccurrenceAt:   start,
+		}
+	}
+
+	t.Run("catches up on every due occurrence", func(t *testing.T) {
+		var created []*planetscale.Expense
+		var advanced []advance
+		service := newService(newRecurringExpense("FREQ=MONTHLY"), func(expense *planetscale.Expense) error {
+			created = append(created, expense)
+			return nil
+		}, &advanced)
+
+		n, err := service.MaterializeDue(context.Background(), now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != 3 || len(created) != 3 {
+			t.Fatalf("expected 3 expenses, got %d (%d created)", n, len(created))
+		}
+		for i, expense := range created {
+			if expense.RecurrenceIndex != int64(i) || !expense.Timestamp.Equal(start.AddDate(0, i, 0)) {
+				t.Errorf("expense %d: unexpected occurrence %d at %v", i, expense.RecurrenceIndex, expense.Timestamp)
+			}
+		}
+		if len(advanced) != 3 || !advanced[2].next.Equal(time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)) {
+			t.Fatalf("unexpected advances %+v", advanced)
+		}
+	})
+
+	t.Run("occurrence created before a restart is not created again", func(t *testing.T) {
+		var advanced []advance
+		calls := 0
+		service := newService(newRecurringExpense("FREQ=MONTHLY;COUNT=1"), func(expense *planetscale.Expense) error {
+			calls++
+			return planetscale.Errorf(planetscale.ECONFLICT, "occurrence already exists")
+		}, &advanced)
+
+		n, err := service.MaterializeDue(context.Background(), now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != 0 || calls != 1 {
+			t.Fatalf("expected no new expenses after 1 attempt, got %d after %d", n, calls)
+		}
+		if len(advanced) != 1 || !advanced[0].next.IsZero() {
+			t.Fatalf("expected the schedule to advance and end, got %+v", advanced)
+		}
+	})
+
+	t.Run("failed occurrence does not advance the schedule", func(t *testing.T) {
+		var advanced []advance
+		service := newService(newRecurringExpense("FREQ=MONTHLY"), func(expense *planetscale.Expense) error {
+			return errors.New("connection reset")
+		}, &advanced)
+
+		n, err := service.MaterializeDue(context.Background(), now)
+		if err == nil {
+			t.Fatal("expected the failure to be reported")
+		}
+		if n != 0 || len(advanced) != 0 {
+			t.Fatalf("expected nothing to happen, got %d expenses and %d advances", n, len(advanced))
+		}
+	})
+
+	t.Run("invalid occurrence pauses the schedule", func(t *testing.T) {
+		var advanced []advance
+		service := newService(newRecurringExpense("FREQ=MONTHLY"), func(expense *planetscale.Expense) error {
+			return planetscale.Errorf(planetscale.EINVALID, "participants are required")
+		}, &advanced)
+		var failure string
+		service.repos.RecurringExpense.(*db_mock.RecurringExpenseRepo).PauseFn = func(tx *sql.Tx, recurringExpenseID int64, message string) error {
+			failure = message
+			return nil
+		}
+
+		n, err := service.MaterializeDue(context.Background(), now)
+		if planetscale.ErrorCode(err) != planetscale.EINVALID {
+			t.Fatalf("expected invalid error, got %v", err)
+		}
+		if n != 0 || len(advanced) != 0 {
+			t.Fatalf("expected nothing to happen, got %d expenses and %d advances", n, len(advanced))
+		} else if failure != "participants are required" {
+			t.Fatalf("expected the schedule to be paused with the failure, got %q", failure)
+		}
+	})
+
+	t.Run("another scheduler advanced first", func(t *testing.T) {
+		var created int
+		var advanced []advance
+		service := newService(newRecurringExpense("FREQ=MONTHLY"), func(expense *planetscale.Expense) error {
+			created++
+			return nil
+		}, &advanced)
+		service.repos.RecurringExpense = &db_mock.RecurringExpenseRepo{
+			FindDueFn: func(tx *sql.Tx, now time.Time) ([]*planetscale.RecurringExpense, error) {
+				return []*planetscale.RecurringExpense{newRecurringExpense("FREQ=MONTHLY")}, nil
+			},
+			AdvanceFn: func(tx *sql.Tx, recurringExpenseID int64, occurrence int64, next time.Time) error {
+				return planetscale.Errorf(planetscale.ECONFLICT, "already advanced")
+			},
+		}
+
+		n, err := service.MaterializeDue(context.Background(), now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != 1 || created != 1 {
+			t.Fatalf("expected to stop after 1 expense, got %d", created)
+		}
+	})
+}
+
+func TestRecurringExpenseScheduler(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	runs := make(chan time.Time, 1)
+	scheduler := NewRecurringExpenseScheduler(&service_mock.RecurringExpenseService{
+		MaterializeDueFn: func(now time.Time) (int, error) {
+			select {
+			case runs <- now:
+			default:
+			}
+			return 0, nil
+		},
+	}, time.Hour)
+	scheduler.Now = func() time.Time { return now }
+
+	if err := scheduler.Open(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case got := <-runs:
+		if !got.Equal(now) {
+			t.Errorf("expected run at %v, got %v", now, got)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the scheduler to run on open")
+	}
+	if err := scheduler.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
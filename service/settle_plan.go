@@ -0,0 +1,59 @@
+package service
+
+import (
+	"sort"
+
+	planetscale "github.com/harshav17/planet_scale"
+)
+
+// simplifyDebts turns net balances into a list of transfers that settles the
+// group. It greedily matches the largest debtor with the largest creditor,
+// which needs at most n-1 transfers for n users with a non-zero balance.
+func simplifyDebts(balances []*planetscale.Balance) []*planetscale.Transfer {
+	type position struct {
+		userID string
+		cents  planetscale.Money
+	}
+
+	var creditors, debtors []*position
+	for _, balance := range balances {
+		if balance.Amount > 0 {
+			creditors = append(creditors, &position{userID: balance.UserID, cents: balance.Amount})
+		} else if balance.Amount < 0 {
+			debtors = append(debtors, &position{userID: balance.UserID, cents: -balance.Amount})
+		}
+	}
+
+	// sort largest first, falling back to the user id so plans are stable
+	byAmount := func(positions []*position) func(i, j int) bool {
+		return func(i, j int) bool {
+			if positions[i].cents != positions[j].cents {
+				return positions[i].cents > positions[j].cents
+			}
+			return positions[i].userID < positions[j].userID
+		}
+	}
+	sort.Slice(creditors, byAmount(creditors))
+	sort.Slice(debtors, byAmount(debtors))
+
+	var transfers []*planetscale.Transfer
+	for i, j := 0, 0; i < len(debtors) && j < len(creditors); {
+		debtor, creditor := debtors[i], creditors[j]
+		cents := min(debtor.cents, creditor.cents)
+		transfers = append(transfers, &planetscale.Transfer{
+			PaidBy: debtor.userID,
+			PaidTo: creditor.userID,
+			Amount: cents,
+		})
+
+		debtor.cents -= cents
+		creditor.cents -= cents
+		if debtor.cents == 0 {
+			i++
+		}
+		if creditor.cents == 0 {
+			j++
+		}
+	}
+	return transfers
+}
@@ -0,0 +1,208 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	planetscale "github.com/harshav17/planet_scale"
+	db_mock "github.com/harshav17/planet_scale/mock/db"
+)
+
+func TestSimplifyDebts(t *testing.T) {
+	tests := []struct {
+		name     string
+		balances []*planetscale.Balance
+		expected []*planetscale.Transfer
+	}{
+		{
+			name: "already settled",
+			balances: []*planetscale.Balance{
+				{UserID: "a", Amount: 0},
+				{UserID: "b", Amount: 0},
+			},
+			expected: nil,
+		},
+		{
+			name: "one debtor one creditor",
+			balances: []*planetscale.Balance{
+				{UserID: "a", Amount: 50_00},
+				{UserID: "b", Amount: -50_00},
+			},
+			expected: []*planetscale.Transfer{
+				{PaidBy: "b", PaidTo: "a", Amount: 50_00},
+			},
+		},
+		{
+			name: "chain collapses into direct payments",
+			balances: []*planetscale.Balance{
+				{UserID: "a", Amount: 200_00},
+				{UserID: "b", Amount: 0},
+				{UserID: "c", Amount: -200_00},
+			},
+			expected: []*planetscale.Transfer{
+				{PaidBy: "c", PaidTo: "a", Amount: 200_00},
+			},
+		},
+		{
+			name: "largest debtor pays largest creditor first",
+			balances: []*planetscale.Balance{
+				{UserID: "a", Amount: 70_00},
+				{UserID: "b", Amount: 30_00},
+				{UserID: "c", Amount: -60_00},
+				{UserID: "d", Amount: -40_00},
+			},
+			expected: []*planetscale.Transfer{
+				{PaidBy: "c", PaidTo: "a", Amount: 60_00},
+				{PaidBy: "d", PaidTo: "a", Amount: 10_00},
+				{PaidBy: "d", PaidTo: "b", Amount: 30_00},
+			},
+		},
+		{
+			name: "ties are broken by user id",
+			balances: []*planetscale.Balance{
+				{UserID: "a", Amount: 66_66},
+				{UserID: "b", Amount: -33_33},
+				{UserID: "c", Amount: -33_33},
+			},
+			expected: []*planetscale.Transfer{
+				{PaidBy: "b", PaidTo: "a", Amount: 33_33},
+				{PaidBy: "c", PaidTo: "a", Amount: 33_33},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := simplifyDebts(test.balances)
+			if len(got) != len(test.expected) {
+				t.Fatalf("expected %d transfers, got %d", len(test.expected), len(got))
+			}
+			for i, transfer := range test.expected {
+				if *got[i] != *transfer {
+					t.Errorf("expected transfer %+v, got %+v", transfer, got[i])
+				}
+			}
+		})
+	}
+}
+
+func TestBalanceService_GetSettlePlan(t *testing.T) {
+	groupID := int64(1)
+	expenses := []*planetscale.Expense{
+		{
+			ExpenseID:   1,
+			GroupID:     &groupID,
+			PaidBy:      "test-user-id",
+			Amount:      300_00,
+			SplitTypeID: planetscale.SplitTypeEqual,
+		},
+	}
+	participants := []*planetscale.ExpenseParticipant{
+		{ExpenseID: 1, UserID: "test-user-id"},
+		{ExpenseID: 1, UserID: "test-user-id-2"},
+		{ExpenseID: 1, UserID: "test-user-id-3"},
+	}
+
+	newService := func(created *[]*planetscale.Settlement) *balanceService {
+		tm := db_mock.TransactionManager{}
+		tm.ExecuteInTxFn = func(ctx context.Context, fn func(*sql.Tx) error) error {
+			return fn(nil)
+		}
+		balanceService := NewBalanceService(&planetscale.RepoProvider{}, tm, nil)
+		balanceService.repos.ExpenseGroup = &db_mock.ExpenseGroupRepo{
+			GetFn: func(tx *sql.Tx, groupID int64) (*planetscale.ExpenseGroup, error) {
+				return &planetscale.ExpenseGroup{ExpenseGroupID: groupID, BaseCurrency: "USD"}, nil
+			},
+		}
+		balanceService.repos.Expense = &db_mock.ExpenseRepo{
+			FindFn: func(tx *sql.Tx, filter planetscale.ExpenseFilter) ([]*planetscale.Expense, error) {
+				return expenses, nil
+			},
+		}
+		balanceService.repos.Settlement = &db_mock.SettlementRepo{
+			FindFn: func(tx *sql.Tx, filter planetscale.SettlementFilter) ([]*planetscale.Settlement, error) {
+				return nil, nil
+			},
+			CreateFn: func(tx *sql.Tx, settlement *planetscale.Settlement) error {
+				settlement.SettlementID = int64(len(*created) + 1)
+				*created = append(*created, settlement)
+				return nil
+			},
+		}
+		balanceService.repos.ExpenseParticipant = &db_mock.ExpenseParticipantRepo{
+			FindFn: func(tx *sql.Tx, filter planetscale.ExpenseParticipantFilter) ([]*planetscale.ExpenseParticipant, error) {
+				return filterParitipantsByExpenseID(participants, filter.ExpenseID), nil
+			},
+		}
+		balanceService.repos.AuditEvent = &db_mock.AuditEventRepo{
+			CreateFn: func(tx *sql.Tx, event *planetscale.AuditEvent) error {
+				return nil
+			},
+		}
+		balanceService.repos.WebhookEvent = &db_mock.WebhookEventRepo{
+			CreateFn: func(tx *sql.Tx, event *planetscale.WebhookEvent) error {
+				return nil
+			},
+		}
+		balanceService.repos.Activity = &db_mock.ActivityRepo{
+			CreateFn: func(tx *sql.Tx, activity *planetscale.Activity) error {
+				return nil
+			},
+		}
+		return balanceService
+	}
+
+	t.Run("preview", func(t *testing.T) {
+		var created []*planetscale.Settlement
+		plan, err := newService(&created).GetSettlePlan(context.Background(), groupID, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(plan.Transfers) != 2 {
+			t.Fatalf("expected 2 transfers, got %d", len(plan.Transfers))
+		} else if len(created) != 0 {
+			t.Fatalf("expected no settlements, got %d", len(created))
+		} else if plan.Settlements != nil {
+			t.Fatalf("expected no settlements in plan, got %d", len(plan.Settlements))
+		}
+	})
+
+	t.Run("apply", func(t *testing.T) {
+		var created []*planetscale.Settlement
+		balanceService := newService(&created)
+		balanceService.events = NewEventHub()
+		defer balanceService.events.Close()
+		subscription, err := balanceService.events.Subscribe(groupID, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer subscription.Cancel()
+
+		plan, err := balanceService.GetSettlePlan(context.Background(), groupID, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(created) != 2 {
+			t.Fatalf("expected 2 settlements, got %d", len(created))
+		} else if len(plan.Settlements) != 2 {
+			t.Fatalf("expected 2 settlements in plan, got %d", len(plan.Settlements))
+		}
+		for _, settlement := range created {
+			if settlement.GroupID != groupID {
+				t.Errorf("expected group id %d, got %d", groupID, settlement.GroupID)
+			} else if settlement.PaidTo != "test-user-id" {
+				t.Errorf("expected paid to test-user-id, got %s", settlement.PaidTo)
+			} else if settlement.Amount != 100_00 {
+				t.Errorf("expected amount 100, got %s", settlement.Amount)
+			}
+		}
+		for range created {
+			if event := <-subscription.Events; event.Type != "settlement.created" {
+				t.Errorf("expected settlement.created event, got %s", event.Type)
+			}
+		}
+	})
+}
@@ -0,0 +1,269 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	planetscale "github.com/harshav17/planet_scale"
+	svix "github.com/svix/svix-webhooks/go"
+)
+
+const (
+	// webhookBatchSize is how many deliveries a single run sends at most.
+	webhookBatchSize = 50
+	// webhookLease keeps a delivery from being picked up again while it is
+	// being sent. It has to outlast the client timeout.
+	webhookLease = time.Minute
+	// webhookTimeout bounds how long a subscriber may take to respond.
+	webhookTimeout = 10 * time.Second
+	// webhookFirstRetry is the wait after the first failed attempt, it
+	// doubles with every attempt after that.
+	webhookFirstRetry = 30 * time.Second
+	webhookMaxRetry   = 6 * time.Hour
+)
+
+type webhookService struct {
+	repos  *planetscale.RepoProvider
+	tm     planetscale.TransactionManager
+	client *http.Client
+}
+
+func NewWebhookService(repoProvider *planetscale.RepoProvider, tm planetscale.TransactionManager) *webhookService {
+	return &webhookService{
+		repos:  repoProvider,
+		tm:     tm,
+		client: &http.Client{Timeout: webhookTimeout},
+	}
+}
+
+func (s *webhookService) DeliverDue(ctx context.Context, now time.Time) (int, error) {
+	// lease the due deliveries first so that sending them does not hold a
+	// transaction open
+	var deliveries []*planetscale.WebhookDelivery
+	leaseFunc := func(tx *sql.Tx) error {
+		var err error
+		deliveries, err = s.repos.WebhookDelivery.Find(tx, planetscale.WebhookDeliveryFilter{
+			DueAt: now,
+			Limit: webhookBatchSize,
+		})
+		if err != nil {
+			return err
+		}
+
+		leasedUntil := now.Add(webhookLease)
+		for _, delivery := range deliveries {
+			err := s.repos.WebhookDelivery.Update(tx, delivery.DeliveryID, &planetscale.WebhookDeliveryUpdate{
+				NextAttemptAt: &leasedUntil,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	err := s.tm.ExecuteInTx(ctx, leaseFunc)
+	if err != nil {
+		return 0, err
+	}
+
+	delivered := 0
+	for _, delivery := range deliveries {
+		attempt := s.send(ctx, delivery, now)
+
+		ok, err := s.record(ctx, delivery, attempt, now)
+		if err != nil {
+			return delivered, err
+		}
+		if ok {
+			delivered++
+		}
+	}
+	return delivered, nil
+}
+
+// send posts the event of the delivery to its subscription, signed the way
+// svix signs the webhooks we receive.
+func (s *webhookService) send(ctx context.Context, delivery *planetscale.WebhookDelivery, now time.Time) *planetscale.WebhookDeliveryAttempt {
+	attempt := &planetscale.WebhookDeliveryAttempt{
+		DeliveryID:  delivery.DeliveryID,
+		AttemptedAt: now,
+	}
+
+	request, err := s.newRequest(ctx, delivery, now)
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+
+	start := time.Now()
+	response, err := s.client.Do(request)
+	attempt.Duration = time.Since(start).Milliseconds()
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	defer response.Body.Close()
+
+	attempt.StatusCode = &response.StatusCode
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		attempt.Error = response.Status
+	}
+	return attempt
+}
+
+func (s *webhookService) newRequest(ctx context.Context, delivery *planetscale.WebhookDelivery, now time.Time) (*http.Request, error) {
+	payload, err := json.Marshal(delivery.Event)
+	if err != nil {
+		return nil, err
+	}
+
+	wh, err := svix.NewWebhook(delivery.Subscription.Secret)
+	if err != nil {
+		return nil, err
+	}
+	// the id stays the same across retries so that subscribers can tell
+	// them apart from new events
+	msgID := "evt_" + strconv.FormatInt(delivery.EventID, 10)
+	signature, err := wh.Sign(msgID, now, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.Subscription.URL, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("svix-id", msgID)
+	request.Header.Set("svix-timestamp", strconv.FormatInt(now.Unix(), 10))
+	request.Header.Set("svix-signature", signature)
+	return request, nil
+}
+
+// record stores the attempt and schedules the next one, if any. It reports
+// whether the delivery succeeded.
+func (s *webhookService) record(ctx context.Context, delivery *planetscale.WebhookDelivery, attempt *planetscale.WebhookDeliveryAttempt, now time.Time) (bool, error) {
+	ok := attempt.Error == ""
+	attempts := delivery.Attempts + 1
+
+	update := &planetscale.WebhookDeliveryUpdate{
+		Attempts: &attempts,
+	}
+	status := planetscale.WebhookDeliveryPending
+	switch {
+	case ok:
+		status = planetscale.WebhookDeliveryDelivered
+		update.DeliveredAt = &now
+	case attempts >= planetscale.WebhookMaxAttempts:
+		status = planetscale.WebhookDeliveryFailed
+	default:
+		next := now.Add(webhookBackoff(attempts))
+		update.NextAttemptAt = &next
+	}
+	update.Status = &status
+
+	recordFunc := func(tx *sql.Tx) error {
+		err := s.repos.WebhookDelivery.CreateAttempt(tx, attempt)
+		if err != nil {
+			return err
+		}
+		return s.repos.WebhookDelivery.Update(tx, delivery.DeliveryID, update)
+	}
+
+	err := s.tm.ExecuteInTx(ctx, recordFunc)
+	if err != nil {
+		return false, fmt.Errorf("record webhook delivery %d: %w", delivery.DeliveryID, err)
+	}
+	if !ok {
+		slog.Warn("webhook delivery failed", slog.Int64("id", delivery.DeliveryID), slog.Int("attempts", attempts), slog.String("err", attempt.Error))
+	}
+	return ok, nil
+}
+
+// webhookBackoff is the wait before the attempt after the given number of
+// failed ones.
+func webhookBackoff(attempts int) time.Duration {
+	backoff := webhookFirstRetry
+	for i := 1; i < attempts; i++ {
+		backoff *= 2
+		if backoff >= webhookMaxRetry {
+			return webhookMaxRetry
+		}
+	}
+	return backoff
+}
+
+// WebhookWorker sends the webhook outbox in the background.
+type WebhookWorker struct {
+	service  planetscale.WebhookService
+	interval time.Duration
+
+	// Returns the current time. Defaults to time.Now().
+	// Can be mocked for tests.
+	Now func() time.Time
+
+	cancel func()
+	done   chan struct{}
+}
+
+func NewWebhookWorker(service planetscale.WebhookService, interval time.Duration) *WebhookWorker {
+	return &WebhookWorker{
+		service:  service,
+		interval: interval,
+		Now:      time.Now,
+	}
+}
+
+// Open starts the worker. Deliveries that were due while the process was down
+// are sent right away.
+func (w *WebhookWorker) Open() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	w.cancel = cancel
+	w.done = make(chan struct{})
+
+	go func() {
+		defer close(w.done)
+
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+		for {
+			w.run(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Close stops the worker and waits for a run in progress to finish.
+func (w *WebhookWorker) Close() error {
+	if w.cancel == nil {
+		return nil
+	}
+	w.cancel()
+	<-w.done
+	return nil
+}
+
+func (w *WebhookWorker) run(ctx context.Context) {
+	delivered, err := w.service.DeliverDue(ctx, w.Now().UTC().Truncate(time.Second))
+	if err != nil {
+		slog.Error("[webhooks] delivery", slog.Any("err", err))
+	}
+	if delivered > 0 {
+		slog.Info("[webhooks] delivered", slog.Int("count", delivered))
+	}
+}
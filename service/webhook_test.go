@@ -0,0 +1,202 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	planetscale "github.com/harshav17/planet_scale"
+	db_mock "github.com/harshav17/planet_scale/mock/db"
+	service_mock "github.com/harshav17/planet_scale/mock/service"
+	svix "github.com/svix/svix-webhooks/go"
+)
+
+const testWebhookSecret = "whsec_MfKQ9r8GKYqrTwjUPD8ILPZIo2LaLaSw"
+
+func TestWebhookService_DeliverDue(t *testing.T) {
+	groupID := int64(1)
+	newDelivery := func(url string, attempts int) *planetscale.WebhookDelivery {
+		return &planetscale.WebhookDelivery{
+			DeliveryID:     1,
+			EventID:        7,
+			SubscriptionID: 3,
+			Status:         planetscale.WebhookDeliveryPending,
+			Attempts:       attempts,
+			Event: &planetscale.WebhookEvent{
+				EventID: 7,
+				GroupID: &groupID,
+				Type:    "expense.created",
+				ActorID: "test-user-id",
+				Data:    json.RawMessage(`{"expense_id":1}`),
+			},
+			Subscription: &planetscale.WebhookSubscription{
+				SubscriptionID: 3,
+				URL:            url,
+				Secret:         testWebhookSecret,
+			},
+		}
+	}
+
+	type recorded struct {
+		attempts []*planetscale.WebhookDeliveryAttempt
+		updates  []*planetscale.WebhookDeliveryUpdate
+	}
+	newService := func(delivery *planetscale.WebhookDelivery, rec *recorded) *webhookService {
+		tm := db_mock.TransactionManager{}
+		tm.ExecuteInTxFn = func(ctx context.Context, fn func(*sql.Tx) error) error {
+			return fn(nil)
+		}
+		webhookService := NewWebhookService(&planetscale.RepoProvider{}, tm)
+		webhookService.repos.WebhookDelivery = &db_mock.WebhookDeliveryRepo{
+			FindFn: func(tx *sql.Tx, filter planetscale.WebhookDeliveryFilter) ([]*planetscale.WebhookDelivery, error) {
+				return []*planetscale.WebhookDelivery{delivery}, nil
+			},
+			UpdateFn: func(tx *sql.Tx, deliveryID int64, update *planetscale.WebhookDeliveryUpdate) error {
+				rec.updates = append(rec.updates, update)
+				return nil
+			},
+			CreateAttemptFn: func(tx *sql.Tx, attempt *planetscale.WebhookDeliveryAttempt) error {
+				rec.attempts = append(rec.attempts, attempt)
+				return nil
+			},
+		}
+		return webhookService
+	}
+
+	t.Run("signed delivery", func(t *testing.T) {
+		wh, err := svix.NewWebhook(testWebhookSecret)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got planetscale.WebhookEvent
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			payload, err := io.ReadAll(r.Body)
+			if err != nil {
+				t.Error(err)
+			}
+			if err := wh.Verify(payload, r.Header); err != nil {
+				t.Errorf("expected a valid signature, got %v", err)
+			}
+			if err := json.Unmarshal(payload, &got); err != nil {
+				t.Error(err)
+			}
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer server.Close()
+
+		var rec recorded
+		now := time.Now().UTC().Truncate(time.Second)
+		delivered, err := newService(newDelivery(server.URL, 0), &rec).DeliverDue(context.Background(), now)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if delivered != 1 {
+			t.Fatalf("expected 1 delivery, got %d", delivered)
+		} else if got.Type != "expense.created" || got.EventID != 7 {
+			t.Fatalf("expected event 7 expense.created, got %d %s", got.EventID, got.Type)
+		} else if len(rec.attempts) != 1 || *rec.attempts[0].StatusCode != http.StatusNoContent {
+			t.Fatalf("expected 1 attempt with status 204, got %v", rec.attempts)
+		}
+		last := rec.updates[len(rec.updates)-1]
+		if *last.Status != planetscale.WebhookDeliveryDelivered || *last.Attempts != 1 {
+			t.Fatalf("expected delivered after 1 attempt, got %s after %d", *last.Status, *last.Attempts)
+		}
+	})
+
+	t.Run("retried with backoff", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer server.Close()
+
+		var rec recorded
+		now := time.Now().UTC().Truncate(time.Second)
+		delivered, err := newService(newDelivery(server.URL, 2), &rec).DeliverDue(context.Background(), now)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		last := rec.updates[len(rec.updates)-1]
+		if delivered != 0 {
+			t.Fatalf("expected no delivery, got %d", delivered)
+		} else if rec.attempts[0].Error == "" {
+			t.Fatal("expected the attempt to record the error")
+		} else if *last.Status != planetscale.WebhookDeliveryPending {
+			t.Fatalf("expected delivery to stay pending, got %s", *last.Status)
+		} else if want := now.Add(2 * time.Minute); !last.NextAttemptAt.Equal(want) {
+			t.Fatalf("expected next attempt at %v, got %v", want, *last.NextAttemptAt)
+		}
+	})
+
+	t.Run("gives up", func(t *testing.T) {
+		var rec recorded
+		now := time.Now().UTC().Truncate(time.Second)
+		// nothing listens on this port
+		delivery := newDelivery("http://127.0.0.1:1", planetscale.WebhookMaxAttempts-1)
+		_, err := newService(delivery, &rec).DeliverDue(context.Background(), now)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		last := rec.updates[len(rec.updates)-1]
+		if *last.Status != planetscale.WebhookDeliveryFailed {
+			t.Fatalf("expected delivery to fail, got %s", *last.Status)
+		} else if rec.attempts[0].StatusCode != nil {
+			t.Fatalf("expected no status code, got %d", *rec.attempts[0].StatusCode)
+		}
+	})
+}
+
+func TestWebhookBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		expected time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{4, 4 * time.Minute},
+		{20, 6 * time.Hour},
+	}
+
+	for _, test := range tests {
+		if got := webhookBackoff(test.attempts); got != test.expected {
+			t.Errorf("after %d attempts: expected %v, got %v", test.attempts, test.expected, got)
+		}
+	}
+}
+
+func TestWebhookWorker(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	runs := make(chan time.Time, 1)
+	worker := NewWebhookWorker(&service_mock.WebhookService{
+		DeliverDueFn: func(now time.Time) (int, error) {
+			select {
+			case runs <- now:
+			default:
+			}
+			return 0, nil
+		},
+	}, time.Hour)
+	worker.Now = func() time.Time { return now }
+
+	if err := worker.Open(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case got := <-runs:
+		if !got.Equal(now) {
+			t.Errorf("expected run at %v, got %v", now, got)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the worker to run on open")
+	}
+	if err := worker.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -12,14 +12,17 @@ type (
 		GroupID      int64     `json:"group_id"`
 		PaidBy       string    `json:"paid_by"`
 		PaidTo       string    `json:"paid_to"`
-		Amount       float64   `json:"amount"`
+		Amount       Money     `json:"amount"`
+		Currency     string    `json:"currency"`
 		Timestamp    time.Time `json:"timestamp"`
+		DeletedAt    time.Time `json:"deleted_at"`
 	}
 
 	SettlementRepo interface {
 		Get(tx *sql.Tx, settlementID int64) (*Settlement, error)
 		Create(tx *sql.Tx, settlement *Settlement) error
 		Delete(tx *sql.Tx, settlementID int64) error
+		Restore(tx *sql.Tx, settlementID int64) error
 		Update(tx *sql.Tx, settlementID int64, settlement *SettlementUpdate) (*Settlement, error)
 		Find(tx *sql.Tx, filter SettlementFilter) ([]*Settlement, error)
 	}
@@ -30,7 +33,18 @@ type (
 	}
 
 	SettlementFilter struct {
-		GroupID int64
+		GroupID   int64
+		GroupIDs  []int64 // any of these groups
+		Deleted   bool    // list the trash instead of live settlements
+		PaidBy    []string
+		From      *time.Time // inclusive
+		To        *time.Time // exclusive
+		MinAmount *Money
+		MaxAmount *Money
+
+		Sort   string // see SortTimestamp and SortAmount
+		Cursor string // from SettlementCursor, continues after that settlement
+		Limit  int    // 0 for no limit
 	}
 
 	SettlementController interface {
@@ -39,5 +53,6 @@ type (
 		HandleDeleteSettlement(w http.ResponseWriter, r *http.Request)
 		HandlePatchSettlement(w http.ResponseWriter, r *http.Request)
 		HandleGetGroupSettlements(w http.ResponseWriter, r *http.Request)
+		HandleRestoreSettlement(w http.ResponseWriter, r *http.Request)
 	}
 )
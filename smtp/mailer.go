@@ -0,0 +1,127 @@
+// Package smtp sends emails through an SMTP server.
+package smtp
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strings"
+	"time"
+
+	planetscale "github.com/harshav17/planet_scale"
+)
+
+// dialTimeout bounds how long connecting to the server may take when the
+// context has no deadline.
+const dialTimeout = 10 * time.Second
+
+type mailer struct {
+	addr string
+	from string
+	auth smtp.Auth
+
+	// Returns the current time. Defaults to time.Now().
+	// Can be mocked for tests.
+	Now func() time.Time
+}
+
+// NewMailer sends emails from the from address through the server at addr,
+// a host:port. Without a username no authentication is used, otherwise PLAIN,
+// which net/smtp only allows over TLS or to localhost.
+func NewMailer(addr string, from string, username string, password string) *mailer {
+	m := &mailer{
+		addr: addr,
+		from: from,
+		Now:  time.Now,
+	}
+	if username != "" {
+		host, _, _ := net.SplitHostPort(addr)
+		m.auth = smtp.PlainAuth("", username, password, host)
+	}
+	return m
+}
+
+// Send delivers the email in a single session. The connection is upgraded to
+// TLS when the server supports it.
+func (m *mailer) Send(ctx context.Context, email *planetscale.Email) error {
+	to, err := mail.ParseAddress(email.To)
+	if err != nil {
+		return planetscale.Errorf(planetscale.EINVALID, "invalid recipient %q", email.To)
+	}
+	from, err := mail.ParseAddress(m.from)
+	if err != nil {
+		return planetscale.Errorf(planetscale.EINVALID, "invalid sender %q", m.from)
+	}
+	msg := m.message(from, to, email)
+
+	host, _, err := net.SplitHostPort(m.addr)
+	if err != nil {
+		return err
+	}
+	dialer := &net.Dialer{Timeout: dialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", m.addr)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if m.auth != nil {
+		if err := c.Auth(m.auth); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(from.Address); err != nil {
+		return err
+	}
+	if err := c.Rcpt(to.Address); err != nil {
+		return err
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// message formats a plain text email. Addresses are re-encoded from their
+// parsed form and the subject is Q-encoded, so neither can inject headers.
+func (m *mailer) message(from *mail.Address, to *mail.Address, email *planetscale.Email) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", from.String())
+	fmt.Fprintf(&b, "To: %s\r\n", to.String())
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", email.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", m.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	b.WriteString("\r\n")
+
+	text := strings.ReplaceAll(email.Text, "\r\n", "\n")
+	b.WriteString(strings.ReplaceAll(text, "\n", "\r\n"))
+	return b.Bytes()
+}
@@ -0,0 +1,157 @@
+package smtp
+
+import (
+	"context"
+	"io"
+	"mime"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	planetscale "github.com/harshav17/planet_scale"
+)
+
+// sink is a local SMTP server that accepts every message and keeps it.
+type sink struct {
+	ln       net.Listener
+	messages chan sinkMessage
+}
+
+type sinkMessage struct {
+	From string
+	To   []string
+	Data string
+}
+
+func MustOpenSink(tb testing.TB) *sink {
+	tb.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		tb.Fatal(err)
+	}
+	s := &sink{ln: ln, messages: make(chan sinkMessage, 10)}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *sink) Close() error {
+	return s.ln.Close()
+}
+
+func (s *sink) serve(conn net.Conn) {
+	defer conn.Close()
+	c := textproto.NewConn(conn)
+	c.PrintfLine("220 localhost ESMTP sink")
+
+	var msg sinkMessage
+	for {
+		line, err := c.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			c.PrintfLine("250 localhost")
+		case "MAIL":
+			msg = sinkMessage{From: strings.Trim(strings.TrimPrefix(arg, "FROM:"), "<>")}
+			c.PrintfLine("250 OK")
+		case "RCPT":
+			msg.To = append(msg.To, strings.Trim(strings.TrimPrefix(arg, "TO:"), "<>"))
+			c.PrintfLine("250 OK")
+		case "DATA":
+			c.PrintfLine("354 go ahead")
+			data, err := c.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			msg.Data = string(data)
+			s.messages <- msg
+			c.PrintfLine("250 OK")
+		case "QUIT":
+			c.PrintfLine("221 bye")
+			return
+		default:
+			c.PrintfLine("502 not implemented")
+		}
+	}
+}
+
+func TestMailer_Send(t *testing.T) {
+	s := MustOpenSink(t)
+	defer s.Close()
+
+	mailer := NewMailer(s.ln.Addr().String(), "Skwabbl <noreply@skwabbl.com>", "", "")
+	mailer.Now = func() time.Time { return time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC) }
+
+	t.Run("plain text", func(t *testing.T) {
+		err := mailer.Send(context.Background(), &planetscale.Email{
+			To:      "Bob <bob@example.com>",
+			Subject: "Alice added you to Café",
+			Text:    "Hi Bob,\nyou owe 20.00 USD.\n",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		msg := <-s.messages
+		if msg.From != "noreply@skwabbl.com" || len(msg.To) != 1 || msg.To[0] != "bob@example.com" {
+			t.Fatalf("unexpected envelope %+v", msg)
+		}
+		parsed, err := mail.ReadMessage(strings.NewReader(msg.Data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+		if err != nil {
+			t.Fatal(err)
+		} else if subject != "Alice added you to Café" {
+			t.Fatalf("unexpected subject %q", subject)
+		} else if got := parsed.Header.Get("Date"); got != "Mon, 01 Jan 2024 00:00:00 +0000" {
+			t.Fatalf("unexpected date %q", got)
+		}
+		body, err := io.ReadAll(parsed.Body)
+		if err != nil {
+			t.Fatal(err)
+		} else if string(body) != "Hi Bob,\nyou owe 20.00 USD.\n" {
+			t.Fatalf("unexpected body %q", body)
+		}
+	})
+
+	t.Run("header injection", func(t *testing.T) {
+		err := mailer.Send(context.Background(), &planetscale.Email{
+			To:      "bob@example.com",
+			Subject: "Hi\r\nBcc: eve@example.com",
+			Text:    "Hi",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		msg := <-s.messages
+		parsed, err := mail.ReadMessage(strings.NewReader(msg.Data))
+		if err != nil {
+			t.Fatal(err)
+		} else if got := parsed.Header.Get("Bcc"); got != "" {
+			t.Fatalf("expected no Bcc header, got %q", got)
+		}
+	})
+
+	t.Run("invalid recipient", func(t *testing.T) {
+		err := mailer.Send(context.Background(), &planetscale.Email{To: "bob", Subject: "Hi", Text: "Hi"})
+		if planetscale.ErrorCode(err) != planetscale.EINVALID {
+			t.Fatalf("expected %s, got %v", planetscale.EINVALID, err)
+		}
+	})
+}
@@ -6,6 +6,15 @@ import (
 	"time"
 )
 
+// Split type identifiers as seeded by the split_types migration.
+const (
+	SplitTypeEqual int64 = iota + 1
+	SplitTypeUnequal
+	SplitTypeItemBased
+	SplitTypeShareBased
+	SplitTypePercentageBased
+)
+
 type (
 	SplitType struct {
 		SplitTypeID int64     `json:"split_type_id"`
@@ -1,6 +1,6 @@
 /** @type {import('tailwindcss').Config} */
 module.exports = {
-    content: ["./http/templates/*.{html,js}"],
+    content: ["./templates/*.{html,js}"],
     theme: {
       extend: {},
     },
@@ -0,0 +1,11 @@
+package templates
+
+import (
+	"embed"
+)
+
+// Templates holds the HTML pages served by the http package and the emails
+// sent by the notifier, the latter named email-<name>.txt.
+//
+//go:embed *.html *.txt
+var Templates embed.FS
@@ -6,21 +6,35 @@ import (
 	"time"
 )
 
+// DeletedUserName replaces the name of users who deleted their account. The
+// user itself is kept so that the expenses and settlements they were part of
+// still add up.
+const DeletedUserName = "Deleted user"
+
 type (
 	User struct {
 		UserID    string    `json:"user_id"`
 		Email     string    `json:"email"`
 		Name      string    `json:"name"`
+		ImageURL  string    `json:"image_url"`
 		CreatedAt time.Time `json:"created_at"`
+		DeletedAt time.Time `json:"deleted_at"`
 	}
 
 	UserRepo interface {
 		Get(tx *sql.Tx, userID string) (*User, error)
 		Create(tx *sql.Tx, user *User) error
+		// Upsert leaves deleted users as they are.
 		Upsert(tx *sql.Tx, user *User) error
+		// Delete anonymizes the user.
+		Delete(tx *sql.Tx, userID string) error
 	}
 
 	UserController interface {
 		HandlePutUser(w http.ResponseWriter, r *http.Request)
+		HandleGetUserBalance(w http.ResponseWriter, r *http.Request)
+		HandleGetDashboard(w http.ResponseWriter, r *http.Request)
+		HandleGetNotificationPreferences(w http.ResponseWriter, r *http.Request)
+		HandlePatchNotificationPreferences(w http.ResponseWriter, r *http.Request)
 	}
 )
@@ -0,0 +1,166 @@
+package planetscale
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"time"
+)
+
+// Statuses of a webhook delivery.
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliveryDelivered = "delivered"
+	// WebhookDeliveryFailed is final, the delivery is not retried anymore.
+	WebhookDeliveryFailed = "failed"
+)
+
+// WebhookMaxAttempts is how often a delivery is tried before it fails.
+const WebhookMaxAttempts = 8
+
+// webhookActions names audited actions the way webhook event types do.
+var webhookActions = map[string]string{
+	AuditActionCreate:  "created",
+	AuditActionUpdate:  "updated",
+	AuditActionDelete:  "deleted",
+	AuditActionRestore: "restored",
+}
+
+type (
+	// WebhookSubscription sends the events of a group to URL. Subscriptions
+	// without a group get the events of every group UserID is a member of,
+	// and those of the direct expenses they are involved in.
+	WebhookSubscription struct {
+		SubscriptionID int64     `json:"subscription_id"`
+		GroupID        *int64    `json:"group_id"`
+		UserID         string    `json:"user_id"`
+		URL            string    `json:"url"`
+		CreatedAt      time.Time `json:"created_at"`
+
+		// Secret signs the payloads. It is only returned when the
+		// subscription is created and cannot be recovered afterwards.
+		Secret string `json:"secret,omitempty"`
+	}
+
+	// WebhookSubscriptionRepo never loads secrets, deliveries do.
+	WebhookSubscriptionRepo interface {
+		Get(tx *sql.Tx, subscriptionID int64) (*WebhookSubscription, error)
+		Create(tx *sql.Tx, subscription *WebhookSubscription) error
+		Delete(tx *sql.Tx, subscriptionID int64) error
+		Find(tx *sql.Tx, filter WebhookSubscriptionFilter) ([]*WebhookSubscription, error)
+	}
+
+	WebhookSubscriptionFilter struct {
+		GroupID int64
+		UserID  string
+		// UserOnly finds the subscriptions of UserID that are not tied to a
+		// group.
+		UserOnly bool
+	}
+
+	// WebhookEvent is an entry of the outbox. It is written in the same
+	// transaction as the change it describes so that no event is sent for a
+	// change that was rolled back, nor lost for one that was committed.
+	WebhookEvent struct {
+		EventID   int64       `json:"event_id"`
+		GroupID   *int64      `json:"group_id"`
+		Type      string      `json:"type"`
+		ActorID   string      `json:"actor_id"`
+		Data      interface{} `json:"data"`
+		CreatedAt time.Time   `json:"created_at"`
+
+		// ExpenseID is the expense the event is about, if any. Events
+		// without a group go to the people involved in it.
+		ExpenseID *int64 `json:"-"`
+	}
+
+	WebhookEventRepo interface {
+		// Create adds the event to the outbox and queues a delivery for
+		// every subscription that receives it.
+		Create(tx *sql.Tx, event *WebhookEvent) error
+	}
+
+	WebhookDelivery struct {
+		DeliveryID     int64     `json:"delivery_id"`
+		EventID        int64     `json:"event_id"`
+		SubscriptionID int64     `json:"subscription_id"`
+		Status         string    `json:"status"`
+		Attempts       int       `json:"attempts"`
+		NextAttemptAt  time.Time `json:"next_attempt_at"`
+		DeliveredAt    time.Time `json:"delivered_at"`
+
+		Event *WebhookEvent `json:"event,omitempty"`
+		// Subscription carries the secret, it is only set for the worker.
+		Subscription *WebhookSubscription      `json:"-"`
+		AttemptLog   []*WebhookDeliveryAttempt `json:"attempt_log,omitempty"`
+	}
+
+	WebhookDeliveryRepo interface {
+		Update(tx *sql.Tx, deliveryID int64, update *WebhookDeliveryUpdate) error
+		Find(tx *sql.Tx, filter WebhookDeliveryFilter) ([]*WebhookDelivery, error)
+		CreateAttempt(tx *sql.Tx, attempt *WebhookDeliveryAttempt) error
+		FindAttempts(tx *sql.Tx, deliveryID int64) ([]*WebhookDeliveryAttempt, error)
+	}
+
+	WebhookDeliveryUpdate struct {
+		Status        *string
+		Attempts      *int
+		NextAttemptAt *time.Time
+		DeliveredAt   *time.Time
+	}
+
+	WebhookDeliveryFilter struct {
+		SubscriptionID int64
+		// DueAt finds pending deliveries whose next attempt is at or before
+		// it, along with their event and subscription.
+		DueAt time.Time
+		Limit int
+	}
+
+	// WebhookDeliveryAttempt records a single try of a delivery.
+	WebhookDeliveryAttempt struct {
+		AttemptID   int64     `json:"attempt_id"`
+		DeliveryID  int64     `json:"delivery_id"`
+		StatusCode  *int      `json:"status_code"`
+		Error       string    `json:"error"`
+		Duration    int64     `json:"duration_ms"`
+		AttemptedAt time.Time `json:"attempted_at"`
+	}
+
+	WebhookService interface {
+		// DeliverDue sends every delivery due at now and returns how many
+		// were delivered.
+		DeliverDue(ctx context.Context, now time.Time) (int, error)
+	}
+
+	WebhookController interface {
+		HandleGetGroupWebhooks(w http.ResponseWriter, r *http.Request)
+		HandlePostGroupWebhook(w http.ResponseWriter, r *http.Request)
+		HandleGetUserWebhooks(w http.ResponseWriter, r *http.Request)
+		HandlePostUserWebhook(w http.ResponseWriter, r *http.Request)
+		HandleDeleteWebhook(w http.ResponseWriter, r *http.Request)
+		HandleGetWebhookDeliveries(w http.ResponseWriter, r *http.Request)
+	}
+)
+
+// NewWebhookEvent turns an audited change into the event sent to webhook
+// subscribers, e.g. "expense.created" carrying the created expense.
+func NewWebhookEvent(audit *AuditEvent) *WebhookEvent {
+	data := audit.After
+	if data == nil {
+		data = audit.Before
+	}
+	event := &WebhookEvent{
+		GroupID: audit.GroupID,
+		Type:    audit.EntityType + "." + webhookActions[audit.Action],
+		ActorID: audit.ActorID,
+		Data:    data,
+	}
+	switch data := data.(type) {
+	case *Expense:
+		event.ExpenseID = &data.ExpenseID
+	case *Item:
+		event.ExpenseID = &data.ExpenseID
+	}
+	return event
+}
@@ -1,5 +1,18 @@
 package planetscale
 
+import (
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// Clerk user event types.
+const (
+	ClerkUserCreated = "user.created"
+	ClerkUserUpdated = "user.updated"
+	ClerkUserDeleted = "user.deleted"
+)
+
 type (
 	ClerkPayload[T any] struct {
 		Data   T      `json:"data"`
@@ -7,19 +20,60 @@ type (
 		Type   string `json:"type"`
 	}
 
+	ClerkEmailAddress struct {
+		EmailAddress string `json:"email_address"`
+		Id           string `json:"id"`
+		Object       string `json:"object"`
+		Verification struct {
+			Status string `json:"status"`
+		} `json:"verification"`
+	}
+
 	ClerkUserPayload struct {
-		EmailAddresses []struct {
-			EmailAddress string `json:"email_address"`
-			Id           string `json:"id"`
-			Object       string `json:"object"`
-			Verification struct {
-				Status string `json:"status"`
-			} `json:"verification"`
-		} `json:"email_addresses"`
-		FirstName       string `json:"first_name"`
-		Id              string `json:"id"`
-		LastName        string `json:"last_name"`
-		Object          string `json:"object"`
-		ProfileImageURL string `json:"profile_image_url"`
+		EmailAddresses        []ClerkEmailAddress `json:"email_addresses"`
+		PrimaryEmailAddressID string              `json:"primary_email_address_id"`
+		FirstName             string              `json:"first_name"`
+		Id                    string              `json:"id"`
+		LastName              string              `json:"last_name"`
+		Object                string              `json:"object"`
+		ProfileImageURL       string              `json:"profile_image_url"`
+		// Deleted is only set on user.deleted events, which carry nothing but
+		// the id otherwise.
+		Deleted bool `json:"deleted"`
+	}
+
+	// ClerkMessage records a webhook that was processed, so that svix
+	// redeliveries of the same message are skipped.
+	ClerkMessage struct {
+		MessageID   string
+		Type        string
+		ProcessedAt time.Time
+	}
+
+	ClerkMessageRepo interface {
+		// Create fails with ECONFLICT when the message was already processed.
+		Create(tx *sql.Tx, message *ClerkMessage) error
 	}
 )
+
+// PrimaryEmail returns the primary email address of the user, or an empty
+// string for users without one, such as those who signed up with a phone
+// number.
+func (p *ClerkUserPayload) PrimaryEmail() string {
+	for _, address := range p.EmailAddresses {
+		if address.Id == p.PrimaryEmailAddressID {
+			return address.EmailAddress
+		}
+	}
+	return ""
+}
+
+// User converts the payload to a user.
+func (p *ClerkUserPayload) User() *User {
+	return &User{
+		UserID:   p.Id,
+		Email:    p.PrimaryEmail(),
+		Name:     strings.TrimSpace(p.FirstName + " " + p.LastName),
+		ImageURL: p.ProfileImageURL,
+	}
+}
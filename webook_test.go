@@ -0,0 +1,31 @@
+package planetscale
+
+import "testing"
+
+func TestClerkUserPayload_PrimaryEmail(t *testing.T) {
+	tests := []struct {
+		name     string
+		payload  ClerkUserPayload
+		expected string
+	}{
+		{"no emails", ClerkUserPayload{PrimaryEmailAddressID: "idn_1"}, ""},
+		{"primary", ClerkUserPayload{
+			PrimaryEmailAddressID: "idn_2",
+			EmailAddresses: []ClerkEmailAddress{
+				{Id: "idn_1", EmailAddress: "first@example.com"},
+				{Id: "idn_2", EmailAddress: "primary@example.com"},
+			},
+		}, "primary@example.com"},
+		{"no primary", ClerkUserPayload{
+			EmailAddresses: []ClerkEmailAddress{{Id: "idn_1", EmailAddress: "first@example.com"}},
+		}, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.payload.PrimaryEmail(); got != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, got)
+			}
+		})
+	}
+}